@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// commandAlias makes a nested command reachable from the top level.
+type commandAlias struct {
+	// Name of the top-level command
+	Name string
+
+	// Path of the command it runs, below the root
+	Target []string
+
+	// The alias only applies when the top-level command of the same name is called
+	// without arguments; that command still handles the calls with arguments
+	WithoutArgs bool
+}
+
+// commandAliases are the shortcuts of the most common workflows.
+var commandAliases = []commandAlias{
+	{Name: "up", Target: []string{"compose", "up"}},
+	{Name: "down", Target: []string{"compose", "down"}},
+	{Name: "run", Target: []string{"compose", "up"}, WithoutArgs: true},
+}
+
+// registerAliases adds the aliases to root. It runs once every command is registered,
+// as the targets are looked up by path.
+func registerAliases(root *cobra.Command, aliases []commandAlias) error {
+	for _, alias := range aliases {
+		target, rest, err := root.Find(alias.Target)
+		if err != nil || target == root || len(rest) > 0 {
+			return fmt.Errorf("alias %s: command %s not found", alias.Name, strings.Join(alias.Target, " "))
+		}
+
+		if alias.WithoutArgs {
+			if err := extendCommand(root, alias.Name, target); err != nil {
+				return err
+			}
+			continue
+		}
+		root.AddCommand(aliasCommand(alias.Name, target))
+	}
+	return nil
+}
+
+// aliasCommand returns a copy of target named name, sharing its flags, its annotations,
+// such as the flags switching it to plain output, and its run hooks.
+func aliasCommand(name string, target *cobra.Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           strings.Replace(target.Use, target.Name(), name, 1),
+		Short:         fmt.Sprintf("%s (alias of %s)", target.Short, target.CommandPath()),
+		Long:          target.Long,
+		Example:       target.Example,
+		Annotations:   maps.Clone(target.Annotations),
+		Args:          target.Args,
+		PreRun:        target.PreRun,
+		PreRunE:       target.PreRunE,
+		Run:           target.Run,
+		RunE:          target.RunE,
+		PostRun:       target.PostRun,
+		PostRunE:      target.PostRunE,
+		SilenceErrors: target.SilenceErrors,
+		SilenceUsage:  target.SilenceUsage,
+	}
+	cmd.Flags().AddFlagSet(target.Flags())
+	return cmd
+}
+
+// extendCommand makes the top-level command name run target, with its default flags,
+// when it is called without arguments.
+func extendCommand(root *cobra.Command, name string, target *cobra.Command) error {
+	var cmd *cobra.Command
+	for _, c := range root.Commands() {
+		if c.Name() == name {
+			cmd = c
+			break
+		}
+	}
+	if cmd == nil || cmd.RunE == nil || target.RunE == nil {
+		return fmt.Errorf("alias %s: no command to extend", name)
+	}
+
+	args, runE := cmd.Args, cmd.RunE
+	cmd.Args = func(c *cobra.Command, a []string) error {
+		if len(a) == 0 || args == nil {
+			return nil
+		}
+		return args(c, a)
+	}
+	cmd.RunE = func(c *cobra.Command, a []string) error {
+		if len(a) == 0 {
+			return target.RunE(target, a)
+		}
+		return runE(c, a)
+	}
+	cmd.Long += fmt.Sprintf("\n\nWithout arguments, %s runs %s.", cmd.CommandPath(), target.CommandPath())
+	return nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasCommandKeepsTarget(t *testing.T) {
+	var ran []string
+	target := &cobra.Command{
+		Use:         "up",
+		Annotations: map[string]string{ui.PlainOutputAnnotation: "json"},
+		PreRunE: func(*cobra.Command, []string) error {
+			ran = append(ran, "pre")
+			return nil
+		},
+		RunE: func(*cobra.Command, []string) error {
+			ran = append(ran, "run")
+			return nil
+		},
+	}
+	target.Flags().Bool("json", false, "")
+	compose := &cobra.Command{Use: "compose"}
+	compose.AddCommand(target)
+	root := &cobra.Command{Use: "ignition"}
+	root.AddCommand(compose)
+
+	require.NoError(t, registerAliases(root, []commandAlias{{Name: "up", Target: []string{"compose", "up"}}}))
+	alias, _, err := root.Find([]string{"up"})
+	require.NoError(t, err)
+	require.NotSame(t, target, alias)
+
+	// The alias hides the logo for the same flags as its target
+	assert.Equal(t, "json", alias.Annotations[ui.PlainOutputAnnotation])
+	assert.NotNil(t, alias.Flags().Lookup("json"))
+
+	root.SetArgs([]string{"up", "--json"})
+	require.NoError(t, root.Execute())
+	assert.Equal(t, []string{"pre", "run"}, ran)
+}
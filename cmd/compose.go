@@ -18,8 +18,12 @@ func init() {
 	// Add compose subcommands
 	ComposeCmd.AddCommand(compose.NewComposeUpCommand(Container))
 	ComposeCmd.AddCommand(compose.NewComposeDownCommand(Container))
+	ComposeCmd.AddCommand(compose.NewComposeApplyCommand(Container))
+	ComposeCmd.AddCommand(compose.NewComposeDiffCommand(Container))
 	ComposeCmd.AddCommand(compose.NewComposeInitCommand(Container))
 	ComposeCmd.AddCommand(compose.NewComposeLogsCommand(Container))
+	ComposeCmd.AddCommand(compose.NewComposeScaleCommand(Container))
+	ComposeCmd.AddCommand(compose.NewComposeEventsCommand(Container))
 
 	rootCmd.AddCommand(ComposeCmd)
 }
@@ -0,0 +1,162 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/di"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/api"
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
+	ignitionErrors "github.com/ignitionstack/ignition/pkg/errors"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// NewComposeApplyCommand creates a new cobra command for compose apply.
+func NewComposeApplyCommand(container *di.Container) *cobra.Command {
+	var filePath string
+	var projectName string
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:         "apply",
+		Annotations: map[string]string{ui.PlainOutputAnnotation: "json"},
+		Short:       "Converge the engine to a compose file",
+		Long: `Send a compose file to the engine as the desired state of the services and pipelines
+of its project.
+
+The engine loads new services, reloads services whose version, config or scale changed,
+stops the services of the project missing from the file and registers or removes its
+pipelines, then reports every change. Services and pipelines of other projects, or started
+without compose apply, are left alone. The project is the name set in the file, or else
+the name of the directory holding it. Applying requires a privileged caller.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			composeManifest, err := manifest.ParseComposeFile(filePath)
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Failed to parse compose file: %v", err))
+				return err
+			}
+			composeManifest.QualifyFunctions(globalConfig.DefaultNamespace())
+
+			req, err := applyRequest(composeManifest, projectName)
+			if err != nil {
+				ui.PrintError(err.Error())
+				return err
+			}
+
+			client, err := container.Get("engineClient")
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Error getting engine client: %v", err))
+				return err
+			}
+			engineClient, ok := client.(*engineclient.EngineClient)
+			if !ok {
+				ui.PrintError("Invalid engine client type")
+				return errors.New("invalid engine client type")
+			}
+
+			resp, err := engineClient.Apply(context.Background(), req)
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Failed to apply compose file: %v", err))
+				if isConnectionError(err) {
+					return ignitionErrors.WithExitCode(err, ExitCodeEngineUnreachable)
+				}
+				return ignitionErrors.WithExitCode(err, ExitCodeTotalFailure)
+			}
+
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(resp); err != nil {
+					return err
+				}
+			} else {
+				printApplyReport(resp)
+			}
+			return applyErr(resp)
+		},
+	}
+
+	cmd.Flags().StringVarP(&filePath, "file", "f", "", "Specify an alternate compose file (default: ignition-compose.yml)")
+	cmd.Flags().StringVarP(&projectName, "project-name", "p", "", "Project owning the services (default: the name in the compose file, or its directory)")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the change report as JSON")
+	return cmd
+}
+
+// applyRequest converts a compose file into the spec the engine converges the services
+// of a project to. An empty project name uses the name of the compose file.
+func applyRequest(composeManifest *manifest.ComposeManifest, projectName string) (api.ApplyRequest, error) {
+	if projectName == "" {
+		projectName = composeManifest.Name
+	}
+	req := api.ApplyRequest{
+		Project:  projectName,
+		Services: make(map[string]api.ApplyService, len(composeManifest.Services)),
+	}
+	for name, service := range composeManifest.Services {
+		req.Services[name] = api.ApplyService{
+			Function:  service.Function,
+			Source:    service.Source,
+			Digest:    service.Digest,
+			Config:    service.ServiceConfig(),
+			DependsOn: service.DependsOn,
+			Scale:     service.Scale,
+		}
+	}
+
+	for name, pipeline := range composeManifest.Pipelines {
+		steps, err := pipelineSteps(pipeline)
+		if err != nil {
+			return api.ApplyRequest{}, fmt.Errorf("invalid pipeline '%s': %w", name, err)
+		}
+		if req.Pipelines == nil {
+			req.Pipelines = make(map[string][]api.PipelineStep, len(composeManifest.Pipelines))
+		}
+		req.Pipelines[name] = steps
+	}
+	return req, nil
+}
+
+// printApplyReport prints a table of the changes of an apply and their counts.
+func printApplyReport(resp *types.ApplyResponse) {
+	table := ui.NewTable([]string{"KIND", "NAME", "ACTION", "DETAILS"})
+	for _, change := range resp.Changes {
+		details := strings.Join(change.Reasons, ", ")
+		if change.Error != "" {
+			details = change.Error
+		}
+		table.AddRow(change.Kind, change.Name, ui.StyleStatusValue(change.Action), details)
+	}
+	fmt.Println(ui.RenderTable(table))
+
+	ui.PrintInfo("Changes", fmt.Sprintf("%d created, %d updated, %d unchanged, %d removed, %d failed, %d skipped",
+		resp.Created, resp.Updated, resp.Unchanged, resp.Removed, resp.Failed, resp.Skipped))
+}
+
+// applyErr returns an error carrying the compose up exit codes when some changes failed.
+func applyErr(resp *types.ApplyResponse) error {
+	var errs []string
+	for _, change := range resp.Changes {
+		if change.Action == types.ApplyFailed || change.Action == types.ApplySkipped {
+			errs = append(errs, fmt.Sprintf("%s '%s': %s", change.Kind, change.Name, change.Error))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	code := ExitCodePartialFailure
+	if len(errs) == len(resp.Changes) {
+		code = ExitCodeTotalFailure
+	}
+	return ignitionErrors.WithExitCode(fmt.Errorf("failed to apply some changes:\n%s", strings.Join(errs, "\n")), code)
+}
@@ -0,0 +1,196 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/di"
+	"github.com/ignitionstack/ignition/internal/ui"
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
+	ignitionErrors "github.com/ignitionstack/ignition/pkg/errors"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// ExitCodeDrift is returned by compose diff --exit-code when the engine differs from the file
+const ExitCodeDrift = 1
+
+// NewComposeDiffCommand creates a new cobra command for compose diff.
+func NewComposeDiffCommand(container *di.Container) *cobra.Command {
+	var filePath string
+	var projectName string
+	var jsonOutput bool
+	var showAll bool
+	var exitCode bool
+
+	cmd := &cobra.Command{
+		Use:         "diff",
+		Annotations: map[string]string{ui.PlainOutputAnnotation: "json"},
+		Short:       "Show how the engine differs from a compose file",
+		Long: `Compare a compose file with the state of the engine and print the changes compose apply
+would make: services of the project to load or remove, loaded digests that differ from
+the tag or range in the file, config keys that changed, stopped functions, scales and
+pipelines.
+
+The engine resolves versions from its registry without loading anything, so diff is safe
+to run against a live engine to spot manual changes or stale deployments.`,
+		Example: `  # Show the drift of the services of ignition-compose.yml
+  ignition compose diff
+
+  # Fail a CI job when the engine does not match the file
+  ignition compose diff --exit-code`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			composeManifest, err := manifest.ParseComposeFile(filePath)
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Failed to parse compose file: %v", err))
+				return err
+			}
+			composeManifest.QualifyFunctions(globalConfig.DefaultNamespace())
+
+			req, err := applyRequest(composeManifest, projectName)
+			if err != nil {
+				ui.PrintError(err.Error())
+				return err
+			}
+
+			client, err := container.Get("engineClient")
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Error getting engine client: %v", err))
+				return err
+			}
+			engineClient, ok := client.(*engineclient.EngineClient)
+			if !ok {
+				ui.PrintError("Invalid engine client type")
+				return errors.New("invalid engine client type")
+			}
+
+			resp, err := engineClient.PlanApply(context.Background(), req)
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Failed to compare with the engine: %v", err))
+				if isConnectionError(err) {
+					return ignitionErrors.WithExitCode(err, ExitCodeEngineUnreachable)
+				}
+				return err
+			}
+
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(resp); err != nil {
+					return err
+				}
+			} else {
+				printDiff(resp, showAll)
+			}
+
+			if exitCode && hasDrift(resp) {
+				return ignitionErrors.WithExitCode(errors.New("the engine differs from the compose file"), ExitCodeDrift)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&filePath, "file", "f", "", "Specify an alternate compose file (default: ignition-compose.yml)")
+	cmd.Flags().StringVarP(&projectName, "project-name", "p", "", "Project owning the services (default: the name in the compose file, or its directory)")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the diff as JSON")
+	cmd.Flags().BoolVar(&showAll, "all", false, "Also list services and pipelines that match the file")
+	cmd.Flags().BoolVar(&exitCode, "exit-code", false, "Exit with 1 when the engine differs from the file")
+	return cmd
+}
+
+// hasDrift reports whether applying the file would change anything.
+func hasDrift(resp *types.ApplyResponse) bool {
+	return resp.Unchanged != len(resp.Changes)
+}
+
+// printDiff prints one line per change, prefixed like a diff: + to create, ~ to update,
+// - to remove and ! for services that cannot be loaded.
+func printDiff(resp *types.ApplyResponse, showAll bool) {
+	if !hasDrift(resp) {
+		ui.PrintSuccess(fmt.Sprintf("No drift: the engine matches the compose file (%d services and pipelines)", len(resp.Changes)))
+		return
+	}
+
+	for _, change := range resp.Changes {
+		if change.Action == types.ApplyUnchanged && !showAll {
+			continue
+		}
+		line := fmt.Sprintf("%s %s %s", diffMarker(change.Action), change.Kind, change.Name)
+		if change.Function != "" {
+			line += " (" + change.Function + ")"
+		}
+		if details := diffDetails(change); details != "" {
+			line += ": " + details
+		}
+		fmt.Println(diffStyle(change.Action).Render(line))
+	}
+
+	fmt.Println()
+	ui.PrintInfo("Drift", fmt.Sprintf("%d to create, %d to update, %d to remove, %d unchanged, %d cannot load",
+		resp.Created, resp.Updated, resp.Removed, resp.Unchanged, resp.Failed))
+}
+
+func diffMarker(action string) string {
+	switch action {
+	case types.ApplyCreated:
+		return "+"
+	case types.ApplyUpdated:
+		return "~"
+	case types.ApplyRemoved:
+		return "-"
+	case types.ApplyFailed:
+		return "!"
+	default:
+		return " "
+	}
+}
+
+func diffStyle(action string) lipgloss.Style {
+	switch action {
+	case types.ApplyCreated:
+		return ui.SuccessStyle
+	case types.ApplyUpdated:
+		return ui.WarningStyle
+	case types.ApplyRemoved, types.ApplyFailed:
+		return ui.ErrorStyle
+	default:
+		return ui.DimStyle
+	}
+}
+
+// diffDetails describes what differs for a change.
+func diffDetails(change types.ApplyChange) string {
+	if change.Error != "" {
+		return change.Error
+	}
+
+	var details []string
+	for _, reason := range change.Reasons {
+		switch reason {
+		case "digest":
+			details = append(details, fmt.Sprintf("digest %s -> %s",
+				registry.TruncateDigest(change.PreviousDigest, 12), registry.TruncateDigest(change.Digest, 12)))
+		case "config":
+			details = append(details, "config "+strings.Join(change.ConfigKeys, ", "))
+		case "loaded":
+			details = append(details, "function is not loaded")
+		case "stopped":
+			details = append(details, "function was stopped")
+		case "function":
+			details = append(details, "moves to another function")
+		default:
+			details = append(details, reason)
+		}
+	}
+	return strings.Join(details, "; ")
+}
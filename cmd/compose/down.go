@@ -7,10 +7,11 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
 	"github.com/ignitionstack/ignition/internal/di"
-	"github.com/ignitionstack/ignition/internal/services"
 	"github.com/ignitionstack/ignition/internal/ui"
 	"github.com/ignitionstack/ignition/internal/ui/models/spinner"
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
 	"github.com/ignitionstack/ignition/pkg/engine/models"
 	"github.com/ignitionstack/ignition/pkg/manifest"
 	"github.com/spf13/cobra"
@@ -34,6 +35,7 @@ func NewComposeDownCommand(container *di.Container) *cobra.Command {
 				ui.PrintError(fmt.Sprintf("Failed to parse compose file: %v", err))
 				return err
 			}
+			composeManifest.QualifyFunctions(globalConfig.DefaultNamespace())
 
 			// Get the engine client from the container
 			client, err := container.Get("engineClient")
@@ -41,7 +43,7 @@ func NewComposeDownCommand(container *di.Container) *cobra.Command {
 				ui.PrintError("Error getting engine client")
 				return fmt.Errorf("error getting engine client: %w", err)
 			}
-			engineClient, ok := client.(*services.EngineClient)
+			engineClient, ok := client.(*engineclient.EngineClient)
 			if !ok {
 				ui.PrintError("Invalid engine client type")
 				return errors.New("invalid engine client type")
@@ -89,6 +91,13 @@ func NewComposeDownCommand(container *di.Container) *cobra.Command {
 				return nil
 			}
 
+			// Remove pipelines first so they stop routing calls to the functions
+			for name := range composeManifest.Pipelines {
+				if err := engineClient.UnregisterPipeline(context.Background(), name); err != nil {
+					ui.PrintError(fmt.Sprintf("Failed to remove pipeline %s: %v", name, err))
+				}
+			}
+
 			// Create a spinner for the unloading process
 			spinnerModel := spinner.NewSpinnerModelWithMessage("Unloading functions...")
 			program := tea.NewProgram(spinnerModel)
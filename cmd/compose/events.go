@@ -0,0 +1,153 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/di"
+	"github.com/ignitionstack/ignition/internal/ui"
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+// NewComposeEventsCommand creates a command that prints lifecycle events of the compose file's functions as they happen.
+func NewComposeEventsCommand(container *di.Container) *cobra.Command {
+	var filePath string
+	var types []string
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:         "events [SERVICE...]",
+		Annotations: map[string]string{ui.PlainOutputAnnotation: "json"},
+		Short:       "Stream lifecycle events of services defined in a compose file",
+		Long: fmt.Sprintf(`Print lifecycle events of the functions behind the services of an ignition-compose.yml
+file as they happen, until interrupted.
+
+Event types: %s.`, strings.Join(events.Types, ", ")),
+		Example: `  # Every event of every service
+  ignition compose events
+
+  # Circuit breaker changes of the api service, as JSON lines
+  ignition compose events api --type circuit_opened --type circuit_closed --json`,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			composeManifest, err := manifest.ParseComposeFile(filePath)
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Failed to parse compose file: %v", err))
+				return err
+			}
+			composeManifest.QualifyFunctions(globalConfig.DefaultNamespace())
+
+			services, err := eventServices(composeManifest, args)
+			if err != nil {
+				ui.PrintError(err.Error())
+				return err
+			}
+
+			client, err := container.Get("engineClient")
+			if err != nil {
+				ui.PrintError("Failed to get engine client")
+				return fmt.Errorf("failed to get engine client: %w", err)
+			}
+			engineClient, ok := client.(*engineclient.EngineClient)
+			if !ok {
+				ui.PrintError("Invalid engine client type")
+				return errors.New("invalid engine client type")
+			}
+
+			filter := events.Filter{Types: types}
+			for key := range services {
+				filter.Functions = append(filter.Functions, key)
+			}
+			sort.Slice(filter.Functions, func(i, j int) bool {
+				return filter.Functions[i].String() < filter.Functions[j].String()
+			})
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			encoder := json.NewEncoder(os.Stdout)
+			err = engineClient.StreamEvents(ctx, filter, func(event events.Event) error {
+				key := interfaces.FunctionKey{Namespace: event.Namespace, Name: event.Function}
+				if jsonOutput {
+					return encoder.Encode(event)
+				}
+				printEvent(strings.Join(services[key], ","), event)
+				return nil
+			})
+			switch {
+			case errors.Is(err, context.Canceled):
+				return nil
+			case err != nil:
+				ui.PrintError(fmt.Sprintf("Event stream failed: %v", err))
+				return err
+			}
+
+			if !jsonOutput {
+				ui.PrintInfo("Status", "Engine closed the event stream")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&filePath, "file", "f", "", "Specify an alternate compose file (default: ignition-compose.yml)")
+	cmd.Flags().StringArrayVar(&types, "type", nil, "Only show events of this type (repeatable)")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print each event as a line of JSON")
+
+	return cmd
+}
+
+// eventServices maps the function of each selected service to the names of the services
+// it backs. Every service is selected when names is empty.
+func eventServices(composeManifest *manifest.ComposeManifest, names []string) (map[interfaces.FunctionKey][]string, error) {
+	if len(names) == 0 {
+		for name := range composeManifest.Services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	services := make(map[interfaces.FunctionKey][]string)
+	for _, name := range names {
+		service, exists := composeManifest.Services[name]
+		if !exists {
+			return nil, fmt.Errorf("service '%s' not found in compose file", name)
+		}
+
+		functionRef, _, _ := strings.Cut(service.Function, ":")
+		namespace, funcName, ok := strings.Cut(functionRef, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid function reference '%s' for service '%s', expected format namespace/name:tag", service.Function, name)
+		}
+		key := interfaces.FunctionKey{Namespace: namespace, Name: funcName}
+		services[key] = append(services[key], name)
+	}
+	return services, nil
+}
+
+// printEvent prints an event on one line, prefixed with the services it concerns.
+func printEvent(services string, event events.Event) {
+	line := fmt.Sprintf("%s  %-15s %s/%s", event.Time.Local().Format("15:04:05.000"), event.Type, event.Namespace, event.Function)
+	if event.To != "" {
+		line += "  " + event.From + " -> " + event.To
+	}
+	if event.Digest != "" {
+		line += "  digest=" + registry.TruncateDigest(event.Digest, 12)
+	}
+	if event.Reason != "" {
+		line += "  (" + event.Reason + ")"
+	}
+	ui.PrintServiceLog(services, line)
+}
@@ -0,0 +1,124 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+)
+
+// Per-service statuses reported while waiting for healthchecks.
+const (
+	serviceHealthy   = "healthy"
+	serviceUnhealthy = "unhealthy"
+	serviceStarting  = "starting" // still being checked when another service turned unhealthy
+)
+
+// serviceHealthResult records the outcome of a service's healthcheck.
+type serviceHealthResult struct {
+	Service  string `json:"service"`
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// waitForHealthy runs the healthchecks of every service until all pass, one exhausts its
+// retries or the timeout expires, and returns the result of each service by name. Services
+// without a healthcheck are healthy once loaded. The first unhealthy service stops the wait.
+func waitForHealthy(ctx context.Context, composeManifest *manifest.ComposeManifest, engineClient *engineclient.EngineClient,
+	timeout time.Duration) ([]serviceHealthResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make(chan serviceHealthResult, len(composeManifest.Services))
+	for name, service := range composeManifest.Services {
+		go func() {
+			results <- probeService(ctx, name, service, engineClient)
+		}()
+	}
+
+	var health []serviceHealthResult
+	for range composeManifest.Services {
+		result := <-results
+		if result.Status == serviceUnhealthy {
+			cancel()
+		}
+		health = append(health, result)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Service < health[j].Service })
+
+	// Services still starting when the timeout expired are unhealthy too
+	timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	var unhealthy []string
+	for i := range health {
+		result := &health[i]
+		if result.Status == serviceStarting && timedOut {
+			result.Status = serviceUnhealthy
+			result.Error = strings.TrimSuffix(fmt.Sprintf("not healthy within %s: %s", timeout, result.Error), ": ")
+		}
+		if result.Status == serviceUnhealthy {
+			unhealthy = append(unhealthy, fmt.Sprintf("service '%s' is unhealthy: %s", result.Service, result.Error))
+		}
+	}
+	if len(unhealthy) > 0 {
+		return health, errors.New(strings.Join(unhealthy, "\n"))
+	}
+	return health, nil
+}
+
+// probeService calls the healthcheck entrypoint of a service until it succeeds, fails
+// more than its retries allow after the start period, or ctx is done.
+func probeService(ctx context.Context, name string, service manifest.ComposeService,
+	engineClient *engineclient.EngineClient) serviceHealthResult {
+	result := serviceHealthResult{Service: name, Status: serviceHealthy}
+	check := service.Healthcheck
+	if check == nil {
+		return result
+	}
+	result.Status = serviceStarting
+
+	// The compose file was validated when it was parsed
+	interval, _ := check.IntervalDuration()
+	callTimeout, _ := check.TimeoutDuration()
+	startPeriod, _ := check.StartPeriodDuration()
+	functionRef, _, _ := strings.Cut(service.Function, ":")
+	namespace, funcName, _ := strings.Cut(functionRef, "/")
+
+	started := time.Now()
+	failures := 0
+	for {
+		result.Attempts++
+		callCtx, cancel := context.WithTimeout(ctx, callTimeout)
+		_, err := engineClient.CallFunction(callCtx, namespace, funcName, check.Entrypoint, []byte(check.Payload), nil)
+		cancel()
+		if err == nil {
+			result.Status = serviceHealthy
+			result.Error = ""
+			return result
+		}
+		if ctx.Err() != nil {
+			return result
+		}
+
+		result.Error = err.Error()
+		if time.Since(started) >= startPeriod {
+			failures++
+		}
+		if failures >= check.RetryCount() {
+			result.Status = serviceUnhealthy
+			result.Error = fmt.Sprintf("healthcheck '%s' failed %d times: %v", check.Entrypoint, failures, err)
+			return result
+		}
+
+		select {
+		case <-ctx.Done():
+			return result
+		case <-time.After(interval):
+		}
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
 	"github.com/ignitionstack/ignition/internal/di"
-	"github.com/ignitionstack/ignition/internal/services"
 	"github.com/ignitionstack/ignition/internal/ui"
 	"github.com/ignitionstack/ignition/internal/ui/models/spinner"
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
 	"github.com/ignitionstack/ignition/pkg/manifest"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +24,7 @@ func NewComposeLogsCommand(container *di.Container) *cobra.Command {
 	var follow bool
 	var since string
 	var tail int
+	var levels []string
 
 	cmd := &cobra.Command{
 		Use:   "logs [SERVICE...]",
@@ -36,6 +39,7 @@ func NewComposeLogsCommand(container *di.Container) *cobra.Command {
 				ui.PrintError(fmt.Sprintf("Failed to parse compose file: %v", err))
 				return err
 			}
+			composeManifest.QualifyFunctions(globalConfig.DefaultNamespace())
 
 			// Get the engine client from the container
 			client, err := container.Get("engineClient")
@@ -43,7 +47,7 @@ func NewComposeLogsCommand(container *di.Container) *cobra.Command {
 				ui.PrintError("Failed to get engine client")
 				return fmt.Errorf("failed to get engine client: %w", err)
 			}
-			engineClient, ok := client.(*services.EngineClient)
+			engineClient, ok := client.(*engineclient.EngineClient)
 			if !ok {
 				ui.PrintError("Invalid engine client type")
 				return errors.New("invalid engine client type")
@@ -86,12 +90,17 @@ func NewComposeLogsCommand(container *di.Container) *cobra.Command {
 				}
 			}
 
+			if _, err := logging.ParseLogLevels(levels); err != nil {
+				ui.PrintError(err.Error())
+				return err
+			}
+
 			// Show logs once
 			spinnerModel := spinner.NewSpinnerModelWithMessage("Retrieving logs...")
 			program := tea.NewProgram(spinnerModel)
 
 			go func() {
-				logs, err := retrieveLogs(context.Background(), servicesToShow, engineClient, sinceDuration, tail)
+				logs, err := retrieveLogs(context.Background(), servicesToShow, engineClient, sinceDuration, tail, levels)
 				if err != nil {
 					program.Send(spinner.ErrorMsg{Err: err})
 				} else {
@@ -143,7 +152,7 @@ func NewComposeLogsCommand(container *di.Container) *cobra.Command {
 				select {
 				case <-ticker.C:
 					// Get only new logs since last check
-					newLogs, err := retrieveLogs(ctx, servicesToShow, engineClient, time.Since(lastSeen), 0)
+					newLogs, err := retrieveLogs(ctx, servicesToShow, engineClient, time.Since(lastSeen), 0, levels)
 					if err != nil {
 						ui.PrintError(fmt.Sprintf("Error retrieving logs: %v", err))
 						return err
@@ -164,12 +173,13 @@ func NewComposeLogsCommand(container *di.Container) *cobra.Command {
 	cmd.Flags().BoolVarP(&follow, "follow", "F", false, "Follow log output")
 	cmd.Flags().StringVar(&since, "since", "", "Show logs since timestamp (e.g., 30m for 30 minutes)")
 	cmd.Flags().IntVar(&tail, "tail", 100, "Number of lines to show from the end of the logs")
+	cmd.Flags().StringSliceVarP(&levels, "level", "l", nil, "Only show logs at these levels: debug, info, warning, error")
 
 	return cmd
 }
 
 // retrieveLogs gets logs for the specified services.
-func retrieveLogs(ctx context.Context, services map[string]manifest.ComposeService, client *services.EngineClient, since time.Duration, tail int) (map[string][]string, error) {
+func retrieveLogs(ctx context.Context, services map[string]manifest.ComposeService, client *engineclient.EngineClient, since time.Duration, tail int, levels []string) (map[string][]string, error) {
 	logs := make(map[string][]string)
 
 	for name, service := range services {
@@ -185,7 +195,7 @@ func retrieveLogs(ctx context.Context, services map[string]manifest.ComposeServi
 		namespace, funcName := nameParts[0], nameParts[1]
 
 		// Retrieve logs for the function
-		functionLogs, err := client.GetFunctionLogs(ctx, namespace, funcName, since, tail)
+		functionLogs, err := client.GetFunctionLogs(ctx, namespace, funcName, since, tail, levels...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to retrieve logs for service '%s': %w", name, err)
 		}
@@ -0,0 +1,85 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ignitionstack/ignition/internal/di"
+	"github.com/ignitionstack/ignition/internal/ui"
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/spf13/cobra"
+)
+
+// NewComposeScaleCommand creates a new cobra command for compose scale.
+func NewComposeScaleCommand(container *di.Container) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scale SERVICE=INSTANCES...",
+		Short: "Set the number of instances of running services",
+		Long: `Set the number of instances the engine keeps for each service loaded by compose up.
+Calls to the service are dispatched round-robin over its instances. A scale of 0
+restores autoscaling within the engine's configured pool bounds.`,
+		Example: `  # Run four instances of the api service
+  ignition compose scale api=4
+
+  # Scale several services at once
+  ignition compose scale api=4 worker=2`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			scales, err := parseScaleArgs(args)
+			if err != nil {
+				return err
+			}
+
+			client, err := container.Get("engineClient")
+			if err != nil {
+				return fmt.Errorf("error getting engine client: %w", err)
+			}
+			engineClient, ok := client.(*engineclient.EngineClient)
+			if !ok {
+				return errors.New("invalid engine client type")
+			}
+
+			var failed []string
+			for _, scale := range scales {
+				if err := engineClient.ScaleService(context.Background(), scale.service, scale.instances); err != nil {
+					ui.PrintError(fmt.Sprintf("Failed to scale %s: %v", scale.service, err))
+					failed = append(failed, scale.service)
+					continue
+				}
+				ui.PrintSuccess(fmt.Sprintf("Scaled %s to %d instances", scale.service, scale.instances))
+			}
+
+			if len(failed) > 0 {
+				return fmt.Errorf("failed to scale %s", strings.Join(failed, ", "))
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+type serviceScale struct {
+	service   string
+	instances int
+}
+
+// parseScaleArgs parses SERVICE=INSTANCES arguments.
+func parseScaleArgs(args []string) ([]serviceScale, error) {
+	scales := make([]serviceScale, 0, len(args))
+	for _, arg := range args {
+		service, count, ok := strings.Cut(arg, "=")
+		if !ok || service == "" {
+			return nil, fmt.Errorf("invalid argument %q, expected SERVICE=INSTANCES", arg)
+		}
+		instances, err := strconv.Atoi(count)
+		if err != nil || instances < 0 {
+			return nil, fmt.Errorf("invalid instance count %q for service %s", count, service)
+		}
+		scales = append(scales, serviceScale{service: service, instances: instances})
+	}
+	return scales, nil
+}
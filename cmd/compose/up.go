@@ -2,40 +2,73 @@ package compose
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
 	"github.com/ignitionstack/ignition/internal/di"
-	"github.com/ignitionstack/ignition/internal/services"
 	"github.com/ignitionstack/ignition/internal/ui"
 	"github.com/ignitionstack/ignition/internal/ui/models/spinner"
+	"github.com/ignitionstack/ignition/pkg/engine/api"
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
 	"github.com/ignitionstack/ignition/pkg/engine/models"
+	ignitionErrors "github.com/ignitionstack/ignition/pkg/errors"
 	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/ignitionstack/ignition/pkg/validation"
 	"github.com/spf13/cobra"
 )
 
 func NewComposeUpCommand(container *di.Container) *cobra.Command {
 	var filePath string
 	var detach bool
+	var jsonOutput bool
+	var wait bool
+	var waitTimeout time.Duration
+	var watchRegistry bool
+	var watchInterval time.Duration
 
 	cmd := &cobra.Command{
-		Use:           "up",
-		Short:         "Create and start functions defined in a compose file",
-		Long:          "Create and start functions defined in an ignition-compose.yml file.",
+		Use:         "up",
+		Annotations: map[string]string{ui.PlainOutputAnnotation: "json"},
+		Short:       "Create and start functions defined in a compose file",
+		Long: `Create and start functions defined in an ignition-compose.yml file.
+
+With --wait, compose up returns once every service's healthcheck passes, and fails as
+soon as one service is unhealthy or the wait times out.
+
+With --watch-registry, compose up keeps polling the registry while it runs and reloads a
+service as soon as the tag or version range it references points at a new digest, so
+pushing a build from CI deploys it.`,
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(_ *cobra.Command, _ []string) error {
+			if watchRegistry && (detach || wait || jsonOutput) {
+				err := errors.New("--watch-registry keeps compose up running and cannot be combined with --detach, --wait or --json")
+				ui.PrintError(err.Error())
+				return err
+			}
+			if watchRegistry && watchInterval <= 0 {
+				err := errors.New("--watch-interval must be positive")
+				ui.PrintError(err.Error())
+				return err
+			}
+
 			composeManifest, err := manifest.ParseComposeFile(filePath)
 			if err != nil {
 				ui.PrintError(fmt.Sprintf("Failed to parse compose file: %v", err))
 				return err
 			}
+			composeManifest.QualifyFunctions(globalConfig.DefaultNamespace())
 
 			// Get the engine client from the container
 			client, err := container.Get("engineClient")
@@ -43,7 +76,7 @@ func NewComposeUpCommand(container *di.Container) *cobra.Command {
 				ui.PrintError(fmt.Sprintf("Error getting engine client: %v", err))
 				return err
 			}
-			engineClient, ok := client.(*services.EngineClient)
+			engineClient, ok := client.(*engineclient.EngineClient)
 			if !ok {
 				ui.PrintError("Invalid engine client type")
 				return errors.New("invalid engine client type")
@@ -51,8 +84,30 @@ func NewComposeUpCommand(container *di.Container) *cobra.Command {
 
 			// Check if engine is running
 			if err := engineClient.Status(context.Background()); err != nil {
-				ui.PrintError(fmt.Sprintf("Failed to connect to engine: %v", err))
-				return err
+				if jsonOutput {
+					summary := &loadSummary{Status: summaryEngineUnreachable, Error: err.Error()}
+					if encodeErr := printSummaryJSON(summary); encodeErr != nil {
+						return encodeErr
+					}
+				} else {
+					ui.PrintError(fmt.Sprintf("Failed to connect to engine: %v", err))
+				}
+				return ignitionErrors.WithExitCode(err, ExitCodeEngineUnreachable)
+			}
+
+			// JSON output is meant for CI, so load once, report and exit
+			if jsonOutput {
+				summary := loadFunctions(context.Background(), composeManifest, engineClient)
+				if wait && summary.Status == summarySuccess {
+					var err error
+					if summary.Health, err = waitForHealthy(context.Background(), composeManifest, engineClient, waitTimeout); err != nil {
+						summary.Status = summaryUnhealthy
+					}
+				}
+				if err := printSummaryJSON(summary); err != nil {
+					return err
+				}
+				return summary.Err()
 			}
 
 			// Create a context that we can cancel
@@ -98,6 +153,10 @@ func NewComposeUpCommand(container *di.Container) *cobra.Command {
 					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 					defer cancel()
 
+					for name := range composeManifest.Pipelines {
+						_ = engineClient.UnregisterPipeline(ctx, name)
+					}
+
 					err := engineClient.StopFunctions(ctx, functionsToUnload)
 					if err != nil {
 						if isConnectionError(err) {
@@ -142,11 +201,11 @@ func NewComposeUpCommand(container *di.Container) *cobra.Command {
 
 			// Load functions in a goroutine
 			go func() {
-				result, err := loadFunctions(ctx, composeManifest, engineClient)
-				if err != nil {
+				summary := loadFunctions(ctx, composeManifest, engineClient)
+				if err := summary.Err(); err != nil {
 					program.Send(spinner.ErrorMsg{Err: err})
 				} else {
-					program.Send(spinner.DoneMsg{Result: result})
+					program.Send(spinner.DoneMsg{Result: summary.Loaded})
 				}
 			}()
 
@@ -173,6 +232,10 @@ func NewComposeUpCommand(container *di.Container) *cobra.Command {
 
 			ui.PrintSuccess(fmt.Sprintf("Successfully loaded %d functions", loadedCount))
 
+			if wait {
+				return waitAndReport(ctx, composeManifest, engineClient, waitTimeout)
+			}
+
 			fmt.Println()
 
 			// If detach is true, return now
@@ -181,6 +244,11 @@ func NewComposeUpCommand(container *di.Container) *cobra.Command {
 				fmt.Println()
 				fmt.Println(ui.DimStyle.Render("Functions are running. Press Ctrl+C to stop..."))
 
+				if watchRegistry {
+					ui.PrintInfo("Watching", fmt.Sprintf("registry tags every %s", watchInterval))
+					go newRegistryWatcher(composeManifest, engineClient, watchInterval).Run(ctx)
+				}
+
 				// Wait for shutdown to complete
 				<-done
 			}
@@ -191,10 +259,46 @@ func NewComposeUpCommand(container *di.Container) *cobra.Command {
 
 	cmd.Flags().StringVarP(&filePath, "file", "f", "", "Specify an alternate compose file (default: ignition-compose.yml)")
 	cmd.Flags().BoolVarP(&detach, "detach", "d", false, "Run functions in the background")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait for every service's healthcheck to pass before returning (implies --detach)")
+	cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", time.Minute, "How long --wait waits for services to become healthy")
+	cmd.Flags().BoolVar(&watchRegistry, "watch-registry", false, "Reload services when the tags they use move to a new digest in the registry")
+	cmd.Flags().DurationVar(&watchInterval, "watch-interval", 10*time.Second, "How often --watch-registry polls the registry")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print a machine-readable JSON summary of per-service results and exit (implies --detach)")
 
 	return cmd
 }
 
+// waitAndReport waits for the services to become healthy behind a spinner and prints
+// the health of each service.
+func waitAndReport(ctx context.Context, composeManifest *manifest.ComposeManifest, engineClient *engineclient.EngineClient,
+	timeout time.Duration) error {
+	program := tea.NewProgram(spinner.NewSpinnerModelWithMessage("Waiting for services to become healthy..."))
+
+	var health []serviceHealthResult
+	var waitErr error
+	go func() {
+		health, waitErr = waitForHealthy(ctx, composeManifest, engineClient, timeout)
+		program.Send(spinner.DoneMsg{Result: len(health)})
+	}()
+	if _, err := program.Run(); err != nil {
+		ui.PrintError(fmt.Sprintf("UI error: %v", err))
+		return err
+	}
+
+	table := ui.NewTable([]string{"SERVICE", "HEALTH", "ATTEMPTS"})
+	for _, result := range health {
+		table.AddRow(result.Service, ui.StyleStatusValue(result.Status), fmt.Sprintf("%d", result.Attempts))
+	}
+	fmt.Println(ui.RenderTable(table))
+
+	if waitErr != nil {
+		ui.PrintError(waitErr.Error())
+		return ignitionErrors.WithExitCode(waitErr, ExitCodeUnhealthy)
+	}
+	ui.PrintSuccess(fmt.Sprintf("All %d services are healthy", len(health)))
+	return nil
+}
+
 // Check if an error is a connection-related error.
 func isConnectionError(err error) bool {
 	if err == nil {
@@ -207,67 +311,406 @@ func isConnectionError(err error) bool {
 		strings.Contains(errMsg, "engine is not running")
 }
 
-func loadFunctions(ctx context.Context, composeManifest *manifest.ComposeManifest, engineClient *services.EngineClient) (int, error) {
-	loadedCount := 0
-	var loadErrs []string
+// Exit codes returned by compose up so that scripts can tell failure modes apart.
+const (
+	ExitCodePartialFailure    = 2
+	ExitCodeTotalFailure      = 3
+	ExitCodeEngineUnreachable = 4
+	ExitCodeUnhealthy         = 5
+)
 
-	for name, service := range composeManifest.Services {
-		// Parse function reference (namespace/name:tag)
-		parts := strings.Split(service.Function, ":")
-		if len(parts) != 2 {
-			loadErrs = append(loadErrs, fmt.Sprintf("invalid function reference '%s' for service '%s', expected format namespace/name:tag", service.Function, name))
-			continue
+// Overall statuses reported in the load summary.
+const (
+	summarySuccess           = "success"
+	summaryPartialFailure    = "partial_failure"
+	summaryTotalFailure      = "total_failure"
+	summaryEngineUnreachable = "engine_unreachable"
+	summaryUnhealthy         = "unhealthy"
+)
+
+// Per-service statuses reported in the load summary.
+const (
+	serviceLoaded  = "loaded"
+	serviceFailed  = "failed"
+	serviceSkipped = "skipped" // a service it depends on was not loaded
+)
+
+// Per-pipeline statuses reported in the load summary.
+const (
+	pipelineRegistered = "registered"
+	pipelineFailed     = "failed"
+)
+
+// serviceLoadResult records the outcome of loading a single compose service.
+type serviceLoadResult struct {
+	Service  string `json:"service"`
+	Function string `json:"function"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// pipelineRegisterResult records the outcome of registering a single compose pipeline.
+type pipelineRegisterResult struct {
+	Pipeline string `json:"pipeline"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// loadSummary aggregates the per-service results of a compose up run.
+type loadSummary struct {
+	Status   string              `json:"status"`
+	Loaded   int                 `json:"loaded"`
+	Failed   int                 `json:"failed"`
+	Skipped  int                 `json:"skipped,omitempty"`
+	Error    string              `json:"error,omitempty"`
+	Services []serviceLoadResult `json:"services"`
+
+	Pipelines []pipelineRegisterResult `json:"pipelines,omitempty"`
+
+	// Healthcheck results, reported with --wait
+	Health []serviceHealthResult `json:"health,omitempty"`
+}
+
+// Err returns an error carrying the exit code matching the summary status,
+// or nil if every service loaded.
+func (s *loadSummary) Err() error {
+	var code int
+	switch s.Status {
+	case summarySuccess:
+		return nil
+	case summaryPartialFailure:
+		code = ExitCodePartialFailure
+	case summaryEngineUnreachable:
+		code = ExitCodeEngineUnreachable
+	case summaryUnhealthy:
+		code = ExitCodeUnhealthy
+	default:
+		code = ExitCodeTotalFailure
+	}
+
+	var errs []string
+	for _, result := range s.Services {
+		if result.Status == serviceFailed || result.Status == serviceSkipped {
+			errs = append(errs, result.Error)
+		}
+	}
+	for _, result := range s.Pipelines {
+		if result.Status == pipelineFailed {
+			errs = append(errs, result.Error)
+		}
+	}
+	if s.Status == summaryUnhealthy {
+		for _, result := range s.Health {
+			if result.Status == serviceUnhealthy {
+				errs = append(errs, fmt.Sprintf("service '%s' is unhealthy: %s", result.Service, result.Error))
+			}
 		}
+		return ignitionErrors.WithExitCode(
+			fmt.Errorf("some services are unhealthy:\n%s", strings.Join(errs, "\n")), code)
+	}
+	return ignitionErrors.WithExitCode(
+		fmt.Errorf("failed to load some functions:\n%s", strings.Join(errs, "\n")), code)
+}
 
-		functionRef, tag := parts[0], parts[1]
+// printSummaryJSON writes the summary to stdout as indented JSON.
+func printSummaryJSON(summary *loadSummary) error {
+	if summary.Services == nil {
+		summary.Services = []serviceLoadResult{}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summary)
+}
+
+func loadFunctions(ctx context.Context, composeManifest *manifest.ComposeManifest, engineClient *engineclient.EngineClient) *loadSummary {
+	summary := &loadSummary{}
 
-		// Parse namespace and name
-		nameParts := strings.Split(functionRef, "/")
-		if len(nameParts) != 2 {
-			loadErrs = append(loadErrs, fmt.Sprintf("invalid function reference '%s' for service '%s', expected format namespace/name:tag", service.Function, name))
+	// Report services in a stable order so the summary is reproducible
+	serviceNames := make([]string, 0, len(composeManifest.Services))
+	for name := range composeManifest.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+
+	results := make(map[string]*serviceLoadResult, len(serviceNames))
+	var req api.LoadBatchRequest
+	for _, name := range serviceNames {
+		service := composeManifest.Services[name]
+		results[name] = &serviceLoadResult{
+			Service:  name,
+			Function: service.Function,
+			Status:   serviceFailed,
+		}
+
+		item, err := serviceLoadItem(name, service, composeManifest.Services)
+		if err != nil {
+			results[name].Error = err.Error()
 			continue
 		}
+		req.Loads = append(req.Loads, item)
+	}
+	req.Loads = skipUnloadableDependents(req.Loads, results)
+
+	// The engine loads the whole file in one request, ordering services by depends_on
+	if len(req.Loads) > 0 {
+		resp, err := engineClient.LoadBatch(ctx, req)
+		for _, item := range req.Loads {
+			if err != nil {
+				results[item.Service].Error = fmt.Sprintf("failed to load service '%s': %v", item.Service, err)
+			}
+		}
+		if err == nil {
+			for _, loaded := range resp.Results {
+				applyLoadBatchResult(results[loaded.Service], composeManifest.Services[loaded.Service], loaded)
+			}
+		}
+	}
 
-		namespace, funcName := nameParts[0], nameParts[1]
+	for _, name := range serviceNames {
+		result := results[name]
+		switch result.Status {
+		case serviceLoaded:
+			summary.Loaded++
+		case serviceSkipped:
+			summary.Skipped++
+		default:
+			summary.Failed++
+		}
+		summary.Services = append(summary.Services, *result)
+	}
 
-		// Create configuration by merging both Config and Environment fields
-		config := make(map[string]string)
+	// Pipelines resolve their services when called, so register them once services are up
+	pipelinesFailed := registerPipelines(ctx, composeManifest, engineClient, summary)
 
-		// First copy from legacy Config field if present
-		for k, v := range service.Config {
-			config[k] = v
+	switch {
+	case summary.Failed == 0 && summary.Skipped == 0 && !pipelinesFailed:
+		summary.Status = summarySuccess
+	case summary.Loaded == 0:
+		summary.Status = summaryTotalFailure
+	default:
+		summary.Status = summaryPartialFailure
+	}
+
+	return summary
+}
+
+// serviceLoadItem builds the batch load of a compose service.
+func serviceLoadItem(name string, service manifest.ComposeService, services map[string]manifest.ComposeService) (api.LoadBatchItem, error) {
+	namespace, funcName, tag, err := parseServiceFunction(name, service)
+	if err != nil {
+		return api.LoadBatchItem{}, err
+	}
+	for _, dep := range service.DependsOn {
+		if _, ok := services[dep]; !ok {
+			return api.LoadBatchItem{}, fmt.Errorf("service '%s' depends on unknown service '%s'", name, dep)
 		}
+	}
+
+	// Apply the scale on every run so removing it from the file restores autoscaling
+	scale := service.Scale
+	return api.LoadBatchItem{
+		LoadRequest: api.LoadRequest{
+			BaseRequest: api.BaseRequest{
+				Namespace: namespace,
+				Name:      funcName,
+			},
+			Digest:       tag,
+			Config:       service.ServiceConfig(),
+			ForceLoad:    true,
+			Service:      name,
+			Source:       service.Source,
+			SourceDigest: service.Digest,
+		},
+		DependsOn: service.DependsOn,
+		Scale:     &scale,
+	}, nil
+}
 
-		// Then copy from Environment field, which takes precedence
-		for k, v := range service.Environment {
-			config[k] = v
+// skipUnloadableDependents marks the services that depend, directly or not, on a service
+// left out of the batch as skipped, and returns the loads still to send.
+func skipUnloadableDependents(loads []api.LoadBatchItem, results map[string]*serviceLoadResult) []api.LoadBatchItem {
+	for {
+		batched := make(map[string]bool, len(loads))
+		for _, item := range loads {
+			batched[item.Service] = true
 		}
 
-		// Load the function
-		err := engineClient.LoadFunction(ctx, namespace, funcName, tag, config)
-		if err != nil {
-			errorMsg := fmt.Sprintf("failed to load function '%s' for service '%s': %v", service.Function, name, err)
-
-			// Provide more helpful error messages for common issues
-			if strings.Contains(err.Error(), "function not found") {
-				errorMsg = fmt.Sprintf("Function '%s' not found for service '%s'. Run 'ignition function build' to create it first.",
-					service.Function, name)
-			} else if strings.Contains(err.Error(), "no such file or directory") {
-				errorMsg = fmt.Sprintf("Unable to load function '%s' for service '%s'. The function file does not exist.",
-					service.Function, name)
+		kept := loads[:0]
+		for _, item := range loads {
+			missing := ""
+			for _, dep := range item.DependsOn {
+				if !batched[dep] {
+					missing = dep
+					break
+				}
 			}
+			if missing == "" {
+				kept = append(kept, item)
+				continue
+			}
+			results[item.Service].Status = serviceSkipped
+			results[item.Service].Error = fmt.Sprintf("service '%s' was not loaded: dependency '%s' was not loaded", item.Service, missing)
+		}
 
-			loadErrs = append(loadErrs, errorMsg)
-			continue
+		if len(kept) == len(batched) {
+			return kept
+		}
+		loads = kept
+	}
+}
+
+// applyLoadBatchResult records the outcome the engine reported for a service.
+func applyLoadBatchResult(result *serviceLoadResult, service manifest.ComposeService, loaded types.LoadBatchResult) {
+	switch loaded.Status {
+	case types.LoadBatchLoaded:
+		result.Status = serviceLoaded
+		result.Error = ""
+	case types.LoadBatchSkipped:
+		result.Status = serviceSkipped
+		result.Error = fmt.Sprintf("service '%s' was not loaded: %s", result.Service, loaded.Error)
+	default:
+		result.Error = serviceLoadError(result.Service, service, errors.New(loaded.Error)).Error()
+	}
+}
+
+// loadService loads the function backing a single compose service.
+func loadService(ctx context.Context, name string, service manifest.ComposeService, engineClient *engineclient.EngineClient) error {
+	namespace, funcName, tag, err := parseServiceFunction(name, service)
+	if err != nil {
+		return err
+	}
+
+	// Load the function under its service name so others can address it
+	if service.Source != "" {
+		err = engineClient.ImportService(ctx, name, namespace, funcName, tag, service.Source, service.Digest, service.ServiceConfig())
+	} else {
+		err = engineClient.LoadService(ctx, name, namespace, funcName, tag, service.ServiceConfig())
+	}
+	if err != nil {
+		return serviceLoadError(name, service, err)
+	}
+	return nil
+}
+
+// parseServiceFunction splits the function reference of a service into its namespace,
+// name and tag. Prebuilt modules are imported, so their tag is optional.
+func parseServiceFunction(name string, service manifest.ComposeService) (namespace, funcName, tag string, err error) {
+	if service.Source != "" {
+		functionRef, tag, _ := strings.Cut(service.Function, ":")
+		namespace, funcName, ok := strings.Cut(functionRef, "/")
+		if !ok {
+			return "", "", "", fmt.Errorf("invalid function reference '%s' for service '%s', expected format namespace/name[:tag]", service.Function, name)
+		}
+		if err := validation.ValidateFunction(namespace, funcName); err != nil {
+			return "", "", "", fmt.Errorf("invalid function reference '%s' for service '%s': %w", service.Function, name, err)
+		}
+		if tag != "" {
+			if err := validation.ValidateTag(tag); err != nil {
+				return "", "", "", fmt.Errorf("invalid function reference '%s' for service '%s': %w", service.Function, name, err)
+			}
 		}
+		return namespace, funcName, tag, nil
+	}
+
+	// Parse function reference (namespace/name:tag)
+	parts := strings.Split(service.Function, ":")
+	if len(parts) != 2 {
+		return "", "", "", fmt.Errorf("invalid function reference '%s' for service '%s', expected format namespace/name:tag", service.Function, name)
+	}
+
+	functionRef, tag := parts[0], parts[1]
+
+	// Parse namespace and name
+	nameParts := strings.Split(functionRef, "/")
+	if len(nameParts) != 2 {
+		return "", "", "", fmt.Errorf("invalid function reference '%s' for service '%s', expected format namespace/name:tag", service.Function, name)
+	}
+
+	namespace, funcName = nameParts[0], nameParts[1]
+	if err := validation.ValidateFunction(namespace, funcName); err != nil {
+		return "", "", "", fmt.Errorf("invalid function reference '%s' for service '%s': %w", service.Function, name, err)
+	}
+	if err := validation.ValidateTag(tag); err != nil {
+		// Semver ranges such as ^1.2 are resolved by the registry on pull
+		if _, rangeErr := registry.ParseVersionRange(tag); rangeErr != nil {
+			return "", "", "", fmt.Errorf("invalid function reference '%s' for service '%s': %w", service.Function, name, err)
+		}
+	}
+	return namespace, funcName, tag, nil
+}
+
+// serviceLoadError explains why the function of a service failed to load.
+func serviceLoadError(name string, service manifest.ComposeService, err error) error {
+	if service.Source != "" {
+		return fmt.Errorf("failed to import '%s' for service '%s': %w", service.Source, name, err)
+	}
+
+	// Provide more helpful error messages for common issues
+	if strings.Contains(err.Error(), "function not found") {
+		return fmt.Errorf("function '%s' not found for service '%s'. Run 'ignition function build' to create it first",
+			service.Function, name)
+	} else if strings.Contains(err.Error(), "no such file or directory") {
+		return fmt.Errorf("unable to load function '%s' for service '%s'. The function file does not exist",
+			service.Function, name)
+	}
+
+	return fmt.Errorf("failed to load function '%s' for service '%s': %w", service.Function, name, err)
+}
 
-		loadedCount++
+// registerPipelines registers every pipeline of the compose file and reports whether any failed.
+func registerPipelines(ctx context.Context, composeManifest *manifest.ComposeManifest,
+	engineClient *engineclient.EngineClient, summary *loadSummary) bool {
+	names := make([]string, 0, len(composeManifest.Pipelines))
+	for name := range composeManifest.Pipelines {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	failed := false
+	for _, name := range names {
+		result := pipelineRegisterResult{Pipeline: name, Status: pipelineRegistered}
+
+		steps, err := pipelineSteps(composeManifest.Pipelines[name])
+		if err == nil {
+			err = engineClient.RegisterPipeline(ctx, name, steps)
+		}
+		if err != nil {
+			result.Status = pipelineFailed
+			result.Error = fmt.Sprintf("failed to register pipeline '%s': %v", name, err)
+			failed = true
+		}
+
+		summary.Pipelines = append(summary.Pipelines, result)
+	}
+
+	return failed
+}
+
+// pipelineSteps converts the steps of a compose pipeline into engine pipeline steps.
+func pipelineSteps(pipeline manifest.ComposePipeline) ([]api.PipelineStep, error) {
+	steps := make([]api.PipelineStep, 0, len(pipeline.Steps))
+	for i, step := range pipeline.Steps {
+		timeout, err := step.TimeoutDuration()
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+
+		converted := api.PipelineStep{
+			Service:    step.Service,
+			Entrypoint: step.Entrypoint,
+			TimeoutMs:  timeout.Milliseconds(),
+			OnError:    step.OnError,
+		}
+		if step.Function != "" {
+			namespace, name, ok := strings.Cut(step.Function, "/")
+			if !ok {
+				return nil, fmt.Errorf("step %d: invalid function reference '%s', expected format namespace/name", i+1, step.Function)
+			}
+			converted.Namespace, converted.Name = namespace, name
+		}
 
-	// Check for errors
-	if len(loadErrs) > 0 {
-		return loadedCount, fmt.Errorf("failed to load some functions:\n%s", strings.Join(loadErrs, "\n"))
+		steps = append(steps, converted)
 	}
 
-	return loadedCount, nil
+	return steps, nil
 }
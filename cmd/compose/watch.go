@@ -0,0 +1,128 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/internal/ui"
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// registryWatcher reloads compose services when the tag or version range they were
+// loaded with resolves to a new digest, for example after CI pushed a build under it.
+type registryWatcher struct {
+	manifest     *manifest.ComposeManifest
+	engineClient *engineclient.EngineClient
+	interval     time.Duration
+
+	// Digest each watched service was last loaded at
+	digests map[string]string
+}
+
+func newRegistryWatcher(composeManifest *manifest.ComposeManifest, engineClient *engineclient.EngineClient,
+	interval time.Duration) *registryWatcher {
+	return &registryWatcher{
+		manifest:     composeManifest,
+		engineClient: engineClient,
+		interval:     interval,
+		digests:      make(map[string]string),
+	}
+}
+
+// Run polls the registry until ctx is done. The first poll records the digests the
+// services were just loaded at.
+func (w *registryWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.check(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check(ctx)
+		}
+	}
+}
+
+// check reloads every watched service whose reference moved since the last check.
+// A failed reload is retried on the next check.
+func (w *registryWatcher) check(ctx context.Context) {
+	for _, name := range w.watchedServices() {
+		service := w.manifest.Services[name]
+		functionRef, reference, _ := strings.Cut(service.Function, ":")
+		namespace, funcName, _ := strings.Cut(functionRef, "/")
+
+		metadata, err := w.engineClient.GetRegistryFunction(ctx, namespace, funcName)
+		if err != nil {
+			if ctx.Err() == nil {
+				ui.PrintWarning(fmt.Sprintf("Failed to check the registry for service '%s': %v", name, err))
+			}
+			continue
+		}
+		digest, ok := resolveWatchedReference(metadata.Versions, reference)
+		if !ok {
+			continue
+		}
+
+		previous, known := w.digests[name]
+		if !known {
+			w.digests[name] = digest
+			continue
+		}
+		if digest == previous {
+			continue
+		}
+
+		if err := loadService(ctx, name, service, w.engineClient); err != nil {
+			if ctx.Err() == nil {
+				ui.PrintError(fmt.Sprintf("Failed to reload service '%s' at %s: %v", name, registry.TruncateDigest(digest, 12), err))
+			}
+			continue
+		}
+		w.digests[name] = digest
+		ui.PrintSuccess(fmt.Sprintf("Reloaded service '%s': %s moved from %s to %s", name, reference,
+			registry.TruncateDigest(previous, 12), registry.TruncateDigest(digest, 12)))
+	}
+}
+
+// watchedServices returns the services loaded by a tag or version range, in name order.
+// Services imported from a source are pinned by their digest and never move.
+func (w *registryWatcher) watchedServices() []string {
+	var names []string
+	for name, service := range w.manifest.Services {
+		if service.Source != "" {
+			continue
+		}
+		if _, reference, ok := strings.Cut(service.Function, ":"); ok && reference != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveWatchedReference returns the digest a tag or version range points at, the
+// same way the registry resolves it on pull.
+func resolveWatchedReference(versions []registry.VersionInfo, reference string) (string, bool) {
+	for _, version := range versions {
+		if registry.HasTag(version.Tags, reference) {
+			return version.FullDigest, true
+		}
+	}
+	versionRange, err := registry.ParseVersionRange(reference)
+	if err != nil {
+		return "", false
+	}
+	version, _, ok := registry.ResolveVersionRange(versions, versionRange)
+	if !ok {
+		return "", false
+	}
+	return version.FullDigest, true
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/validation"
+	"github.com/spf13/cobra"
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Switch between engines",
+	Long: `Commands for managing named contexts, each pointing the CLI at an engine: the socket
+of a local engine or the admin API of a remote one, with its token and the namespace of
+function references written without one.
+
+Contexts are kept in ~/.ignition/contexts.yaml. The current context applies to every
+command; IGNITION_CONTEXT selects another one for a shell, and --socket still talks to
+the given socket.`,
+	Example: `  # Add a remote engine
+  ignition context set prod --address https://engine.internal:9090 --token $TOKEN --namespace payments
+
+  # Talk to it from every command
+  ignition context use prod
+
+  # Return to the local engine
+  ignition context use --none`,
+}
+
+func newContextListCommand() *cobra.Command {
+	var plain bool
+
+	cmd := &cobra.Command{
+		Use:           "list",
+		Short:         "List contexts",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			contexts, err := globalConfig.LoadContexts(globalConfig.ContextsPath())
+			if err != nil {
+				return err
+			}
+			active, _, _ := contexts.Active()
+
+			if plain {
+				for _, ctx := range contexts.Contexts {
+					fmt.Printf("%s\t%s\t%s\n", ctx.Name, ctx.Endpoint(), ctx.Namespace)
+				}
+				return nil
+			}
+
+			if len(contexts.Contexts) == 0 {
+				fmt.Println("No contexts, commands use the default socket")
+				return nil
+			}
+
+			table := ui.NewTable([]string{"CURRENT", "NAME", "ENGINE", "NAMESPACE"})
+			for _, ctx := range contexts.Contexts {
+				current := ""
+				if ctx.Name == active.Name {
+					current = "*"
+				}
+				table.AddRow(current, ctx.Name, ctx.Endpoint(), ctx.Namespace)
+			}
+			fmt.Println(ui.RenderTable(table))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&plain, "plain", "p", false, "Output in plain text format (for scripting)")
+	return cmd
+}
+
+func newContextUseCommand() *cobra.Command {
+	var none bool
+
+	cmd := &cobra.Command{
+		Use:           "use [name]",
+		Short:         "Make a context the current one",
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if none == (len(args) == 1) {
+				return fmt.Errorf("pass either a context name or --none")
+			}
+
+			path := globalConfig.ContextsPath()
+			contexts, err := globalConfig.LoadContexts(path)
+			if err != nil {
+				return err
+			}
+			var name string
+			if len(args) == 1 {
+				name = args[0]
+			}
+			if err := contexts.Use(name); err != nil {
+				return err
+			}
+			if err := contexts.Save(path); err != nil {
+				return err
+			}
+
+			if name == "" {
+				ui.PrintSuccess("Commands now use the default socket")
+			} else {
+				ui.PrintSuccess(fmt.Sprintf("Switched to context %s", name))
+			}
+			if env := os.Getenv(globalConfig.ContextEnv); env != "" && env != name {
+				ui.PrintWarning(fmt.Sprintf("%s=%s overrides it in this shell", globalConfig.ContextEnv, env))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&none, "none", false, "Unset the current context and use the default socket")
+	return cmd
+}
+
+func newContextSetCommand() *cobra.Command {
+	var ctx globalConfig.Context
+
+	cmd := &cobra.Command{
+		Use:   "set <name>",
+		Short: "Add or replace a context",
+		Long: `Add a context, or replace the one with the same name. A context points at either
+the socket of a local engine or the admin API of a remote engine (server.admin_addr),
+whose token (server.admin_token) is sent as a bearer token.`,
+		Example: `  # A local engine on another socket
+  ignition context set dev --socket /tmp/ignition-dev.sock
+
+  # A remote engine
+  ignition context set prod --address https://engine.internal:9090 --token $TOKEN`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			ctx.Name = args[0]
+
+			path := globalConfig.ContextsPath()
+			contexts, err := globalConfig.LoadContexts(path)
+			if err != nil {
+				return err
+			}
+			if err := contexts.Set(ctx); err != nil {
+				return err
+			}
+			if err := contexts.Save(path); err != nil {
+				return err
+			}
+
+			ui.PrintSuccess(fmt.Sprintf("Context %s saved", ctx.Name))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&ctx.Socket, "socket", "", "Socket of a local engine")
+	cmd.Flags().StringVar(&ctx.Address, "address", "", "URL of the admin API of a remote engine")
+	cmd.Flags().StringVar(&ctx.Token, "token", "", "Token of the admin API")
+	cmd.Flags().StringVar(&ctx.Namespace, "namespace", "", "Namespace of function references written without one")
+	return cmd
+}
+
+func newContextRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:           "remove <name>",
+		Short:         "Remove a context",
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			path := globalConfig.ContextsPath()
+			contexts, err := globalConfig.LoadContexts(path)
+			if err != nil {
+				return err
+			}
+			if !contexts.Remove(args[0]) {
+				return fmt.Errorf("context %s not found", args[0])
+			}
+			if err := contexts.Save(path); err != nil {
+				return err
+			}
+
+			ui.PrintSuccess(fmt.Sprintf("Context %s removed", args[0]))
+			return nil
+		},
+	}
+}
+
+func newContextCurrentCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:           "current",
+		Short:         "Show the context commands use",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx, ok, err := globalConfig.ActiveContext()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("No current context, commands use the default socket")
+				return nil
+			}
+			fmt.Printf("%s\t%s\n", ctx.Name, ctx.Endpoint())
+			return nil
+		},
+	}
+}
+
+func newContextNamespaceCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "namespace [namespace]",
+		Short: "Show or set the default namespace",
+		Long: `Show or set the namespace of function references written without one, such as
+my_function:latest. Setting it changes the current context, or the default of every
+command when no context is current. IGNITION_NAMESPACE overrides it for a shell, and
+the namespace of a compose file for its services.`,
+		Example: `  # Build and call functions in the payments namespace
+  ignition context namespace payments
+  ignition function build -t my_function:latest
+  ignition function call my_function handler`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			path := globalConfig.ContextsPath()
+			contexts, err := globalConfig.LoadContexts(path)
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				fmt.Println(contexts.DefaultNamespace())
+				return nil
+			}
+
+			namespace := args[0]
+			if err := validation.ValidateNamespace(namespace); err != nil {
+				return err
+			}
+			ctx, ok, err := contexts.Active()
+			if err != nil {
+				return err
+			}
+			if ok {
+				ctx.Namespace = namespace
+				if err := contexts.Set(ctx); err != nil {
+					return err
+				}
+			} else {
+				contexts.Namespace = namespace
+			}
+			if err := contexts.Save(path); err != nil {
+				return err
+			}
+
+			if ok {
+				ui.PrintSuccess(fmt.Sprintf("Default namespace of context %s set to %s", ctx.Name, namespace))
+			} else {
+				ui.PrintSuccess(fmt.Sprintf("Default namespace set to %s", namespace))
+			}
+			return nil
+		},
+	}
+}
+
+func init() {
+	contextCmd.AddCommand(newContextListCommand())
+	contextCmd.AddCommand(newContextUseCommand())
+	contextCmd.AddCommand(newContextSetCommand())
+	contextCmd.AddCommand(newContextRemoveCommand())
+	contextCmd.AddCommand(newContextCurrentCommand())
+	contextCmd.AddCommand(newContextNamespaceCommand())
+
+	rootCmd.AddCommand(contextCmd)
+}
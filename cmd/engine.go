@@ -24,11 +24,28 @@ The engine must be running to deploy or call functions.`,
   ignition engine start --socket /tmp/custom-socket.sock
 
   # Start the engine with a custom registry directory
-  ignition engine start --directory /path/to/registry`,
+  ignition engine start --directory /path/to/registry
+
+  # Show admin operations from the last hour
+  ignition engine audit --since 1h
+
+  # Capture loaded functions, services and pipelines for a later restore
+  ignition engine snapshot -o ignition-snapshot.json
+
+  # Collect a diagnostic bundle to attach to a bug report
+  ignition engine diag
+
+  # Keep the engine running in the background as a systemd or launchd service
+  ignition engine install-service`,
 }
 
 func init() {
 	engineCmd.AddCommand(engine.NewEngineStartCommand())
+	engineCmd.AddCommand(engine.NewEngineAuditCommand())
+	engineCmd.AddCommand(engine.NewEngineSnapshotCommand())
+	engineCmd.AddCommand(engine.NewEngineDiagCommand())
+	engineCmd.AddCommand(engine.NewEngineInstallServiceCommand())
+	engineCmd.AddCommand(engine.NewEngineUninstallServiceCommand())
 
 	rootCmd.AddCommand(engineCmd)
 }
@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/audit"
+	"github.com/spf13/cobra"
+)
+
+// NewEngineAuditCommand creates a command to show the engine's admin audit log.
+func NewEngineAuditCommand() *cobra.Command {
+	var (
+		auditSocketPath string
+		since           time.Duration
+		operation       string
+		plain           bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show the admin audit log",
+		Long: `Show admin operations recorded by the engine.
+
+Every load, unload, stop, build and tag reassignment sent to the engine socket is
+recorded with its timestamp, source (socket peer and token fingerprint) and parameters.`,
+		Example: `  # Show operations from the last hour
+  ignition engine audit --since 1h
+
+  # Show only builds from the last day
+  ignition engine audit --since 24h --operation build`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			engineClient, err := globalConfig.NewEngineClient(auditSocketPath)
+			if err != nil {
+				return fmt.Errorf("failed to create engine client: %w", err)
+			}
+
+			events, err := engineClient.GetAuditLog(context.Background(), since, operation)
+			if err != nil {
+				return fmt.Errorf("failed to get audit log: %w", err)
+			}
+
+			if plain {
+				for _, event := range events {
+					fmt.Printf("%s\t%s\t%s\t%s\t%s\n", event.Timestamp.Format(time.RFC3339),
+						event.Operation, event.Source, auditResult(event), formatAuditParams(event.Params))
+				}
+				return nil
+			}
+
+			if len(events) == 0 {
+				fmt.Println("No audit events found")
+				return nil
+			}
+
+			table := ui.NewTable([]string{"TIME", "OPERATION", "SOURCE", "RESULT", "PARAMS"})
+			for _, event := range events {
+				table.AddRow(event.Timestamp.Local().Format(time.DateTime), event.Operation,
+					event.Source, auditResult(event), formatAuditParams(event.Params))
+			}
+			fmt.Println(ui.RenderTable(table))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&auditSocketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	cmd.Flags().DurationVar(&since, "since", 0, "Only show operations newer than this duration (e.g. 1h)")
+	cmd.Flags().StringVar(&operation, "operation", "", "Only show this operation (load, unload, stop, build, reassign-tag)")
+	cmd.Flags().BoolVar(&plain, "plain", false, "Output in plain, machine-readable format")
+
+	return cmd
+}
+
+func auditResult(event audit.Event) string {
+	if event.Success {
+		return "ok"
+	}
+	return "failed: " + event.Error
+}
+
+// formatAuditParams renders parameters as sorted key=value pairs
+func formatAuditParams(params map[string]string) string {
+	pairs := make([]string, 0, len(params))
+	for key, value := range params {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/selfupdate"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/spf13/cobra"
+)
+
+// diagTimeout bounds each request the diagnostic bundle makes to the engine
+const diagTimeout = 10 * time.Second
+
+// diagBundle collects the files of a diagnostic bundle, and the errors met gathering them
+type diagBundle struct {
+	files  []diagFile
+	errors []string
+}
+
+type diagFile struct {
+	name string
+	data []byte
+}
+
+// addJSON adds value as an indented JSON file, or records why it could not be gathered.
+func (b *diagBundle) addJSON(name string, value interface{}, err error) {
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("%s: %v", name, err))
+		return
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("%s: %v", name, err))
+		return
+	}
+	b.files = append(b.files, diagFile{name: name, data: append(data, '\n')})
+}
+
+// NewEngineDiagCommand creates a command that collects a diagnostic bundle for bug reports.
+func NewEngineDiagCommand() *cobra.Command {
+	var (
+		diagSocketPath string
+		configPath     string
+		output         string
+		tail           int
+		since          time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diag",
+		Short: "Collect a diagnostic bundle to attach to bug reports",
+		Long: `Collect what is needed to look into an engine problem into a single tarball:
+
+  version.json           versions of the CLI and the engine
+  host.json              operating system, architecture and CPUs of this host
+  config.json            the engine configuration, with tokens, keys and credentials redacted
+  status.json            engine status, instance pools and cold starts
+  functions.json         functions with their digests; config values are redacted
+  snapshot.json          functions, scales, services and pipelines; secret config values are redacted
+  circuit_breakers.json  the state of every circuit breaker
+  logs/                  the recent logs of every function
+
+Parts that cannot be gathered, for example because the engine is not running, are
+listed in errors.txt and the rest of the bundle is still written.`,
+		Example: `  # Write ignition-diag-<time>.tar.gz in the current directory
+  ignition engine diag
+
+  # Include more log lines per function
+  ignition engine diag --tail 1000 -o diag.tar.gz`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			now := time.Now().UTC()
+			if output == "" {
+				output = "ignition-diag-" + now.Format("20060102T150405Z") + ".tar.gz"
+			}
+
+			bundle := &diagBundle{}
+			engineVersion := collectEngineState(bundle, diagSocketPath, tail, since)
+			bundle.addJSON("version.json", map[string]string{
+				"cli":    selfupdate.CurrentVersion(),
+				"engine": engineVersion,
+				"go":     runtime.Version(),
+			}, nil)
+			bundle.addJSON("host.json", hostDetails(), nil)
+			cfg, err := loadDiagConfig(configPath)
+			if err == nil {
+				bundle.addJSON("config.json", cfg.Redacted(), nil)
+			} else {
+				bundle.addJSON("config.json", nil, err)
+			}
+
+			if err := writeDiagBundle(output, now, bundle); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+
+			ui.PrintSuccess(fmt.Sprintf("Diagnostic bundle written to %s", output))
+			if len(bundle.errors) > 0 {
+				ui.PrintWarning("Some parts could not be collected, see errors.txt")
+			}
+			ui.PrintInfo("Review", "secrets are redacted, but check function logs before sharing the bundle")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&diagSocketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	cmd.Flags().StringVarP(&configPath, "config", "c", config.DefaultConfigPath, "Path to the engine configuration file")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Bundle to write (ignition-diag-<time>.tar.gz if not specified)")
+	cmd.Flags().IntVar(&tail, "tail", 200, "Log lines to include per function")
+	cmd.Flags().DurationVar(&since, "since", 0, "Only include logs from this long ago (e.g. 1h)")
+
+	return cmd
+}
+
+// collectEngineState adds the status, functions, circuit breakers and logs of the running
+// engine to the bundle and returns the engine version.
+func collectEngineState(bundle *diagBundle, socketPath string, tail int, since time.Duration) string {
+	engineClient, err := globalConfig.NewEngineClient(socketPath)
+	if err != nil {
+		bundle.addJSON("status.json", nil, err)
+		return ""
+	}
+	client := engineClient.API()
+
+	ctx, cancel := context.WithTimeout(context.Background(), diagTimeout)
+	status, err := client.Status(ctx)
+	cancel()
+	bundle.addJSON("status.json", status, err)
+	if err != nil {
+		// Without an engine to answer, the other requests would fail the same way
+		return ""
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), diagTimeout)
+	functions, err := client.ListFunctions(ctx)
+	cancel()
+	// Function config often holds credentials, so only its keys are kept
+	for i := range functions {
+		for key := range functions[i].Config {
+			functions[i].Config[key] = "[REDACTED]"
+		}
+	}
+	bundle.addJSON("functions.json", functions, err)
+
+	// The engine masks the values of config keys matching its redaction patterns
+	ctx, cancel = context.WithTimeout(context.Background(), diagTimeout)
+	snapshot, err := client.RedactedSnapshot(ctx)
+	cancel()
+	bundle.addJSON("snapshot.json", snapshot, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), diagTimeout)
+	breakers, err := client.ListCircuitBreakers(ctx)
+	cancel()
+	bundle.addJSON("circuit_breakers.json", breakers, err)
+
+	for _, fn := range functions {
+		ctx, cancel = context.WithTimeout(context.Background(), diagTimeout)
+		logs, err := client.GetFunctionLogs(ctx, fn.Namespace, fn.Name, since, tail)
+		cancel()
+		name := path.Join("logs", fn.Namespace, fn.Name+".log")
+		if err != nil {
+			bundle.errors = append(bundle.errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		bundle.files = append(bundle.files, diagFile{name: name, data: []byte(strings.Join(logs, "\n") + "\n")})
+	}
+
+	return status.Version
+}
+
+// loadDiagConfig loads the engine configuration without creating a missing config file.
+func loadDiagConfig(configPath string) (*config.Config, error) {
+	if _, err := os.Stat(config.ExpandHome(configPath)); err != nil {
+		return config.LoadEnvConfig()
+	}
+	return config.LoadConfig(configPath)
+}
+
+// hostDetails describes the host the CLI runs on.
+func hostDetails() map[string]interface{} {
+	hostname, _ := os.Hostname()
+	return map[string]interface{}{
+		"hostname": hostname,
+		"os":       runtime.GOOS,
+		"arch":     runtime.GOARCH,
+		"cpus":     runtime.NumCPU(),
+	}
+}
+
+// writeDiagBundle writes the files of the bundle, and errors.txt when some are missing,
+// as a gzipped tar under a directory named after the bundle.
+func writeDiagBundle(output string, now time.Time, bundle *diagBundle) error {
+	files := bundle.files
+	if len(bundle.errors) > 0 {
+		files = append(files, diagFile{name: "errors.txt", data: []byte(strings.Join(bundle.errors, "\n") + "\n")})
+	}
+
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	root := strings.TrimSuffix(filepath.Base(output), ".tar.gz")
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		header := &tar.Header{Name: path.Join(root, f.name), Mode: 0600, Size: int64(len(f.data)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
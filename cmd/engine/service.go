@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/ignitionstack/ignition/internal/daemon"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// serviceFlags are the flags install-service and uninstall-service share.
+type serviceFlags struct {
+	system   bool
+	user     string
+	noStart  bool
+	dryRun   bool
+	restart  string
+	config   string
+	extraArg []string
+}
+
+// NewEngineInstallServiceCommand creates a command that runs the engine as a systemd or launchd service.
+func NewEngineInstallServiceCommand() *cobra.Command {
+	var flags serviceFlags
+
+	cmd := &cobra.Command{
+		Use:   "install-service [-- engine start flags]",
+		Short: "Run the engine in the background as a systemd or launchd service",
+		Long: `Write a systemd unit on Linux, or a launchd property list on macOS, that runs
+'ignition engine start' with this binary, restarts it when it fails and starts it at
+login, then start it.
+
+By default the service belongs to the current user: a systemd user unit in
+~/.config/systemd/user or a launch agent in ~/Library/LaunchAgents. With --system, it is
+installed for the whole machine, which needs root, and runs as --user (by default the
+user who ran sudo). Arguments after -- are passed to 'ignition engine start'.`,
+		Example: `  # Run the engine for the current user
+  ignition engine install-service
+
+  # Run it at boot as the ignition user, always restarting it
+  sudo ignition engine install-service --system --user ignition --restart always
+
+  # Print the unit with extra engine flags without installing it
+  ignition engine install-service --dry-run -- --http :9090`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			flags.extraArg = args
+			manager, spec, err := serviceSpec(flags)
+			if err != nil {
+				return err
+			}
+			definition, err := daemon.Render(manager, spec)
+			if err != nil {
+				return err
+			}
+
+			path := daemon.Path(manager, spec)
+			if flags.dryRun {
+				fmt.Printf("# %s\n%s", path, definition)
+				return nil
+			}
+
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+			}
+			if err := os.WriteFile(path, []byte(definition), 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+			ui.PrintSuccess(fmt.Sprintf("Wrote %s", path))
+
+			if flags.noStart {
+				return nil
+			}
+			if err := runServiceCommands(daemon.StartCommands(manager, spec)); err != nil {
+				return err
+			}
+			ui.PrintSuccess("Engine service started")
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.system, "system", false, "Install for the whole machine instead of the current user (needs root)")
+	cmd.Flags().StringVar(&flags.user, "user", "", "User the engine of a system service runs as (default: the user who ran sudo)")
+	cmd.Flags().StringVar(&flags.restart, "restart", daemon.RestartOnFailure, "When to restart the engine: on-failure, always or no")
+	cmd.Flags().StringVarP(&flags.config, "config", "c", "", "Configuration file the engine starts with (default: ~/.ignition/config.yaml of the user)")
+	cmd.Flags().BoolVar(&flags.noStart, "no-start", false, "Write the service definition without starting it")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print the service definition instead of installing it")
+	return cmd
+}
+
+// NewEngineUninstallServiceCommand creates a command that stops and removes the engine service.
+func NewEngineUninstallServiceCommand() *cobra.Command {
+	var flags serviceFlags
+
+	cmd := &cobra.Command{
+		Use:   "uninstall-service",
+		Short: "Stop and remove the engine service",
+		Long: `Stop the engine service written by 'ignition engine install-service' and remove its
+systemd unit or launchd property list. Pass --system for a service installed with it.`,
+		Example: `  # Remove the service of the current user
+  ignition engine uninstall-service
+
+  # Remove the machine-wide service
+  sudo ignition engine uninstall-service --system`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			flags.restart = daemon.RestartOnFailure
+			manager, spec, err := serviceSpec(flags)
+			if err != nil {
+				return err
+			}
+
+			path := daemon.Path(manager, spec)
+			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("no engine service installed at %s", path)
+			}
+
+			// A service that is already stopped is removed all the same
+			if err := runServiceCommands(daemon.StopCommands(manager, spec)); err != nil {
+				ui.PrintWarning(err.Error())
+			}
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+			if err := runServiceCommands(daemon.RemovedCommands(manager, spec)); err != nil {
+				ui.PrintWarning(err.Error())
+			}
+
+			ui.PrintSuccess(fmt.Sprintf("Removed %s", path))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.system, "system", false, "Remove the machine-wide service instead of the current user's")
+	return cmd
+}
+
+// serviceSpec describes the engine service of this binary for the flags.
+func serviceSpec(flags serviceFlags) (daemon.Manager, daemon.Spec, error) {
+	manager, err := daemon.DetectManager()
+	if err != nil {
+		return "", daemon.Spec{}, err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", daemon.Spec{}, fmt.Errorf("failed to locate the ignition binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	account, err := serviceUser(flags)
+	if err != nil {
+		return "", daemon.Spec{}, err
+	}
+
+	args := []string{"engine", "start"}
+	if flags.config != "" {
+		configPath, err := filepath.Abs(flags.config)
+		if err != nil {
+			return "", daemon.Spec{}, fmt.Errorf("invalid config path: %w", err)
+		}
+		args = append(args, "--config", configPath)
+	}
+	args = append(args, flags.extraArg...)
+
+	return manager, daemon.Spec{
+		Executable: executable,
+		Args:       args,
+		System:     flags.system,
+		User:       account.Username,
+		Home:       account.HomeDir,
+		Restart:    flags.restart,
+	}, nil
+}
+
+// serviceUser returns the account the engine runs as: --user, or else the user who ran
+// sudo for a system service, or else the current user.
+func serviceUser(flags serviceFlags) (*user.User, error) {
+	name := flags.user
+	if name != "" && !flags.system {
+		return nil, errors.New("--user only applies to a --system service")
+	}
+	if name == "" && flags.system {
+		name = os.Getenv("SUDO_USER")
+	}
+	if name == "" {
+		current, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the current user: %w", err)
+		}
+		return current, nil
+	}
+
+	account, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown user %s: %w", name, err)
+	}
+	return account, nil
+}
+
+// runServiceCommands runs the service manager commands in order, stopping at the first failure.
+func runServiceCommands(commands [][]string) error {
+	for _, command := range commands {
+		output, err := exec.Command(command[0], command[1:]...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s failed: %w: %s", strings.Join(command, " "), err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// NewEngineSnapshotCommand creates a command to capture the engine's runtime state.
+func NewEngineSnapshotCommand() *cobra.Command {
+	var (
+		snapshotSocketPath string
+		output             string
+		redact             bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Capture the engine's runtime state",
+		Long: `Capture the runtime state of a running engine into a snapshot file.
+
+The snapshot records every loaded, unloaded and stopped function with its digest and
+config, scaled instance counts, service names and pipelines. Start an engine with
+--from-snapshot to bring all of it back.
+
+With --redact, the values of config keys matching the engine's redaction patterns are
+masked so the snapshot can be shared; redacted snapshots cannot be restored.`,
+		Example: `  # Write a snapshot of the running engine
+  ignition engine snapshot -o ignition-snapshot.json
+
+  # Restore it on the next start
+  ignition engine start --from-snapshot ignition-snapshot.json
+
+  # Print the state without secrets, to attach to a bug report
+  ignition engine snapshot --redact`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			engineClient, err := globalConfig.NewEngineClient(snapshotSocketPath)
+			if err != nil {
+				return fmt.Errorf("failed to create engine client: %w", err)
+			}
+
+			capture := engineClient.Snapshot
+			if redact {
+				capture = engineClient.RedactedSnapshot
+			}
+			snapshot, err := capture(context.Background())
+			if err != nil {
+				return fmt.Errorf("failed to capture snapshot: %w", err)
+			}
+
+			data, err := json.MarshalIndent(snapshot, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode snapshot: %w", err)
+			}
+
+			if output == "" || output == "-" {
+				fmt.Println(string(data))
+				return nil
+			}
+
+			if err := os.WriteFile(output, append(data, '\n'), 0600); err != nil {
+				return fmt.Errorf("failed to write snapshot: %w", err)
+			}
+
+			fmt.Printf("Snapshot of %d functions, %d services and %d pipelines written to %s\n",
+				len(snapshot.Functions), len(snapshot.Services), len(snapshot.Pipelines), output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&snapshotSocketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Snapshot file to write (stdout if not specified)")
+	cmd.Flags().BoolVar(&redact, "redact", false, "Mask the values of secret config keys")
+
+	return cmd
+}
+
+// readSnapshot loads a snapshot file written by the snapshot command.
+func readSnapshot(path string) (*types.EngineSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot types.EngineSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+
+	return &snapshot, nil
+}
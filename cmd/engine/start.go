@@ -1,13 +1,18 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	globalConfig "github.com/ignitionstack/ignition/internal/config"
 	"github.com/ignitionstack/ignition/pkg/engine"
 	"github.com/ignitionstack/ignition/pkg/engine/config"
 	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	localRegistry "github.com/ignitionstack/ignition/pkg/registry/local"
+	"github.com/ignitionstack/ignition/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +27,11 @@ func NewEngineStartCommand() *cobra.Command {
 		logLevel     string
 		showConfig   bool
 		defaultsOnly bool
+		envOnly      bool
+		fromSnapshot string
+		noSocket     bool
+		adminAddr    string
+		waitForLock  time.Duration
 	}
 
 	cmd := &cobra.Command{
@@ -37,6 +47,10 @@ The engine server provides:
 * Circuit breaker patterns for resilience
 
 The engine must be running for other Ignition commands like function calls to work.
+
+Only one engine can use a registry directory at a time. When another process holds the
+registry database, start fails naming its pid, unless --wait-for-lock gives it time to
+release the database, for example while an old engine shuts down.
 The engine can be configured with various flags, environment variables, or a YAML config file.`,
 		Example: `  # Start the engine with default settings
   ignition engine start
@@ -48,11 +62,17 @@ The engine can be configured with various flags, environment variables, or a YAM
   ignition engine start --config ~/.ignition/custom-config.yaml
 
   # Start with detailed logging
-  ignition engine start --log-level debug --log-file /var/log/ignition.log`,
-		RunE: func(_ *cobra.Command, _ []string) error {
+  ignition engine start --log-level debug --log-file /var/log/ignition.log
+
+  # Restore functions, services and pipelines captured by 'ignition engine snapshot'
+  ignition engine start --from-snapshot ignition-snapshot.json
+
+  # Run in a container: no Unix socket, admin API over TCP, everything else from the environment
+  IGNITION_SERVER_ADMIN_TOKEN=secret ignition engine start --env-only --no-socket --admin-addr :9090`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
 			// If the user just wants to see the config, print it and exit
 			if cmdConfig.showConfig {
-				cfg, err := loadConfig(globalConfig.ConfigPath, cmdConfig.defaultsOnly)
+				cfg, err := loadConfig(globalConfig.ConfigPath, cmdConfig.defaultsOnly, cmdConfig.envOnly)
 				if err != nil {
 					return fmt.Errorf("failed to load configuration: %w", err)
 				}
@@ -60,6 +80,8 @@ The engine can be configured with various flags, environment variables, or a YAM
 				fmt.Printf("Engine configuration:\n")
 				fmt.Printf("  Server:\n")
 				fmt.Printf("    SocketPath: %s\n", cfg.Server.SocketPath)
+				fmt.Printf("    SocketEnabled: %t\n", cfg.Server.SocketEnabled)
+				fmt.Printf("    AdminAddr: %s\n", cfg.Server.AdminAddr)
 				fmt.Printf("    HTTPAddr: %s\n", cfg.Server.HTTPAddr)
 				fmt.Printf("    RegistryDir: %s\n", cfg.Server.RegistryDir)
 				fmt.Printf("  Engine:\n")
@@ -75,22 +97,42 @@ The engine can be configured with various flags, environment variables, or a YAM
 				return nil
 			}
 
+			// Read the snapshot up front so a bad file fails before the engine starts
+			var snapshot *types.EngineSnapshot
+			if cmdConfig.fromSnapshot != "" {
+				loaded, err := readSnapshot(cmdConfig.fromSnapshot)
+				if err != nil {
+					return err
+				}
+				snapshot = loaded
+			}
+
 			// Load configuration from file and environment variables
-			cfg, err := loadConfig(globalConfig.ConfigPath, cmdConfig.defaultsOnly)
+			cfg, err := loadConfig(globalConfig.ConfigPath, cmdConfig.defaultsOnly, cmdConfig.envOnly)
 			if err != nil {
 				return fmt.Errorf("failed to load configuration: %w", err)
 			}
 
-			// Override config with command line flags if provided
-			if cmdConfig.socketPath != "" {
+			// Override config with command line flags if provided. The socket path flag has
+			// a default, so it only wins over the config file and environment when set.
+			if cmd.Flags().Changed("socket-path") {
 				cfg.Server.SocketPath = cmdConfig.socketPath
 			}
+			if cmdConfig.noSocket {
+				cfg.Server.SocketEnabled = false
+			}
+			if cmdConfig.adminAddr != "" {
+				cfg.Server.AdminAddr = cmdConfig.adminAddr
+			}
 			if cmdConfig.httpAddr != "" {
 				cfg.Server.HTTPAddr = cmdConfig.httpAddr
 			}
 			if cmdConfig.registryDir != "" {
 				cfg.Server.RegistryDir = cmdConfig.registryDir
 			}
+			if cmd.Flags().Changed("wait-for-lock") {
+				cfg.Server.LockWait = cmdConfig.waitForLock
+			}
 
 			// Ensure registry directory exists
 			if err := ensureDirectoryExists(cfg.Server.RegistryDir); err != nil {
@@ -115,12 +157,27 @@ The engine can be configured with various flags, environment variables, or a YAM
 
 			// Create the engine with our configuration
 			eng, err := engine.NewEngineWithConfig(cfg, logger)
+			if errors.Is(err, localRegistry.ErrDatabaseLocked) {
+				return fmt.Errorf("failed to create engine: %w; stop it, or start with --wait-for-lock to wait for it to exit", err)
+			}
 			if err != nil {
 				return fmt.Errorf("failed to create engine: %w", err)
 			}
 
-			// Start the engine
-			if err := eng.Start(); err != nil {
+			// Restore the snapshot before serving so callers never see a partial state
+			if snapshot != nil {
+				if err := eng.RestoreSnapshot(context.Background(), snapshot); err != nil {
+					logger.Errorf("Snapshot %s restored with errors: %v", cmdConfig.fromSnapshot, err)
+				}
+			}
+
+			// Start the engine, then close its databases so the registry is left clean for
+			// the next engine and for commands reading it while no engine runs
+			err = eng.Start()
+			if closeErr := eng.Close(); closeErr != nil {
+				logger.Errorf("Failed to close the engine: %v", closeErr)
+			}
+			if err != nil {
 				return fmt.Errorf("engine server failed: %w", err)
 			}
 
@@ -142,15 +199,23 @@ The engine can be configured with various flags, environment variables, or a YAM
 	cmd.Flags().StringVarP(&globalConfig.ConfigPath, "config", "c", config.DefaultConfigPath, "Path to the configuration file")
 	cmd.Flags().BoolVarP(&cmdConfig.showConfig, "show-config", "C", false, "Show the configuration and exit")
 	cmd.Flags().BoolVar(&cmdConfig.defaultsOnly, "defaults-only", false, "Use only default configuration, ignore config file and env vars")
+	cmd.Flags().BoolVar(&cmdConfig.envOnly, "env-only", false, "Configure from defaults and IGNITION_* env vars only, without reading or creating a config file")
+	cmd.Flags().BoolVar(&cmdConfig.noSocket, "no-socket", false, "Do not serve the admin API on a Unix socket")
+	cmd.Flags().StringVar(&cmdConfig.adminAddr, "admin-addr", "", "Serve the admin API over TCP on this address (requires server.admin_token)")
+	cmd.Flags().StringVar(&cmdConfig.fromSnapshot, "from-snapshot", "", "Restore the state captured by 'ignition engine snapshot' on start")
+	cmd.Flags().DurationVar(&cmdConfig.waitForLock, "wait-for-lock", 0, "Wait up to this long for another process to release the registry database")
 
 	return cmd
 }
 
 // loadConfig loads the configuration from the specified path and environment variables.
-func loadConfig(configPath string, defaultsOnly bool) (*config.Config, error) {
+func loadConfig(configPath string, defaultsOnly, envOnly bool) (*config.Config, error) {
 	if defaultsOnly {
 		return config.DefaultConfig(), nil
 	}
+	if envOnly {
+		return config.LoadEnvConfig()
+	}
 	return config.LoadConfig(configPath)
 }
 
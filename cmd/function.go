@@ -37,6 +37,17 @@ func init() {
 	rootCmd.AddCommand(function.NewFunctionStopCommand())
 	rootCmd.AddCommand(function.NewFunctionTagCommand())
 	rootCmd.AddCommand(function.NewFunctionListCommand())
+	functionCmd.AddCommand(function.NewFunctionResolveCommand())
+	functionCmd.AddCommand(function.NewFunctionSBOMCommand())
+	functionCmd.AddCommand(function.NewFunctionVariantCommand())
+	functionCmd.AddCommand(function.NewFunctionCallLoggingCommand())
+	functionCmd.AddCommand(function.NewFunctionLogsCommand())
+
+	// Dead letter management lives under the function group
+	functionCmd.AddCommand(function.NewFunctionDLQCommand())
+	functionCmd.AddCommand(function.NewFunctionBenchCommand())
+	functionCmd.AddCommand(function.NewFunctionTestCommand())
+	functionCmd.AddCommand(function.NewFunctionRunLocalCommand())
 
 	// Add the functionCmd to the root command
 	rootCmd.AddCommand(functionCmd)
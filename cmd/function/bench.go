@@ -0,0 +1,328 @@
+package function
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/api"
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/spf13/cobra"
+)
+
+// benchOptions holds the flags of the bench command
+type benchOptions struct {
+	socketPath  string
+	duration    time.Duration
+	concurrency int
+	payload     string
+	timeout     time.Duration
+	config      []string
+	jsonOutput  bool
+}
+
+// benchReport summarizes a benchmark run
+type benchReport struct {
+	Function    string         `json:"function"`
+	Entrypoint  string         `json:"entrypoint"`
+	Concurrency int            `json:"concurrency"`
+	Duration    string         `json:"duration"`
+	Calls       int            `json:"calls"`
+	Errors      int            `json:"errors"`
+	Throughput  float64        `json:"throughput"`
+	Latency     benchLatency   `json:"latency"`
+	ErrorCounts map[string]int `json:"error_counts,omitempty"`
+
+	// Engine-side pool statistics after the run
+	Pool *components.PoolStats `json:"pool,omitempty"`
+
+	// Instance restarts observed by the engine during the run
+	Restarts int64 `json:"restarts"`
+}
+
+// benchLatency holds latency percentiles of successful calls
+type benchLatency struct {
+	Mean string `json:"mean"`
+	P50  string `json:"p50"`
+	P90  string `json:"p90"`
+	P99  string `json:"p99"`
+	Max  string `json:"max"`
+}
+
+// NewFunctionBenchCommand creates a command that drives load against a function through the engine.
+func NewFunctionBenchCommand() *cobra.Command {
+	var opts benchOptions
+
+	cmd := &cobra.Command{
+		Use:         "bench [namespace/name:reference] [entrypoint]",
+		Annotations: map[string]string{ui.PlainOutputAnnotation: "json"},
+		Short:       "Benchmark a function through the engine",
+		Long: `Load a function into the engine and call an entrypoint from concurrent workers
+for a fixed duration, then report throughput, latency percentiles and errors.
+
+Latency is measured by the client, so it includes the socket round trip. The engine's
+instance pool statistics are read before and after the run to report how many
+instances served the load and whether any crashed.`,
+		Example: `  # Call the handler entrypoint from 16 workers for 30 seconds
+  ignition function bench default/hello-world:latest handler --duration 30s --concurrency 16
+
+  # Send the contents of a file as the payload
+  ignition function bench default/hello-world:latest greet --payload @payload.json`,
+		Args:          cobra.ExactArgs(2),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			namespace, name, reference, err := parseNamespaceAndName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid function name format: %w", err)
+			}
+			if opts.concurrency < 1 {
+				return fmt.Errorf("concurrency must be at least 1")
+			}
+			if opts.duration <= 0 {
+				return fmt.Errorf("duration must be positive")
+			}
+
+			payload, err := readPayloadFlag(opts.payload)
+			if err != nil {
+				return err
+			}
+
+			report, err := runBench(namespace, name, reference, args[1], payload, opts)
+			if err != nil {
+				return err
+			}
+
+			if opts.jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(report)
+			}
+
+			printBenchReport(report)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.socketPath, "socket", "s", client.DefaultSocketPath(), "Path to the Unix socket")
+	cmd.Flags().DurationVarP(&opts.duration, "duration", "d", 30*time.Second, "How long to drive load")
+	cmd.Flags().IntVarP(&opts.concurrency, "concurrency", "n", 16, "Number of concurrent callers")
+	cmd.Flags().StringVarP(&opts.payload, "payload", "p", "", "Payload sent with every call (@file reads it from a file)")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 0, "Deadline for each call (0 uses the engine default)")
+	cmd.Flags().StringArrayVarP(&opts.config, "config", "c", []string{}, "Configuration values to load the function with (format: key=value)")
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "Print the report as JSON")
+
+	return cmd
+}
+
+// readPayloadFlag returns the payload flag, reading it from a file when prefixed with @
+func readPayloadFlag(value string) (string, error) {
+	path, isFile := strings.CutPrefix(value, "@")
+	if !isFile {
+		return value, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read payload file: %w", err)
+	}
+	return string(data), nil
+}
+
+// runBench loads the function and calls it from concurrent workers until the duration elapses
+func runBench(namespace, name, reference, entrypoint, payload string, opts benchOptions) (*benchReport, error) {
+	transport := client.DefaultTransportOptions()
+	transport.MaxIdleConns = max(transport.MaxIdleConns, opts.concurrency)
+
+	clientOptions, err := globalConfig.ClientOptions(opts.socketPath)
+	if err != nil {
+		return nil, err
+	}
+	clientOptions.Transport = &transport
+	engineClient, err := client.New(clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create engine client: %w", err)
+	}
+
+	config := make(map[string]string)
+	for _, item := range opts.config {
+		if parts := splitKeyValue(item); len(parts) == 2 {
+			config[parts[0]] = parts[1]
+		}
+	}
+
+	ctx := context.Background()
+	_, err = engineClient.LoadFunction(ctx, api.LoadRequest{
+		BaseRequest: api.BaseRequest{Namespace: namespace, Name: name},
+		Digest:      reference,
+		Config:      config,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load function: %w", err)
+	}
+
+	key := interfaces.NewFunctionKey(namespace, name).Encode()
+	before := benchPoolStats(ctx, engineClient, key)
+
+	req := api.CallRequest{
+		BaseRequest: api.BaseRequest{Namespace: namespace, Name: name},
+		Entrypoint:  entrypoint,
+		Payload:     payload,
+		Timeout:     opts.timeout,
+	}
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		errCounts = make(map[string]int)
+		wg        sync.WaitGroup
+	)
+
+	runCtx, cancel := context.WithTimeout(ctx, opts.duration)
+	defer cancel()
+
+	start := time.Now()
+	for range opts.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var local []time.Duration
+			localErrs := make(map[string]int)
+			for runCtx.Err() == nil {
+				callStart := time.Now()
+				_, err := engineClient.CallFunction(ctx, req)
+				if err != nil {
+					localErrs[benchErrorKey(err)]++
+					continue
+				}
+				local = append(local, time.Since(callStart))
+			}
+
+			mu.Lock()
+			latencies = append(latencies, local...)
+			for k, v := range localErrs {
+				errCounts[k] += v
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := &benchReport{
+		Function:    fmt.Sprintf("%s/%s:%s", namespace, name, reference),
+		Entrypoint:  entrypoint,
+		Concurrency: opts.concurrency,
+		Duration:    elapsed.Round(time.Millisecond).String(),
+		Calls:       len(latencies),
+		Throughput:  float64(len(latencies)) / elapsed.Seconds(),
+		Latency:     summarizeLatencies(latencies),
+	}
+	for _, count := range errCounts {
+		report.Errors += count
+	}
+	if report.Errors > 0 {
+		report.ErrorCounts = errCounts
+	}
+
+	if after := benchPoolStats(ctx, engineClient, key); after != nil {
+		report.Pool = after
+		if before != nil {
+			report.Restarts = after.Restarts - before.Restarts
+		}
+	}
+
+	return report, nil
+}
+
+// benchPoolStats reads the engine's pool statistics for a function, if available
+func benchPoolStats(ctx context.Context, engineClient api.Client, key string) *components.PoolStats {
+	status, err := engineClient.Status(ctx)
+	if err != nil {
+		return nil
+	}
+	stats, ok := status.Pools[key]
+	if !ok {
+		return nil
+	}
+	return &stats
+}
+
+// benchErrorKey groups errors by HTTP status and message
+func benchErrorKey(err error) string {
+	var respErr api.ResponseError
+	if errors.As(err, &respErr) && respErr.Code != 0 {
+		return fmt.Sprintf("%d %s", respErr.Code, respErr.Message)
+	}
+	return err.Error()
+}
+
+// summarizeLatencies computes the mean and percentiles of the given latencies
+func summarizeLatencies(latencies []time.Duration) benchLatency {
+	if len(latencies) == 0 {
+		return benchLatency{}
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+	}
+
+	percentile := func(p float64) string {
+		idx := int(p*float64(len(sorted))+0.5) - 1
+		idx = min(max(idx, 0), len(sorted)-1)
+		return sorted[idx].String()
+	}
+
+	return benchLatency{
+		Mean: (total / time.Duration(len(sorted))).String(),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P99:  percentile(0.99),
+		Max:  sorted[len(sorted)-1].String(),
+	}
+}
+
+func printBenchReport(report *benchReport) {
+	fmt.Printf("Benchmarked %s (%s) with %d workers for %s\n\n",
+		report.Function, report.Entrypoint, report.Concurrency, report.Duration)
+
+	table := ui.NewTable([]string{"CALLS", "ERRORS", "CALLS/S", "MEAN", "P50", "P90", "P99", "MAX"})
+	table.AddRow(fmt.Sprintf("%d", report.Calls), fmt.Sprintf("%d", report.Errors),
+		fmt.Sprintf("%.1f", report.Throughput), report.Latency.Mean, report.Latency.P50,
+		report.Latency.P90, report.Latency.P99, report.Latency.Max)
+	fmt.Println(ui.RenderTable(table))
+
+	if report.Pool != nil {
+		fmt.Printf("\nEngine pool: %d instances (max %d), %d restarts during the run\n",
+			report.Pool.Instances, report.Pool.MaxInstances, report.Restarts)
+	}
+
+	if len(report.ErrorCounts) > 0 {
+		messages := make([]string, 0, len(report.ErrorCounts))
+		for message := range report.ErrorCounts {
+			messages = append(messages, message)
+		}
+		sort.Strings(messages)
+
+		fmt.Println("\nErrors:")
+		for _, message := range messages {
+			fmt.Printf("  %6d  %s\n", report.ErrorCounts[message], message)
+		}
+	}
+}
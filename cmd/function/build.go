@@ -2,6 +2,8 @@ package function
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
@@ -10,12 +12,14 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
 	"github.com/ignitionstack/ignition/internal/ui"
 	"github.com/ignitionstack/ignition/internal/ui/models/spinner"
 	"github.com/ignitionstack/ignition/pkg/engine/api"
 	"github.com/ignitionstack/ignition/pkg/engine/client"
 	"github.com/ignitionstack/ignition/pkg/manifest"
 	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/ignitionstack/ignition/pkg/validation"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 )
@@ -43,7 +47,16 @@ WebAssembly module, and registers it in the local registry. The build process:
 4. Stores the built function in the registry with specified tags
 5. Makes the function available for deployment
 
-The build command requires a running engine to perform the compilation process.`,
+The build command requires a running engine to perform the compilation process. The
+registry records the provenance of each build: the ignition and toolchain versions, the
+hash of the sources, the build commands and the build time, shown by
+'ignition function resolve'. With --reproducible the toolchain is asked to leave
+timestamps and source paths out of the module, where it allows it. With --debug the
+module keeps its function names and DWARF sections, so traps are recorded in the
+function logs with symbolized stack traces.
+
+A failed build reports the last lines the toolchain printed. With --verbose the whole
+output of the toolchain (cargo, tinygo, npm...) is printed while the build runs.`,
 		Example: `  # Build function in the current directory
   ignition build
 
@@ -54,22 +67,27 @@ The build command requires a running engine to perform the compilation process.`
   ignition build -t namespace/name:tag
 
   # Build with multiple tags
-  ignition build -t namespace/name:latest -t namespace/name:v1.0.0`,
+  ignition build -t namespace/name:latest -t namespace/name:v1.0.0
+
+  # Build without timestamps and source paths in the module
+  ignition build --reproducible -t namespace/name:v1.0.0
+
+  # Build with symbols and source lines for stack traces
+  ignition build --debug -t namespace/name:debug
+
+  # Show the toolchain output while building
+  ignition build --verbose -t namespace/name:latest`,
 		Args:          cobra.MaximumNArgs(1),
 		RunE:          buildFunction,
 		SilenceErrors: true,
 		SilenceUsage:  true,
 	}
 
-	// Use the default socket path
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
-	defaultSocketPath := filepath.Join(homeDir, ".ignition", "engine.sock")
-
-	cmd.Flags().StringVarP(&socketPath, "socket", "s", defaultSocketPath, "Path to the Unix socket")
+	cmd.Flags().StringVarP(&socketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
 	cmd.Flags().StringArrayP("tag", "t", []string{}, "Tags for the function (can be specified multiple times)")
+	cmd.Flags().Bool("reproducible", false, "Normalize the timestamps and paths the toolchain embeds in the module")
+	cmd.Flags().Bool("debug", false, "Keep function names and DWARF sections in the module for symbolized stack traces")
+	cmd.Flags().Bool("verbose", false, "Print the output of the toolchain while building")
 
 	return cmd
 }
@@ -93,20 +111,46 @@ func buildFunction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	reproducible, err := cmd.Flags().GetBool("reproducible")
+	if err != nil {
+		return fmt.Errorf("failed to get reproducible flag: %w", err)
+	}
+	debug, err := cmd.Flags().GetBool("debug")
+	if err != nil {
+		return fmt.Errorf("failed to get debug flag: %w", err)
+	}
+	verbose, err := cmd.Flags().GetBool("verbose")
+	if err != nil {
+		return fmt.Errorf("failed to get verbose flag: %w", err)
+	}
+	opts := types.BuildOptions{Reproducible: reproducible, Debug: debug}
+
 	// Create engine client
-	engineClient, err := client.New(client.Options{
-		SocketPath: socketPath,
-	})
+	clientOptions, err := globalConfig.ClientOptions(socketPath)
+	if err != nil {
+		return err
+	}
+	engineClient, err := client.New(clientOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create engine client: %w", err)
 	}
 
+	// The toolchain output replaces the spinner
+	if verbose {
+		result, err := runVerboseBuild(absPath, tags, functionConfig, opts, engineClient)
+		if err != nil {
+			return err
+		}
+		displayBuildResults(*result, tags)
+		return nil
+	}
+
 	// Start the build spinner
 	spinnerModel := spinner.NewSpinnerModelWithMessage("Building...")
 	program := tea.NewProgram(spinnerModel)
 
 	// Run the build in a goroutine to allow the spinner to update
-	go runBuild(program, absPath, tags, functionConfig, engineClient)
+	go runBuild(program, absPath, tags, functionConfig, opts, engineClient)
 
 	// Run the UI program and wait for completion
 	model, err := program.Run()
@@ -187,6 +231,10 @@ func loadFunctionManifest(path string) (manifest.FunctionManifest, error) {
 		return config, errors.New("function language is required in ignition.yml")
 	}
 
+	if err := config.FunctionSettings.VersionSettings.Config.Validate(); err != nil {
+		return config, fmt.Errorf("invalid config schema in ignition.yml: %w", err)
+	}
+
 	return config, nil
 }
 
@@ -212,10 +260,9 @@ func parseTags(cmd *cobra.Command, absPath string) ([]TagInfo, error) {
 			return nil, fmt.Errorf("failed to parse manifest: %w", err)
 		}
 
-		// Use the function name from the manifest with default namespace
+		// Use the function name from the manifest in the default namespace
 		if config.FunctionSettings.Name != "" {
-			namespace := "default" // Always use default namespace
-			tagFlags = append(tagFlags, fmt.Sprintf("%s/%s:latest", namespace, config.FunctionSettings.Name))
+			tagFlags = append(tagFlags, config.FunctionSettings.Name+":latest")
 		}
 	}
 
@@ -236,10 +283,11 @@ func parseTags(cmd *cobra.Command, absPath string) ([]TagInfo, error) {
 	return tags, nil
 }
 
-// parseTag parses a tag in the format namespace/name:tag or namespace/name (defaults to :latest)
+// parseTag parses a tag in the format namespace/name:tag or namespace/name (defaults to :latest).
+// A tag without a namespace is in the default namespace.
 func parseTag(tag string) (namespace, name, tagValue string, err error) {
 	// Split namespace and name from tag
-	parts := strings.Split(tag, "/")
+	parts := strings.Split(globalConfig.ExpandReference(tag), "/")
 	if len(parts) != 2 {
 		return "", "", "", fmt.Errorf("invalid tag format: %s (expected namespace/name or namespace/name:tag)", tag)
 	}
@@ -266,45 +314,30 @@ func parseTag(tag string) (namespace, name, tagValue string, err error) {
 		return "", "", "", fmt.Errorf("invalid tag format: %s (all parts must be non-empty)", tag)
 	}
 
+	if err := validation.ValidateFunction(namespace, name); err != nil {
+		return "", "", "", err
+	}
+	if err := validation.ValidateTag(tagValue); err != nil {
+		return "", "", "", err
+	}
+
 	return namespace, name, tagValue, nil
 }
 
 // runBuild executes the build process and updates the spinner with progress.
 func runBuild(program *tea.Program, absPath string, tags []TagInfo,
-	functionConfig manifest.FunctionManifest, client api.Client) {
+	functionConfig manifest.FunctionManifest, opts types.BuildOptions, client api.Client) {
 	buildStart := time.Now()
 	var finalResult *types.BuildResult
 
 	// Send build requests for each tag
 	for _, tagInfo := range tags {
-		// Create build request
-		req := api.BuildRequest{
-			BaseRequest: api.BaseRequest{
-				Namespace: tagInfo.Namespace,
-				Name:      tagInfo.Name,
-			},
-			Path:     absPath,
-			Tag:      tagInfo.Tag,
-			Manifest: functionConfig,
-		}
-
-		// Send build request
-		result, err := client.BuildFunction(context.Background(), req)
+		result, err := buildTag(client, absPath, tagInfo, functionConfig, opts)
 		if err != nil {
 			program.Send(err)
 			return
 		}
-
-		// Convert models.BuildResult to types.BuildResult
-		typesResult := &types.BuildResult{
-			Name:      result.BuildResult.Name,
-			Namespace: result.BuildResult.Namespace,
-			Digest:    result.BuildResult.Digest,
-			BuildTime: result.BuildResult.BuildTime,
-			Tag:       result.BuildResult.Tag,
-			Reused:    result.BuildResult.Reused,
-		}
-		finalResult = typesResult
+		finalResult = result
 	}
 
 	if finalResult != nil {
@@ -313,6 +346,111 @@ func runBuild(program *tea.Program, absPath string, tags []TagInfo,
 	}
 }
 
+// runVerboseBuild builds the function for each tag like runBuild, printing the output
+// of the toolchain to stderr while each build runs.
+func runVerboseBuild(absPath string, tags []TagInfo, functionConfig manifest.FunctionManifest,
+	opts types.BuildOptions, client api.Client) (*types.BuildResult, error) {
+	buildStart := time.Now()
+	var finalResult *types.BuildResult
+
+	for _, tagInfo := range tags {
+		ui.PrintInfo("Building", fmt.Sprintf("%s/%s:%s", tagInfo.Namespace, tagInfo.Name, tagInfo.Tag))
+
+		// The engine keeps the output under an ID chosen here, so it can be read while the build runs
+		opts.BuildID = newBuildID()
+		type outcome struct {
+			result *types.BuildResult
+			err    error
+		}
+		done := make(chan outcome, 1)
+		go func() {
+			result, err := buildTag(client, absPath, tagInfo, functionConfig, opts)
+			done <- outcome{result, err}
+		}()
+
+		ticker := time.NewTicker(buildLogInterval)
+		var offset int64
+		var out outcome
+	poll:
+		for {
+			select {
+			case out = <-done:
+				break poll
+			case <-ticker.C:
+				offset = printBuildLog(client, opts.BuildID, offset)
+			}
+		}
+		ticker.Stop()
+
+		// Print what was written since the last poll
+		printBuildLog(client, opts.BuildID, offset)
+		if out.err != nil {
+			return nil, out.err
+		}
+		finalResult = out.result
+	}
+
+	if finalResult == nil {
+		return nil, errors.New("no tags to build")
+	}
+	finalResult.BuildTime = time.Since(buildStart).String()
+	return finalResult, nil
+}
+
+// buildLogInterval is how often the output of a verbose build is read from the engine
+const buildLogInterval = 500 * time.Millisecond
+
+// printBuildLog prints the output of a build from offset and returns the offset to read
+// from next. The build may not have started yet, so failures to read are not reported.
+func printBuildLog(client api.Client, buildID string, offset int64) int64 {
+	log, err := client.GetBuildLog(context.Background(), buildID, offset)
+	if err != nil {
+		return offset
+	}
+	fmt.Fprint(os.Stderr, log.Output)
+	return log.Size
+}
+
+// buildTag sends the build request of one tag.
+func buildTag(client api.Client, absPath string, tagInfo TagInfo, functionConfig manifest.FunctionManifest,
+	opts types.BuildOptions) (*types.BuildResult, error) {
+	req := api.BuildRequest{
+		BaseRequest: api.BaseRequest{
+			Namespace: tagInfo.Namespace,
+			Name:      tagInfo.Name,
+		},
+		Path:         absPath,
+		Tag:          tagInfo.Tag,
+		Manifest:     functionConfig,
+		Reproducible: opts.Reproducible,
+		Debug:        opts.Debug,
+		BuildID:      opts.BuildID,
+	}
+
+	result, err := client.BuildFunction(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert models.BuildResult to types.BuildResult
+	return &types.BuildResult{
+		Name:      result.BuildResult.Name,
+		Namespace: result.BuildResult.Namespace,
+		Digest:    result.BuildResult.Digest,
+		BuildTime: result.BuildResult.BuildTime,
+		Tag:       result.BuildResult.Tag,
+		Reused:    result.BuildResult.Reused,
+		BuildID:   result.BuildResult.BuildID,
+	}, nil
+}
+
+// newBuildID returns a random ID for a build.
+func newBuildID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
 // displayBuildResults shows the build results to the user.
 func displayBuildResults(result types.BuildResult, tags []TagInfo) {
 	ui.PrintSuccess("Function built successfully")
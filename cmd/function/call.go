@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"time"
+	"unicode/utf8"
 
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
 	"github.com/ignitionstack/ignition/pkg/engine/api"
 	"github.com/ignitionstack/ignition/pkg/engine/client"
 	"github.com/spf13/cobra"
@@ -16,8 +18,11 @@ import (
 var (
 	entrypoint     string
 	payload        string
+	payloadFile    string
 	callSocketPath string
 	callConfigFlag []string
+	callEnvFiles   []string
+	callTimeout    time.Duration
 )
 
 func NewFunctionCallCommand() *cobra.Command {
@@ -44,6 +49,12 @@ The command requires a running engine to execute the function.`,
   # Call with a specific entrypoint
   ignition call default/hello-world:latest --entrypoint greet --payload '{"name": "World"}'
 
+  # Send the raw bytes of a file as the payload
+  ignition call default/resize:latest --payload-file photo.jpg > thumbnail.jpg
+
+  # Give up if the call takes longer than two seconds
+  ignition call default/hello-world:latest --timeout 2s
+
   # Call using function digest instead of tag
   ignition call default/hello-world:d7a8fbb307d7809469ca9abcb0082e4f`,
 		Args: cobra.ExactArgs(1),
@@ -53,19 +64,22 @@ The command requires a running engine to execute the function.`,
 				return fmt.Errorf("invalid function name format: %w", err)
 			}
 
-			// Parse config flag values into a map
-			config := make(map[string]string)
-			for _, configItem := range callConfigFlag {
-				parts := splitKeyValue(configItem)
-				if len(parts) == 2 {
-					config[parts[0]] = parts[1]
-				}
+			config, err := functionConfig(callEnvFiles, callConfigFlag)
+			if err != nil {
+				return err
+			}
+
+			binaryPayload, err := readPayloadFile(payloadFile)
+			if err != nil {
+				return err
 			}
 
 			// Create engine client
-			engineClient, err := client.New(client.Options{
-				SocketPath: callSocketPath,
-			})
+			clientOptions, err := globalConfig.ClientOptions(callSocketPath)
+			if err != nil {
+				return err
+			}
+			engineClient, err := client.New(clientOptions)
 			if err != nil {
 				return fmt.Errorf("failed to create engine client: %w", err)
 			}
@@ -76,10 +90,12 @@ The command requires a running engine to execute the function.`,
 					Namespace: namespace,
 					Name:      name,
 				},
-				Reference:  reference,
-				Entrypoint: entrypoint,
-				Payload:    payload,
-				Config:     config,
+				Reference:     reference,
+				Entrypoint:    entrypoint,
+				Payload:       payload,
+				BinaryPayload: binaryPayload,
+				Config:        config,
+				Timeout:       callTimeout,
 			}
 
 			// Call function
@@ -87,38 +103,43 @@ The command requires a running engine to execute the function.`,
 			if err != nil {
 				return fmt.Errorf("failed to call function: %w", err)
 			}
-
-			// Check if output looks like JSON
-			if isJSON(output) {
-				var prettyJSON bytes.Buffer
-				if err := json.Indent(&prettyJSON, output, "", "  "); err == nil {
-					fmt.Println(prettyJSON.String())
-					return nil
-				}
-			}
-
-			// Otherwise print as string
-			fmt.Println(string(output))
-			return nil
+			return printOutput(output)
 		},
 	}
 
 	cmd.Flags().StringVarP(&entrypoint, "entrypoint", "e", "handler", "the entrypoint wasm function")
 	cmd.Flags().StringVarP(&payload, "payload", "p", "", "the payload to send to the entrypoint")
+	cmd.Flags().StringVar(&payloadFile, "payload-file", "", "send the raw content of a file as the payload (- reads stdin)")
+	cmd.MarkFlagsMutuallyExclusive("payload", "payload-file")
 
-	// Use the default socket path
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
-	defaultSocketPath := filepath.Join(homeDir, ".ignition", "engine.sock")
-
-	cmd.Flags().StringVarP(&callSocketPath, "socket", "s", defaultSocketPath, "Path to the Unix socket")
+	cmd.Flags().StringVarP(&callSocketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	cmd.Flags().DurationVar(&callTimeout, "timeout", 0, "Deadline for the call, capped at the engine's default timeout (0 uses the default)")
 	cmd.Flags().StringArrayVarP(&callConfigFlag, "config", "c", []string{}, "Configuration values to pass to the function (format: key=value)")
+	cmd.Flags().StringArrayVar(&callEnvFiles, "env-file", []string{}, "Read configuration values from a file of KEY=VALUE lines (repeatable, --config takes precedence)")
 
 	return cmd
 }
 
+// printOutput prints the output of a call, indented if it is JSON. Binary output is
+// written unchanged, so it can be redirected to a file.
+func printOutput(output []byte) error {
+	if isJSON(output) {
+		var prettyJSON bytes.Buffer
+		if err := json.Indent(&prettyJSON, output, "", "  "); err == nil {
+			fmt.Println(prettyJSON.String())
+			return nil
+		}
+	}
+
+	if !utf8.Valid(output) {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+
+	fmt.Println(string(output))
+	return nil
+}
+
 // isJSON checks if a byte slice contains valid JSON
 func isJSON(data []byte) bool {
 	var js interface{}
@@ -0,0 +1,73 @@
+package function
+
+import (
+	"context"
+	"fmt"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+func NewFunctionCallLoggingCommand() *cobra.Command {
+	var socketPath string
+	var service bool
+
+	cmd := &cobra.Command{
+		Use:   "call-logging [namespace/name] [all|quiet|1/N]",
+		Short: "Set which calls of a running function are logged",
+		Long: `Set which calls of a running function write entries to its logs.
+
+Every call writes an entry when it starts and another when it succeeds, which floods the
+log store of functions called many times a second. 1/N logs one call in N, and quiet
+logs none. Failed calls log their error whatever the setting.
+
+The setting takes effect immediately and lasts until the function is loaded again;
+use --call-logging on ignition run to choose it at load time.`,
+		Example: `  # Log one call in a hundred
+  ignition function call-logging my_namespace/my_function 1/100
+
+  # Only log failed calls of the function behind a compose service
+  ignition function call-logging --service api quiet
+
+  # Log every call again
+  ignition function call-logging my_namespace/my_function all`,
+		Args:          cobra.ExactArgs(2),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			policy, err := types.ParseCallLogging(args[1])
+			if err != nil {
+				return err
+			}
+
+			engineClient, err := globalConfig.NewEngineClient(socketPath)
+			if err != nil {
+				return fmt.Errorf("failed to create engine client: %w", err)
+			}
+
+			target := args[0]
+			if service {
+				err = engineClient.SetServiceCallLogging(context.Background(), target, policy.String())
+			} else {
+				namespace, name, _, parseErr := parseNamespaceAndName(target)
+				if parseErr != nil {
+					return fmt.Errorf("invalid function name format: %w", parseErr)
+				}
+				target = namespace + "/" + name
+				err = engineClient.SetCallLogging(context.Background(), namespace, name, policy.String())
+			}
+			if err != nil {
+				return err
+			}
+
+			ui.PrintSuccess(fmt.Sprintf("Call logging of %s set to %s", target, policy))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&socketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	cmd.Flags().BoolVar(&service, "service", false, "Address the function by compose service name")
+	return cmd
+}
@@ -0,0 +1,131 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// maxDLQPayloadWidth truncates payloads in the table view
+const maxDLQPayloadWidth = 40
+
+// NewFunctionDLQCommand creates a command to inspect, re-drive or purge a function's dead letters.
+func NewFunctionDLQCommand() *cobra.Command {
+	var (
+		dlqSocketPath string
+		redrive       bool
+		purge         bool
+		ids           []string
+		plain         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "dlq [namespace/name]",
+		Short: "Inspect, re-drive or purge failed calls of a function",
+		Long: `Manage the dead letter store of a function.
+
+When engine.dead_letter.enabled is set, the engine keeps the payload and error of
+every failed call (errors, timeouts and calls rejected by an open circuit breaker).
+Without flags the captured calls are listed. --redrive replays them and removes the
+ones that succeed; --purge removes them. Use --id to select specific entries.`,
+		Example: `  # List failed calls
+  ignition function dlq my-namespace/my-function
+
+  # Replay every failed call
+  ignition function dlq my-namespace/my-function --redrive
+
+  # Remove one entry
+  ignition function dlq my-namespace/my-function --purge --id 1842f0c3a1b2c3d400000001`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if redrive && purge {
+				return fmt.Errorf("--redrive and --purge cannot be used together")
+			}
+
+			namespace, name, err := parseNamespaceAndNameWithoutTag(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid function name format: %w", err)
+			}
+
+			engineClient, err := globalConfig.NewEngineClient(dlqSocketPath)
+			if err != nil {
+				return fmt.Errorf("failed to create engine client: %w", err)
+			}
+			ctx := context.Background()
+
+			switch {
+			case redrive:
+				result, err := engineClient.RedriveDeadLetters(ctx, namespace, name, ids)
+				if err != nil {
+					return fmt.Errorf("failed to re-drive dead letters: %w", err)
+				}
+				for _, r := range result.Results {
+					if r.Error != "" {
+						ui.PrintError(fmt.Sprintf("%s: %s", r.ID, r.Error))
+					}
+				}
+				ui.PrintSuccess(fmt.Sprintf("Re-drove %d calls: %d succeeded, %d failed",
+					len(result.Results), result.Succeeded, result.Failed))
+				return nil
+
+			case purge:
+				removed, err := engineClient.PurgeDeadLetters(ctx, namespace, name, ids)
+				if err != nil {
+					return fmt.Errorf("failed to purge dead letters: %w", err)
+				}
+				ui.PrintSuccess(fmt.Sprintf("Removed %d dead letter entries", removed))
+				return nil
+			}
+
+			entries, err := engineClient.ListDeadLetters(ctx, namespace, name)
+			if err != nil {
+				return fmt.Errorf("failed to list dead letters: %w", err)
+			}
+
+			if plain {
+				for _, entry := range entries {
+					fmt.Printf("%s\t%s\t%s\t%s\t%d\t%s\t%s\n", entry.ID, entry.Timestamp.Format(time.RFC3339),
+						entry.Entrypoint, entry.Reason, entry.Attempts, entry.Error, entry.Payload)
+				}
+				return nil
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No failed calls captured")
+				return nil
+			}
+
+			table := ui.NewTable([]string{"ID", "TIME", "ENTRYPOINT", "REASON", "ATTEMPTS", "ERROR", "PAYLOAD"})
+			for _, entry := range entries {
+				table.AddRow(entry.ID, entry.Timestamp.Local().Format(time.DateTime), entry.Entrypoint,
+					entry.Reason, fmt.Sprintf("%d", entry.Attempts), entry.Error, truncate(entry.Payload, maxDLQPayloadWidth))
+			}
+			fmt.Println(ui.RenderTable(table))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&dlqSocketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	cmd.Flags().BoolVar(&redrive, "redrive", false, "Replay the captured calls")
+	cmd.Flags().BoolVar(&purge, "purge", false, "Remove the captured calls")
+	cmd.Flags().StringSliceVar(&ids, "id", nil, "Only re-drive or purge these entries (repeatable)")
+	cmd.Flags().BoolVar(&plain, "plain", false, "Output in plain, machine-readable format")
+
+	return cmd
+}
+
+// truncate shortens s to at most width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
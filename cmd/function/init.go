@@ -2,6 +2,7 @@ package function
 
 import (
 	"fmt"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
@@ -13,15 +14,34 @@ import (
 )
 
 var language string
+var kind string
+var initProxy string
+var initOffline bool
 
 func NewFunctionInitCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init [name]",
 		Short: "Initialize a new function",
-		Args:  cobra.MaximumNArgs(1),
-		RunE:  functionInit,
+		Long: `Initialize a new function from the Extism PDK template of a language.
+
+With --kind http, the function is scaffolded as an HTTP handler: its template routes
+requests by method and path, and its manifest enables http_request and http_envelope so
+it receives whole requests and controls its responses. The http kind is available for
+golang, typescript and javascript functions.
+
+The template is downloaded through the proxy named by --proxy, or else by HTTPS_PROXY,
+and kept in ~/.ignition/templates, which is used when the download fails. With --offline
+nothing is downloaded: the kept template is used, or else the basic template of the
+language bundled with ignition.`,
+		Example: `  # A web backend in Go
+  ignition init my_api --language golang --kind http`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: functionInit,
 	}
 	cmd.Flags().StringVarP(&language, "language", "l", "", "Programming language")
+	cmd.Flags().StringVar(&kind, "kind", services.KindDefault, fmt.Sprintf("Kind of function to scaffold (%s)", strings.Join(services.Kinds, ", ")))
+	cmd.Flags().StringVar(&initProxy, "proxy", "", "Proxy URL to download the template through (defaults to HTTPS_PROXY)")
+	cmd.Flags().BoolVar(&initOffline, "offline", false, "Create the function from the cached or bundled template without network access")
 
 	return cmd
 }
@@ -64,8 +84,8 @@ func functionInit(_ *cobra.Command, args []string) error {
 
 	go func() {
 		p.Send("Initializing function...")
-		service := services.NewFunctionService()
-		err := service.InitFunction(name, language)
+		service := services.NewFunctionServiceWithOptions(services.FunctionServiceOptions{Offline: initOffline})
+		err := service.InitFunction(name, language, kind, initProxy)
 		if err != nil {
 			p.Send(fmt.Errorf("error initializing function: %w", err))
 			return
@@ -1,24 +1,22 @@
 package function
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"net"
-	"net/http"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
 	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/client"
 	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/validation"
 	"github.com/spf13/cobra"
 )
 
 func NewFunctionListCommand() *cobra.Command {
-	var socketPath string
+	var source registrySource
 	cmd := &cobra.Command{
 		Use:     "list [namespace/name]",
 		Aliases: []string{"ls"},
@@ -34,7 +32,13 @@ of that specific function including:
 * Size
 
 The registry contains all functions that have been built or loaded, and this
-command allows you to explore what's available to run.`,
+command allows you to explore what's available to run.
+
+Functions are listed through the engine. When it cannot be reached, or with --offline,
+the registry in --directory is read directly, without locking out other readers.
+This only works while no engine holds the registry: a running engine locks its
+database, so a registry in use is reported with the pid of the engine holding it
+and must be listed through that engine, for instance with --socket.`,
 		Example: `  # List all available functions
   ignition function list
 
@@ -42,66 +46,49 @@ command allows you to explore what's available to run.`,
   ignition function list my-namespace/my-function
   
   # List in plain format (useful for scripting)
-  ignition function list --plain`,
+  ignition function list --plain
+
+  # List the registry without contacting the engine
+  ignition function list --offline`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Check if output should be machine-readable
 			plainFormat, _ := cmd.Flags().GetBool("plain")
 
-			var req map[string]string
-
 			if len(args) == 1 {
 				namespace, name, err := parseNamespaceAndNameWithoutTag(args[0])
 				if err != nil {
 					return fmt.Errorf("invalid function name format: %w", err)
 				}
 
-				req = map[string]string{
-					"namespace": namespace,
-					"name":      name,
-				}
-			} else {
-				req = map[string]string{}
-			}
-
-			reqBody, err := json.Marshal(req)
-			if err != nil {
-				return fmt.Errorf("failed to encode request: %w", err)
-			}
-
-			client := http.Client{
-				Transport: &http.Transport{
-					Dial: func(_, _ string) (net.Conn, error) {
-						return net.Dial("unix", socketPath)
-					},
-				},
-			}
-
-			resp, err := client.Post("http://unix/list", "application/json", bytes.NewBuffer(reqBody))
-			if err != nil {
-				return fmt.Errorf("failed to send request to engine: %w", err)
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusOK {
-				return fmt.Errorf("engine returned error: %s", resp.Status)
-			}
-
-			if len(args) == 1 {
-				var metadata registry.FunctionMetadata
-				if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
-					return fmt.Errorf("failed to decode response: %w", err)
+				var metadata *registry.FunctionMetadata
+				err = source.read(func(engineClient *client.EngineClient) (err error) {
+					metadata, err = engineClient.GetRegistryFunction(context.Background(), namespace, name)
+					return err
+				}, func(reg registry.Registry) (err error) {
+					metadata, err = reg.Get(namespace, name)
+					return err
+				})
+				if err != nil {
+					return fmt.Errorf("failed to fetch function: %w", err)
 				}
 
 				if plainFormat {
-					renderFunctionMetadataPlain(metadata)
+					renderFunctionMetadataPlain(*metadata)
 				} else {
-					renderFunctionMetadata(metadata)
+					renderFunctionMetadata(*metadata)
 				}
 			} else {
 				var metadataList []registry.FunctionMetadata
-				if err := json.NewDecoder(resp.Body).Decode(&metadataList); err != nil {
-					return fmt.Errorf("failed to decode response: %w", err)
+				err := source.read(func(engineClient *client.EngineClient) (err error) {
+					metadataList, err = engineClient.ListRegistryFunctions(context.Background())
+					return err
+				}, func(reg registry.Registry) (err error) {
+					metadataList, err = reg.ListAll()
+					return err
+				})
+				if err != nil {
+					return fmt.Errorf("failed to list functions: %w", err)
 				}
 
 				if plainFormat {
@@ -115,20 +102,13 @@ command allows you to explore what's available to run.`,
 		},
 	}
 
-	// Use the default socket path
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
-	defaultSocketPath := filepath.Join(homeDir, ".ignition", "engine.sock")
-
-	cmd.Flags().StringVarP(&socketPath, "socket", "s", defaultSocketPath, "Path to the Unix socket")
+	addRegistrySourceFlags(cmd, &source)
 	cmd.Flags().Bool("plain", false, "Output in plain, machine-readable format (useful for piping to other commands)")
 	return cmd
 }
 
 func parseNamespaceAndNameWithoutTag(input string) (namespace, name string, err error) {
-	parts := strings.Split(input, "/")
+	parts := strings.Split(globalConfig.ExpandReference(input), "/")
 	if len(parts) != 2 {
 		return "", "", errors.New("invalid format: expected namespace/name")
 	}
@@ -140,6 +120,10 @@ func parseNamespaceAndNameWithoutTag(input string) (namespace, name string, err
 		return "", "", errors.New("namespace and name cannot be empty")
 	}
 
+	if err := validation.ValidateFunction(namespace, name); err != nil {
+		return "", "", err
+	}
+
 	return namespace, name, nil
 }
 
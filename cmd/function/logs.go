@@ -0,0 +1,69 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/spf13/cobra"
+)
+
+func NewFunctionLogsCommand() *cobra.Command {
+	var socketPath string
+	var since time.Duration
+	var tail int
+	var levels []string
+
+	cmd := &cobra.Command{
+		Use:   "logs [namespace/name]",
+		Short: "Show the logs of a running function",
+		Long: `Show the logs of a function loaded in the engine, oldest first.
+
+--level keeps only the entries at the given levels (debug, info, warning or error);
+repeat it or separate levels with commas to keep several. The level filter applies
+before --tail, so --level error --tail 20 shows the last 20 errors.`,
+		Example: `  # Show the last 100 log lines
+  ignition function logs my_namespace/my_function
+
+  # Show only errors from the last hour
+  ignition function logs my_namespace/my_function --level error --since 1h
+
+  # Show errors and warnings
+  ignition function logs my_namespace/my_function --level error,warning`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			namespace, name, _, err := parseNamespaceAndName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid function name format: %w", err)
+			}
+			if _, err := logging.ParseLogLevels(levels); err != nil {
+				return err
+			}
+
+			engineClient, err := globalConfig.NewEngineClient(socketPath)
+			if err != nil {
+				return fmt.Errorf("failed to create engine client: %w", err)
+			}
+
+			logs, err := engineClient.GetFunctionLogs(context.Background(), namespace, name, since, tail, levels...)
+			if err != nil {
+				return fmt.Errorf("failed to get logs: %w", err)
+			}
+
+			for _, line := range logs {
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&socketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	cmd.Flags().DurationVar(&since, "since", 0, "Only show logs from this long ago (e.g. 30m)")
+	cmd.Flags().IntVar(&tail, "tail", 100, "Number of lines to show from the end of the logs (0 for all)")
+	cmd.Flags().StringSliceVarP(&levels, "level", "l", nil, "Only show logs at these levels: debug, info, warning, error")
+	return cmd
+}
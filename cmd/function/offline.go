@@ -0,0 +1,101 @@
+package function
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	localRegistry "github.com/ignitionstack/ignition/pkg/registry/local"
+	"github.com/spf13/cobra"
+)
+
+// registrySource is where a read-only command reads the registry: through the engine,
+// or from the registry directory when the engine cannot be reached or --offline is set.
+// The engine config gives the key of an encrypted registry directory.
+type registrySource struct {
+	socketPath  string
+	registryDir string
+	configPath  string
+	offline     bool
+}
+
+// addRegistrySourceFlags registers the flags choosing where a command reads the registry.
+func addRegistrySourceFlags(cmd *cobra.Command, source *registrySource) {
+	cmd.Flags().StringVarP(&source.socketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	cmd.Flags().StringVarP(&source.registryDir, "directory", "d", config.DefaultConfig().Server.RegistryDir, "Registry directory read when the engine cannot be reached")
+	cmd.Flags().StringVar(&source.configPath, "engine-config", config.DefaultConfigPath, "Engine config file with the encryption settings of the registry directory")
+	cmd.Flags().BoolVar(&source.offline, "offline", false, "Read the registry directory without contacting the engine")
+}
+
+// read runs viaEngine against the engine, or viaRegistry against the registry directory
+// opened read-only when the engine cannot be reached or the source is offline.
+func (s registrySource) read(viaEngine func(*client.EngineClient) error, viaRegistry func(registry.Registry) error) error {
+	if s.offline {
+		return s.readLocal(true, viaRegistry)
+	}
+
+	engineClient, err := globalConfig.NewEngineClient(s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to create engine client: %w", err)
+	}
+	err = viaEngine(engineClient)
+	if isEngineUnreachable(err) {
+		return s.readLocal(false, viaRegistry)
+	}
+	return err
+}
+
+// isEngineUnreachable reports whether a request failed because the engine could not be
+// connected to, rather than with an error from the engine.
+func isEngineUnreachable(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// readLocal runs read on the registry directory, opened read-only and decrypted with the
+// key of the engine config. Unless offline was asked for, a note says the engine could
+// not be reached.
+func (s registrySource) readLocal(offline bool, read func(registry.Registry) error) error {
+	// Encrypted registries need the key configured for the engine. A missing config file
+	// leaves defaults and the environment, as a read must not create one on the way
+	configPath := s.configPath
+	if _, err := os.Stat(config.ExpandHome(configPath)); errors.Is(err, os.ErrNotExist) {
+		configPath = ""
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	key, err := cfg.Registry.Encryption.LoadKey(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to load registry encryption key: %w", err)
+	}
+
+	opened := false
+	err = localRegistry.ReadRegistry(s.registryDir, key, func(reg registry.Registry) error {
+		opened = true
+		if !offline {
+			fmt.Fprintf(os.Stderr, "The engine cannot be reached, reading the registry in %s\n", s.registryDir)
+		}
+		return read(reg)
+	})
+	switch {
+	case opened:
+		return err
+	case offline && errors.Is(err, localRegistry.ErrDatabaseLocked):
+		return fmt.Errorf("the registry is in use (%w); read it through the engine instead", err)
+	case offline && err != nil:
+		return err
+	case errors.Is(err, localRegistry.ErrDatabaseLocked):
+		return fmt.Errorf("the engine cannot be reached, but its registry is in use (%w); check the engine socket or context", err)
+	case err != nil:
+		return fmt.Errorf("the engine cannot be reached: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package function
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+)
+
+const replHelp = `Each line is sent as the payload of a call to the current entrypoint. A line
+starting with { or [ may continue over several lines until the JSON document is complete.
+
+Commands:
+  :entrypoint NAME   call NAME from now on (:e for short)
+  :help              show this help
+  :quit              leave (or press Ctrl+D)`
+
+// repl sends payloads read line by line to an entrypoint of a loaded function and
+// pretty-prints the responses.
+type repl struct {
+	engineClient *client.EngineClient
+	namespace    string
+	name         string
+	entrypoint   string
+	out          io.Writer
+}
+
+// run reads from in until it is exhausted or :quit is entered. A failed call is
+// reported and the session goes on.
+func (r *repl) run(ctx context.Context, in io.Reader) error {
+	fmt.Fprintln(r.out, ui.DimStyle.Render("Type :help for help, :quit or Ctrl+D to leave."))
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var pending strings.Builder
+	for {
+		if pending.Len() == 0 {
+			fmt.Fprintf(r.out, "%s/%s:%s> ", r.namespace, r.name, r.entrypoint)
+		} else {
+			fmt.Fprint(r.out, "... ")
+		}
+		if !scanner.Scan() {
+			fmt.Fprintln(r.out)
+			return scanner.Err()
+		}
+		line := scanner.Text()
+
+		if pending.Len() == 0 {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, ":") {
+				if quit := r.command(trimmed); quit {
+					return nil
+				}
+				continue
+			}
+			if trimmed == "" {
+				continue
+			}
+		} else {
+			pending.WriteByte('\n')
+		}
+		pending.WriteString(line)
+
+		// JSON documents are sent once complete
+		payload := pending.String()
+		if trimmed := strings.TrimSpace(payload); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			if !json.Valid([]byte(trimmed)) && !jsonMalformed(trimmed) {
+				continue
+			}
+		}
+		pending.Reset()
+
+		r.call(ctx, []byte(payload))
+	}
+}
+
+// command runs a REPL command and reports whether the session should end.
+func (r *repl) command(line string) bool {
+	command, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch command {
+	case ":quit", ":q", ":exit":
+		return true
+	case ":help", ":h":
+		fmt.Fprintln(r.out, replHelp)
+	case ":entrypoint", ":e":
+		if arg == "" {
+			fmt.Fprintf(r.out, "Current entrypoint: %s\n", r.entrypoint)
+		} else {
+			r.entrypoint = arg
+		}
+	default:
+		ui.PrintError(fmt.Sprintf("Unknown command %s, type :help for help", command))
+	}
+	return false
+}
+
+// call sends one payload and prints the response, indenting JSON responses.
+func (r *repl) call(ctx context.Context, payload []byte) {
+	start := time.Now()
+	output, err := r.engineClient.CallFunction(ctx, r.namespace, r.name, r.entrypoint, payload, nil)
+	elapsed := time.Since(start)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Call failed: %v", err))
+		return
+	}
+
+	if isJSON(output) {
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, output, "", "  ") == nil {
+			output = pretty.Bytes()
+		}
+	}
+	fmt.Fprintln(r.out, string(output))
+	fmt.Fprintln(r.out, ui.DimStyle.Render(fmt.Sprintf("(%s)", elapsed.Round(time.Microsecond))))
+}
+
+// jsonMalformed reports whether an incomplete-looking document already contains a
+// syntax error, so waiting for more lines cannot fix it.
+func jsonMalformed(document string) bool {
+	decoder := json.NewDecoder(strings.NewReader(document))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false
+		}
+		if err != nil {
+			return true
+		}
+	}
+}
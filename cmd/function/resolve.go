@@ -0,0 +1,135 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+func NewFunctionResolveCommand() *cobra.Command {
+	var source registrySource
+
+	cmd := &cobra.Command{
+		Use:   "resolve [namespace/name:reference]",
+		Short: "Show which version a digest, tag or semver range resolves to",
+		Long: `Show which version of a function the registry pulls for a reference.
+
+A reference is matched against digests first, then tags. Otherwise it is read as a
+semantic version range and resolves to the highest matching tag, skipping pre-releases.
+
+The registry is read through the engine, or from --directory when the engine cannot be
+reached or with --offline.`,
+		Example: `  # Highest 1.x release at or above 1.2.0
+  ignition function resolve my-namespace/my-function:^1.2
+
+  # Highest 1.2.x release
+  ignition function resolve my-namespace/my-function:~1.2
+
+  # Resolve against the registry directory without the engine
+  ignition function resolve my-namespace/my-function:latest --offline`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			namespace, name, reference, err := parseNamespaceAndName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid function name format: %w", err)
+			}
+
+			var metadata *registry.FunctionMetadata
+			err = source.read(func(engineClient *client.EngineClient) (err error) {
+				metadata, err = engineClient.GetRegistryFunction(context.Background(), namespace, name)
+				return err
+			}, func(reg registry.Registry) (err error) {
+				metadata, err = reg.Get(namespace, name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to fetch function: %w", err)
+			}
+
+			version, how, err := resolveReference(metadata.Versions, reference)
+			if err != nil {
+				return fmt.Errorf("%s/%s: %w", namespace, name, err)
+			}
+
+			ui.PrintInfo("Reference", reference)
+			ui.PrintInfo("Resolved by", how)
+			ui.PrintInfo("Digest", version.Hash)
+			ui.PrintInfo("Tags", strings.Join(version.Tags, ", "))
+			ui.PrintInfo("Created", version.CreatedAt.Format("2006-01-02 15:04:05"))
+			printProvenance(version.Provenance)
+			printVariants(version.Variants)
+			return nil
+		},
+	}
+
+	addRegistrySourceFlags(cmd, &source)
+	return cmd
+}
+
+// printProvenance shows how a version was built, for versions built by the engine.
+func printProvenance(provenance *registry.Provenance) {
+	if provenance == nil {
+		return
+	}
+
+	ui.PrintInfo("Built by", provenance.Builder)
+	ui.PrintInfo("Built", provenance.BuiltAt.Format("2006-01-02 15:04:05"))
+	ui.PrintInfo("Source hash", provenance.SourceHash)
+	ui.PrintInfo("Reproducible", strconv.FormatBool(provenance.Reproducible))
+	ui.PrintInfo("Debug build", strconv.FormatBool(provenance.Debug))
+
+	tools := make([]string, 0, len(provenance.Toolchain))
+	for tool := range provenance.Toolchain {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	for _, tool := range tools {
+		ui.PrintInfo("Toolchain "+tool, provenance.Toolchain[tool])
+	}
+	for _, flag := range provenance.Flags {
+		ui.PrintInfo("Flag", flag)
+	}
+}
+
+// printVariants lists the variant modules stored with a version.
+func printVariants(variants []registry.Variant) {
+	for _, variant := range variants {
+		kind := "module"
+		if variant.Component {
+			kind = "component"
+		}
+		ui.PrintInfo("Variant "+variant.Name, fmt.Sprintf("%s, %d bytes, %s", kind, variant.Size, variant.Checksum))
+	}
+}
+
+// resolveReference picks a version the same way the registry does on pull: by digest,
+// then by tag, then as the highest tag in a semver range.
+func resolveReference(versions []registry.VersionInfo, reference string) (*registry.VersionInfo, string, error) {
+	for i, version := range versions {
+		if version.Hash == reference {
+			return &versions[i], "digest", nil
+		}
+	}
+	for i, version := range versions {
+		if registry.HasTag(version.Tags, reference) {
+			return &versions[i], "tag", nil
+		}
+	}
+
+	versionRange, err := registry.ParseVersionRange(reference)
+	if err != nil {
+		return nil, "", fmt.Errorf("no digest or tag %q", reference)
+	}
+	version, tag, ok := registry.ResolveVersionRange(versions, versionRange)
+	if !ok {
+		return nil, "", fmt.Errorf("no tag matches version range %q", reference)
+	}
+	return version, fmt.Sprintf("semver range (highest match: %s)", tag), nil
+}
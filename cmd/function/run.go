@@ -1,20 +1,17 @@
 package function
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net"
-	"net/http"
 	"os"
-	"path/filepath"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
 	"github.com/ignitionstack/ignition/internal/ui"
 	"github.com/ignitionstack/ignition/internal/ui/models/spinner"
-	"github.com/ignitionstack/ignition/pkg/engine"
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/engine/components"
 	"github.com/ignitionstack/ignition/pkg/types"
 	"github.com/spf13/cobra"
 )
@@ -22,17 +19,61 @@ import (
 func NewFunctionRunCommand() *cobra.Command {
 	var runSocketPath string
 	var runConfigFlag []string
+	var envFiles []string
+	var logMaxEntries int
+	var logMaxAge time.Duration
+	var reloadPolicy string
+	var priority string
+	var maxQueueWait time.Duration
+	var callLogging string
+	var variant string
+	var interactive bool
+	var entrypoint string
+	var payload string
 	cmd := &cobra.Command{
-		Use:           "run [namespace/name:identifier]",
-		Short:         "Load and optionally run a WASM file from the registry on the engine",
+		Use:   "run [namespace/name:identifier]",
+		Short: "Load and optionally run a WASM file from the registry on the engine",
+		Long: `Load a function from the registry on the engine, even if it was stopped.
+
+With --payload, run then calls an entrypoint of the loaded function once and prints the
+response, like ignition call but on the loaded instances.
+
+With --interactive, run then reads payloads from the terminal, one per line, calls an
+entrypoint of the function with each and pretty-prints the responses. JSON documents
+may span several lines. Type :help in the session for its commands.`,
+		Example: `  # Load a function
+  ignition run my_namespace/my_function:latest
+
+  # Load it and call its greet entrypoint
+  ignition run my_namespace/my_function:latest --entrypoint greet --payload '{"name": "World"}'
+
+  # Load it and try payloads against its greet entrypoint
+  ignition run my_namespace/my_function:latest --interactive --entrypoint greet`,
 		Args:          cobra.ExactArgs(1),
 		SilenceErrors: true,
 		SilenceUsage:  true,
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(c *cobra.Command, args []string) error {
+			callOnce := c.Flags().Changed("payload")
+			if callOnce && interactive {
+				return fmt.Errorf("--payload and --interactive cannot be combined")
+			}
 			namespace, name, identifier, err := parseNamespaceAndName(args[0])
 			if err != nil {
 				return fmt.Errorf("invalid function name format: %w", err)
 			}
+			if _, err := types.ParseReloadPolicy(reloadPolicy); err != nil {
+				return err
+			}
+			if _, err := components.ParsePriority(priority); err != nil {
+				return err
+			}
+			if _, err := types.ParseCallLogging(callLogging); err != nil {
+				return err
+			}
+			config, err := functionConfig(envFiles, runConfigFlag)
+			if err != nil {
+				return err
+			}
 
 			spinnerModel := spinner.NewSpinnerModelWithMessage("Loading...")
 			p := tea.NewProgram(spinnerModel)
@@ -40,55 +81,23 @@ func NewFunctionRunCommand() *cobra.Command {
 			go func() {
 				loadStart := time.Now()
 
-				// Parse config flag values into a map
-				config := make(map[string]string)
-				for _, configItem := range runConfigFlag {
-					parts := splitKeyValue(configItem)
-					if len(parts) == 2 {
-						config[parts[0]] = parts[1]
-					}
-				}
-
-				req := map[string]interface{}{
-					"namespace":  namespace,
-					"name":       name,
-					"digest":     identifier,
-					"force_load": true, // Always force load when running with function run command
-				}
-
-				// Only add config if there are values
-				if len(config) > 0 {
-					req["config"] = config
-				}
-
-				reqBody, err := json.Marshal(req)
+				engineClient, err := globalConfig.NewEngineClient(runSocketPath)
 				if err != nil {
-					p.Send(fmt.Errorf("failed to encode request: %w", err))
+					p.Send(fmt.Errorf("failed to create engine client: %w", err))
 					return
 				}
 
-				client := http.Client{
-					Transport: &http.Transport{
-						DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-							return net.Dial("unix", runSocketPath)
-						},
-					},
-				}
-
-				resp, err := client.Post("http://unix/load", "application/json", bytes.NewBuffer(reqBody))
-				if err != nil {
-					p.Send(fmt.Errorf("failed to send request to engine: %w", err))
-					return
-				}
-				defer resp.Body.Close()
-
-				if resp.StatusCode != http.StatusOK {
-					var errResp engine.RequestError
-					if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-						p.Send(fmt.Errorf("failed to decode error response: %w", err))
-						return
-					}
-					p.Send(errResp)
+				// LoadFunction always force loads, so stopped functions can be run again
+				if err := engineClient.LoadFunctionWithOptions(context.Background(), namespace, name, identifier, config, client.LoadOptions{
+					LogMaxEntries: logMaxEntries,
+					LogMaxAge:     logMaxAge,
+					ReloadPolicy:  reloadPolicy,
+					Priority:      priority,
+					MaxQueueWait:  maxQueueWait,
+					CallLogging:   callLogging,
+					Variant:       variant,
+				}); err != nil {
+					p.Send(err)
 					return
 				}
 
@@ -116,18 +125,49 @@ func NewFunctionRunCommand() *cobra.Command {
 			}
 
 			ui.PrintSuccess("Function loaded successfully")
+
+			if callOnce {
+				engineClient, err := globalConfig.NewEngineClient(runSocketPath)
+				if err != nil {
+					return fmt.Errorf("failed to create engine client: %w", err)
+				}
+				output, err := engineClient.CallFunction(context.Background(), namespace, name, entrypoint, []byte(payload), nil)
+				if err != nil {
+					return fmt.Errorf("failed to call function: %w", err)
+				}
+				return printOutput(output)
+			}
+
+			if interactive {
+				engineClient, err := globalConfig.NewEngineClient(runSocketPath)
+				if err != nil {
+					return fmt.Errorf("failed to create engine client: %w", err)
+				}
+				session := &repl{
+					engineClient: engineClient,
+					namespace:    namespace,
+					name:         name,
+					entrypoint:   entrypoint,
+					out:          os.Stdout,
+				}
+				return session.run(context.Background(), os.Stdin)
+			}
 			return nil
 		},
 	}
 
-	// Use the default socket path
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
-	defaultSocketPath := filepath.Join(homeDir, ".ignition", "engine.sock")
-
-	cmd.Flags().StringVarP(&runSocketPath, "socket", "s", defaultSocketPath, "Path to the Unix socket")
+	cmd.Flags().StringVarP(&runSocketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
 	cmd.Flags().StringArrayVarP(&runConfigFlag, "config", "c", []string{}, "Configuration values to pass to the function (format: key=value)")
+	cmd.Flags().StringArrayVar(&envFiles, "env-file", []string{}, "Read configuration values from a file of KEY=VALUE lines (repeatable, --config takes precedence)")
+	cmd.Flags().IntVar(&logMaxEntries, "log-max-entries", 0, "Maximum number of log entries the engine keeps for the function (0 uses the engine default)")
+	cmd.Flags().DurationVar(&logMaxAge, "log-max-age", 0, "How long the engine keeps log entries of the function (0 uses the engine default)")
+	cmd.Flags().StringVar(&reloadPolicy, "reload-policy", "", "Version to load when the engine reloads the function after eviction: latest, pinned, tag:<tag> or a semver range such as ~1.2 (default latest)")
+	cmd.Flags().StringVar(&variant, "variant", "", "Variant of the version to load, such as debug, or default for its module (default: the engine's preferred variants)")
+	cmd.Flags().StringVar(&priority, "priority", "", "Queue priority of the function's calls when its instances are all busy: high, normal or low (default normal)")
+	cmd.Flags().DurationVar(&maxQueueWait, "max-queue-wait", 0, "Longest a call waits for a free instance before it is turned away with a 503 (default: engine setting)")
+	cmd.Flags().StringVar(&callLogging, "call-logging", "", "Calls that write entries to the function's logs: all, quiet or 1/N to log one call in N; errors are always logged (default all)")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Call the function with payloads typed in an interactive session after loading it")
+	cmd.Flags().StringVarP(&payload, "payload", "p", "", "Call the entrypoint once with this payload after loading the function")
+	cmd.Flags().StringVarP(&entrypoint, "entrypoint", "e", "handler", "Entrypoint called with --payload or in the interactive session")
 	return cmd
 }
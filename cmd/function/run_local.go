@@ -0,0 +1,219 @@
+package function
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/internal/services"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/egress"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/proxy"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// runLocalOptions holds the flags of the run-local command
+type runLocalOptions struct {
+	entrypoint string
+	payload    string
+	wasmPath   string
+	timeout    time.Duration
+	config     []string
+	envFiles   []string
+	offline    bool
+}
+
+// NewFunctionRunLocalCommand creates a command that executes a function in-process, without the engine.
+func NewFunctionRunLocalCommand() *cobra.Command {
+	var opts runLocalOptions
+
+	cmd := &cobra.Command{
+		Use:   "run-local [path]",
+		Short: "Build and call a function in-process without the engine",
+		Long: `Build the function in the given directory and call an entrypoint in-process
+using the Extism runtime. No engine needs to be running, which makes this suited to
+quick iteration and CI smoke tests.
+
+The WASI and allowed URL settings are read from ignition.yml. Use --wasm to skip the
+build and run an already built module. Logs written by the function go to stderr.
+
+Service calls through the ignition_call_service host function are not available,
+just like with one-off calls through the engine.`,
+		Example: `  # Build the function in the current directory and call its handler
+  ignition function run-local . --payload '{"name": "World"}'
+
+  # Run a prebuilt module with a specific entrypoint
+  ignition function run-local ./hello-world --wasm ./hello-world/plugin.wasm --entrypoint greet`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			payload, err := readPayloadFlag(opts.payload)
+			if err != nil {
+				return err
+			}
+
+			config, err := functionConfig(opts.envFiles, opts.config)
+			if err != nil {
+				return err
+			}
+
+			wasmBytes, settings, err := loadLocalModule(args, opts.wasmPath, opts.offline)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			if opts.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+				defer cancel()
+			}
+
+			output, err := runLocal(ctx, wasmBytes, settings, config, opts.entrypoint, []byte(payload))
+			if err != nil {
+				return err
+			}
+
+			if isJSON(output) {
+				var prettyJSON bytes.Buffer
+				if err := json.Indent(&prettyJSON, output, "", "  "); err == nil {
+					fmt.Println(prettyJSON.String())
+					return nil
+				}
+			}
+
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.entrypoint, "entrypoint", "e", "handler", "The entrypoint wasm function")
+	cmd.Flags().StringVarP(&opts.payload, "payload", "p", "", "The payload to send to the entrypoint (@file reads it from a file)")
+	cmd.Flags().StringVar(&opts.wasmPath, "wasm", "", "Run this prebuilt wasm module instead of building")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 0, "Deadline for the call (0 means no deadline)")
+	cmd.Flags().StringArrayVarP(&opts.config, "config", "c", []string{}, "Configuration values to pass to the function (format: key=value)")
+	cmd.Flags().StringArrayVar(&opts.envFiles, "env-file", []string{}, "Read configuration values from a file of KEY=VALUE lines (repeatable, --config takes precedence)")
+	cmd.Flags().BoolVar(&opts.offline, "offline", false, "Build without network access, using only vendored or cached dependencies")
+
+	return cmd
+}
+
+// loadLocalModule returns the wasm to run and its settings, building the function unless a
+// prebuilt module is given, offline when asked. Without an ignition.yml a prebuilt module runs
+// with WASI enabled.
+func loadLocalModule(args []string, wasmPath string, offline bool) ([]byte, manifest.FunctionVersionSettings, error) {
+	settings := manifest.FunctionVersionSettings{Wasi: true}
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	if wasmPath != "" {
+		if _, err := os.Stat(filepath.Join(dir, "ignition.yml")); err == nil {
+			functionConfig, err := loadFunctionManifest(dir)
+			if err != nil {
+				return nil, settings, err
+			}
+			settings = functionConfig.FunctionSettings.VersionSettings
+		}
+
+		wasmBytes, err := os.ReadFile(wasmPath)
+		if err != nil {
+			return nil, settings, fmt.Errorf("failed to read wasm module: %w", err)
+		}
+		return wasmBytes, settings, nil
+	}
+
+	absPath, err := validateAndPrepareBuildDir(args)
+	if err != nil {
+		return nil, settings, err
+	}
+
+	functionConfig, err := loadFunctionManifest(absPath)
+	if err != nil {
+		return nil, settings, err
+	}
+
+	fmt.Fprintln(os.Stderr, ui.DimStyle.Render(fmt.Sprintf("Building %s...", functionConfig.FunctionSettings.Name)))
+	buildResult, err := services.NewFunctionServiceWithOptions(services.FunctionServiceOptions{Offline: offline}).BuildFunction(absPath, functionConfig, types.BuildOptions{})
+	if err != nil {
+		return nil, settings, err
+	}
+
+	wasmBytes, err := os.ReadFile(buildResult.Path)
+	if err != nil {
+		return nil, settings, fmt.Errorf("failed to read built wasm module: %w", err)
+	}
+	return wasmBytes, functionConfig.FunctionSettings.VersionSettings, nil
+}
+
+// runLocal creates a plugin from the module and calls the entrypoint once
+func runLocal(ctx context.Context, wasmBytes []byte, settings manifest.FunctionVersionSettings,
+	config map[string]string, entrypoint string, payload []byte) ([]byte, error) {
+	info, err := registry.ValidateModule(wasmBytes, settings, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(info.Entrypoints, entrypoint) {
+		return nil, fmt.Errorf("entrypoint %q not exported by the module (available: %s)",
+			entrypoint, strings.Join(info.Entrypoints, ", "))
+	}
+
+	plugin, err := components.CreatePlugin(wasmBytes, &registry.VersionInfo{Settings: settings}, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin: %w", err)
+	}
+	defer plugin.Close(context.Background())
+
+	plugin.SetLogger(func(level extism.LogLevel, message string) {
+		fmt.Fprintf(os.Stderr, "[%s] %s\n", level, message)
+	})
+
+	// Connections are checked against allowed_urls like in the engine, without its deny rules
+	policy, err := egress.NewPolicy(settings.AllowedUrls, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed_urls: %w", err)
+	}
+	egress.Install()
+
+	// A function's own proxy replaces HTTP(S)_PROXY, and "direct" bypasses it
+	switch settings.Proxy {
+	case "":
+	case proxy.Direct:
+		ctx = egress.WithProxy(ctx, func(*http.Request) (*url.URL, error) { return nil, nil })
+	default:
+		if _, err := proxy.Parse(settings.Proxy); err != nil {
+			return nil, err
+		}
+		ctx = egress.WithProxy(ctx, proxy.Settings{HTTP: settings.Proxy, HTTPS: settings.Proxy}.Func())
+	}
+	ctx = egress.WithPolicy(ctx, policy, func(decision egress.Decision) {
+		if !decision.Allowed {
+			fmt.Fprintf(os.Stderr, "[egress] %s\n", decision)
+		}
+	})
+
+	code, output, err := plugin.CallWithContext(ctx, entrypoint, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call function: %w", err)
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("function returned non-zero exit code: %d", code)
+	}
+	return output, nil
+}
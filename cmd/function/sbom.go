@@ -0,0 +1,74 @@
+package function
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+func NewFunctionSBOMCommand() *cobra.Command {
+	var source registrySource
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "sbom [namespace/name:reference]",
+		Short: "Print the SBOM of a function version",
+		Long: `Print the software bill of materials of a function version as CycloneDX JSON.
+
+The SBOM is recorded when the engine builds a version, from go list for Go, cargo
+metadata for Rust and package-lock.json for JavaScript, TypeScript and AssemblyScript.
+It lists the packages the module was built from, so versions built elsewhere and
+pushed to the registry have none. The reference is resolved like a pull.
+
+The registry is read through the engine, or from --directory when the engine cannot be
+reached or with --offline.`,
+		Example: `  # SBOM of the latest version
+  ignition function sbom my-namespace/my-function:latest
+
+  # Write the SBOM of a digest to a file
+  ignition function sbom my-namespace/my-function:3f2a9c1b7d4e -o sbom.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			namespace, name, reference, err := parseNamespaceAndName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid function name format: %w", err)
+			}
+
+			var sbom *registry.SBOM
+			err = source.read(func(engineClient *client.EngineClient) (err error) {
+				sbom, err = engineClient.FunctionSBOM(context.Background(), namespace, name, reference)
+				return err
+			}, func(reg registry.Registry) (err error) {
+				store, ok := reg.(registry.SBOMStore)
+				if !ok {
+					return errors.New("the registry does not store SBOMs")
+				}
+				sbom, err = store.SBOM(namespace, name, reference)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to fetch SBOM: %w", err)
+			}
+
+			data, err := json.MarshalIndent(sbom, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode SBOM: %w", err)
+			}
+			if output != "" {
+				return os.WriteFile(output, append(data, '\n'), 0o644)
+			}
+			fmt.Println(string(data))
+			return nil
+		},
+	}
+
+	addRegistrySourceFlags(cmd, &source)
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the SBOM to a file instead of stdout")
+	return cmd
+}
@@ -1,20 +1,14 @@
 package function
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net"
-	"net/http"
-	"os"
-	"path/filepath"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
 	"github.com/ignitionstack/ignition/internal/ui"
 	"github.com/ignitionstack/ignition/internal/ui/models/spinner"
-	"github.com/ignitionstack/ignition/pkg/engine"
 	"github.com/spf13/cobra"
 )
 
@@ -45,39 +39,14 @@ Stopped functions will still appear in 'ignition ps' but with a "stopped" status
 			go func() {
 				stopStart := time.Now()
 
-				req := map[string]interface{}{
-					"namespace": namespace,
-					"name":      name,
-				}
-
-				reqBody, err := json.Marshal(req)
-				if err != nil {
-					p.Send(fmt.Errorf("failed to encode request: %w", err))
-					return
-				}
-
-				client := http.Client{
-					Transport: &http.Transport{
-						DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-							return net.Dial("unix", stopSocketPath)
-						},
-					},
-				}
-
-				resp, err := client.Post("http://unix/stop", "application/json", bytes.NewBuffer(reqBody))
+				engineClient, err := globalConfig.NewEngineClient(stopSocketPath)
 				if err != nil {
-					p.Send(fmt.Errorf("failed to send request to engine: %w", err))
+					p.Send(fmt.Errorf("failed to create engine client: %w", err))
 					return
 				}
-				defer resp.Body.Close()
 
-				if resp.StatusCode != http.StatusOK {
-					var errResp engine.RequestError
-					if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-						p.Send(fmt.Errorf("failed to decode error response: %w", err))
-						return
-					}
-					p.Send(errResp)
+				if err := engineClient.StopFunction(context.Background(), namespace, name); err != nil {
+					p.Send(err)
 					return
 				}
 
@@ -108,13 +77,6 @@ Stopped functions will still appear in 'ignition ps' but with a "stopped" status
 		},
 	}
 
-	// Use the default socket path
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
-	defaultSocketPath := filepath.Join(homeDir, ".ignition", "engine.sock")
-
-	cmd.Flags().StringVarP(&stopSocketPath, "socket", "s", defaultSocketPath, "Path to the Unix socket")
+	cmd.Flags().StringVarP(&stopSocketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
 	return cmd
 }
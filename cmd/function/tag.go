@@ -1,14 +1,13 @@
 package function
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net"
 	"net/http"
-	"os"
-	"path/filepath"
 
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/pkg/engine/api"
 	"github.com/spf13/cobra"
 )
 
@@ -29,41 +28,18 @@ func NewFunctionTagCommand() *cobra.Command {
 			// The second argument is the tag to assign
 			tag := args[1]
 
-			// Send a request to the engine to assign the tag
-			req := map[string]string{
-				"namespace": namespace,
-				"name":      name,
-				"tag":       tag,
-				"digest":    digest,
-			}
-
-			reqBody, err := json.Marshal(req)
-			if err != nil {
-				return fmt.Errorf("failed to encode request: %w", err)
-			}
-
-			// Create an HTTP client with Unix socket transport
-			client := http.Client{
-				Transport: &http.Transport{
-					Dial: func(_, _ string) (net.Conn, error) {
-						return net.Dial("unix", socketPath)
-					},
-				},
-			}
-
-			// Send the request to the engine
-			resp, err := client.Post("http://unix/reassign-tag", "application/json", bytes.NewBuffer(reqBody))
+			engineClient, err := globalConfig.NewEngineClient(socketPath)
 			if err != nil {
-				return fmt.Errorf("failed to send request to engine: %w", err)
+				return fmt.Errorf("failed to create engine client: %w", err)
 			}
-			defer resp.Body.Close()
 
-			// Check the response status
-			if resp.StatusCode == http.StatusNotFound {
-				return fmt.Errorf("function %s not found", args[0])
-			}
-			if resp.StatusCode != http.StatusOK {
-				return fmt.Errorf("engine returned error: %s", resp.Status)
+			// Ask the engine to assign the tag
+			if err := engineClient.ReassignTag(context.Background(), namespace, name, tag, digest); err != nil {
+				var respErr api.ResponseError
+				if errors.As(err, &respErr) && respErr.Code == http.StatusNotFound {
+					return fmt.Errorf("function %s not found", args[0])
+				}
+				return fmt.Errorf("failed to assign tag: %w", err)
 			}
 
 			// Print success message
@@ -72,15 +48,8 @@ func NewFunctionTagCommand() *cobra.Command {
 		},
 	}
 
-	// Use the default socket path
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
-	defaultSocketPath := filepath.Join(homeDir, ".ignition", "engine.sock")
-
 	// Add the socket path flag
-	cmd.Flags().StringVarP(&socketPath, "socket", "s", defaultSocketPath, "Path to the Unix socket")
+	cmd.Flags().StringVarP(&socketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
 
 	return cmd
 }
@@ -0,0 +1,188 @@
+package function
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/api"
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/functest"
+	"github.com/spf13/cobra"
+)
+
+// testOptions holds the flags of the test command
+type testOptions struct {
+	socketPath string
+	specPath   string
+	junitPath  string
+	timeout    time.Duration
+	config     []string
+}
+
+// NewFunctionTestCommand creates a command that runs a function's test spec through the engine.
+func NewFunctionTestCommand() *cobra.Command {
+	var opts testOptions
+
+	cmd := &cobra.Command{
+		Use:   "test [namespace/name:reference] [path]",
+		Short: "Run a function's test spec through the engine",
+		Long: `Run the tests declared in ignition.test.yml in the function directory.
+
+Each test calls an entrypoint once with a fixed input, or many times with inputs
+generated from a JSON schema (fuzz), using temporary instances of the function.
+Outputs are checked against an exact value, a substring, a JSON value or a JSON schema.
+
+  tests:
+    - name: greets by name
+      entrypoint: greet
+      input: {name: World}
+      expect:
+        json: {message: "Hello, World!"}
+    - name: handles any name
+      entrypoint: greet
+      fuzz:
+        runs: 200
+        seed: 42
+        schema: {type: object, required: [name], properties: {name: {type: string}}}
+      expect:
+        schema: {type: object, required: [message]}
+
+The command exits with an error when any test fails.`,
+		Example: `  # Run the tests in the current directory against the latest build
+  ignition function test default/hello-world:latest
+
+  # Write a JUnit report for CI
+  ignition function test default/hello-world:latest ./hello-world --junit report.xml`,
+		Args:          cobra.RangeArgs(1, 2),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			namespace, name, reference, err := parseNamespaceAndName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid function name format: %w", err)
+			}
+
+			specPath := opts.specPath
+			if specPath == "" {
+				dir := "."
+				if len(args) > 1 {
+					dir = args[1]
+				}
+				specPath = filepath.Join(dir, functest.SpecFile)
+			}
+
+			spec, err := functest.LoadSpec(specPath)
+			if err != nil {
+				return err
+			}
+
+			clientOptions, err := globalConfig.ClientOptions(opts.socketPath)
+			if err != nil {
+				return err
+			}
+			engineClient, err := client.New(clientOptions)
+			if err != nil {
+				return fmt.Errorf("failed to create engine client: %w", err)
+			}
+
+			baseConfig := make(map[string]string)
+			for _, configItem := range opts.config {
+				parts := splitKeyValue(configItem)
+				if len(parts) == 2 {
+					baseConfig[parts[0]] = parts[1]
+				}
+			}
+
+			call := func(ctx context.Context, entrypoint string, payload []byte, config map[string]string) ([]byte, error) {
+				merged := make(map[string]string, len(baseConfig)+len(config))
+				for k, v := range baseConfig {
+					merged[k] = v
+				}
+				for k, v := range config {
+					merged[k] = v
+				}
+
+				return engineClient.OneOffCall(ctx, api.OneOffCallRequest{
+					BaseRequest: api.BaseRequest{
+						Namespace: namespace,
+						Name:      name,
+					},
+					Reference:  reference,
+					Entrypoint: entrypoint,
+					Payload:    string(payload),
+					Config:     merged,
+					Timeout:    opts.timeout,
+				})
+			}
+
+			function := fmt.Sprintf("%s/%s:%s", namespace, name, reference)
+			report := functest.Run(context.Background(), function, spec, call)
+			printTestReport(report)
+
+			if opts.junitPath != "" {
+				if err := writeJUnitReport(opts.junitPath, report); err != nil {
+					return err
+				}
+			}
+
+			if failed := report.Failures(); failed > 0 {
+				return fmt.Errorf("%d of %d tests failed", failed, len(report.Results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.socketPath, "socket", "s", client.DefaultSocketPath(), "Path to the Unix socket")
+	cmd.Flags().StringVarP(&opts.specPath, "file", "f", "", "Path to the test spec (defaults to ignition.test.yml in the function directory)")
+	cmd.Flags().StringVar(&opts.junitPath, "junit", "", "Write a JUnit XML report to this file")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 0, "Deadline for each call (0 uses the engine default)")
+	cmd.Flags().StringArrayVarP(&opts.config, "config", "c", []string{}, "Configuration values passed to every call (format: key=value)")
+
+	return cmd
+}
+
+// printTestReport prints one line per test followed by a summary
+func printTestReport(report *functest.Report) {
+	for _, res := range report.Results {
+		calls := ""
+		if res.Calls > 1 {
+			calls = fmt.Sprintf(", %d calls", res.Calls)
+		}
+
+		if res.Passed() {
+			fmt.Printf("%s %s %s\n", ui.SuccessStyle.Render(ui.SuccessSymbol+" PASS"), res.Name,
+				ui.DimStyle.Render(fmt.Sprintf("(%s%s)", res.Duration.Round(time.Millisecond), calls)))
+			continue
+		}
+
+		fmt.Printf("%s %s %s\n", ui.ErrorStyle.Render(ui.ErrorSymbol+" FAIL"), res.Name,
+			ui.DimStyle.Render(fmt.Sprintf("(%s%s)", res.Duration.Round(time.Millisecond), calls)))
+		fmt.Printf("    %s\n", res.Failure)
+		if res.FailingInput != "" {
+			fmt.Printf("    input: %s\n", res.FailingInput)
+		}
+	}
+
+	fmt.Println()
+	passed := len(report.Results) - report.Failures()
+	ui.PrintInfo("Tests", fmt.Sprintf("%d passed, %d failed in %s", passed, report.Failures(), report.Duration.Round(time.Millisecond)))
+}
+
+// writeJUnitReport writes the report as JUnit XML to path
+func writeJUnitReport(path string, report *functest.Report) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create junit report: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	return functest.WriteJUnit(file, report)
+}
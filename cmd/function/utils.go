@@ -2,7 +2,14 @@ package function
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/validation"
 )
 
 // splitKeyValue splits a string in format "key=value" into a tuple ["key", "value"].
@@ -12,10 +19,48 @@ func splitKeyValue(input string) []string {
 	return parts
 }
 
-// parseNamespaceAndName parses a string in the format namespace/name:tag or namespace/name (defaults to :latest)
+// functionConfig builds the config a function is loaded with from env files and
+// key=value flags, which take precedence over the files.
+func functionConfig(envFiles, configFlags []string) (map[string]string, error) {
+	config, err := manifest.ParseEnvFiles(envFiles)
+	if err != nil {
+		return nil, err
+	}
+	for _, configItem := range configFlags {
+		parts := splitKeyValue(configItem)
+		if len(parts) == 2 {
+			config[parts[0]] = parts[1]
+		}
+	}
+	return config, nil
+}
+
+// readPayloadFile reads the raw payload of a call from a file, or from stdin for "-".
+// It returns nil when no file is given.
+func readPayloadFile(path string) ([]byte, error) {
+	switch path {
+	case "":
+		return nil, nil
+	case "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payload from stdin: %w", err)
+		}
+		return data, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload file: %w", err)
+	}
+	return data, nil
+}
+
+// parseNamespaceAndName parses a string in the format namespace/name:tag or namespace/name (defaults to :latest).
+// A reference without a namespace is in the default namespace.
 func parseNamespaceAndName(input string) (namespace, name, tag string, err error) {
 	// Split namespace and name/tag
-	parts := strings.Split(input, "/")
+	parts := strings.Split(globalConfig.ExpandReference(input), "/")
 	if len(parts) != 2 {
 		return "", "", "", fmt.Errorf("invalid format: %s (expected namespace/name or namespace/name:tag)", input)
 	}
@@ -42,5 +87,15 @@ func parseNamespaceAndName(input string) (namespace, name, tag string, err error
 		return "", "", "", fmt.Errorf("invalid format: %s (all parts must be non-empty)", input)
 	}
 
+	if err := validation.ValidateFunction(namespace, name); err != nil {
+		return "", "", "", err
+	}
+	if err := validation.ValidateTag(tag); err != nil {
+		// Semver ranges such as ^1.2 are resolved by the registry on pull
+		if _, rangeErr := registry.ParseVersionRange(tag); rangeErr != nil {
+			return "", "", "", err
+		}
+	}
+
 	return namespace, name, tag, nil
 }
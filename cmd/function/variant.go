@@ -0,0 +1,63 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// NewFunctionVariantCommand creates a command adding a variant module to a version.
+func NewFunctionVariantCommand() *cobra.Command {
+	var socketPath string
+
+	cmd := &cobra.Command{
+		Use:   "variant [namespace/name:reference] [variant] [file]",
+		Short: "Add a variant module to a function version",
+		Long: `Store another build of a version next to its module, such as a debug build keeping
+its names section or a WASI preview 2 component. A variant of the same name is replaced.
+
+Variants are loaded with ignition run --variant, or in the order of the engine's
+registry.preferred_variants when a load names none. Components are stored but skipped
+when loading, as the runtime only runs core modules. ignition function resolve lists
+the variants of a version.`,
+		Example: `  # Attach a debug build to v1.2.0
+  ignition function variant my-namespace/my-function:v1.2.0 debug ./plugin-debug.wasm
+
+  # Load it
+  ignition run my-namespace/my-function:v1.2.0 --variant debug`,
+		Args:          cobra.ExactArgs(3),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			namespace, name, reference, err := parseNamespaceAndName(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid function name format: %w", err)
+			}
+			payload, err := os.ReadFile(args[2])
+			if err != nil {
+				return fmt.Errorf("failed to read variant: %w", err)
+			}
+
+			engineClient, err := globalConfig.NewEngineClient(socketPath)
+			if err != nil {
+				return fmt.Errorf("failed to create engine client: %w", err)
+			}
+
+			version, err := engineClient.AddVariant(context.Background(), namespace, name, reference, args[1], payload)
+			if err != nil {
+				return fmt.Errorf("failed to add variant: %w", err)
+			}
+
+			ui.PrintSuccess(fmt.Sprintf("Variant %s added to %s/%s@%s", args[1], namespace, name, version.Hash))
+			printVariants(version.Variants)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&socketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	return cmd
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/ignitionstack/ignition/cmd/namespace"
+	"github.com/spf13/cobra"
+)
+
+var namespaceCmd = &cobra.Command{
+	Use:   "namespace",
+	Short: "Inspect namespaces",
+	Long:  `Commands for inspecting the namespaces functions are grouped in.`,
+	Example: `  # Show this month's usage of every namespace
+  ignition namespace usage`,
+}
+
+func init() {
+	namespaceCmd.AddCommand(namespace.NewNamespaceUsageCommand())
+
+	rootCmd.AddCommand(namespaceCmd)
+}
@@ -0,0 +1,101 @@
+package namespace
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/usage"
+	"github.com/spf13/cobra"
+)
+
+// NewNamespaceUsageCommand creates a command to show invocation counts and quotas of namespaces.
+func NewNamespaceUsageCommand() *cobra.Command {
+	var (
+		usageSocketPath string
+		plain           bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "usage [namespace]",
+		Short: "Show invocations and execution time per namespace",
+		Long: `Show how many calls the functions of each namespace served and how long they ran,
+in the current UTC day and month, next to the quotas set in engine.usage.
+
+Without a namespace, every namespace with calls this month or a quota of its own is shown.`,
+		Example: `  # Show the usage of every namespace
+  ignition namespace usage
+
+  # Show the usage of one namespace
+  ignition namespace usage my-namespace`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			var namespace string
+			if len(args) == 1 {
+				namespace = args[0]
+			}
+
+			engineClient, err := globalConfig.NewEngineClient(usageSocketPath)
+			if err != nil {
+				return fmt.Errorf("failed to create engine client: %w", err)
+			}
+
+			reports, err := engineClient.GetUsage(context.Background(), namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get usage: %w", err)
+			}
+
+			if plain {
+				for _, report := range reports {
+					fmt.Printf("%s\t%d\t%.3f\t%d\t%.3f\n", report.Namespace,
+						report.Daily.Invocations, report.Daily.ExecutionSeconds,
+						report.Monthly.Invocations, report.Monthly.ExecutionSeconds)
+				}
+				return nil
+			}
+
+			if len(reports) == 0 {
+				fmt.Println("No usage recorded this month")
+				return nil
+			}
+
+			table := ui.NewTable([]string{"NAMESPACE", "CALLS TODAY", "SECONDS TODAY", "CALLS THIS MONTH", "SECONDS THIS MONTH"})
+			for _, report := range reports {
+				quota := report.Quota
+				if quota == nil {
+					quota = &usage.Quota{}
+				}
+				table.AddRow(report.Namespace,
+					withLimit(strconv.FormatInt(report.Daily.Invocations, 10), quota.DailyInvocations > 0, strconv.FormatInt(quota.DailyInvocations, 10)),
+					withLimit(formatSeconds(report.Daily.ExecutionSeconds), quota.DailySeconds > 0, formatSeconds(quota.DailySeconds)),
+					withLimit(strconv.FormatInt(report.Monthly.Invocations, 10), quota.MonthlyInvocations > 0, strconv.FormatInt(quota.MonthlyInvocations, 10)),
+					withLimit(formatSeconds(report.Monthly.ExecutionSeconds), quota.MonthlySeconds > 0, formatSeconds(quota.MonthlySeconds)))
+			}
+			fmt.Println(ui.RenderTable(table))
+			fmt.Printf("Day %s, month %s (UTC)\n", reports[0].Day, reports[0].Month)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&usageSocketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	cmd.Flags().BoolVar(&plain, "plain", false, "Output in plain, machine-readable format")
+
+	return cmd
+}
+
+// withLimit appends the quota limit to a value, as in "120 / 1000"
+func withLimit(value string, limited bool, limit string) string {
+	if !limited {
+		return value
+	}
+	return value + " / " + limit
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 1, 64)
+}
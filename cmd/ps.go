@@ -5,8 +5,8 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/ignitionstack/ignition/internal/services"
 	"github.com/ignitionstack/ignition/internal/ui"
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
 	"github.com/ignitionstack/ignition/pkg/types"
 	"github.com/spf13/cobra"
 )
@@ -15,6 +15,8 @@ const (
 	StatusStopped  = "stopped"
 	StatusUnloaded = "unloaded"
 	StatusRunning  = "running"
+	StatusIdle     = "idle"
+	StatusFailed   = "failed"
 )
 
 // PsCmd creates a new cobra command for listing running functions.
@@ -48,7 +50,7 @@ is not running, it will display a warning and show no functions.`,
 			}
 			return fmt.Errorf("error getting engine client: %w", err)
 		}
-		engineClient, ok := client.(*services.EngineClient)
+		engineClient, ok := client.(*engineclient.EngineClient)
 		if !ok {
 			if !plainFormat {
 				ui.PrintError("Invalid engine client type")
@@ -94,10 +96,6 @@ is not running, it will display a warning and show no functions.`,
 					status := fn.Status
 					if status == "" {
 						status = StatusRunning
-					} else if status == StatusStopped {
-						status = StatusStopped
-					} else if status == StatusUnloaded {
-						status = StatusUnloaded
 					}
 					fmt.Printf(dataFormat, fn.Namespace, fn.Name, status)
 				}
@@ -114,28 +112,32 @@ is not running, it will display a warning and show no functions.`,
 		if engineRunning && len(runningFunctions) > 0 {
 			unloadedFunctionsExist := false
 			stoppedFunctionsExist := false
+			idleFunctionsExist := false
+			failedFunctionsExist := false
 
 			for _, fn := range runningFunctions {
-				var statusStyle string
-
-				if fn.Status == StatusUnloaded {
-					statusStyle = ui.StyleStatusValue(StatusUnloaded)
+				status := fn.Status
+				switch status {
+				case "":
+					status = StatusRunning
+				case StatusUnloaded:
 					unloadedFunctionsExist = true
-				} else if fn.Status == StatusStopped {
-					statusStyle = ui.StyleStatusValue(StatusStopped)
+				case StatusStopped:
 					stoppedFunctionsExist = true
-				} else {
-					statusStyle = ui.StyleStatusValue(StatusRunning)
+				case StatusIdle:
+					idleFunctionsExist = true
+				case StatusFailed:
+					failedFunctionsExist = true
 				}
 
-				table.AddRow(fn.Namespace, fn.Name, statusStyle)
+				table.AddRow(fn.Namespace, fn.Name, ui.StyleStatusValue(status))
 			}
 
 			// Render the table
 			fmt.Println(ui.RenderTable(table))
 
 			// Show explanation notes for different function statuses
-			if unloadedFunctionsExist || stoppedFunctionsExist {
+			if unloadedFunctionsExist || stoppedFunctionsExist || idleFunctionsExist || failedFunctionsExist {
 				fmt.Println()
 
 				if unloadedFunctionsExist {
@@ -145,6 +147,14 @@ is not running, it will display a warning and show no functions.`,
 				if stoppedFunctionsExist {
 					ui.PrintInfo("Note", "Functions with '"+StatusStopped+"' status will not be automatically reloaded when called")
 				}
+
+				if idleFunctionsExist {
+					ui.PrintInfo("Note", "Functions with '"+StatusIdle+"' status were unloaded after going unused and load again when called")
+				}
+
+				if failedFunctionsExist {
+					ui.PrintInfo("Note", "Functions with '"+StatusFailed+"' status failed to load or have an open circuit breaker")
+				}
 			}
 		} else {
 			ui.PrintInfo("Status", "No functions found")
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/ignitionstack/ignition/cmd/registry"
+	"github.com/spf13/cobra"
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage the local function registry",
+	Long:  `Commands for maintaining the local registry database that stores built functions.`,
+	Example: `  # Show pending schema migrations
+  ignition registry migrate --dry-run
+
+  # Copy the registry to a secondary directory
+  ignition registry sync --to /mnt/backup/registry`,
+}
+
+func init() {
+	registryCmd.AddCommand(registry.NewRegistryMigrateCommand())
+	registryCmd.AddCommand(registry.NewRegistrySyncCommand())
+
+	rootCmd.AddCommand(registryCmd)
+}
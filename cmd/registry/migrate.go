@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	localRegistry "github.com/ignitionstack/ignition/pkg/registry/local"
+	"github.com/spf13/cobra"
+)
+
+// NewRegistryMigrateCommand creates a command to upgrade the registry schema.
+func NewRegistryMigrateCommand() *cobra.Command {
+	var (
+		registryDir string
+		configPath  string
+		dryRun      bool
+		jsonOutput  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:         "migrate",
+		Annotations: map[string]string{ui.PlainOutputAnnotation: "json"},
+		Short:       "Upgrade the registry database schema",
+		Long: `Upgrade the registry database to the schema of this version of Ignition.
+
+The engine runs pending migrations automatically on start. This command runs them
+without starting the engine, or with --dry-run shows what would change. Before any
+record is rewritten, a full backup of the database is written to the registry's
+backups directory.
+
+The engine must not be running, since it holds the registry database open.`,
+		Example: `  # Show pending migrations without changing anything
+  ignition registry migrate --dry-run
+
+  # Migrate a registry in another directory
+  ignition registry migrate --directory /data/registry`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			// Encrypted registries need the key configured for the engine
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			var opts []localRegistry.Option
+			key, err := cfg.Registry.Encryption.LoadKey(context.Background())
+			if err != nil {
+				return fmt.Errorf("failed to load registry encryption key: %w", err)
+			}
+			if key != nil {
+				cipher, err := localRegistry.NewCipher(key)
+				if err != nil {
+					return err
+				}
+				opts = append(opts, localRegistry.WithEncryption(cipher))
+			}
+
+			dbRepo, err := localRegistry.OpenDatabase(registryDir)
+			if err != nil {
+				return err
+			}
+			defer dbRepo.Close()
+
+			reg := localRegistry.NewLocalRegistry(registryDir, dbRepo, opts...)
+			report, err := reg.(registry.Migrator).Migrate(dryRun)
+			if err != nil {
+				return fmt.Errorf("migration failed: %w", err)
+			}
+
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(report)
+			}
+
+			printMigrationReport(report)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&registryDir, "directory", "d", config.DefaultConfig().Server.RegistryDir, "Registry directory")
+	cmd.Flags().StringVarP(&configPath, "config", "c", config.DefaultConfigPath, "Config file with the registry encryption settings")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show pending migrations without applying them")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the migration report as JSON")
+
+	return cmd
+}
+
+func printMigrationReport(report *registry.MigrationReport) {
+	if report.FromVersion == report.ToVersion {
+		fmt.Printf("Registry schema is up to date (version %d)\n", report.ToVersion)
+		return
+	}
+
+	if report.DryRun {
+		fmt.Printf("Registry schema would be migrated from version %d to %d\n", report.FromVersion, report.ToVersion)
+	} else {
+		fmt.Printf("Registry schema migrated from version %d to %d\n", report.FromVersion, report.ToVersion)
+	}
+
+	table := ui.NewTable([]string{"VERSION", "MIGRATION", "RECORDS"})
+	for _, step := range report.Steps {
+		table.AddRow(fmt.Sprintf("%d", step.Version), step.Description, fmt.Sprintf("%d", step.Records))
+	}
+	fmt.Println(ui.RenderTable(table))
+
+	if report.BackupPath != "" {
+		fmt.Printf("Backup written to %s\n", report.BackupPath)
+	}
+}
@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	globalConfig "github.com/ignitionstack/ignition/internal/config"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/ignitionstack/ignition/pkg/engine/replication"
+	"github.com/spf13/cobra"
+)
+
+// NewRegistrySyncCommand creates a command to copy the registry of the running engine to
+// a replication target.
+func NewRegistrySyncCommand() *cobra.Command {
+	var (
+		syncSocketPath string
+		target         string
+		token          string
+		jsonOutput     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:         "sync",
+		Annotations: map[string]string{ui.PlainOutputAnnotation: "json"},
+		Short:       "Copy the registry to a secondary directory or engine",
+		Long: `Copy the versions and tags of the registry of the running engine that a secondary
+registry is missing, so it matches the primary again.
+
+The target is the directory of a secondary registry, or the URL of the TCP admin API
+of another engine. Without --to, the registry.replication target of the engine is
+synced, which catches up on writes replication dropped or gave up on.
+
+Versions are never removed from the target, and tags are only moved to match the
+primary registry.`,
+		Example: `  # Catch up the configured replication target
+  ignition registry sync
+
+  # Copy the registry to a mounted volume
+  ignition registry sync --to /mnt/backup/registry
+
+  # Copy the registry to a standby engine
+  ignition registry sync --to https://standby:9443 --token "$STANDBY_ADMIN_TOKEN"`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			// The engine may run in another directory
+			if target != "" && !replication.IsRemote(target) {
+				absTarget, err := filepath.Abs(target)
+				if err != nil {
+					return err
+				}
+				target = absTarget
+			}
+
+			engineClient, err := globalConfig.NewEngineClient(syncSocketPath)
+			if err != nil {
+				return fmt.Errorf("failed to create engine client: %w", err)
+			}
+
+			report, err := engineClient.SyncRegistry(context.Background(), target, token)
+			if err != nil {
+				return fmt.Errorf("registry sync failed: %w", err)
+			}
+
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(report)
+			}
+
+			fmt.Printf("Synced registry to %s: %d versions copied, %d tags updated\n",
+				report.Target, report.VersionsCopied, report.TagsUpdated)
+			for _, syncErr := range report.Errors {
+				fmt.Fprintf(os.Stderr, "  %s\n", syncErr)
+			}
+			if len(report.Errors) > 0 {
+				return fmt.Errorf("%d items could not be synced", len(report.Errors))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&syncSocketPath, "socket", "s", globalConfig.DefaultSocket, "Path to the Unix socket")
+	cmd.Flags().StringVar(&target, "to", "", "Registry directory or admin API URL of the target engine (default: registry.replication.target)")
+	cmd.Flags().StringVar(&token, "token", "", "Admin token of the target engine")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the sync report as JSON")
+
+	return cmd
+}
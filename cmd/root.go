@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"os"
+	"slices"
+	"strings"
 
 	globalConfig "github.com/ignitionstack/ignition/internal/config"
 	"github.com/ignitionstack/ignition/internal/di"
 	"github.com/ignitionstack/ignition/internal/services"
 	"github.com/ignitionstack/ignition/internal/ui"
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
+	ignitionErrors "github.com/ignitionstack/ignition/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -61,14 +65,19 @@ Key capabilities:
 		if err != nil {
 			// Don't return error, as some commands don't need the engine
 			// Just silently continue with default client
-			client := services.NewEngineClientWithDefaults()
+			client := engineclient.NewEngineClientWithDefaults()
 			Container.Register("engineClient", client)
 		}
 
-		// Check if any command in the hierarchy has a plain flag set to true
+		// Check if any command in the hierarchy has a plain flag set to true, or one of the
+		// flags the command marks as switching it to machine-readable output
+		plainFlags := []string{"plain"}
+		if names := cmd.Annotations[ui.PlainOutputAnnotation]; names != "" {
+			plainFlags = append(plainFlags, strings.Split(names, ",")...)
+		}
 		plainFlag := false
 		cmd.Flags().Visit(func(f *pflag.Flag) {
-			if f.Name == "plain" && f.Value.String() == "true" {
+			if slices.Contains(plainFlags, f.Name) && f.Value.String() == "true" {
 				plainFlag = true
 			}
 		})
@@ -85,9 +94,14 @@ Key capabilities:
 var Container = di.NewContainer()
 
 func Execute() {
+	if err := registerAliases(rootCmd, commandAliases); err != nil {
+		ui.PrintError(err.Error())
+		os.Exit(1)
+	}
+
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(ignitionErrors.ExitCode(err))
 	}
 }
 
@@ -104,13 +118,13 @@ func init() {
 
 	// Engine client will be initialized in setupEngineClient()
 	// We register a default one for now, it will be replaced in PersistentPreRunE
-	Container.Register("engineClient", services.NewEngineClientWithDefaults())
+	Container.Register("engineClient", engineclient.NewEngineClientWithDefaults())
 }
 
 // setupEngineClient creates an engine client using the socket path
-func setupEngineClient() (*services.EngineClient, error) {
+func setupEngineClient() (*engineclient.EngineClient, error) {
 	// Create client using the socket path
-	client, err := services.NewEngineClient(socketPath)
+	client, err := globalConfig.NewEngineClient(socketPath)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ignitionstack/ignition/internal/selfupdate"
+	"github.com/ignitionstack/ignition/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func newSelfUpdateCommand() *cobra.Command {
+	var (
+		channel  string
+		endpoint string
+		keys     []string
+		check    bool
+		force    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update ignition to the latest release",
+		Long: `Check the release endpoint for the latest release of a channel and, when it is newer
+than this binary, download the binary for this platform, verify its sha256 digest and
+its ed25519 signature, and replace this executable with it in one rename. The signature
+covers the channel, version and platform of the binary, and is checked before the
+version is trusted.
+
+Releases are signed with the release key built into the binary. --key trusts other
+keys, such as the key of a private build.`,
+		Example: `  # Update to the latest stable release
+  ignition self-update
+
+  # Only tell whether an update is available
+  ignition self-update --check
+
+  # Follow beta releases
+  ignition self-update --channel beta`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			trusted, err := selfupdate.ParseKeys(append([]string{selfupdate.ReleaseKey}, keys...))
+			if err != nil {
+				return err
+			}
+			updater := selfupdate.NewUpdater(endpoint, trusted)
+			ctx := context.Background()
+
+			current := selfupdate.CurrentVersion()
+			release, err := updater.Latest(ctx, channel)
+			if err != nil {
+				return err
+			}
+			if !release.NewerThan(current) && !force {
+				ui.PrintSuccess(fmt.Sprintf("ignition %s is the latest %s release", current, channel))
+				return nil
+			}
+			if check {
+				ui.PrintInfo("Update available", fmt.Sprintf("%s → %s (%s)", current, release.Version, channel))
+				return nil
+			}
+
+			executable, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to locate the ignition binary: %w", err)
+			}
+			if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+				executable = resolved
+			}
+
+			ui.PrintInfo("Downloading", fmt.Sprintf("ignition %s for %s", release.Version, updater.Platform))
+			data, err := updater.Download(ctx, release)
+			if err != nil {
+				return err
+			}
+			if err := selfupdate.Replace(executable, data); err != nil {
+				return fmt.Errorf("failed to replace %s: %w", executable, err)
+			}
+
+			ui.PrintSuccess(fmt.Sprintf("Updated ignition %s → %s", current, release.Version))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&channel, "channel", selfupdate.ChannelStable, "Release channel to follow: stable or beta")
+	cmd.Flags().StringVar(&endpoint, "endpoint", selfupdate.DefaultEndpoint, "URL the release manifests are served from")
+	cmd.Flags().StringArrayVar(&keys, "key", nil, "Also trust releases signed with this base64 ed25519 public key (repeatable)")
+	cmd.Flags().BoolVar(&check, "check", false, "Only report whether a newer release is available")
+	cmd.Flags().BoolVar(&force, "force", false, "Install the latest release even if it is not newer")
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(newSelfUpdateCommand())
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/validation"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ContextEnv selects the context of a single shell or command, overriding the current one
+	ContextEnv = "IGNITION_CONTEXT"
+
+	// NamespaceEnv sets the default namespace of a single shell or command
+	NamespaceEnv = "IGNITION_NAMESPACE"
+
+	// FallbackNamespace is the namespace of references written without one when no
+	// default namespace is configured
+	FallbackNamespace = "default"
+)
+
+// Context names an engine the CLI talks to: a local socket or a remote admin API.
+type Context struct {
+	Name string `yaml:"name"`
+
+	// Unix socket of a local engine
+	Socket string `yaml:"socket,omitempty"`
+
+	// URL of the TCP admin API of a remote engine, such as https://engine.internal:9090
+	Address string `yaml:"address,omitempty"`
+
+	// Bearer token of the remote admin API
+	Token string `yaml:"token,omitempty"`
+
+	// Namespace of function references written without one
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// Validate checks the name, that exactly one of socket and address is set, and the namespace.
+func (c Context) Validate() error {
+	if c.Name == "" {
+		return errors.New("context name cannot be empty")
+	}
+	switch {
+	case c.Socket != "" && c.Address != "":
+		return fmt.Errorf("context %s sets both a socket and an address", c.Name)
+	case c.Socket == "" && c.Address == "":
+		return fmt.Errorf("context %s sets neither a socket nor an address", c.Name)
+	case c.Address != "":
+		u, err := url.Parse(c.Address)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("context %s: address must be an http:// or https:// URL", c.Name)
+		}
+	case c.Token != "":
+		return fmt.Errorf("context %s: tokens are only sent to remote addresses", c.Name)
+	}
+	if c.Namespace != "" {
+		if err := validation.ValidateNamespace(c.Namespace); err != nil {
+			return fmt.Errorf("context %s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// Endpoint describes where the context points, for listings.
+func (c Context) Endpoint() string {
+	if c.Address != "" {
+		return c.Address
+	}
+	return c.Socket
+}
+
+// Contexts is the contexts file of the CLI.
+type Contexts struct {
+	// Context used by every command unless IGNITION_CONTEXT or --socket says otherwise
+	Current string `yaml:"current,omitempty"`
+
+	// Namespace of function references written without one, unless the active context
+	// sets its own
+	Namespace string `yaml:"namespace,omitempty"`
+
+	Contexts []Context `yaml:"contexts"`
+}
+
+// ContextsPath returns the path of the contexts file.
+func ContextsPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".ignition", "contexts.yaml")
+}
+
+// LoadContexts reads a contexts file. A missing file holds no contexts.
+func LoadContexts(path string) (*Contexts, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Contexts{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contexts: %w", err)
+	}
+
+	var contexts Contexts
+	if err := yaml.Unmarshal(data, &contexts); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &contexts, nil
+}
+
+// Save writes the contexts file, readable by the user only as it holds tokens.
+func (c *Contexts) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode contexts: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write contexts: %w", err)
+	}
+	return nil
+}
+
+// Get returns the context with the given name.
+func (c *Contexts) Get(name string) (Context, bool) {
+	for _, ctx := range c.Contexts {
+		if ctx.Name == name {
+			return ctx, true
+		}
+	}
+	return Context{}, false
+}
+
+// Set adds a context, or replaces the one with the same name.
+func (c *Contexts) Set(ctx Context) error {
+	if err := ctx.Validate(); err != nil {
+		return err
+	}
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == ctx.Name {
+			c.Contexts[i] = ctx
+			return nil
+		}
+	}
+	c.Contexts = append(c.Contexts, ctx)
+	return nil
+}
+
+// Remove deletes a context, and unsets it as the current one.
+func (c *Contexts) Remove(name string) bool {
+	i := slices.IndexFunc(c.Contexts, func(ctx Context) bool { return ctx.Name == name })
+	if i < 0 {
+		return false
+	}
+	c.Contexts = slices.Delete(c.Contexts, i, i+1)
+	if c.Current == name {
+		c.Current = ""
+	}
+	return true
+}
+
+// Use makes a context the current one. An empty name returns to the default socket.
+func (c *Contexts) Use(name string) error {
+	if name != "" {
+		if _, ok := c.Get(name); !ok {
+			return fmt.Errorf("context %s not found", name)
+		}
+	}
+	c.Current = name
+	return nil
+}
+
+// Active returns the context selected by IGNITION_CONTEXT, or else the current one.
+func (c *Contexts) Active() (Context, bool, error) {
+	name := c.Current
+	if env := os.Getenv(ContextEnv); env != "" {
+		name = env
+	}
+	if name == "" {
+		return Context{}, false, nil
+	}
+	ctx, ok := c.Get(name)
+	if !ok {
+		return Context{}, false, fmt.Errorf("context %s not found", name)
+	}
+	return ctx, true, nil
+}
+
+// ActiveContext returns the active context of the contexts file, if any.
+func ActiveContext() (Context, bool, error) {
+	contexts, err := LoadContexts(ContextsPath())
+	if err != nil {
+		return Context{}, false, err
+	}
+	return contexts.Active()
+}
+
+// ClientOptions returns the options of an engine client for a command. A socket other
+// than the default one was passed explicitly and wins; otherwise the active context, if
+// any, decides which engine to talk to.
+func ClientOptions(socketPath string) (client.Options, error) {
+	if socketPath != "" && socketPath != DefaultSocket {
+		return client.Options{SocketPath: socketPath}, nil
+	}
+
+	ctx, ok, err := ActiveContext()
+	switch {
+	case err != nil:
+		return client.Options{}, err
+	case !ok:
+		return client.Options{SocketPath: socketPath}, nil
+	case ctx.Address != "":
+		return client.Options{Address: ctx.Address, Token: ctx.Token}, nil
+	default:
+		return client.Options{SocketPath: ctx.Socket}, nil
+	}
+}
+
+// NewEngineClient creates an engine client for a command, honoring the active context.
+func NewEngineClient(socketPath string) (*client.EngineClient, error) {
+	opts, err := ClientOptions(socketPath)
+	if err != nil {
+		return nil, err
+	}
+	apiClient, err := client.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	return client.WrapEngineClient(apiClient), nil
+}
+
+// DefaultNamespace returns the namespace of references written without one: that of
+// IGNITION_NAMESPACE, else of the active context, else of the contexts file, else
+// "default".
+func (c *Contexts) DefaultNamespace() string {
+	if env := os.Getenv(NamespaceEnv); env != "" {
+		return env
+	}
+	if ctx, ok, err := c.Active(); err == nil && ok && ctx.Namespace != "" {
+		return ctx.Namespace
+	}
+	if c.Namespace != "" {
+		return c.Namespace
+	}
+	return FallbackNamespace
+}
+
+// DefaultNamespace returns the default namespace of the contexts file of the user.
+func DefaultNamespace() string {
+	contexts, err := LoadContexts(ContextsPath())
+	if err != nil {
+		contexts = &Contexts{}
+	}
+	return contexts.DefaultNamespace()
+}
+
+// ExpandReference prefixes a function reference written without a namespace, such as
+// my_function:latest, with the default namespace.
+func ExpandReference(ref string) string {
+	if strings.Contains(ref, "/") {
+		return ref
+	}
+	return DefaultNamespace() + "/" + ref
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextValidate(t *testing.T) {
+	assert.NoError(t, Context{Name: "dev", Socket: "/tmp/ignition.sock"}.Validate())
+	assert.NoError(t, Context{Name: "prod", Address: "https://engine.internal:9090", Token: "secret", Namespace: "payments"}.Validate())
+
+	for _, ctx := range []Context{
+		{Socket: "/tmp/ignition.sock"},
+		{Name: "both", Socket: "/tmp/ignition.sock", Address: "https://engine.internal"},
+		{Name: "neither"},
+		{Name: "scheme", Address: "engine.internal:9090"},
+		{Name: "token", Socket: "/tmp/ignition.sock", Token: "secret"},
+		{Name: "namespace", Socket: "/tmp/ignition.sock", Namespace: "Not Valid"},
+	} {
+		assert.Error(t, ctx.Validate(), ctx.Name)
+	}
+}
+
+func TestContexts(t *testing.T) {
+	t.Setenv(ContextEnv, "")
+	path := filepath.Join(t.TempDir(), ".ignition", "contexts.yaml")
+
+	contexts, err := LoadContexts(path)
+	require.NoError(t, err)
+	_, ok, err := contexts.Active()
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, contexts.Set(Context{Name: "dev", Socket: "/tmp/dev.sock"}))
+	require.NoError(t, contexts.Set(Context{Name: "prod", Address: "https://engine.internal:9090", Token: "secret"}))
+	require.NoError(t, contexts.Set(Context{Name: "dev", Socket: "/tmp/other.sock"}))
+	require.Error(t, contexts.Use("staging"))
+	require.NoError(t, contexts.Use("prod"))
+	require.NoError(t, contexts.Save(path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := LoadContexts(path)
+	require.NoError(t, err)
+	assert.Equal(t, contexts, loaded)
+	require.Len(t, loaded.Contexts, 2)
+	assert.Equal(t, "/tmp/other.sock", loaded.Contexts[0].Socket)
+
+	active, ok, err := loaded.Active()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "prod", active.Name)
+
+	// The environment overrides the current context
+	t.Setenv(ContextEnv, "dev")
+	active, _, err = loaded.Active()
+	require.NoError(t, err)
+	assert.Equal(t, "dev", active.Name)
+	t.Setenv(ContextEnv, "staging")
+	_, _, err = loaded.Active()
+	require.Error(t, err)
+
+	assert.True(t, loaded.Remove("prod"))
+	assert.False(t, loaded.Remove("prod"))
+	assert.Empty(t, loaded.Current)
+}
+
+func TestClientOptionsExplicitSocket(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ContextEnv, "")
+
+	contexts := &Contexts{}
+	require.NoError(t, contexts.Set(Context{Name: "prod", Address: "https://engine.internal:9090", Token: "secret"}))
+	require.NoError(t, contexts.Use("prod"))
+	require.NoError(t, contexts.Save(ContextsPath()))
+
+	opts, err := ClientOptions(DefaultSocket)
+	require.NoError(t, err)
+	assert.Equal(t, "https://engine.internal:9090", opts.Address)
+	assert.Equal(t, "secret", opts.Token)
+
+	// A socket passed explicitly wins over the context
+	opts, err = ClientOptions("/tmp/explicit.sock")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/explicit.sock", opts.SocketPath)
+	assert.Empty(t, opts.Address)
+}
+
+func TestDefaultNamespace(t *testing.T) {
+	t.Setenv(ContextEnv, "")
+	t.Setenv(NamespaceEnv, "")
+
+	contexts := &Contexts{}
+	assert.Equal(t, FallbackNamespace, contexts.DefaultNamespace())
+
+	contexts.Namespace = "team"
+	assert.Equal(t, "team", contexts.DefaultNamespace())
+
+	// The namespace of the active context wins over the one of the file
+	require.NoError(t, contexts.Set(Context{Name: "dev", Socket: "/tmp/dev.sock"}))
+	require.NoError(t, contexts.Set(Context{Name: "prod", Socket: "/tmp/prod.sock", Namespace: "payments"}))
+	require.NoError(t, contexts.Use("dev"))
+	assert.Equal(t, "team", contexts.DefaultNamespace())
+	require.NoError(t, contexts.Use("prod"))
+	assert.Equal(t, "payments", contexts.DefaultNamespace())
+
+	t.Setenv(NamespaceEnv, "scratch")
+	assert.Equal(t, "scratch", contexts.DefaultNamespace())
+}
+
+func TestExpandReference(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ContextEnv, "")
+	t.Setenv(NamespaceEnv, "")
+
+	assert.Equal(t, "default/my_function:latest", ExpandReference("my_function:latest"))
+	assert.Equal(t, "other/my_function", ExpandReference("other/my_function"))
+
+	t.Setenv(NamespaceEnv, "payments")
+	assert.Equal(t, "payments/my_function", ExpandReference("my_function"))
+}
@@ -1,19 +1,13 @@
 package config
 
 import (
-	"os"
-	"path/filepath"
-
 	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/ipc"
 )
 
-// DefaultSocketPath returns the default socket path used by the engine
+// DefaultSocketPath returns the default socket path used by the engine, a named pipe on Windows
 func DefaultSocketPath() string {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
-	return filepath.Join(homeDir, ".ignition", "engine.sock")
+	return ipc.DefaultPath()
 }
 
 // Global configuration variables
@@ -0,0 +1,241 @@
+// Package daemon writes the service definitions that keep the engine running in the
+// background: a systemd unit on Linux and a launchd property list on macOS.
+package daemon
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// Name is the name of the systemd unit
+	Name = "ignition-engine"
+
+	// Label is the label of the launchd job
+	Label = "com.ignitionstack.engine"
+)
+
+// Manager is the service manager of the platform.
+type Manager string
+
+const (
+	Systemd Manager = "systemd"
+	Launchd Manager = "launchd"
+)
+
+// Restart policies of the engine process
+const (
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+	RestartNever     = "no"
+)
+
+// DetectManager returns the service manager of the platform the CLI runs on.
+func DetectManager() (Manager, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return Systemd, nil
+	case "darwin":
+		return Launchd, nil
+	default:
+		return "", fmt.Errorf("installing the engine as a service is not supported on %s", runtime.GOOS)
+	}
+}
+
+// Spec describes the engine service.
+type Spec struct {
+	// Absolute path of the ignition binary
+	Executable string
+
+	// Arguments after the binary, such as engine start --config ...
+	Args []string
+
+	// Install for the whole machine, run as User, instead of for the current user only
+	System bool
+
+	// User the engine of a system service runs as
+	User string
+
+	// Home directory of the user the engine runs as, where ~/.ignition resolves
+	Home string
+
+	// Restart policy: on-failure, always or no
+	Restart string
+}
+
+// Validate checks that the spec can be rendered.
+func (s Spec) Validate() error {
+	if !filepath.IsAbs(s.Executable) {
+		return fmt.Errorf("executable %q must be an absolute path", s.Executable)
+	}
+	if !filepath.IsAbs(s.Home) {
+		return fmt.Errorf("home directory %q must be an absolute path", s.Home)
+	}
+	if s.System && s.User == "" {
+		return errors.New("a system service needs a user to run the engine as")
+	}
+	switch s.Restart {
+	case RestartOnFailure, RestartAlways, RestartNever:
+	default:
+		return fmt.Errorf("unknown restart policy %q, expected on-failure, always or no", s.Restart)
+	}
+	return nil
+}
+
+// Path returns where the service definition of spec is installed.
+func Path(m Manager, spec Spec) string {
+	switch {
+	case m == Systemd && spec.System:
+		return filepath.Join("/etc/systemd/system", Name+".service")
+	case m == Systemd:
+		return filepath.Join(spec.Home, ".config/systemd/user", Name+".service")
+	case spec.System:
+		return filepath.Join("/Library/LaunchDaemons", Label+".plist")
+	default:
+		return filepath.Join(spec.Home, "Library/LaunchAgents", Label+".plist")
+	}
+}
+
+// Render returns the service definition of spec.
+func Render(m Manager, spec Spec) (string, error) {
+	if err := spec.Validate(); err != nil {
+		return "", err
+	}
+	if m == Systemd {
+		return systemdUnit(spec), nil
+	}
+	return launchdPlist(spec), nil
+}
+
+// StartCommands returns the commands that register and start the installed service.
+func StartCommands(m Manager, spec Spec) [][]string {
+	if m == Launchd {
+		return [][]string{{"launchctl", "load", "-w", Path(m, spec)}}
+	}
+	systemctl := systemctlCommand(spec)
+	return [][]string{
+		append(systemctl, "daemon-reload"),
+		append(systemctl, "enable", "--now", Name+".service"),
+	}
+}
+
+// StopCommands returns the commands that stop and unregister the service before its
+// definition is removed.
+func StopCommands(m Manager, spec Spec) [][]string {
+	if m == Launchd {
+		return [][]string{{"launchctl", "unload", "-w", Path(m, spec)}}
+	}
+	return [][]string{append(systemctlCommand(spec), "disable", "--now", Name+".service")}
+}
+
+// RemovedCommands returns the commands that make the service manager forget a removed
+// service definition.
+func RemovedCommands(m Manager, spec Spec) [][]string {
+	if m == Launchd {
+		return nil
+	}
+	return [][]string{append(systemctlCommand(spec), "daemon-reload")}
+}
+
+func systemctlCommand(spec Spec) []string {
+	if spec.System {
+		return []string{"systemctl"}
+	}
+	return []string{"systemctl", "--user"}
+}
+
+func systemdUnit(spec Spec) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Ignition WebAssembly engine\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("Wants=network-online.target\n\n")
+
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=simple\n")
+	args := append([]string{spec.Executable}, spec.Args...)
+	for i, arg := range args {
+		args[i] = systemdQuote(arg)
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(args, " "))
+	if spec.System {
+		fmt.Fprintf(&b, "User=%s\n", spec.User)
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote("HOME="+spec.Home))
+	}
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdQuote(spec.Home))
+	fmt.Fprintf(&b, "Restart=%s\n", spec.Restart)
+	b.WriteString("RestartSec=5s\n")
+	b.WriteString("KillSignal=SIGTERM\n")
+	b.WriteString("TimeoutStopSec=30s\n\n")
+
+	b.WriteString("[Install]\n")
+	if spec.System {
+		b.WriteString("WantedBy=multi-user.target\n")
+	} else {
+		b.WriteString("WantedBy=default.target\n")
+	}
+	return b.String()
+}
+
+// systemdQuote quotes a word of a unit file, escaping the specifiers systemd expands.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if !strings.ContainsAny(s, " \t\"'\\$;") {
+		return s
+	}
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$").Replace(s)
+	return `"` + s + `"`
+}
+
+func launchdPlist(spec Spec) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+
+	plistString(&b, "Label", Label)
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{spec.Executable}, spec.Args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", escapeXML(arg))
+	}
+	b.WriteString("\t</array>\n")
+	if spec.System {
+		plistString(&b, "UserName", spec.User)
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		fmt.Fprintf(&b, "\t\t<key>HOME</key>\n\t\t<string>%s</string>\n", escapeXML(spec.Home))
+		b.WriteString("\t</dict>\n")
+	}
+	plistString(&b, "WorkingDirectory", spec.Home)
+
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	switch spec.Restart {
+	case RestartAlways:
+		b.WriteString("\t<key>KeepAlive</key>\n\t<true/>\n")
+	case RestartOnFailure:
+		b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	}
+	b.WriteString("\t<key>ThrottleInterval</key>\n\t<integer>5</integer>\n")
+
+	// launchd has no journal, so output goes next to the rest of the engine's files
+	logFile := filepath.Join(spec.Home, ".ignition", "engine.log")
+	plistString(&b, "StandardOutPath", logFile)
+	plistString(&b, "StandardErrorPath", logFile)
+
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func plistString(b *strings.Builder, key, value string) {
+	fmt.Fprintf(b, "\t<key>%s</key>\n\t<string>%s</string>\n", key, escapeXML(value))
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecValidate(t *testing.T) {
+	valid := Spec{Executable: "/usr/local/bin/ignition", Home: "/home/dev", Restart: RestartOnFailure}
+	assert.NoError(t, valid.Validate())
+
+	for _, spec := range []Spec{
+		{Executable: "ignition", Home: "/home/dev", Restart: RestartOnFailure},
+		{Executable: "/usr/local/bin/ignition", Home: "~", Restart: RestartOnFailure},
+		{Executable: "/usr/local/bin/ignition", Home: "/home/dev", Restart: "sometimes"},
+		{Executable: "/usr/local/bin/ignition", Home: "/home/dev", Restart: RestartAlways, System: true},
+	} {
+		assert.Error(t, spec.Validate(), "%+v", spec)
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	spec := Spec{
+		Executable: "/opt/ignition tools/ignition",
+		Args:       []string{"engine", "start", "--http", ":9090", "--log-file", "/var/log/50%.log"},
+		System:     true,
+		User:       "ignition",
+		Home:       "/var/lib/ignition",
+		Restart:    RestartAlways,
+	}
+	unit, err := Render(Systemd, spec)
+	require.NoError(t, err)
+
+	assert.Contains(t, unit, `ExecStart="/opt/ignition tools/ignition" engine start --http :9090 --log-file /var/log/50%%.log`+"\n")
+	assert.Contains(t, unit, "User=ignition\n")
+	assert.Contains(t, unit, "Environment=HOME=/var/lib/ignition\n")
+	assert.Contains(t, unit, "Restart=always\n")
+	assert.Contains(t, unit, "WantedBy=multi-user.target\n")
+	assert.Equal(t, "/etc/systemd/system/ignition-engine.service", Path(Systemd, spec))
+
+	// A unit of the current user runs as them
+	spec.System, spec.User = false, ""
+	unit, err = Render(Systemd, spec)
+	require.NoError(t, err)
+	assert.NotContains(t, unit, "User=")
+	assert.Contains(t, unit, "WantedBy=default.target\n")
+	assert.Equal(t, "/var/lib/ignition/.config/systemd/user/ignition-engine.service", Path(Systemd, spec))
+	assert.Equal(t, [][]string{
+		{"systemctl", "--user", "daemon-reload"},
+		{"systemctl", "--user", "enable", "--now", "ignition-engine.service"},
+	}, StartCommands(Systemd, spec))
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	spec := Spec{
+		Executable: "/usr/local/bin/ignition",
+		Args:       []string{"engine", "start", "--config", "/Users/dev/a&b.yaml"},
+		Home:       "/Users/dev",
+		Restart:    RestartOnFailure,
+	}
+	plist, err := Render(Launchd, spec)
+	require.NoError(t, err)
+
+	// The property list is well formed XML
+	decoder := xml.NewDecoder(strings.NewReader(plist))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			assert.Equal(t, "EOF", err.Error())
+			break
+		}
+	}
+
+	assert.Contains(t, plist, "<string>/Users/dev/a&amp;b.yaml</string>")
+	assert.Contains(t, plist, "<key>SuccessfulExit</key>\n\t\t<false/>")
+	assert.Contains(t, plist, "<string>/Users/dev/.ignition/engine.log</string>")
+	assert.NotContains(t, plist, "UserName")
+	assert.Equal(t, "/Users/dev/Library/LaunchAgents/com.ignitionstack.engine.plist", Path(Launchd, spec))
+
+	spec.Restart = RestartNever
+	plist, err = Render(Launchd, spec)
+	require.NoError(t, err)
+	assert.NotContains(t, plist, "KeepAlive")
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"path/filepath"
 	"sync"
-	"time"
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/ignitionstack/ignition/internal/repository"
@@ -202,10 +201,7 @@ func (z *zapLoggerAdapter) Debugf(format string, args ...interface{}) {
 }
 
 func NewBadgerDB(lc fx.Lifecycle, config AppConfig) (*badger.DB, error) {
-	opts := badger.DefaultOptions(filepath.Join(config.RegistryDir, "registry.db"))
-	opts.Logger = nil
-
-	db, err := badger.Open(opts)
+	db, err := localRegistry.OpenBadger(filepath.Join(config.RegistryDir, "registry.db"), 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open registry database: %w", err)
 	}
@@ -229,29 +225,19 @@ func NewRegistry(dbRepo repository.DBRepository, config AppConfig) registry.Regi
 }
 
 func NewEngine(params EngineParams) *engine.Engine {
-	// Create config object
-	cfg := &config.Config{
-		Engine: config.EngineConfig{
-			DefaultTimeout:   30 * time.Second,
-			LogStoreCapacity: 1000,
-			CircuitBreaker: config.CircuitBreakerConfig{
-				FailureThreshold: 5,
-				ResetTimeout:     30 * time.Second,
-			},
-			PluginManager: config.PluginManagerConfig{
-				TTL:             10 * time.Minute,
-				CleanupInterval: 1 * time.Minute,
-			},
-		},
-		Server: config.ServerConfig{
-			SocketPath:  params.Config.SocketPath,
-			HTTPAddr:    params.Config.HTTPAddr,
-			RegistryDir: params.Config.RegistryDir,
-		},
-	}
-
-	// Create engine with the config
-	engine, err := engine.NewEngineWithConfig(cfg, params.Logger)
+	// Start from the same defaults as the engine configuration file
+	cfg := config.DefaultConfig()
+	cfg.Server.SocketPath = params.Config.SocketPath
+	cfg.Server.HTTPAddr = params.Config.HTTPAddr
+	cfg.Server.RegistryDir = params.Config.RegistryDir
+
+	// Use the registry provided to the container rather than opening another one
+	engine, err := engine.New(cfg.Server.RegistryDir,
+		engine.WithSocketPath(cfg.Server.SocketPath),
+		engine.WithHTTPAddr(cfg.Server.HTTPAddr),
+		engine.WithLogger(params.Logger),
+		engine.WithOptions(engine.OptionsFromConfig(cfg)),
+		engine.WithRegistry(params.Registry))
 	if err != nil {
 		// In a production system, we'd handle this error better
 		// but in this DI context, panicking is acceptable
@@ -1,10 +1,19 @@
 package repository
 
-import "github.com/dgraph-io/badger/v4"
+import (
+	"errors"
+	"io"
+
+	"github.com/dgraph-io/badger/v4"
+)
 
 type DBRepository interface {
 	View(fn func(txn *badger.Txn) error) error
 	Update(fn func(txn *badger.Txn) error) error
+	RunGC(discardRatio float64) (int, error)
+	Size() (lsm, vlog int64)
+	CacheSize() int64
+	Backup(w io.Writer) error
 	Close() error
 }
 
@@ -24,6 +33,39 @@ func (r *BadgerDBRepository) Update(fn func(txn *badger.Txn) error) error {
 	return r.db.Update(fn)
 }
 
+// RunGC runs value log garbage collection until no more files can be rewritten
+// and returns the number of rewritten value log files.
+func (r *BadgerDBRepository) RunGC(discardRatio float64) (int, error) {
+	rewrites := 0
+	for {
+		err := r.db.RunValueLogGC(discardRatio)
+		if errors.Is(err, badger.ErrNoRewrite) || errors.Is(err, badger.ErrRejected) {
+			return rewrites, nil
+		}
+		if err != nil {
+			return rewrites, err
+		}
+		rewrites++
+	}
+}
+
+// Size returns the on-disk size of the LSM tree and the value log.
+func (r *BadgerDBRepository) Size() (lsm, vlog int64) {
+	return r.db.Size()
+}
+
+// CacheSize returns the bytes held by the block and index caches.
+func (r *BadgerDBRepository) CacheSize() int64 {
+	blocks, index := r.db.BlockCacheMetrics(), r.db.IndexCacheMetrics()
+	return int64(blocks.CostAdded() - blocks.CostEvicted() + index.CostAdded() - index.CostEvicted())
+}
+
+// Backup writes a full backup of the database to w, restorable with badger's Load.
+func (r *BadgerDBRepository) Backup(w io.Writer) error {
+	_, err := r.db.Backup(w, 0)
+	return err
+}
+
 func (r *BadgerDBRepository) Close() error {
 	return r.db.Close()
 }
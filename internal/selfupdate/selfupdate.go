@@ -0,0 +1,285 @@
+// Package selfupdate replaces the ignition binary with the latest release of a channel,
+// once its checksum and signature have been verified.
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// DefaultEndpoint serves the release manifest of each channel, as <channel>.json
+const DefaultEndpoint = "https://ignitionstack.github.io/ignition/releases"
+
+// Release channels
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// maxBinarySize bounds the download of a release binary
+const maxBinarySize = 512 << 20
+
+var (
+	// Version of this binary, set at build time with
+	// -ldflags "-X github.com/ignitionstack/ignition/internal/selfupdate.Version=1.4.0"
+	Version = ""
+
+	// ReleaseKey is the base64 ed25519 public key releases are signed with, set at build
+	// time like Version
+	ReleaseKey = ""
+)
+
+// CurrentVersion returns the version of this binary, "dev" when it was not built from a release.
+func CurrentVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// Release is the manifest of the latest release of a channel.
+type Release struct {
+	Version string `json:"version"`
+
+	// Channel the manifest was fetched from, which the signatures of its binaries cover
+	Channel string `json:"-"`
+
+	// Binaries of the release keyed by GOOS/GOARCH, such as linux/amd64
+	Binaries map[string]Binary `json:"binaries"`
+}
+
+// Binary is the executable of a release for one platform.
+type Binary struct {
+	// URL of the executable, relative to the manifest or absolute
+	URL string `json:"url"`
+
+	// Hex sha256 digest of the executable
+	SHA256 string `json:"sha256"`
+
+	// Base64 ed25519 signature of the SigningPayload of the binary
+	Signature string `json:"signature"`
+}
+
+// SigningPayload returns what the signature of a release binary covers. Binding the
+// channel, version and platform to the digest keeps a release endpoint from passing off
+// an older signed binary as a newer version, a beta build as stable, or a binary built
+// for another platform.
+func SigningPayload(channel, version, platform, sha256 string) []byte {
+	return []byte(fmt.Sprintf("ignition release\nchannel: %s\nversion: %s\nplatform: %s\nsha256: %s\n",
+		channel, version, platform, strings.ToLower(sha256)))
+}
+
+// Updater finds, downloads and verifies releases.
+type Updater struct {
+	// Base URL of the release manifests
+	Endpoint string
+
+	// Keys trusted to sign releases
+	Keys []ed25519.PublicKey
+
+	// Platform of the binaries to download, GOOS/GOARCH
+	Platform string
+
+	Client *http.Client
+}
+
+// NewUpdater returns an updater for the platform the CLI runs on, trusting the keys.
+func NewUpdater(endpoint string, keys []ed25519.PublicKey) *Updater {
+	return &Updater{
+		Endpoint: endpoint,
+		Keys:     keys,
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+		Client:   &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// ParseKeys decodes base64 ed25519 public keys, skipping empty ones.
+func ParseKeys(encoded []string) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for i, value := range encoded {
+		if value == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("release key %d is not a base64 ed25519 public key", i+1)
+		}
+		keys = append(keys, ed25519.PublicKey(key))
+	}
+	return keys, nil
+}
+
+// Latest fetches the manifest of the latest release of channel and verifies the signature
+// of its binary for the platform, so its version can be trusted.
+func (u *Updater) Latest(ctx context.Context, channel string) (*Release, error) {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return nil, fmt.Errorf("unknown channel %q, expected stable or beta", channel)
+	}
+
+	body, err := u.get(ctx, u.manifestURL(channel), 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the %s release: %w", channel, err)
+	}
+	var release Release
+	if err := json.Unmarshal(body, &release); err != nil {
+		return nil, fmt.Errorf("invalid %s release manifest: %w", channel, err)
+	}
+	if release.Version == "" {
+		return nil, fmt.Errorf("the %s release manifest has no version", channel)
+	}
+	release.Channel = channel
+
+	if _, err := u.verifiedBinary(&release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// Download fetches the binary of the release for the platform and verifies that it
+// matches its digest and that one of the keys signed it for the release.
+func (u *Updater) Download(ctx context.Context, release *Release) ([]byte, error) {
+	binary, err := u.verifiedBinary(release)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(strings.TrimSuffix(u.Endpoint, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid release endpoint: %w", err)
+	}
+	ref, err := url.Parse(binary.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid binary URL %q: %w", binary.URL, err)
+	}
+	data, err := u.get(ctx, base.ResolveReference(ref).String(), maxBinarySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download release %s: %w", release.Version, err)
+	}
+
+	digest := sha256.Sum256(data)
+	if hex.EncodeToString(digest[:]) != strings.ToLower(binary.SHA256) {
+		return nil, fmt.Errorf("release %s does not match its sha256 digest", release.Version)
+	}
+	return data, nil
+}
+
+// verifiedBinary returns the binary of the release for the platform once a trusted key
+// is found to have signed it for the channel and version of the release.
+func (u *Updater) verifiedBinary(release *Release) (Binary, error) {
+	if len(u.Keys) == 0 {
+		return Binary{}, errors.New("no release signing key is trusted, so releases cannot be verified")
+	}
+	binary, ok := release.Binaries[u.Platform]
+	if !ok {
+		return Binary{}, fmt.Errorf("release %s has no binary for %s", release.Version, u.Platform)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(binary.Signature)
+	if err != nil || binary.Signature == "" {
+		return Binary{}, errors.New("release binary is not signed")
+	}
+	payload := SigningPayload(release.Channel, release.Version, u.Platform, binary.SHA256)
+	for _, key := range u.Keys {
+		if ed25519.Verify(key, payload, signature) {
+			return binary, nil
+		}
+	}
+	return Binary{}, fmt.Errorf("release signature does not match any trusted key for %s %s on %s",
+		release.Channel, release.Version, u.Platform)
+}
+
+func (u *Updater) manifestURL(channel string) string {
+	return strings.TrimSuffix(u.Endpoint, "/") + "/" + channel + ".json"
+}
+
+func (u *Updater) get(ctx context.Context, target string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", target, limit)
+	}
+	return data, nil
+}
+
+// NewerThan reports whether the release is newer than version. Any release is newer
+// than a binary that was not built from a release.
+func (r *Release) NewerThan(version string) bool {
+	cmp, ok := registry.CompareVersions(r.Version, version)
+	return !ok || cmp > 0
+}
+
+// Replace atomically replaces the executable at path with data: the new binary is
+// written next to it and renamed over it, so the executable is never half written.
+func Replace(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return fmt.Errorf("failed to write next to %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm() | 0o111); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// Windows does not replace a running executable, but lets it be renamed away
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			_ = os.Rename(old, path)
+			return err
+		}
+		return nil
+	}
+	return os.Rename(tmp.Name(), path)
+}
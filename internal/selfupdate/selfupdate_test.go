@@ -0,0 +1,148 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// releaseServer serves release 1.4.0 of binary for linux/amd64 on the stable channel.
+// Its signature by key covers the channel and version given, so a mismatch stands for a
+// release endpoint relabeling a release signed for another one.
+func releaseServer(t *testing.T, binary []byte, key ed25519.PrivateKey, signedChannel, signedVersion string) *httptest.Server {
+	t.Helper()
+	sum := sha256.Sum256(binary)
+	digest := hex.EncodeToString(sum[:])
+	signature := ed25519.Sign(key, SigningPayload(signedChannel, signedVersion, "linux/amd64", digest))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/releases/stable.json", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(Release{
+			Version: "1.4.0",
+			Binaries: map[string]Binary{"linux/amd64": {
+				URL:       "1.4.0/ignition-linux-amd64",
+				SHA256:    digest,
+				Signature: base64.StdEncoding.EncodeToString(signature),
+			}},
+		})
+	})
+	mux.HandleFunc("/releases/1.4.0/ignition-linux-amd64", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(binary)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadVerifiesRelease(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	binary := []byte("new ignition binary")
+	server := releaseServer(t, binary, private, ChannelStable, "1.4.0")
+
+	updater := NewUpdater(server.URL+"/releases", []ed25519.PublicKey{public})
+	updater.Platform = "linux/amd64"
+
+	release, err := updater.Latest(context.Background(), ChannelStable)
+	require.NoError(t, err)
+	assert.True(t, release.NewerThan("1.3.2"))
+	assert.True(t, release.NewerThan("dev"))
+	assert.False(t, release.NewerThan("v1.4.0"))
+
+	data, err := updater.Download(context.Background(), release)
+	require.NoError(t, err)
+	assert.Equal(t, binary, data)
+
+	// Releases signed with another key are refused
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	updater.Keys = []ed25519.PublicKey{other}
+	_, err = updater.Download(context.Background(), release)
+	assert.ErrorContains(t, err, "does not match any trusted key")
+	_, err = updater.Latest(context.Background(), ChannelStable)
+	assert.ErrorContains(t, err, "does not match any trusted key")
+
+	// So are binaries that do not match their digest
+	updater.Keys = []ed25519.PublicKey{public}
+	swapped := release.Binaries["linux/amd64"]
+	swapped.URL = "stable.json"
+	tampered := &Release{Version: release.Version, Channel: release.Channel, Binaries: map[string]Binary{"linux/amd64": swapped}}
+	_, err = updater.Download(context.Background(), tampered)
+	assert.ErrorContains(t, err, "does not match its sha256 digest")
+
+	updater.Platform = "plan9/386"
+	_, err = updater.Download(context.Background(), release)
+	assert.ErrorContains(t, err, "no binary for plan9/386")
+
+	updater.Keys = nil
+	_, err = updater.Download(context.Background(), release)
+	assert.ErrorContains(t, err, "no release signing key")
+	_, err = updater.Latest(context.Background(), ChannelStable)
+	assert.ErrorContains(t, err, "no release signing key")
+
+	_, err = updater.Latest(context.Background(), "nightly")
+	assert.Error(t, err)
+}
+
+func TestLatestRejectsRelabeledReleases(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for name, signed := range map[string][2]string{
+		"older version": {ChannelStable, "1.2.0"},
+		"beta build":    {ChannelBeta, "1.4.0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := releaseServer(t, []byte("signed ignition binary"), private, signed[0], signed[1])
+			updater := NewUpdater(server.URL+"/releases", []ed25519.PublicKey{public})
+			updater.Platform = "linux/amd64"
+
+			// The version is refused before it is compared with the running one
+			_, err := updater.Latest(context.Background(), ChannelStable)
+			assert.ErrorContains(t, err, "does not match any trusted key for stable 1.4.0 on linux/amd64")
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys, err := ParseKeys([]string{"", base64.StdEncoding.EncodeToString(public)})
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	_, err = ParseKeys([]string{"not a key"})
+	assert.Error(t, err)
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignition")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o755))
+
+	require.NoError(t, Replace(path, []byte("new")))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	// Nothing is left next to the executable
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -1,34 +1,42 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
+	"embed"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net"
-	"net/http"
+	"io/fs"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/ignitionstack/ignition/pkg/builders"
+	"github.com/ignitionstack/ignition/pkg/engine/api"
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/engine/ipc"
 	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/proxy"
 	"github.com/ignitionstack/ignition/pkg/registry"
 	"github.com/ignitionstack/ignition/pkg/types"
 )
 
 // FunctionService defines the interface for function-related operations.
 type FunctionService interface {
-	// InitFunction initializes a new function with the given name and language
-	InitFunction(name string, language string) error
+	// InitFunction initializes a new function of the given kind with the given name and language,
+	// downloading its template through proxyURL (empty uses HTTP(S)_PROXY)
+	InitFunction(name string, language string, kind string, proxyURL string) error
 
 	// BuildFunction builds a function and returns the build result
-	BuildFunction(path string, functionConfig manifest.FunctionManifest) (result *BuildResult, err error)
+	BuildFunction(path string, functionConfig manifest.FunctionManifest, opts types.BuildOptions) (result *BuildResult, err error)
 
 	// CalculateHash computes a hash for a function based on its source code and config
 	CalculateHash(path string, config manifest.FunctionManifest) (*BuildResult, error)
@@ -45,8 +53,30 @@ type BuildResult struct {
 	Name   string // Function name
 	Path   string // Path to the built WASM file
 	Digest string // Content hash of the built WASM file
+
+	// How the module was built
+	Provenance registry.Provenance
+
+	// Packages the module was built from, nil when the builder could not list them
+	Components []registry.SBOMComponent
 }
 
+// Function kinds InitFunction can scaffold.
+const (
+	KindDefault = "default" // the Extism PDK template of the language
+	KindHTTP    = "http"    // an HTTP handler that routes requests by method and path
+)
+
+// Kinds lists the function kinds InitFunction can scaffold.
+var Kinds = []string{KindDefault, KindHTTP}
+
+// kindTemplates holds, under templates/<kind>/<language>, the files each kind writes
+// over the language template, and under templates/base/<language> the basic language
+// templates used offline. Their names end in .tmpl so tools leave them alone.
+//
+//go:embed all:templates
+var kindTemplates embed.FS
+
 // FunctionDetails provides information about a function for internal use.
 type FunctionDetails struct {
 	Namespace string   `json:"namespace"`
@@ -55,45 +85,68 @@ type FunctionDetails struct {
 	Tags      []string `json:"tags,omitempty"`
 }
 
+// FunctionServiceOptions configures a function service.
+type FunctionServiceOptions struct {
+	// Offline keeps init and build off the network: templates come from the template
+	// cache or the basic templates bundled with the binary, and build commands only use
+	// vendored or cached dependencies
+	Offline bool
+
+	// Directory templates are cached in once cloned (empty uses ~/.ignition/templates)
+	TemplateCacheDir string
+}
+
 // functionService implements the FunctionService interface.
 type functionService struct {
-	builderFactory BuilderFactory
-	socketPath     string
-	httpClient     *http.Client
+	builderFactory   BuilderFactory
+	socketPath       string
+	engineClient     api.Client
+	offline          bool
+	templateCacheDir string
 }
 
 func NewFunctionService() FunctionService {
+	return NewFunctionServiceWithOptions(FunctionServiceOptions{})
+}
+
+// NewFunctionServiceWithOptions creates a function service configured by opts.
+func NewFunctionServiceWithOptions(opts FunctionServiceOptions) FunctionService {
 	// Use the default socket path from global config
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		homeDir = "."
 	}
-	socketPath := filepath.Join(homeDir, ".ignition", "engine.sock")
+	socketPath := ipc.DefaultPath()
+	if opts.TemplateCacheDir == "" {
+		opts.TemplateCacheDir = filepath.Join(homeDir, ".ignition", "templates")
+	}
 
 	// Create an HTTP client that connects to the Unix socket
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-				return net.Dial("unix", socketPath)
-			},
-		},
-	}
+	engineClient, _ := client.New(client.Options{SocketPath: socketPath})
 
 	return &functionService{
-		builderFactory: NewBuilderFactory(),
-		socketPath:     socketPath,
-		httpClient:     httpClient,
+		builderFactory:   NewBuilderFactory(),
+		socketPath:       socketPath,
+		engineClient:     engineClient,
+		offline:          opts.Offline,
+		templateCacheDir: opts.TemplateCacheDir,
 	}
 }
 
-func (f *functionService) BuildFunction(path string, functionConfig manifest.FunctionManifest) (*BuildResult, error) {
+func (f *functionService) BuildFunction(path string, functionConfig manifest.FunctionManifest, opts types.BuildOptions) (*BuildResult, error) {
 	language := functionConfig.FunctionSettings.Language
 	if language == "" {
 		return nil, errors.New("language not specified in function config")
 	}
 
+	// The source is hashed before the build adds its artifacts
+	sourceHash := sha256.New()
+	if err := hashSourceCode(sourceHash, path); err != nil {
+		return nil, err
+	}
+
 	// Get the appropriate builder for the language
-	builder, err := f.builderFactory.GetBuilder(language)
+	builder, err := f.builderFactory.GetBuilder(language, builders.Options{Offline: f.offline, Reproducible: opts.Reproducible, Debug: opts.Debug, Output: opts.Output})
 	if err != nil {
 		return nil, fmt.Errorf("builder initialization failed: %w", err)
 	}
@@ -114,20 +167,66 @@ func (f *functionService) BuildFunction(path string, functionConfig manifest.Fun
 		Name:   functionConfig.FunctionSettings.Name,
 		Path:   buildResult.OutputPath,
 		Digest: checksum,
+		Provenance: registry.Provenance{
+			Builder:      builderVersion(),
+			Toolchain:    buildResult.Toolchain,
+			SourceHash:   fmt.Sprintf("sha256:%x", sourceHash.Sum(nil)),
+			Flags:        buildResult.Flags,
+			Reproducible: opts.Reproducible,
+			Debug:        opts.Debug,
+			BuiltAt:      time.Now().UTC(),
+		},
+		Components: sbomComponents(buildResult.Dependencies),
 	}, nil
 }
 
-func (f *functionService) InitFunction(name string, language string) error {
+// sbomComponents returns the dependencies of a build as SBOM components.
+func sbomComponents(deps []builders.Dependency) []registry.SBOMComponent {
+	if deps == nil {
+		return nil
+	}
+	components := make([]registry.SBOMComponent, 0, len(deps))
+	for _, dep := range deps {
+		components = append(components, registry.SBOMComponent{
+			Type:    "library",
+			Name:    dep.Name,
+			Version: dep.Version,
+			PURL:    dep.PURL(),
+		})
+	}
+	return components
+}
+
+// builderVersion returns the version of ignition recorded in the provenance of builds.
+func builderVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "ignition (unknown)"
+	}
+	return fmt.Sprintf("ignition %s (%s)", info.Main.Version, info.GoVersion)
+}
+
+func (f *functionService) InitFunction(name string, language string, kind string, proxyURL string) error {
 	// Validate inputs
 	if name == "" {
 		return errors.New("function name cannot be empty")
 	}
+	if kind == "" {
+		kind = KindDefault
+	}
 
 	// Check if language is supported
 	templateURL, err := getTemplateURL(language)
 	if err != nil {
 		return err
 	}
+	if err := checkKind(kind, language); err != nil {
+		return err
+	}
+	templateProxy, err := templateProxy(templateURL, proxyURL)
+	if err != nil {
+		return err
+	}
 
 	// Create the function directory path
 	path := fmt.Sprintf("./%s", name)
@@ -137,13 +236,18 @@ func (f *functionService) InitFunction(name string, language string) error {
 		return errors.New("directory already exists")
 	}
 
-	// Clone the template repository
-	if err := cloneTemplate(path, templateURL); err != nil {
+	// Write the language template
+	if err := f.writeTemplate(path, language, templateURL, templateProxy); err != nil {
+		return err
+	}
+
+	// Write the files of the function kind over the template
+	if err := applyKindTemplate(path, kind, language); err != nil {
 		return err
 	}
 
 	// Create and write the manifest file
-	if err := createManifestFile(path, name, language); err != nil {
+	if err := createManifestFile(path, name, language, kind); err != nil {
 		return err
 	}
 
@@ -176,7 +280,7 @@ func (f *functionService) CalculateHash(path string, config manifest.FunctionMan
 
 // BuilderFactory creates language-specific builders.
 type BuilderFactory interface {
-	GetBuilder(language string) (builders.Builder, error)
+	GetBuilder(language string, opts builders.Options) (builders.Builder, error)
 }
 
 // defaultBuilderFactory is the default implementation of BuilderFactory.
@@ -187,27 +291,27 @@ func NewBuilderFactory() BuilderFactory {
 	return &defaultBuilderFactory{}
 }
 
-// GetBuilder returns a builder for the specified language.
-func (f *defaultBuilderFactory) GetBuilder(language string) (builders.Builder, error) {
+// GetBuilder returns a builder for the specified language, running its commands with opts.
+func (f *defaultBuilderFactory) GetBuilder(language string, opts builders.Options) (builders.Builder, error) {
 	switch strings.ToLower(language) {
 	case "rust":
-		return builders.NewRustBuilder(), nil
+		return builders.NewRustBuilder(opts), nil
 	case "typescript":
-		return builders.NewJSBuilder(), nil
+		return builders.NewJSBuilder(opts), nil
 	case "javascript":
-		builder := builders.NewJSBuilder()
+		builder := builders.NewJSBuilder(opts)
 		if err := builder.VerifyDependencies(); err != nil {
 			return nil, err
 		}
 		return builder, nil
 	case "golang":
-		builder := builders.NewGoBuilder()
+		builder := builders.NewGoBuilder(opts)
 		if err := builder.VerifyDependencies(); err != nil {
 			return nil, err
 		}
 		return builder, nil
 	case "assemblyscript":
-		builder := builders.NewAssemblyScriptBuilder()
+		builder := builders.NewAssemblyScriptBuilder(opts)
 		if err := builder.VerifyDependencies(); err != nil {
 			return nil, err
 		}
@@ -216,7 +320,7 @@ func (f *defaultBuilderFactory) GetBuilder(language string) (builders.Builder, e
 		// Zig builder is disabled until fixed
 		return nil, errors.New("zig builder is currently disabled; see pkg/builders/zig.go for details")
 	case "python":
-		builder := builders.NewPythonBuilder()
+		builder := builders.NewPythonBuilder(opts)
 		if err := builder.VerifyDependencies(); err != nil {
 			return nil, err
 		}
@@ -246,11 +350,76 @@ func getTemplateURL(language string) (string, error) {
 	return url, nil
 }
 
-// cloneTemplate clones a template repository to the specified path.
-func cloneTemplate(path, url string) error {
+// writeTemplate writes the template of a language to path. Online, the template is
+// cloned and cached, and the cached copy is used when cloning fails. Offline, the cached
+// copy is used, or else the basic template bundled with the binary.
+func (f *functionService) writeTemplate(path, language, templateURL, proxyURL string) error {
+	cacheDir := filepath.Join(f.templateCacheDir, strings.ToLower(language))
+	_, err := os.Stat(cacheDir)
+	cached := err == nil
+
+	if !f.offline {
+		err := cloneTemplate(path, templateURL, proxyURL)
+		if err == nil {
+			// The cache is a convenience, a function is created without it
+			_ = cacheTemplate(path, cacheDir)
+			return nil
+		}
+		if !cached {
+			return err
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove partial clone: %w", err)
+		}
+	}
+
+	if cached {
+		if err := os.CopyFS(path, os.DirFS(cacheDir)); err != nil {
+			return fmt.Errorf("failed to copy cached template: %w", err)
+		}
+		return nil
+	}
+	return writeEmbeddedTemplate(path, baseTemplateDir(language))
+}
+
+// cacheTemplate replaces the cached template of a language with a fresh clone.
+func cacheTemplate(path, cacheDir string) error {
+	if err := os.RemoveAll(cacheDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cacheDir), 0755); err != nil {
+		return err
+	}
+	return os.CopyFS(cacheDir, os.DirFS(path))
+}
+
+func baseTemplateDir(language string) string {
+	return "templates/base/" + strings.ToLower(language)
+}
+
+// templateProxy returns the proxy to clone a template through, or "" to use HTTP(S)_PROXY.
+func templateProxy(templateURL, proxyURL string) (string, error) {
+	if proxyURL == "" {
+		return "", nil
+	}
+	u, err := url.Parse(templateURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid template URL: %w", err)
+	}
+	selected, err := proxy.Settings{HTTP: proxyURL, HTTPS: proxyURL}.For(u)
+	if err != nil || selected == nil {
+		return "", err
+	}
+	return selected.String(), nil
+}
+
+// cloneTemplate clones a template repository to the specified path, through proxyURL
+// when it is set.
+func cloneTemplate(path, url, proxyURL string) error {
 	// Clone the template repository
 	_, err := git.PlainClone(path, false, &git.CloneOptions{
-		URL: url,
+		URL:          url,
+		ProxyOptions: transport.ProxyOptions{URL: proxyURL},
 	})
 	if err != nil {
 		return fmt.Errorf("error cloning template: %w", err)
@@ -264,8 +433,61 @@ func cloneTemplate(path, url string) error {
 	return nil
 }
 
+// checkKind returns an error unless the kind can be scaffolded in the language.
+func checkKind(kind, language string) error {
+	switch kind {
+	case KindDefault:
+		return nil
+	case KindHTTP:
+		if _, err := fs.Stat(kindTemplates, kindTemplateDir(kind, language)); err != nil {
+			return fmt.Errorf("kind %s is not available for %s functions", kind, language)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown function kind: %s (expected one of %s)", kind, strings.Join(Kinds, ", "))
+	}
+}
+
+func kindTemplateDir(kind, language string) string {
+	return "templates/" + kind + "/" + strings.ToLower(language)
+}
+
+// applyKindTemplate writes the files of a function kind into the function directory,
+// replacing the language template's files of the same name.
+func applyKindTemplate(path, kind, language string) error {
+	if kind == KindDefault {
+		return nil
+	}
+
+	return writeEmbeddedTemplate(path, kindTemplateDir(kind, language))
+}
+
+// writeEmbeddedTemplate writes the embedded template files under dir into the function
+// directory, dropping their .tmpl suffix.
+func writeEmbeddedTemplate(path, dir string) error {
+	return fs.WalkDir(kindTemplates, dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		content, err := kindTemplates.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read template %s: %w", file, err)
+		}
+
+		target := filepath.Join(path, filepath.FromSlash(strings.TrimSuffix(strings.TrimPrefix(file, dir+"/"), ".tmpl")))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", target, err)
+		}
+		if err := os.WriteFile(target, content, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		return nil
+	})
+}
+
 // createManifestFile creates a new manifest file for the function.
-func createManifestFile(path, name, language string) error {
+func createManifestFile(path, name, language, kind string) error {
 	// Create the function manifest
 	functionManifest := manifest.FunctionManifest{
 		FunctionSettings: manifest.FunctionSettings{
@@ -274,6 +496,10 @@ func createManifestFile(path, name, language string) error {
 			VersionSettings: manifest.FunctionVersionSettings{
 				Wasi:        true,
 				AllowedUrls: []string{},
+
+				// HTTP handlers take whole requests and answer with response envelopes
+				HTTPEnvelope: kind == KindHTTP,
+				HTTPRequest:  kind == KindHTTP,
 			},
 		},
 	}
@@ -386,79 +612,25 @@ func shouldSkipFile(path string) bool {
 }
 
 func (f *functionService) LoadFunction(ctx context.Context, namespace, name, tag string) error {
-	// Create load request
-	loadRequest := types.LoadRequest{
-		FunctionRequest: types.FunctionRequest{
+	loadRequest := api.LoadRequest{
+		BaseRequest: api.BaseRequest{
 			Namespace: namespace,
 			Name:      name,
 		},
 		Digest: tag,
 	}
 
-	// Marshal request to JSON
-	reqBytes, err := json.Marshal(loadRequest)
-	if err != nil {
-		return fmt.Errorf("failed to marshal load request: %w", err)
-	}
-
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		"http://unix/load",
-		bytes.NewBuffer(reqBytes),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	resp, err := f.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send load request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("engine returned error status %d: %s", resp.StatusCode, string(body))
+	if _, err := f.engineClient.LoadFunction(ctx, loadRequest); err != nil {
+		return err
 	}
 
 	return nil
 }
 
 func (f *functionService) ListFunctions(ctx context.Context) ([]types.FunctionInfo, error) {
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		"http://unix/list",
-		bytes.NewBufferString("{}"), // Empty JSON object for listing all functions
-	)
+	registryFunctions, err := f.engineClient.ListRegistryFunctions(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	resp, err := f.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send list request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("engine returned error status %d: %s", resp.StatusCode, string(body))
-	}
-
-	// Parse response
-	var registryFunctions []registry.FunctionMetadata
-	if err := json.NewDecoder(resp.Body).Decode(&registryFunctions); err != nil {
-		return nil, fmt.Errorf("failed to decode list response: %w", err)
+		return nil, err
 	}
 
 	// Convert registry functions to FunctionInfo
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ignitionstack/ignition/pkg/builders"
 	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -16,7 +17,7 @@ type mockBuilderFactory struct {
 	mockBuilder builders.Builder
 }
 
-func (f *mockBuilderFactory) GetBuilder(_ string) (builders.Builder, error) {
+func (f *mockBuilderFactory) GetBuilder(_ string, _ builders.Options) (builders.Builder, error) {
 	return f.mockBuilder, nil
 }
 
@@ -83,11 +84,11 @@ func TestInitFunction(t *testing.T) {
 		},
 	}
 
-	service := NewFunctionService()
+	service := NewFunctionServiceWithOptions(FunctionServiceOptions{TemplateCacheDir: t.TempDir()})
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.InitFunction(tt.name, tt.language)
+			err := service.InitFunction(tt.name, tt.language, KindDefault, "")
 			if tt.shouldError {
 				assert.Error(t, err)
 				return
@@ -135,6 +136,8 @@ func TestBuildFunction_WithMock(t *testing.T) {
 		buildFunc: func(_ string) (*builders.BuildResult, error) {
 			return &builders.BuildResult{
 				OutputPath: wasmPath,
+				Toolchain:  map[string]string{"tinygo": "tinygo version 0.31.2"},
+				Flags:      []string{"tinygo build -o plugin.wasm -target wasi -no-debug main.go"},
 			}, nil
 		},
 		verifyDependenciesFunc: func() error {
@@ -158,11 +161,20 @@ func TestBuildFunction_WithMock(t *testing.T) {
 	}
 
 	// Test building a function
-	result, err := service.BuildFunction(tempDir, config)
+	result, err := service.BuildFunction(tempDir, config, types.BuildOptions{Reproducible: true})
 	require.NoError(t, err)
 	assert.Equal(t, "test-function", result.Name)
 	assert.Equal(t, wasmPath, result.Path)
 	assert.NotEmpty(t, result.Digest)
+
+	// The build is recorded with the toolchain and the hash of the sources
+	provenance := result.Provenance
+	assert.Equal(t, "tinygo version 0.31.2", provenance.Toolchain["tinygo"])
+	assert.Len(t, provenance.Flags, 1)
+	assert.True(t, provenance.Reproducible)
+	assert.Contains(t, provenance.Builder, "ignition")
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", provenance.SourceHash)
+	assert.False(t, provenance.BuiltAt.IsZero())
 }
 
 func TestCalculateHash(t *testing.T) {
@@ -270,3 +282,63 @@ func TestGetTemplateURL(t *testing.T) {
 		})
 	}
 }
+
+func TestInitFunctionOffline(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cacheDir := t.TempDir()
+	service := NewFunctionServiceWithOptions(FunctionServiceOptions{Offline: true, TemplateCacheDir: cacheDir})
+
+	// Without a cached template, the bundled one is used
+	files := map[string]string{
+		"golang":         "main.go",
+		"javascript":     "src/index.js",
+		"typescript":     "src/index.ts",
+		"rust":           "src/lib.rs",
+		"assemblyscript": "index.ts",
+		"python":         "plugin/__init__.py",
+	}
+	for language, file := range files {
+		require.NoError(t, service.InitFunction(language+"-fn", language, KindDefault, ""), language)
+		content, err := os.ReadFile(filepath.Join(language+"-fn", file))
+		require.NoError(t, err, language)
+		assert.Contains(t, string(content), "greet", language)
+		assert.FileExists(t, filepath.Join(language+"-fn", "ignition.yml"), language)
+	}
+
+	// The http kind is written over the bundled template
+	require.NoError(t, service.InitFunction("web", "typescript", KindHTTP, ""))
+	assert.FileExists(t, filepath.Join("web", "package.json"))
+	content, err := os.ReadFile(filepath.Join("web", "src", "index.ts"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "handle")
+
+	// A cached template is preferred
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "rust", "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "rust", "src", "lib.rs"), []byte("// cached"), 0600))
+	require.NoError(t, service.InitFunction("cached", "rust", KindDefault, ""))
+	content, err = os.ReadFile(filepath.Join("cached", "src", "lib.rs"))
+	require.NoError(t, err)
+	assert.Equal(t, "// cached", string(content))
+}
+
+func TestApplyKindTemplate(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, checkKind(KindHTTP, "typescript"))
+	require.NoError(t, applyKindTemplate(dir, KindHTTP, "typescript"))
+	require.NoError(t, createManifestFile(dir, "web", "typescript", KindHTTP))
+
+	for _, file := range []string{"src/index.ts", "src/index.d.ts"} {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		require.NoError(t, err, file)
+		assert.Contains(t, string(content), "handle")
+	}
+
+	manifestContent, err := os.ReadFile(filepath.Join(dir, "ignition.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifestContent), "http_request: true")
+	assert.Contains(t, string(manifestContent), "http_envelope: true")
+
+	assert.Error(t, checkKind(KindHTTP, "python"))
+	assert.Error(t, checkKind("grpc", "golang"))
+}
@@ -8,7 +8,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ignitionstack/ignition/internal/ui"
 	"github.com/ignitionstack/ignition/internal/ui/models/spinner"
-	"github.com/ignitionstack/ignition/pkg/client"
+	"github.com/ignitionstack/ignition/pkg/engine/client"
 	"github.com/ignitionstack/ignition/pkg/types"
 )
 
@@ -19,10 +19,10 @@ type TagInfo struct {
 }
 
 type BuildHandler struct {
-	engineClient client.EngineClient
+	engineClient *client.EngineClient
 }
 
-func NewBuildHandler(engineClient client.EngineClient) *BuildHandler {
+func NewBuildHandler(engineClient *client.EngineClient) *BuildHandler {
 	return &BuildHandler{
 		engineClient: engineClient,
 	}
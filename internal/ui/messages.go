@@ -25,6 +25,11 @@ const (
 // Command prompt symbol.
 const CommandPrompt = "❯"
 
+// PlainOutputAnnotation is the command annotation naming, comma separated, the flags
+// other than --plain that switch a command to machine-readable output, such as "json".
+// The logo is left out while one of them is set, so it does not corrupt that output.
+const PlainOutputAnnotation = "ignition/plain-output-flags"
+
 // PrintLogo prints the Ignition logo banner.
 func PrintLogo() {
 	width := TerminalWidth()
@@ -163,13 +168,13 @@ func StyleStatusValue(status string) string {
 	status = strings.ToLower(status)
 
 	switch status {
-	case "running":
+	case "running", "healthy":
 		return RunningStyle.Render(SuccessSymbol + " " + status)
-	case "error", "failed":
+	case "error", "failed", "unhealthy":
 		return ErrorStyle.Render(ErrorSymbol + " " + status)
-	case "pending":
+	case "pending", "starting", "loading":
 		return PendingStyle.Render("⋯ " + status)
-	case "unloaded":
+	case "unloaded", "idle":
 		return lipgloss.NewStyle().Foreground(lipgloss.Color(UnloadedColor)).Render("◌ " + status)
 	case "stopped":
 		return StoppedStyle.Render("⊘ " + status)
@@ -9,7 +9,9 @@ import (
 	"path/filepath"
 )
 
-type assemblyscriptBuilder struct{}
+type assemblyscriptBuilder struct {
+	opts Options
+}
 
 func (a *assemblyscriptBuilder) VerifyDependencies() error {
 	cmd := exec.Command("npx", "--version")
@@ -38,9 +40,9 @@ func (a *assemblyscriptBuilder) VerifyDependencies() error {
 
 func (a *assemblyscriptBuilder) Build(path string) (*BuildResult, error) {
 	// Install dependencies
-	dependencyCmd := exec.Command("npm", "install")
+	dependencyCmd := a.opts.apply(exec.Command("npm", "install"))
 	dependencyCmd.Dir = path
-	if err := runCommandWithOutput(dependencyCmd, "dependency installation"); err != nil {
+	if err := a.opts.run(dependencyCmd, "dependency installation"); err != nil {
 		return nil, err
 	}
 
@@ -65,17 +67,30 @@ func (a *assemblyscriptBuilder) Build(path string) (*BuildResult, error) {
 	outputFile := "plugin.wasm"
 
 	// Build WASM using assemblyscript compiler
-	ascCmd := exec.Command("npx", "asc", sourceFile, "--outFile", outputFile, "--use", "abort=")
+	args := []string{"asc", sourceFile, "--outFile", outputFile, "--use", "abort="}
+	if a.opts.Debug {
+		args = append(args, "--debug")
+	}
+	ascCmd := a.opts.apply(exec.Command("npx", args...))
 	ascCmd.Dir = path
-	if err := runCommandWithOutput(ascCmd, "AssemblyScript compilation"); err != nil {
+	if err := a.opts.run(ascCmd, "AssemblyScript compilation"); err != nil {
 		return nil, err
 	}
 
+	// Packages are listed for the SBOM only, so a failure leaves it out
+	deps, _ := npmDependencies(path)
+
 	return &BuildResult{
 		OutputPath: filepath.Join(path, outputFile),
+		Toolchain: map[string]string{
+			"npm": toolchainVersion("npm", "--version"),
+			"asc": toolchainVersion("npx", "asc", "--version"),
+		},
+		Flags:        a.opts.flags(nil, dependencyCmd, ascCmd),
+		Dependencies: deps,
 	}, nil
 }
 
-func NewAssemblyScriptBuilder() Builder {
-	return &assemblyscriptBuilder{}
+func NewAssemblyScriptBuilder(opts Options) Builder {
+	return &assemblyscriptBuilder{opts: opts}
 }
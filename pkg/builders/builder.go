@@ -1,5 +1,14 @@
 package builders
 
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
 type Builder interface {
 	Build(path string) (*BuildResult, error)
 	VerifyDependencies() error
@@ -7,17 +16,164 @@ type Builder interface {
 
 type BuildResult struct {
 	OutputPath string
+
+	// Versions of the tools that built the module, by tool name
+	Toolchain map[string]string
+
+	// Environment settings and commands the module was built with
+	Flags []string
+
+	// Packages the module was built from, nil when they could not be listed
+	Dependencies []Dependency
 }
 
+// maxErrorOutput bounds the output of a failed command kept in its BuildError
+const maxErrorOutput = 4 << 10
+
 type BuildError struct {
-	Err    error
-	Stderr string
-	Step   string
+	Err  error
+	Step string
+
+	// The last lines the failed command printed, at most maxErrorOutput bytes
+	Output string
 }
 
 func (e *BuildError) Error() string {
-	if e.Stderr != "" {
-		return e.Stderr
+	msg := e.Err.Error()
+	if e.Step != "" {
+		msg = e.Step + " failed: " + msg
+	}
+	if e.Output != "" {
+		msg += "\n" + e.Output
+	}
+	return msg
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// Options configures the commands a builder runs.
+type Options struct {
+	// Offline keeps build commands off the network, so dependencies must already be
+	// vendored or in the toolchain's cache
+	Offline bool
+
+	// Reproducible normalizes the timestamps and paths toolchains embed in modules,
+	// where they allow it
+	Reproducible bool
+
+	// Debug keeps the function names and DWARF sections of modules and builds them
+	// with fewer optimizations, so traps are reported with symbols and source lines
+	Debug bool
+
+	// Output receives what build commands print on stdout and stderr; nil passes it
+	// through to the stdout and stderr of this process
+	Output io.Writer
+}
+
+// offlineEnv tells Go modules, Cargo, npm and pip to use only what is vendored or cached.
+var offlineEnv = []string{
+	"GOPROXY=off",
+	"CARGO_NET_OFFLINE=true",
+	"npm_config_offline=true",
+	"PIP_NO_INDEX=1",
+}
+
+// reproducibleEnv pins the timestamps and hash seeds toolchains read from the environment.
+var reproducibleEnv = []string{
+	"SOURCE_DATE_EPOCH=0",
+	"PYTHONHASHSEED=0",
+}
+
+func (o Options) env() []string {
+	var env []string
+	if o.Offline {
+		env = append(env, offlineEnv...)
+	}
+	if o.Reproducible {
+		env = append(env, reproducibleEnv...)
+	}
+	return env
+}
+
+// apply sets the environment of a build command, with the extra variables of a builder.
+func (o Options) apply(cmd *exec.Cmd, extra ...string) *exec.Cmd {
+	if env := append(o.env(), extra...); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+// run runs a build command, sending its output to o.Output and returning a BuildError
+// with the end of the output when it fails.
+func (o Options) run(cmd *exec.Cmd, step string) error {
+	tail := &tailBuffer{max: maxErrorOutput}
+	if o.Output != nil {
+		// A single writer for both streams keeps their lines in order
+		out := io.MultiWriter(tail, o.Output)
+		cmd.Stdout = out
+		cmd.Stderr = out
+	} else {
+		cmd.Stdout = io.MultiWriter(tail, os.Stdout)
+		cmd.Stderr = io.MultiWriter(tail, os.Stderr)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return &BuildError{
+			Err:    err,
+			Step:   step,
+			Output: tail.String(),
+		}
+	}
+	return nil
+}
+
+// tailBuffer keeps the last max bytes written to it, starting at a line when it can.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := t.buf
+	if len(out) == t.max {
+		// Drop the partial line the limit cut into
+		if i := bytes.IndexByte(out, '\n'); i >= 0 {
+			out = out[i+1:]
+		}
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// flags returns the environment settings and command lines of a build, for its provenance.
+func (o Options) flags(extra []string, cmds ...*exec.Cmd) []string {
+	flags := append(o.env(), extra...)
+	for _, cmd := range cmds {
+		flags = append(flags, strings.Join(cmd.Args, " "))
+	}
+	return flags
+}
+
+// toolchainVersion returns the first line a tool prints about its version, or "unknown".
+func toolchainVersion(name string, args ...string) string {
+	out, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return "unknown"
 	}
-	return e.Err.Error()
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return line
 }
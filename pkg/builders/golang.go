@@ -1,16 +1,15 @@
 package builders
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
-	"io"
-	"os"
 	"os/exec"
 	"path/filepath"
 )
 
-type goBuilder struct{}
+type goBuilder struct {
+	opts Options
+}
 
 func (g *goBuilder) VerifyDependencies() error {
 	cmd := exec.Command("tinygo", "version")
@@ -26,29 +25,32 @@ func (g *goBuilder) VerifyDependencies() error {
 }
 
 func (g *goBuilder) Build(path string) (*BuildResult, error) {
-	cmd := exec.Command("tinygo", "build", "-o", "plugin.wasm", "-target", "wasi", "main.go")
+	args := []string{"build", "-o", "plugin.wasm", "-target", "wasi"}
+	switch {
+	case g.opts.Debug:
+		// Less inlining keeps the frames of traps close to the sources
+		args = append(args, "-opt=1")
+	case g.opts.Reproducible:
+		// Debug information carries the paths of the sources
+		args = append(args, "-no-debug")
+	}
+	cmd := g.opts.apply(exec.Command("tinygo", append(args, "main.go")...))
 	cmd.Dir = path
-
-	// Create a buffer to capture stderr
-	var stderr bytes.Buffer
-	// Use MultiWriter to write to both stderr buffer and os.Stderr
-	multiWriter := io.MultiWriter(&stderr, os.Stderr)
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = multiWriter
-
-	if err := cmd.Run(); err != nil {
-		return nil, &BuildError{
-			Err:    err,
-			Stderr: stderr.String(),
-		}
+	if err := g.opts.run(cmd, "TinyGo compilation"); err != nil {
+		return nil, err
 	}
 
+	// Modules are listed for the SBOM only, so a failure leaves it out
+	deps, _ := goDependencies(path, g.opts)
+
 	return &BuildResult{
-		OutputPath: filepath.Join(path, "plugin.wasm"),
+		OutputPath:   filepath.Join(path, "plugin.wasm"),
+		Toolchain:    map[string]string{"tinygo": toolchainVersion("tinygo", "version")},
+		Flags:        g.opts.flags(nil, cmd),
+		Dependencies: deps,
 	}, nil
 }
 
-func NewGoBuilder() Builder {
-	return &goBuilder{}
+func NewGoBuilder(opts Options) Builder {
+	return &goBuilder{opts: opts}
 }
@@ -4,13 +4,13 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"io"
-	"os"
 	"os/exec"
 	"path/filepath"
 )
 
-type jsBuilder struct{}
+type jsBuilder struct {
+	opts Options
+}
 
 func (j *jsBuilder) VerifyDependencies() error {
 	cmd := exec.Command("extism-js", "--version")
@@ -28,50 +28,43 @@ func (j *jsBuilder) VerifyDependencies() error {
 	return nil
 }
 
-func runCommandWithOutput(cmd *exec.Cmd, step string) error {
-	var stderr bytes.Buffer
-	multiWriter := io.MultiWriter(&stderr, os.Stderr)
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = multiWriter
-
-	if err := cmd.Run(); err != nil {
-		return &BuildError{
-			Err:    err,
-			Stderr: stderr.String(),
-			Step:   step,
-		}
-	}
-	return nil
-}
-
 func (j *jsBuilder) Build(path string) (*BuildResult, error) {
 	// Install dependencies
-	dependencyCmd := exec.Command("npm", "install")
+	dependencyCmd := j.opts.apply(exec.Command("npm", "install"))
 	dependencyCmd.Dir = path
-	if err := runCommandWithOutput(dependencyCmd, "dependency installation"); err != nil {
+	if err := j.opts.run(dependencyCmd, "dependency installation"); err != nil {
 		return nil, err
 	}
 
 	// Run esbuild
-	esBuildCmd := exec.Command("node", "esbuild.js")
+	esBuildCmd := j.opts.apply(exec.Command("node", "esbuild.js"))
 	esBuildCmd.Dir = path
-	if err := runCommandWithOutput(esBuildCmd, "esbuild"); err != nil {
+	if err := j.opts.run(esBuildCmd, "esbuild"); err != nil {
 		return nil, err
 	}
 
 	// Build WASM
-	wasmCmd := exec.Command("extism-js", "dist/index.js", "-i", "src/index.d.ts", "-o", "dist/plugin.wasm")
+	wasmCmd := j.opts.apply(exec.Command("extism-js", "dist/index.js", "-i", "src/index.d.ts", "-o", "dist/plugin.wasm"))
 	wasmCmd.Dir = path
-	if err := runCommandWithOutput(wasmCmd, "WASM compilation"); err != nil {
+	if err := j.opts.run(wasmCmd, "WASM compilation"); err != nil {
 		return nil, err
 	}
 
+	// Packages are listed for the SBOM only, so a failure leaves it out
+	deps, _ := npmDependencies(path)
+
 	return &BuildResult{
 		OutputPath: filepath.Join(path, "dist", "plugin.wasm"),
+		Toolchain: map[string]string{
+			"node":      toolchainVersion("node", "--version"),
+			"npm":       toolchainVersion("npm", "--version"),
+			"extism-js": toolchainVersion("extism-js", "--version"),
+		},
+		Flags:        j.opts.flags(nil, dependencyCmd, esBuildCmd, wasmCmd),
+		Dependencies: deps,
 	}, nil
 }
 
-func NewJSBuilder() Builder {
-	return &jsBuilder{}
+func NewJSBuilder(opts Options) Builder {
+	return &jsBuilder{opts: opts}
 }
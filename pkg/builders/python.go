@@ -9,7 +9,9 @@ import (
 	"path/filepath"
 )
 
-type pythonBuilder struct{}
+type pythonBuilder struct {
+	opts Options
+}
 
 func (p *pythonBuilder) VerifyDependencies() error {
 	cmd := exec.Command("extism-py", "--version")
@@ -34,13 +36,14 @@ func (p *pythonBuilder) Build(path string) (*BuildResult, error) {
 	pluginDir := filepath.Join(path, "plugin")
 	initPyPath := filepath.Join(pluginDir, "__init__.py")
 
+	var buildCmd *exec.Cmd
 	if _, err := os.Stat(initPyPath); err == nil {
 		// We found the plugin/__init__.py structure
 		// Build WASM using extism-py with the plugin directory
-		buildCmd := exec.Command("extism-py", initPyPath, "-o", outputFile)
+		buildCmd = p.opts.apply(exec.Command("extism-py", initPyPath, "-o", outputFile))
 		buildCmd.Dir = path
 
-		if err := runCommandWithOutput(buildCmd, "Python compilation"); err != nil {
+		if err := p.opts.run(buildCmd, "Python compilation"); err != nil {
 			return nil, err
 		}
 	} else {
@@ -70,19 +73,21 @@ func (p *pythonBuilder) Build(path string) (*BuildResult, error) {
 		}
 
 		// Build WASM using extism-py with the discovered file
-		buildCmd := exec.Command("extism-py", sourceFile, "-o", outputFile)
+		buildCmd = p.opts.apply(exec.Command("extism-py", sourceFile, "-o", outputFile))
 		buildCmd.Dir = path
 
-		if err := runCommandWithOutput(buildCmd, "Python compilation"); err != nil {
+		if err := p.opts.run(buildCmd, "Python compilation"); err != nil {
 			return nil, err
 		}
 	}
 
 	return &BuildResult{
 		OutputPath: filepath.Join(path, outputFile),
+		Toolchain:  map[string]string{"extism-py": toolchainVersion("extism-py", "--version")},
+		Flags:      p.opts.flags(nil, buildCmd),
 	}, nil
 }
 
-func NewPythonBuilder() Builder {
-	return &pythonBuilder{}
+func NewPythonBuilder(opts Options) Builder {
+	return &pythonBuilder{opts: opts}
 }
@@ -18,7 +18,9 @@ type cargo struct {
 	} `toml:"package"`
 }
 
-type rustBuilder struct{}
+type rustBuilder struct {
+	opts Options
+}
 
 func (r *rustBuilder) VerifyDependencies() error {
 	// Check if cargo is installed
@@ -60,19 +62,40 @@ func (r *rustBuilder) Build(path string) (*BuildResult, error) {
 		return nil, err
 	}
 
-	cmd := exec.Command("cargo", "build", "--target=wasm32-wasip1", "-r", "-q")
+	// Paths of the sources are embedded in panic messages and debug information
+	var extra []string
+	if r.opts.Reproducible {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		extra = append(extra, "RUSTFLAGS=--remap-path-prefix="+absPath+"=.")
+	}
+	if r.opts.Debug {
+		// The release profile is kept so the module stays at the same path
+		extra = append(extra, "CARGO_PROFILE_RELEASE_DEBUG=true", "CARGO_PROFILE_RELEASE_STRIP=none")
+	}
+
+	cmd := r.opts.apply(exec.Command("cargo", "build", "--target=wasm32-wasip1", "-r", "-q"), extra...)
 	cmd.Dir = path
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := r.opts.run(cmd, "Cargo build"); err != nil {
 		return nil, err
 	}
 
+	// Packages are listed for the SBOM only, so a failure leaves it out
+	deps, _ := cargoDependencies(path, r.opts)
+
 	return &BuildResult{
 		OutputPath: filepath.Join(path, "target", "wasm32-wasip1", "release", fmt.Sprintf("%s.wasm", strings.ReplaceAll(cargoConfig.Package.Name, "-", "_"))),
+		Toolchain: map[string]string{
+			"cargo": toolchainVersion("cargo", "--version"),
+			"rustc": toolchainVersion("rustc", "--version"),
+		},
+		Flags:        r.opts.flags(extra, cmd),
+		Dependencies: deps,
 	}, nil
 }
 
-func NewRustBuilder() Builder {
-	return &rustBuilder{}
+func NewRustBuilder(opts Options) Builder {
+	return &rustBuilder{opts: opts}
 }
@@ -0,0 +1,173 @@
+package builders
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dependency is a package a module was built from.
+type Dependency struct {
+	// Package ecosystem, as in package URLs: golang, cargo or npm
+	Ecosystem string
+	Name      string
+	Version   string
+}
+
+// PURL returns the package URL of the dependency.
+func (d Dependency) PURL() string {
+	name := d.Name
+	if d.Ecosystem == "npm" && strings.HasPrefix(name, "@") {
+		// The scope of npm packages is a namespace, its @ percent-encoded
+		name = "%40" + name[1:]
+	}
+	purl := fmt.Sprintf("pkg:%s/%s", d.Ecosystem, name)
+	if d.Version != "" {
+		purl += "@" + d.Version
+	}
+	return purl
+}
+
+// goDependencies lists the modules of the build list of a Go module.
+func goDependencies(path string, opts Options) ([]Dependency, error) {
+	cmd := opts.apply(exec.Command("go", "list", "-m", "-json", "all"))
+	cmd.Dir = path
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w", err)
+	}
+
+	type module struct {
+		Path    string
+		Version string
+		Main    bool
+		Replace *module
+	}
+
+	var deps []Dependency
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var m module
+		if err := decoder.Decode(&m); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		if m.Main {
+			continue
+		}
+		if m.Replace != nil && m.Replace.Version != "" {
+			m.Path, m.Version = m.Replace.Path, m.Replace.Version
+		}
+		deps = append(deps, Dependency{Ecosystem: "golang", Name: m.Path, Version: m.Version})
+	}
+	return deps, nil
+}
+
+// cargoDependencies lists the packages a Cargo crate resolves to, without its own.
+func cargoDependencies(path string, opts Options) ([]Dependency, error) {
+	cmd := opts.apply(exec.Command("cargo", "metadata", "--format-version", "1"))
+	cmd.Dir = path
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("cargo metadata failed: %w", err)
+	}
+
+	var metadata struct {
+		Packages []struct {
+			ID      string `json:"id"`
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"packages"`
+		WorkspaceMembers []string `json:"workspace_members"`
+	}
+	if err := json.Unmarshal(out, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse cargo metadata: %w", err)
+	}
+
+	members := make(map[string]bool, len(metadata.WorkspaceMembers))
+	for _, id := range metadata.WorkspaceMembers {
+		members[id] = true
+	}
+	var deps []Dependency
+	for _, p := range metadata.Packages {
+		if members[p.ID] {
+			continue
+		}
+		deps = append(deps, Dependency{Ecosystem: "cargo", Name: p.Name, Version: p.Version})
+	}
+	return deps, nil
+}
+
+// npmDependencies lists the packages installed from the package-lock.json of a project,
+// leaving out development dependencies.
+func npmDependencies(path string) ([]Dependency, error) {
+	data, err := os.ReadFile(filepath.Join(path, "package-lock.json"))
+	if err != nil {
+		return nil, err
+	}
+	return parsePackageLock(data)
+}
+
+func parsePackageLock(data []byte) ([]Dependency, error) {
+	type lockedPackage struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Dev     bool   `json:"dev"`
+	}
+	var lock struct {
+		// Lockfile versions 2 and 3, keyed by install path
+		Packages map[string]lockedPackage `json:"packages"`
+
+		// Lockfile version 1, keyed by name
+		Dependencies map[string]lockedPackage `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse package-lock.json: %w", err)
+	}
+
+	var deps []Dependency
+	if lock.Packages != nil {
+		for installPath, p := range lock.Packages {
+			// The empty path is the project itself
+			if installPath == "" || p.Dev {
+				continue
+			}
+			name := p.Name
+			if name == "" {
+				if i := strings.LastIndex(installPath, "node_modules/"); i >= 0 {
+					name = installPath[i+len("node_modules/"):]
+				} else {
+					name = installPath
+				}
+			}
+			deps = append(deps, Dependency{Ecosystem: "npm", Name: name, Version: p.Version})
+		}
+		sortDependencies(deps)
+		return deps, nil
+	}
+	for name, p := range lock.Dependencies {
+		if p.Dev {
+			continue
+		}
+		deps = append(deps, Dependency{Ecosystem: "npm", Name: name, Version: p.Version})
+	}
+	sortDependencies(deps)
+	return deps, nil
+}
+
+func sortDependencies(deps []Dependency) {
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Name != deps[j].Name {
+			return deps[i].Name < deps[j].Name
+		}
+		return deps[i].Version < deps[j].Version
+	})
+}
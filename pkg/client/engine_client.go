@@ -1,16 +1,22 @@
 // Package client provides the public interface for interacting with the Ignition engine.
+//
+// Deprecated: use github.com/ignitionstack/ignition/pkg/engine/client, whose
+// EngineClient implements this interface.
 package client
 
 import (
 	"context"
 	"time"
 
+	engineclient "github.com/ignitionstack/ignition/pkg/engine/client"
 	"github.com/ignitionstack/ignition/pkg/engine/models"
 	"github.com/ignitionstack/ignition/pkg/manifest"
 	"github.com/ignitionstack/ignition/pkg/types"
 )
 
 // EngineClient provides an interface for interacting with the Ignition engine
+//
+// Deprecated: use *client.EngineClient from pkg/engine/client.
 type EngineClient interface {
 	// Status checks if the engine is running
 	Status(ctx context.Context) error
@@ -18,6 +24,9 @@ type EngineClient interface {
 	// LoadFunction loads a function into the engine
 	LoadFunction(ctx context.Context, namespace, name, tag string, config map[string]string) error
 
+	// LoadService loads a function and registers it under a compose service name
+	LoadService(ctx context.Context, service, namespace, name, tag string, config map[string]string) error
+
 	// UnloadFunction unloads a function from the engine
 	UnloadFunction(ctx context.Context, namespace, name string) error
 
@@ -36,8 +45,8 @@ type EngineClient interface {
 	// ListFunctions lists all loaded functions
 	ListFunctions(ctx context.Context) ([]types.LoadedFunction, error)
 
-	// GetFunctionLogs gets logs for a function
-	GetFunctionLogs(ctx context.Context, namespace, name string, since time.Duration, tail int) ([]string, error)
+	// GetFunctionLogs gets logs for a function, only those at one of levels when any are given
+	GetFunctionLogs(ctx context.Context, namespace, name string, since time.Duration, tail int, levels ...string) ([]string, error)
 
 	// UnloadFunctions unloads multiple functions at once
 	UnloadFunctions(ctx context.Context, functions []models.FunctionReference) error
@@ -45,3 +54,12 @@ type EngineClient interface {
 	// StopFunctions stops multiple functions at once
 	StopFunctions(ctx context.Context, functions []models.FunctionReference) error
 }
+
+// New creates an engine client for the given socket path.
+//
+// Deprecated: use client.NewEngineClient from pkg/engine/client.
+func New(socketPath string) (EngineClient, error) {
+	return engineclient.NewEngineClient(socketPath)
+}
+
+var _ EngineClient = (*engineclient.EngineClient)(nil)
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/audit"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/engine/policy"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// UseAdmissionPolicy adds an evaluator asked before every version is stored in the
+// registry (build, import, push) and before it is loaded, next to the configured rules
+// and webhook. A version is admitted only if every evaluator allows it.
+func (e *Engine) UseAdmissionPolicy(evaluator policy.Evaluator) {
+	e.admission.Use(evaluator)
+}
+
+// useVulnerabilityPolicy adds the configured check of SBOMs against vulnerability
+// advisories to the admission of loads.
+func (e *Engine) useVulnerabilityPolicy() error {
+	cfg := e.options.Policy.Vulnerabilities
+	if cfg.Mode == "" || cfg.Mode == "off" {
+		return nil
+	}
+	store, ok := e.registry.(registry.SBOMStore)
+	if !ok {
+		e.logger.Printf("Warning: the registry does not store SBOMs, vulnerabilities are not checked")
+		return nil
+	}
+	evaluator, err := policy.NewVulnerabilities(cfg, store, e.transport, e.reportVulnerability)
+	if err != nil {
+		return err
+	}
+	e.admission.Use(evaluator)
+	return nil
+}
+
+// reportVulnerability records a vulnerability that did not stop a version from loading:
+// overrides in the audit log, warnings in the logs of the function.
+func (e *Engine) reportVulnerability(input policy.Input, finding policy.Finding) {
+	key := GetFunctionKey(input.Namespace, input.Name)
+	if finding.Override == nil {
+		msg := fmt.Sprintf("Vulnerability %s: %s", finding, finding.Summary)
+		e.logger.Printf("Warning: %s/%s: %s", input.Namespace, input.Name, msg)
+		e.logStore.AddLog(key, logging.LevelWarning, msg)
+		return
+	}
+
+	e.logStore.AddLog(key, logging.LevelWarning, fmt.Sprintf("Vulnerability %s accepted by override: %s", finding, finding.Override.Reason))
+	e.RecordAudit(audit.Event{
+		Timestamp: time.Now(),
+		Operation: audit.OperationVulnerabilityOverride,
+		Source:    "policy",
+		Params: map[string]string{
+			"namespace": input.Namespace,
+			"name":      input.Name,
+			"digest":    input.Digest,
+			"advisory":  finding.Advisory,
+			"severity":  finding.Severity,
+			"package":   finding.Package,
+			"reason":    finding.Override.Reason,
+		},
+		Success: true,
+	})
+}
+
+// admissionError converts an admission denial into a 403 response, returning nil for other errors.
+func admissionError(err error) *RequestError {
+	var denied *policy.DeniedError
+	if !errors.As(err, &denied) {
+		return nil
+	}
+	reqErr := NewRequestErrorWithCause(denied.Error(), http.StatusForbidden, err)
+	return &reqErr
+}
@@ -4,7 +4,14 @@ import (
 	"context"
 	"time"
 
+	"github.com/ignitionstack/ignition/pkg/engine/audit"
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/dlq"
+	"github.com/ignitionstack/ignition/pkg/engine/events"
 	"github.com/ignitionstack/ignition/pkg/engine/models"
+	"github.com/ignitionstack/ignition/pkg/engine/usage"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
 )
 
 // Client is the interface for communicating with the Ignition engine
@@ -15,6 +22,15 @@ type Client interface {
 	// LoadFunction loads a function into the engine
 	LoadFunction(ctx context.Context, req LoadRequest) (*LoadResponse, error)
 
+	// LoadBatch loads several functions, concurrently where their dependencies allow
+	LoadBatch(ctx context.Context, req LoadBatchRequest) (*types.LoadBatchResponse, error)
+
+	// Apply converges the services and pipelines of the engine to a spec
+	Apply(ctx context.Context, req ApplyRequest) (*types.ApplyResponse, error)
+
+	// PlanApply reports the changes Apply would make, without making them
+	PlanApply(ctx context.Context, req ApplyRequest) (*types.ApplyResponse, error)
+
 	// UnloadFunction unloads a function from the engine
 	UnloadFunction(ctx context.Context, req UnloadRequest) error
 
@@ -30,15 +46,80 @@ type Client interface {
 	// BuildFunction builds a function
 	BuildFunction(ctx context.Context, req BuildRequest) (*BuildResponse, error)
 
+	// GetBuildLog gets the state of a recent build and its output from byte offset
+	GetBuildLog(ctx context.Context, id string, offset int64) (*types.BuildLog, error)
+
 	// ListFunctions lists all loaded functions
 	ListFunctions(ctx context.Context) ([]models.Function, error)
 
-	// GetFunctionLogs gets logs for a function
-	GetFunctionLogs(ctx context.Context, namespace, name string, since time.Duration, tail int) (LogsResponse, error)
+	// GetFunctionLogs gets logs for a function, only those at one of levels when any are given
+	GetFunctionLogs(ctx context.Context, namespace, name string, since time.Duration, tail int, levels ...string) (LogsResponse, error)
+
+	// UnloadFunctions unloads multiple functions at once, returning the outcome for each
+	UnloadFunctions(ctx context.Context, functions []models.FunctionReference) ([]BatchResult, error)
+
+	// StopFunctions stops multiple functions at once, returning the outcome for each
+	StopFunctions(ctx context.Context, functions []models.FunctionReference) ([]BatchResult, error)
+
+	// ScaleFunction sets the number of instances kept for a function
+	ScaleFunction(ctx context.Context, req ScaleRequest) error
+
+	// SetCallLogging sets which calls of a running function write entries to its logs
+	SetCallLogging(ctx context.Context, req CallLoggingRequest) error
+
+	// ReassignTag points a tag at a different digest
+	ReassignTag(ctx context.Context, req ReassignTagRequest) error
+
+	// GetRegistryFunction gets the registry metadata for a function
+	GetRegistryFunction(ctx context.Context, namespace, name string) (*registry.FunctionMetadata, error)
+
+	// ListRegistryFunctions lists every function in the registry
+	ListRegistryFunctions(ctx context.Context) ([]registry.FunctionMetadata, error)
+
+	// RegisterPipeline registers or replaces a pipeline
+	RegisterPipeline(ctx context.Context, req RegisterPipelineRequest) error
+
+	// UnregisterPipeline removes a pipeline
+	UnregisterPipeline(ctx context.Context, name string) error
+
+	// ListDeadLetters lists the failed calls captured for a function
+	ListDeadLetters(ctx context.Context, namespace, name string) ([]dlq.Entry, error)
+
+	// RedriveDeadLetters replays captured calls, all of them when no IDs are given
+	RedriveDeadLetters(ctx context.Context, namespace, name string, ids []string) (*RedriveResponse, error)
+
+	// PurgeDeadLetters removes captured calls, all of them when no IDs are given
+	PurgeDeadLetters(ctx context.Context, namespace, name string, ids []string) (int, error)
+
+	// GetAuditLog gets admin audit events recorded within the given window
+	GetAuditLog(ctx context.Context, since time.Duration, operation string) ([]audit.Event, error)
+
+	// GetUsage gets the usage of every namespace this month, or of one namespace
+	GetUsage(ctx context.Context, namespace string) ([]usage.Report, error)
+
+	// ListCircuitBreakers gets the state of every circuit breaker
+	ListCircuitBreakers(ctx context.Context) ([]components.CircuitBreakerStatus, error)
+
+	// SyncRegistry copies the versions and tags the target is missing, to the configured
+	// replication target when target is empty
+	SyncRegistry(ctx context.Context, target, token string) (*types.RegistrySyncReport, error)
+
+	// PullVersion reads a version and its module from the registry of the engine
+	PullVersion(ctx context.Context, namespace, name, reference string) (*types.PullResponse, error)
+
+	// AddVariant stores a variant of a version in the registry of the engine
+	AddVariant(ctx context.Context, namespace, name, reference, variant string, payload []byte) (*registry.VersionInfo, error)
+
+	// FunctionSBOM reads the SBOM of a version from the registry of the engine
+	FunctionSBOM(ctx context.Context, namespace, name, reference string) (*registry.SBOM, error)
+
+	// Snapshot captures the runtime state of the engine
+	Snapshot(ctx context.Context) (*types.EngineSnapshot, error)
 
-	// UnloadFunctions unloads multiple functions at once
-	UnloadFunctions(ctx context.Context, functions []models.FunctionReference) error
+	// RedactedSnapshot captures the runtime state of the engine with secret config values masked
+	RedactedSnapshot(ctx context.Context) (*types.EngineSnapshot, error)
 
-	// StopFunctions stops multiple functions at once
-	StopFunctions(ctx context.Context, functions []models.FunctionReference) error
+	// StreamEvents calls handle with each lifecycle event matching the filter until ctx is
+	// done, handle returns an error or the engine ends the stream
+	StreamEvents(ctx context.Context, filter events.Filter, handle func(events.Event) error) error
 }
@@ -1,6 +1,13 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
 	"github.com/ignitionstack/ignition/pkg/engine/models"
 	"github.com/ignitionstack/ignition/pkg/manifest"
 )
@@ -17,6 +24,81 @@ type LoadRequest struct {
 	Digest    string            `json:"digest"`
 	Config    map[string]string `json:"config,omitempty"`
 	ForceLoad bool              `json:"force_load,omitempty"`
+	Service   string            `json:"service,omitempty"`
+
+	// Sent as the Idempotency-Key header, so a retry gets the response of the first request
+	IdempotencyKey string `json:"-"`
+
+	// Import the module from an https:// URL or oci:// reference before loading
+	Source       string `json:"source,omitempty"`
+	SourceDigest string `json:"source_digest,omitempty"`
+
+	// Log retention of the function; zero values use the engine defaults
+	LogMaxEntries int   `json:"log_max_entries,omitempty"`
+	LogMaxAgeMs   int64 `json:"log_max_age_ms,omitempty"`
+
+	// Version to load when the function is reloaded automatically: latest, pinned,
+	// tag:<tag> or a semver range such as ~1.2
+	ReloadPolicy string `json:"reload_policy,omitempty"`
+
+	// Priority of the function's calls when its pool is saturated: high, normal or low
+	Priority string `json:"priority,omitempty"`
+
+	// Longest the function's calls wait for a free instance; zero uses the engine default
+	MaxQueueWaitMs int64 `json:"max_queue_wait_ms,omitempty"`
+
+	// Calls that write entries to the function's logs: all, quiet or 1/N to log one call in N
+	CallLogging string `json:"call_logging,omitempty"`
+
+	// Variant of the version to load, "default" for its module (empty loads the
+	// preferred variants of the engine)
+	Variant string `json:"variant,omitempty"`
+}
+
+// LoadBatchItem is one function of a batch load
+type LoadBatchItem struct {
+	LoadRequest
+
+	// Services of the batch that must load before this one
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// Instances to keep after the load, 0 to restore autoscaling; nil leaves the scaling as it is
+	Scale *int `json:"scale,omitempty"`
+}
+
+// LoadBatchRequest represents a request to load several functions at once
+type LoadBatchRequest struct {
+	Loads []LoadBatchItem `json:"loads"`
+
+	// Loads running at once; zero uses the engine default
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// Sent as the Idempotency-Key header, so a retry gets the response of the first request
+	IdempotencyKey string `json:"-"`
+}
+
+// ApplyService is a service of an applied spec
+type ApplyService struct {
+	Function  string            `json:"function"` // namespace/name:tag, the tag is optional with a source
+	Source    string            `json:"source,omitempty"`
+	Digest    string            `json:"digest,omitempty"`
+	Config    map[string]string `json:"config,omitempty"`
+	DependsOn []string          `json:"depends_on,omitempty"`
+	Scale     int               `json:"scale,omitempty"`
+}
+
+// ApplyRequest represents the desired services and pipelines of a project; the ones of
+// the project missing from it are removed
+type ApplyRequest struct {
+	Project   string                    `json:"project"`
+	Services  map[string]ApplyService   `json:"services"`
+	Pipelines map[string][]PipelineStep `json:"pipelines,omitempty"`
+
+	// Loads running at once; zero uses the engine default
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// Sent as the Idempotency-Key header, so a retry gets the response of the first request
+	IdempotencyKey string `json:"-"`
 }
 
 // UnloadRequest represents a request to unload a function from the engine
@@ -35,6 +117,17 @@ type CallRequest struct {
 	Entrypoint string            `json:"entrypoint"`
 	Payload    string            `json:"payload,omitempty"`
 	Config     map[string]string `json:"config,omitempty"`
+
+	// Raw payload sent in a multipart body instead of the Payload string, so binary
+	// data arrives unchanged; takes precedence over Payload when not nil
+	BinaryPayload []byte `json:"-"`
+
+	// Per-call deadline sent as the X-Ignition-Timeout header (0 uses the engine default)
+	Timeout time.Duration `json:"-"`
+
+	// Queue priority sent as the X-Ignition-Priority header: high, normal or low
+	// (empty uses the function's priority)
+	Priority string `json:"-"`
 }
 
 // OneOffCallRequest represents a request to call a function by loading it temporarily
@@ -44,6 +137,17 @@ type OneOffCallRequest struct {
 	Entrypoint string            `json:"entrypoint"`
 	Payload    string            `json:"payload,omitempty"`
 	Config     map[string]string `json:"config,omitempty"`
+
+	// Raw payload sent in a multipart body instead of the Payload string, so binary
+	// data arrives unchanged; takes precedence over Payload when not nil
+	BinaryPayload []byte `json:"-"`
+
+	// Per-call deadline sent as the X-Ignition-Timeout header (0 uses the engine default)
+	Timeout time.Duration `json:"-"`
+
+	// Queue priority sent as the X-Ignition-Priority header: high, normal or low
+	// (empty uses the function's priority)
+	Priority string `json:"-"`
 }
 
 // BuildRequest represents a request to build a function
@@ -52,6 +156,70 @@ type BuildRequest struct {
 	Path     string                    `json:"path"`
 	Tag      string                    `json:"tag,omitempty"`
 	Manifest manifest.FunctionManifest `json:"manifest"`
+
+	// Reproducible normalizes the timestamps and paths the toolchain embeds in the module
+	Reproducible bool `json:"reproducible,omitempty"`
+
+	// Debug keeps function names and DWARF sections in the module
+	Debug bool `json:"debug,omitempty"`
+
+	// BuildID names the build, so its output can be read while it runs
+	BuildID string `json:"build_id,omitempty"`
+
+	// Sent as the Idempotency-Key header, so a retry gets the response of the first request
+	IdempotencyKey string `json:"-"`
+}
+
+// ReassignTagRequest represents a request to point a tag at a different digest
+type ReassignTagRequest struct {
+	BaseRequest
+	Tag    string `json:"tag"`
+	Digest string `json:"digest"`
+}
+
+// PipelineStep is one entrypoint call in a pipeline, addressed by service or by namespace/name
+type PipelineStep struct {
+	Service    string `json:"service,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Entrypoint string `json:"entrypoint"`
+	TimeoutMs  int64  `json:"timeout_ms,omitempty"`
+	OnError    string `json:"on_error,omitempty"`
+}
+
+// RegisterPipelineRequest represents a request to register a pipeline
+type RegisterPipelineRequest struct {
+	Name  string         `json:"name"`
+	Steps []PipelineStep `json:"steps"`
+}
+
+// UnregisterPipelineRequest represents a request to remove a pipeline
+type UnregisterPipelineRequest struct {
+	Name string `json:"name"`
+}
+
+// DeadLetterRequest selects dead letter entries by ID; no IDs selects every entry
+type DeadLetterRequest struct {
+	IDs []string `json:"ids,omitempty"`
+}
+
+// RedriveResult represents the outcome of replaying one dead letter entry
+type RedriveResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RedriveResponse represents the response from a dead letter re-drive
+type RedriveResponse struct {
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+	Results   []RedriveResult `json:"results"`
+}
+
+// PurgeResponse represents the response from a dead letter purge
+type PurgeResponse struct {
+	Removed int `json:"removed"`
 }
 
 // StatusResponse represents the response from a status check
@@ -59,6 +227,15 @@ type StatusResponse struct {
 	Status    string `json:"status"`
 	Version   string `json:"version"`
 	Timestamp string `json:"timestamp"`
+
+	// Instance pool statistics keyed by namespace/name
+	Pools map[string]components.PoolStats `json:"pools,omitempty"`
+
+	// Function log store usage
+	Logs *logging.LogStoreUsage `json:"logs,omitempty"`
+
+	// Cold start phases keyed by namespace/name
+	ColdStarts map[string]components.ColdStartStats `json:"cold_starts,omitempty"`
 }
 
 // CallResponse represents the response from a function call
@@ -88,11 +265,104 @@ type ListFunctionsResponse struct {
 // LogsResponse represents the response from a logs request
 type LogsResponse []string
 
+// ScaleRequest sets the number of instances of a function, addressed by
+// namespace and name or by service name. Zero instances restores autoscaling.
+type ScaleRequest struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Service   string `json:"service,omitempty"`
+	Instances int    `json:"instances"`
+}
+
+// CallLoggingRequest sets which calls of a running function write entries to its logs,
+// addressed by namespace and name or by service name.
+type CallLoggingRequest struct {
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Service     string `json:"service,omitempty"`
+	CallLogging string `json:"call_logging"`
+}
+
+// BatchResult is the outcome of a batch operation for one function
+type BatchResult struct {
+	Function models.FunctionReference
+
+	// Number of requests sent, including retries
+	Attempts int
+
+	// Final error, nil on success
+	Err error
+}
+
+// BatchError reports a batch operation in which some functions failed
+type BatchError struct {
+	Operation string
+	Results   []BatchResult
+}
+
+// Failed returns the results of the functions that failed
+func (e *BatchError) Failed() []BatchResult {
+	var failed []BatchResult
+	for _, result := range e.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+func (e *BatchError) Error() string {
+	var lines []string
+	for _, result := range e.Failed() {
+		lines = append(lines, fmt.Sprintf("failed to %s function '%s/%s' for service '%s': %v",
+			e.Operation, result.Function.Namespace, result.Function.Name, result.Function.Service, result.Err))
+	}
+	return fmt.Sprintf("failed to %s some functions:\n%s", e.Operation, strings.Join(lines, "\n"))
+}
+
 // ResponseError represents an error response from the engine API
 type ResponseError struct {
 	ErrorType string `json:"error"`
 	Message   string `json:"message"`
 	Code      int    `json:"code"`
+
+	// Domain and code of engine errors, such as execution and execution_trap
+	Domain    string `json:"domain,omitempty"`
+	ErrorCode string `json:"-"`
+
+	// Seconds to wait before retrying a call turned away under load
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// UnmarshalJSON reads an error response, whose code is either the HTTP status or, for
+// engine errors, their code with the status given separately.
+func (e *ResponseError) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ErrorType  string          `json:"error"`
+		Message    string          `json:"message"`
+		Code       json.RawMessage `json:"code"`
+		Status     int             `json:"status"`
+		Domain     string          `json:"domain"`
+		RetryAfter int             `json:"retry_after"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*e = ResponseError{ErrorType: raw.ErrorType, Message: raw.Message, Code: raw.Status, Domain: raw.Domain,
+		RetryAfter: raw.RetryAfter}
+	if len(raw.Code) == 0 || string(raw.Code) == "null" {
+		return nil
+	}
+	var status int
+	if err := json.Unmarshal(raw.Code, &status); err == nil {
+		e.Code = status
+		return nil
+	}
+	if err := json.Unmarshal(raw.Code, &e.ErrorCode); err != nil {
+		return fmt.Errorf("invalid error code %s", raw.Code)
+	}
+	return nil
 }
 
 func (e ResponseError) Error() string {
@@ -0,0 +1,363 @@
+package engine
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// serviceSnapshot is the state of a service and its function before an apply loads it.
+type serviceSnapshot struct {
+	registered    bool
+	target        FunctionKey // function the service pointed at
+	loaded        bool
+	stopped       bool
+	digest        string
+	configChanged bool
+	configKeys    []string // keys whose value differs from the spec
+	scale         int
+}
+
+// snapshotService records the state an apply compares a service against.
+func (e *Engine) snapshotService(req types.LoadRequest) serviceSnapshot {
+	key := GetFunctionKey(req.Namespace, req.Name)
+	snapshot := serviceSnapshot{
+		loaded:  e.pluginManager.IsPluginLoaded(key),
+		stopped: e.IsFunctionStopped(req.Namespace, req.Name),
+		scale:   e.pluginManager.GetFunctionScales()[key],
+	}
+	snapshot.target, snapshot.registered = e.services.Resolve(req.Service)
+	if snapshot.loaded {
+		config := e.loadedConfig(key, loadConfig(req))
+		current, _ := e.pluginManager.GetPluginConfig(key)
+		snapshot.digest, _ = e.pluginManager.GetPluginDigest(key)
+		snapshot.configChanged = e.pluginManager.HasConfigChanged(key, config)
+		snapshot.configKeys = changedConfigKeys(current, config)
+	}
+	return snapshot
+}
+
+// changedConfigKeys returns the keys set to different values in two configs, sorted.
+// Values are left out since they may be secrets.
+func changedConfigKeys(current, desired map[string]string) []string {
+	var keys []string
+	for key, value := range desired {
+		if previous, ok := current[key]; !ok || previous != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range current {
+		if _, ok := desired[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// resolveDigest returns the digest loading a request would load, read from the registry
+// metadata without pulling the module. It reports false when the registry holds no
+// matching version, or when the request imports a source without pinning its digest.
+func (e *Engine) resolveDigest(req types.LoadRequest) (string, bool) {
+	if req.Source != "" {
+		return req.SourceDigest, req.SourceDigest != ""
+	}
+
+	metadata, err := e.registry.Get(req.Namespace, req.Name)
+	if err != nil {
+		return "", false
+	}
+	if version := findVersionByTag(metadata, req.Digest); version != nil {
+		return version.FullDigest, true
+	}
+	for _, version := range metadata.Versions {
+		if version.Hash == req.Digest || version.FullDigest == req.Digest {
+			return version.FullDigest, true
+		}
+	}
+	if versionRange, err := registry.ParseVersionRange(req.Digest); err == nil {
+		if version, _, ok := registry.ResolveVersionRange(metadata.Versions, versionRange); ok {
+			return version.FullDigest, true
+		}
+	}
+	return "", false
+}
+
+// serviceChange reports what loading a service did, given its state before and the digest
+// of its function after.
+func serviceChange(item types.LoadBatchItem, result types.LoadBatchResult, before serviceSnapshot, digest string) types.ApplyChange {
+	change := classifyService(item, before, digest)
+	switch result.Status {
+	case types.LoadBatchFailed:
+		change.Action, change.Reasons, change.ConfigKeys, change.Error = types.ApplyFailed, nil, nil, result.Error
+	case types.LoadBatchSkipped:
+		change.Action, change.Reasons, change.ConfigKeys, change.Error = types.ApplySkipped, nil, nil, result.Error
+	}
+	return change
+}
+
+// classifyService compares a service of the spec with its state before the apply, given
+// the digest its function has, or would have, once loaded.
+func classifyService(item types.LoadBatchItem, before serviceSnapshot, digest string) types.ApplyChange {
+	key := GetFunctionKey(item.Namespace, item.Name)
+	change := types.ApplyChange{
+		Kind:           types.ApplyKindService,
+		Name:           item.Service,
+		Function:       key.String(),
+		Digest:         digest,
+		PreviousDigest: before.digest,
+	}
+
+	// A stopped function lost its service names, so it comes back as a new service
+	if !before.registered {
+		change.Action = types.ApplyCreated
+		if before.stopped {
+			change.Reasons = append(change.Reasons, "stopped")
+		}
+		return change
+	}
+
+	if before.target != key {
+		change.Reasons = append(change.Reasons, "function")
+	}
+	switch {
+	case !before.loaded:
+		change.Reasons = append(change.Reasons, "loaded")
+	case digest != "" && before.digest != digest:
+		change.Reasons = append(change.Reasons, "digest")
+	}
+	if before.configChanged {
+		change.Reasons = append(change.Reasons, "config")
+		change.ConfigKeys = before.configKeys
+	}
+	if item.Scale != nil && before.scale != *item.Scale {
+		change.Reasons = append(change.Reasons, "scale")
+	}
+
+	change.Action = types.ApplyUnchanged
+	if len(change.Reasons) > 0 {
+		change.Action = types.ApplyUpdated
+	}
+	return change
+}
+
+// handleApply converges the services and pipelines of a project to a compose-style spec:
+// it loads new services, reloads changed ones, stops the services of the project missing
+// from the spec and registers or removes its pipelines, then reports every change.
+func (h *Handlers) handleApply(w http.ResponseWriter, r *http.Request) error {
+	var req types.ApplyRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	if !h.applying.TryLock() {
+		return NewRequestError("Another apply is still running", http.StatusConflict)
+	}
+	defer h.applying.Unlock()
+
+	h.logger.Printf("Received apply request for project %s with %d services and %d pipelines",
+		req.Project, len(req.Services), len(req.Pipelines))
+
+	// A client giving up does not stop the apply halfway between two states
+	resp := h.apply(context.WithoutCancel(r.Context()), req)
+	return h.writeJSONResponse(w, resp)
+}
+
+// handleApplyPlan reports the changes applying a spec would make, without making them.
+// It only reads the engine, so it takes neither the apply lock nor a privileged caller.
+func (h *Handlers) handleApplyPlan(w http.ResponseWriter, r *http.Request) error {
+	var req types.ApplyRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	return h.writeJSONResponse(w, h.plan(req))
+}
+
+// apply converges the engine to a validated spec.
+func (h *Handlers) apply(ctx context.Context, req types.ApplyRequest) types.ApplyResponse {
+	batch, _ := req.LoadBatch()
+
+	before := make([]serviceSnapshot, len(batch.Loads))
+	for i, item := range batch.Loads {
+		before[i] = h.engine.snapshotService(item.LoadRequest)
+	}
+	var results []types.LoadBatchResult
+	if len(batch.Loads) > 0 {
+		results = runLoadBatch(ctx, batch, h.loadBatchItem)
+	}
+
+	var changes []types.ApplyChange
+	desired := make(map[FunctionKey]bool, len(batch.Loads))
+	for i, item := range batch.Loads {
+		key := GetFunctionKey(item.Namespace, item.Name)
+		desired[key] = true
+		digest, _ := h.engine.pluginManager.GetPluginDigest(key)
+		changes = append(changes, serviceChange(item, results[i], before[i], digest))
+		if results[i].Status == types.LoadBatchLoaded {
+			h.engine.services.SetOwner(item.Service, req.Project)
+		}
+	}
+	h.stopReplacedFunctions(before, desired)
+	changes = append(changes, h.removeServices(req.Project, req.Services, desired, false)...)
+	return applyResponse(changes, h.applyPipelines(req.Project, req.Pipelines, false))
+}
+
+// plan reports the changes applying a validated spec would make, without making them.
+func (h *Handlers) plan(req types.ApplyRequest) types.ApplyResponse {
+	batch, _ := req.LoadBatch()
+
+	var changes []types.ApplyChange
+	desired := make(map[FunctionKey]bool, len(batch.Loads))
+	for _, item := range batch.Loads {
+		desired[GetFunctionKey(item.Namespace, item.Name)] = true
+
+		digest, ok := h.engine.resolveDigest(item.LoadRequest)
+		change := classifyService(item, h.engine.snapshotService(item.LoadRequest), digest)
+		if !ok && item.Source == "" {
+			change.Action, change.Reasons, change.ConfigKeys = types.ApplyFailed, nil, nil
+			change.Error = fmt.Sprintf("no version of %s matches %q in the registry", change.Function, item.Digest)
+		}
+		changes = append(changes, change)
+	}
+	changes = append(changes, h.removeServices(req.Project, req.Services, desired, true)...)
+	return applyResponse(changes, h.applyPipelines(req.Project, req.Pipelines, true))
+}
+
+// applyResponse sorts the service changes by name, appends the pipeline changes and
+// counts the actions.
+func applyResponse(services, pipelines []types.ApplyChange) types.ApplyResponse {
+	slices.SortStableFunc(services, func(a, b types.ApplyChange) int { return cmp.Compare(a.Name, b.Name) })
+
+	resp := types.ApplyResponse{Changes: append(services, pipelines...)}
+	for _, change := range resp.Changes {
+		switch change.Action {
+		case types.ApplyCreated:
+			resp.Created++
+		case types.ApplyUpdated:
+			resp.Updated++
+		case types.ApplyUnchanged:
+			resp.Unchanged++
+		case types.ApplyRemoved:
+			resp.Removed++
+		case types.ApplyFailed:
+			resp.Failed++
+		case types.ApplySkipped:
+			resp.Skipped++
+		}
+	}
+	return resp
+}
+
+// removeServices removes the services of the project missing from the spec, or only
+// reports them on a dry run. Their function is stopped, unless a service of the spec or a
+// service the apply leaves alone still loads it.
+func (h *Handlers) removeServices(project string, services map[string]types.ApplyService, desired map[FunctionKey]bool, dryRun bool) []types.ApplyChange {
+	entries := h.engine.services.Entries()
+	var removed []string
+	kept := make(map[FunctionKey]bool)
+	for _, service := range slices.Sorted(maps.Keys(entries)) {
+		_, wanted := services[service]
+		if wanted || h.engine.services.Owner(service) != project {
+			kept[entries[service]] = true
+			continue
+		}
+		removed = append(removed, service)
+	}
+
+	var changes []types.ApplyChange
+	stopped := make(map[FunctionKey]bool)
+	for _, service := range removed {
+		key := entries[service]
+		change := types.ApplyChange{Kind: types.ApplyKindService, Name: service, Function: key.String(), Action: types.ApplyRemoved}
+		change.PreviousDigest, _ = h.engine.pluginManager.GetPluginDigest(key)
+
+		switch {
+		case dryRun:
+		case desired[key] || kept[key]:
+			h.engine.services.Unregister(service)
+		case !stopped[key]:
+			// Stopping a function also removes every service name pointing at it
+			if err := h.engine.StopFunction(key.Namespace, key.Name); err != nil {
+				change.Action = types.ApplyFailed
+				change.Error = err.Error()
+			}
+			stopped[key] = true
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// stopReplacedFunctions stops the functions services of the spec pointed at before the
+// apply moved them to another function, unless a service still uses them.
+func (h *Handlers) stopReplacedFunctions(before []serviceSnapshot, desired map[FunctionKey]bool) {
+	used := make(map[FunctionKey]bool)
+	for _, key := range h.engine.services.Entries() {
+		used[key] = true
+	}
+	for _, snapshot := range before {
+		if !snapshot.registered || desired[snapshot.target] || used[snapshot.target] {
+			continue
+		}
+		if err := h.engine.StopFunction(snapshot.target.Namespace, snapshot.target.Name); err != nil {
+			h.logger.Printf("Failed to stop replaced function %s: %v", snapshot.target, err)
+		}
+		used[snapshot.target] = true
+	}
+}
+
+// applyPipelines registers the pipelines of the spec for the project and removes the other
+// pipelines of the project, or only reports what it would do on a dry run.
+func (h *Handlers) applyPipelines(project string, pipelines map[string][]types.PipelineStep, dryRun bool) []types.ApplyChange {
+	var names []string
+	for _, name := range h.engine.pipelines.List() {
+		if h.engine.pipelines.Owner(name) == project {
+			names = append(names, name)
+		}
+	}
+	for name := range pipelines {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	changes := make([]types.ApplyChange, 0, len(names))
+	for _, name := range names {
+		change := types.ApplyChange{Kind: types.ApplyKindPipeline, Name: name}
+		current, exists := h.engine.pipelines.Get(name)
+		steps, wanted := pipelines[name]
+
+		switch {
+		case !wanted:
+			change.Action = types.ApplyRemoved
+		case !exists:
+			change.Action = types.ApplyCreated
+		case slices.Equal(current, steps):
+			change.Action = types.ApplyUnchanged
+		default:
+			change.Action = types.ApplyUpdated
+			change.Reasons = []string{"steps"}
+		}
+		changes = append(changes, change)
+
+		switch {
+		case dryRun:
+		case change.Action == types.ApplyRemoved:
+			h.engine.UnregisterPipeline(name)
+		case change.Action == types.ApplyUnchanged:
+			h.engine.pipelines.SetOwner(name, project)
+		default:
+			h.engine.RegisterPipeline(name, steps)
+			h.engine.pipelines.SetOwner(name, project)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"io"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceChange(t *testing.T) {
+	scale := 2
+	item := batchItem("api")
+	item.Scale = &scale
+	loaded := types.LoadBatchResult{Status: types.LoadBatchLoaded}
+	key := GetFunctionKey("ns", "api")
+
+	change := serviceChange(item, loaded, serviceSnapshot{}, "sha256:b")
+	assert.Equal(t, types.ApplyCreated, change.Action)
+	assert.Equal(t, "ns/api", change.Function)
+
+	same := serviceSnapshot{registered: true, target: key, loaded: true, digest: "sha256:b", scale: 2}
+	change = serviceChange(item, loaded, same, "sha256:b")
+	assert.Equal(t, types.ApplyUnchanged, change.Action)
+	assert.Empty(t, change.Reasons)
+
+	changed := serviceSnapshot{
+		registered:    true,
+		target:        GetFunctionKey("ns", "old"),
+		loaded:        true,
+		digest:        "sha256:a",
+		configChanged: true,
+		configKeys:    changedConfigKeys(map[string]string{"LEVEL": "info", "OLD": "1"}, map[string]string{"LEVEL": "debug", "NEW": "1"}),
+	}
+	change = serviceChange(item, loaded, changed, "sha256:b")
+	assert.Equal(t, types.ApplyUpdated, change.Action)
+	assert.Equal(t, []string{"function", "digest", "config", "scale"}, change.Reasons)
+	assert.Equal(t, []string{"LEVEL", "NEW", "OLD"}, change.ConfigKeys)
+	assert.Equal(t, "sha256:a", change.PreviousDigest)
+	assert.Equal(t, "sha256:b", change.Digest)
+
+	stopped := serviceSnapshot{stopped: true}
+	change = serviceChange(item, loaded, stopped, "sha256:b")
+	assert.Equal(t, types.ApplyCreated, change.Action)
+	assert.Equal(t, []string{"stopped"}, change.Reasons)
+
+	change = serviceChange(item, types.LoadBatchResult{Status: types.LoadBatchSkipped, Error: `dependency "db" was not loaded`}, same, "")
+	assert.Equal(t, types.ApplySkipped, change.Action)
+	assert.Contains(t, change.Error, "db")
+}
+
+func TestApplyPipelines(t *testing.T) {
+	h := &Handlers{engine: &Engine{pipelines: NewPipelineRegistry(), logger: logging.NewStdLogger(io.Discard)}}
+	step := types.PipelineStep{Service: "api", Entrypoint: "handle"}
+	for _, name := range []string{"kept", "edited", "dropped"} {
+		h.engine.RegisterPipeline(name, []types.PipelineStep{step})
+		h.engine.pipelines.SetOwner(name, "shop")
+	}
+
+	// Pipelines of other projects, or registered outside any, are left alone
+	h.engine.RegisterPipeline("billing", []types.PipelineStep{step})
+	h.engine.pipelines.SetOwner("billing", "accounts")
+	h.engine.RegisterPipeline("manual", []types.PipelineStep{step})
+
+	edited := []types.PipelineStep{step, {Service: "worker", Entrypoint: "store"}}
+	spec := map[string][]types.PipelineStep{
+		"kept":   {step},
+		"edited": edited,
+		"added":  {step},
+	}
+
+	// A dry run reports the same changes without making them
+	planned := h.applyPipelines("shop", spec, true)
+	assert.Equal(t, []string{"billing", "dropped", "edited", "kept", "manual"}, h.engine.pipelines.List())
+
+	changes := h.applyPipelines("shop", spec, false)
+	assert.Equal(t, planned, changes)
+
+	actions := make(map[string]string, len(changes))
+	for _, change := range changes {
+		actions[change.Name] = change.Action
+	}
+	assert.Equal(t, map[string]string{
+		"added":   types.ApplyCreated,
+		"dropped": types.ApplyRemoved,
+		"edited":  types.ApplyUpdated,
+		"kept":    types.ApplyUnchanged,
+	}, actions)
+	assert.Equal(t, []string{"added", "billing", "edited", "kept", "manual"}, h.engine.pipelines.List())
+	assert.Equal(t, "shop", h.engine.pipelines.Owner("added"))
+	assert.Equal(t, "accounts", h.engine.pipelines.Owner("billing"))
+	assert.Empty(t, h.engine.pipelines.Owner("manual"))
+
+	steps, _ := h.engine.pipelines.Get("edited")
+	assert.Equal(t, edited, steps)
+}
+
+func TestApplyRequestLoadBatch(t *testing.T) {
+	req := types.ApplyRequest{Project: "shop", Services: map[string]types.ApplyService{
+		"web":    {Function: "shop/web:^1.2", DependsOn: []string{"db"}, Scale: 3},
+		"db":     {Function: "shop/db:v2"},
+		"resize": {Function: "images/resize", Source: "oci://ghcr.io/acme/resize:1.4.0"},
+	}}
+	batch, err := req.LoadBatch()
+	require.NoError(t, err)
+	require.Len(t, batch.Loads, 3)
+
+	web := batch.Loads[2]
+	assert.Equal(t, "web", web.Service)
+	assert.Equal(t, "^1.2", web.Digest)
+	assert.Equal(t, 3, *web.Scale)
+	assert.True(t, web.ForceLoad)
+
+	req.Project = ""
+	assert.ErrorContains(t, req.Validate(), "project is required")
+
+	req.Project = "shop"
+	req.Services["db"] = types.ApplyService{Function: "shop/db"}
+	assert.ErrorContains(t, req.Validate(), "needs a tag")
+}
+
+func TestRemoveServicesOfProject(t *testing.T) {
+	engine, tmpDir := setupTestEngine(t)
+	defer cleanupTest(tmpDir)
+	h := NewHandlers(engine, engine.logger)
+
+	for service, owner := range map[string]string{"api": "shop", "legacy": "shop", "ledger": "accounts", "manual": ""} {
+		require.NoError(t, engine.RegisterService(service, "ns", service))
+		engine.services.SetOwner(service, owner)
+	}
+
+	// Only the services the project applied are removed when missing from its spec
+	changes := h.removeServices("shop", map[string]types.ApplyService{"api": {Function: "ns/api:v1"}}, nil, true)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "legacy", changes[0].Name)
+	assert.Equal(t, types.ApplyRemoved, changes[0].Action)
+
+	// Loading a service outside an apply takes it out of its project
+	require.NoError(t, engine.RegisterService("legacy", "ns", "legacy"))
+	assert.Empty(t, h.removeServices("shop", map[string]types.ApplyService{"api": {Function: "ns/api:v1"}}, nil, true))
+}
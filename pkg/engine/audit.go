@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ignitionstack/ignition/pkg/engine/audit"
+)
+
+// maxAuditBodySize caps how much of a request body is inspected for audit parameters
+const maxAuditBodySize = 1 << 20
+
+// peerSourceKey is the context key holding the socket peer of a connection
+type peerSourceKey struct{}
+
+// peerInfo describes the other end of an admin API connection. Unix socket peers
+// carry the uid, gid and pid of the calling process when the platform reports them.
+type peerInfo struct {
+	source         string
+	unix           bool
+	hasCredentials bool
+	uid, gid       uint32
+	pid            int32
+}
+
+// withPeerSource stores the peer of a socket connection in the connection context
+func withPeerSource(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, peerSourceKey{}, socketPeer(conn))
+}
+
+// requestPeer returns the peer of the connection a request arrived on.
+func requestPeer(r *http.Request) (peerInfo, bool) {
+	peer, ok := r.Context().Value(peerSourceKey{}).(peerInfo)
+	return peer, ok
+}
+
+// RecordAudit stores an admin operation in the audit log. Failures are logged, never returned.
+func (e *Engine) RecordAudit(event audit.Event) {
+	if e.auditLog == nil {
+		return
+	}
+	if err := e.auditLog.Record(event); err != nil {
+		e.logger.Errorf("Failed to record audit event for %s: %v", event.Operation, err)
+	}
+}
+
+// AuditLog returns audit events matching the query.
+func (e *Engine) AuditLog(query audit.Query) ([]audit.Event, error) {
+	if e.auditLog == nil {
+		return []audit.Event{}, nil
+	}
+	return e.auditLog.Query(query)
+}
+
+// auditSource describes who issued a request: the socket peer and, if present, a token fingerprint.
+// Raw tokens are never stored.
+func auditSource(r *http.Request) string {
+	parts := []string{}
+	if peer, ok := requestPeer(r); ok && peer.source != "" {
+		parts = append(parts, peer.source)
+	}
+	if token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); token != "" {
+		sum := sha256.Sum256([]byte(token))
+		parts = append(parts, "token:"+hex.EncodeToString(sum[:])[:12])
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, " ")
+}
+
+// auditPathValues are the route wildcards recorded with the request parameters.
+var auditPathValues = []string{"namespace", "name", "action"}
+
+// auditParams extracts the top-level request parameters for the audit log and restores the body.
+// Config values may hold secrets, so only their keys are recorded; nested objects, tokens and
+// module payloads are omitted. Routes addressing a function by path record its wildcards.
+func auditParams(r *http.Request) map[string]string {
+	params := make(map[string]string)
+	for _, key := range auditPathValues {
+		if value := r.PathValue(key); value != "" {
+			params[key] = value
+		}
+	}
+	if len(params) == 0 {
+		params = nil
+	}
+	if r.Body == nil {
+		return params
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBodySize))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil || len(body) == 0 {
+		return params
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return params
+	}
+
+	if params == nil {
+		params = make(map[string]string, len(fields))
+	}
+	for key, raw := range fields {
+		if key == "token" || key == "payload" {
+			continue
+		}
+		if key == "config" {
+			var config map[string]string
+			if json.Unmarshal(raw, &config) == nil && len(config) > 0 {
+				keys := make([]string, 0, len(config))
+				for k := range config {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				params["config_keys"] = strings.Join(keys, ",")
+			}
+			continue
+		}
+
+		var value interface{}
+		if json.Unmarshal(raw, &value) != nil {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				params[key] = v
+			}
+		case bool, float64:
+			params[key] = string(raw)
+		}
+	}
+
+	return params
+}
@@ -0,0 +1,57 @@
+// Package audit records admin-plane operations performed against the engine.
+package audit
+
+import (
+	"time"
+)
+
+// Operations recorded in the audit log
+const (
+	OperationLoad        = "load"
+	OperationLoadBatch   = "load-batch"
+	OperationUnload      = "unload"
+	OperationStop        = "stop"
+	OperationBuild       = "build"
+	OperationReassignTag = "reassign-tag"
+	OperationScale       = "scale"
+	OperationCallLogging = "call-logging"
+	OperationPush        = "push"
+	OperationSync        = "sync"
+	OperationApply       = "apply"
+
+	// Dead letter redrives and purges, and pipeline registrations
+	OperationDeadLetter         = "dead-letter"
+	OperationRegisterPipeline   = "register-pipeline"
+	OperationUnregisterPipeline = "unregister-pipeline"
+
+	// Loads of versions with a vulnerability accepted by an override of the policy
+	OperationVulnerabilityOverride = "vulnerability-override"
+)
+
+// Event is a single audited admin operation
+type Event struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Operation string            `json:"operation"`
+	Source    string            `json:"source"`
+	Params    map[string]string `json:"params,omitempty"`
+	Success   bool              `json:"success"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// Query selects events from the audit log
+type Query struct {
+	// Only return events at or after this time (zero means no lower bound)
+	Since time.Time
+
+	// Only return events for this operation (empty means all operations)
+	Operation string
+
+	// Maximum number of most recent events to return (0 means no limit)
+	Limit int
+}
+
+// Store persists audit events
+type Store interface {
+	Record(event Event) error
+	Query(query Query) ([]Event, error)
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/internal/repository"
+)
+
+// keyPrefix namespaces audit entries inside the shared registry database
+var keyPrefix = []byte("audit:")
+
+// badgerStore keeps audit events in Badger, keyed by timestamp so iteration is chronological
+type badgerStore struct {
+	dbRepo    repository.DBRepository
+	retention time.Duration
+	seq       atomic.Uint32
+}
+
+// NewBadgerStore creates an audit store backed by the given database.
+// Events older than retention expire automatically; zero keeps them forever.
+func NewBadgerStore(dbRepo repository.DBRepository, retention time.Duration) Store {
+	return &badgerStore{
+		dbRepo:    dbRepo,
+		retention: retention,
+	}
+}
+
+func (s *badgerStore) Record(event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	key := s.eventKey(event.Timestamp)
+	return s.dbRepo.Update(func(txn *badger.Txn) error {
+		entry := badger.NewEntry(key, value)
+		if s.retention > 0 {
+			entry = entry.WithTTL(s.retention)
+		}
+		return txn.SetEntry(entry)
+	})
+}
+
+func (s *badgerStore) Query(query Query) ([]Event, error) {
+	events := []Event{}
+
+	err := s.dbRepo.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		start := keyPrefix
+		if !query.Since.IsZero() {
+			start = timeKey(query.Since)
+		}
+
+		for it.Seek(start); it.ValidForPrefix(keyPrefix); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var event Event
+				if err := json.Unmarshal(val, &event); err != nil {
+					return fmt.Errorf("failed to unmarshal audit event: %w", err)
+				}
+				if query.Operation == "" || event.Operation == query.Operation {
+					events = append(events, event)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+
+	if query.Limit > 0 && len(events) > query.Limit {
+		events = events[len(events)-query.Limit:]
+	}
+
+	return events, nil
+}
+
+// eventKey builds a unique, time-ordered key for an event
+func (s *badgerStore) eventKey(ts time.Time) []byte {
+	key := timeKey(ts)
+	return binary.BigEndian.AppendUint32(key, s.seq.Add(1))
+}
+
+// timeKey encodes a timestamp so that byte order matches chronological order
+func timeKey(ts time.Time) []byte {
+	key := make([]byte, 0, len(keyPrefix)+12)
+	key = append(key, keyPrefix...)
+	return binary.BigEndian.AppendUint64(key, uint64(ts.UnixNano()))
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBadgerStoreRecordAndQuery(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := NewBadgerStore(repository.NewBadgerDBRepository(db), time.Hour)
+
+	now := time.Now()
+	events := []Event{
+		{Timestamp: now.Add(-2 * time.Hour), Operation: OperationBuild, Source: "unix:uid=0,pid=1", Success: true},
+		{Timestamp: now.Add(-30 * time.Minute), Operation: OperationLoad, Params: map[string]string{"name": "fn"}, Success: true},
+		{Timestamp: now.Add(-10 * time.Minute), Operation: OperationStop, Success: false, Error: "function not loaded"},
+		{Timestamp: now.Add(-5 * time.Minute), Operation: OperationLoad, Success: true},
+	}
+	for _, event := range events {
+		require.NoError(t, store.Record(event))
+	}
+
+	all, err := store.Query(Query{})
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	assert.Equal(t, OperationBuild, all[0].Operation)
+	assert.Equal(t, OperationLoad, all[3].Operation)
+
+	recent, err := store.Query(Query{Since: now.Add(-time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, recent, 3)
+	assert.Equal(t, "fn", recent[0].Params["name"])
+	assert.Equal(t, "function not loaded", recent[1].Error)
+
+	loads, err := store.Query(Query{Operation: OperationLoad, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, loads, 1)
+	assert.True(t, loads[0].Timestamp.Equal(events[3].Timestamp))
+}
@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+const (
+	// maxBuildLogs is how many builds keep their output, the oldest being dropped first
+	maxBuildLogs = 32
+
+	// maxBuildOutput bounds the output kept per build; past it, the start is dropped
+	maxBuildOutput = 1 << 20
+)
+
+// ErrBuildInProgress is returned when a build is started with the ID of one still running
+var ErrBuildInProgress = errors.New("a build with this ID is already running")
+
+// buildLogStore keeps the output of the most recent builds by build ID.
+type buildLogStore struct {
+	mu     sync.Mutex
+	builds map[string]*buildLog
+	order  []string // IDs, oldest build first
+}
+
+func newBuildLogStore() *buildLogStore {
+	return &buildLogStore{builds: make(map[string]*buildLog)}
+}
+
+// start records a new running build, replacing a finished one with the same ID.
+func (s *buildLogStore) start(id, namespace, name, tag string, now time.Time) (*buildLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if previous, ok := s.builds[id]; ok {
+		if previous.running() {
+			return nil, ErrBuildInProgress
+		}
+		s.order = slices.DeleteFunc(s.order, func(other string) bool { return other == id })
+	}
+
+	build := &buildLog{info: types.BuildLog{
+		ID:        id,
+		Namespace: namespace,
+		Name:      name,
+		Tag:       tag,
+		Status:    types.BuildRunning,
+		StartedAt: now,
+	}}
+	s.builds[id] = build
+	s.order = append(s.order, id)
+	for len(s.order) > maxBuildLogs {
+		delete(s.builds, s.order[0])
+		s.order = s.order[1:]
+	}
+	return build, nil
+}
+
+// get returns the build with this ID.
+func (s *buildLogStore) get(id string) (*buildLog, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	build, ok := s.builds[id]
+	return build, ok
+}
+
+// buildLog is the state and output of one build. Build commands write their output to it.
+type buildLog struct {
+	mu      sync.Mutex
+	info    types.BuildLog
+	output  []byte
+	dropped int64 // bytes dropped from the start of the output
+}
+
+func (b *buildLog) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.output = append(b.output, p...)
+	if excess := len(b.output) - maxBuildOutput; excess > 0 {
+		b.output = b.output[excess:]
+		b.dropped += int64(excess)
+	}
+	return len(p), nil
+}
+
+func (b *buildLog) running() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.info.Status == types.BuildRunning
+}
+
+// finish records the outcome of the build.
+func (b *buildLog) finish(err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.info.Status = types.BuildSucceeded
+	if err != nil {
+		b.info.Status = types.BuildFailed
+		b.info.Error = err.Error()
+	}
+	b.info.FinishedAt = &now
+}
+
+// read returns the build with its output from offset, or from the oldest byte still
+// kept when the output before offset was dropped.
+func (b *buildLog) read(offset int64) types.BuildLog {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	log := b.info
+	log.Size = b.dropped + int64(len(b.output))
+	log.Offset = min(max(offset, b.dropped), log.Size)
+	log.Output = string(b.output[log.Offset-b.dropped:])
+	return log
+}
+
+// newBuildID returns a random build ID.
+func newBuildID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// BuildFunction builds a function and stores it in the registry. The output of the build
+// commands is kept under the build ID of opts, a random one when empty, and also written
+// to opts.Output when set.
+func (e *Engine) BuildFunction(namespace, name, path, tag string, config manifest.FunctionManifest, opts types.BuildOptions) (*types.BuildResult, error) {
+	if opts.BuildID == "" {
+		opts.BuildID = newBuildID()
+	}
+	build, err := e.builds.start(opts.BuildID, namespace, name, tag, e.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if opts.Output != nil {
+		opts.Output = io.MultiWriter(build, opts.Output)
+	} else {
+		opts.Output = build
+	}
+
+	result, err := e.functionManager.BuildFunction(namespace, name, path, tag, config, opts)
+	build.finish(err, e.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	result.BuildID = opts.BuildID
+	return result, nil
+}
+
+// BuildLog returns a recent build with its output from offset, or false when no build
+// with this ID is kept.
+func (e *Engine) BuildLog(id string, offset int64) (types.BuildLog, bool) {
+	build, ok := e.builds.get(id)
+	if !ok {
+		return types.BuildLog{}, false
+	}
+	return build.read(offset), true
+}
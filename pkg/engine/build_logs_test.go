@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLogStore(t *testing.T) {
+	store := newBuildLogStore()
+	now := time.Now()
+
+	build, err := store.start("b1", "ns", "fn", "latest", now)
+	require.NoError(t, err)
+	_, err = store.start("b1", "ns", "fn", "latest", now)
+	assert.ErrorIs(t, err, ErrBuildInProgress)
+
+	_, _ = build.Write([]byte("Compiling fn\n"))
+	log := build.read(0)
+	assert.Equal(t, types.BuildRunning, log.Status)
+	assert.Equal(t, "Compiling fn\n", log.Output)
+
+	// Reading from the size returns only what is written next
+	_, _ = build.Write([]byte("error[E0425]: cannot find value\n"))
+	log = build.read(log.Size)
+	assert.Equal(t, "error[E0425]: cannot find value\n", log.Output)
+
+	build.finish(errors.New("exit status 101"), now)
+	log = build.read(0)
+	assert.Equal(t, types.BuildFailed, log.Status)
+	assert.Equal(t, "exit status 101", log.Error)
+	require.NotNil(t, log.FinishedAt)
+
+	// A finished build can be replaced
+	_, err = store.start("b1", "ns", "fn", "latest", now)
+	require.NoError(t, err)
+
+	// The oldest builds are dropped past the limit
+	for i := 0; i < maxBuildLogs; i++ {
+		_, err := store.start(fmt.Sprintf("b%d", i+2), "ns", "fn", "", now)
+		require.NoError(t, err)
+	}
+	_, ok := store.get("b1")
+	assert.False(t, ok)
+	_, ok = store.get("b2")
+	assert.True(t, ok)
+}
+
+func TestBuildLogDropsOldOutput(t *testing.T) {
+	build := &buildLog{}
+	_, _ = build.Write([]byte(strings.Repeat("a", maxBuildOutput)))
+	_, _ = build.Write([]byte("tail"))
+
+	log := build.read(0)
+	assert.Equal(t, int64(maxBuildOutput+4), log.Size)
+	assert.Equal(t, int64(4), log.Offset)
+	assert.True(t, strings.HasSuffix(log.Output, "tail"))
+	assert.Len(t, log.Output, maxBuildOutput)
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// callEnvelopeRequested reports whether the caller asked for the call output to be
+// wrapped in a types.CallEnvelope, with ?envelope=true or an Accept header such as
+// application/json; profile="ignition-envelope".
+func callEnvelopeRequested(r *http.Request) (bool, error) {
+	if value := r.URL.Query().Get("envelope"); value != "" {
+		requested, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, NewBadRequestError(fmt.Sprintf("Invalid envelope parameter %q, expected true or false", value))
+		}
+		return requested, nil
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == "application/json" && params["profile"] == types.CallEnvelopeProfile {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// requestID returns the caller's request ID, or a new random one.
+func requestID(r *http.Request) string {
+	if id := strings.TrimSpace(r.Header.Get(types.RequestIDHeader)); id != "" {
+		return id
+	}
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// sendCallEnvelope writes the output of a call wrapped with its execution metadata.
+func (h *Handlers) sendCallEnvelope(w http.ResponseWriter, r *http.Request, params *functionCallParams, output []byte, start time.Time) error {
+	function := params.namespace + "/" + params.name
+	if digest, ok := h.engine.functionLoader.GetDigest(params.namespace, params.name); ok {
+		function += "@" + digest
+	}
+
+	envelope := types.CallEnvelope{
+		ExecutionTimeMs: float64(time.Since(start).Microseconds()) / 1000,
+		Function:        function,
+		RequestID:       requestID(r),
+	}
+	envelope.Output, envelope.IsBase64 = envelopeOutput(output)
+
+	w.Header().Set(types.RequestIDHeader, envelope.RequestID)
+	w.Header().Set("Content-Type", `application/json; profile="`+types.CallEnvelopeProfile+`"`)
+	return json.NewEncoder(w).Encode(envelope)
+}
+
+// envelopeOutput embeds JSON output as is, and other output as a string, base64 encoded
+// when it is not valid UTF-8.
+func envelopeOutput(output []byte) (json.RawMessage, bool) {
+	if len(output) > 0 && json.Valid(output) {
+		return output, false
+	}
+
+	isBase64 := !utf8.Valid(output)
+	text := string(output)
+	if isBase64 {
+		text = base64.StdEncoding.EncodeToString(output)
+	}
+	encoded, _ := json.Marshal(text)
+	return encoded, isBase64
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallEnvelopeRequested(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   bool
+	}{
+		{name: "plain call", target: "/ns/fn/run"},
+		{name: "query parameter", target: "/ns/fn/run?envelope=true", want: true},
+		{name: "query parameter off", target: "/ns/fn/run?envelope=0", accept: `application/json; profile="ignition-envelope"`},
+		{name: "accept profile", target: "/ns/fn/run", accept: `text/plain, application/json; profile="ignition-envelope"`, want: true},
+		{name: "other profile", target: "/ns/fn/run", accept: `application/json; profile="other"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			got, err := callEnvelopeRequested(r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := callEnvelopeRequested(httptest.NewRequest(http.MethodPost, "/ns/fn/run?envelope=maybe", nil))
+	assert.Error(t, err)
+}
+
+func TestEnvelopeOutput(t *testing.T) {
+	output, isBase64 := envelopeOutput([]byte(`{"ok":true}`))
+	assert.JSONEq(t, `{"ok":true}`, string(output))
+	assert.False(t, isBase64)
+
+	output, isBase64 = envelopeOutput([]byte("hello"))
+	assert.Equal(t, `"hello"`, string(output))
+	assert.False(t, isBase64)
+
+	output, isBase64 = envelopeOutput(nil)
+	assert.Equal(t, `""`, string(output))
+	assert.False(t, isBase64)
+
+	output, isBase64 = envelopeOutput([]byte{0xff, 0x00})
+	assert.Equal(t, `"/wA="`, string(output))
+	assert.True(t, isBase64)
+}
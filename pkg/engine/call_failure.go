@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// classifyCallError returns the code of a failed call: traps raised by the wasm runtime,
+// non-zero exits, failures of host functions, timeouts, or errors reported by the
+// function itself. Domain errors keep their own code.
+func classifyCallError(err error) domainerrors.Code {
+	var de *domainerrors.DomainError
+	if errors.As(err, &de) {
+		return de.ErrCode
+	}
+	if errors.Is(err, ErrFunctionNotLoaded) {
+		return domainerrors.CodeFunctionNotLoaded
+	}
+
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitCode() {
+		case sys.ExitCodeDeadlineExceeded:
+			return domainerrors.CodeExecutionTimeout
+		case sys.ExitCodeContextCanceled:
+			return domainerrors.CodeExecutionCancelled
+		default:
+			return domainerrors.CodeExecutionExit
+		}
+	}
+
+	// Panics of host functions are recovered by the runtime with the stack of the call
+	if trap, ok := components.ParseTrap(err); ok {
+		if strings.HasPrefix(trap.Message, "wasm error:") {
+			return domainerrors.CodeExecutionTrap
+		}
+		return domainerrors.CodeHostFunctionError
+	}
+	return domainerrors.CodeExecutionFailed
+}
+
+// callFailure wraps the error of a failed call with its code.
+func callFailure(err error) error {
+	if isDomainError(err) {
+		return err
+	}
+	return domainerrors.Wrap(domainerrors.DomainExecution, classifyCallError(err), "failed to call function", err)
+}
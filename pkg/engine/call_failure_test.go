@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+func TestClassifyCallError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code domainerrors.Code
+	}{
+		{errors.New("invalid input"), domainerrors.CodeExecutionFailed},
+		{errors.New("wasm error: unreachable\nwasm stack trace:\n\tmain.handler() i32"), domainerrors.CodeExecutionTrap},
+		{errors.New("egress denied (recovered by wazero)\nwasm stack trace:\n\textism:host/env.http_request(i64,i64) i64"),
+			domainerrors.CodeHostFunctionError},
+		{sys.NewExitError(3), domainerrors.CodeExecutionExit},
+		{fmt.Errorf("call: %w", sys.NewExitError(sys.ExitCodeDeadlineExceeded)), domainerrors.CodeExecutionTimeout},
+		{domainerrors.Wrap(domainerrors.DomainExecution, domainerrors.CodeExecutionTimeout, "timed out", context.DeadlineExceeded),
+			domainerrors.CodeExecutionTimeout},
+		{ErrFunctionNotLoaded, domainerrors.CodeFunctionNotLoaded},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, classifyCallError(tt.err), tt.err.Error())
+	}
+
+	err := callFailure(errors.New("wasm error: unreachable\nwasm stack trace:\n\tmain.handler() i32"))
+	assert.True(t, domainerrors.Is(err, domainerrors.DomainExecution, domainerrors.CodeExecutionTrap))
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/api"
+	"github.com/ignitionstack/ignition/pkg/engine/ipc"
+	"github.com/ignitionstack/ignition/pkg/engine/models"
+)
+
+// BatchOptions tunes operations that act on many functions at once
+type BatchOptions struct {
+	// Maximum number of requests in flight to the engine
+	Concurrency int
+
+	// Number of extra attempts for a function after a transient error
+	Retries int
+
+	// Initial backoff between attempts, doubled after each attempt
+	RetryBackoff time.Duration
+}
+
+// DefaultBatchOptions returns batch settings that keep the engine socket responsive
+func DefaultBatchOptions() BatchOptions {
+	return BatchOptions{
+		Concurrency:  8,
+		Retries:      2,
+		RetryBackoff: 100 * time.Millisecond,
+	}
+}
+
+// batchFunctionOperation applies an operation to multiple functions using a bounded
+// pool of workers, retrying transient failures. It returns one result per function,
+// in input order, and an *api.BatchError if any function failed.
+func (c *clientImpl) batchFunctionOperation(
+	ctx context.Context,
+	functions []models.FunctionReference,
+	operationName string,
+	operation func(context.Context, string, string) error,
+) ([]api.BatchResult, error) {
+	results := make([]api.BatchResult, len(functions))
+
+	workers := c.batch.Concurrency
+	if workers <= 0 || workers > len(functions) {
+		workers = len(functions)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				function := functions[i]
+				attempts, err := c.withRetries(ctx, func() error {
+					return operation(ctx, function.Namespace, function.Name)
+				})
+				results[i] = api.BatchResult{Function: function, Attempts: attempts, Err: err}
+			}
+		}()
+	}
+
+	for i := range functions {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Err != nil {
+			return results, &api.BatchError{Operation: operationName, Results: results}
+		}
+	}
+
+	return results, nil
+}
+
+// withRetries runs op until it succeeds, fails permanently, or runs out of attempts.
+// It stops early rather than sleep past the context deadline.
+func (c *clientImpl) withRetries(ctx context.Context, op func() error) (int, error) {
+	backoff := c.batch.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt > c.batch.Retries || !isTransientError(err) || ctx.Err() != nil {
+			return attempt, err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isTransientError reports whether a failed request is worth retrying
+func isTransientError(err error) bool {
+	var respErr api.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.Code {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	return ipc.NotReady(err) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/api"
+	"github.com/ignitionstack/ignition/pkg/engine/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchFunctionOperationBoundsConcurrency(t *testing.T) {
+	c := &clientImpl{batch: BatchOptions{Concurrency: 3}}
+
+	functions := make([]models.FunctionReference, 20)
+	for i := range functions {
+		functions[i] = models.FunctionReference{Namespace: "ns", Name: fmt.Sprintf("fn%d", i)}
+	}
+
+	var inFlight, peak atomic.Int32
+	results, err := c.batchFunctionOperation(context.Background(), functions, "stop", func(context.Context, string, string) error {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := peak.Load()
+			if current <= seen || peak.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, results, len(functions))
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	for i, result := range results {
+		assert.Equal(t, functions[i], result.Function)
+		assert.Equal(t, 1, result.Attempts)
+	}
+}
+
+func TestBatchFunctionOperationRetriesTransientErrors(t *testing.T) {
+	c := &clientImpl{batch: BatchOptions{Concurrency: 2, Retries: 2, RetryBackoff: time.Millisecond}}
+
+	functions := []models.FunctionReference{
+		{Namespace: "ns", Name: "flaky"},
+		{Namespace: "ns", Name: "broken"},
+		{Namespace: "ns", Name: "busy"},
+	}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	results, err := c.batchFunctionOperation(context.Background(), functions, "unload", func(_ context.Context, _, name string) error {
+		mu.Lock()
+		calls[name]++
+		attempt := calls[name]
+		mu.Unlock()
+
+		switch name {
+		case "flaky":
+			if attempt == 1 {
+				return api.ResponseError{Message: "unavailable", Code: http.StatusServiceUnavailable}
+			}
+			return nil
+		case "broken":
+			return api.ResponseError{Message: "not found", Code: http.StatusNotFound}
+		default:
+			return api.ResponseError{Message: "busy", Code: http.StatusServiceUnavailable}
+		}
+	})
+
+	var batchErr *api.BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Failed(), 2)
+	assert.Contains(t, err.Error(), "failed to unload function 'ns/broken'")
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 2, results[0].Attempts)
+	assert.Error(t, results[1].Err)
+	assert.Equal(t, 1, results[1].Attempts)
+	assert.Error(t, results[2].Err)
+	assert.Equal(t, 3, results[2].Attempts)
+}
+
+func TestWithRetriesRespectsDeadline(t *testing.T) {
+	c := &clientImpl{batch: BatchOptions{Retries: 5, RetryBackoff: time.Hour}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	attempts, err := c.withRetries(ctx, func() error {
+		return api.ResponseError{Message: "busy", Code: http.StatusServiceUnavailable}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
@@ -7,38 +7,55 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/ignitionstack/ignition/pkg/engine/api"
+	"github.com/ignitionstack/ignition/pkg/engine/audit"
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/dlq"
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+	"github.com/ignitionstack/ignition/pkg/engine/ipc"
 	"github.com/ignitionstack/ignition/pkg/engine/models"
+	"github.com/ignitionstack/ignition/pkg/engine/usage"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
 )
 
 // clientImpl is the implementation of the api.Client interface
 type clientImpl struct {
 	socketPath string
+	baseURL    string
+	token      string
 	httpClient *http.Client
+	batch      BatchOptions
 }
 
 // Options for creating a new engine client
 type Options struct {
 	SocketPath string
+
+	// URL of the TCP admin API of a remote engine, used instead of the socket when set
+	Address string
+
+	// Bearer token sent to the remote admin API
+	Token string
+
+	// Transport settings; nil uses DefaultTransportOptions
+	Transport *TransportOptions
+
+	// Batch operation settings; nil uses DefaultBatchOptions
+	Batch *BatchOptions
 }
 
-// DefaultSocketPath returns the default engine socket path
+// DefaultSocketPath returns the default engine socket path, a named pipe on Windows
 func DefaultSocketPath() string {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
-	return filepath.Join(homeDir, ".ignition", "engine.sock")
+	return ipc.DefaultPath()
 }
 
 // New creates a new engine client with the given options
@@ -48,18 +65,34 @@ func New(opts Options) (api.Client, error) {
 		socketPath = DefaultSocketPath()
 	}
 
-	// Create an HTTP client that connects to the Unix socket
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-				return net.Dial("unix", socketPath)
-			},
-		},
+	transport := DefaultTransportOptions()
+	if opts.Transport != nil {
+		transport = *opts.Transport
+	}
+
+	batch := DefaultBatchOptions()
+	if opts.Batch != nil {
+		batch = *opts.Batch
+	}
+
+	// Remote engines are reached over TCP, local ones through the Unix socket
+	if opts.Address != "" {
+		return &clientImpl{
+			baseURL:    strings.TrimSuffix(opts.Address, "/") + "/",
+			token:      opts.Token,
+			httpClient: NewTCPHTTPClient(transport),
+			batch:      batch,
+		}, nil
 	}
 
+	// Create an HTTP client that connects to the Unix socket
+	httpClient := NewUnixSocketHTTPClient(socketPath, transport)
+
 	return &clientImpl{
 		socketPath: socketPath,
+		baseURL:    "http://unix/",
 		httpClient: httpClient,
+		batch:      batch,
 	}, nil
 }
 
@@ -99,7 +132,7 @@ func (c *clientImpl) Status(ctx context.Context) (*api.StatusResponse, error) {
 
 // LoadFunction loads a function into the engine
 func (c *clientImpl) LoadFunction(ctx context.Context, req api.LoadRequest) (*api.LoadResponse, error) {
-	resp, err := c.sendRequest(ctx, http.MethodPost, "load", req)
+	resp, err := c.sendRequestWithHeaders(ctx, http.MethodPost, "load", req, idempotencyHeader(req.IdempotencyKey))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send load request: %w", err)
 	}
@@ -113,6 +146,54 @@ func (c *clientImpl) LoadFunction(ctx context.Context, req api.LoadRequest) (*ap
 	return &loadResp, nil
 }
 
+// LoadBatch loads several functions, concurrently where their dependencies allow
+func (c *clientImpl) LoadBatch(ctx context.Context, req api.LoadBatchRequest) (*types.LoadBatchResponse, error) {
+	resp, err := c.sendRequestWithHeaders(ctx, http.MethodPost, "load-batch", req, idempotencyHeader(req.IdempotencyKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send load batch request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var batchResp types.LoadBatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batchResp); err != nil {
+		return nil, fmt.Errorf("failed to decode load batch response: %w", err)
+	}
+
+	return &batchResp, nil
+}
+
+// Apply converges the services and pipelines of the engine to a spec
+func (c *clientImpl) Apply(ctx context.Context, req api.ApplyRequest) (*types.ApplyResponse, error) {
+	resp, err := c.sendRequestWithHeaders(ctx, http.MethodPost, "apply", req, idempotencyHeader(req.IdempotencyKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send apply request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var applyResp types.ApplyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&applyResp); err != nil {
+		return nil, fmt.Errorf("failed to decode apply response: %w", err)
+	}
+
+	return &applyResp, nil
+}
+
+// PlanApply reports the changes Apply would make, without making them
+func (c *clientImpl) PlanApply(ctx context.Context, req api.ApplyRequest) (*types.ApplyResponse, error) {
+	resp, err := c.sendRequest(ctx, http.MethodPost, "apply/plan", req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send apply plan request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var planResp types.ApplyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&planResp); err != nil {
+		return nil, fmt.Errorf("failed to decode apply plan response: %w", err)
+	}
+
+	return &planResp, nil
+}
+
 // UnloadFunction unloads a function from the engine
 func (c *clientImpl) UnloadFunction(ctx context.Context, req api.UnloadRequest) error {
 	resp, err := c.sendRequest(ctx, http.MethodPost, "unload", req)
@@ -137,7 +218,7 @@ func (c *clientImpl) StopFunction(ctx context.Context, req api.StopRequest) erro
 
 // CallFunction calls a function
 func (c *clientImpl) CallFunction(ctx context.Context, req api.CallRequest) ([]byte, error) {
-	resp, err := c.sendRequest(ctx, http.MethodPost, "call", req)
+	resp, err := c.sendCallRequest(ctx, "call", req, req.BinaryPayload, callHeaders(req.Timeout, req.Priority))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send call request: %w", err)
 	}
@@ -148,7 +229,7 @@ func (c *clientImpl) CallFunction(ctx context.Context, req api.CallRequest) ([]b
 
 // OneOffCall loads a function temporarily and calls it
 func (c *clientImpl) OneOffCall(ctx context.Context, req api.OneOffCallRequest) ([]byte, error) {
-	resp, err := c.sendRequest(ctx, http.MethodPost, "call-once", req)
+	resp, err := c.sendCallRequest(ctx, "call-once", req, req.BinaryPayload, callHeaders(req.Timeout, req.Priority))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send one-off call request: %w", err)
 	}
@@ -159,7 +240,7 @@ func (c *clientImpl) OneOffCall(ctx context.Context, req api.OneOffCallRequest)
 
 // BuildFunction builds a function
 func (c *clientImpl) BuildFunction(ctx context.Context, req api.BuildRequest) (*api.BuildResponse, error) {
-	resp, err := c.sendRequest(ctx, http.MethodPost, "build", req)
+	resp, err := c.sendRequestWithHeaders(ctx, http.MethodPost, "build", req, idempotencyHeader(req.IdempotencyKey))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send build request: %w", err)
 	}
@@ -173,6 +254,27 @@ func (c *clientImpl) BuildFunction(ctx context.Context, req api.BuildRequest) (*
 	return &buildResp, nil
 }
 
+// GetBuildLog gets the state of a recent build and its output from byte offset
+func (c *clientImpl) GetBuildLog(ctx context.Context, id string, offset int64) (*types.BuildLog, error) {
+	endpoint := fmt.Sprintf("builds/%s/logs", id)
+	if offset > 0 {
+		endpoint += "?offset=" + strconv.FormatInt(offset, 10)
+	}
+
+	resp, err := c.sendRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send build logs request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var log types.BuildLog
+	if err := json.NewDecoder(resp.Body).Decode(&log); err != nil {
+		return nil, fmt.Errorf("failed to decode build logs response: %w", err)
+	}
+
+	return &log, nil
+}
+
 // ListFunctions lists all loaded functions
 func (c *clientImpl) ListFunctions(ctx context.Context) ([]models.Function, error) {
 	resp, err := c.sendRequest(ctx, http.MethodGet, "loaded", nil)
@@ -190,7 +292,7 @@ func (c *clientImpl) ListFunctions(ctx context.Context) ([]models.Function, erro
 }
 
 // GetFunctionLogs gets logs for a function
-func (c *clientImpl) GetFunctionLogs(ctx context.Context, namespace, name string, since time.Duration, tail int) (api.LogsResponse, error) {
+func (c *clientImpl) GetFunctionLogs(ctx context.Context, namespace, name string, since time.Duration, tail int, levels ...string) (api.LogsResponse, error) {
 	// Create query parameters
 	query := url.Values{}
 
@@ -205,6 +307,11 @@ func (c *clientImpl) GetFunctionLogs(ctx context.Context, namespace, name string
 		query.Add("tail", strconv.Itoa(tail))
 	}
 
+	// Add a level parameter per requested level
+	for _, level := range levels {
+		query.Add("level", level)
+	}
+
 	// Create the URL with query parameters
 	endpoint := fmt.Sprintf("logs/%s/%s", namespace, name)
 	if len(query) > 0 {
@@ -225,45 +332,8 @@ func (c *clientImpl) GetFunctionLogs(ctx context.Context, namespace, name string
 	return logs, nil
 }
 
-// batchFunctionOperation applies an operation to multiple functions concurrently
-func (c *clientImpl) batchFunctionOperation(
-	ctx context.Context,
-	functions []models.FunctionReference,
-	operationName string,
-	operation func(context.Context, string, string) error,
-) error {
-	var errs []string
-	var errsMu sync.Mutex
-	var wg sync.WaitGroup
-
-	for _, function := range functions {
-		wg.Add(1)
-		go func(namespace, name, serviceName string) {
-			defer wg.Done()
-
-			err := operation(ctx, namespace, name)
-			if err != nil {
-				errsMu.Lock()
-				errs = append(errs, fmt.Sprintf("failed to %s function '%s/%s' for service '%s': %v",
-					operationName, namespace, name, serviceName, err))
-				errsMu.Unlock()
-			}
-		}(function.Namespace, function.Name, function.Service)
-	}
-
-	// Wait for all operations to complete
-	wg.Wait()
-
-	// Check for errors
-	if len(errs) > 0 {
-		return fmt.Errorf("failed to %s some functions:\n%s", operationName, strings.Join(errs, "\n"))
-	}
-
-	return nil
-}
-
 // UnloadFunctions unloads multiple functions at once
-func (c *clientImpl) UnloadFunctions(ctx context.Context, functions []models.FunctionReference) error {
+func (c *clientImpl) UnloadFunctions(ctx context.Context, functions []models.FunctionReference) ([]api.BatchResult, error) {
 	return c.batchFunctionOperation(ctx, functions, "unload", func(ctx context.Context, namespace, name string) error {
 		req := api.UnloadRequest{
 			BaseRequest: api.BaseRequest{
@@ -276,7 +346,7 @@ func (c *clientImpl) UnloadFunctions(ctx context.Context, functions []models.Fun
 }
 
 // StopFunctions stops multiple functions at once
-func (c *clientImpl) StopFunctions(ctx context.Context, functions []models.FunctionReference) error {
+func (c *clientImpl) StopFunctions(ctx context.Context, functions []models.FunctionReference) ([]api.BatchResult, error) {
 	return c.batchFunctionOperation(ctx, functions, "stop", func(ctx context.Context, namespace, name string) error {
 		req := api.StopRequest{
 			BaseRequest: api.BaseRequest{
@@ -288,30 +358,448 @@ func (c *clientImpl) StopFunctions(ctx context.Context, functions []models.Funct
 	})
 }
 
+// ScaleFunction sets the number of instances kept for a function
+func (c *clientImpl) ScaleFunction(ctx context.Context, req api.ScaleRequest) error {
+	resp, err := c.sendRequest(ctx, http.MethodPost, "scale", req)
+	if err != nil {
+		return fmt.Errorf("failed to send scale request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// SetCallLogging sets which calls of a running function write entries to its logs
+func (c *clientImpl) SetCallLogging(ctx context.Context, req api.CallLoggingRequest) error {
+	resp, err := c.sendRequest(ctx, http.MethodPost, "call-logging", req)
+	if err != nil {
+		return fmt.Errorf("failed to send call logging request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// ReassignTag points a tag at a different digest
+func (c *clientImpl) ReassignTag(ctx context.Context, req api.ReassignTagRequest) error {
+	resp, err := c.sendRequest(ctx, http.MethodPost, "reassign-tag", req)
+	if err != nil {
+		return fmt.Errorf("failed to send reassign tag request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// RegisterPipeline registers or replaces a pipeline
+func (c *clientImpl) RegisterPipeline(ctx context.Context, req api.RegisterPipelineRequest) error {
+	resp, err := c.sendRequest(ctx, http.MethodPost, "pipelines/register", req)
+	if err != nil {
+		return fmt.Errorf("failed to send register pipeline request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// UnregisterPipeline removes a pipeline
+func (c *clientImpl) UnregisterPipeline(ctx context.Context, name string) error {
+	req := api.UnregisterPipelineRequest{Name: name}
+	resp, err := c.sendRequest(ctx, http.MethodPost, "pipelines/unregister", req)
+	if err != nil {
+		return fmt.Errorf("failed to send unregister pipeline request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// ListDeadLetters lists the failed calls captured for a function
+func (c *clientImpl) ListDeadLetters(ctx context.Context, namespace, name string) ([]dlq.Entry, error) {
+	resp, err := c.sendRequest(ctx, http.MethodGet, fmt.Sprintf("dlq/%s/%s", namespace, name), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send dead letter list request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var entries []dlq.Entry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode dead letter list response: %w", err)
+	}
+
+	return entries, nil
+}
+
+// RedriveDeadLetters replays captured calls, all of them when no IDs are given
+func (c *clientImpl) RedriveDeadLetters(ctx context.Context, namespace, name string, ids []string) (*api.RedriveResponse, error) {
+	req := api.DeadLetterRequest{IDs: ids}
+	resp, err := c.sendRequest(ctx, http.MethodPost, fmt.Sprintf("dlq/%s/%s/redrive", namespace, name), req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send dead letter redrive request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result api.RedriveResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode dead letter redrive response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// PurgeDeadLetters removes captured calls, all of them when no IDs are given
+func (c *clientImpl) PurgeDeadLetters(ctx context.Context, namespace, name string, ids []string) (int, error) {
+	req := api.DeadLetterRequest{IDs: ids}
+	resp, err := c.sendRequest(ctx, http.MethodPost, fmt.Sprintf("dlq/%s/%s/purge", namespace, name), req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send dead letter purge request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result api.PurgeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode dead letter purge response: %w", err)
+	}
+
+	return result.Removed, nil
+}
+
+// GetRegistryFunction gets the registry metadata for a function
+func (c *clientImpl) GetRegistryFunction(ctx context.Context, namespace, name string) (*registry.FunctionMetadata, error) {
+	req := api.BaseRequest{Namespace: namespace, Name: name}
+	resp, err := c.sendRequest(ctx, http.MethodPost, "list", req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send list request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var metadata registry.FunctionMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode list response: %w", err)
+	}
+
+	return &metadata, nil
+}
+
+// ListRegistryFunctions lists every function in the registry
+func (c *clientImpl) ListRegistryFunctions(ctx context.Context) ([]registry.FunctionMetadata, error) {
+	resp, err := c.sendRequest(ctx, http.MethodPost, "list", api.BaseRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send list request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var functions []registry.FunctionMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&functions); err != nil {
+		return nil, fmt.Errorf("failed to decode list response: %w", err)
+	}
+
+	return functions, nil
+}
+
+// GetAuditLog gets admin audit events recorded within the given window
+func (c *clientImpl) GetAuditLog(ctx context.Context, since time.Duration, operation string) ([]audit.Event, error) {
+	query := url.Values{}
+	if since > 0 {
+		query.Add("since", since.String())
+	}
+	if operation != "" {
+		query.Add("operation", operation)
+	}
+
+	endpoint := "audit"
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	resp, err := c.sendRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send audit request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var events []audit.Event
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return nil, fmt.Errorf("failed to decode audit response: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetUsage gets the usage of every namespace this month, or of one namespace
+func (c *clientImpl) GetUsage(ctx context.Context, namespace string) ([]usage.Report, error) {
+	endpoint := "usage"
+	if namespace != "" {
+		endpoint += "?" + url.Values{"namespace": {namespace}}.Encode()
+	}
+
+	resp, err := c.sendRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send usage request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var reports []usage.Report
+	if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
+		return nil, fmt.Errorf("failed to decode usage response: %w", err)
+	}
+
+	return reports, nil
+}
+
+// ListCircuitBreakers gets the state of every circuit breaker
+func (c *clientImpl) ListCircuitBreakers(ctx context.Context) ([]components.CircuitBreakerStatus, error) {
+	resp, err := c.sendRequest(ctx, http.MethodGet, "circuit-breakers", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send circuit breakers request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var statuses []components.CircuitBreakerStatus
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return nil, fmt.Errorf("failed to decode circuit breakers response: %w", err)
+	}
+
+	return statuses, nil
+}
+
+// SyncRegistry copies the versions and tags the target is missing, to the configured
+// replication target when target is empty
+func (c *clientImpl) SyncRegistry(ctx context.Context, target, token string) (*types.RegistrySyncReport, error) {
+	req := types.RegistrySyncRequest{Target: target, Token: token}
+	resp, err := c.sendRequest(ctx, http.MethodPost, "registry/sync", req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send registry sync request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var report types.RegistrySyncReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode registry sync response: %w", err)
+	}
+
+	return &report, nil
+}
+
+// PullVersion reads a version and its module from the registry of the engine
+func (c *clientImpl) PullVersion(ctx context.Context, namespace, name, reference string) (*types.PullResponse, error) {
+	req := types.PullRequest{
+		FunctionRequest: types.FunctionRequest{Namespace: namespace, Name: name},
+		Reference:       reference,
+	}
+	resp, err := c.sendRequest(ctx, http.MethodPost, "registry/pull", req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send pull request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var pulled types.PullResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pulled); err != nil {
+		return nil, fmt.Errorf("failed to decode pull response: %w", err)
+	}
+
+	return &pulled, nil
+}
+
+// AddVariant stores a variant of a version in the registry of the engine
+func (c *clientImpl) AddVariant(ctx context.Context, namespace, name, reference, variant string, payload []byte) (*registry.VersionInfo, error) {
+	req := types.AddVariantRequest{
+		FunctionRequest: types.FunctionRequest{Namespace: namespace, Name: name},
+		Reference:       reference,
+		Variant:         variant,
+		Payload:         payload,
+	}
+	resp, err := c.sendRequest(ctx, http.MethodPost, "registry/variant", req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send variant request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var version registry.VersionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return nil, fmt.Errorf("failed to decode variant response: %w", err)
+	}
+
+	return &version, nil
+}
+
+// FunctionSBOM reads the SBOM of a version from the registry of the engine
+func (c *clientImpl) FunctionSBOM(ctx context.Context, namespace, name, reference string) (*registry.SBOM, error) {
+	req := types.SBOMRequest{
+		FunctionRequest: types.FunctionRequest{Namespace: namespace, Name: name},
+		Reference:       reference,
+	}
+	resp, err := c.sendRequest(ctx, http.MethodPost, "registry/sbom", req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send SBOM request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var sbom registry.SBOM
+	if err := json.NewDecoder(resp.Body).Decode(&sbom); err != nil {
+		return nil, fmt.Errorf("failed to decode SBOM response: %w", err)
+	}
+
+	return &sbom, nil
+}
+
+// Snapshot captures the runtime state of the engine
+func (c *clientImpl) Snapshot(ctx context.Context) (*types.EngineSnapshot, error) {
+	return c.snapshot(ctx, "snapshot")
+}
+
+// RedactedSnapshot captures the runtime state of the engine with secret config values masked
+func (c *clientImpl) RedactedSnapshot(ctx context.Context) (*types.EngineSnapshot, error) {
+	return c.snapshot(ctx, "snapshot?redact=true")
+}
+
+func (c *clientImpl) snapshot(ctx context.Context, endpoint string) (*types.EngineSnapshot, error) {
+	resp, err := c.sendRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send snapshot request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var snapshot types.EngineSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot response: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// StreamEvents calls handle with each lifecycle event matching the filter until ctx is
+// done, handle returns an error or the engine ends the stream
+func (c *clientImpl) StreamEvents(ctx context.Context, filter events.Filter, handle func(events.Event) error) error {
+	query := url.Values{}
+	for _, key := range filter.Functions {
+		query.Add("function", key.Namespace+"/"+key.Name)
+	}
+	for _, eventType := range filter.Types {
+		query.Add("type", eventType)
+	}
+
+	endpoint := "events"
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	resp, err := c.sendRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send events request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event events.Event
+		if err := decoder.Decode(&event); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode event: %w", err)
+		}
+		if err := handle(event); err != nil {
+			return err
+		}
+	}
+}
+
 // sendRequest is a helper function to send a request to the engine
 func (c *clientImpl) sendRequest(ctx context.Context, method, endpoint string, body interface{}) (*http.Response, error) {
-	var bodyReader io.Reader
-	if body != nil {
-		jsonData, err := json.Marshal(body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
-		}
-		bodyReader = bytes.NewBuffer(jsonData)
+	return c.sendRequestWithHeaders(ctx, method, endpoint, body, nil)
+}
+
+// callHeaders builds the deadline and priority headers for a call, or nil when neither is set
+func callHeaders(timeout time.Duration, priority string) http.Header {
+	headers := http.Header{}
+	if timeout > 0 {
+		headers.Set(types.TimeoutHeader, timeout.String())
+	}
+	if priority != "" {
+		headers.Set(types.PriorityHeader, priority)
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// sendCallRequest posts a call request, as multipart/form-data with the payload in its
+// own part when there is a raw payload, or as JSON otherwise
+func (c *clientImpl) sendCallRequest(ctx context.Context, endpoint string, body interface{}, payload []byte, headers http.Header) (*http.Response, error) {
+	if payload == nil {
+		return c.sendRequestWithHeaders(ctx, http.MethodPost, endpoint, body, headers)
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	requestPart, err := writer.CreateFormField(types.CallRequestPart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request part: %w", err)
+	}
+	if err := json.NewEncoder(requestPart).Encode(body); err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	payloadPart, err := writer.CreateFormFile(types.CallPayloadPart, types.CallPayloadPart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payload part: %w", err)
+	}
+	if _, err := payloadPart.Write(payload); err != nil {
+		return nil, fmt.Errorf("failed to write payload part: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close multipart body: %w", err)
+	}
+
+	return c.sendBody(ctx, http.MethodPost, endpoint, &buf, writer.FormDataContentType(), headers)
+}
+
+// idempotencyHeader returns the Idempotency-Key header for key, or none when it is empty
+func idempotencyHeader(key string) http.Header {
+	if key == "" {
+		return nil
+	}
+	return http.Header{types.IdempotencyKeyHeader: []string{key}}
+}
+
+// sendRequestWithHeaders sends a request to the engine with extra headers
+func (c *clientImpl) sendRequestWithHeaders(ctx context.Context, method, endpoint string, body interface{}, headers http.Header) (*http.Response, error) {
+	if body == nil {
+		return c.sendBody(ctx, method, endpoint, nil, "", headers)
 	}
 
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	return c.sendBody(ctx, method, endpoint, bytes.NewBuffer(jsonData), "application/json", headers)
+}
+
+// sendBody sends a request with an encoded body (nil for none) to the engine
+func (c *clientImpl) sendBody(ctx context.Context, method, endpoint string, body io.Reader, contentType string, headers http.Header) (*http.Response, error) {
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(
 		ctx,
 		method,
-		"http://unix/"+endpoint,
-		bodyReader,
+		c.baseURL+endpoint,
+		body,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for name, values := range headers {
+		req.Header[name] = values
 	}
 
 	// Send request
@@ -327,6 +815,13 @@ func (c *clientImpl) sendRequest(ctx context.Context, method, endpoint string, b
 
 		var errResp api.ErrorResponse
 		if err := json.Unmarshal(bodyBytes, &errResp); err == nil {
+			// The engine reports the message under "error" and the status separately
+			if errResp.Message == "" {
+				errResp.Message = errResp.ErrorType
+			}
+			if errResp.Code == 0 {
+				errResp.Code = resp.StatusCode
+			}
 			return nil, errResp
 		}
 
@@ -0,0 +1,454 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/api"
+	"github.com/ignitionstack/ignition/pkg/engine/audit"
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/dlq"
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+	"github.com/ignitionstack/ignition/pkg/engine/models"
+	"github.com/ignitionstack/ignition/pkg/engine/usage"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// EngineClient is the convenience API over api.Client used by the CLI and embedders
+type EngineClient struct {
+	client api.Client
+}
+
+// NewEngineClientWithDefaults creates an engine client for the default socket path
+func NewEngineClientWithDefaults() *EngineClient {
+	client, _ := New(Options{})
+
+	return &EngineClient{
+		client: client,
+	}
+}
+
+// NewEngineClient creates an engine client for the given socket path
+func NewEngineClient(socketPath string) (*EngineClient, error) {
+	engineClient, err := New(Options{
+		SocketPath: socketPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EngineClient{
+		client: engineClient,
+	}, nil
+}
+
+// WrapEngineClient wraps an existing api.Client
+func WrapEngineClient(client api.Client) *EngineClient {
+	return &EngineClient{
+		client: client,
+	}
+}
+
+// API returns the underlying request-level client
+func (c *EngineClient) API() api.Client {
+	return c.client
+}
+
+// Status checks if the engine is running
+func (c *EngineClient) Status(ctx context.Context) error {
+	_, err := c.client.Status(ctx)
+	return err
+}
+
+// Ping is an alias for Status
+func (c *EngineClient) Ping(ctx context.Context) error {
+	return c.Status(ctx)
+}
+
+// LoadFunction loads a function into the engine
+func (c *EngineClient) LoadFunction(ctx context.Context, namespace, name, tag string, config map[string]string) error {
+	return c.LoadService(ctx, "", namespace, name, tag, config)
+}
+
+// LoadOptions holds the per-function settings a load can carry. Zero values use the engine defaults.
+type LoadOptions struct {
+	LogMaxEntries int
+	LogMaxAge     time.Duration
+
+	// Version to load when the engine reloads the function on its own: "latest",
+	// "pinned", "tag:<tag>" or a semver range such as "~1.2"
+	ReloadPolicy string
+
+	// Queue priority of the function's calls: "high", "normal" or "low"
+	Priority string
+
+	// Longest the function's calls wait for a free instance before they are turned away
+	MaxQueueWait time.Duration
+
+	// Calls that write entries to the function's logs: "all", "quiet" or "1/N"
+	CallLogging string
+
+	// Variant of the version to load, such as "debug" (empty loads the preferred variants)
+	Variant string
+
+	// Key identifying the load, so a retry with the same key and settings gets the
+	// response of the first attempt instead of loading again
+	IdempotencyKey string
+}
+
+// LoadFunctionWithLogRetention loads a function and limits how many log entries the
+// engine keeps for it and for how long. Zero values use the engine defaults.
+func (c *EngineClient) LoadFunctionWithLogRetention(ctx context.Context, namespace, name, tag string, config map[string]string,
+	maxEntries int, maxAge time.Duration) error {
+	return c.LoadFunctionWithOptions(ctx, namespace, name, tag, config, LoadOptions{
+		LogMaxEntries: maxEntries,
+		LogMaxAge:     maxAge,
+	})
+}
+
+// LoadFunctionWithOptions loads a function with per-function settings.
+func (c *EngineClient) LoadFunctionWithOptions(ctx context.Context, namespace, name, tag string, config map[string]string,
+	opts LoadOptions) error {
+	req := api.LoadRequest{
+		BaseRequest: api.BaseRequest{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Digest:         tag,
+		Config:         config,
+		ForceLoad:      true,
+		LogMaxEntries:  opts.LogMaxEntries,
+		LogMaxAgeMs:    opts.LogMaxAge.Milliseconds(),
+		ReloadPolicy:   opts.ReloadPolicy,
+		Priority:       opts.Priority,
+		MaxQueueWaitMs: opts.MaxQueueWait.Milliseconds(),
+		CallLogging:    opts.CallLogging,
+		Variant:        opts.Variant,
+		IdempotencyKey: opts.IdempotencyKey,
+	}
+
+	_, err := c.client.LoadFunction(ctx, req)
+	return err
+}
+
+// LoadService loads a function into the engine and registers it under a service name
+func (c *EngineClient) LoadService(ctx context.Context, service, namespace, name, tag string, config map[string]string) error {
+	req := api.LoadRequest{
+		BaseRequest: api.BaseRequest{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Digest:    tag,
+		Config:    config,
+		ForceLoad: true,
+		Service:   service,
+	}
+
+	_, err := c.client.LoadFunction(ctx, req)
+	return err
+}
+
+// LoadBatch loads several functions in one request. The engine runs the loads
+// concurrently, each once the services it depends on are loaded, and reports the
+// outcome of every load in request order.
+func (c *EngineClient) LoadBatch(ctx context.Context, req api.LoadBatchRequest) (*types.LoadBatchResponse, error) {
+	return c.client.LoadBatch(ctx, req)
+}
+
+// Apply converges the engine to a spec: it loads new services, reloads changed ones,
+// stops services missing from the spec and registers or removes pipelines.
+func (c *EngineClient) Apply(ctx context.Context, req api.ApplyRequest) (*types.ApplyResponse, error) {
+	return c.client.Apply(ctx, req)
+}
+
+// PlanApply reports the changes Apply would make without making them. The engine
+// resolves versions from its registry instead of loading them.
+func (c *EngineClient) PlanApply(ctx context.Context, req api.ApplyRequest) (*types.ApplyResponse, error) {
+	return c.client.PlanApply(ctx, req)
+}
+
+// ScaleFunction keeps the given number of instances of a function (0 restores autoscaling)
+func (c *EngineClient) ScaleFunction(ctx context.Context, namespace, name string, instances int) error {
+	return c.client.ScaleFunction(ctx, api.ScaleRequest{
+		Namespace: namespace,
+		Name:      name,
+		Instances: instances,
+	})
+}
+
+// ScaleService keeps the given number of instances of the function behind a service (0 restores autoscaling)
+func (c *EngineClient) ScaleService(ctx context.Context, service string, instances int) error {
+	return c.client.ScaleFunction(ctx, api.ScaleRequest{
+		Service:   service,
+		Instances: instances,
+	})
+}
+
+// SetCallLogging sets which calls of a running function write entries to its logs
+func (c *EngineClient) SetCallLogging(ctx context.Context, namespace, name, callLogging string) error {
+	return c.client.SetCallLogging(ctx, api.CallLoggingRequest{
+		Namespace:   namespace,
+		Name:        name,
+		CallLogging: callLogging,
+	})
+}
+
+// SetServiceCallLogging sets which calls of the function behind a service write entries to its logs
+func (c *EngineClient) SetServiceCallLogging(ctx context.Context, service, callLogging string) error {
+	return c.client.SetCallLogging(ctx, api.CallLoggingRequest{
+		Service:     service,
+		CallLogging: callLogging,
+	})
+}
+
+// ImportService imports a prebuilt module from an https:// URL or oci:// reference,
+// loads it and registers it under a service name. The tag is optional.
+func (c *EngineClient) ImportService(ctx context.Context, service, namespace, name, tag, source, digest string, config map[string]string) error {
+	req := api.LoadRequest{
+		BaseRequest: api.BaseRequest{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Digest:       tag,
+		Config:       config,
+		ForceLoad:    true,
+		Service:      service,
+		Source:       source,
+		SourceDigest: digest,
+	}
+
+	_, err := c.client.LoadFunction(ctx, req)
+	return err
+}
+
+// UnloadFunction unloads a function from the engine
+func (c *EngineClient) UnloadFunction(ctx context.Context, namespace, name string) error {
+	req := api.UnloadRequest{
+		BaseRequest: api.BaseRequest{
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+
+	return c.client.UnloadFunction(ctx, req)
+}
+
+// StopFunction stops a function in the engine
+func (c *EngineClient) StopFunction(ctx context.Context, namespace, name string) error {
+	req := api.StopRequest{
+		BaseRequest: api.BaseRequest{
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+
+	return c.client.StopFunction(ctx, req)
+}
+
+// CallFunction calls a function
+func (c *EngineClient) CallFunction(ctx context.Context, namespace, name, entrypoint string, payload []byte, config map[string]string) ([]byte, error) {
+	req := api.CallRequest{
+		BaseRequest: api.BaseRequest{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Entrypoint:    entrypoint,
+		BinaryPayload: payload,
+		Config:        config,
+	}
+
+	return c.client.CallFunction(ctx, req)
+}
+
+// OneOffCall loads a function temporarily and calls it
+func (c *EngineClient) OneOffCall(ctx context.Context, namespace, name, reference, entrypoint string, payload []byte, config map[string]string) ([]byte, error) {
+	req := api.OneOffCallRequest{
+		BaseRequest: api.BaseRequest{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Reference:     reference,
+		Entrypoint:    entrypoint,
+		BinaryPayload: payload,
+		Config:        config,
+	}
+
+	return c.client.OneOffCall(ctx, req)
+}
+
+// BuildFunction builds a function
+func (c *EngineClient) BuildFunction(ctx context.Context, namespace, name, path, tag string, manifest manifest.FunctionManifest) (*types.BuildResult, error) {
+	req := api.BuildRequest{
+		BaseRequest: api.BaseRequest{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Path:     path,
+		Tag:      tag,
+		Manifest: manifest,
+	}
+
+	resp, err := c.client.BuildFunction(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to the expected type
+	return &types.BuildResult{
+		Name:      resp.BuildResult.Name,
+		Namespace: resp.BuildResult.Namespace,
+		Digest:    resp.BuildResult.Digest,
+		BuildTime: resp.BuildResult.BuildTime,
+		Tag:       resp.BuildResult.Tag,
+		Reused:    resp.BuildResult.Reused,
+		BuildID:   resp.BuildResult.BuildID,
+	}, nil
+}
+
+// GetBuildLog gets the state of a recent build and its output from byte offset
+func (c *EngineClient) GetBuildLog(ctx context.Context, id string, offset int64) (*types.BuildLog, error) {
+	return c.client.GetBuildLog(ctx, id, offset)
+}
+
+// ListFunctions lists all loaded functions
+func (c *EngineClient) ListFunctions(ctx context.Context) ([]types.LoadedFunction, error) {
+	modelFunctions, err := c.client.ListFunctions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert from models.Function to types.LoadedFunction
+	var result []types.LoadedFunction
+	for _, fn := range modelFunctions {
+		result = append(result, types.LoadedFunction{
+			Namespace: fn.Namespace,
+			Name:      fn.Name,
+			Status:    fn.Status,
+		})
+	}
+
+	return result, nil
+}
+
+// GetFunctionLogs gets logs for a function, only those at one of levels
+// (debug, info, warning, error) when any are given
+func (c *EngineClient) GetFunctionLogs(ctx context.Context, namespace, name string, since time.Duration, tail int, levels ...string) ([]string, error) {
+	return c.client.GetFunctionLogs(ctx, namespace, name, since, tail, levels...)
+}
+
+// UnloadFunctions unloads multiple functions at once.
+// If any function fails, the error is an *api.BatchError holding every function's outcome.
+func (c *EngineClient) UnloadFunctions(ctx context.Context, functions []models.FunctionReference) error {
+	_, err := c.client.UnloadFunctions(ctx, functions)
+	return err
+}
+
+// StopFunctions stops multiple functions at once.
+// If any function fails, the error is an *api.BatchError holding every function's outcome.
+func (c *EngineClient) StopFunctions(ctx context.Context, functions []models.FunctionReference) error {
+	_, err := c.client.StopFunctions(ctx, functions)
+	return err
+}
+
+// ReassignTag points a tag at a different digest
+func (c *EngineClient) ReassignTag(ctx context.Context, namespace, name, tag, digest string) error {
+	req := api.ReassignTagRequest{
+		BaseRequest: api.BaseRequest{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Tag:    tag,
+		Digest: digest,
+	}
+
+	return c.client.ReassignTag(ctx, req)
+}
+
+// GetRegistryFunction gets the registry metadata for a function
+func (c *EngineClient) GetRegistryFunction(ctx context.Context, namespace, name string) (*registry.FunctionMetadata, error) {
+	return c.client.GetRegistryFunction(ctx, namespace, name)
+}
+
+// ListRegistryFunctions lists every function in the registry
+func (c *EngineClient) ListRegistryFunctions(ctx context.Context) ([]registry.FunctionMetadata, error) {
+	return c.client.ListRegistryFunctions(ctx)
+}
+
+// RegisterPipeline registers or replaces a pipeline
+func (c *EngineClient) RegisterPipeline(ctx context.Context, name string, steps []api.PipelineStep) error {
+	return c.client.RegisterPipeline(ctx, api.RegisterPipelineRequest{Name: name, Steps: steps})
+}
+
+// UnregisterPipeline removes a pipeline
+func (c *EngineClient) UnregisterPipeline(ctx context.Context, name string) error {
+	return c.client.UnregisterPipeline(ctx, name)
+}
+
+// ListDeadLetters lists the failed calls captured for a function
+func (c *EngineClient) ListDeadLetters(ctx context.Context, namespace, name string) ([]dlq.Entry, error) {
+	return c.client.ListDeadLetters(ctx, namespace, name)
+}
+
+// RedriveDeadLetters replays captured calls, all of them when no IDs are given
+func (c *EngineClient) RedriveDeadLetters(ctx context.Context, namespace, name string, ids []string) (*api.RedriveResponse, error) {
+	return c.client.RedriveDeadLetters(ctx, namespace, name, ids)
+}
+
+// PurgeDeadLetters removes captured calls, all of them when no IDs are given
+func (c *EngineClient) PurgeDeadLetters(ctx context.Context, namespace, name string, ids []string) (int, error) {
+	return c.client.PurgeDeadLetters(ctx, namespace, name, ids)
+}
+
+// GetAuditLog gets admin audit events recorded within the given window
+func (c *EngineClient) GetAuditLog(ctx context.Context, since time.Duration, operation string) ([]audit.Event, error) {
+	return c.client.GetAuditLog(ctx, since, operation)
+}
+
+// GetUsage gets the usage of every namespace this month, or of one namespace
+func (c *EngineClient) GetUsage(ctx context.Context, namespace string) ([]usage.Report, error) {
+	return c.client.GetUsage(ctx, namespace)
+}
+
+// ListCircuitBreakers gets the state of every circuit breaker
+func (c *EngineClient) ListCircuitBreakers(ctx context.Context) ([]components.CircuitBreakerStatus, error) {
+	return c.client.ListCircuitBreakers(ctx)
+}
+
+// SyncRegistry copies the versions and tags the target is missing, to the configured
+// replication target when target is empty
+func (c *EngineClient) SyncRegistry(ctx context.Context, target, token string) (*types.RegistrySyncReport, error) {
+	return c.client.SyncRegistry(ctx, target, token)
+}
+
+// PullVersion reads a version and its module from the registry of the engine
+func (c *EngineClient) PullVersion(ctx context.Context, namespace, name, reference string) (*types.PullResponse, error) {
+	return c.client.PullVersion(ctx, namespace, name, reference)
+}
+
+// AddVariant stores a variant of a version in the registry of the engine
+func (c *EngineClient) AddVariant(ctx context.Context, namespace, name, reference, variant string, payload []byte) (*registry.VersionInfo, error) {
+	return c.client.AddVariant(ctx, namespace, name, reference, variant, payload)
+}
+
+// FunctionSBOM reads the SBOM of a version from the registry of the engine
+func (c *EngineClient) FunctionSBOM(ctx context.Context, namespace, name, reference string) (*registry.SBOM, error) {
+	return c.client.FunctionSBOM(ctx, namespace, name, reference)
+}
+
+// Snapshot captures the runtime state of the engine
+func (c *EngineClient) Snapshot(ctx context.Context) (*types.EngineSnapshot, error) {
+	return c.client.Snapshot(ctx)
+}
+
+// RedactedSnapshot captures the runtime state of the engine with secret config values masked
+func (c *EngineClient) RedactedSnapshot(ctx context.Context) (*types.EngineSnapshot, error) {
+	return c.client.RedactedSnapshot(ctx)
+}
+
+// StreamEvents calls handle with each lifecycle event matching the filter until ctx is
+// done, handle returns an error or the engine ends the stream
+func (c *EngineClient) StreamEvents(ctx context.Context, filter events.Filter, handle func(events.Event) error) error {
+	return c.client.StreamEvents(ctx, filter, handle)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/ipc"
+)
+
+// TransportOptions tunes the HTTP transport used to talk to the engine socket
+type TransportOptions struct {
+	// Maximum number of idle keep-alive connections kept open to the engine
+	MaxIdleConns int
+
+	// How long an idle connection stays in the pool
+	IdleConnTimeout time.Duration
+
+	// Timeout for establishing a socket connection
+	DialTimeout time.Duration
+
+	// Timeout waiting for response headers (0 means no timeout, needed for long builds)
+	ResponseHeaderTimeout time.Duration
+
+	// Number of extra dial attempts when the engine socket refuses connections or is missing
+	DialRetries int
+
+	// Initial backoff between dial attempts, doubled after each attempt
+	DialRetryBackoff time.Duration
+}
+
+// DefaultTransportOptions returns transport settings suited to CLI and embedded use
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		MaxIdleConns:          16,
+		IdleConnTimeout:       90 * time.Second,
+		DialTimeout:           5 * time.Second,
+		ResponseHeaderTimeout: 0,
+		DialRetries:           3,
+		DialRetryBackoff:      100 * time.Millisecond,
+	}
+}
+
+// NewUnixSocketHTTPClient creates an HTTP client that sends every request to the engine socket
+func NewUnixSocketHTTPClient(socketPath string, opts TransportOptions) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:           unixDialer(socketPath, opts),
+			MaxIdleConns:          opts.MaxIdleConns,
+			MaxIdleConnsPerHost:   opts.MaxIdleConns,
+			IdleConnTimeout:       opts.IdleConnTimeout,
+			ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		},
+	}
+}
+
+// NewTCPHTTPClient creates an HTTP client for the TCP admin API of a remote engine
+func NewTCPHTTPClient(opts TransportOptions) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: opts.DialTimeout}).DialContext,
+			MaxIdleConns:          opts.MaxIdleConns,
+			MaxIdleConnsPerHost:   opts.MaxIdleConns,
+			IdleConnTimeout:       opts.IdleConnTimeout,
+			ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		},
+	}
+}
+
+// unixDialer dials the socket, or named pipe on Windows, retrying with exponential
+// backoff while the engine is not accepting connections
+func unixDialer(socketPath string, opts TransportOptions) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		backoff := opts.DialRetryBackoff
+		for attempt := 0; ; attempt++ {
+			conn, err := dialSocket(ctx, socketPath, opts.DialTimeout)
+			if err == nil || attempt >= opts.DialRetries || !ipc.NotReady(err) {
+				return conn, err
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, err
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// dialSocket makes one dial attempt, giving up after timeout
+func dialSocket(ctx context.Context, socketPath string, timeout time.Duration) (net.Conn, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return ipc.Dial(ctx, socketPath)
+}
@@ -0,0 +1,36 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// QueueTimeoutError is returned by Acquire when a call waited for an instance of a
+// saturated pool longer than its max queue wait.
+type QueueTimeoutError struct {
+	// How long the call waited before it was turned away
+	Waited time.Duration
+
+	// Calls still waiting when it was turned away
+	Depth int
+}
+
+func (e *QueueTimeoutError) Error() string {
+	return fmt.Sprintf("no instance became free within %s (%d calls waiting)", e.Waited, e.Depth)
+}
+
+type maxQueueWaitKey struct{}
+
+// WithMaxQueueWait returns a context whose calls wait at most d for an instance of a
+// saturated pool, overriding the MaxQueueWait of the pool. Zero waits as long as the
+// context allows.
+func WithMaxQueueWait(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, maxQueueWaitKey{}, d)
+}
+
+// MaxQueueWaitFromContext returns the max queue wait set with WithMaxQueueWait, if any.
+func MaxQueueWaitFromContext(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(maxQueueWaitKey{}).(time.Duration)
+	return d, ok
+}
@@ -19,6 +19,9 @@ type CircuitBreaker interface {
 	// Reset the circuit breaker
 	Reset()
 
+	// Open the circuit immediately, regardless of the failure count
+	Trip()
+
 	// Get the current state
 	GetState() string
 
@@ -33,6 +36,11 @@ const (
 	StateOpen     = "open"
 )
 
+// StateChangeFunc is called after the circuit breaker of a function, or of one of its
+// entrypoints, moves from one state to another, outside of the breaker's lock. The
+// entrypoint is empty for the breaker of the whole function.
+type StateChangeFunc func(key FunctionKey, entrypoint, from, to string, failures int)
+
 // that is simplified to use a consistent locking strategy.
 type defaultCircuitBreaker struct {
 	// Mutex for protecting all state changes
@@ -50,67 +58,100 @@ type defaultCircuitBreaker struct {
 	// Settings
 	failureThreshold int
 	resetTimeout     time.Duration
+	clock            Clock
+
+	// Called on every state change (may be nil)
+	onStateChange func(from, to string, failures int)
 }
 
 // NewCircuitBreakerWithOptions creates a new circuit breaker with custom settings.
 func NewCircuitBreakerWithOptions(failureThreshold int, resetTimeout time.Duration) CircuitBreaker {
+	return newCircuitBreaker(failureThreshold, resetTimeout, nil, nil)
+}
+
+func newCircuitBreaker(failureThreshold int, resetTimeout time.Duration, clock Clock,
+	onStateChange func(from, to string, failures int)) *defaultCircuitBreaker {
+	clock = clockOrSystem(clock)
 	return &defaultCircuitBreaker{
 		state:            StateClosed,
 		failures:         0,
-		lastFailure:      time.Now(),
+		lastFailure:      clock.Now(),
 		failureThreshold: failureThreshold,
 		resetTimeout:     resetTimeout,
+		clock:            clock,
+		onStateChange:    onStateChange,
 	}
 }
 
-// RecordSuccess records a successful operation and resets the failure count if in half-open state.
-func (cb *defaultCircuitBreaker) RecordSuccess() {
+// update applies a change under the lock and reports a resulting state change
+// once the lock is released.
+func (cb *defaultCircuitBreaker) update(change func()) {
 	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+	from := cb.state
+	change()
+	to, failures := cb.state, cb.failures
+	cb.mutex.Unlock()
 
-	if cb.state == StateHalfOpen {
-		cb.failures = 0
-		cb.state = StateClosed
+	if from != to && cb.onStateChange != nil {
+		cb.onStateChange(from, to, failures)
 	}
 }
 
-func (cb *defaultCircuitBreaker) RecordFailure() bool {
-	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
-
-	cb.failures++
-	cb.lastFailure = time.Now()
-
-	if cb.state == StateClosed && cb.failures >= cb.failureThreshold {
-		cb.state = StateOpen
-	}
+// RecordSuccess records a successful operation and resets the failure count if in half-open state.
+func (cb *defaultCircuitBreaker) RecordSuccess() {
+	cb.update(func() {
+		if cb.state == StateHalfOpen {
+			cb.failures = 0
+			cb.state = StateClosed
+		}
+	})
+}
 
-	return cb.state == StateOpen
+func (cb *defaultCircuitBreaker) RecordFailure() bool {
+	var open bool
+	cb.update(func() {
+		cb.failures++
+		cb.lastFailure = cb.clock.Now()
+
+		if cb.state == StateClosed && cb.failures >= cb.failureThreshold {
+			cb.state = StateOpen
+		}
+
+		open = cb.state == StateOpen
+	})
+	return open
 }
 
 func (cb *defaultCircuitBreaker) IsOpen() bool {
-	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
-
-	if cb.state != StateOpen {
-		return false
-	}
-
-	// Check if reset timeout has expired
-	if time.Since(cb.lastFailure) > cb.resetTimeout {
-		cb.state = StateHalfOpen
-		return false
-	}
-
-	return true
+	var open bool
+	cb.update(func() {
+		if cb.state != StateOpen {
+			return
+		}
+
+		// Check if reset timeout has expired
+		if cb.clock.Since(cb.lastFailure) > cb.resetTimeout {
+			cb.state = StateHalfOpen
+			return
+		}
+
+		open = true
+	})
+	return open
 }
 
 func (cb *defaultCircuitBreaker) Reset() {
-	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+	cb.update(func() {
+		cb.failures = 0
+		cb.state = StateClosed
+	})
+}
 
-	cb.failures = 0
-	cb.state = StateClosed
+func (cb *defaultCircuitBreaker) Trip() {
+	cb.update(func() {
+		cb.state = StateOpen
+		cb.lastFailure = cb.clock.Now()
+	})
 }
 
 func (cb *defaultCircuitBreaker) GetState() string {
@@ -1,32 +1,82 @@
 package components
 
 import (
+	"cmp"
+	"slices"
 	"sync"
 	"time"
+
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+)
+
+// Granularities of circuit breakers
+const (
+	// One circuit breaker per function, opened by failures of any of its entrypoints
+	GranularityFunction = "function"
+
+	// One circuit breaker per entrypoint, so a failing entrypoint leaves the others callable
+	GranularityEntrypoint = "entrypoint"
 )
 
 // CircuitBreakerManager manages circuit breakers for functions.
 type CircuitBreakerManager interface {
 	// Get a circuit breaker for a function
-	GetCircuitBreaker(key string) CircuitBreaker
+	GetCircuitBreaker(key FunctionKey) CircuitBreaker
+
+	// Get the circuit breaker of one entrypoint of a function
+	GetEntrypointCircuitBreaker(key FunctionKey, entrypoint string) CircuitBreaker
 
-	// Remove a circuit breaker
-	RemoveCircuitBreaker(key string)
+	// Remove the circuit breakers of a function, including those of its entrypoints
+	RemoveCircuitBreaker(key FunctionKey)
 
 	// Reset all circuit breakers
 	Reset()
 
 	// Get state of a circuit breaker
-	GetCircuitBreakerState(key string) string
+	GetCircuitBreakerState(key FunctionKey) string
+
+	// Get all circuit breakers of functions as a map
+	GetAllCircuitBreakers() map[FunctionKey]CircuitBreaker
+
+	// List the state of every circuit breaker, of functions and of entrypoints
+	ListCircuitBreakers() []CircuitBreakerStatus
+}
+
+// CircuitBreakerStatus is the state of one circuit breaker.
+type CircuitBreakerStatus struct {
+	Namespace string `json:"namespace"`
+	Function  string `json:"function"`
 
-	// Get all circuit breakers as a map
-	GetAllCircuitBreakers() map[string]CircuitBreaker
+	// Entrypoint of the breaker, empty for the breaker of the whole function
+	Entrypoint string `json:"entrypoint,omitempty"`
+
+	State    string `json:"state"`
+	Failures int    `json:"failures"`
 }
 
 // CircuitBreakerSettings holds configuration for circuit breakers.
 type CircuitBreakerSettings struct {
 	FailureThreshold int
 	ResetTimeout     time.Duration
+
+	// Whether calls are guarded by a breaker per function or per entrypoint (empty
+	// means per function)
+	Granularity string
+
+	// Codes of failed calls that do not count toward the failure threshold
+	Ignore []domainerrors.Code
+
+	// Time source for reset timeouts; nil uses the system clock
+	Clock Clock
+
+	// Optional callback for circuit breaker state changes
+	OnStateChange StateChangeFunc
+}
+
+// breakerKey identifies the breaker of a function, or of one of its entrypoints.
+type breakerKey struct {
+	key        FunctionKey
+	entrypoint string
 }
 
 // defaultCircuitBreakerManager implements the CircuitBreakerManager interface.
@@ -37,6 +87,8 @@ type defaultCircuitBreakerManager struct {
 	// Default settings for new circuit breakers
 	failureThreshold int
 	resetTimeout     time.Duration
+	clock            Clock
+	onStateChange    StateChangeFunc
 }
 
 // NewCircuitBreakerManagerWithOptions creates a new circuit breaker manager with custom settings.
@@ -44,13 +96,25 @@ func NewCircuitBreakerManagerWithOptions(settings CircuitBreakerSettings) Circui
 	return &defaultCircuitBreakerManager{
 		failureThreshold: settings.FailureThreshold,
 		resetTimeout:     settings.ResetTimeout,
+		clock:            settings.Clock,
+		onStateChange:    settings.OnStateChange,
 	}
 }
 
 // GetCircuitBreaker retrieves a circuit breaker by key, creating it if it doesn't exist.
-func (cbm *defaultCircuitBreakerManager) GetCircuitBreaker(key string) CircuitBreaker {
+func (cbm *defaultCircuitBreakerManager) GetCircuitBreaker(key FunctionKey) CircuitBreaker {
+	return cbm.breaker(breakerKey{key: key})
+}
+
+// GetEntrypointCircuitBreaker retrieves the circuit breaker of an entrypoint, creating
+// it if it doesn't exist.
+func (cbm *defaultCircuitBreakerManager) GetEntrypointCircuitBreaker(key FunctionKey, entrypoint string) CircuitBreaker {
+	return cbm.breaker(breakerKey{key: key, entrypoint: entrypoint})
+}
+
+func (cbm *defaultCircuitBreakerManager) breaker(bk breakerKey) CircuitBreaker {
 	// Try to get existing circuit breaker
-	if cb, exists := cbm.circuitBreakers.Load(key); exists {
+	if cb, exists := cbm.circuitBreakers.Load(bk); exists {
 		circuitBreaker, ok := cb.(CircuitBreaker)
 		if !ok {
 			// This should never happen, but let's be defensive
@@ -60,13 +124,21 @@ func (cbm *defaultCircuitBreakerManager) GetCircuitBreaker(key string) CircuitBr
 	}
 
 	// Create a new circuit breaker with default settings
-	newCB := NewCircuitBreakerWithOptions(
+	var onStateChange func(from, to string, failures int)
+	if cbm.onStateChange != nil {
+		onStateChange = func(from, to string, failures int) {
+			cbm.onStateChange(bk.key, bk.entrypoint, from, to, failures)
+		}
+	}
+	newCB := newCircuitBreaker(
 		cbm.failureThreshold,
 		cbm.resetTimeout,
+		cbm.clock,
+		onStateChange,
 	)
 
 	// Try to store it (may fail if another goroutine created one concurrently)
-	actualCB, _ := cbm.circuitBreakers.LoadOrStore(key, newCB)
+	actualCB, _ := cbm.circuitBreakers.LoadOrStore(bk, newCB)
 	circuitBreaker, ok := actualCB.(CircuitBreaker)
 	if !ok {
 		// This should never happen, but let's be defensive
@@ -76,8 +148,13 @@ func (cbm *defaultCircuitBreakerManager) GetCircuitBreaker(key string) CircuitBr
 }
 
 // RemoveCircuitBreaker removes a circuit breaker from the manager.
-func (cbm *defaultCircuitBreakerManager) RemoveCircuitBreaker(key string) {
-	cbm.circuitBreakers.Delete(key)
+func (cbm *defaultCircuitBreakerManager) RemoveCircuitBreaker(key FunctionKey) {
+	cbm.circuitBreakers.Range(func(k, _ interface{}) bool {
+		if bk, ok := k.(breakerKey); ok && bk.key == key {
+			cbm.circuitBreakers.Delete(k)
+		}
+		return true
+	})
 }
 
 // Reset resets all circuit breakers to their initial state.
@@ -95,8 +172,8 @@ func (cbm *defaultCircuitBreakerManager) Reset() {
 }
 
 // GetCircuitBreakerState gets the state of a specific circuit breaker.
-func (cbm *defaultCircuitBreakerManager) GetCircuitBreakerState(key string) string {
-	if cb, exists := cbm.circuitBreakers.Load(key); exists {
+func (cbm *defaultCircuitBreakerManager) GetCircuitBreakerState(key FunctionKey) string {
+	if cb, exists := cbm.circuitBreakers.Load(breakerKey{key: key}); exists {
 		circuitBreaker, ok := cb.(CircuitBreaker)
 		if !ok {
 			// This should never happen, but let's be defensive
@@ -107,19 +184,45 @@ func (cbm *defaultCircuitBreakerManager) GetCircuitBreakerState(key string) stri
 	return ""
 }
 
-// GetAllCircuitBreakers returns all circuit breakers as a map.
-// This is used primarily for testing and monitoring.
-func (cbm *defaultCircuitBreakerManager) GetAllCircuitBreakers() map[string]CircuitBreaker {
-	result := make(map[string]CircuitBreaker)
+// GetAllCircuitBreakers returns the circuit breakers of functions as a map, leaving out
+// those of entrypoints. This is used primarily for testing and monitoring.
+func (cbm *defaultCircuitBreakerManager) GetAllCircuitBreakers() map[FunctionKey]CircuitBreaker {
+	result := make(map[FunctionKey]CircuitBreaker)
 
 	cbm.circuitBreakers.Range(func(key, value interface{}) bool {
-		k, kOk := key.(string)
+		k, kOk := key.(breakerKey)
 		v, vOk := value.(CircuitBreaker)
-		if kOk && vOk {
-			result[k] = v
+		if kOk && vOk && k.entrypoint == "" {
+			result[k.key] = v
 		}
 		return true
 	})
 
 	return result
 }
+
+// ListCircuitBreakers returns the state of every circuit breaker, ordered by function
+// and entrypoint.
+func (cbm *defaultCircuitBreakerManager) ListCircuitBreakers() []CircuitBreakerStatus {
+	statuses := []CircuitBreakerStatus{}
+	cbm.circuitBreakers.Range(func(key, value interface{}) bool {
+		k, kOk := key.(breakerKey)
+		v, vOk := value.(CircuitBreaker)
+		if kOk && vOk {
+			statuses = append(statuses, CircuitBreakerStatus{
+				Namespace:  k.key.Namespace,
+				Function:   k.key.Name,
+				Entrypoint: k.entrypoint,
+				State:      v.GetState(),
+				Failures:   v.GetFailureCount(),
+			})
+		}
+		return true
+	})
+
+	slices.SortFunc(statuses, func(a, b CircuitBreakerStatus) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Function, b.Function),
+			cmp.Compare(a.Entrypoint, b.Entrypoint))
+	})
+	return statuses
+}
@@ -0,0 +1,77 @@
+package components
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerReportsStateChanges(t *testing.T) {
+	type change struct {
+		key      FunctionKey
+		from, to string
+		failures int
+	}
+	var changes []change
+
+	clock := NewFakeClock(time.Now())
+	manager := NewCircuitBreakerManagerWithOptions(CircuitBreakerSettings{
+		FailureThreshold: 2,
+		ResetTimeout:     time.Minute,
+		Clock:            clock,
+		OnStateChange: func(key FunctionKey, _, from, to string, failures int) {
+			changes = append(changes, change{key, from, to, failures})
+		},
+	})
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	cb := manager.GetCircuitBreaker(key)
+
+	cb.RecordFailure()
+	assert.Empty(t, changes)
+
+	assert.True(t, cb.RecordFailure())
+	clock.Advance(30 * time.Second)
+	assert.True(t, cb.IsOpen())
+	clock.Advance(31 * time.Second)
+	assert.False(t, cb.IsOpen())
+	cb.RecordSuccess()
+
+	assert.Equal(t, []change{
+		{key, StateClosed, StateOpen, 2},
+		{key, StateOpen, StateHalfOpen, 2},
+		{key, StateHalfOpen, StateClosed, 0},
+	}, changes)
+}
+
+func TestEntrypointCircuitBreakers(t *testing.T) {
+	var opened []string
+	manager := NewCircuitBreakerManagerWithOptions(CircuitBreakerSettings{
+		FailureThreshold: 1,
+		ResetTimeout:     time.Minute,
+		Granularity:      GranularityEntrypoint,
+		OnStateChange: func(_ FunctionKey, entrypoint, _, to string, _ int) {
+			if to == StateOpen {
+				opened = append(opened, entrypoint)
+			}
+		},
+	})
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+
+	// A failing entrypoint does not open the breaker of the others
+	manager.GetEntrypointCircuitBreaker(key, "charge").RecordFailure()
+	assert.True(t, manager.GetEntrypointCircuitBreaker(key, "charge").IsOpen())
+	assert.False(t, manager.GetEntrypointCircuitBreaker(key, "refund").IsOpen())
+	assert.False(t, manager.GetCircuitBreaker(key).IsOpen())
+	assert.Equal(t, []string{"charge"}, opened)
+
+	assert.Equal(t, []CircuitBreakerStatus{
+		{Namespace: "ns", Function: "fn", State: StateClosed},
+		{Namespace: "ns", Function: "fn", Entrypoint: "charge", State: StateOpen, Failures: 1},
+		{Namespace: "ns", Function: "fn", Entrypoint: "refund", State: StateClosed},
+	}, manager.ListCircuitBreakers())
+	assert.Len(t, manager.GetAllCircuitBreakers(), 1)
+
+	manager.RemoveCircuitBreaker(key)
+	assert.Empty(t, manager.ListCircuitBreakers())
+}
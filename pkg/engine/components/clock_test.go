@@ -0,0 +1,68 @@
+package components
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClockTickers(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ticker := clock.NewTicker(time.Minute)
+	timer := clock.After(90 * time.Second)
+	assert.Equal(t, 2, clock.Waiters())
+
+	clock.Advance(59 * time.Second)
+	assert.Empty(t, ticker.C())
+	assert.Empty(t, timer)
+
+	// Ticks that are not received are dropped rather than queued
+	clock.Advance(5 * time.Minute)
+	assert.Len(t, ticker.C(), 1)
+	assert.Equal(t, clock.Now().Add(-4*time.Minute-59*time.Second), <-ticker.C())
+	assert.Len(t, timer, 1)
+	assert.Equal(t, 1, clock.Waiters())
+
+	ticker.Stop()
+	clock.Advance(time.Hour)
+	assert.Empty(t, ticker.C())
+	assert.Equal(t, 0, clock.Waiters())
+}
+
+func TestPluginManagerUnloadsIdlePluginsAfterTTL(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	pm := NewPluginManager(logging.NewStdLogger(io.Discard), PluginManagerSettings{
+		TTL:             10 * time.Minute,
+		CleanupInterval: time.Minute,
+		Clock:           clock,
+		Pool:            PoolSettings{ScaleInterval: time.Hour},
+	})
+	defer pm.Shutdown()
+
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	factory := func(context.Context) (*extism.Plugin, error) {
+		return newTestPlugin(t), nil
+	}
+	pm.StorePlugin(key, newTestPlugin(t), factory, "digest", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pm.StartCleanup(ctx)
+
+	// Using the plugin keeps it loaded past the TTL measured from when it was stored
+	clock.Advance(8 * time.Minute)
+	_, ok := pm.GetPool(key)
+	assert.True(t, ok)
+	clock.Advance(8 * time.Minute)
+	assert.True(t, pm.IsPluginLoaded(key))
+
+	clock.Advance(3 * time.Minute)
+	assert.Eventually(t, func() bool { return !pm.IsPluginLoaded(key) }, 5*time.Second, 5*time.Millisecond)
+	loaded, _ := pm.WasPreviouslyLoaded(key)
+	assert.True(t, loaded)
+}
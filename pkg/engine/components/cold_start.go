@@ -0,0 +1,156 @@
+package components
+
+import (
+	"sync"
+	"time"
+)
+
+// ColdStartPhases holds the duration of each phase of a cold start in milliseconds.
+type ColdStartPhases struct {
+	PullMs        float64 `json:"pull_ms"`
+	CompileMs     float64 `json:"compile_ms"`
+	InstantiateMs float64 `json:"instantiate_ms"`
+	FirstCallMs   float64 `json:"first_call_ms"`
+	TotalMs       float64 `json:"total_ms"`
+}
+
+// ColdStart describes one load of a function up to its first call.
+type ColdStart struct {
+	Time time.Time `json:"time"`
+	ColdStartPhases
+
+	// The function was loaded but has not been called yet, so FirstCallMs is unknown
+	AwaitingFirstCall bool `json:"awaiting_first_call,omitempty"`
+}
+
+// ColdStartStats summarizes the cold starts of a function. Averages and maxima
+// cover the cold starts that reached their first call.
+type ColdStartStats struct {
+	Count   int64           `json:"count"`
+	Last    *ColdStart      `json:"last,omitempty"`
+	Average ColdStartPhases `json:"average"`
+	Max     ColdStartPhases `json:"max"`
+}
+
+// ColdStartTracker records the phases of every function load (registry pull,
+// module compile, first instantiation) and of the first call that follows.
+type ColdStartTracker struct {
+	mu        sync.Mutex
+	functions map[FunctionKey]*coldStartRecord
+}
+
+type coldStartRecord struct {
+	last  ColdStart
+	count int64
+	sum   ColdStartPhases
+	max   ColdStartPhases
+}
+
+// NewColdStartTracker creates an empty tracker.
+func NewColdStartTracker() *ColdStartTracker {
+	return &ColdStartTracker{functions: make(map[FunctionKey]*coldStartRecord)}
+}
+
+// Loaded records the load phases of a function; the cold start completes with its next call.
+func (t *ColdStartTracker) Loaded(key FunctionKey, pull, compile, instantiate time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.functions[key]
+	if !ok {
+		record = &coldStartRecord{}
+		t.functions[key] = record
+	}
+	record.last = ColdStart{
+		Time: time.Now(),
+		ColdStartPhases: ColdStartPhases{
+			PullMs:        milliseconds(pull),
+			CompileMs:     milliseconds(compile),
+			InstantiateMs: milliseconds(instantiate),
+			TotalMs:       milliseconds(pull + compile + instantiate),
+		},
+		AwaitingFirstCall: true,
+	}
+}
+
+// Called records a call of a function. It completes the cold start when the call
+// is the first since the function was loaded, returning the finished record.
+func (t *ColdStartTracker) Called(key FunctionKey, duration time.Duration) (ColdStart, bool) {
+	if t == nil {
+		return ColdStart{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.functions[key]
+	if !ok || !record.last.AwaitingFirstCall {
+		return ColdStart{}, false
+	}
+
+	last := &record.last
+	last.AwaitingFirstCall = false
+	last.FirstCallMs = milliseconds(duration)
+	last.TotalMs += last.FirstCallMs
+
+	record.count++
+	record.sum = addPhases(record.sum, last.ColdStartPhases)
+	record.max = maxPhases(record.max, last.ColdStartPhases)
+	return *last, true
+}
+
+// Stats returns the cold start statistics of every function that was loaded.
+func (t *ColdStartTracker) Stats() map[FunctionKey]ColdStartStats {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[FunctionKey]ColdStartStats, len(t.functions))
+	for key, record := range t.functions {
+		last := record.last
+		entry := ColdStartStats{Count: record.count, Last: &last, Max: record.max}
+		if record.count > 0 {
+			n := float64(record.count)
+			entry.Average = ColdStartPhases{
+				PullMs:        record.sum.PullMs / n,
+				CompileMs:     record.sum.CompileMs / n,
+				InstantiateMs: record.sum.InstantiateMs / n,
+				FirstCallMs:   record.sum.FirstCallMs / n,
+				TotalMs:       record.sum.TotalMs / n,
+			}
+		}
+		stats[key] = entry
+	}
+	return stats
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func addPhases(a, b ColdStartPhases) ColdStartPhases {
+	return ColdStartPhases{
+		PullMs:        a.PullMs + b.PullMs,
+		CompileMs:     a.CompileMs + b.CompileMs,
+		InstantiateMs: a.InstantiateMs + b.InstantiateMs,
+		FirstCallMs:   a.FirstCallMs + b.FirstCallMs,
+		TotalMs:       a.TotalMs + b.TotalMs,
+	}
+}
+
+func maxPhases(a, b ColdStartPhases) ColdStartPhases {
+	return ColdStartPhases{
+		PullMs:        max(a.PullMs, b.PullMs),
+		CompileMs:     max(a.CompileMs, b.CompileMs),
+		InstantiateMs: max(a.InstantiateMs, b.InstantiateMs),
+		FirstCallMs:   max(a.FirstCallMs, b.FirstCallMs),
+		TotalMs:       max(a.TotalMs, b.TotalMs),
+	}
+}
@@ -0,0 +1,45 @@
+package components
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColdStartTracker(t *testing.T) {
+	tracker := NewColdStartTracker()
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+
+	// Calls of a function that was not loaded through the tracker are not cold starts
+	_, ok := tracker.Called(key, time.Millisecond)
+	assert.False(t, ok)
+
+	tracker.Loaded(key, 10*time.Millisecond, 20*time.Millisecond, 30*time.Millisecond)
+	stats := tracker.Stats()[key]
+	assert.Equal(t, int64(0), stats.Count)
+	require.NotNil(t, stats.Last)
+	assert.True(t, stats.Last.AwaitingFirstCall)
+	assert.Equal(t, 60.0, stats.Last.TotalMs)
+
+	coldStart, ok := tracker.Called(key, 40*time.Millisecond)
+	require.True(t, ok)
+	assert.Equal(t, 40.0, coldStart.FirstCallMs)
+	assert.Equal(t, 100.0, coldStart.TotalMs)
+
+	// Only the first call after a load completes a cold start
+	_, ok = tracker.Called(key, time.Second)
+	assert.False(t, ok)
+
+	tracker.Loaded(key, 30*time.Millisecond, 0, 10*time.Millisecond)
+	tracker.Called(key, 0)
+
+	stats = tracker.Stats()[key]
+	assert.Equal(t, int64(2), stats.Count)
+	assert.Equal(t, 20.0, stats.Average.PullMs)
+	assert.Equal(t, 70.0, stats.Average.TotalMs)
+	assert.Equal(t, 30.0, stats.Max.PullMs)
+	assert.Equal(t, 40.0, stats.Max.FirstCallMs)
+	assert.Equal(t, 100.0, stats.Max.TotalMs)
+}
@@ -0,0 +1,114 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+)
+
+// CompiledCacheStats describes the modules held by a compiled module cache.
+type CompiledCacheStats struct {
+	Modules    int   `json:"modules"`
+	References int   `json:"references"`
+	Hits       int64 `json:"hits"`
+}
+
+// CompiledCache shares compiled modules between the functions that load the same
+// module with the same settings and config, such as one digest loaded under several
+// names. Each module is closed once the last function holding it releases it.
+type CompiledCache struct {
+	mu      sync.Mutex
+	modules map[string]*cachedModule
+	hits    int64
+}
+
+type cachedModule struct {
+	compiled *extism.CompiledPlugin
+	refs     int
+}
+
+// NewCompiledCache creates an empty compiled module cache.
+func NewCompiledCache() *CompiledCache {
+	return &CompiledCache{modules: make(map[string]*cachedModule)}
+}
+
+// CompiledCacheKey identifies a compiled module by the digest of its wasm and the
+// settings and config compiled into it along with the wasm.
+func CompiledCacheKey(digest string, settings manifest.FunctionVersionSettings, config map[string]string) string {
+	// Maps are encoded with sorted keys, so equal configs give equal keys
+	encoded, _ := json.Marshal(struct {
+		AllowedUrls []string          `json:"allowed_urls"`
+		Wasi        bool              `json:"wasi"`
+		Config      map[string]string `json:"config"`
+	}{settings.AllowedUrls, settings.Wasi, config})
+	return digest + "\x00" + string(encoded)
+}
+
+// Acquire returns the module stored under key and the function releasing it, or false
+// when no loaded function holds that module.
+func (c *CompiledCache) Acquire(key string) (*extism.CompiledPlugin, func(), bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	module, ok := c.modules[key]
+	if !ok {
+		return nil, nil, false
+	}
+	module.refs++
+	c.hits++
+	return module.compiled, c.releaser(key, module), true
+}
+
+// Add stores a compiled module under key and returns the module to use with the
+// function releasing it. When a concurrent load stored the same module first, compiled
+// is closed and the stored module returned instead.
+func (c *CompiledCache) Add(key string, compiled *extism.CompiledPlugin) (*extism.CompiledPlugin, func()) {
+	c.mu.Lock()
+	module, ok := c.modules[key]
+	if ok {
+		module.refs++
+	} else {
+		module = &cachedModule{compiled: compiled, refs: 1}
+		c.modules[key] = module
+	}
+	c.mu.Unlock()
+
+	if ok {
+		compiled.Close(context.Background())
+	}
+	return module.compiled, c.releaser(key, module)
+}
+
+// releaser returns a function dropping one reference to module, once however often it
+// is called.
+func (c *CompiledCache) releaser(key string, module *cachedModule) func() {
+	return sync.OnceFunc(func() {
+		c.mu.Lock()
+		module.refs--
+		unused := module.refs == 0
+		if unused {
+			delete(c.modules, key)
+		}
+		c.mu.Unlock()
+
+		if unused {
+			module.compiled.Close(context.Background())
+		}
+	})
+}
+
+// Stats returns the number of cached modules, the references held to them and how
+// many loads reused a cached module.
+func (c *CompiledCache) Stats() CompiledCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CompiledCacheStats{Modules: len(c.modules), Hits: c.hits}
+	for _, module := range c.modules {
+		stats.References += module.refs
+	}
+	return stats
+}
@@ -0,0 +1,58 @@
+package components
+
+import (
+	"context"
+	"testing"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompiledCache(t *testing.T) {
+	compile := func() *extism.CompiledPlugin {
+		compiled, err := extism.NewCompiledPlugin(context.Background(),
+			extism.Manifest{Wasm: []extism.Wasm{extism.WasmData{Data: emptyModule}}},
+			extism.PluginConfig{}, []extism.HostFunction{})
+		require.NoError(t, err)
+		return compiled
+	}
+
+	cache := NewCompiledCache()
+	settings := manifest.FunctionVersionSettings{Wasi: true}
+	key := CompiledCacheKey("sha256:a", settings, map[string]string{"a": "1", "b": "2"})
+
+	// Config order does not matter, but config values and settings do
+	assert.Equal(t, key, CompiledCacheKey("sha256:a", settings, map[string]string{"b": "2", "a": "1"}))
+	assert.NotEqual(t, key, CompiledCacheKey("sha256:a", settings, map[string]string{"a": "1"}))
+	assert.NotEqual(t, key, CompiledCacheKey("sha256:a", manifest.FunctionVersionSettings{}, map[string]string{"a": "1", "b": "2"}))
+
+	_, _, ok := cache.Acquire(key)
+	assert.False(t, ok)
+
+	first, releaseFirst := cache.Add(key, compile())
+	shared, releaseShared, ok := cache.Acquire(key)
+	require.True(t, ok)
+	assert.Same(t, first, shared)
+
+	// A module compiled concurrently is dropped for the stored one
+	duplicate, releaseDuplicate := cache.Add(key, compile())
+	assert.Same(t, first, duplicate)
+	assert.Equal(t, CompiledCacheStats{Modules: 1, References: 3, Hits: 1}, cache.Stats())
+
+	// Releasing twice drops a single reference
+	releaseFirst()
+	releaseFirst()
+	releaseDuplicate()
+	assert.Equal(t, 1, cache.Stats().References)
+
+	plugin, err := shared.Instance(context.Background(), extism.PluginInstanceConfig{})
+	require.NoError(t, err)
+	plugin.Close(context.Background())
+
+	releaseShared()
+	assert.Equal(t, CompiledCacheStats{Hits: 1}, cache.Stats())
+	_, _, ok = cache.Acquire(key)
+	assert.False(t, ok)
+}
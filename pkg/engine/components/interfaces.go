@@ -4,43 +4,49 @@ import (
 	"context"
 
 	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
 	"github.com/ignitionstack/ignition/pkg/engine/logging"
 )
 
+// FunctionKey identifies a function by namespace and name.
+type FunctionKey = interfaces.FunctionKey
+
 // FunctionID represents a unique function identifier.
-type FunctionID struct {
-	Namespace string
-	Name      string
-}
+//
+// Deprecated: use FunctionKey.
+type FunctionID = FunctionKey
 
 // PluginManager defines all plugin management capabilities
 type PluginManager interface {
 	// Plugin operations
-	GetPlugin(key string) (*extism.Plugin, bool)
-	StorePlugin(key string, plugin *extism.Plugin, digest string, config map[string]string)
-	RemovePlugin(key string) bool
+	GetPool(key FunctionKey) (*PluginPool, bool)
+	StorePlugin(key FunctionKey, plugin *extism.Plugin, factory PluginFactory, digest string, config map[string]string)
+	RemovePlugin(key FunctionKey) bool
+	ScaleFunction(key FunctionKey, instances int) error
 
 	// Plugin state management
-	IsPluginLoaded(key string) bool
-	WasPreviouslyLoaded(key string) (bool, map[string]string)
-	HasConfigChanged(key string, newConfig map[string]string) bool
-	HasDigestChanged(key string, newDigest string) bool
-	GetPluginDigest(key string) (string, bool)
-	GetPluginConfig(key string) (map[string]string, bool)
+	IsPluginLoaded(key FunctionKey) bool
+	WasPreviouslyLoaded(key FunctionKey) (bool, map[string]string)
+	HasConfigChanged(key FunctionKey, newConfig map[string]string) bool
+	HasDigestChanged(key FunctionKey, newDigest string) bool
+	GetPluginDigest(key FunctionKey) (string, bool)
+	GetPluginConfig(key FunctionKey) (map[string]string, bool)
 
 	// Function state control
-	StopFunction(key string) bool
-	IsFunctionStopped(key string) bool
-	ClearStoppedStatus(key string)
+	StopFunction(key FunctionKey) bool
+	IsFunctionStopped(key FunctionKey) bool
+	ClearStoppedStatus(key FunctionKey)
 
 	// Lifecycle management
 	StartCleanup(ctx context.Context)
 	Shutdown()
 
 	// Information provider
-	ListLoadedFunctions() []string
+	ListLoadedFunctions() []FunctionKey
 	GetLoadedFunctionCount() int
-	GetPreviouslyLoadedFunctions() map[string]bool
-	GetStoppedFunctions() map[string]bool
-	GetLogStore() *logging.FunctionLogStore
+	GetPreviouslyLoadedFunctions() map[FunctionKey]bool
+	GetStoppedFunctions() map[FunctionKey]bool
+	GetFunctionScales() map[FunctionKey]int
+	GetPoolStats() map[FunctionKey]PoolStats
+	GetLogStore() logging.LogStore
 }
@@ -0,0 +1,103 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// FunctionPhase is the state of a function in its lifecycle.
+type FunctionPhase string
+
+// Function lifecycle states.
+const (
+	PhaseUnloaded FunctionPhase = "unloaded" // not in memory, loaded again on request
+	PhaseLoading  FunctionPhase = "loading"  // being pulled, compiled and instantiated
+	PhaseRunning  FunctionPhase = "running"  // loaded and serving calls
+	PhaseIdle     FunctionPhase = "idle"     // evicted after its TTL, loaded again on request
+	PhaseStopped  FunctionPhase = "stopped"  // stopped explicitly, loaded again only when forced
+	PhaseFailed   FunctionPhase = "failed"   // its load failed or its circuit breaker is open
+)
+
+// transitions lists the states each state may move to.
+var transitions = map[FunctionPhase][]FunctionPhase{
+	PhaseUnloaded: {PhaseLoading, PhaseStopped},
+	PhaseLoading:  {PhaseRunning, PhaseFailed, PhaseStopped},
+	PhaseRunning:  {PhaseLoading, PhaseIdle, PhaseUnloaded, PhaseStopped, PhaseFailed},
+	PhaseIdle:     {PhaseLoading, PhaseUnloaded, PhaseStopped},
+	PhaseStopped:  {PhaseLoading},
+	PhaseFailed:   {PhaseLoading, PhaseRunning, PhaseUnloaded, PhaseStopped},
+}
+
+// ErrInvalidTransition is returned when a function cannot move to the requested state
+// from its current one.
+var ErrInvalidTransition = errors.New("invalid function state transition")
+
+// PhaseChangeFunc is called after a function moves from one state to another,
+// outside of the lifecycle's lock.
+type PhaseChangeFunc func(key FunctionKey, from, to FunctionPhase, reason string)
+
+// Lifecycle holds the state of every function the engine has seen and only lets it
+// change along the allowed transitions. Functions it has not seen are unloaded.
+type Lifecycle struct {
+	mu       sync.RWMutex
+	phases   map[FunctionKey]FunctionPhase
+	onChange PhaseChangeFunc
+}
+
+// NewLifecycle creates a lifecycle calling onChange, which may be nil, on every transition.
+func NewLifecycle(onChange PhaseChangeFunc) *Lifecycle {
+	return &Lifecycle{
+		phases:   make(map[FunctionKey]FunctionPhase),
+		onChange: onChange,
+	}
+}
+
+// Phase returns the state of a function.
+func (l *Lifecycle) Phase(key FunctionKey) FunctionPhase {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.phase(key)
+}
+
+func (l *Lifecycle) phase(key FunctionKey) FunctionPhase {
+	if phase, ok := l.phases[key]; ok {
+		return phase
+	}
+	return PhaseUnloaded
+}
+
+// Phases returns the state of every function that has left the unloaded state at least once.
+func (l *Lifecycle) Phases() map[FunctionKey]FunctionPhase {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	phases := make(map[FunctionKey]FunctionPhase, len(l.phases))
+	for key, phase := range l.phases {
+		phases[key] = phase
+	}
+	return phases
+}
+
+// Transition moves a function to the given state. Moving to the current state does
+// nothing; moving along a transition that is not allowed returns ErrInvalidTransition.
+func (l *Lifecycle) Transition(key FunctionKey, to FunctionPhase, reason string) error {
+	l.mu.Lock()
+	from := l.phase(key)
+	if from == to {
+		l.mu.Unlock()
+		return nil
+	}
+	if !slices.Contains(transitions[from], to) {
+		l.mu.Unlock()
+		return fmt.Errorf("%w: %s from %s to %s", ErrInvalidTransition, key, from, to)
+	}
+	l.phases[key] = to
+	l.mu.Unlock()
+
+	if l.onChange != nil {
+		l.onChange(key, from, to, reason)
+	}
+	return nil
+}
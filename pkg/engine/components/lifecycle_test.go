@@ -0,0 +1,52 @@
+package components
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycle(t *testing.T) {
+	type change struct {
+		from, to FunctionPhase
+		reason   string
+	}
+	var changes []change
+	lifecycle := NewLifecycle(func(_ FunctionKey, from, to FunctionPhase, reason string) {
+		changes = append(changes, change{from, to, reason})
+	})
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+
+	assert.Equal(t, PhaseUnloaded, lifecycle.Phase(key))
+	assert.Empty(t, lifecycle.Phases())
+
+	require.NoError(t, lifecycle.Transition(key, PhaseLoading, ""))
+	require.NoError(t, lifecycle.Transition(key, PhaseRunning, ""))
+	require.NoError(t, lifecycle.Transition(key, PhaseIdle, "idle"))
+
+	// Idle functions are loaded again, not resumed
+	err := lifecycle.Transition(key, PhaseRunning, "")
+	require.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Equal(t, PhaseIdle, lifecycle.Phase(key))
+
+	require.NoError(t, lifecycle.Transition(key, PhaseStopped, "requested"))
+
+	// Stopped functions cannot be unloaded or fail, and staying stopped changes nothing
+	require.ErrorIs(t, lifecycle.Transition(key, PhaseUnloaded, ""), ErrInvalidTransition)
+	require.ErrorIs(t, lifecycle.Transition(key, PhaseFailed, ""), ErrInvalidTransition)
+	require.NoError(t, lifecycle.Transition(key, PhaseStopped, ""))
+
+	require.NoError(t, lifecycle.Transition(key, PhaseLoading, ""))
+	require.NoError(t, lifecycle.Transition(key, PhaseFailed, "bad module"))
+
+	assert.Equal(t, []change{
+		{PhaseUnloaded, PhaseLoading, ""},
+		{PhaseLoading, PhaseRunning, ""},
+		{PhaseRunning, PhaseIdle, "idle"},
+		{PhaseIdle, PhaseStopped, "requested"},
+		{PhaseStopped, PhaseLoading, ""},
+		{PhaseLoading, PhaseFailed, "bad module"},
+	}, changes)
+	assert.Equal(t, map[FunctionKey]FunctionPhase{key: PhaseFailed}, lifecycle.Phases())
+}
@@ -2,12 +2,17 @@ package components
 
 import (
 	"context"
+	"fmt"
+	"hash/maphash"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/engine/egress"
 	"github.com/ignitionstack/ignition/pkg/engine/logging"
 	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/tetratelabs/wazero"
 )
 
 // The PluginManager interface is defined in interfaces.go
@@ -19,50 +24,121 @@ type PluginManagerSettings struct {
 
 	// How often to run the plugin cleanup routine
 	CleanupInterval time.Duration
+
+	// Time source for TTL cleanup and instance pools; nil uses the system clock
+	Clock Clock
+
+	// Store for per-function lifecycle logs; nil creates a private store
+	LogStore logging.LogStore
+
+	// Bounds for each function's autoscaled instance pool
+	Pool PoolSettings
+
+	// Called after a plugin is unloaded for being idle longer than the TTL (may be nil)
+	OnEvict func(key FunctionKey)
+}
+
+// pluginShardCount is the number of shards loaded plugins are spread over, so storing
+// or evicting one function only blocks the functions sharing its shard.
+const pluginShardCount = 32
+
+// pluginEntry is the instance pool of a loaded function with the time it was last
+// used, which calls update without taking a write lock.
+type pluginEntry struct {
+	pool     *PluginPool
+	lastUsed atomic.Int64 // Unix nanoseconds
+}
+
+// pluginShard holds the loaded plugins of the functions hashed to it.
+type pluginShard struct {
+	mu      sync.RWMutex
+	entries map[FunctionKey]*pluginEntry
 }
 
 // defaultPluginManager implements the PluginManager interface.
 type defaultPluginManager struct {
-	// Primary plugin storage
-	plugins        map[string]*extism.Plugin
-	pluginLastUsed map[string]time.Time
-	pluginsMux     sync.RWMutex
+	// Primary plugin storage, one instance pool per function
+	shards    [pluginShardCount]pluginShard
+	shardSeed maphash.Seed
 
 	// Plugin state and metadata
-	pluginDigests       map[string]string
+	pluginDigests       map[FunctionKey]string
 	pluginDigestsMux    sync.RWMutex
-	pluginConfigs       map[string]map[string]string
+	pluginConfigs       map[FunctionKey]map[string]string
 	pluginConfigsMux    sync.RWMutex
-	previouslyLoaded    map[string]bool
+	previouslyLoaded    map[FunctionKey]bool
 	previouslyLoadedMux sync.RWMutex
-	stoppedFunctions    map[string]bool
+	stoppedFunctions    map[FunctionKey]bool
 	stoppedFunctionsMux sync.RWMutex
 
+	// Instance counts set with ScaleFunction, kept across reloads
+	scales    map[FunctionKey]int
+	scalesMux sync.RWMutex
+
 	// Configuration
 	ttlDuration     time.Duration
 	cleanupInterval time.Duration
-	cleanupTicker   *time.Ticker
+	cleanupTicker   Ticker
+	poolSettings    PoolSettings
+	clock           Clock
+	onEvict         func(key FunctionKey)
 
 	// Dependencies
 	logger   logging.Logger
-	logStore *logging.FunctionLogStore
+	logStore logging.LogStore
 }
 
 // NewPluginManager creates a new plugin manager with the specified settings.
 func NewPluginManager(logger logging.Logger, options PluginManagerSettings) PluginManager {
-	logStoreCapacity := 1000 // Default value
+	logStore := options.LogStore
+	if logStore == nil {
+		logStore = logging.NewFunctionLogStore(1000)
+	}
 
-	return &defaultPluginManager{
-		plugins:          make(map[string]*extism.Plugin),
-		pluginLastUsed:   make(map[string]time.Time),
+	pm := &defaultPluginManager{
+		shardSeed:        maphash.MakeSeed(),
 		ttlDuration:      options.TTL,
 		cleanupInterval:  options.CleanupInterval,
+		poolSettings:     options.Pool,
+		clock:            clockOrSystem(options.Clock),
+		onEvict:          options.OnEvict,
 		logger:           logger,
-		pluginDigests:    make(map[string]string),
-		pluginConfigs:    make(map[string]map[string]string),
-		previouslyLoaded: make(map[string]bool),
-		stoppedFunctions: make(map[string]bool),
-		logStore:         logging.NewFunctionLogStore(logStoreCapacity),
+		pluginDigests:    make(map[FunctionKey]string),
+		pluginConfigs:    make(map[FunctionKey]map[string]string),
+		previouslyLoaded: make(map[FunctionKey]bool),
+		stoppedFunctions: make(map[FunctionKey]bool),
+		scales:           make(map[FunctionKey]int),
+		logStore:         logStore,
+	}
+	for i := range pm.shards {
+		pm.shards[i].entries = make(map[FunctionKey]*pluginEntry)
+	}
+	return pm
+}
+
+// shard returns the shard holding the plugin of a function.
+func (pm *defaultPluginManager) shard(key FunctionKey) *pluginShard {
+	return &pm.shards[maphash.Comparable(pm.shardSeed, key)%pluginShardCount]
+}
+
+// lookup returns the entry of a loaded function.
+func (pm *defaultPluginManager) lookup(key FunctionKey) (*pluginEntry, bool) {
+	shard := pm.shard(key)
+	shard.mu.RLock()
+	entry, ok := shard.entries[key]
+	shard.mu.RUnlock()
+	return entry, ok
+}
+
+// forEachEntry calls fn for every loaded function, holding the read lock of one shard at a time.
+func (pm *defaultPluginManager) forEachEntry(fn func(key FunctionKey, entry *pluginEntry)) {
+	for i := range pm.shards {
+		shard := &pm.shards[i]
+		shard.mu.RLock()
+		for key, entry := range shard.entries {
+			fn(key, entry)
+		}
+		shard.mu.RUnlock()
 	}
 }
 
@@ -74,7 +150,7 @@ func (pm *defaultPluginManager) StartCleanup(ctx context.Context) {
 	}
 
 	pm.logger.Printf("Starting plugin cleanup goroutine with interval %s", cleanupInterval)
-	pm.cleanupTicker = time.NewTicker(cleanupInterval)
+	pm.cleanupTicker = pm.clock.NewTicker(cleanupInterval)
 
 	go func() {
 		// Run cleanup immediately on start
@@ -82,7 +158,7 @@ func (pm *defaultPluginManager) StartCleanup(ctx context.Context) {
 
 		for {
 			select {
-			case <-pm.cleanupTicker.C:
+			case <-pm.cleanupTicker.C():
 				pm.logger.Printf("Running plugin cleanup (TTL: %s)", pm.ttlDuration)
 				pm.cleanupUnusedPlugins()
 
@@ -98,59 +174,67 @@ func (pm *defaultPluginManager) StartCleanup(ctx context.Context) {
 }
 
 func (pm *defaultPluginManager) cleanupUnusedPlugins() {
-	pm.pluginsMux.Lock()
-	defer pm.pluginsMux.Unlock()
-
-	now := time.Now()
-	for key, lastUsed := range pm.pluginLastUsed {
-		if now.Sub(lastUsed) > pm.ttlDuration {
-			if plugin, exists := pm.plugins[key]; exists {
-				plugin.Close(context.TODO())
-				delete(pm.plugins, key)
-				delete(pm.pluginLastUsed, key)
-				pm.logger.Printf("Plugin %s unloaded due to inactivity, preserving configuration for potential reload", key)
-				if pm.logStore != nil {
-					pm.logStore.AddLog(key, logging.LevelInfo, "Plugin unloaded due to inactivity, preserving configuration for potential reload")
-				}
+	var evicted []FunctionKey
+	defer func() {
+		if pm.onEvict == nil {
+			return
+		}
+		for _, key := range evicted {
+			pm.onEvict(key)
+		}
+	}()
+
+	now := pm.clock.Now()
+	for i := range pm.shards {
+		shard := &pm.shards[i]
+		shard.mu.Lock()
+		for key, entry := range shard.entries {
+			if now.Sub(time.Unix(0, entry.lastUsed.Load())) <= pm.ttlDuration {
+				continue
+			}
+			entry.pool.Close()
+			delete(shard.entries, key)
+			evicted = append(evicted, key)
+			pm.logger.Printf("Plugin %s unloaded due to inactivity, preserving configuration for potential reload", key)
+			if pm.logStore != nil {
+				pm.logStore.AddLog(key, logging.LevelInfo, "Plugin unloaded due to inactivity, preserving configuration for potential reload")
 			}
 		}
+		shard.mu.Unlock()
 	}
 }
 
-func (pm *defaultPluginManager) GetPlugin(key string) (*extism.Plugin, bool) {
-	pm.pluginsMux.RLock()
-	plugin, ok := pm.plugins[key]
-	pm.pluginsMux.RUnlock()
-
-	// If the plugin exists, update the last used time with a write lock
-	if ok {
-		pm.pluginsMux.Lock()
-		// Double-check the plugin still exists after getting the write lock
-		if _, stillExists := pm.plugins[key]; stillExists {
-			pm.pluginLastUsed[key] = time.Now()
-		} else {
-			ok = false
-			plugin = nil
-		}
-		pm.pluginsMux.Unlock()
+// GetPool returns the instance pool of a loaded function and marks it used. It only
+// takes the read lock of the function's shard, so calls never wait on each other.
+func (pm *defaultPluginManager) GetPool(key FunctionKey) (*PluginPool, bool) {
+	entry, ok := pm.lookup(key)
+	if !ok {
+		return nil, false
 	}
 
-	return plugin, ok
+	entry.lastUsed.Store(pm.clock.Now().UnixNano())
+	return entry.pool, true
 }
 
-func (pm *defaultPluginManager) StorePlugin(key string, plugin *extism.Plugin, digest string, config map[string]string) {
-	// Handle plugin map updates with its own lock
+func (pm *defaultPluginManager) StorePlugin(key FunctionKey, plugin *extism.Plugin, factory PluginFactory,
+	digest string, config map[string]string) {
+	pool := NewPluginPool(key, plugin, factory, pm.poolSettingsFor(key), pm.logger, pm.logStore)
+
+	entry := &pluginEntry{pool: pool}
+	entry.lastUsed.Store(pm.clock.Now().UnixNano())
+
+	// Handle plugin map updates with the lock of the function's shard
 	func() {
-		pm.pluginsMux.Lock()
-		defer pm.pluginsMux.Unlock()
+		shard := pm.shard(key)
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
 
-		// If there's an existing plugin, close it first
-		if existing, exists := pm.plugins[key]; exists {
-			existing.Close(context.TODO())
+		// If there's an existing pool, close it first
+		if existing, exists := shard.entries[key]; exists {
+			existing.pool.Close()
 		}
 
-		pm.plugins[key] = plugin
-		pm.pluginLastUsed[key] = time.Now()
+		shard.entries[key] = entry
 	}()
 
 	// Handle digest update with its own lock
@@ -184,15 +268,58 @@ func (pm *defaultPluginManager) StorePlugin(key string, plugin *extism.Plugin, d
 	}
 }
 
-func (pm *defaultPluginManager) RemovePlugin(key string) bool {
-	pm.pluginsMux.Lock()
-	defer pm.pluginsMux.Unlock()
+// ScaleFunction pins a function's pool at the given number of instances, applying
+// to the loaded pool and to future loads. Zero restores the configured autoscaling bounds.
+func (pm *defaultPluginManager) ScaleFunction(key FunctionKey, instances int) error {
+	if instances < 0 || instances > MaxScaleInstances {
+		return fmt.Errorf("instances must be between 0 and %d", MaxScaleInstances)
+	}
+
+	pm.scalesMux.Lock()
+	if instances == 0 {
+		delete(pm.scales, key)
+	} else {
+		pm.scales[key] = instances
+	}
+	pm.scalesMux.Unlock()
+
+	entry, loaded := pm.lookup(key)
+	if !loaded {
+		return nil
+	}
+
+	settings := pm.poolSettingsFor(key)
+	return entry.pool.Resize(settings.MinInstances, settings.MaxInstances)
+}
+
+// poolSettingsFor returns the pool settings of a function, honoring its scale.
+func (pm *defaultPluginManager) poolSettingsFor(key FunctionKey) PoolSettings {
+	settings := pm.poolSettings
+	if settings.Clock == nil {
+		settings.Clock = pm.clock
+	}
 
-	plugin, exists := pm.plugins[key]
+	pm.scalesMux.RLock()
+	instances, scaled := pm.scales[key]
+	pm.scalesMux.RUnlock()
+
+	if scaled {
+		settings.MinInstances, settings.MaxInstances = instances, instances
+	} else {
+		settings = normalizePoolSettings(settings, true)
+	}
+	return settings
+}
+
+func (pm *defaultPluginManager) RemovePlugin(key FunctionKey) bool {
+	shard := pm.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	entry, exists := shard.entries[key]
 	if exists {
-		plugin.Close(context.TODO())
-		delete(pm.plugins, key)
-		delete(pm.pluginLastUsed, key)
+		entry.pool.Close()
+		delete(shard.entries, key)
 		if pm.logStore != nil {
 			pm.logStore.AddLog(key, logging.LevelInfo, "Plugin unloaded but configuration preserved for potential reload")
 		}
@@ -203,7 +330,7 @@ func (pm *defaultPluginManager) RemovePlugin(key string) bool {
 }
 
 // StopFunction permanently stops a function and prevents automatic reloading.
-func (pm *defaultPluginManager) StopFunction(key string) bool {
+func (pm *defaultPluginManager) StopFunction(key FunctionKey) bool {
 	// First unload the plugin if it's loaded
 	removed := pm.RemovePlugin(key)
 
@@ -220,7 +347,7 @@ func (pm *defaultPluginManager) StopFunction(key string) bool {
 }
 
 // IsFunctionStopped checks if a function has been explicitly stopped.
-func (pm *defaultPluginManager) IsFunctionStopped(key string) bool {
+func (pm *defaultPluginManager) IsFunctionStopped(key FunctionKey) bool {
 	pm.stoppedFunctionsMux.RLock()
 	defer pm.stoppedFunctionsMux.RUnlock()
 
@@ -229,7 +356,7 @@ func (pm *defaultPluginManager) IsFunctionStopped(key string) bool {
 }
 
 // ClearStoppedStatus removes the stopped status from a function, allowing it to be loaded again.
-func (pm *defaultPluginManager) ClearStoppedStatus(key string) {
+func (pm *defaultPluginManager) ClearStoppedStatus(key FunctionKey) {
 	pm.stoppedFunctionsMux.Lock()
 	delete(pm.stoppedFunctions, key)
 	pm.stoppedFunctionsMux.Unlock()
@@ -239,15 +366,12 @@ func (pm *defaultPluginManager) ClearStoppedStatus(key string) {
 	}
 }
 
-func (pm *defaultPluginManager) IsPluginLoaded(key string) bool {
-	pm.pluginsMux.RLock()
-	_, exists := pm.plugins[key]
-	pm.pluginsMux.RUnlock()
-
+func (pm *defaultPluginManager) IsPluginLoaded(key FunctionKey) bool {
+	_, exists := pm.lookup(key)
 	return exists
 }
 
-func (pm *defaultPluginManager) WasPreviouslyLoaded(key string) (bool, map[string]string) {
+func (pm *defaultPluginManager) WasPreviouslyLoaded(key FunctionKey) (bool, map[string]string) {
 	pm.previouslyLoadedMux.RLock()
 	wasLoaded, exists := pm.previouslyLoaded[key]
 	pm.previouslyLoadedMux.RUnlock()
@@ -269,7 +393,7 @@ func (pm *defaultPluginManager) WasPreviouslyLoaded(key string) (bool, map[strin
 }
 
 // HasConfigChanged compares stored config with a new config to check for changes.
-func (pm *defaultPluginManager) HasConfigChanged(key string, newConfig map[string]string) bool {
+func (pm *defaultPluginManager) HasConfigChanged(key FunctionKey, newConfig map[string]string) bool {
 	pm.pluginConfigsMux.RLock()
 	currentConfig, hasConfig := pm.pluginConfigs[key]
 	pm.pluginConfigsMux.RUnlock()
@@ -297,7 +421,7 @@ func (pm *defaultPluginManager) HasConfigChanged(key string, newConfig map[strin
 	return false
 }
 
-func (pm *defaultPluginManager) HasDigestChanged(key string, newDigest string) bool {
+func (pm *defaultPluginManager) HasDigestChanged(key FunctionKey, newDigest string) bool {
 	pm.pluginDigestsMux.RLock()
 	currentDigest, hasDigest := pm.pluginDigests[key]
 	pm.pluginDigestsMux.RUnlock()
@@ -305,27 +429,60 @@ func (pm *defaultPluginManager) HasDigestChanged(key string, newDigest string) b
 	return !hasDigest || currentDigest != newDigest
 }
 
-func CreatePlugin(wasmBytes []byte, versionInfo *registry.VersionInfo, config map[string]string) (*extism.Plugin, error) {
+// CreatePlugin instantiates an Extism plugin from WASM bytes, exposing any given host functions.
+func CreatePlugin(wasmBytes []byte, versionInfo *registry.VersionInfo, config map[string]string,
+	hostFunctions ...extism.HostFunction) (*extism.Plugin, error) {
+	manifest, pluginConfig := pluginManifest(wasmBytes, versionInfo, config)
+
+	if hostFunctions == nil {
+		hostFunctions = []extism.HostFunction{}
+	}
+
+	return extism.NewPlugin(context.Background(), manifest, pluginConfig, hostFunctions)
+}
+
+// CompilePlugin compiles WASM bytes once so several instances can be created from
+// them with InstantiatePlugin. Closing the compiled plugin closes those instances.
+func CompilePlugin(wasmBytes []byte, versionInfo *registry.VersionInfo, config map[string]string,
+	hostFunctions ...extism.HostFunction) (*extism.CompiledPlugin, error) {
+	manifest, pluginConfig := pluginManifest(wasmBytes, versionInfo, config)
+
+	if hostFunctions == nil {
+		hostFunctions = []extism.HostFunction{}
+	}
+
+	return extism.NewCompiledPlugin(context.Background(), manifest, pluginConfig, hostFunctions)
+}
+
+// InstantiatePlugin creates an instance of a compiled plugin.
+func InstantiatePlugin(compiled *extism.CompiledPlugin) (*extism.Plugin, error) {
+	return compiled.Instance(context.Background(), extism.PluginInstanceConfig{})
+}
+
+func pluginManifest(wasmBytes []byte, versionInfo *registry.VersionInfo, config map[string]string) (extism.Manifest, extism.PluginConfig) {
 	manifest := extism.Manifest{
-		AllowedHosts: versionInfo.Settings.AllowedUrls,
+		AllowedHosts: egress.HostPatterns(versionInfo.Settings.AllowedUrls),
 		Wasm: []extism.Wasm{
 			extism.WasmData{Data: wasmBytes},
 		},
 		Config: config,
 	}
 
+	// Closing an instance aborts the call running on it, which is how abandoned calls are
+	// interrupted. Traps carry source lines for modules keeping their DWARF sections.
 	pluginConfig := extism.PluginConfig{
-		EnableWasi: versionInfo.Settings.Wasi,
+		EnableWasi:    versionInfo.Settings.Wasi,
+		RuntimeConfig: wazero.NewRuntimeConfig().WithCloseOnContextDone(true).WithDebugInfoEnabled(true),
 	}
 
-	return extism.NewPlugin(context.Background(), manifest, pluginConfig, []extism.HostFunction{})
+	return manifest, pluginConfig
 }
 
-func (pm *defaultPluginManager) GetLogStore() *logging.FunctionLogStore {
+func (pm *defaultPluginManager) GetLogStore() logging.LogStore {
 	return pm.logStore
 }
 
-func (pm *defaultPluginManager) GetPluginDigest(key string) (string, bool) {
+func (pm *defaultPluginManager) GetPluginDigest(key FunctionKey) (string, bool) {
 	pm.pluginDigestsMux.RLock()
 	digest, exists := pm.pluginDigests[key]
 	pm.pluginDigestsMux.RUnlock()
@@ -333,7 +490,7 @@ func (pm *defaultPluginManager) GetPluginDigest(key string) (string, bool) {
 	return digest, exists
 }
 
-func (pm *defaultPluginManager) GetPluginConfig(key string) (map[string]string, bool) {
+func (pm *defaultPluginManager) GetPluginConfig(key FunctionKey) (map[string]string, bool) {
 	pm.pluginConfigsMux.RLock()
 	config, exists := pm.pluginConfigs[key]
 
@@ -351,12 +508,12 @@ func (pm *defaultPluginManager) GetPluginConfig(key string) (map[string]string,
 }
 
 // GetPreviouslyLoadedFunctions returns a map of all functions that have been previously loaded.
-func (pm *defaultPluginManager) GetPreviouslyLoadedFunctions() map[string]bool {
+func (pm *defaultPluginManager) GetPreviouslyLoadedFunctions() map[FunctionKey]bool {
 	pm.previouslyLoadedMux.RLock()
 	defer pm.previouslyLoadedMux.RUnlock()
 
 	// Make a copy to avoid concurrency issues
-	result := make(map[string]bool, len(pm.previouslyLoaded))
+	result := make(map[FunctionKey]bool, len(pm.previouslyLoaded))
 	for k, v := range pm.previouslyLoaded {
 		result[k] = v
 	}
@@ -364,13 +521,26 @@ func (pm *defaultPluginManager) GetPreviouslyLoadedFunctions() map[string]bool {
 	return result
 }
 
+// GetFunctionScales returns the instance count of every scaled function.
+func (pm *defaultPluginManager) GetFunctionScales() map[FunctionKey]int {
+	pm.scalesMux.RLock()
+	defer pm.scalesMux.RUnlock()
+
+	result := make(map[FunctionKey]int, len(pm.scales))
+	for k, v := range pm.scales {
+		result[k] = v
+	}
+
+	return result
+}
+
 // GetStoppedFunctions returns a map of all functions that have been stopped.
-func (pm *defaultPluginManager) GetStoppedFunctions() map[string]bool {
+func (pm *defaultPluginManager) GetStoppedFunctions() map[FunctionKey]bool {
 	pm.stoppedFunctionsMux.RLock()
 	defer pm.stoppedFunctionsMux.RUnlock()
 
 	// Make a copy to avoid concurrency issues
-	result := make(map[string]bool, len(pm.stoppedFunctions))
+	result := make(map[FunctionKey]bool, len(pm.stoppedFunctions))
 	for k, v := range pm.stoppedFunctions {
 		result[k] = v
 	}
@@ -383,32 +553,46 @@ func (pm *defaultPluginManager) Shutdown() {
 		pm.cleanupTicker.Stop()
 	}
 
-	pm.pluginsMux.Lock()
-	defer pm.pluginsMux.Unlock()
-
-	for key, plugin := range pm.plugins {
-		plugin.Close(context.TODO())
-		delete(pm.plugins, key)
+	for i := range pm.shards {
+		shard := &pm.shards[i]
+		shard.mu.Lock()
+		for key, entry := range shard.entries {
+			entry.pool.Close()
+			delete(shard.entries, key)
+		}
+		shard.mu.Unlock()
 	}
 }
 
-// ListLoadedFunctions returns a list of currently loaded function keys.
-func (pm *defaultPluginManager) ListLoadedFunctions() []string {
-	pm.pluginsMux.RLock()
-	defer pm.pluginsMux.RUnlock()
+// GetPoolStats returns a snapshot of every loaded function's instance pool.
+func (pm *defaultPluginManager) GetPoolStats() map[FunctionKey]PoolStats {
+	stats := make(map[FunctionKey]PoolStats)
+	pm.forEachEntry(func(key FunctionKey, entry *pluginEntry) {
+		stats[key] = entry.pool.Stats()
+	})
 
-	keys := make([]string, 0, len(pm.plugins))
-	for key := range pm.plugins {
+	return stats
+}
+
+// ListLoadedFunctions returns a list of currently loaded function keys.
+func (pm *defaultPluginManager) ListLoadedFunctions() []FunctionKey {
+	keys := make([]FunctionKey, 0)
+	pm.forEachEntry(func(key FunctionKey, _ *pluginEntry) {
 		keys = append(keys, key)
-	}
+	})
 
 	return keys
 }
 
 // GetLoadedFunctionCount returns the number of currently loaded functions.
 func (pm *defaultPluginManager) GetLoadedFunctionCount() int {
-	pm.pluginsMux.RLock()
-	defer pm.pluginsMux.RUnlock()
+	count := 0
+	for i := range pm.shards {
+		shard := &pm.shards[i]
+		shard.mu.RLock()
+		count += len(shard.entries)
+		shard.mu.RUnlock()
+	}
 
-	return len(pm.plugins)
+	return count
 }
@@ -0,0 +1,86 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManagerEvictsUnusedPlugins(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	var evicted []FunctionKey
+	pm := NewPluginManager(logging.NewStdLogger(io.Discard), PluginManagerSettings{
+		TTL:     time.Minute,
+		Clock:   clock,
+		Pool:    PoolSettings{MinInstances: 1, MaxInstances: 1, ScaleInterval: time.Hour},
+		OnEvict: func(key FunctionKey) { evicted = append(evicted, key) },
+	}).(*defaultPluginManager)
+	defer pm.Shutdown()
+
+	factory := func(context.Context) (*extism.Plugin, error) { return newTestPlugin(t), nil }
+	used := FunctionKey{Namespace: "ns", Name: "used"}
+	unused := FunctionKey{Namespace: "ns", Name: "unused"}
+	pm.StorePlugin(used, newTestPlugin(t), factory, "sha256:a", nil)
+	pm.StorePlugin(unused, newTestPlugin(t), factory, "sha256:b", nil)
+	assert.Equal(t, 2, pm.GetLoadedFunctionCount())
+
+	// Looking a pool up marks the function used
+	clock.Advance(45 * time.Second)
+	_, ok := pm.GetPool(used)
+	require.True(t, ok)
+	clock.Advance(30 * time.Second)
+
+	pm.cleanupUnusedPlugins()
+	assert.Equal(t, []FunctionKey{unused}, evicted)
+	assert.Equal(t, []FunctionKey{used}, pm.ListLoadedFunctions())
+	assert.False(t, pm.IsPluginLoaded(unused))
+	_, ok = pm.GetPool(unused)
+	assert.False(t, ok)
+}
+
+func TestPluginManagerConcurrentAccess(t *testing.T) {
+	pm := NewPluginManager(logging.NewStdLogger(io.Discard), PluginManagerSettings{
+		TTL:  time.Hour,
+		Pool: PoolSettings{MinInstances: 1, MaxInstances: 1, ScaleInterval: time.Hour},
+	})
+	defer pm.Shutdown()
+
+	factory := func(context.Context) (*extism.Plugin, error) { return newTestPlugin(t), nil }
+	keys := make([]FunctionKey, 8)
+	for i := range keys {
+		keys[i] = FunctionKey{Namespace: "ns", Name: fmt.Sprintf("fn%d", i)}
+		pm.StorePlugin(keys[i], newTestPlugin(t), factory, "", nil)
+	}
+
+	// Lookups of every function race with one function being reloaded and removed
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				pm.GetPool(key)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 10 {
+			pm.StorePlugin(keys[0], newTestPlugin(t), factory, "", nil)
+			pm.RemovePlugin(keys[0])
+		}
+	}()
+	wg.Wait()
+
+	assert.Equal(t, len(keys)-1, pm.GetLoadedFunctionCount())
+	assert.Len(t, pm.GetPoolStats(), len(keys)-1)
+}
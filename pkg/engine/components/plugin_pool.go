@@ -0,0 +1,780 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+// ErrPoolClosed is returned when acquiring an instance from a pool that has been unloaded.
+var ErrPoolClosed = errors.New("plugin pool is closed")
+
+// ErrPoolPinned is returned when resizing a pool that cannot create instances.
+var ErrPoolPinned = errors.New("plugin pool is pinned to a single instance")
+
+// MaxScaleInstances is the largest instance count a pool can be resized to.
+const MaxScaleInstances = 256
+
+// PoolSettings bounds the number of warm instances kept for each function.
+type PoolSettings struct {
+	// Minimum number of instances kept warm
+	MinInstances int
+
+	// Maximum number of instances serving calls concurrently
+	MaxInstances int
+
+	// How often scale-down decisions are made
+	ScaleInterval time.Duration
+
+	// Consecutive instance crashes tolerated before the function's circuit breaker opens
+	MaxRestarts int
+
+	// Delay before replacing a crashed instance, doubled for each consecutive crash
+	RestartBackoff time.Duration
+
+	// Upper bound on the restart delay
+	MaxRestartBackoff time.Duration
+
+	// Longest a call waits for an instance before it is turned away with a
+	// QueueTimeoutError; zero waits as long as the call's context allows
+	MaxQueueWait time.Duration
+
+	// Called with the number of waiting calls whenever it changes; nil disables it
+	OnQueueChange func(key FunctionKey, depth int)
+
+	// Time source for autoscaling and restart backoff; nil uses the system clock
+	Clock Clock
+}
+
+// DefaultPoolSettings returns the pool settings used when none are configured.
+func DefaultPoolSettings() PoolSettings {
+	return PoolSettings{
+		MinInstances:      1,
+		MaxInstances:      4,
+		ScaleInterval:     5 * time.Second,
+		MaxRestarts:       5,
+		RestartBackoff:    100 * time.Millisecond,
+		MaxRestartBackoff: 30 * time.Second,
+	}
+}
+
+// IsFatalInstanceError reports whether a call error means the instance can no
+// longer be trusted: the guest trapped, exited or its module was closed. Errors
+// the guest reports through extism's error channel leave the instance usable.
+func IsFatalInstanceError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "wasm error:") ||
+		strings.Contains(msg, "module closed") ||
+		strings.Contains(msg, "module is closed") ||
+		strings.Contains(msg, "failed to get output")
+}
+
+// PluginFactory creates an additional plugin instance for a pool.
+type PluginFactory func(ctx context.Context) (*extism.Plugin, error)
+
+// ScaleEvent records a pool scaling decision.
+type ScaleEvent struct {
+	Time   time.Time `json:"time"`
+	From   int       `json:"from"`
+	To     int       `json:"to"`
+	Reason string    `json:"reason"`
+}
+
+// PoolStats is a snapshot of a function's plugin pool.
+type PoolStats struct {
+	Instances    int         `json:"instances"`
+	Idle         int         `json:"idle"`
+	InFlight     int         `json:"in_flight"`
+	QueueDepth   int         `json:"queue_depth"`
+	CallRate     float64     `json:"call_rate"`
+	MinInstances int         `json:"min_instances"`
+	MaxInstances int         `json:"max_instances"`
+	LastScale    *ScaleEvent `json:"last_scale,omitempty"`
+	Restarts     int64       `json:"restarts"`
+	CrashLooping bool        `json:"crash_looping,omitempty"`
+
+	// Calls turned away after waiting longer than the max queue wait
+	QueueTimeouts int64 `json:"queue_timeouts"`
+
+	// Calls by priority class, for classes that have seen calls
+	Priorities map[string]PriorityStats `json:"priorities,omitempty"`
+}
+
+// PluginPool holds the warm instances of one function. Extism plugins are not safe
+// for concurrent calls, so each call borrows an instance for its duration. The pool
+// grows as soon as calls queue, and shrinks one instance per interval while peak
+// demand stays below its size. Queued calls get freed instances by priority, then
+// in arrival order.
+type PluginPool struct {
+	key      FunctionKey
+	factory  PluginFactory
+	settings PoolSettings
+	logger   logging.Logger
+	logStore logging.LogStore
+
+	// Idle instances; sized for any instance count the pool may be resized to so releases never block
+	idle chan *extism.Plugin
+	stop chan struct{}
+
+	mu        sync.Mutex
+	instances int
+	pending   int // instances being created
+	retire    int // busy instances to close when released
+	inFlight  int
+	waiting   int
+	peak      int // highest in-flight plus waiting since the last tick
+	calls     int64
+	callRate  float64
+	lastTick  time.Time
+	lastScale *ScaleEvent
+	restarts  int64 // crashed instances replaced since the pool was created
+	timeouts  int64 // queued calls turned away after the max queue wait
+	crashes   int   // consecutive crashes without a healthy call in between
+	closed    bool
+
+	// Calls waiting for an instance, and counters, by priority
+	classes [numPriorities]priorityClass
+
+	// Run once the pool is closed and owns no instances
+	onDrained func()
+	drained   bool
+}
+
+// poolWaiter is a queued call. The instance handed to it arrives on ready.
+type poolWaiter struct {
+	ready chan *extism.Plugin
+	since time.Time
+}
+
+type priorityClass struct {
+	queue     []*poolWaiter
+	calls     int64
+	queued    int64
+	served    int64 // queued calls that got an instance
+	waitTotal time.Duration
+	waitMax   time.Duration
+}
+
+// NewPluginPool creates a pool seeded with an already initialized instance. A nil
+// factory pins the pool to that single instance.
+func NewPluginPool(key FunctionKey, first *extism.Plugin, factory PluginFactory, settings PoolSettings,
+	logger logging.Logger, logStore logging.LogStore) *PluginPool {
+	settings = normalizePoolSettings(settings, factory != nil)
+
+	p := &PluginPool{
+		key:       key,
+		factory:   factory,
+		settings:  settings,
+		logger:    logger,
+		logStore:  logStore,
+		idle:      make(chan *extism.Plugin, poolCapacity(settings, factory != nil)),
+		stop:      make(chan struct{}),
+		instances: 1,
+		lastTick:  settings.Clock.Now(),
+	}
+	p.idle <- first
+
+	if settings.MinInstances > 1 {
+		p.pending = settings.MinInstances - 1
+		go p.grow(settings.MinInstances-1, "warming to minimum instances")
+	}
+	go p.autoscale()
+
+	return p
+}
+
+// poolCapacity returns the most instances a pool with these settings can ever hold.
+func poolCapacity(settings PoolSettings, canGrow bool) int {
+	if !canGrow {
+		return settings.MaxInstances
+	}
+	return max(settings.MaxInstances, MaxScaleInstances)
+}
+
+func normalizePoolSettings(settings PoolSettings, canGrow bool) PoolSettings {
+	if !canGrow {
+		settings.MinInstances, settings.MaxInstances = 1, 1
+	}
+	if settings.MinInstances < 1 {
+		settings.MinInstances = 1
+	}
+	if settings.MaxInstances < settings.MinInstances {
+		settings.MaxInstances = settings.MinInstances
+	}
+	defaults := DefaultPoolSettings()
+	if settings.ScaleInterval <= 0 {
+		settings.ScaleInterval = defaults.ScaleInterval
+	}
+	if settings.MaxRestarts <= 0 {
+		settings.MaxRestarts = defaults.MaxRestarts
+	}
+	if settings.RestartBackoff <= 0 {
+		settings.RestartBackoff = defaults.RestartBackoff
+	}
+	if settings.MaxRestartBackoff < settings.RestartBackoff {
+		settings.MaxRestartBackoff = max(defaults.MaxRestartBackoff, settings.RestartBackoff)
+	}
+	settings.Clock = clockOrSystem(settings.Clock)
+	return settings
+}
+
+// Acquire borrows an instance, waiting for one to become free if necessary. Waiting
+// calls are served by the priority set on ctx with WithPriority, normal by default,
+// and give up with a QueueTimeoutError after the max queue wait. Every successful
+// Acquire must be paired with Release.
+func (p *PluginPool) Acquire(ctx context.Context) (*extism.Plugin, error) {
+	priority, _ := PriorityFromContext(ctx)
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	p.calls++
+	class := &p.classes[priority]
+	class.calls++
+
+	// Idle instances only exist while no call is queued
+	select {
+	case plugin := <-p.idle:
+		p.inFlight++
+		p.notePeak()
+		p.mu.Unlock()
+		return plugin, nil
+	default:
+	}
+
+	waiter := &poolWaiter{ready: make(chan *extism.Plugin, 1), since: p.settings.Clock.Now()}
+	class.queue = append(class.queue, waiter)
+	class.queued++
+	p.waiting++
+	p.notePeak()
+	p.queueChangedLocked()
+
+	// Scale up straight away when calls start queueing
+	if p.waiting > p.pending {
+		p.scaleUpLocked(1, fmt.Sprintf("queue depth %d", p.waiting))
+	}
+	maxWait, ok := MaxQueueWaitFromContext(ctx)
+	if !ok {
+		maxWait = p.settings.MaxQueueWait
+	}
+	p.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if maxWait > 0 {
+		timeout = p.settings.Clock.After(maxWait)
+	}
+
+	select {
+	case plugin := <-waiter.ready:
+		return plugin, nil
+	case <-p.stop:
+		p.abandon(priority, waiter)
+		return nil, ErrPoolClosed
+	case <-ctx.Done():
+		p.abandon(priority, waiter)
+		return nil, ctx.Err()
+	case <-timeout:
+		if !p.dequeue(priority, waiter) {
+			// An instance was handed over as the wait ran out
+			return <-waiter.ready, nil
+		}
+		p.mu.Lock()
+		p.timeouts++
+		err := &QueueTimeoutError{Waited: p.settings.Clock.Since(waiter.since), Depth: p.waiting}
+		p.mu.Unlock()
+		return nil, err
+	}
+}
+
+// abandon takes a waiter that gave up off its queue. An instance handed to it in
+// the meantime is passed on as if the call had finished.
+func (p *PluginPool) abandon(priority Priority, waiter *poolWaiter) {
+	if !p.dequeue(priority, waiter) {
+		p.giveBack(<-waiter.ready, false)
+	}
+}
+
+// dequeue takes a waiter off its queue. It reports false when an instance was
+// already handed to the waiter, which then arrives on ready.
+func (p *PluginPool) dequeue(priority Priority, waiter *poolWaiter) bool {
+	p.mu.Lock()
+	class := &p.classes[priority]
+	for i, w := range class.queue {
+		if w == waiter {
+			class.queue = append(class.queue[:i], class.queue[i+1:]...)
+			p.waiting--
+			p.queueChangedLocked()
+			p.mu.Unlock()
+			return true
+		}
+	}
+	p.mu.Unlock()
+	return false
+}
+
+// handOffLocked gives a free instance to the longest waiting call of the highest
+// priority, or keeps it idle when no call is queued. Caller holds p.mu.
+func (p *PluginPool) handOffLocked(plugin *extism.Plugin) {
+	for i := range p.classes {
+		class := &p.classes[i]
+		if len(class.queue) == 0 {
+			continue
+		}
+
+		waiter := class.queue[0]
+		class.queue[0] = nil
+		class.queue = class.queue[1:]
+		p.waiting--
+		p.inFlight++
+		p.notePeak()
+		p.queueChangedLocked()
+
+		wait := p.settings.Clock.Since(waiter.since)
+		class.served++
+		class.waitTotal += wait
+		class.waitMax = max(class.waitMax, wait)
+
+		waiter.ready <- plugin
+		return
+	}
+
+	// Never blocks: the channel can hold every instance the pool may own
+	p.idle <- plugin
+}
+
+// Release returns a borrowed instance to the pool after a call it survived.
+func (p *PluginPool) Release(plugin *extism.Plugin) {
+	p.giveBack(plugin, true)
+}
+
+// giveBack returns a borrowed instance; a healthy call ends the crash streak.
+func (p *PluginPool) giveBack(plugin *extism.Plugin, healthy bool) {
+	p.mu.Lock()
+	p.inFlight--
+	if healthy {
+		p.crashes = 0
+	}
+	if p.closed || p.retire > 0 {
+		if p.retire > 0 {
+			p.retire--
+		}
+		p.instances--
+		p.mu.Unlock()
+		plugin.Close(context.TODO())
+		p.runIfDrained()
+		return
+	}
+
+	p.handOffLocked(plugin)
+	p.mu.Unlock()
+}
+
+// Discard closes a borrowed instance that crashed and replaces it after an
+// exponential backoff. It reports whether consecutive crashes have exceeded
+// MaxRestarts, in which case the caller should stop sending calls for a while.
+func (p *PluginPool) Discard(plugin *extism.Plugin, cause error) bool {
+	p.mu.Lock()
+	p.inFlight--
+	p.instances--
+	p.crashes++
+	crashes := p.crashes
+	exceeded := crashes > p.settings.MaxRestarts
+
+	// A crashed instance that was due for retirement needs no replacement
+	replace := !p.closed && p.factory != nil
+	if p.retire > 0 {
+		p.retire--
+		replace = false
+	}
+	if replace {
+		p.pending++
+		p.restarts++
+	}
+	abandoned := p.factory == nil && p.instances == 0
+	delay := p.restartDelayLocked(crashes)
+	p.mu.Unlock()
+
+	plugin.Close(context.TODO())
+
+	// The stack of a trap is logged with the failed call
+	reason := fmt.Sprint(cause)
+	if trap, ok := ParseTrap(cause); ok {
+		reason = trap.Message
+	}
+	msg := fmt.Sprintf("Plugin instance crashed (%d in a row): %s", crashes, reason)
+	p.logger.Errorf("%s: %s", p.key, msg)
+	p.logStore.AddLog(p.key, logging.LevelError, msg)
+
+	switch {
+	case replace:
+		go p.restart(delay, crashes)
+	case abandoned:
+		// Nothing can replace the only instance, so fail calls instead of queueing them forever
+		p.Close()
+	}
+	p.runIfDrained()
+
+	return exceeded
+}
+
+// Replace closes a borrowed instance that can't be reused although it did not crash,
+// such as one whose call was interrupted, and creates its replacement right away.
+// Unlike Discard, it does not count toward the crash streak.
+func (p *PluginPool) Replace(plugin *extism.Plugin, reason string) {
+	p.mu.Lock()
+	p.inFlight--
+	p.instances--
+
+	replace := !p.closed && p.factory != nil
+	if p.retire > 0 {
+		p.retire--
+		replace = false
+	}
+	if replace {
+		p.pending++
+	}
+	abandoned := p.factory == nil && p.instances == 0
+	p.mu.Unlock()
+
+	plugin.Close(context.TODO())
+
+	msg := fmt.Sprintf("Plugin instance replaced: %s", reason)
+	p.logger.Printf("%s: %s", p.key, msg)
+	p.logStore.AddLog(p.key, logging.LevelInfo, msg)
+
+	switch {
+	case replace:
+		go p.restart(0, 0)
+	case abandoned:
+		p.Close()
+	}
+	p.runIfDrained()
+}
+
+// restartDelayLocked returns the backoff before the replacement for the given crash. Caller holds p.mu.
+func (p *PluginPool) restartDelayLocked(crashes int) time.Duration {
+	delay := p.settings.RestartBackoff
+	for i := 1; i < crashes && delay < p.settings.MaxRestartBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.settings.MaxRestartBackoff)
+}
+
+// restart creates the replacement for a crashed instance, whose slot was reserved in
+// p.pending, retrying with a growing backoff until it succeeds or the pool closes.
+func (p *PluginPool) restart(delay time.Duration, crashes int) {
+	for {
+		select {
+		case <-p.stop:
+			p.mu.Lock()
+			p.pending--
+			p.mu.Unlock()
+			p.runIfDrained()
+			return
+		case <-p.settings.Clock.After(delay):
+		}
+
+		plugin, err := p.factory(context.Background())
+		if err != nil {
+			p.logger.Errorf("Failed to restart plugin instance for %s: %v", p.key, err)
+			p.logStore.AddLog(p.key, logging.LevelError, fmt.Sprintf("Failed to restart instance: %v", err))
+
+			p.mu.Lock()
+			crashes++
+			delay = p.restartDelayLocked(crashes)
+			p.mu.Unlock()
+			continue
+		}
+
+		p.mu.Lock()
+		p.pending--
+		if p.closed {
+			p.mu.Unlock()
+			plugin.Close(context.TODO())
+			p.runIfDrained()
+			return
+		}
+		p.instances++
+		p.handOffLocked(plugin)
+		p.mu.Unlock()
+
+		msg := fmt.Sprintf("Plugin instance restarted after %v backoff", delay)
+		p.logger.Printf("%s: %s", p.key, msg)
+		p.logStore.AddLog(p.key, logging.LevelInfo, msg)
+		return
+	}
+}
+
+// Stats returns a snapshot of the pool.
+func (p *PluginPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := PoolStats{
+		Instances:    p.instances - p.retire,
+		Idle:         len(p.idle),
+		InFlight:     p.inFlight,
+		QueueDepth:   p.waiting,
+		CallRate:     p.callRate,
+		MinInstances: p.settings.MinInstances,
+		MaxInstances: p.settings.MaxInstances,
+		Restarts:     p.restarts,
+		CrashLooping: p.crashes > p.settings.MaxRestarts,
+
+		QueueTimeouts: p.timeouts,
+	}
+	if p.lastScale != nil {
+		event := *p.lastScale
+		stats.LastScale = &event
+	}
+	for i, class := range p.classes {
+		if class.calls == 0 {
+			continue
+		}
+		if stats.Priorities == nil {
+			stats.Priorities = make(map[string]PriorityStats, numPriorities)
+		}
+		entry := PriorityStats{
+			Waiting:   len(class.queue),
+			Calls:     class.calls,
+			Queued:    class.queued,
+			MaxWaitMs: milliseconds(class.waitMax),
+		}
+		if class.served > 0 {
+			entry.AvgWaitMs = milliseconds(class.waitTotal) / float64(class.served)
+		}
+		stats.Priorities[Priority(i).String()] = entry
+	}
+	return stats
+}
+
+// Resize changes the instance bounds of the pool. Instances are added right away
+// to reach the new minimum; idle instances above the new maximum are closed and
+// busy ones retired as their calls finish. Instances are handed out in the order
+// they were released, so calls rotate round-robin over the idle instances.
+func (p *PluginPool) Resize(minInstances, maxInstances int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.closed:
+		return ErrPoolClosed
+	case p.factory == nil:
+		return ErrPoolPinned
+	case minInstances < 1 || maxInstances < minInstances || maxInstances > cap(p.idle):
+		return fmt.Errorf("invalid pool bounds %d-%d, must be between 1 and %d", minInstances, maxInstances, cap(p.idle))
+	}
+
+	p.settings.MinInstances, p.settings.MaxInstances = minInstances, maxInstances
+	reason := fmt.Sprintf("resized to %d-%d instances", minInstances, maxInstances)
+
+	current := p.instances + p.pending - p.retire
+	if current < minInstances {
+		p.scaleUpLocked(minInstances-current, reason)
+	}
+	// Instances still being created are trimmed by the next autoscale tick
+	for ; current > maxInstances && p.instances > p.retire; current-- {
+		p.scaleDownLocked(reason)
+	}
+
+	return nil
+}
+
+// Close releases idle instances immediately and busy ones as their calls finish.
+func (p *PluginPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	p.mu.Unlock()
+
+	for {
+		select {
+		case plugin := <-p.idle:
+			p.mu.Lock()
+			p.instances--
+			p.mu.Unlock()
+			plugin.Close(context.TODO())
+		default:
+			p.runIfDrained()
+			return
+		}
+	}
+}
+
+// OnDrained registers fn to run once the pool is closed and its last instance,
+// including those busy at the time, has been closed. It releases resources the
+// instances share, such as their compiled module.
+func (p *PluginPool) OnDrained(fn func()) {
+	p.mu.Lock()
+	p.onDrained = fn
+	p.mu.Unlock()
+	p.runIfDrained()
+}
+
+// runIfDrained runs the drained callback the first time the closed pool owns no instances.
+func (p *PluginPool) runIfDrained() {
+	p.mu.Lock()
+	if !p.closed || p.drained || p.onDrained == nil || p.instances > 0 || p.pending > 0 {
+		p.mu.Unlock()
+		return
+	}
+	p.drained = true
+	fn := p.onDrained
+	p.mu.Unlock()
+
+	fn()
+}
+
+// queueChangedLocked reports the number of waiting calls. Caller holds p.mu.
+func (p *PluginPool) queueChangedLocked() {
+	if p.settings.OnQueueChange != nil {
+		p.settings.OnQueueChange(p.key, p.waiting)
+	}
+}
+
+func (p *PluginPool) notePeak() {
+	if demand := p.inFlight + p.waiting; demand > p.peak {
+		p.peak = demand
+	}
+}
+
+// autoscale periodically refreshes the call rate and makes scale-down decisions.
+func (p *PluginPool) autoscale() {
+	ticker := p.settings.Clock.NewTicker(p.settings.ScaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C():
+			p.tick()
+		}
+	}
+}
+
+func (p *PluginPool) tick() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.settings.Clock.Now()
+	if elapsed := now.Sub(p.lastTick).Seconds(); elapsed > 0 {
+		p.callRate = float64(p.calls) / elapsed
+	}
+	p.calls = 0
+	p.lastTick = now
+
+	demand := p.peak
+	p.peak = p.inFlight + p.waiting
+
+	current := p.instances + p.pending - p.retire
+	switch {
+	case p.waiting > p.pending:
+		p.scaleUpLocked(p.waiting-p.pending, fmt.Sprintf("queue depth %d at %.1f calls/s", p.waiting, p.callRate))
+	case current < p.settings.MinInstances:
+		p.scaleUpLocked(p.settings.MinInstances-current, "below minimum instances")
+	case current > p.settings.MaxInstances && p.instances > p.retire:
+		p.scaleDownLocked("above maximum instances")
+	case demand < current && current > p.settings.MinInstances:
+		p.scaleDownLocked(fmt.Sprintf("peak demand %d below %d instances at %.1f calls/s", demand, current, p.callRate))
+	}
+}
+
+// scaleUpLocked adds up to n instances without exceeding the maximum. Caller holds p.mu.
+func (p *PluginPool) scaleUpLocked(n int, reason string) {
+	if p.closed || p.factory == nil {
+		return
+	}
+
+	// Cancel pending retirements before creating new instances
+	for n > 0 && p.retire > 0 {
+		p.retire--
+		n--
+	}
+
+	available := p.settings.MaxInstances - (p.instances + p.pending)
+	if n > available {
+		n = available
+	}
+	if n <= 0 {
+		return
+	}
+
+	p.pending += n
+	go p.grow(n, reason)
+}
+
+// grow creates n instances whose slots were already reserved in p.pending.
+func (p *PluginPool) grow(n int, reason string) {
+	p.mu.Lock()
+	from := p.instances - p.retire
+	p.mu.Unlock()
+
+	created := 0
+	for i := 0; i < n; i++ {
+		plugin, err := p.factory(context.Background())
+
+		p.mu.Lock()
+		p.pending--
+		if err != nil || p.closed {
+			p.mu.Unlock()
+			if err != nil {
+				p.logger.Errorf("Failed to add instance to plugin pool for %s: %v", p.key, err)
+				p.logStore.AddLog(p.key, logging.LevelError, fmt.Sprintf("Failed to add pool instance: %v", err))
+			} else if plugin != nil {
+				plugin.Close(context.TODO())
+			}
+			p.runIfDrained()
+			continue
+		}
+		p.instances++
+		p.handOffLocked(plugin)
+		p.mu.Unlock()
+		created++
+	}
+
+	if created > 0 {
+		p.mu.Lock()
+		to := p.instances - p.retire
+		p.recordScaleLocked(from, to, reason)
+		p.mu.Unlock()
+	}
+}
+
+// scaleDownLocked removes one instance, closing an idle one now or a busy one on release. Caller holds p.mu.
+func (p *PluginPool) scaleDownLocked(reason string) {
+	from := p.instances - p.retire
+
+	select {
+	case plugin := <-p.idle:
+		p.instances--
+		plugin.Close(context.TODO())
+	default:
+		p.retire++
+	}
+
+	p.recordScaleLocked(from, from-1, reason)
+}
+
+func (p *PluginPool) recordScaleLocked(from, to int, reason string) {
+	p.lastScale = &ScaleEvent{Time: p.settings.Clock.Now(), From: from, To: to, Reason: reason}
+
+	msg := fmt.Sprintf("Plugin pool scaled from %d to %d instances: %s", from, to, reason)
+	p.logger.Printf("%s: %s", p.key, msg)
+	p.logStore.AddLog(p.key, logging.LevelInfo, msg)
+}
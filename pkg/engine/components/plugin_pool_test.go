@@ -0,0 +1,373 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emptyModule is the smallest valid wasm module
+var emptyModule = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+func newTestPlugin(t *testing.T) *extism.Plugin {
+	t.Helper()
+	plugin, err := extism.NewPlugin(context.Background(),
+		extism.Manifest{Wasm: []extism.Wasm{extism.WasmData{Data: emptyModule}}},
+		extism.PluginConfig{}, []extism.HostFunction{})
+	require.NoError(t, err)
+	return plugin
+}
+
+func TestPluginPoolScalesWithQueueDepth(t *testing.T) {
+	var created atomic.Int32
+	factory := func(context.Context) (*extism.Plugin, error) {
+		created.Add(1)
+		return newTestPlugin(t), nil
+	}
+
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	pool := NewPluginPool(key, newTestPlugin(t), factory,
+		PoolSettings{MinInstances: 1, MaxInstances: 2, ScaleInterval: time.Hour},
+		logging.NewStdLogger(io.Discard), logging.NewFunctionLogStore(100))
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The first call takes the seed instance, the second queues and triggers a scale up
+	first, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	second, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+
+	stats := pool.Stats()
+	assert.Equal(t, 2, stats.Instances)
+	assert.Equal(t, 2, stats.InFlight)
+	require.NotNil(t, stats.LastScale)
+	assert.Equal(t, 2, stats.LastScale.To)
+	assert.Equal(t, int32(1), created.Load())
+
+	// At the maximum, a further call waits until an instance is released
+	waitCtx, waitCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer waitCancel()
+	_, err = pool.Acquire(waitCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	pool.Release(first)
+	pool.Release(second)
+
+	// The first tick still sees the burst; the next scales back down to the minimum
+	pool.tick()
+	assert.Equal(t, 2, pool.Stats().Instances)
+	pool.tick()
+	stats = pool.Stats()
+	assert.Equal(t, 1, stats.Instances)
+	assert.Equal(t, 1, stats.LastScale.To)
+}
+
+func TestPluginPoolWithoutFactoryIsFixed(t *testing.T) {
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	pool := NewPluginPool(key, newTestPlugin(t), nil,
+		PoolSettings{MinInstances: 2, MaxInstances: 4},
+		logging.NewStdLogger(io.Discard), logging.NewFunctionLogStore(100))
+
+	stats := pool.Stats()
+	assert.Equal(t, 1, stats.MinInstances)
+	assert.Equal(t, 1, stats.MaxInstances)
+	assert.ErrorIs(t, pool.Resize(2, 2), ErrPoolPinned)
+
+	pool.Close()
+	_, err := pool.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestPluginPoolResize(t *testing.T) {
+	factory := func(context.Context) (*extism.Plugin, error) {
+		return newTestPlugin(t), nil
+	}
+
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	pool := NewPluginPool(key, newTestPlugin(t), factory,
+		PoolSettings{MinInstances: 1, MaxInstances: 2, ScaleInterval: time.Hour},
+		logging.NewStdLogger(io.Discard), logging.NewFunctionLogStore(100))
+	defer pool.Close()
+
+	// Growing past the configured maximum warms the new minimum right away
+	require.NoError(t, pool.Resize(5, 5))
+	require.Eventually(t, func() bool { return pool.Stats().Instances == 5 }, 5*time.Second, 10*time.Millisecond)
+	stats := pool.Stats()
+	assert.Equal(t, 5, stats.MinInstances)
+	assert.Equal(t, 5, stats.MaxInstances)
+
+	// Shrinking closes idle instances immediately and retires busy ones on release
+	busy, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, pool.Resize(1, 1))
+	assert.Equal(t, 1, pool.Stats().Instances)
+	pool.Release(busy)
+	assert.Equal(t, 1, pool.Stats().Instances)
+
+	assert.Error(t, pool.Resize(0, 1))
+	assert.Error(t, pool.Resize(1, MaxScaleInstances+1))
+}
+
+func TestPluginManagerScaleSurvivesReload(t *testing.T) {
+	pm := NewPluginManager(logging.NewStdLogger(io.Discard), PluginManagerSettings{Pool: DefaultPoolSettings()})
+	defer pm.Shutdown()
+
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	factory := func(context.Context) (*extism.Plugin, error) {
+		return newTestPlugin(t), nil
+	}
+
+	// Scaling an unloaded function applies once it loads
+	require.NoError(t, pm.ScaleFunction(key, 3))
+	pm.StorePlugin(key, newTestPlugin(t), factory, "digest", nil)
+	assert.Equal(t, 3, pm.GetPoolStats()[key].MaxInstances)
+
+	// Zero restores the configured bounds
+	require.NoError(t, pm.ScaleFunction(key, 0))
+	stats := pm.GetPoolStats()[key]
+	assert.Equal(t, DefaultPoolSettings().MinInstances, stats.MinInstances)
+	assert.Equal(t, DefaultPoolSettings().MaxInstances, stats.MaxInstances)
+
+	assert.Error(t, pm.ScaleFunction(key, -1))
+}
+
+func TestPluginPoolRestartsCrashedInstances(t *testing.T) {
+	var created atomic.Int32
+	factory := func(context.Context) (*extism.Plugin, error) {
+		created.Add(1)
+		return newTestPlugin(t), nil
+	}
+
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	pool := NewPluginPool(key, newTestPlugin(t), factory,
+		PoolSettings{MinInstances: 1, MaxInstances: 1, ScaleInterval: time.Hour,
+			MaxRestarts: 1, RestartBackoff: time.Millisecond, MaxRestartBackoff: 5 * time.Millisecond},
+		logging.NewStdLogger(io.Discard), logging.NewFunctionLogStore(100))
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The first crash is tolerated and the instance is replaced after the backoff
+	plugin, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, pool.Discard(plugin, errors.New("wasm error: unreachable")))
+
+	plugin, err = pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), created.Load())
+
+	// A second crash in a row exceeds MaxRestarts
+	assert.True(t, pool.Discard(plugin, errors.New("wasm error: unreachable")))
+	stats := pool.Stats()
+	assert.Equal(t, int64(2), stats.Restarts)
+	assert.True(t, stats.CrashLooping)
+
+	// A healthy call clears the crash streak
+	plugin, err = pool.Acquire(ctx)
+	require.NoError(t, err)
+	pool.Release(plugin)
+	assert.False(t, pool.Stats().CrashLooping)
+}
+
+func TestPluginPoolReplacesInterruptedInstances(t *testing.T) {
+	var created atomic.Int32
+	factory := func(context.Context) (*extism.Plugin, error) {
+		created.Add(1)
+		return newTestPlugin(t), nil
+	}
+
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	pool := NewPluginPool(key, newTestPlugin(t), factory,
+		PoolSettings{MinInstances: 1, MaxInstances: 1, ScaleInterval: time.Hour, MaxRestarts: 0},
+		logging.NewStdLogger(io.Discard), logging.NewFunctionLogStore(100))
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// An interrupted call's instance is replaced without counting as a crash
+	plugin, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	pool.Replace(plugin, "call abandoned by its caller")
+
+	plugin, err = pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), created.Load())
+	pool.Release(plugin)
+
+	stats := pool.Stats()
+	assert.Equal(t, 1, stats.Instances)
+	assert.Zero(t, stats.Restarts)
+	assert.False(t, stats.CrashLooping)
+}
+
+func TestIsFatalInstanceError(t *testing.T) {
+	assert.False(t, IsFatalInstanceError(nil))
+	assert.False(t, IsFatalInstanceError(errors.New("invalid input")))
+	assert.True(t, IsFatalInstanceError(errors.New("wasm error: out of bounds memory access")))
+	assert.True(t, IsFatalInstanceError(errors.New("module closed with exit_code(1)")))
+}
+
+func TestParseTrap(t *testing.T) {
+	_, ok := ParseTrap(errors.New("invalid input"))
+	assert.False(t, ok)
+
+	trap, ok := ParseTrap(errors.New("wasm error: unreachable\nwasm stack trace:\n" +
+		"\tmain.runtime._panic(i32)\n\t  0x16e2: /src/runtime.go:73:6\n" +
+		"\tmain.handler() i32\n\t  0x190b: /src/main.go:19:7\n\t  0x18ed: /src/main.go:4:3 (inlined)"))
+	require.True(t, ok)
+	assert.Equal(t, "wasm error: unreachable", trap.Message)
+	require.Len(t, trap.Frames, 2)
+	assert.Equal(t, "main.handler() i32", trap.Frames[1].Function)
+	assert.Len(t, trap.Frames[1].Sources, 2)
+	assert.True(t, trap.Symbolized())
+	assert.Equal(t, "wasm error: unreachable\n  at main.runtime._panic(i32) (0x16e2: /src/runtime.go:73:6)\n"+
+		"  at main.handler() i32 (0x190b: /src/main.go:19:7)", trap.String())
+
+	// Frames of modules without a names section are numbered, and Go stack traces are dropped
+	trap, ok = ParseTrap(errors.New("boom (recovered by wazero)\nwasm stack trace:\n\tmain.$12() i32\n\n" +
+		"Go runtime stack trace:\ngoroutine 1"))
+	require.True(t, ok)
+	require.Len(t, trap.Frames, 1)
+	assert.False(t, trap.Symbolized())
+}
+
+func TestPluginPoolDrainsAfterBusyInstances(t *testing.T) {
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	pool := NewPluginPool(key, newTestPlugin(t), nil, PoolSettings{},
+		logging.NewStdLogger(io.Discard), logging.NewFunctionLogStore(100))
+
+	var drained atomic.Int32
+	pool.OnDrained(func() { drained.Add(1) })
+
+	plugin, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+
+	// The busy instance keeps the pool from draining until it is released
+	pool.Close()
+	assert.Equal(t, int32(0), drained.Load())
+
+	pool.Release(plugin)
+	assert.Equal(t, int32(1), drained.Load())
+
+	// Registering on a drained pool runs the callback right away
+	pool.OnDrained(func() { drained.Add(1) })
+	pool.Close()
+	assert.Equal(t, int32(1), drained.Load())
+}
+
+func TestPluginPoolServesQueuedCallsByPriority(t *testing.T) {
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	pool := NewPluginPool(key, newTestPlugin(t), nil, PoolSettings{},
+		logging.NewStdLogger(io.Discard), logging.NewFunctionLogStore(100))
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	busy, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+
+	type served struct {
+		priority Priority
+		plugin   *extism.Plugin
+	}
+	order := make(chan served, 3)
+	for i, priority := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+		go func() {
+			plugin, err := pool.Acquire(WithPriority(ctx, priority))
+			assert.NoError(t, err)
+			order <- served{priority, plugin}
+		}()
+		require.Eventually(t, func() bool { return pool.Stats().QueueDepth == i+1 }, time.Second, time.Millisecond)
+	}
+
+	// A call that gives up leaves the queue without taking an instance
+	gaveUp, giveUp := context.WithCancel(WithPriority(ctx, PriorityHigh))
+	giveUp()
+	_, err = pool.Acquire(gaveUp)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	pool.Release(busy)
+	var got []Priority
+	for range 3 {
+		next := <-order
+		got = append(got, next.priority)
+		pool.Release(next.plugin)
+	}
+	assert.Equal(t, []Priority{PriorityHigh, PriorityNormal, PriorityLow}, got)
+
+	stats := pool.Stats()
+	assert.Equal(t, 0, stats.QueueDepth)
+	assert.Equal(t, int64(2), stats.Priorities["high"].Calls)
+	assert.Equal(t, int64(2), stats.Priorities["high"].Queued)
+	assert.Equal(t, int64(2), stats.Priorities["normal"].Calls)
+	assert.Equal(t, int64(1), stats.Priorities["low"].Queued)
+	assert.Positive(t, stats.Priorities["low"].MaxWaitMs)
+}
+
+func TestPluginPoolTurnsAwayCallsAfterMaxQueueWait(t *testing.T) {
+	var depths []int
+	clock := NewFakeClock(time.Now())
+	key := FunctionKey{Namespace: "ns", Name: "fn"}
+	pool := NewPluginPool(key, newTestPlugin(t), nil, PoolSettings{
+		MaxQueueWait:  time.Second,
+		OnQueueChange: func(_ FunctionKey, depth int) { depths = append(depths, depth) },
+		Clock:         clock,
+	}, logging.NewStdLogger(io.Discard), logging.NewFunctionLogStore(100))
+	defer pool.Close()
+
+	busy, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+
+	rejected := make(chan error, 1)
+	go func() {
+		_, err := pool.Acquire(context.Background())
+		rejected <- err
+	}()
+	require.Eventually(t, func() bool { return pool.Stats().QueueDepth == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+
+	var timeout *QueueTimeoutError
+	require.ErrorAs(t, <-rejected, &timeout)
+	assert.Equal(t, time.Second, timeout.Waited)
+	assert.Equal(t, int64(1), pool.Stats().QueueTimeouts)
+	assert.Equal(t, []int{1, 0}, depths)
+
+	// The wait set on a context overrides the pool's
+	admitted := make(chan *extism.Plugin, 1)
+	go func() {
+		plugin, err := pool.Acquire(WithMaxQueueWait(context.Background(), time.Minute))
+		assert.NoError(t, err)
+		admitted <- plugin
+	}()
+	require.Eventually(t, func() bool { return pool.Stats().QueueDepth == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	pool.Release(busy)
+	pool.Release(<-admitted)
+}
+
+func TestParsePriority(t *testing.T) {
+	priority, err := ParsePriority("HIGH")
+	require.NoError(t, err)
+	assert.Equal(t, PriorityHigh, priority)
+
+	priority, err = ParsePriority("")
+	require.NoError(t, err)
+	assert.Equal(t, PriorityNormal, priority)
+
+	_, err = ParsePriority("urgent")
+	assert.Error(t, err)
+}
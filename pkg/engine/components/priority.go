@@ -0,0 +1,73 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Priority orders calls waiting for an instance of a saturated pool. Calls of a
+// higher priority are served first; calls of the same priority in arrival order.
+type Priority int
+
+// Priority classes, from most to least urgent.
+const (
+	PriorityHigh Priority = iota
+	PriorityNormal
+	PriorityLow
+
+	numPriorities = 3
+)
+
+var priorityNames = [numPriorities]string{"high", "normal", "low"}
+
+// ParsePriority parses "high", "normal" or "low". An empty string is normal.
+func ParsePriority(value string) (Priority, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return PriorityNormal, nil
+	}
+	for i, name := range priorityNames {
+		if name == value {
+			return Priority(i), nil
+		}
+	}
+	return PriorityNormal, fmt.Errorf("invalid priority %q, expected high, normal or low", value)
+}
+
+func (p Priority) String() string {
+	if p < 0 || p >= numPriorities {
+		return priorityNames[PriorityNormal]
+	}
+	return priorityNames[p]
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose calls wait for pool instances with the given priority.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority set with WithPriority, if any.
+func PriorityFromContext(ctx context.Context) (Priority, bool) {
+	priority, ok := ctx.Value(priorityKey{}).(Priority)
+	if !ok || priority < 0 || priority >= numPriorities {
+		return PriorityNormal, false
+	}
+	return priority, true
+}
+
+// PriorityStats describes the calls of one priority class of a pool.
+type PriorityStats struct {
+	// Calls currently waiting for an instance
+	Waiting int `json:"waiting"`
+
+	// Calls made, and how many of them had to wait
+	Calls  int64 `json:"calls"`
+	Queued int64 `json:"queued"`
+
+	// Average and longest wait of the calls that waited, in milliseconds
+	AvgWaitMs float64 `json:"avg_wait_ms"`
+	MaxWaitMs float64 `json:"max_wait_ms"`
+}
@@ -0,0 +1,75 @@
+package components
+
+import "strings"
+
+// Trap is a call aborted by the wasm runtime, with the stack it was aborted at.
+type Trap struct {
+	// Message of the error, without the stack trace
+	Message string
+
+	// Frames of the stack, innermost first
+	Frames []TrapFrame
+}
+
+// TrapFrame is a function on the stack of a trap.
+type TrapFrame struct {
+	// Function and signature, such as main.handler() i32, or main.$12() i32 when the
+	// module has no name for it
+	Function string
+
+	// Source lines of the frame, when the module keeps its DWARF sections
+	Sources []string
+}
+
+// ParseTrap reads the stack trace the runtime appends to errors from wasm code.
+func ParseTrap(err error) (*Trap, bool) {
+	if err == nil {
+		return nil, false
+	}
+	message, trace, ok := strings.Cut(err.Error(), "\nwasm stack trace:\n")
+	if !ok {
+		return nil, false
+	}
+
+	// Errors raised by the runtime itself are followed by a Go stack trace
+	trace, _, _ = strings.Cut(trace, "\n\n")
+
+	trap := &Trap{Message: message}
+	for _, line := range strings.Split(trace, "\n") {
+		line = strings.TrimPrefix(line, "\t")
+		switch {
+		case strings.TrimSpace(line) == "":
+		case strings.HasPrefix(line, " ") && len(trap.Frames) > 0:
+			frame := &trap.Frames[len(trap.Frames)-1]
+			frame.Sources = append(frame.Sources, strings.TrimSpace(line))
+		default:
+			trap.Frames = append(trap.Frames, TrapFrame{Function: line})
+		}
+	}
+	return trap, true
+}
+
+// Symbolized reports whether every frame of the trap is named.
+func (t *Trap) Symbolized() bool {
+	for _, frame := range t.Frames {
+		if strings.Contains(frame.Function, ".$") {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the message of the trap followed by one line per frame, each with its
+// innermost source line.
+func (t *Trap) String() string {
+	var b strings.Builder
+	b.WriteString(t.Message)
+	for _, frame := range t.Frames {
+		b.WriteString("\n  at ")
+		b.WriteString(frame.Function)
+		if len(frame.Sources) > 0 {
+			b.WriteString(" (" + frame.Sources[0] + ")")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content codings supported on the public HTTP endpoint
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// compressionMiddleware decompresses gzip or deflate request bodies and compresses
+// responses of at least CompressionMinSize bytes when the client accepts it.
+func (h *Handlers) compressionMiddleware() Middleware {
+	opts := h.engine.options
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			if !opts.CompressionEnabled {
+				return next(w, r)
+			}
+
+			if err := decompressRequest(w, r, opts.MaxDecompressedSize); err != nil {
+				return err
+			}
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" {
+				return next(w, r)
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				minSize:        opts.CompressionMinSize,
+				statusCode:     http.StatusOK,
+			}
+			err := next(cw, r)
+
+			// Leave the response to the error middleware if the handler wrote nothing
+			if err != nil && cw.untouched() {
+				return err
+			}
+			if closeErr := cw.Close(); err == nil {
+				err = closeErr
+			}
+			return err
+		}
+	}
+}
+
+// decompressRequest replaces an encoded request body with a decoding reader capped at maxSize bytes.
+func decompressRequest(w http.ResponseWriter, r *http.Request, maxSize int64) error {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return nil
+	}
+
+	var (
+		reader io.ReadCloser
+		err    error
+	)
+	switch encoding {
+	case encodingGzip:
+		reader, err = gzip.NewReader(r.Body)
+	case encodingDeflate:
+		reader, err = zlib.NewReader(r.Body)
+	default:
+		return NewRequestError(fmt.Sprintf("Unsupported Content-Encoding: %s", encoding), http.StatusUnsupportedMediaType)
+	}
+	if err != nil {
+		return NewBadRequestError(fmt.Sprintf("Invalid %s request body", encoding))
+	}
+
+	if maxSize > 0 {
+		reader = http.MaxBytesReader(w, reader, maxSize)
+	}
+
+	r.Body = reader
+	r.ContentLength = -1
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	return nil
+}
+
+// negotiateEncoding picks the response coding from an Accept-Encoding header, preferring gzip.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		accepted[coding] = true
+	}
+
+	switch {
+	case accepted[encodingGzip] || accepted["*"]:
+		return encodingGzip
+	case accepted[encodingDeflate]:
+		return encodingDeflate
+	default:
+		return ""
+	}
+}
+
+// compressWriter buffers the response until minSize bytes are written, then
+// switches to a compressed stream. Smaller responses are sent unchanged.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding   string
+	minSize    int
+	statusCode int
+	buf        []byte
+	encoder    io.WriteCloser
+	plain      bool
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	cw.statusCode = code
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	switch {
+	case cw.encoder != nil:
+		return cw.encoder.Write(p)
+	case cw.plain:
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) < cw.minSize {
+		return len(p), nil
+	}
+
+	// Leave bodies the function already encoded, and bodiless statuses, untouched
+	if cw.Header().Get("Content-Encoding") != "" || !bodyAllowed(cw.statusCode) {
+		if err := cw.flushPlain(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if err := cw.startEncoder(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends what was written so far. Responses flushed before reaching minSize
+// bytes, such as streamed calls, are sent uncompressed.
+func (cw *compressWriter) Flush() {
+	switch {
+	case cw.encoder != nil:
+		if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+			_ = flusher.Flush()
+		}
+	case !cw.plain:
+		_ = cw.flushPlain()
+	}
+	_ = http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// untouched reports whether nothing of the response has been written or buffered yet.
+func (cw *compressWriter) untouched() bool {
+	return cw.encoder == nil && !cw.plain && len(cw.buf) == 0
+}
+
+// Close flushes any buffered response, finishing the compressed stream if one was started.
+func (cw *compressWriter) Close() error {
+	switch {
+	case cw.encoder != nil:
+		return cw.encoder.Close()
+	case cw.plain:
+		return nil
+	default:
+		return cw.flushPlain()
+	}
+}
+
+func (cw *compressWriter) startEncoder() error {
+	header := cw.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", cw.encoding)
+	header.Add("Vary", "Accept-Encoding")
+	cw.ResponseWriter.WriteHeader(cw.statusCode)
+
+	if cw.encoding == encodingGzip {
+		cw.encoder = gzip.NewWriter(cw.ResponseWriter)
+	} else {
+		cw.encoder = zlib.NewWriter(cw.ResponseWriter)
+	}
+
+	buf := cw.buf
+	cw.buf = nil
+	_, err := cw.encoder.Write(buf)
+	return err
+}
+
+func (cw *compressWriter) flushPlain() error {
+	cw.plain = true
+	cw.ResponseWriter.WriteHeader(cw.statusCode)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// bodyAllowed reports whether a response with the given status may carry a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
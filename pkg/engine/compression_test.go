@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCompressionTestHandler(minSize int) http.HandlerFunc {
+	options := DefaultEngineOptions().WithCompression(true, minSize, 1024)
+	h := &Handlers{engine: &Engine{options: options}, logger: logging.NewStdLogger(io.Discard)}
+
+	echo := func(w http.ResponseWriter, r *http.Request) error {
+		payload, err := decodeCallPayload(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(payload))
+		return err
+	}
+	return h.withMiddleware(echo, h.compressionMiddleware(), h.errorMiddleware())
+}
+
+func gzipBytes(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	handler := newCompressionTestHandler(16)
+	large := strings.Repeat("a", 64)
+
+	// Compressed request bodies are decoded and large responses compressed
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(gzipBytes(t, `{"payload":"`+large+`"}`)))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, large, string(body))
+
+	// Responses under the threshold are sent as-is
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"payload":"small"}`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "small", rec.Body.String())
+
+	// Clients that refuse gzip get plain responses
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"payload":"`+large+`"}`))
+	req.Header.Set("Accept-Encoding", "gzip;q=0")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, large, rec.Body.String())
+}
+
+func TestCompressionMiddlewareRejectsBadBodies(t *testing.T) {
+	handler := newCompressionTestHandler(16)
+
+	tests := []struct {
+		encoding string
+		body     []byte
+		status   int
+	}{
+		{"br", []byte("x"), http.StatusUnsupportedMediaType},
+		{"gzip", []byte("not gzip"), http.StatusBadRequest},
+		{"gzip", gzipBytes(t, `{"payload":"`+strings.Repeat("a", 2048)+`"}`), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+		req.Header.Set("Content-Encoding", tt.encoding)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		assert.Equal(t, tt.status, rec.Code, tt.encoding)
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	assert.Equal(t, "gzip", negotiateEncoding("deflate, gzip"))
+	assert.Equal(t, "deflate", negotiateEncoding("deflate, gzip;q=0"))
+	assert.Equal(t, "gzip", negotiateEncoding("*"))
+	assert.Equal(t, "", negotiateEncoding("identity"))
+	assert.Equal(t, "", negotiateEncoding(""))
+}
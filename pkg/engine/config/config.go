@@ -1,13 +1,26 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"fmt"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/go-viper/mapstructure/v2"
+	"github.com/ignitionstack/ignition/pkg/engine/egress"
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/ignitionstack/ignition/pkg/engine/ipc"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/proxy"
+	"github.com/ignitionstack/ignition/pkg/validation"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
@@ -30,6 +43,9 @@ type Config struct {
 
 	// Server options
 	Server ServerConfig `koanf:"server"`
+
+	// Registry options
+	Registry RegistryConfig `koanf:"registry"`
 }
 
 // EngineConfig holds engine-specific configuration
@@ -40,9 +56,66 @@ type EngineConfig struct {
 	// Capacity of the log store
 	LogStoreCapacity int `koanf:"log_store_capacity"`
 
+	// Minimum level recorded in function logs (debug, info, warning, error)
+	LogLevel string `koanf:"log_level"`
+
+	// How long function log entries are kept (0 keeps them until evicted by capacity)
+	LogRetention time.Duration `koanf:"log_retention"`
+
+	// How often expired function log entries are trimmed (0 only trims when a function logs)
+	LogTrimInterval time.Duration `koanf:"log_trim_interval"`
+
+	// How long admin audit events are kept (0 keeps them forever)
+	AuditRetention time.Duration `koanf:"audit_retention"`
+
+	// Dead letter capture of failed calls
+	DeadLetter DeadLetterConfig `koanf:"dead_letter"`
+
+	// Spooling of large payloads and outputs to temporary files
+	Spool SpoolConfig `koanf:"spool"`
+
+	// Persistence of function logs to files under the registry directory
+	LogFiles LogFilesConfig `koanf:"log_files"`
+
+	// Forwarding of function logs to external systems
+	LogShipping LogShippingConfig `koanf:"log_shipping"`
+
 	// Circuit breaker settings
 	CircuitBreaker CircuitBreakerConfig `koanf:"circuit_breaker"`
 
+	// Notifications about circuit breaker events
+	Notifications NotificationsConfig `koanf:"notifications"`
+
+	// Admission policies evaluated before functions are stored or loaded
+	Policy PolicyConfig `koanf:"policy"`
+
+	// Metering of invocations per namespace and their quotas
+	Usage UsageConfig `koanf:"usage"`
+
+	// Collector of call metrics
+	Metrics MetricsConfig `koanf:"metrics"`
+
+	// Sampling of engine memory, goroutines and caches, with diagnostic bundles on breach
+	Watchdog WatchdogConfig `koanf:"watchdog"`
+
+	// Active/standby pairing of engines on one host
+	HA HAConfig `koanf:"ha"`
+
+	// Connections the HTTP requests of functions may make, beyond their allowed_urls
+	Egress EgressConfig `koanf:"egress"`
+
+	// Config keys whose values are masked in function logs and redacted snapshots
+	Redaction RedactionConfig `koanf:"redaction"`
+
+	// Proxy of function HTTP requests and remote registries
+	Proxy ProxyConfig `koanf:"proxy"`
+
+	// Function builds
+	Build BuildConfig `koanf:"build"`
+
+	// Replay of load and build responses to retries carrying the same Idempotency-Key
+	Idempotency IdempotencyConfig `koanf:"idempotency"`
+
 	// Plugin manager settings
 	PluginManager PluginManagerConfig `koanf:"plugin_manager"`
 }
@@ -52,11 +125,377 @@ type ServerConfig struct {
 	// Socket path for Unix socket
 	SocketPath string `koanf:"socket_path"`
 
-	// HTTP address to listen on
+	// Serve the admin API on the Unix socket
+	SocketEnabled bool `koanf:"socket_enabled"`
+
+	// TCP address of the admin API, for environments without a shared filesystem (empty disables it)
+	AdminAddr string `koanf:"admin_addr"`
+
+	// Bearer token required on the TCP admin API
+	AdminToken string `koanf:"admin_token"`
+
+	// Uids allowed to build, push, load, stop, unload, retag and scale functions, apply compose
+	// files and manage dead letters and pipelines over the Unix socket (empty allows everyone)
+	PrivilegedUIDs []uint32 `koanf:"privileged_uids"`
+
+	// HTTP address to listen on, serving every namespace (empty disables it)
 	HTTPAddr string `koanf:"http_addr"`
 
+	// Additional HTTP listeners that only serve functions of some namespaces
+	Listeners []ListenerConfig `koanf:"listeners"`
+
+	// TCP address serving GET /metrics with the prometheus collector (empty disables it)
+	MetricsAddr string `koanf:"metrics_addr"`
+
 	// Registry directory path
 	RegistryDir string `koanf:"registry_dir"`
+
+	// How long to wait for another process to release the registry database on start
+	// (0 fails at once)
+	LockWait time.Duration `koanf:"lock_wait"`
+
+	// Send CORS headers on the HTTP endpoint; disable when only internal clients call it
+	CORSEnabled bool `koanf:"cors_enabled"`
+
+	// Compression on the public HTTP endpoint
+	Compression CompressionConfig `koanf:"compression"`
+}
+
+// ListenerConfig holds an HTTP listener scoped to some namespaces
+type ListenerConfig struct {
+	// TCP address to listen on
+	Addr string `koanf:"addr"`
+
+	// Namespaces whose functions the listener serves; calls to any other function get 404
+	Namespaces []string `koanf:"namespaces"`
+}
+
+// Validate checks that every listener has its own address and at least one valid namespace.
+func (c ServerConfig) Validate() error {
+	addrs := map[string]bool{}
+	for _, addr := range []string{c.HTTPAddr, c.AdminAddr, c.MetricsAddr} {
+		if addr != "" {
+			addrs[addr] = true
+		}
+	}
+
+	for i, listener := range c.Listeners {
+		if listener.Addr == "" {
+			return fmt.Errorf("listener %d: addr is required", i+1)
+		}
+		if addrs[listener.Addr] {
+			return fmt.Errorf("listener %d: address %s is already in use by another listener", i+1, listener.Addr)
+		}
+		addrs[listener.Addr] = true
+
+		if len(listener.Namespaces) == 0 {
+			return fmt.Errorf("listener %d: at least one namespace is required", i+1)
+		}
+		for _, namespace := range listener.Namespaces {
+			if err := validation.ValidateNamespace(namespace); err != nil {
+				return fmt.Errorf("listener %d: %w", i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CompressionConfig holds HTTP compression configuration
+type CompressionConfig struct {
+	// Accept gzip/deflate request bodies and compress responses
+	Enabled bool `koanf:"enabled"`
+
+	// Smallest response in bytes that gets compressed
+	MinSize int `koanf:"min_size"`
+
+	// Maximum decompressed request body size in bytes (0 disables the limit)
+	MaxRequestSize int64 `koanf:"max_request_size"`
+}
+
+// RegistryConfig holds registry-specific configuration
+type RegistryConfig struct {
+	// Maximum size in bytes of a wasm module accepted on push (0 disables the limit)
+	MaxModuleSize int64 `koanf:"max_module_size"`
+
+	// How often to run value log GC and storage consistency checks (0 disables it)
+	MaintenanceInterval time.Duration `koanf:"maintenance_interval"`
+
+	// Map wasm modules from storage when loading them instead of reading them into memory
+	MapModules bool `koanf:"map_modules"`
+
+	// Variants of a version loaded in order of preference when a load names none, such
+	// as wasi-p1; versions without any of them load their default module
+	PreferredVariants []string `koanf:"preferred_variants"`
+
+	// Verification of stored wasm modules against their metadata when the engine starts
+	IntegrityCheck IntegrityCheckConfig `koanf:"integrity_check"`
+
+	// Encryption of wasm modules and function metadata at rest
+	Encryption RegistryEncryptionConfig `koanf:"encryption"`
+
+	// Asynchronous copy of registry writes to a secondary registry
+	Replication ReplicationConfig `koanf:"replication"`
+
+	// Shared registry that versions missing locally are pulled from and cached
+	Upstream UpstreamConfig `koanf:"upstream"`
+}
+
+// IntegrityCheckConfig sets how stored wasm modules are verified when the engine starts.
+// Every version is checked for its module; the modules of sampled versions are also
+// hashed. Missing and corrupt modules are pulled again from the upstream registry, if any.
+type IntegrityCheckConfig struct {
+	// full hashes every module, sample hashes sample_size modules picked at random, and
+	// off disables the check
+	Mode string `koanf:"mode"`
+
+	// Number of modules hashed in sample mode
+	SampleSize int `koanf:"sample_size"`
+}
+
+// Validate checks the mode and sample size.
+func (c IntegrityCheckConfig) Validate() error {
+	switch c.Mode {
+	case "full", "off":
+		return nil
+	case "sample":
+		if c.SampleSize <= 0 {
+			return fmt.Errorf("sample_size must be positive")
+		}
+		return nil
+	default:
+		return fmt.Errorf("mode must be full, sample or off, got %q", c.Mode)
+	}
+}
+
+// UpstreamConfig sets the registry that pulls fall back to when a version is not stored
+// locally. An empty URL disables the fallback.
+type UpstreamConfig struct {
+	// http(s) URL of the admin API of another engine, or oci://registry/repository prefix
+	// holding each function as <prefix>/<namespace>/<name>
+	URL string `koanf:"url"`
+
+	// Bearer token of the upstream engine, best set through IGNITION_REGISTRY_UPSTREAM_TOKEN
+	Token string `koanf:"token"`
+
+	// How long a single upstream pull may take
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+// Validate checks the upstream URL scheme.
+func (c UpstreamConfig) Validate() error {
+	if c.URL == "" {
+		return nil
+	}
+	for _, scheme := range []string{"http://", "https://", "oci://"} {
+		if strings.HasPrefix(c.URL, scheme) {
+			return nil
+		}
+	}
+	return fmt.Errorf("url %q must start with http://, https:// or oci://", c.URL)
+}
+
+// ReplicationConfig sets where registry writes are replicated to. Pushes and tag moves
+// are queued and applied to the target in order; an empty target disables replication.
+type ReplicationConfig struct {
+	// Directory of a secondary registry, or http(s) URL of the admin API of another engine
+	Target string `koanf:"target"`
+
+	// Bearer token of the remote admin API, best set through IGNITION_REGISTRY_REPLICATION_TOKEN
+	Token string `koanf:"token"`
+
+	// Writes waiting to be replicated; further writes are dropped until the queue drains
+	QueueSize int `koanf:"queue_size"`
+
+	// Attempts per write before it is given up, leaving it to `ignition registry sync`
+	MaxRetries int `koanf:"max_retries"`
+}
+
+// Validate checks the replication target and queue settings.
+func (c ReplicationConfig) Validate() error {
+	if c.Target == "" {
+		return nil
+	}
+	if strings.Contains(c.Target, "://") {
+		u, err := url.Parse(c.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("target %q must be a directory or an http(s) URL", c.Target)
+		}
+	}
+	if c.QueueSize <= 0 {
+		return fmt.Errorf("queue_size must be positive")
+	}
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must not be negative")
+	}
+	return nil
+}
+
+// keyCommandTimeout bounds how long the registry key command may run
+const keyCommandTimeout = 30 * time.Second
+
+// RegistryEncryptionConfig sets where the AES-256 key of the registry comes from. At most
+// one source may be set; none leaves the registry unencrypted.
+type RegistryEncryptionConfig struct {
+	// Base64 encoded 32-byte key, best set through IGNITION_REGISTRY_ENCRYPTION_KEY
+	Key string `koanf:"key"`
+
+	// File holding the base64 encoded key
+	KeyFile string `koanf:"key_file"`
+
+	// Command printing the base64 encoded key, e.g. a KMS client decrypting a data key
+	KeyCommand []string `koanf:"key_command"`
+}
+
+// Enabled reports whether a key source is set.
+func (c RegistryEncryptionConfig) Enabled() bool {
+	return c.Key != "" || c.KeyFile != "" || len(c.KeyCommand) > 0
+}
+
+// LoadKey reads the key from its source, returning nil when encryption is disabled.
+func (c RegistryEncryptionConfig) LoadKey(ctx context.Context) ([]byte, error) {
+	var encoded []byte
+	switch {
+	case c.Key != "":
+		encoded = []byte(c.Key)
+	case c.KeyFile != "":
+		data, err := os.ReadFile(ExpandHome(c.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		encoded = data
+	case len(c.KeyCommand) > 0:
+		ctx, cancel := context.WithTimeout(ctx, keyCommandTimeout)
+		defer cancel()
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, c.KeyCommand[0], c.KeyCommand[1:]...)
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("key command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		encoded = output
+	default:
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes encoded in base64")
+	}
+	return key, nil
+}
+
+// Validate checks that at most one key source is set.
+func (c RegistryEncryptionConfig) Validate() error {
+	sources := 0
+	for _, set := range []bool{c.Key != "", c.KeyFile != "", len(c.KeyCommand) > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("only one of key, key_file and key_command may be set")
+	}
+	return nil
+}
+
+// DeadLetterConfig holds dead letter store configuration
+type DeadLetterConfig struct {
+	// Persist the payload and error of failed calls
+	Enabled bool `koanf:"enabled"`
+
+	// Maximum number of entries kept per function (0 means no cap)
+	MaxEntries int `koanf:"max_entries"`
+}
+
+// SpoolConfig holds payload spooling configuration
+type SpoolConfig struct {
+	// Size in bytes above which payloads and outputs are kept in temporary files (0 disables spooling)
+	Threshold int64 `koanf:"threshold"`
+
+	// Directory of the temporary files (empty uses the system temporary directory)
+	Dir string `koanf:"dir"`
+}
+
+// LogFilesConfig holds function log file configuration
+type LogFilesConfig struct {
+	// Write every function log entry to <registry_dir>/logs/<namespace>/<name>.jsonl
+	Enabled bool `koanf:"enabled"`
+
+	// Size in bytes at which a log file is rotated (0 never rotates)
+	MaxSize int64 `koanf:"max_size"`
+
+	// Number of rotated files kept per function
+	MaxFiles int `koanf:"max_files"`
+
+	// Rotated files older than this are deleted (0 keeps them until evicted by max_files)
+	MaxAge time.Duration `koanf:"max_age"`
+}
+
+// LogShippingConfig holds function log shipping configuration
+type LogShippingConfig struct {
+	// Entries buffered per sink before new entries are dropped
+	BufferSize int `koanf:"buffer_size"`
+
+	// Maximum number of entries sent in one request
+	BatchSize int `koanf:"batch_size"`
+
+	// How long entries wait for a batch to fill before being sent
+	FlushInterval time.Duration `koanf:"flush_interval"`
+
+	// Attempts to resend a failed batch before it is dropped
+	MaxRetries int `koanf:"max_retries"`
+
+	// Destinations of the logs (none disables shipping)
+	Sinks []LogSinkConfig `koanf:"sinks"`
+}
+
+// LogSinkConfig describes one log shipping destination
+type LogSinkConfig struct {
+	// Destination type: syslog, loki or otlp
+	Type string `koanf:"type"`
+
+	// Push endpoint of Loki (…/loki/api/v1/push) or OTLP/HTTP (…/v1/logs)
+	URL string `koanf:"url"`
+
+	// Extra HTTP headers, such as authorization or a tenant ID
+	Headers map[string]string `koanf:"headers"`
+
+	// Static labels added to every Loki stream
+	Labels map[string]string `koanf:"labels"`
+
+	// Syslog network (udp, tcp or unix) and address; both empty use the local syslog daemon
+	Network string `koanf:"network"`
+	Address string `koanf:"address"`
+
+	// Syslog tag
+	Tag string `koanf:"tag"`
+
+	// Only ship logs of these namespaces (empty ships every namespace)
+	Namespaces []string `koanf:"namespaces"`
+
+	// Timeout of a single send
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+// Validate checks every sink for a known type and the settings it needs.
+func (c LogShippingConfig) Validate() error {
+	for i, sink := range c.Sinks {
+		switch sink.Type {
+		case "loki", "otlp":
+			if sink.URL == "" {
+				return fmt.Errorf("sink %d: %s requires a url", i+1, sink.Type)
+			}
+		case "syslog":
+			if (sink.Network == "") != (sink.Address == "") {
+				return fmt.Errorf("sink %d: syslog requires both network and address, or neither", i+1)
+			}
+		default:
+			return fmt.Errorf("sink %d: unknown type %q (expected syslog, loki or otlp)", i+1, sink.Type)
+		}
+	}
+	return nil
 }
 
 // CircuitBreakerConfig holds circuit breaker configuration
@@ -66,6 +505,402 @@ type CircuitBreakerConfig struct {
 
 	// Reset timeout after which to try again
 	ResetTimeout time.Duration `koanf:"reset_timeout"`
+
+	// Error codes of failed calls that do not count toward the failure threshold, such
+	// as execution_failed for errors reported by functions themselves
+	Ignore []string `koanf:"ignore"`
+
+	// Whether each function has one breaker (function) or each of its entrypoints has
+	// its own (entrypoint)
+	Granularity string `koanf:"granularity"`
+}
+
+// Validate checks the granularity and that ignored codes are codes of failed calls.
+func (c CircuitBreakerConfig) Validate() error {
+	switch c.Granularity {
+	case "", "function", "entrypoint":
+	default:
+		return fmt.Errorf("granularity must be function or entrypoint, got %q", c.Granularity)
+	}
+	for _, code := range c.Ignore {
+		if !slices.Contains(domainerrors.CallFailureCodes, domainerrors.Code(code)) {
+			return fmt.Errorf("unknown error code %q in ignore", code)
+		}
+	}
+	return nil
+}
+
+// NotificationsConfig holds the webhooks notified when a circuit breaker opens or closes
+type NotificationsConfig struct {
+	// Number of recent errors of the function included in a notification
+	ErrorSamples int `koanf:"error_samples"`
+
+	// Timeout of a single webhook request
+	Timeout time.Duration `koanf:"timeout"`
+
+	// Webhooks to notify (none disables notifications)
+	Webhooks []WebhookConfig `koanf:"webhooks"`
+}
+
+// WebhookConfig describes one notification webhook
+type WebhookConfig struct {
+	// Endpoint receiving a POST per event
+	URL string `koanf:"url"`
+
+	// Payload format: json (the event itself) or slack (a Slack-compatible message)
+	Format string `koanf:"format"`
+
+	// Events to send: circuit_open, circuit_closed (empty sends both)
+	Events []string `koanf:"events"`
+
+	// Extra HTTP headers, such as authorization
+	Headers map[string]string `koanf:"headers"`
+}
+
+// Validate checks every webhook for a URL, a known format and known events.
+func (c NotificationsConfig) Validate() error {
+	for i, hook := range c.Webhooks {
+		if hook.URL == "" {
+			return fmt.Errorf("webhook %d: url is required", i+1)
+		}
+		switch hook.Format {
+		case "", "json", "slack":
+		default:
+			return fmt.Errorf("webhook %d: unknown format %q (expected json or slack)", i+1, hook.Format)
+		}
+		for _, event := range hook.Events {
+			if event != "circuit_open" && event != "circuit_closed" {
+				return fmt.Errorf("webhook %d: unknown event %q (expected circuit_open or circuit_closed)", i+1, event)
+			}
+		}
+	}
+	return nil
+}
+
+// PolicyConfig holds the admission policies evaluated before a function version is
+// stored in the registry (build, import, push) and before it is loaded
+type PolicyConfig struct {
+	// Built-in rules
+	Rules PolicyRulesConfig `koanf:"rules"`
+
+	// External evaluator asked about every admission (empty url disables it)
+	Webhook PolicyWebhookConfig `koanf:"webhook"`
+
+	// Check of the SBOM of a version against vulnerability advisories when it is loaded
+	Vulnerabilities VulnerabilityPolicyConfig `koanf:"vulnerabilities"`
+}
+
+// PolicyRulesConfig holds the built-in admission rules; empty values disable a rule
+type PolicyRulesConfig struct {
+	// Namespace patterns functions may be stored and loaded in, such as team-*
+	AllowedNamespaces []string `koanf:"allowed_namespaces"`
+
+	// Labels every version must set in its manifest settings
+	RequiredLabels []string `koanf:"required_labels"`
+
+	// Largest initial or declared maximum memory of a module, in MiB
+	MaxMemoryMB int `koanf:"max_memory_mb"`
+
+	// Host patterns the allowed_urls of a version may list, such as *.example.com
+	AllowedURLs []string `koanf:"allowed_urls"`
+
+	// Require a signature of the module digest by one of the signature keys
+	RequireSignature bool `koanf:"require_signature"`
+
+	// Base64 ed25519 public keys trusted to sign modules
+	SignatureKeys []string `koanf:"signature_keys"`
+}
+
+// PolicyWebhookConfig holds the external admission evaluator
+type PolicyWebhookConfig struct {
+	// Endpoint receiving a POST per admission and answering {"allowed": bool, "reasons": [...]}
+	URL string `koanf:"url"`
+
+	// Timeout of a single request
+	Timeout time.Duration `koanf:"timeout"`
+
+	// Admit functions when the webhook cannot be reached or fails
+	FailOpen bool `koanf:"fail_open"`
+
+	// Extra HTTP headers, such as authorization
+	Headers map[string]string `koanf:"headers"`
+}
+
+// VulnerabilityPolicyConfig holds the check of the packages a version was built from
+// against vulnerability advisories, done before a version is loaded
+type VulnerabilityPolicyConfig struct {
+	// What to do with vulnerable versions: off, warn (load and log) or enforce (refuse)
+	Mode string `koanf:"mode"`
+
+	// Lowest severity acted on: low, moderate, high or critical
+	Severity string `koanf:"severity"`
+
+	// File or directory of advisories in the OSV format, checked instead of the OSV API
+	AdvisoryDB string `koanf:"advisory_db"`
+
+	// Base URL of the OSV API, used when no advisory database is set
+	OSVURL string `koanf:"osv_url"`
+
+	// Timeout of a single OSV API request
+	Timeout time.Duration `koanf:"timeout"`
+
+	// Load versions when advisories cannot be fetched
+	FailOpen bool `koanf:"fail_open"`
+
+	// Advisories accepted for some functions, recorded in the audit log when used
+	Overrides []VulnerabilityOverride `koanf:"overrides"`
+}
+
+// VulnerabilityOverride accepts an advisory for the functions matching a pattern
+type VulnerabilityOverride struct {
+	// Advisory ID or alias, such as GHSA-xxxx-xxxx-xxxx or CVE-2024-1234
+	Advisory string `koanf:"advisory"`
+
+	// namespace/name pattern of the functions it applies to, such as team-*/* (empty
+	// matches every function)
+	Function string `koanf:"function"`
+
+	// Why the advisory is accepted
+	Reason string `koanf:"reason"`
+}
+
+// Validate checks the mode, the severity and the overrides.
+func (c VulnerabilityPolicyConfig) Validate() error {
+	switch c.Mode {
+	case "", "off", "warn", "enforce":
+	default:
+		return fmt.Errorf("unknown mode %q (expected off, warn or enforce)", c.Mode)
+	}
+	switch c.Severity {
+	case "", "low", "moderate", "high", "critical":
+	default:
+		return fmt.Errorf("unknown severity %q (expected low, moderate, high or critical)", c.Severity)
+	}
+	for i, override := range c.Overrides {
+		if override.Advisory == "" {
+			return fmt.Errorf("override %d: advisory is required", i+1)
+		}
+		if override.Reason == "" {
+			return fmt.Errorf("override %d: reason is required", i+1)
+		}
+	}
+	return nil
+}
+
+// Validate checks that signatures can be verified when they are required.
+func (c PolicyConfig) Validate() error {
+	if c.Rules.RequireSignature && len(c.Rules.SignatureKeys) == 0 {
+		return fmt.Errorf("rules.require_signature needs at least one signature key")
+	}
+	if err := c.Vulnerabilities.Validate(); err != nil {
+		return fmt.Errorf("vulnerabilities: %w", err)
+	}
+	return nil
+}
+
+// HAConfig pairs engines on one host. The engine holding the lock file serves requests;
+// the others wait as standbys with the functions of the active engine loaded, and one of
+// them takes over the socket and listeners when the active engine exits.
+type HAConfig struct {
+	// Lock file shared by the paired engines (empty runs the engine on its own)
+	LockFile string `koanf:"lock_file"`
+
+	// How often a standby tries to take over and loads what the active engine runs
+	SyncInterval time.Duration `koanf:"sync_interval"`
+}
+
+// Validate checks the standby sync interval.
+func (c HAConfig) Validate() error {
+	if c.LockFile != "" && c.SyncInterval <= 0 {
+		return fmt.Errorf("sync_interval must be positive")
+	}
+	return nil
+}
+
+// EgressConfig holds the egress rules applied to the HTTP requests of every function
+type EgressConfig struct {
+	// Host patterns and CIDR blocks, with an optional :port, that functions may never
+	// connect to, whatever their allowed_urls
+	Deny []string `koanf:"deny"`
+}
+
+// Validate checks that every deny rule parses.
+func (c EgressConfig) Validate() error {
+	_, err := egress.ParseRules(c.Deny)
+	return err
+}
+
+// RedactionConfig sets the config keys treated as secrets
+type RedactionConfig struct {
+	// Parts of config keys, matched ignoring case, whose values are secrets
+	// (empty uses SECRET, TOKEN, PASSWORD, PASSWD, KEY, CREDENTIAL and PRIVATE)
+	Patterns []string `koanf:"patterns"`
+}
+
+// Validate checks that no pattern is empty, which would mask every value.
+func (c RedactionConfig) Validate() error {
+	for i, pattern := range c.Patterns {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("pattern %d is empty", i+1)
+		}
+	}
+	return nil
+}
+
+// ProxyConfig sets the proxies of function HTTP requests, remote registries and module
+// downloads. Left empty, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+// apply.
+type ProxyConfig struct {
+	// Proxy URL of http:// requests
+	HTTP string `koanf:"http"`
+
+	// Proxy URL of https:// requests
+	HTTPS string `koanf:"https"`
+
+	// Comma separated hosts, domains, addresses and CIDR blocks reached without a proxy
+	NoProxy string `koanf:"no_proxy"`
+}
+
+// Settings returns the proxies as proxy settings.
+func (c ProxyConfig) Settings() proxy.Settings {
+	return proxy.Settings{HTTP: c.HTTP, HTTPS: c.HTTPS, NoProxy: c.NoProxy}
+}
+
+// Validate checks the proxy URLs.
+func (c ProxyConfig) Validate() error {
+	return c.Settings().Validate()
+}
+
+// BuildConfig holds the settings of function builds
+type BuildConfig struct {
+	// Forbid network access during builds: Go modules, Cargo, npm and pip only use
+	// vendored or cached dependencies
+	Offline bool `koanf:"offline"`
+}
+
+// IdempotencyConfig holds how long the responses of load and build requests are kept
+// for retries with the same Idempotency-Key
+type IdempotencyConfig struct {
+	// How long a response is replayed after the first request (0 disables idempotency keys)
+	Window time.Duration `koanf:"window"`
+
+	// Maximum number of keys remembered; the ones closest to expiring are dropped first
+	MaxKeys int `koanf:"max_keys"`
+}
+
+// Validate checks the window and the number of keys.
+func (c IdempotencyConfig) Validate() error {
+	if c.Window < 0 {
+		return fmt.Errorf("window must not be negative")
+	}
+	if c.Window > 0 && c.MaxKeys < 1 {
+		return fmt.Errorf("max_keys must be at least 1")
+	}
+	return nil
+}
+
+// MetricsConfig selects the collector of call metrics
+type MetricsConfig struct {
+	// memory reports metrics in the engine status, prometheus also serves them on
+	// GET /metrics of the admin API and server.metrics_addr, and none disables them
+	Collector string `koanf:"collector"`
+}
+
+// Validate checks the collector name.
+func (c MetricsConfig) Validate() error {
+	switch c.Collector {
+	case "memory", "prometheus", "none":
+		return nil
+	default:
+		return fmt.Errorf("collector must be memory, prometheus or none, got %q", c.Collector)
+	}
+}
+
+// WatchdogConfig holds the thresholds of the engine resource watchdog
+type WatchdogConfig struct {
+	// Sample resource usage and write a diagnostic bundle when a threshold is exceeded
+	Enabled bool `koanf:"enabled"`
+
+	// How often resource usage is sampled
+	Interval time.Duration `koanf:"interval"`
+
+	// Resident memory of the engine process in bytes (0 means no limit)
+	MaxRSS int64 `koanf:"max_rss"`
+
+	// Number of goroutines (0 means no limit)
+	MaxGoroutines int `koanf:"max_goroutines"`
+
+	// Bytes held by the block and index caches of the registry database (0 means no limit)
+	MaxCache int64 `koanf:"max_cache"`
+
+	// Minimum time between two bundles, so a lasting breach does not fill the disk
+	Cooldown time.Duration `koanf:"cooldown"`
+
+	// Directory bundles are written to (empty means diagnostics under the registry directory)
+	Dir string `koanf:"dir"`
+
+	// Number of bundles kept; older ones are removed
+	MaxBundles int `koanf:"max_bundles"`
+}
+
+// Validate checks the sampling interval and that no threshold is negative.
+func (c WatchdogConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if c.MaxRSS < 0 || c.MaxGoroutines < 0 || c.MaxCache < 0 || c.Cooldown < 0 {
+		return fmt.Errorf("thresholds and cooldown must not be negative")
+	}
+	if c.MaxBundles < 1 {
+		return fmt.Errorf("max_bundles must be at least 1")
+	}
+	return nil
+}
+
+// UsageConfig holds usage metering and quota configuration
+type UsageConfig struct {
+	// How often usage counters are written to the registry database
+	PersistInterval time.Duration `koanf:"persist_interval"`
+
+	// Quota of namespaces without one of their own
+	DefaultQuota QuotaConfig `koanf:"default_quota"`
+
+	// Quotas of single namespaces, replacing the default quota
+	Quotas map[string]QuotaConfig `koanf:"quotas"`
+}
+
+// QuotaConfig limits the usage of a namespace per UTC day and month (0 means no limit)
+type QuotaConfig struct {
+	DailyInvocations   int64   `koanf:"daily_invocations"`
+	MonthlyInvocations int64   `koanf:"monthly_invocations"`
+	DailySeconds       float64 `koanf:"daily_seconds"`
+	MonthlySeconds     float64 `koanf:"monthly_seconds"`
+}
+
+// Validate checks that usage is persisted and that no quota is negative.
+func (c UsageConfig) Validate() error {
+	if c.PersistInterval <= 0 {
+		return fmt.Errorf("persist_interval must be positive")
+	}
+	if err := c.DefaultQuota.validate(); err != nil {
+		return fmt.Errorf("default_quota: %w", err)
+	}
+	for namespace, quota := range c.Quotas {
+		if err := quota.validate(); err != nil {
+			return fmt.Errorf("quotas.%s: %w", namespace, err)
+		}
+	}
+	return nil
+}
+
+func (c QuotaConfig) validate() error {
+	if c.DailyInvocations < 0 || c.MonthlyInvocations < 0 || c.DailySeconds < 0 || c.MonthlySeconds < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	return nil
 }
 
 // PluginManagerConfig holds plugin manager configuration
@@ -75,6 +910,34 @@ type PluginManagerConfig struct {
 
 	// How often to run the cleanup routine
 	CleanupInterval time.Duration `koanf:"cleanup_interval"`
+
+	// Per-function instance pool autoscaling
+	Pool PoolConfig `koanf:"pool"`
+}
+
+// PoolConfig holds plugin pool autoscaling configuration
+type PoolConfig struct {
+	// Minimum number of warm instances per loaded function
+	MinInstances int `koanf:"min_instances"`
+
+	// Maximum number of instances per function serving calls concurrently
+	MaxInstances int `koanf:"max_instances"`
+
+	// How often the call rate is sampled and idle instances are scaled down
+	ScaleInterval time.Duration `koanf:"scale_interval"`
+
+	// Consecutive instance crashes tolerated before the circuit breaker opens
+	MaxRestarts int `koanf:"max_restarts"`
+
+	// Delay before replacing a crashed instance, doubled for each consecutive crash
+	RestartBackoff time.Duration `koanf:"restart_backoff"`
+
+	// Upper bound on the restart delay
+	MaxRestartBackoff time.Duration `koanf:"max_restart_backoff"`
+
+	// Longest a call waits for a free instance before it is turned away with a 503;
+	// zero waits until the call times out
+	MaxQueueWait time.Duration `koanf:"max_queue_wait"`
 }
 
 // DefaultConfig returns a configuration with sensible defaults
@@ -88,25 +951,128 @@ func DefaultConfig() *Config {
 		Engine: EngineConfig{
 			DefaultTimeout:   30 * time.Second,
 			LogStoreCapacity: 1000,
+			LogLevel:         "info",
+			LogTrimInterval:  time.Minute,
+			AuditRetention:   30 * 24 * time.Hour,
+			DeadLetter: DeadLetterConfig{
+				Enabled:    false,
+				MaxEntries: 100,
+			},
+			Spool: SpoolConfig{
+				Threshold: 8 << 20,
+			},
+			LogShipping: LogShippingConfig{
+				BufferSize:    10000,
+				BatchSize:     500,
+				FlushInterval: 2 * time.Second,
+				MaxRetries:    3,
+			},
+			LogFiles: LogFilesConfig{
+				Enabled:  false,
+				MaxSize:  10 << 20,
+				MaxFiles: 5,
+				MaxAge:   7 * 24 * time.Hour,
+			},
 			CircuitBreaker: CircuitBreakerConfig{
 				FailureThreshold: 5,
 				ResetTimeout:     30 * time.Second,
+				Granularity:      "function",
+			},
+			Notifications: NotificationsConfig{
+				ErrorSamples: 5,
+				Timeout:      5 * time.Second,
+			},
+			Policy: PolicyConfig{
+				Webhook: PolicyWebhookConfig{
+					Timeout: 5 * time.Second,
+				},
+				Vulnerabilities: VulnerabilityPolicyConfig{
+					Mode:     "off",
+					Severity: "critical",
+					OSVURL:   "https://api.osv.dev",
+					Timeout:  10 * time.Second,
+				},
+			},
+			Usage: UsageConfig{
+				PersistInterval: 30 * time.Second,
+			},
+			Metrics: MetricsConfig{
+				Collector: "memory",
+			},
+			Watchdog: WatchdogConfig{
+				Interval:      30 * time.Second,
+				MaxRSS:        2 << 30,
+				MaxGoroutines: 10000,
+				MaxCache:      1 << 30,
+				Cooldown:      15 * time.Minute,
+				MaxBundles:    5,
+			},
+			HA: HAConfig{
+				SyncInterval: 2 * time.Second,
+			},
+			Idempotency: IdempotencyConfig{
+				Window:  10 * time.Minute,
+				MaxKeys: 1000,
 			},
 			PluginManager: PluginManagerConfig{
 				TTL:             10 * time.Minute,
 				CleanupInterval: 1 * time.Minute,
+				Pool: PoolConfig{
+					MinInstances:      1,
+					MaxInstances:      4,
+					ScaleInterval:     5 * time.Second,
+					MaxRestarts:       5,
+					RestartBackoff:    100 * time.Millisecond,
+					MaxRestartBackoff: 30 * time.Second,
+				},
 			},
 		},
 		Server: ServerConfig{
-			SocketPath:  filepath.Join(homeDir, ".ignition", "engine.sock"),
-			HTTPAddr:    "localhost:8080",
-			RegistryDir: filepath.Join(homeDir, ".ignition", "registry"),
+			SocketPath:    ipc.DefaultPath(),
+			SocketEnabled: true,
+			HTTPAddr:      "localhost:8080",
+			CORSEnabled:   true,
+			RegistryDir:   filepath.Join(homeDir, ".ignition", "registry"),
+			Compression: CompressionConfig{
+				Enabled:        true,
+				MinSize:        1024,
+				MaxRequestSize: 32 << 20,
+			},
+		},
+		Registry: RegistryConfig{
+			MaxModuleSize:       64 << 20,
+			MaintenanceInterval: 1 * time.Hour,
+			IntegrityCheck:      IntegrityCheckConfig{Mode: "sample", SampleSize: 10},
+			Replication: ReplicationConfig{
+				QueueSize:  1000,
+				MaxRetries: 5,
+			},
+			Upstream: UpstreamConfig{
+				Timeout: time.Minute,
+			},
 		},
 	}
 }
 
+// ExpandHome replaces a leading ~/ in path with the home directory.
+func ExpandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, path[2:])
+		}
+	}
+	return path
+}
+
+// LoadEnvConfig loads configuration from defaults and environment variables only.
+// No config file is read or created, so it suits containers without a home directory.
+func LoadEnvConfig() (*Config, error) {
+	return LoadConfig("")
+}
+
 // LoadConfig loads configuration from the specified path and environment variables
 // If the config file doesn't exist, a default one will be created at the specified path.
+// An empty path skips the config file entirely.
 func LoadConfig(configPath string) (*Config, error) {
 	k := koanf.New(".")
 
@@ -118,17 +1084,14 @@ func LoadConfig(configPath string) (*Config, error) {
 	}
 
 	// Expand tilde in config path if needed
-	expandedPath := configPath
-	if strings.HasPrefix(configPath, "~/") {
-		homeDir, err := os.UserHomeDir()
-		if err == nil {
-			expandedPath = filepath.Join(homeDir, configPath[2:])
-		}
-	}
+	expandedPath := ExpandHome(configPath)
 
 	// Try to load from config file (if it exists)
 	configFileExists := false
-	if _, err := os.Stat(expandedPath); err == nil {
+	if configPath == "" {
+		// Environment-only configuration: never read or create a file
+		configFileExists = true
+	} else if _, err := os.Stat(expandedPath); err == nil {
 		configFileExists = true
 		if err := k.Load(file.Provider(expandedPath), yaml.Parser()); err != nil {
 			return nil, fmt.Errorf("failed to load config file: %w", err)
@@ -136,9 +1099,7 @@ func LoadConfig(configPath string) (*Config, error) {
 	}
 
 	// Load from environment variables
-	if err := k.Load(env.Provider(EnvPrefix, ".", func(s string) string {
-		return strings.Replace(strings.ToLower(strings.TrimPrefix(s, EnvPrefix)), "_", ".", -1)
-	}), nil); err != nil {
+	if err := k.Load(env.Provider(EnvPrefix, ".", envKeyMapper(k.Keys())), nil); err != nil {
 		return nil, fmt.Errorf("failed to load environment variables: %w", err)
 	}
 
@@ -149,15 +1110,75 @@ func LoadConfig(configPath string) (*Config, error) {
 		DecoderConfig: &mapstructure.DecoderConfig{
 			DecodeHook: mapstructure.ComposeDecodeHookFunc(
 				mapstructure.StringToTimeDurationHookFunc(),
-				mapstructure.StringToSliceHookFunc(","),
+				stringToSliceHook(","),
 			),
-			Result:      &config,
-			ErrorUnused: true,
+			// Environment variables are strings, so let them fill numeric and boolean fields
+			WeaklyTypedInput: true,
+			Result:           &config,
+			ErrorUnused:      true,
 		},
 	}); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if _, err := logging.ParseLogLevel(config.Engine.LogLevel); err != nil {
+		return nil, fmt.Errorf("invalid engine.log_level: %w", err)
+	}
+	if err := config.Server.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid server.listeners: %w", err)
+	}
+	if err := config.Engine.CircuitBreaker.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.circuit_breaker: %w", err)
+	}
+	if err := config.Engine.LogShipping.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.log_shipping: %w", err)
+	}
+	if err := config.Engine.Notifications.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.notifications: %w", err)
+	}
+	if err := config.Engine.Policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.policy: %w", err)
+	}
+	if err := config.Engine.Usage.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.usage: %w", err)
+	}
+	if err := config.Engine.Metrics.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.metrics: %w", err)
+	}
+	if config.Server.MetricsAddr != "" && config.Engine.Metrics.Collector != "prometheus" {
+		return nil, fmt.Errorf("invalid server.metrics_addr: requires engine.metrics.collector prometheus")
+	}
+	if err := config.Engine.Watchdog.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.watchdog: %w", err)
+	}
+	if err := config.Engine.Idempotency.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.idempotency: %w", err)
+	}
+	if err := config.Engine.HA.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.ha: %w", err)
+	}
+	if err := config.Engine.Egress.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.egress: %w", err)
+	}
+	if err := config.Engine.Redaction.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.redaction: %w", err)
+	}
+	if err := config.Engine.Proxy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid engine.proxy: %w", err)
+	}
+	if err := config.Registry.Encryption.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid registry.encryption: %w", err)
+	}
+	if err := config.Registry.Replication.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid registry.replication: %w", err)
+	}
+	if err := config.Registry.Upstream.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid registry.upstream: %w", err)
+	}
+	if err := config.Registry.IntegrityCheck.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid registry.integrity_check: %w", err)
+	}
+
 	// If the config file doesn't exist, create it with the default settings
 	if !configFileExists {
 		// Ensure the directory exists
@@ -196,6 +1217,39 @@ func LoadConfig(configPath string) (*Config, error) {
 	return &config, nil
 }
 
+// stringToSliceHook splits comma separated environment variables into slices of any
+// element type, such as the numeric uids of server.privileged_uids.
+func stringToSliceHook(sep string) mapstructure.DecodeHookFuncKind {
+	return func(f reflect.Kind, t reflect.Kind, data interface{}) (interface{}, error) {
+		if f != reflect.String || t != reflect.Slice {
+			return data, nil
+		}
+		raw := data.(string)
+		if raw == "" {
+			return []string{}, nil
+		}
+		return strings.Split(raw, sep), nil
+	}
+}
+
+// envKeyMapper maps environment variables to config keys. Keys contain underscores
+// themselves, so IGNITION_SERVER_SOCKET_PATH is matched against the known keys to
+// find server.socket_path; unknown variables fall back to one level per underscore.
+func envKeyMapper(keys []string) func(string) string {
+	known := make(map[string]string, len(keys))
+	for _, key := range keys {
+		known[strings.ReplaceAll(key, ".", "_")] = key
+	}
+
+	return func(s string) string {
+		name := strings.ToLower(strings.TrimPrefix(s, EnvPrefix))
+		if key, ok := known[name]; ok {
+			return key
+		}
+		return strings.ReplaceAll(name, "_", ".")
+	}
+}
+
 // structProvider is a provider that loads configuration from a struct
 type structProvider struct {
 	cfg interface{}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEnvConfig(t *testing.T) {
+	t.Setenv("IGNITION_SERVER_SOCKET_ENABLED", "false")
+	t.Setenv("IGNITION_SERVER_ADMIN_ADDR", ":9090")
+	t.Setenv("IGNITION_SERVER_REGISTRY_DIR", "/data/registry")
+	t.Setenv("IGNITION_SERVER_COMPRESSION_MIN_SIZE", "2048")
+	t.Setenv("IGNITION_ENGINE_PLUGIN_MANAGER_POOL_MAX_INSTANCES", "8")
+	t.Setenv("IGNITION_ENGINE_DEFAULT_TIMEOUT", "5s")
+	t.Setenv("IGNITION_SERVER_PRIVILEGED_UIDS", "0,1000")
+	t.Setenv("IGNITION_REGISTRY_REPLICATION_TOKEN", "standby-token")
+
+	cfg, err := LoadEnvConfig()
+	require.NoError(t, err)
+
+	assert.False(t, cfg.Server.SocketEnabled)
+	assert.Equal(t, ":9090", cfg.Server.AdminAddr)
+	assert.Equal(t, "/data/registry", cfg.Server.RegistryDir)
+	assert.Equal(t, 2048, cfg.Server.Compression.MinSize)
+	assert.Equal(t, 8, cfg.Engine.PluginManager.Pool.MaxInstances)
+	assert.Equal(t, 5*time.Second, cfg.Engine.DefaultTimeout)
+	assert.Equal(t, []uint32{0, 1000}, cfg.Server.PrivilegedUIDs)
+	assert.Equal(t, "standby-token", cfg.Registry.Replication.Token)
+}
+
+func TestLoadEnvConfigRejectsUnknownKeys(t *testing.T) {
+	t.Setenv("IGNITION_SERVER_SOCKET_PATHS", "/tmp/engine.sock")
+
+	_, err := LoadEnvConfig()
+	assert.Error(t, err)
+}
+
+func TestLoadConfigLogShippingSinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+engine:
+  log_shipping:
+    batch_size: 100
+    sinks:
+      - type: loki
+        url: http://loki:3100/loki/api/v1/push
+        namespaces: [prod]
+        labels:
+          env: production
+      - type: syslog
+`), 0o644))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	shipping := cfg.Engine.LogShipping
+	assert.Equal(t, 100, shipping.BatchSize)
+	assert.Equal(t, 2*time.Second, shipping.FlushInterval)
+	require.Len(t, shipping.Sinks, 2)
+	assert.Equal(t, []string{"prod"}, shipping.Sinks[0].Namespaces)
+	assert.Equal(t, map[string]string{"env": "production"}, shipping.Sinks[0].Labels)
+	assert.Equal(t, "syslog", shipping.Sinks[1].Type)
+
+	require.NoError(t, os.WriteFile(path, []byte("engine:\n  log_shipping:\n    sinks:\n      - type: loki\n"), 0o644))
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, "requires a url")
+}
+
+func TestLoadConfigNamespaceListeners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  http_addr: ""
+  listeners:
+    - addr: ":8080"
+      namespaces: [public, docs]
+`), 0o644))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Server.HTTPAddr)
+	require.Len(t, cfg.Server.Listeners, 1)
+	assert.Equal(t, []string{"public", "docs"}, cfg.Server.Listeners[0].Namespaces)
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  listeners:\n    - addr: \":8080\"\n"), 0o644))
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, "at least one namespace")
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  http_addr: \":8080\"\n  listeners:\n    - addr: \":8080\"\n      namespaces: [public]\n"), 0o644))
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, "already in use")
+}
+
+func TestRegistryEncryptionKeySources(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+
+	t.Setenv("IGNITION_REGISTRY_ENCRYPTION_KEY", encoded)
+	cfg, err := LoadEnvConfig()
+	require.NoError(t, err)
+	key, err := cfg.Registry.Encryption.LoadKey(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{7}, 32), key)
+
+	keyFile := filepath.Join(t.TempDir(), "registry.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(encoded+"\n"), 0600))
+	key, err = RegistryEncryptionConfig{KeyFile: keyFile}.LoadKey(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+
+	key, err = RegistryEncryptionConfig{KeyCommand: []string{"echo", encoded}}.LoadKey(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+
+	_, err = RegistryEncryptionConfig{Key: "c2hvcnQ="}.LoadKey(context.Background())
+	assert.ErrorContains(t, err, "32 bytes")
+
+	assert.Error(t, RegistryEncryptionConfig{Key: encoded, KeyFile: keyFile}.Validate())
+}
+
+func TestReplicationConfigValidate(t *testing.T) {
+	defaults := DefaultConfig().Registry.Replication
+	assert.NoError(t, defaults.Validate())
+
+	for _, target := range []string{"/mnt/replica", "https://standby:9443"} {
+		cfg := defaults
+		cfg.Target = target
+		assert.NoError(t, cfg.Validate(), target)
+	}
+
+	cfg := defaults
+	cfg.Target = "ftp://standby"
+	assert.ErrorContains(t, cfg.Validate(), "must be a directory or an http(s) URL")
+
+	cfg.Target = "/mnt/replica"
+	cfg.QueueSize = 0
+	assert.ErrorContains(t, cfg.Validate(), "queue_size")
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+// redactedValue replaces secrets in a redacted configuration
+const redactedValue = "[REDACTED]"
+
+// secretKeys are the config keys of the engine's own secrets, such as the admin token and
+// the registry encryption key, redacted whatever engine.redaction.patterns holds
+var secretKeys = map[string]bool{
+	"admin_token": true,
+	"token":       true,
+	"key":         true,
+	"key_command": true,
+}
+
+// webhookKeys are the config keys holding webhooks, whose URLs often embed a secret in
+// their path, such as Slack incoming webhooks
+var webhookKeys = map[string]bool{
+	"webhook":  true,
+	"webhooks": true,
+}
+
+// Redacted returns the configuration keyed like the config file, so it can be attached to
+// bug reports. The engine's own tokens and keys are replaced, as are the values of keys
+// matching engine.redaction.patterns, header values and the credentials of URLs. Webhook URLs are
+// cut down to their scheme and host.
+func (c *Config) Redacted() map[string]interface{} {
+	r := redactor{secrets: logging.NewRedaction(c.Engine.Redaction.Patterns)}
+	redacted, _ := r.redact(reflect.ValueOf(*c), "", "").(map[string]interface{})
+	return redacted
+}
+
+// redactor replaces the secrets of a configuration.
+type redactor struct {
+	secrets *logging.Redaction
+}
+
+// redact converts v into maps, slices and plain values, replacing secrets. key is the
+// config key v is stored under, and parent the key of the struct or map holding it.
+func (r redactor) redact(v reflect.Value, key, parent string) interface{} {
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name := field.Tag.Get("koanf")
+			if name == "" || !field.IsExported() {
+				continue
+			}
+			out[name] = r.redact(v.Field(i), name, key)
+		}
+		return out
+	case reflect.Slice:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = r.redact(v.Index(i), key, parent)
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			name := fmt.Sprint(iter.Key().Interface())
+			// Headers carry authorization, so only their names are kept
+			if key == "headers" {
+				out[name] = redactedValue
+				continue
+			}
+			out[name] = r.redact(iter.Value(), name, key)
+		}
+		return out
+	case reflect.String:
+		return r.redactString(v.String(), key, parent)
+	}
+
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return v.Interface()
+}
+
+// redactString replaces secret values, the user info of URLs, such as proxy credentials,
+// and everything but the scheme and host of webhook URLs.
+func (r redactor) redactString(s, key, parent string) string {
+	if s == "" {
+		return s
+	}
+	if secretKeys[key] || r.secrets.IsSecretKey(key) {
+		return redactedValue
+	}
+	if !strings.Contains(s, "://") {
+		return s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		// A URL that does not parse may still carry credentials
+		return redactedValue
+	}
+	if webhookKeys[parent] && key == "url" {
+		return (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+	}
+	if u.User != nil {
+		u.User = url.User("REDACTED")
+		return u.String()
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// engineConfig collects the options passed to New
+type engineConfig struct {
+	socketPath string
+	httpAddr   string
+	logger     logging.Logger
+	options    *Options
+	parts      engineParts
+}
+
+// engineParts are the components an embedder supplies in place of the built-in ones.
+type engineParts struct {
+	registry      registry.Registry
+	pluginManager PluginManager
+	state         interfaces.StateManager
+	metrics       interfaces.MetricsCollector
+}
+
+// Option configures an engine created by New.
+type Option func(*engineConfig)
+
+// WithSocketPath serves the admin API on a Unix socket at path when the engine runs.
+// Without it the socket listener is disabled.
+func WithSocketPath(path string) Option {
+	return func(c *engineConfig) {
+		c.socketPath = path
+	}
+}
+
+// WithHTTPAddr serves function calls over HTTP on addr when the engine runs.
+func WithHTTPAddr(addr string) Option {
+	return func(c *engineConfig) {
+		c.httpAddr = addr
+	}
+}
+
+// WithLogger sends engine logs to logger. By default they go to standard output.
+func WithLogger(logger logging.Logger) Option {
+	return func(c *engineConfig) {
+		c.logger = logger
+	}
+}
+
+// WithOptions replaces the default engine options.
+func WithOptions(options *Options) Option {
+	return func(c *engineConfig) {
+		if options != nil {
+			c.options = options
+		}
+	}
+}
+
+// WithRegistry stores and pulls versions through reg instead of the built-in registry.
+// The registry directory still holds the engine database, with the audit log, usage
+// counters and dead letters, in engine.db. Admission policies are checked when versions are loaded rather than
+// pushed. Registry encryption, replication and upstream pulls wrap the built-in
+// registry, so they cannot be configured along with it.
+func WithRegistry(reg registry.Registry) Option {
+	return func(c *engineConfig) {
+		c.parts.registry = reg
+	}
+}
+
+// WithPluginManager keeps loaded plugins in manager instead of the built-in plugin
+// manager. Idle evictions made by manager are not published as events.
+func WithPluginManager(manager PluginManager) Option {
+	return func(c *engineConfig) {
+		c.parts.pluginManager = manager
+	}
+}
+
+// WithStateManager keeps state informed of every function the engine loads, reloads,
+// unloads or stops. The engine still answers state queries from its plugin manager.
+func WithStateManager(state interfaces.StateManager) Option {
+	return func(c *engineConfig) {
+		c.parts.state = state
+	}
+}
+
+// WithMetricsCollector records call metrics in metrics instead of the collector
+// selected by the engine options.
+func WithMetricsCollector(metrics interfaces.MetricsCollector) Option {
+	return func(c *engineConfig) {
+		c.parts.metrics = metrics
+	}
+}
+
+// New creates an engine keeping its registry in registryDir, built from the
+// components given as options and the built-in ones for the rest.
+func New(registryDir string, opts ...Option) (*Engine, error) {
+	cfg := engineConfig{options: DefaultEngineOptions()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.socketPath == "" {
+		cfg.options.WithSocket(false)
+	}
+	return newEngine(cfg.socketPath, cfg.httpAddr, registryDir, cfg.logger, cfg.options, cfg.parts)
+}
+
+// mirrorState applies a lifecycle event of a function to a state manager.
+func mirrorState(state interfaces.StateManager, pluginManager PluginManager, event events.Event) {
+	if state == nil {
+		return
+	}
+
+	switch event.Type {
+	case events.TypeLoaded, events.TypeReloaded:
+		config, _ := pluginManager.GetPluginConfig(GetFunctionKey(event.Namespace, event.Function))
+		state.ClearStoppedStatus(event.Namespace, event.Function)
+		state.MarkLoaded(event.Namespace, event.Function, event.Digest, config)
+	case events.TypeUnloaded:
+		state.MarkUnloaded(event.Namespace, event.Function)
+	case events.TypeStopped:
+		state.MarkStopped(event.Namespace, event.Function)
+	}
+}
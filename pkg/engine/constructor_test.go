@@ -0,0 +1,120 @@
+package engine_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/engine"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/engine/testutil"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	localRegistry "github.com/ignitionstack/ignition/pkg/registry/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingState keeps the digest of loaded functions and the stopped ones.
+type recordingState struct {
+	interfaces.StateManager
+
+	mu      sync.Mutex
+	loaded  map[string]string
+	stopped map[string]bool
+}
+
+func (s *recordingState) MarkLoaded(namespace, name, digest string, _ map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loaded[namespace+"/"+name] = digest
+}
+
+func (s *recordingState) MarkUnloaded(namespace, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.loaded, namespace+"/"+name)
+}
+
+func (s *recordingState) MarkStopped(namespace, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped[namespace+"/"+name] = true
+}
+
+func (s *recordingState) ClearStoppedStatus(namespace, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.stopped, namespace+"/"+name)
+}
+
+// recordingMetrics keeps the outcome of each call and the concurrency reported last.
+type recordingMetrics struct {
+	mu          sync.Mutex
+	executions  []bool
+	concurrency []int
+}
+
+func (m *recordingMetrics) RecordExecution(_ interfaces.FunctionKey, _ float64, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executions = append(m.executions, success)
+}
+
+func (m *recordingMetrics) RecordMemoryUsage(interfaces.FunctionKey, int64) {}
+
+func (m *recordingMetrics) RecordConcurrency(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.concurrency = append(m.concurrency, count)
+}
+
+func TestNewWithSuppliedComponents(t *testing.T) {
+	dir := t.TempDir()
+	db, err := localRegistry.OpenDatabase(dir)
+	require.NoError(t, err)
+	defer db.Close()
+	reg := localRegistry.NewLocalRegistry(dir, db)
+
+	state := &recordingState{loaded: map[string]string{}, stopped: map[string]bool{}}
+	metrics := &recordingMetrics{}
+	eng, err := engine.New(dir,
+		engine.WithLogger(logging.NewStdLogger(io.Discard)),
+		engine.WithRegistry(reg),
+		engine.WithStateManager(state),
+		engine.WithMetricsCollector(metrics))
+	require.NoError(t, err)
+	defer eng.Close()
+
+	ctx := context.Background()
+	digest, err := eng.LoadFunctionFromBytes(ctx, "ns", "echo", testutil.EchoModule, manifest.FunctionVersionSettings{}, nil)
+	require.NoError(t, err)
+	_, err = reg.Get("ns", "echo")
+	require.NoError(t, err, "the module is stored in the supplied registry")
+
+	output, err := eng.CallFunctionWithContext(ctx, "ns", "echo", testutil.EntrypointEcho, []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(output))
+	assert.Equal(t, []bool{true}, metrics.executions)
+	assert.Equal(t, []int{1, 0}, metrics.concurrency)
+
+	assert.Equal(t, digest, state.loaded["ns/echo"])
+	require.NoError(t, eng.StopFunction("ns", "echo"))
+	assert.True(t, state.stopped["ns/echo"])
+}
+
+func TestNewRefusesBuiltInRegistryOptionsWithSuppliedRegistry(t *testing.T) {
+	dir := t.TempDir()
+	db, err := localRegistry.OpenDatabase(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	options := engine.DefaultEngineOptions()
+	options.RegistryUpstream.URL = "http://shared:9090"
+	_, err = engine.New(dir,
+		engine.WithLogger(logging.NewStdLogger(io.Discard)),
+		engine.WithOptions(options),
+		engine.WithRegistry(localRegistry.NewLocalRegistry(dir, db)))
+	assert.ErrorContains(t, err, "upstream registry cannot be used with a supplied registry")
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ignitionstack/ignition/pkg/engine/dlq"
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// ErrDeadLetterDisabled is returned by dead letter operations when capture is turned off.
+var ErrDeadLetterDisabled = errors.New("dead letter capture is disabled")
+
+// captureDeadLetter stores a failed call. Calls to functions that are not loaded are
+// left out because the handler reloads and retries them, as are calls the caller
+// cancelled.
+func (e *Engine) captureDeadLetter(ctx context.Context, namespace, name, entrypoint string, payload []byte, callErr error) {
+	if e.deadLetters == nil {
+		return
+	}
+
+	reason, ok := deadLetterReason(ctx, callErr)
+	if !ok {
+		return
+	}
+
+	key := GetFunctionKey(namespace, name)
+	entry := dlq.Entry{
+		Entrypoint: entrypoint,
+		Payload:    string(payload),
+		Reason:     reason,
+		Error:      callErr.Error(),
+	}
+	if _, err := e.deadLetters.Add(key, entry); err != nil {
+		e.logger.Errorf("Failed to capture dead letter for %s: %v", key, err)
+		return
+	}
+	e.logStore.AddLog(key, logging.LevelWarning, fmt.Sprintf("Captured failed call to %s as dead letter (%s)", entrypoint, reason))
+}
+
+// deadLetterReason classifies a call failure, reporting false for failures that are not captured.
+func deadLetterReason(ctx context.Context, err error) (string, bool) {
+	switch {
+	case errors.Is(err, ErrFunctionNotLoaded),
+		domainerrors.Is(err, domainerrors.DomainFunction, domainerrors.CodeFunctionNotLoaded),
+		domainerrors.Is(err, domainerrors.DomainExecution, domainerrors.CodeExecutionCancelled),
+		errors.Is(ctx.Err(), context.Canceled):
+		return "", false
+	case domainerrors.Is(err, domainerrors.DomainExecution, domainerrors.CodeCircuitBreakerOpen):
+		return dlq.ReasonCircuitOpen, true
+	case domainerrors.Is(err, domainerrors.DomainExecution, domainerrors.CodeExecutionTimeout),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return dlq.ReasonTimeout, true
+	default:
+		return dlq.ReasonError, true
+	}
+}
+
+// DeadLetters returns the captured failed calls of a function, oldest first.
+func (e *Engine) DeadLetters(namespace, name string) ([]dlq.Entry, error) {
+	if e.deadLetters == nil {
+		return nil, ErrDeadLetterDisabled
+	}
+	return e.deadLetters.List(GetFunctionKey(namespace, name))
+}
+
+// RedriveDeadLetters replays captured calls in capture order. Entries that succeed are
+// removed; entries that fail again stay with their attempt count and error updated.
+// With no IDs every entry of the function is replayed.
+func (e *Engine) RedriveDeadLetters(ctx context.Context, namespace, name string, ids []string) (*types.RedriveResponse, error) {
+	entries, err := e.selectDeadLetters(namespace, name, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	key := GetFunctionKey(namespace, name)
+	response := &types.RedriveResponse{Results: []types.RedriveResult{}}
+
+	for _, entry := range entries {
+		result := types.RedriveResult{ID: entry.ID, Status: types.RedriveSucceeded}
+
+		// Call the function manager directly so a failed replay updates the entry instead of adding one
+		_, callErr := e.functionManager.CallFunction(ctx, namespace, name, entry.Entrypoint, []byte(entry.Payload))
+		if callErr == nil {
+			if _, err := e.deadLetters.Remove(key, entry.ID); err != nil {
+				return nil, err
+			}
+			response.Succeeded++
+		} else {
+			entry.Attempts++
+			entry.Error = callErr.Error()
+			if reason, ok := deadLetterReason(ctx, callErr); ok {
+				entry.Reason = reason
+			}
+			if err := e.deadLetters.Update(key, entry); err != nil && !errors.Is(err, dlq.ErrEntryNotFound) {
+				return nil, err
+			}
+
+			result.Status = types.RedriveFailed
+			result.Error = callErr.Error()
+			response.Failed++
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	return response, nil
+}
+
+// PurgeDeadLetters removes captured calls by ID, or every entry of the function when no IDs are given.
+func (e *Engine) PurgeDeadLetters(namespace, name string, ids []string) (int, error) {
+	if e.deadLetters == nil {
+		return 0, ErrDeadLetterDisabled
+	}
+	return e.deadLetters.Remove(GetFunctionKey(namespace, name), ids...)
+}
+
+// selectDeadLetters returns the entries with the given IDs, or all entries when none are given.
+func (e *Engine) selectDeadLetters(namespace, name string, ids []string) ([]dlq.Entry, error) {
+	entries, err := e.DeadLetters(namespace, name)
+	if err != nil || len(ids) == 0 {
+		return entries, err
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	selected := make([]dlq.Entry, 0, len(ids))
+	for _, entry := range entries {
+		if wanted[entry.ID] {
+			selected = append(selected, entry)
+			delete(wanted, entry.ID)
+		}
+	}
+	for _, id := range ids {
+		if wanted[id] {
+			return nil, fmt.Errorf("%w: %s", dlq.ErrEntryNotFound, id)
+		}
+	}
+
+	return selected, nil
+}
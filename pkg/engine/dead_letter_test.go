@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/engine/dlq"
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetterReason(t *testing.T) {
+	ctx := context.Background()
+
+	reason, ok := deadLetterReason(ctx, errors.New("boom"))
+	assert.True(t, ok)
+	assert.Equal(t, dlq.ReasonError, reason)
+
+	reason, ok = deadLetterReason(ctx, domainerrors.New(domainerrors.DomainExecution, domainerrors.CodeCircuitBreakerOpen, "open"))
+	assert.True(t, ok)
+	assert.Equal(t, dlq.ReasonCircuitOpen, reason)
+
+	reason, ok = deadLetterReason(ctx, domainerrors.New(domainerrors.DomainExecution, domainerrors.CodeExecutionTimeout, "slow"))
+	assert.True(t, ok)
+	assert.Equal(t, dlq.ReasonTimeout, reason)
+
+	// Unloaded functions are reloaded and retried by the handler, so they are not captured
+	_, ok = deadLetterReason(ctx, domainerrors.ErrFunctionNotLoaded)
+	assert.False(t, ok)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, ok = deadLetterReason(cancelled, errors.New("boom"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// callContext derives the context for a call from the X-Ignition-Timeout and
+// X-Ignition-Priority headers. The requested timeout is capped at the engine's
+// default timeout, which also applies when the header is absent.
+func (h *Handlers) callContext(r *http.Request) (context.Context, context.CancelFunc, error) {
+	timeout := h.engine.defaultTimeout
+	parent := r.Context()
+
+	if value := r.Header.Get(types.PriorityHeader); value != "" {
+		priority, err := components.ParsePriority(value)
+		if err != nil {
+			return nil, nil, NewBadRequestError(fmt.Sprintf("invalid %s header: %v", types.PriorityHeader, err))
+		}
+		parent = components.WithPriority(parent, priority)
+	}
+
+	if value := r.Header.Get(types.TimeoutHeader); value != "" {
+		requested, err := parseTimeoutHeader(value)
+		if err != nil {
+			return nil, nil, NewBadRequestError(err.Error())
+		}
+		if timeout <= 0 || requested < timeout {
+			timeout = requested
+		}
+	}
+
+	if timeout <= 0 {
+		ctx, cancel := context.WithCancel(parent)
+		return ctx, cancel, nil
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	return ctx, cancel, nil
+}
+
+// parseTimeoutHeader accepts a Go duration or a whole number of milliseconds.
+func parseTimeoutHeader(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		ms, msErr := strconv.ParseInt(value, 10, 64)
+		if msErr != nil {
+			return 0, fmt.Errorf("invalid %s header: %q", types.TimeoutHeader, value)
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s header: timeout must be positive", types.TimeoutHeader)
+	}
+	return timeout, nil
+}
+
+// setExecutionTime reports the time spent since start on the response.
+func setExecutionTime(w http.ResponseWriter, start time.Time) {
+	ms := float64(time.Since(start).Microseconds()) / 1000
+	w.Header().Set(types.ExecutionTimeHeader, strconv.FormatFloat(ms, 'f', 3, 64))
+}
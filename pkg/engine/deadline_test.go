@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeoutHeader(t *testing.T) {
+	timeout, err := parseTimeoutHeader("250ms")
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, timeout)
+
+	timeout, err = parseTimeoutHeader("1500")
+	require.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, timeout)
+
+	for _, value := range []string{"0", "-1s", "soon"} {
+		_, err := parseTimeoutHeader(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestCallContextCapsTimeout(t *testing.T) {
+	h := &Handlers{engine: &Engine{defaultTimeout: time.Second}}
+
+	deadlineFor := func(header string) time.Duration {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		if header != "" {
+			r.Header.Set(types.TimeoutHeader, header)
+		}
+		ctx, cancel, err := h.callContext(r)
+		require.NoError(t, err)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		return time.Until(deadline)
+	}
+
+	assert.LessOrEqual(t, deadlineFor("100ms"), 100*time.Millisecond)
+	assert.Greater(t, deadlineFor("1h"), 500*time.Millisecond)
+	assert.LessOrEqual(t, deadlineFor("1h"), time.Second)
+	assert.LessOrEqual(t, deadlineFor(""), time.Second)
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(types.TimeoutHeader, "nope")
+	_, _, err := h.callContext(r)
+	assert.Error(t, err)
+}
@@ -0,0 +1,182 @@
+package dlq
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/internal/repository"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+)
+
+// keyPrefix namespaces dead letter entries inside the shared registry database
+const keyPrefix = "dlq:"
+
+// badgerStore keeps entries in Badger under dlq:<function>/<id>. IDs encode the
+// capture time, so iteration is chronological within each function.
+type badgerStore struct {
+	dbRepo     repository.DBRepository
+	maxEntries int
+	seq        atomic.Uint32
+}
+
+// NewBadgerStore creates a dead letter store backed by the given database.
+// Each function keeps at most maxEntries entries; zero means no cap.
+func NewBadgerStore(dbRepo repository.DBRepository, maxEntries int) Store {
+	return &badgerStore{
+		dbRepo:     dbRepo,
+		maxEntries: maxEntries,
+	}
+}
+
+func (s *badgerStore) Add(key interfaces.FunctionKey, entry Entry) (Entry, error) {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	entry.ID = s.newID(entry.Timestamp)
+
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to marshal dead letter entry: %w", err)
+	}
+
+	err = s.dbRepo.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(entryKey(key, entry.ID), value); err != nil {
+			return err
+		}
+		return s.evict(txn, key)
+	})
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to store dead letter entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+func (s *badgerStore) List(key interfaces.FunctionKey) ([]Entry, error) {
+	entries := []Entry{}
+	prefix := functionPrefix(key)
+
+	err := s.dbRepo.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var entry Entry
+				if err := json.Unmarshal(val, &entry); err != nil {
+					return fmt.Errorf("failed to unmarshal dead letter entry: %w", err)
+				}
+				entries = append(entries, entry)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letter entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (s *badgerStore) Update(key interfaces.FunctionKey, entry Entry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter entry: %w", err)
+	}
+
+	return s.dbRepo.Update(func(txn *badger.Txn) error {
+		k := entryKey(key, entry.ID)
+		if _, err := txn.Get(k); err != nil {
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return ErrEntryNotFound
+			}
+			return err
+		}
+		return txn.Set(k, value)
+	})
+}
+
+func (s *badgerStore) Remove(key interfaces.FunctionKey, ids ...string) (int, error) {
+	removed := 0
+
+	err := s.dbRepo.Update(func(txn *badger.Txn) error {
+		keys := make([][]byte, 0, len(ids))
+		if len(ids) == 0 {
+			keys = s.keys(txn, key)
+		}
+		for _, id := range ids {
+			k := entryKey(key, id)
+			if _, err := txn.Get(k); err == nil {
+				keys = append(keys, k)
+			}
+		}
+
+		for _, k := range keys {
+			if err := txn.Delete(k); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove dead letter entries: %w", err)
+	}
+
+	return removed, nil
+}
+
+// evict deletes the oldest entries of a function beyond the cap
+func (s *badgerStore) evict(txn *badger.Txn, key interfaces.FunctionKey) error {
+	if s.maxEntries <= 0 {
+		return nil
+	}
+
+	keys := s.keys(txn, key)
+	for i := 0; i < len(keys)-s.maxEntries; i++ {
+		if err := txn.Delete(keys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keys returns the keys of a function's entries, oldest first
+func (s *badgerStore) keys(txn *badger.Txn, key interfaces.FunctionKey) [][]byte {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	prefix := functionPrefix(key)
+	var keys [][]byte
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		keys = append(keys, it.Item().KeyCopy(nil))
+	}
+	return keys
+}
+
+// newID builds a unique ID whose lexical order matches capture order
+func (s *badgerStore) newID(ts time.Time) string {
+	id := binary.BigEndian.AppendUint64(nil, uint64(ts.UnixNano()))
+	id = binary.BigEndian.AppendUint32(id, s.seq.Add(1))
+	return hex.EncodeToString(id)
+}
+
+func functionPrefix(key interfaces.FunctionKey) []byte {
+	return []byte(keyPrefix + key.Encode() + "/")
+}
+
+func entryKey(key interfaces.FunctionKey, id string) []byte {
+	return append(functionPrefix(key), id...)
+}
@@ -0,0 +1,60 @@
+package dlq
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/internal/repository"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBadgerStoreCapAndRemove(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := NewBadgerStore(repository.NewBadgerDBRepository(db), 2)
+	key := interfaces.NewFunctionKey("ns", "fn")
+	other := interfaces.NewFunctionKey("ns", "fn2")
+
+	for _, payload := range []string{"first", "second", "third"} {
+		_, err := store.Add(key, Entry{Entrypoint: "run", Payload: payload, Reason: ReasonError, Error: "boom"})
+		require.NoError(t, err)
+	}
+	_, err = store.Add(other, Entry{Entrypoint: "run", Reason: ReasonTimeout})
+	require.NoError(t, err)
+
+	// The oldest entry is evicted once the cap is reached
+	entries, err := store.List(key)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "second", entries[0].Payload)
+	assert.Equal(t, "third", entries[1].Payload)
+
+	entries[0].Attempts = 1
+	require.NoError(t, store.Update(key, entries[0]))
+	assert.ErrorIs(t, store.Update(key, Entry{ID: "missing"}), ErrEntryNotFound)
+
+	removed, err := store.Remove(key, entries[1].ID, "missing")
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	entries, err = store.List(key)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].Attempts)
+
+	// Purging one function leaves the others alone
+	removed, err = store.Remove(key)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	entries, err = store.List(other)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
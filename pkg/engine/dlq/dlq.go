@@ -0,0 +1,45 @@
+// Package dlq keeps the payloads of failed function calls so they can be inspected and re-driven.
+package dlq
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+)
+
+// Reasons a call ends up in the dead letter store
+const (
+	ReasonError       = "error"
+	ReasonTimeout     = "timeout"
+	ReasonCircuitOpen = "circuit_open"
+)
+
+// ErrEntryNotFound is returned when a dead letter entry does not exist
+var ErrEntryNotFound = errors.New("dead letter entry not found")
+
+// Entry is a failed call captured for later inspection or re-drive
+type Entry struct {
+	ID         string    `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Entrypoint string    `json:"entrypoint"`
+	Payload    string    `json:"payload,omitempty"`
+	Reason     string    `json:"reason"`
+	Error      string    `json:"error"`
+	Attempts   int       `json:"attempts"`
+}
+
+// Store persists dead letter entries per function
+type Store interface {
+	// Add stores an entry, evicting the oldest entries of the function beyond the cap
+	Add(key interfaces.FunctionKey, entry Entry) (Entry, error)
+
+	// List returns the entries of a function, oldest first
+	List(key interfaces.FunctionKey) ([]Entry, error)
+
+	// Update replaces an existing entry
+	Update(key interfaces.FunctionKey, entry Entry) error
+
+	// Remove deletes entries by ID, or every entry of the function when no IDs are given
+	Remove(key interfaces.FunctionKey, ids ...string) (int, error)
+}
@@ -0,0 +1,225 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"runtime"
+	"strconv"
+	"sync"
+	"weak"
+)
+
+// policyKey is the context key of the policy checked by connections dialed with a context.
+type policyKey struct{}
+
+// proxyKey is the context key of the proxy selection of requests sent with a context.
+type proxyKey struct{}
+
+// ProxyFunc selects the proxy of a request, returning nil to connect directly.
+type ProxyFunc func(*http.Request) (*url.URL, error)
+
+// guard is the policy of a context and the function told about each decision.
+type guard struct {
+	policy *Policy
+	report func(Decision)
+
+	// Addresses of the proxies requests were allowed through, dialed without a check
+	proxies sync.Map
+}
+
+// WithPolicy returns a context whose HTTP connections are checked against policy once
+// Install has been called. report, which may be nil, is told about every decision.
+func WithPolicy(ctx context.Context, policy *Policy, report func(Decision)) context.Context {
+	return context.WithValue(ctx, policyKey{}, &guard{policy: policy, report: report})
+}
+
+// WithProxy returns a context whose HTTP requests select their proxy with proxy once
+// Install has been called, instead of from the environment.
+func WithProxy(ctx context.Context, proxy ProxyFunc) context.Context {
+	return context.WithValue(ctx, proxyKey{}, proxy)
+}
+
+var installOnce sync.Once
+
+// Install makes http.DefaultTransport, which plugins send their HTTP requests through,
+// use the proxy and check the policy of the request context before connecting. Hosts
+// are resolved first, so CIDR blocks apply to the addresses names resolve to, and only
+// an allowed address is dialed. Requests sent through a proxy are checked against the
+// host and port of their URL instead, as the proxy resolves names. Requests whose
+// context carries neither connect as before, and so does a default transport that was
+// replaced.
+//
+// Policies are only checked when connecting, so http.DefaultClient, which plugins send
+// their requests with, keeps the connections of each policy in a pool of its own: a
+// connection allowed by one policy is never reused by a request under another.
+func Install() {
+	installOnce.Do(func() {
+		transport, ok := http.DefaultTransport.(*http.Transport)
+		if !ok {
+			return
+		}
+		dial := transport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		transport.DialContext = guardedDial(dial, net.DefaultResolver)
+		transport.Proxy = guardedProxy(transport.Proxy)
+		if http.DefaultClient.Transport == nil {
+			http.DefaultClient.Transport = &policyTransport{base: transport}
+		}
+	})
+}
+
+// policyTransport sends the requests of contexts with a policy through a transport of
+// that policy, and other requests through base.
+type policyTransport struct {
+	base *http.Transport
+
+	// Transports keyed by the policy they serve, closed once the policy is collected
+	transports sync.Map
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	g, ok := req.Context().Value(policyKey{}).(*guard)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	return t.forPolicy(g.policy).RoundTrip(req)
+}
+
+// forPolicy returns the transport of a policy, a clone of base created on first use.
+func (t *policyTransport) forPolicy(policy *Policy) *http.Transport {
+	key := weak.Make(policy)
+	if transport, ok := t.transports.Load(key); ok {
+		return transport.(*http.Transport)
+	}
+
+	transport, loaded := t.transports.LoadOrStore(key, t.base.Clone())
+	if !loaded {
+		runtime.AddCleanup(policy, func(key weak.Pointer[Policy]) {
+			if transport, ok := t.transports.LoadAndDelete(key); ok {
+				transport.(*http.Transport).CloseIdleConnections()
+			}
+		}, key)
+	}
+	return transport.(*http.Transport)
+}
+
+// guardedProxy wraps the proxy selection of a transport with the proxy and the policy
+// check of the request context.
+func guardedProxy(defaultProxy ProxyFunc) ProxyFunc {
+	return func(req *http.Request) (*url.URL, error) {
+		selectProxy := defaultProxy
+		if proxy, ok := req.Context().Value(proxyKey{}).(ProxyFunc); ok {
+			selectProxy = proxy
+		}
+		if selectProxy == nil {
+			return nil, nil
+		}
+		proxyURL, err := selectProxy(req)
+		g, ok := req.Context().Value(policyKey{}).(*guard)
+		if err != nil || proxyURL == nil || !ok {
+			return proxyURL, err
+		}
+
+		// Names are resolved by the proxy, so only addresses in URLs match CIDR blocks
+		host := req.URL.Hostname()
+		addr, _ := netip.ParseAddr(host)
+		port, _ := strconv.Atoi(req.URL.Port())
+		if port == 0 {
+			port = defaultPort(req.URL.Scheme)
+		}
+		decision := g.policy.Decide(host, addr, port)
+		if g.report != nil {
+			g.report(decision)
+		}
+		if !decision.Allowed {
+			return nil, fmt.Errorf("%w: %s", ErrDenied, decision)
+		}
+		g.proxies.Store(proxyAddress(proxyURL), true)
+		return proxyURL, nil
+	}
+}
+
+// dialFunc dials a network address.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// guardedDial wraps dial with the policy check of the dialing context.
+func guardedDial(dial dialFunc, resolver *net.Resolver) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		g, ok := ctx.Value(policyKey{}).(*guard)
+		if !ok {
+			return dial(ctx, network, address)
+		}
+		if _, ok := g.proxies.Load(address); ok {
+			return dial(ctx, network, address)
+		}
+
+		host, portString, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		port, err := strconv.Atoi(portString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in %q", address)
+		}
+
+		addrs, err := resolve(ctx, resolver, host)
+		if err != nil {
+			return nil, err
+		}
+
+		// The first allowed address is dialed, so a name cannot resolve differently later
+		var denied Decision
+		for _, addr := range addrs {
+			decision := g.policy.Decide(host, addr, port)
+			if g.report != nil {
+				g.report(decision)
+			}
+			if decision.Allowed {
+				return dial(ctx, network, net.JoinHostPort(addr.String(), portString))
+			}
+			denied = decision
+		}
+		return nil, fmt.Errorf("%w: %s", ErrDenied, denied)
+	}
+}
+
+// proxyAddress returns the address a transport dials to reach a proxy.
+func proxyAddress(proxyURL *url.URL) string {
+	port := proxyURL.Port()
+	if port == "" {
+		port = strconv.Itoa(defaultPort(proxyURL.Scheme))
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+func defaultPort(scheme string) int {
+	switch scheme {
+	case "https":
+		return 443
+	case "socks5":
+		return 1080
+	default:
+		return 80
+	}
+}
+
+// resolve returns the addresses of host, which may be an address itself.
+func resolve(ctx context.Context, resolver *net.Resolver, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{addr}, nil
+	}
+	addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	return addrs, nil
+}
@@ -0,0 +1,176 @@
+// Package egress decides which hosts, addresses and ports the HTTP requests of
+// functions may connect to.
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrDenied is matched by every connection refused by a policy.
+var ErrDenied = errors.New("egress denied")
+
+// Rule matches connections by host pattern, such as *.example.com, or by CIDR block,
+// such as 10.0.0.0/8, optionally restricted to one port, such as api.example.com:443.
+type Rule struct {
+	raw    string
+	host   string
+	prefix netip.Prefix
+	port   int
+}
+
+// ParseRule parses a host pattern or CIDR block with an optional :port suffix. IPv6
+// blocks with a port are written in brackets, such as [fd00::/8]:443. A URL stands for
+// its host and port, so https://api.example.com is the same as api.example.com.
+func ParseRule(s string) (Rule, error) {
+	hostPort := s
+	if _, rest, ok := strings.Cut(s, "://"); ok {
+		hostPort, _, _ = strings.Cut(rest, "/")
+	}
+
+	rule := Rule{raw: s, host: hostPort}
+	if host, port, err := net.SplitHostPort(hostPort); err == nil {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return Rule{}, fmt.Errorf("invalid port in %q", s)
+		}
+		rule.host, rule.port = host, n
+	} else if strings.HasPrefix(hostPort, "[") && strings.HasSuffix(hostPort, "]") {
+		rule.host = hostPort[1 : len(hostPort)-1]
+	}
+	if rule.host == "" {
+		return Rule{}, fmt.Errorf("empty host in %q", s)
+	}
+
+	if strings.Contains(rule.host, "/") {
+		prefix, err := netip.ParsePrefix(rule.host)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid CIDR block in %q: %w", s, err)
+		}
+		rule.prefix, rule.host = prefix.Masked(), ""
+		return rule, nil
+	}
+	if _, err := path.Match(rule.host, ""); err != nil {
+		return Rule{}, fmt.Errorf("invalid host pattern in %q: %w", s, err)
+	}
+	rule.host = strings.ToLower(rule.host)
+	return rule, nil
+}
+
+// ParseRules parses every rule, failing on the first invalid one.
+func ParseRules(rules []string) ([]Rule, error) {
+	parsed := make([]Rule, 0, len(rules))
+	for _, s := range rules {
+		rule, err := ParseRule(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, rule)
+	}
+	return parsed, nil
+}
+
+// String returns the rule as it was written.
+func (r Rule) String() string {
+	return r.raw
+}
+
+// Matches reports whether a connection to addr on port, dialed for host, is covered
+// by the rule. Host patterns match host, CIDR blocks match addr.
+func (r Rule) Matches(host string, addr netip.Addr, port int) bool {
+	if r.port != 0 && r.port != port {
+		return false
+	}
+	if r.prefix.IsValid() {
+		return addr.IsValid() && r.prefix.Contains(addr.Unmap())
+	}
+	matched, _ := path.Match(r.host, strings.ToLower(host))
+	return matched
+}
+
+// HostPatterns returns the host patterns a plugin's own host check must let through for
+// rules to be enforced when connecting. CIDR blocks cover hosts that are only known
+// once resolved, so they let every host through.
+func HostPatterns(rules []string) []string {
+	patterns := make([]string, 0, len(rules))
+	for _, s := range rules {
+		rule, err := ParseRule(s)
+		switch {
+		case err != nil:
+			patterns = append(patterns, s)
+		case rule.prefix.IsValid():
+			return []string{"*"}
+		default:
+			patterns = append(patterns, rule.host)
+		}
+	}
+	return patterns
+}
+
+// Policy allows connections matching one of its allow rules and none of its deny rules.
+type Policy struct {
+	Allow []Rule
+	Deny  []Rule
+}
+
+// NewPolicy parses the allow rules of a function, to be checked along with deny rules
+// set for every function.
+func NewPolicy(allow []string, deny []Rule) (*Policy, error) {
+	rules, err := ParseRules(allow)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{Allow: rules, Deny: deny}, nil
+}
+
+// Decision is the verdict of a policy on a single connection.
+type Decision struct {
+	Host    string
+	Addr    netip.Addr
+	Port    int
+	Allowed bool
+
+	// Rule that allowed or denied the connection, empty when no allow rule matched
+	Rule string
+}
+
+func (d Decision) String() string {
+	target := net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+	if d.Addr.IsValid() && d.Addr.String() != d.Host {
+		target += " (" + d.Addr.String() + ")"
+	}
+
+	switch {
+	case d.Allowed:
+		return fmt.Sprintf("allowed %s by %s", target, d.Rule)
+	case d.Rule != "":
+		return fmt.Sprintf("denied %s by deny rule %s", target, d.Rule)
+	default:
+		return fmt.Sprintf("denied %s, no allow rule matches", target)
+	}
+}
+
+// Decide checks a connection to addr on port, dialed for host. Deny rules are checked
+// first, so they override allow rules.
+func (p *Policy) Decide(host string, addr netip.Addr, port int) Decision {
+	decision := Decision{Host: host, Addr: addr, Port: port}
+	for _, rule := range p.Deny {
+		if rule.Matches(host, addr, port) {
+			decision.Rule = rule.String()
+			return decision
+		}
+	}
+	for _, rule := range p.Allow {
+		if rule.Matches(host, addr, port) {
+			decision.Allowed = true
+			decision.Rule = rule.String()
+			return decision
+		}
+	}
+	return decision
+}
@@ -0,0 +1,164 @@
+package egress
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRule(t *testing.T) {
+	for _, s := range []string{"api.example.com", "*.example.com:443", "10.0.0.0/8", "10.0.0.0/8:5432",
+		"fd00::/8", "[fd00::/8]:443", "https://api.example.com/v1", "http://[::1]"} {
+		_, err := ParseRule(s)
+		assert.NoError(t, err, s)
+	}
+	for _, s := range []string{"", ":443", "api.example.com:0", "api.example.com:http", "10.0.0.0/33", "[a-"} {
+		_, err := ParseRule(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestPolicyDecide(t *testing.T) {
+	deny, err := ParseRules([]string{"169.254.0.0/16", "internal.example.com"})
+	require.NoError(t, err)
+	policy, err := NewPolicy([]string{"*.example.com:443", "10.0.0.0/8", "https://api.other.com"}, deny)
+	require.NoError(t, err)
+
+	public := netip.MustParseAddr("93.184.216.34")
+	tests := []struct {
+		host    string
+		addr    netip.Addr
+		port    int
+		allowed bool
+		rule    string
+	}{
+		{"api.example.com", public, 443, true, "*.example.com:443"},
+		{"API.Example.com", public, 443, true, "*.example.com:443"},
+		{"api.example.com", public, 80, false, ""},
+		{"api.other.com", public, 8443, true, "https://api.other.com"},
+		{"db.local", netip.MustParseAddr("10.1.2.3"), 5432, true, "10.0.0.0/8"},
+		{"db.local", netip.MustParseAddr("::ffff:10.1.2.3"), 5432, true, "10.0.0.0/8"},
+		{"db.local", netip.MustParseAddr("192.168.1.1"), 5432, false, ""},
+
+		// Deny rules override allow rules, whether they match the host or the address
+		{"internal.example.com", public, 443, false, "internal.example.com"},
+		{"metadata.example.com", netip.MustParseAddr("169.254.169.254"), 443, false, "169.254.0.0/16"},
+	}
+	for _, tt := range tests {
+		decision := policy.Decide(tt.host, tt.addr, tt.port)
+		assert.Equal(t, tt.allowed, decision.Allowed, "%s %s:%d", tt.host, tt.addr, tt.port)
+		assert.Equal(t, tt.rule, decision.Rule, "%s %s:%d", tt.host, tt.addr, tt.port)
+	}
+}
+
+func TestHostPatterns(t *testing.T) {
+	assert.Equal(t, []string{"api.example.com", "*.example.com"},
+		HostPatterns([]string{"https://api.example.com", "*.example.com:443"}))
+	assert.Equal(t, []string{"*"}, HostPatterns([]string{"api.example.com", "10.0.0.0/8"}))
+	assert.Empty(t, HostPatterns(nil))
+}
+
+func TestGuardedDial(t *testing.T) {
+	var dialed []string
+	dial := guardedDial(func(_ context.Context, _, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		return nil, nil
+	}, net.DefaultResolver)
+
+	policy, err := NewPolicy([]string{"10.0.0.0/8:443"}, nil)
+	require.NoError(t, err)
+	var decisions []Decision
+	ctx := WithPolicy(context.Background(), policy, func(d Decision) { decisions = append(decisions, d) })
+
+	_, err = dial(ctx, "tcp", "10.0.0.1:443")
+	require.NoError(t, err)
+	_, err = dial(ctx, "tcp", "10.0.0.1:80")
+	require.ErrorIs(t, err, ErrDenied)
+	assert.ErrorContains(t, err, "no allow rule matches")
+
+	// Contexts without a policy dial unchecked
+	_, err = dial(context.Background(), "tcp", "192.168.1.1:80")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"10.0.0.1:443", "192.168.1.1:80"}, dialed)
+	require.Len(t, decisions, 2)
+	assert.True(t, decisions[0].Allowed)
+	assert.False(t, decisions[1].Allowed)
+}
+
+func TestGuardedProxy(t *testing.T) {
+	proxyURL, err := url.Parse("http://proxy.internal:3128")
+	require.NoError(t, err)
+	selectProxy := guardedProxy(func(*http.Request) (*url.URL, error) { return proxyURL, nil })
+
+	policy, err := NewPolicy([]string{"api.example.com:443"}, nil)
+	require.NoError(t, err)
+	var decisions []Decision
+	ctx := WithPolicy(context.Background(), policy, func(d Decision) { decisions = append(decisions, d) })
+
+	// Requests through a proxy are checked against their URL
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/v1", nil).WithContext(ctx)
+	selected, err := selectProxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, proxyURL, selected)
+	req = httptest.NewRequest(http.MethodGet, "http://api.example.com/v1", nil).WithContext(ctx)
+	_, err = selectProxy(req)
+	require.ErrorIs(t, err, ErrDenied)
+	require.Len(t, decisions, 2)
+
+	// The proxy a request was allowed through is dialed without a check
+	var dialed []string
+	dial := guardedDial(func(_ context.Context, _, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		return nil, nil
+	}, net.DefaultResolver)
+	_, err = dial(ctx, "tcp", "proxy.internal:3128")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"proxy.internal:3128"}, dialed)
+	assert.Len(t, decisions, 2)
+
+	// The proxy of the context replaces the default one
+	direct := WithProxy(ctx, func(*http.Request) (*url.URL, error) { return nil, nil })
+	selected, err = selectProxy(httptest.NewRequest(http.MethodGet, "http://anywhere.com", nil).WithContext(direct))
+	require.NoError(t, err)
+	assert.Nil(t, selected)
+}
+
+func TestPolicyTransportKeepsConnectionsPerPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	base := &http.Transport{DialContext: guardedDial((&net.Dialer{}).DialContext, net.DefaultResolver)}
+	client := &http.Client{Transport: &policyTransport{base: base}}
+	send := func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	loopback, err := NewPolicy([]string{"127.0.0.0/8"}, nil)
+	require.NoError(t, err)
+	private, err := NewPolicy([]string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+
+	// The idle connection the first policy opened to the host is not reused by the second
+	require.NoError(t, send(WithPolicy(context.Background(), loopback, nil)))
+	require.ErrorIs(t, send(WithPolicy(context.Background(), private, nil)), ErrDenied)
+	require.NoError(t, send(WithPolicy(context.Background(), loopback, nil)))
+
+	// Requests without a policy share the base transport
+	require.NoError(t, send(context.Background()))
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// LoadFunctionFromBytes stores a wasm module in the registry and loads it, so programs
+// embedding the engine can run modules that were never built or pushed. Loading the
+// same module again reuses the stored version. It returns the module digest.
+func (e *Engine) LoadFunctionFromBytes(ctx context.Context, namespace, name string, wasmBytes []byte,
+	settings manifest.FunctionVersionSettings, config map[string]string) (string, error) {
+	sum := sha256.Sum256(wasmBytes)
+	digest := hex.EncodeToString(sum[:])
+
+	if err := e.registry.Push(namespace, name, wasmBytes, digest, "", settings); err != nil {
+		return "", fmt.Errorf("failed to store module: %w", err)
+	}
+	e.logStore.AddLog(GetFunctionKey(namespace, name), logging.LevelInfo,
+		fmt.Sprintf("Stored module from memory (%d bytes, %s)", len(wasmBytes), digest))
+
+	if err := e.LoadFunctionWithForce(ctx, namespace, name, registry.TruncateDigest(digest, 12), config, true); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// Close unloads every function and closes the registry database and log files.
+// The engine cannot be used afterwards.
+func (e *Engine) Close() error {
+	e.pluginManager.Shutdown()
+
+	e.notifier.Close()
+
+	var shipperErr, sinkErr error
+	if e.logShipper != nil {
+		shipperErr = e.logShipper.Close()
+	}
+	if e.logSink != nil {
+		sinkErr = e.logSink.Close()
+	}
+	return errors.Join(e.db.Close(), shipperErr, sinkErr)
+}
@@ -2,20 +2,35 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
-	"github.com/dgraph-io/badger/v4"
 	"github.com/ignitionstack/ignition/internal/repository"
 	"github.com/ignitionstack/ignition/internal/services"
+	"github.com/ignitionstack/ignition/pkg/engine/audit"
 	"github.com/ignitionstack/ignition/pkg/engine/components"
 	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/dlq"
+	"github.com/ignitionstack/ignition/pkg/engine/egress"
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
 	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/engine/logship"
+	"github.com/ignitionstack/ignition/pkg/engine/metrics"
+	"github.com/ignitionstack/ignition/pkg/engine/notify"
+	"github.com/ignitionstack/ignition/pkg/engine/policy"
+	"github.com/ignitionstack/ignition/pkg/engine/replication"
+	"github.com/ignitionstack/ignition/pkg/engine/usage"
 	"github.com/ignitionstack/ignition/pkg/manifest"
 	"github.com/ignitionstack/ignition/pkg/registry"
 	localRegistry "github.com/ignitionstack/ignition/pkg/registry/local"
+	"github.com/ignitionstack/ignition/pkg/registry/remote"
+	"github.com/ignitionstack/ignition/pkg/registry/upstream"
 	"github.com/ignitionstack/ignition/pkg/types"
 )
 
@@ -29,10 +44,22 @@ const (
 type Engine struct {
 	// Core dependencies
 	registry       registry.Registry
+	db             repository.DBRepository
+	registryDir    string
 	functionSvc    services.FunctionService
 	defaultTimeout time.Duration
 	logger         logging.Logger
-	logStore       *logging.FunctionLogStore
+	logStore       logging.LogStore
+	redaction      *logging.Redaction
+	logSink        *logging.FileSink
+	logShipper     *logship.Shipper
+	notifier       *notify.Notifier
+	coldStarts     *components.ColdStartTracker
+	panics         *panicCounters
+	clock          components.Clock
+	events         *events.Bus
+	lifecycle      *components.Lifecycle
+	metrics        interfaces.MetricsCollector
 
 	// Components
 	pluginManager   PluginManager
@@ -43,6 +70,56 @@ type Engine struct {
 	functionLoader   *FunctionLoader
 	functionExecutor *FunctionExecutor
 
+	// Compose service name to function mapping
+	services *ServiceRegistry
+
+	// Pipelines declared by compose files
+	pipelines *PipelineRegistry
+
+	// Versions to use when functions are reloaded automatically; absent means "latest"
+	reloadMu       sync.RWMutex
+	reloadPolicies map[FunctionKey]types.ReloadPolicy
+
+	// Most recent registry maintenance result
+	maintenanceMu   sync.RWMutex
+	lastMaintenance *registry.MaintenanceReport
+	lastIntegrity   *registry.IntegrityReport
+
+	// Output of the most recent builds by build ID
+	builds *buildLogStore
+
+	// When the resource watchdog last wrote a diagnostic bundle
+	watchdogMu         sync.Mutex
+	lastWatchdogBundle time.Time
+
+	// Durable log of admin operations
+	auditLog audit.Store
+
+	// Admission policies checked before versions are stored or loaded
+	admission *policy.Admission
+
+	// Encrypts the registry and its replicas in directories (nil when encryption is disabled)
+	registryCipher *localRegistry.Cipher
+
+	// Copies registry writes to the replication target (nil when replication is disabled)
+	replicator *replication.Replicator
+
+	// Transport of requests to remote registries and replication targets
+	transport http.RoundTripper
+
+	// Invocations counted per namespace, checked against quotas before each call
+	usage *usage.Meter
+
+	// Failed calls kept for re-drive (nil when capture is disabled)
+	deadLetters dlq.Store
+
+	// Downloads prebuilt modules referenced by URL or OCI reference
+	fetcher *remote.Fetcher
+
+	// Leadership of an engine paired with a standby (zero when HA is disabled)
+	haMu sync.RWMutex
+	ha   haState
+
 	// Server configuration
 	socketPath  string
 	httpAddr    string
@@ -57,6 +134,12 @@ type Engine struct {
 // Accepts optional logger and options parameters (nil values use defaults).
 func NewEngineWithOptions(socketPath, httpAddr string, registryDir string,
 	logger logging.Logger, options *Options) (*Engine, error) {
+	return newEngine(socketPath, httpAddr, registryDir, logger, options, engineParts{})
+}
+
+// newEngine creates an engine from the built-in components, replaced by those in parts.
+func newEngine(socketPath, httpAddr string, registryDir string,
+	logger logging.Logger, options *Options, parts engineParts) (*Engine, error) {
 	// Use defaults for nil parameters
 	if logger == nil {
 		logger = logging.NewStdLogger(os.Stdout)
@@ -65,31 +148,181 @@ func NewEngineWithOptions(socketPath, httpAddr string, registryDir string,
 		options = DefaultEngineOptions()
 	}
 
+	// Versions are checked against the admission policies before they are stored or loaded
+	admission, err := policy.New(options.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up admission policies: %w", err)
+	}
+
+	// The HTTP requests of functions are checked against their allowed_urls and these
+	// deny rules when they connect
+	egressDeny, err := egress.ParseRules(options.Egress.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid egress deny rules: %w", err)
+	}
+	egress.Install()
+
+	// Remote registries are reached through the configured proxy, or the environment's
+	transport := options.Proxy.Settings().Transport()
+
+	// Calls are recorded by the collector of the embedder, or else the configured one
+	collector := parts.metrics
+	if collector == nil {
+		collector, err = metrics.New(options.Metrics.Collector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A standby watches the active engine through the socket they share
+	if options.HA.LockFile != "" && !options.SocketEnabled {
+		return nil, errors.New("engine HA requires the socket listener")
+	}
+
+	// Registry writes are queued for replication from the start; the target is opened by Run
+	var replicator *replication.Replicator
+	if options.Replication.Target != "" {
+		if err := checkReplicationTarget(registryDir, options.Replication.Target); err != nil {
+			return nil, err
+		}
+		replicator = replication.New(options.Replication, logger)
+	}
+
 	// Setup the registry
-	registry, err := setupRegistry(registryDir)
+	var registry registry.Registry
+	var dbRepo repository.DBRepository
+	var cipher *localRegistry.Cipher
+	if parts.registry != nil {
+		registry = parts.registry
+		dbRepo, err = openSuppliedRegistry(registryDir, options)
+	} else {
+		registry, dbRepo, cipher, err = setupRegistry(registryDir, options, admission, replicator, transport)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup registry: %w", err)
 	}
 
+	// Upgrade the registry schema before anything reads it
+	if err := migrateRegistry(registry, logger); err != nil {
+		dbRepo.Close()
+		return nil, err
+	}
+
 	// Create function service
-	functionService := services.NewFunctionService()
+	functionService := services.NewFunctionServiceWithOptions(services.FunctionServiceOptions{Offline: options.Build.Offline})
+
+	// Notify webhooks about circuit breaker events when configured
+	var notifier *notify.Notifier
+	if len(options.Notifications.Webhooks) > 0 {
+		notifier, err = notify.New(options.Notifications, logger)
+		if err != nil {
+			dbRepo.Close()
+			return nil, fmt.Errorf("failed to set up notifications: %w", err)
+		}
+	}
+
+	// Persist function logs next to the registry when enabled
+	var logSink *logging.FileSink
+	if options.LogFilesEnabled {
+		sinkOptions := options.LogFiles
+		sinkOptions.Dir = filepath.Join(registryDir, "logs")
+		logSink, err = logging.NewFileSink(sinkOptions)
+		if err != nil {
+			dbRepo.Close()
+			return nil, err
+		}
+	}
+
+	// Forward function logs to external systems when sinks are configured
+	var logShipper *logship.Shipper
+	var forwarder logging.Forwarder
+	if len(options.LogShipping.Sinks) > 0 {
+		logShipper, err = logship.New(options.LogShipping, logger)
+		if err != nil {
+			if logSink != nil {
+				logSink.Close()
+			}
+			dbRepo.Close()
+			return nil, fmt.Errorf("failed to set up log shipping: %w", err)
+		}
+		forwarder = logShipper
+	}
 
 	// Create common components
-	logStore := logging.NewFunctionLogStore(options.LogStoreCapacity)
-	pluginManager := components.NewPluginManager(logger, components.PluginManagerSettings{
-		TTL:             options.PluginManagerSettings.TTL,
-		CleanupInterval: options.PluginManagerSettings.CleanupInterval,
+	logStore := logging.NewFunctionLogStoreWithOptions(logging.LogStoreOptions{
+		MaxEntries: options.LogStoreCapacity,
+		MinLevel:   options.LogLevel,
+		Retention:  options.LogRetention,
+		Sink:       logSink,
+		Forwarder:  forwarder,
 	})
-	circuitBreakerManager := components.NewCircuitBreakerManagerWithOptions(options.CircuitBreakerSettings)
+	clock := options.Clock
+	if clock == nil {
+		clock = components.SystemClock()
+	}
+	eventBus := events.NewBus()
+	lifecycle := components.NewLifecycle(publishPhaseChange(eventBus))
+	pluginManager := parts.pluginManager
+	if pluginManager == nil {
+		poolSettings := options.PluginManagerSettings.Pool
+		if recorder, ok := collector.(metrics.QueueRecorder); ok && poolSettings.OnQueueChange == nil {
+			poolSettings.OnQueueChange = recorder.RecordQueueDepth
+		}
+		pluginManager = components.NewPluginManager(logger, components.PluginManagerSettings{
+			TTL:             options.PluginManagerSettings.TTL,
+			CleanupInterval: options.PluginManagerSettings.CleanupInterval,
+			Clock:           clock,
+			LogStore:        logStore,
+			Pool:            poolSettings,
+			OnEvict: func(key FunctionKey) {
+				event := events.Event{Type: events.TypeUnloaded, Namespace: key.Namespace, Function: key.Name, Reason: "idle"}
+				// A function loaded again meanwhile stays running
+				_ = lifecycle.Transition(key, components.PhaseIdle, "idle")
+				eventBus.Publish(event)
+				mirrorState(parts.state, nil, event)
+			},
+		})
+	}
+	circuitBreakerSettings := options.CircuitBreakerSettings
+	circuitBreakerSettings.Clock = clock
+	circuitBreakerSettings.OnStateChange = chainStateChange(circuitBreakerSettings.OnStateChange, publishCircuitChange(eventBus))
+	circuitBreakerSettings.OnStateChange = chainStateChange(circuitBreakerSettings.OnStateChange, trackCircuitChange(lifecycle))
+	if notifier != nil {
+		circuitBreakerSettings.OnStateChange = chainStateChange(circuitBreakerSettings.OnStateChange, notifier.CircuitChanged)
+	}
+	circuitBreakerManager := components.NewCircuitBreakerManagerWithOptions(circuitBreakerSettings)
 
 	// Create function management components
 	functionLoader := NewFunctionLoader(registry, pluginManager, circuitBreakerManager, logStore, logger)
 	functionExecutor := NewFunctionExecutor(pluginManager, circuitBreakerManager, logStore, logger, options.DefaultTimeout)
+	functionExecutor.notifier = notifier
+	functionExecutor.breakerIgnores = options.CircuitBreakerSettings.Ignore
+	functionExecutor.breakerGranularity = options.CircuitBreakerSettings.Granularity
+	functionLoader.events = eventBus
+	functionLoader.state = parts.state
+	functionLoader.lifecycle = lifecycle
+	functionLoader.mapModules = options.MapModules
+	functionLoader.preferredVariants = options.PreferredVariants
+	functionLoader.metrics = collector
+	functionExecutor.metrics = collector
+	functionLoader.admission = admission
+	functionLoader.egressDeny = egressDeny
+	functionLoader.proxy = options.Proxy.Settings()
+	functionLoader.redaction = logging.NewRedaction(options.Redaction.Patterns)
+	functionExecutor.egress = functionLoader.Egress
+
+	// Both halves of a cold start are recorded in one tracker: the loader times the
+	// load phases and the executor the first call
+	coldStarts := components.NewColdStartTracker()
+	functionLoader.coldStarts = coldStarts
+	functionExecutor.coldStarts = coldStarts
 	functionManager := NewFunctionManager(functionLoader, functionExecutor, registry, functionService, options.DefaultTimeout)
 
 	// Assemble the engine
-	return &Engine{
+	engine := &Engine{
 		registry:         registry,
+		db:               dbRepo,
+		registryDir:      registryDir,
 		functionSvc:      functionService,
 		socketPath:       socketPath,
 		httpAddr:         httpAddr,
@@ -97,13 +330,113 @@ func NewEngineWithOptions(socketPath, httpAddr string, registryDir string,
 		initialized:      true,
 		defaultTimeout:   options.DefaultTimeout,
 		logStore:         logStore,
+		redaction:        functionLoader.redaction,
+		admission:        admission,
+		registryCipher:   cipher,
+		replicator:       replicator,
+		logSink:          logSink,
+		logShipper:       logShipper,
+		notifier:         notifier,
+		coldStarts:       coldStarts,
+		panics:           functionExecutor.panics,
+		clock:            clock,
+		events:           eventBus,
+		lifecycle:        lifecycle,
+		metrics:          collector,
 		pluginManager:    pluginManager,
 		circuitBreakers:  circuitBreakerManager,
 		functionLoader:   functionLoader,
 		functionExecutor: functionExecutor,
 		functionManager:  functionManager,
+		services:         NewServiceRegistry(),
+		pipelines:        NewPipelineRegistry(),
+		builds:           newBuildLogStore(),
+		auditLog:         audit.NewBadgerStore(dbRepo, options.AuditRetention),
+		usage:            usage.NewMeter(usage.NewBadgerStore(dbRepo), options.Usage, clock.Now),
+		fetcher:          remote.NewFetcher(&http.Client{Timeout: time.Minute, Transport: transport}, options.MaxModuleSize),
+		transport:        transport,
 		options:          options,
-	}, nil
+	}
+	if options.DeadLetterEnabled {
+		engine.deadLetters = dlq.NewBadgerStore(dbRepo, options.DeadLetterMaxEntries)
+	}
+
+	// Expose service discovery host functions to every loaded plugin
+	functionLoader.SetHostFunctions(engine.hostFunctions)
+
+	// Loads are checked against vulnerability advisories once the audit log is open
+	if err := engine.useVulnerabilityPolicy(); err != nil {
+		engine.Close()
+		return nil, fmt.Errorf("failed to set up the vulnerability policy: %w", err)
+	}
+
+	return engine, nil
+}
+
+// chainStateChange calls both circuit breaker callbacks, skipping a missing first one.
+func chainStateChange(first, second components.StateChangeFunc) components.StateChangeFunc {
+	if first == nil {
+		return second
+	}
+	return func(key FunctionKey, entrypoint, from, to string, failures int) {
+		first(key, entrypoint, from, to, failures)
+		second(key, entrypoint, from, to, failures)
+	}
+}
+
+// publishCircuitChange returns a circuit breaker callback that publishes openings and closings.
+func publishCircuitChange(bus *events.Bus) components.StateChangeFunc {
+	return func(key FunctionKey, entrypoint, _, to string, failures int) {
+		event := events.Event{Namespace: key.Namespace, Function: key.Name, Entrypoint: entrypoint}
+		switch to {
+		case components.StateOpen:
+			event.Type = events.TypeCircuitOpened
+			event.Reason = fmt.Sprintf("%d consecutive failures", failures)
+		case components.StateClosed:
+			event.Type = events.TypeCircuitClosed
+		default:
+			return
+		}
+		bus.Publish(event)
+	}
+}
+
+// trackCircuitChange returns a circuit breaker callback that marks a function failed
+// while its circuit is open and running again once it closes. Circuits of entrypoints
+// leave the function running, as its other entrypoints can still be called.
+func trackCircuitChange(lifecycle *components.Lifecycle) components.StateChangeFunc {
+	return func(key FunctionKey, entrypoint, _, to string, failures int) {
+		if entrypoint != "" {
+			return
+		}
+		switch to {
+		case components.StateOpen:
+			_ = lifecycle.Transition(key, components.PhaseFailed, fmt.Sprintf("circuit breaker open after %d consecutive failures", failures))
+		case components.StateClosed:
+			if lifecycle.Phase(key) == components.PhaseFailed {
+				_ = lifecycle.Transition(key, components.PhaseRunning, "circuit breaker closed")
+			}
+		}
+	}
+}
+
+// publishPhaseChange returns a lifecycle callback that publishes every state change.
+func publishPhaseChange(bus *events.Bus) components.PhaseChangeFunc {
+	return func(key FunctionKey, from, to components.FunctionPhase, reason string) {
+		bus.Publish(events.Event{
+			Type:      events.TypeStateChanged,
+			Namespace: key.Namespace,
+			Function:  key.Name,
+			Reason:    reason,
+			From:      string(from),
+			To:        string(to),
+		})
+	}
+}
+
+// Events returns the bus lifecycle events of functions are published on.
+func (e *Engine) Events() *events.Bus {
+	return e.events
 }
 
 // NewEngineWithConfig creates a new engine instance using a configuration object.
@@ -138,38 +471,148 @@ func NewEngineWithConfig(cfg *config.Config, logger logging.Logger) (*Engine, er
 	return engine, nil
 }
 
-func setupRegistry(registryDir string) (registry.Registry, error) {
-	opts := badger.DefaultOptions(filepath.Join(registryDir, "registry.db"))
-	opts.Logger = nil
+// setupRegistry opens the registry database and returns the registry along with
+// the database, which is shared with the audit log, and the cipher encrypting it
+// (nil when encryption is disabled). Writes are queued on replicator unless it is nil.
+func setupRegistry(registryDir string, options *Options, admission *policy.Admission,
+	replicator *replication.Replicator, transport http.RoundTripper) (registry.Registry, repository.DBRepository, *localRegistry.Cipher, error) {
+	registryOptions := []localRegistry.Option{localRegistry.WithModuleValidation(options.MaxModuleSize)}
+
+	// The key is read before the database is opened, so a missing key fails fast
+	key, err := options.RegistryEncryption.LoadKey(context.Background())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load registry encryption key: %w", err)
+	}
+	var cipher *localRegistry.Cipher
+	if key != nil {
+		cipher, err = localRegistry.NewCipher(key)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		registryOptions = append(registryOptions, localRegistry.WithEncryption(cipher))
+	}
+	if replicator != nil {
+		registryOptions = append(registryOptions, localRegistry.WithWriteObserver(replicator.Enqueue))
+	}
+	if options.RegistryUpstream.URL != "" {
+		up, err := upstream.New(options.RegistryUpstream.URL, options.RegistryUpstream.Token,
+			options.RegistryUpstream.Timeout, options.MaxModuleSize, transport)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		registryOptions = append(registryOptions, localRegistry.WithUpstream(up))
+	}
 
-	db, err := badger.Open(opts)
+	dbRepo, err := localRegistry.OpenDatabaseWithWait(registryDir, options.LockWait)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open registry database: %w", err)
+		return nil, nil, nil, err
 	}
 
-	dbRepo := repository.NewBadgerDBRepository(db)
-	return localRegistry.NewLocalRegistry(registryDir, dbRepo), nil
+	admit := func(namespace, name, digest string, settings manifest.FunctionVersionSettings, info *registry.ModuleInfo) error {
+		return admission.Admit(context.Background(), policy.Input{
+			Stage:     policy.StagePush,
+			Namespace: namespace,
+			Name:      name,
+			Digest:    digest,
+			Settings:  settings,
+			Module:    info,
+		})
+	}
+
+	registryOptions = append(registryOptions, localRegistry.WithAdmission(admit))
+	return localRegistry.NewLocalRegistry(registryDir, dbRepo, registryOptions...), dbRepo, cipher, nil
+}
+
+// openSuppliedRegistry opens the engine database kept next to a registry supplied by an
+// embedder, refusing the options that only apply to the built-in registry. The database
+// is stored apart from the built-in one, which the supplied registry may be using.
+func openSuppliedRegistry(registryDir string, options *Options) (repository.DBRepository, error) {
+	switch {
+	case options.RegistryEncryption.Enabled():
+		return nil, errors.New("registry encryption cannot be used with a supplied registry")
+	case options.Replication.Target != "":
+		return nil, errors.New("registry replication cannot be used with a supplied registry")
+	case options.RegistryUpstream.URL != "":
+		return nil, errors.New("an upstream registry cannot be used with a supplied registry")
+	}
+
+	db, err := localRegistry.OpenBadger(filepath.Join(registryDir, "engine.db"), options.LockWait)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open engine database: %w", err)
+	}
+	return repository.NewBadgerDBRepository(db), nil
 }
 
 func (e *Engine) GetConfig() *config.Config {
 	return e.config
 }
 
+// ColdStarts returns how long each phase of the recent cold starts of every
+// loaded function took: registry pull, compile, instantiate and first call.
+func (e *Engine) ColdStarts() map[FunctionKey]components.ColdStartStats {
+	return e.coldStarts.Stats()
+}
+
+// CompiledModules describes the compiled modules shared by the functions loading
+// the same digest with the same settings and config.
+func (e *Engine) CompiledModules() components.CompiledCacheStats {
+	return e.functionLoader.CompiledStats()
+}
+
 func (e *Engine) Start() error {
+	return e.Run(context.Background())
+}
+
+// Run starts the engine like Start and serves until a shutdown signal arrives or ctx
+// is done, for programs and tests that run the engine in-process.
+func (e *Engine) Run(ctx context.Context) error {
 	// Validate engine state
 	if err := e.validateState(); err != nil {
 		return err
 	}
 
 	// Create context for cleanup routines
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// Open the replication target before the server accepts writes
+	if e.replicator != nil {
+		target, err := e.openReplicationTarget(e.options.Replication.Target, e.options.Replication.Token)
+		if err != nil {
+			return fmt.Errorf("failed to open replication target: %w", err)
+		}
+		e.replicator.Start(ctx, e.registry, target)
+		defer func() {
+			if err := e.replicator.Close(); err != nil {
+				e.logger.Errorf("Failed to close replication target: %v", err)
+			}
+		}()
+	}
+
+	// A standby waits here until the active engine goes away
+	if err := e.awaitLeadership(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer e.releaseLeadership()
+
 	// Initialize components
 	e.initializeComponents(ctx)
 
 	// Set up and start the server
-	return e.startServer()
+	err := e.startServer(ctx)
+
+	// Wait for notifications and send the logs still queued for external sinks
+	e.notifier.Close()
+	e.flushUsage()
+	if e.logShipper != nil {
+		if closeErr := e.logShipper.Close(); closeErr != nil {
+			e.logger.Errorf("Failed to flush shipped logs: %v", closeErr)
+		}
+	}
+	return err
 }
 
 func (e *Engine) validateState() error {
@@ -182,14 +625,42 @@ func (e *Engine) validateState() error {
 func (e *Engine) initializeComponents(ctx context.Context) {
 	// Start the plugin manager's cleanup routine
 	e.pluginManager.StartCleanup(ctx)
+
+	// Verify stored modules, then start periodic registry maintenance
+	e.startIntegrityCheck()
+	e.startRegistryMaintenance(ctx)
+
+	// Start trimming expired function logs
+	e.startLogTrimming(ctx)
+
+	// Start persisting usage counters
+	e.startUsagePersistence(ctx)
+
+	// Start sampling memory, goroutines and caches
+	e.startWatchdog(ctx)
 }
 
-func (e *Engine) startServer() error {
+func (e *Engine) startServer(ctx context.Context) error {
 	handlers := NewHandlers(e, e.logger)
-	server := NewServer(e.socketPath, e.httpAddr, handlers, e.logger)
+	socketPath := e.socketPath
+	if !e.options.SocketEnabled {
+		socketPath = ""
+	}
+	server := NewServer(socketPath, e.httpAddr, handlers, e.logger).
+		WithAdminListener(e.options.AdminAddr, e.options.AdminToken).
+		WithNamespaceListeners(e.options.Listeners).
+		WithMetricsListener(e.options.MetricsAddr)
+
+	e.logger.Printf("Starting Ignition engine server on socket %s and HTTP %s", displayAddr(socketPath), displayAddr(e.httpAddr))
+	return server.Serve(ctx)
+}
 
-	e.logger.Printf("Starting Ignition engine server on socket %s and HTTP %s", e.socketPath, e.httpAddr)
-	return server.Start()
+// displayAddr renders a listener address for logs, marking disabled listeners
+func displayAddr(addr string) string {
+	if addr == "" {
+		return "(disabled)"
+	}
+	return addr
 }
 
 // LoadFunctionWithContext loads a function with the specified identifier and configuration.
@@ -204,7 +675,30 @@ func (e *Engine) LoadFunctionWithForce(ctx context.Context, namespace, name, ide
 
 // CallFunctionWithContext calls a function with the specified parameters.
 func (e *Engine) CallFunctionWithContext(ctx context.Context, namespace, name, entrypoint string, payload []byte) ([]byte, error) {
-	return e.functionManager.CallFunction(ctx, namespace, name, entrypoint, payload)
+	// Namespaces over their quota are refused before the call is queued
+	if err := e.usage.Check(namespace); err != nil {
+		return nil, err
+	}
+
+	// Chunks emitted without a caller streaming them are returned ahead of the output
+	var chunks *chunkBuffer
+	if chunkWriterFrom(ctx) == nil {
+		chunks = &chunkBuffer{}
+		ctx = WithChunkWriter(ctx, chunks.write)
+	}
+
+	start := e.clock.Now()
+	output, err := e.functionManager.CallFunction(ctx, namespace, name, entrypoint, payload)
+	e.meterCall(namespace, e.clock.Since(start), err)
+	if chunks != nil {
+		if emitted := chunks.close(); len(emitted) > 0 && err == nil {
+			output = append(emitted, output...)
+		}
+	}
+	if err != nil {
+		e.captureDeadLetter(ctx, namespace, name, entrypoint, payload, err)
+	}
+	return output, err
 }
 
 // UnloadFunction unloads a function, removing it from memory but preserving its configuration.
@@ -212,9 +706,21 @@ func (e *Engine) UnloadFunction(namespace, name string) error {
 	return e.functionManager.UnloadFunction(namespace, name)
 }
 
+// ScaleFunction keeps the given number of instances of a function, dispatching calls
+// round-robin over them. The scale survives reloads; zero restores autoscaling.
+func (e *Engine) ScaleFunction(namespace, name string, instances int) error {
+	return e.pluginManager.ScaleFunction(GetFunctionKey(namespace, name), instances)
+}
+
 // StopFunction stops a function and marks it as explicitly stopped to prevent auto-reload.
+// Any service names pointing at the function are removed.
 func (e *Engine) StopFunction(namespace, name string) error {
-	return e.functionManager.StopFunction(namespace, name)
+	if err := e.functionManager.StopFunction(namespace, name); err != nil {
+		return err
+	}
+
+	e.services.UnregisterFunction(namespace, name)
+	return nil
 }
 
 // IsLoaded checks if a function is currently loaded.
@@ -235,9 +741,37 @@ func (e *Engine) IsFunctionStopped(namespace, name string) bool {
 	return state.Stopped
 }
 
-// BuildFunction builds a function and stores it in the registry.
-func (e *Engine) BuildFunction(namespace, name, path, tag string, config manifest.FunctionManifest) (*types.BuildResult, error) {
-	return e.functionManager.BuildFunction(namespace, name, path, tag, config)
+// SetFunctionPriority sets the queue priority of calls to a function that do not set their own.
+func (e *Engine) SetFunctionPriority(namespace, name string, priority components.Priority) {
+	e.functionExecutor.SetPriority(GetFunctionKey(namespace, name), priority)
+}
+
+// FunctionPriority returns the queue priority of calls to a function that do not set their own.
+func (e *Engine) FunctionPriority(namespace, name string) components.Priority {
+	return e.functionExecutor.Priority(GetFunctionKey(namespace, name))
+}
+
+// SetFunctionMaxQueueWait sets how long calls to a function wait for a free instance
+// before they are turned away. Zero restores the default of the engine.
+func (e *Engine) SetFunctionMaxQueueWait(namespace, name string, wait time.Duration) {
+	e.functionExecutor.SetMaxQueueWait(GetFunctionKey(namespace, name), wait)
+}
+
+// FunctionMaxQueueWait returns the max queue wait set for a function, zero when it
+// uses the default of the engine.
+func (e *Engine) FunctionMaxQueueWait(namespace, name string) time.Duration {
+	wait, _ := e.functionExecutor.MaxQueueWait(GetFunctionKey(namespace, name))
+	return wait
+}
+
+// SetFunctionCallLogging sets which calls of a function write entries to its logs.
+func (e *Engine) SetFunctionCallLogging(namespace, name string, policy types.CallLogging) {
+	e.functionExecutor.SetCallLogging(GetFunctionKey(namespace, name), policy)
+}
+
+// FunctionCallLogging returns which calls of a function write entries to its logs.
+func (e *Engine) FunctionCallLogging(namespace, name string) types.CallLogging {
+	return e.functionExecutor.CallLogging(GetFunctionKey(namespace, name))
 }
 
 // ReassignTag reassigns a tag to a different function version.
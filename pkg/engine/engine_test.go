@@ -7,6 +7,7 @@ import (
 
 	"github.com/ignitionstack/ignition/pkg/engine/logging"
 	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -67,7 +68,7 @@ func TestBuildFunction(t *testing.T) {
 	}
 
 	// Test building function
-	result, err := engine.BuildFunction("test-namespace", "test-function", functionDir, "latest", config)
+	result, err := engine.BuildFunction("test-namespace", "test-function", functionDir, "latest", config, types.BuildOptions{})
 
 	// Since we don't have actual build implementation in test, we expect an error
 	assert.Error(t, err)
@@ -133,7 +134,7 @@ func TestIntegration(t *testing.T) {
 	}
 
 	// Build function
-	buildResult, err := engine.BuildFunction("test-namespace", "test-function", functionDir, "latest", config)
+	buildResult, err := engine.BuildFunction("test-namespace", "test-function", functionDir, "latest", config, types.BuildOptions{})
 	require.Error(t, err) // Expected error since we don't have actual build implementation
 
 	if buildResult != nil {
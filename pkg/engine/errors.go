@@ -78,6 +78,10 @@ func WrapEngineError(message string, err error) error {
 	return fmt.Errorf("%s: %w", message, err)
 }
 
+// RetryAfterDetail is the detail of a domain error holding the seconds after which a
+// rejected call may be retried, sent back in the Retry-After header.
+const RetryAfterDetail = "retry_after"
+
 func isDomainError(err error) bool {
 	var de *domainerrors.DomainError
 	return errors.As(err, &de)
@@ -105,6 +109,11 @@ var errorCodeStatusMap = map[domainerrors.Domain]map[domainerrors.Code]int{
 		domainerrors.CodeExecutionCancelled: http.StatusRequestTimeout,
 		domainerrors.CodeCircuitBreakerOpen: http.StatusServiceUnavailable,
 		domainerrors.CodeExecutionFailed:    http.StatusInternalServerError,
+		domainerrors.CodeExecutionPanicked:  http.StatusInternalServerError,
+		domainerrors.CodeExecutionTrap:      http.StatusInternalServerError,
+		domainerrors.CodeExecutionExit:      http.StatusInternalServerError,
+		domainerrors.CodeHostFunctionError:  http.StatusBadGateway,
+		domainerrors.CodeQueueTimeout:       http.StatusServiceUnavailable,
 	},
 	domainerrors.DomainRegistry: {
 		domainerrors.CodeRegistryNotFound: http.StatusNotFound,
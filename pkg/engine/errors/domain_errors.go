@@ -60,8 +60,31 @@ const (
 	CodeExecutionCancelled Code = "execution_cancelled"
 	CodeCircuitBreakerOpen Code = "circuit_breaker_open"
 	CodeExecutionFailed    Code = "execution_failed"
+	CodeExecutionPanicked  Code = "execution_panicked"
+
+	// The wasm runtime aborted the call, such as on unreachable or out of bounds memory access
+	CodeExecutionTrap Code = "execution_trap"
+
+	// The function exited or returned with a non-zero exit code without an error message
+	CodeExecutionExit Code = "execution_exit"
+
+	// A host function called by the function failed
+	CodeHostFunctionError Code = "host_function_error"
+
+	// The call waited for an instance of a saturated pool longer than its max queue wait
+	CodeQueueTimeout Code = "queue_timeout"
 )
 
+// CallFailureCodes are the codes failed calls of a function are classified with.
+var CallFailureCodes = []Code{
+	CodeExecutionFailed,
+	CodeExecutionTrap,
+	CodeExecutionExit,
+	CodeHostFunctionError,
+	CodeExecutionTimeout,
+	CodeExecutionPanicked,
+}
+
 // DomainError represents a domain-specific error.
 type DomainError struct {
 	// The error domain (engine, function, registry, etc.)
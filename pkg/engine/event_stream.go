@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+)
+
+// Events a slow client can fall behind by before it starts missing them
+const eventStreamBuffer = 256
+
+// handleEvents streams lifecycle events as newline-delimited JSON until the client
+// disconnects or the server shuts down. Repeated function=namespace/name and
+// type=<event type> parameters narrow the stream.
+func (h *Handlers) handleEvents(w http.ResponseWriter, r *http.Request) error {
+	filter, err := parseEventFilter(r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	sub := h.engine.events.Subscribe(filter, eventStreamBuffer)
+	defer sub.Close()
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.Errorf("Event stream cannot be flushed: %v", err)
+		return nil
+	}
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-h.streamsDone:
+			return nil
+		case event := <-sub.C:
+			if encoder.Encode(event) != nil || rc.Flush() != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// parseEventFilter reads the function and type parameters of an event stream request.
+func parseEventFilter(query url.Values) (events.Filter, error) {
+	var filter events.Filter
+	for _, function := range query["function"] {
+		namespace, name, ok := strings.Cut(function, "/")
+		if !ok || namespace == "" || name == "" {
+			return filter, NewBadRequestError(fmt.Sprintf("Invalid 'function' parameter %q, expected namespace/name", function))
+		}
+		filter.Functions = append(filter.Functions, GetFunctionKey(namespace, name))
+	}
+	for _, eventType := range query["type"] {
+		if !slices.Contains(events.Types, eventType) {
+			return filter, NewBadRequestError(fmt.Sprintf("Unknown event type %q, expected one of %s",
+				eventType, strings.Join(events.Types, ", ")))
+		}
+		filter.Types = append(filter.Types, eventType)
+	}
+	return filter, nil
+}
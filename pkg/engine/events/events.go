@@ -0,0 +1,152 @@
+// Package events fans engine lifecycle events, such as functions being loaded or
+// circuit breakers opening, out to subscribers like `ignition compose events`.
+package events
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+)
+
+// Event types.
+const (
+	TypeLoaded        = "loaded"
+	TypeReloaded      = "reloaded" // loaded again because its digest or config changed
+	TypeUnloaded      = "unloaded"
+	TypeStopped       = "stopped"
+	TypeCircuitOpened = "circuit_opened"
+	TypeCircuitClosed = "circuit_closed"
+	TypeStateChanged  = "state_changed" // moved from one lifecycle state to another
+
+	// Engine events, which name no function
+	TypeStandby  = "standby"  // waiting behind the active engine of an HA pair
+	TypeFailover = "failover" // a standby took over from the active engine
+	TypeWatchdog = "watchdog" // resource usage exceeded a watchdog threshold
+)
+
+// Types lists every event type.
+var Types = []string{TypeLoaded, TypeReloaded, TypeUnloaded, TypeStopped, TypeCircuitOpened, TypeCircuitClosed,
+	TypeStateChanged, TypeStandby, TypeFailover, TypeWatchdog}
+
+// Event describes a change in the lifecycle of a function.
+type Event struct {
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Function  string `json:"function"`
+	Digest    string `json:"digest,omitempty"`
+
+	// Entrypoint of a circuit_opened or circuit_closed event, when circuit breakers
+	// guard each entrypoint
+	Entrypoint string `json:"entrypoint,omitempty"`
+
+	Reason string    `json:"reason,omitempty"`
+	From   string    `json:"from,omitempty"` // previous state of a state_changed event
+	To     string    `json:"to,omitempty"`   // new state of a state_changed event
+	Time   time.Time `json:"time"`
+}
+
+// Filter selects the events a subscriber receives. Empty fields match everything.
+type Filter struct {
+	Functions []interfaces.FunctionKey
+	Types     []string
+}
+
+// Match reports whether the event passes the filter.
+func (f Filter) Match(event Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, event.Type) {
+		return false
+	}
+	if len(f.Functions) > 0 {
+		key := interfaces.FunctionKey{Namespace: event.Namespace, Name: event.Function}
+		if !slices.Contains(f.Functions, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// Bus delivers published events to every matching subscription. Publishing never
+// blocks: a subscriber whose buffer is full misses the event, which is counted.
+// A nil *Bus discards events.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// NewBus creates an event bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the events matching its filter on C until it is closed.
+type Subscription struct {
+	C <-chan Event
+
+	bus     *Bus
+	ch      chan Event
+	filter  Filter
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// Subscribe registers a subscription buffering up to buffer events.
+func (b *Bus) Subscribe(filter Filter, buffer int) *Subscription {
+	ch := make(chan Event, max(buffer, 1))
+	sub := &Subscription{C: ch, bus: b, ch: ch, filter: filter}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Publish stamps the event with the current time if it has none and delivers it.
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribers returns the number of open subscriptions.
+func (b *Bus) Subscribers() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Close unsubscribes and closes C. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+		close(s.ch)
+	})
+}
+
+// Dropped returns the number of events missed because the buffer was full.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusDeliversMatchingEvents(t *testing.T) {
+	bus := NewBus()
+	api := interfaces.FunctionKey{Namespace: "ns", Name: "api"}
+
+	all := bus.Subscribe(Filter{}, 10)
+	circuits := bus.Subscribe(Filter{Functions: []interfaces.FunctionKey{api}, Types: []string{TypeCircuitOpened}}, 10)
+	assert.Equal(t, 2, bus.Subscribers())
+
+	bus.Publish(Event{Type: TypeLoaded, Namespace: "ns", Function: "api"})
+	bus.Publish(Event{Type: TypeCircuitOpened, Namespace: "ns", Function: "worker"})
+	bus.Publish(Event{Type: TypeCircuitOpened, Namespace: "ns", Function: "api"})
+
+	assert.Len(t, all.C, 3)
+	assert.Len(t, circuits.C, 1)
+	event := <-circuits.C
+	assert.Equal(t, "api", event.Function)
+	assert.False(t, event.Time.IsZero())
+
+	// Closing unsubscribes and ends the channel
+	circuits.Close()
+	circuits.Close()
+	_, open := <-circuits.C
+	assert.False(t, open)
+	assert.Equal(t, 1, bus.Subscribers())
+}
+
+func TestBusDropsEventsForSlowSubscribers(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(Filter{}, 1)
+	defer sub.Close()
+
+	for range 3 {
+		bus.Publish(Event{Type: TypeUnloaded, Namespace: "ns", Function: "fn"})
+	}
+	assert.Len(t, sub.C, 1)
+	assert.Equal(t, int64(2), sub.Dropped())
+
+	var nilBus *Bus
+	nilBus.Publish(Event{Type: TypeLoaded})
+	assert.Equal(t, 0, nilBus.Subscribers())
+}
@@ -2,110 +2,406 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/egress"
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
 	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/engine/metrics"
+	"github.com/ignitionstack/ignition/pkg/engine/notify"
 	"github.com/ignitionstack/ignition/pkg/engine/utils"
+	"github.com/ignitionstack/ignition/pkg/types"
 )
 
 type FunctionExecutor struct {
 	pluginManager   PluginManager
 	circuitBreakers CircuitBreakerManager
-	logStore        *logging.FunctionLogStore
+	logStore        logging.LogStore
 	logger          logging.Logger
 	defaultTimeout  time.Duration
+	interceptors    *interceptorRegistry
+	notifier        *notify.Notifier
+	coldStarts      *components.ColdStartTracker
+	panics          *panicCounters
+	metrics         interfaces.MetricsCollector
+
+	// Codes of failed calls that do not count toward opening the circuit breaker
+	breakerIgnores []domainerrors.Code
+
+	// Whether calls are guarded by the breaker of their function or of their entrypoint
+	breakerGranularity string
+
+	// Egress policy and proxy of the loaded version of a function (nil leaves allowed_urls
+	// to Extism and proxies to the environment)
+	egress func(FunctionKey) (functionEgress, bool)
+
+	// Calls in progress, reported to the metrics collector
+	inFlight atomic.Int64
+
+	// Queue priority of calls that do not set their own; absent means normal
+	prioritiesMu sync.RWMutex
+	priorities   map[FunctionKey]components.Priority
+
+	// Max queue wait of calls to a function; absent uses the pool default
+	queueWaitsMu sync.RWMutex
+	queueWaits   map[FunctionKey]time.Duration
+
+	// Calls of a function that write entries to its logs; absent logs every call
+	callLogsMu sync.RWMutex
+	callLogs   map[FunctionKey]*callLogState
 }
 
+// callLogState counts the calls of a function to sample the ones it logs
+type callLogState struct {
+	policy types.CallLogging
+	calls  atomic.Uint64
+}
+
+// callUnloggedKey marks the context of a call whose entries are left out of the function's logs
+type callUnloggedKey struct{}
+
 func NewFunctionExecutor(pluginManager PluginManager, circuitBreakers CircuitBreakerManager,
-	logStore *logging.FunctionLogStore, logger logging.Logger, defaultTimeout time.Duration) *FunctionExecutor {
+	logStore logging.LogStore, logger logging.Logger, defaultTimeout time.Duration) *FunctionExecutor {
 	return &FunctionExecutor{
 		pluginManager:   pluginManager,
 		circuitBreakers: circuitBreakers,
 		logStore:        logStore,
 		logger:          logger,
 		defaultTimeout:  defaultTimeout,
+		interceptors:    newInterceptorRegistry(),
+		priorities:      make(map[FunctionKey]components.Priority),
+		queueWaits:      make(map[FunctionKey]time.Duration),
+		callLogs:        make(map[FunctionKey]*callLogState),
+		panics:          &panicCounters{},
+		metrics:         metrics.Nop{},
+	}
+}
+
+// SetPriority sets the queue priority of calls to a function that do not set their own.
+func (e *FunctionExecutor) SetPriority(functionKey FunctionKey, priority components.Priority) {
+	e.prioritiesMu.Lock()
+	defer e.prioritiesMu.Unlock()
+
+	if priority == components.PriorityNormal {
+		delete(e.priorities, functionKey)
+		return
 	}
+	e.priorities[functionKey] = priority
+}
+
+// Priority returns the queue priority of calls to a function that do not set their own.
+func (e *FunctionExecutor) Priority(functionKey FunctionKey) components.Priority {
+	e.prioritiesMu.RLock()
+	defer e.prioritiesMu.RUnlock()
+
+	if priority, ok := e.priorities[functionKey]; ok {
+		return priority
+	}
+	return components.PriorityNormal
+}
+
+// SetMaxQueueWait sets how long calls to a function wait for a free instance before
+// they are turned away. Zero restores the default of the engine.
+func (e *FunctionExecutor) SetMaxQueueWait(functionKey FunctionKey, wait time.Duration) {
+	e.queueWaitsMu.Lock()
+	defer e.queueWaitsMu.Unlock()
+
+	if wait <= 0 {
+		delete(e.queueWaits, functionKey)
+		return
+	}
+	e.queueWaits[functionKey] = wait
+}
+
+// MaxQueueWait returns the max queue wait set for a function, if any.
+func (e *FunctionExecutor) MaxQueueWait(functionKey FunctionKey) (time.Duration, bool) {
+	e.queueWaitsMu.RLock()
+	defer e.queueWaitsMu.RUnlock()
+
+	wait, ok := e.queueWaits[functionKey]
+	return wait, ok
+}
+
+// SetCallLogging sets which calls of a function write entries to its logs.
+func (e *FunctionExecutor) SetCallLogging(functionKey FunctionKey, policy types.CallLogging) {
+	e.callLogsMu.Lock()
+	defer e.callLogsMu.Unlock()
+
+	if policy.Mode == "" || policy.Mode == types.CallLogAll {
+		delete(e.callLogs, functionKey)
+		return
+	}
+	e.callLogs[functionKey] = &callLogState{policy: policy}
+}
+
+// CallLogging returns which calls of a function write entries to its logs.
+func (e *FunctionExecutor) CallLogging(functionKey FunctionKey) types.CallLogging {
+	e.callLogsMu.RLock()
+	defer e.callLogsMu.RUnlock()
+
+	if state, ok := e.callLogs[functionKey]; ok {
+		return state.policy
+	}
+	return types.CallLogging{Mode: types.CallLogAll}
+}
+
+// logsCall reports whether the next call of a function writes its entries.
+func (e *FunctionExecutor) logsCall(functionKey FunctionKey) bool {
+	e.callLogsMu.RLock()
+	state, ok := e.callLogs[functionKey]
+	e.callLogsMu.RUnlock()
+
+	if !ok {
+		return true
+	}
+	return state.policy.Logs(state.calls.Add(1) - 1)
 }
 
 func (e *FunctionExecutor) CallFunction(ctx context.Context, namespace, name, entrypoint string, payload []byte) ([]byte, error) {
 	functionKey := GetFunctionKey(namespace, name)
 
-	// Log the function call
-	e.logStore.AddLog(functionKey, logging.LevelInfo, fmt.Sprintf("Function call: %s with payload size %d bytes", entrypoint, len(payload)))
+	// Log the function call, unless it is sampled out; errors are logged either way
+	if e.logsCall(functionKey) {
+		e.logStore.AddLog(functionKey, logging.LevelInfo, fmt.Sprintf("Function call: %s with payload size %d bytes", entrypoint, len(payload)))
+	} else {
+		ctx = context.WithValue(ctx, callUnloggedKey{}, true)
+	}
+
+	// Calls without a priority of their own queue with the function's priority
+	if _, ok := components.PriorityFromContext(ctx); !ok {
+		ctx = components.WithPriority(ctx, e.Priority(functionKey))
+	}
+	if wait, ok := e.MaxQueueWait(functionKey); ok {
+		ctx = components.WithMaxQueueWait(ctx, wait)
+	}
 
-	// Check the circuit breaker state and get the plugin
-	cb, plugin, err := e.prepareExecution(functionKey)
+	// Run the call through the registered interceptors
+	handler := e.interceptors.wrap(functionKey, func(ctx context.Context, call *Call) ([]byte, error) {
+		e.metrics.RecordConcurrency(int(e.inFlight.Add(1)))
+		start := time.Now()
+		output, err := e.execute(ctx, functionKey, call)
+		e.metrics.RecordExecution(functionKey, time.Since(start).Seconds(), err == nil)
+		if recorder, ok := e.metrics.(metrics.FailureRecorder); ok && err != nil {
+			recorder.RecordFailure(functionKey, string(classifyCallError(err)))
+		}
+		e.metrics.RecordConcurrency(int(e.inFlight.Add(-1)))
+		return output, err
+	})
+
+	return handler(ctx, &Call{
+		Namespace:  namespace,
+		Name:       name,
+		Entrypoint: entrypoint,
+		Payload:    payload,
+	})
+}
+
+// execute runs a call once it has passed the interceptors.
+func (e *FunctionExecutor) execute(ctx context.Context, functionKey FunctionKey, call *Call) ([]byte, error) {
+	// Check the circuit breaker state and get the plugin pool
+	cb, pool, err := e.prepareExecution(functionKey, call.Entrypoint)
 	if err != nil {
 		return nil, err
 	}
 
 	// Execute the function
-	return e.executeFunction(ctx, functionKey, plugin, cb, entrypoint, payload)
+	return e.executeFunction(ctx, functionKey, pool, cb, call.Entrypoint, call.Payload)
 }
 
-// prepareExecution checks circuit breaker state and retrieves the plugin.
-func (e *FunctionExecutor) prepareExecution(functionKey string) (CircuitBreaker, *extism.Plugin, error) {
+// callBreaker is the circuit breaker guarding a call, with the entrypoint it guards
+// (empty when it guards the whole function).
+type callBreaker struct {
+	CircuitBreaker
+	entrypoint string
+}
+
+// circuitBreaker returns the breaker guarding calls to an entrypoint.
+func (e *FunctionExecutor) circuitBreaker(functionKey FunctionKey, entrypoint string) callBreaker {
+	if e.breakerGranularity == components.GranularityEntrypoint {
+		return callBreaker{e.circuitBreakers.GetEntrypointCircuitBreaker(functionKey, entrypoint), entrypoint}
+	}
+	return callBreaker{CircuitBreaker: e.circuitBreakers.GetCircuitBreaker(functionKey)}
+}
+
+// prepareExecution checks circuit breaker state and retrieves the plugin pool.
+func (e *FunctionExecutor) prepareExecution(functionKey FunctionKey, entrypoint string) (callBreaker, *components.PluginPool, error) {
 	// Check circuit breaker
-	cb := e.circuitBreakers.GetCircuitBreaker(functionKey)
+	cb := e.circuitBreaker(functionKey, entrypoint)
 	if cb.IsOpen() {
 		errMsg := fmt.Sprintf("Circuit breaker is open for function %s", functionKey)
+		if cb.entrypoint != "" {
+			errMsg = fmt.Sprintf("Circuit breaker is open for entrypoint %s of function %s", cb.entrypoint, functionKey)
+		}
 		e.logStore.AddLog(functionKey, logging.LevelError, errMsg)
-		return nil, nil, WrapEngineError(errMsg, nil)
+		return callBreaker{}, nil, domainerrors.New(domainerrors.DomainExecution, domainerrors.CodeCircuitBreakerOpen, errMsg)
 	}
 
-	// Get the plugin
-	plugin, ok := e.pluginManager.GetPlugin(functionKey)
+	// Get the plugin pool
+	pool, ok := e.pluginManager.GetPool(functionKey)
 	if !ok {
 		e.logStore.AddLog(functionKey, logging.LevelError, "Function not loaded")
-		return nil, nil, ErrFunctionNotLoaded
+		return callBreaker{}, nil, ErrFunctionNotLoaded
 	}
 
-	return cb, plugin, nil
+	return cb, pool, nil
 }
 
 type callResult struct {
-	output []byte
-	err    error
+	output      []byte
+	err         error
+	fatal       bool // the instance crashed and must not be reused
+	interrupted bool // the instance was closed to abort the call
 }
 
 // executeFunction performs the actual function execution with proper error handling.
 func (e *FunctionExecutor) executeFunction(
 	ctx context.Context,
-	functionKey string,
-	plugin *extism.Plugin,
-	cb CircuitBreaker,
+	functionKey FunctionKey,
+	pool *components.PluginPool,
+	cb callBreaker,
 	entrypoint string,
 	payload []byte,
 ) ([]byte, error) {
 	startTime := time.Now()
 
-	// Create a wrapper function for the shared utility
-	wrapper := func() (callResult, error) {
-		_, output, callErr := plugin.Call(entrypoint, payload)
-		return callResult{output, callErr}, nil
+	// Borrow an instance, waiting for one to free up if the pool is at capacity
+	plugin, err := pool.Acquire(ctx)
+	var queueTimeout *components.QueueTimeoutError
+	if recorder, ok := e.metrics.(metrics.QueueRecorder); ok && (err == nil || errors.As(err, &queueTimeout)) {
+		recorder.RecordQueueWait(functionKey, time.Since(startTime).Seconds(), err == nil)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return e.handleCancellation(ctx, functionKey, cb, startTime)
+		}
+		if errors.As(err, &queueTimeout) {
+			return nil, e.rejectQueuedCall(functionKey, queueTimeout)
+		}
+		return nil, e.logAndWrapError(functionKey, "failed to acquire plugin instance", err)
+	}
+
+	// When the caller gives up, the instance is closed to abort the call. The instance
+	// is returned to the pool, or replaced if it was closed, only once the call returns.
+	var mu sync.Mutex
+	running, interrupted := true, false
+	task := utils.Task[callResult]{
+		Name: "Call to " + entrypoint,
+		Interrupt: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if running {
+				interrupted = true
+				plugin.Close(context.Background())
+			}
+		},
+		Observe: e.observe(functionKey),
 	}
 
-	// Execute with context cancellation handling
-	result, _ := utils.ExecuteWithContext(ctx, wrapper)
+	result, _ := utils.Run(ctx, task, func() (result callResult, _ error) {
+		defer func() {
+			if r := recover(); r != nil {
+				recordCallPanic(e.panics, e.logStore, functionKey, "Call to "+entrypoint, r)
+				result = callResult{
+					err: domainerrors.New(domainerrors.DomainExecution, domainerrors.CodeExecutionPanicked,
+						fmt.Sprintf("Plugin instance panicked: %v", r)),
+					fatal: true,
+				}
+			}
+			mu.Lock()
+			running = false
+			result.interrupted = interrupted
+			mu.Unlock()
+			e.returnInstance(functionKey, pool, plugin, cb, result)
+		}()
+
+		// Host functions see the call's context values, such as the calling function and
+		// where to stream output chunks; cancellation closes the instance instead
+		callCtx := e.withEgress(withCaller(context.WithoutCancel(ctx), functionKey), functionKey)
+		rc, output, callErr := plugin.CallWithContext(callCtx, entrypoint, payload)
+		if callErr == nil && rc != 0 {
+			callErr = domainerrors.New(domainerrors.DomainExecution, domainerrors.CodeExecutionExit,
+				fmt.Sprintf("%s returned exit code %d", entrypoint, rc))
+		}
+		if callErr == nil {
+			// An interrupted instance is being closed, so its memory is not read
+			mu.Lock()
+			if !interrupted {
+				recordMemory(e.metrics, functionKey, plugin)
+			}
+			mu.Unlock()
+		}
+		return callResult{output: output, err: callErr, fatal: components.IsFatalInstanceError(callErr)}, nil
+	})
 
 	// If the context was cancelled, handle it specially
 	if ctx.Err() != nil {
-		return e.handleCancellation(ctx, functionKey, cb)
+		return e.handleCancellation(ctx, functionKey, cb, startTime)
 	}
 
 	// Otherwise process the result with the actual call result
-	return e.processResult(functionKey, cb, entrypoint, result, startTime)
+	logged := ctx.Value(callUnloggedKey{}) == nil
+	return e.processResult(functionKey, cb, entrypoint, result, startTime, logged)
 }
 
-func (e *FunctionExecutor) logCircuitBreakerOpen(functionKey string) {
+// returnInstance hands an instance back to its pool, replacing it if the call crashed it.
+// A function whose instances keep crashing has its circuit breaker opened.
+func (e *FunctionExecutor) returnInstance(functionKey FunctionKey, pool *components.PluginPool,
+	plugin *extism.Plugin, cb callBreaker, result callResult) {
+	if result.interrupted {
+		pool.Replace(plugin, "call abandoned by its caller")
+		return
+	}
+	if !result.fatal {
+		pool.Release(plugin)
+		return
+	}
+
+	// Recorded here, before the circuit may trip, so the notification includes the crash
+	e.notifier.RecordError(functionKey, result.err)
+	if pool.Discard(plugin, result.err) {
+		cb.Trip()
+		e.logCircuitBreakerOpen(functionKey, cb.entrypoint)
+	}
+}
+
+// observe records calls that kept running after their caller gave up in the function logs.
+func (e *FunctionExecutor) observe(functionKey FunctionKey) func(utils.Observation) {
+	return func(o utils.Observation) {
+		if o.Abandoned {
+			e.logStore.AddLog(functionKey, logging.LevelWarning,
+				fmt.Sprintf("%s interrupted after %v", o.Name, o.Duration.Round(time.Millisecond)))
+		}
+	}
+}
+
+func (e *FunctionExecutor) logCircuitBreakerOpen(functionKey FunctionKey, entrypoint string) {
 	cbMsg := fmt.Sprintf("Circuit breaker opened for function %s", functionKey)
-	e.logger.Printf(cbMsg)
+	if entrypoint != "" {
+		cbMsg = fmt.Sprintf("Circuit breaker opened for entrypoint %s of function %s", entrypoint, functionKey)
+	}
+	e.logger.Printf("%s", cbMsg)
 	e.logStore.AddLog(functionKey, logging.LevelError, cbMsg)
 }
 
-func (e *FunctionExecutor) logAndWrapError(functionKey, operation string, err error) error {
+// logTrap records the stack trace of a trap in the function logs.
+func (e *FunctionExecutor) logTrap(functionKey FunctionKey, entrypoint string, trap *components.Trap) {
+	msg := fmt.Sprintf("Trap in %s: %s", entrypoint, trap)
+	if !trap.Symbolized() {
+		msg += "\nThe module has no names for some frames; build it with ignition build --debug for a symbolized trace"
+	}
+	e.logStore.AddLog(functionKey, logging.LevelError, msg)
+}
+
+func (e *FunctionExecutor) logAndWrapError(functionKey FunctionKey, operation string, err error) error {
 	errMsg := fmt.Sprintf("%s: %v", operation, err)
 	e.logStore.AddLog(functionKey, logging.LevelError, errMsg)
 	return WrapEngineError(operation, err)
@@ -124,73 +420,152 @@ type ExecutionStats struct {
 	FailedExecutions     int64
 }
 
-func (e *FunctionExecutor) GetStats(_, _ string) ExecutionStats {
-	// Currently we don't track these stats, so return empty values
-	// This is a placeholder for future implementation
+// GetStats returns the calls of a function recorded by the metrics collector, or empty
+// stats when the collector cannot report them.
+func (e *FunctionExecutor) GetStats(namespace, name string) ExecutionStats {
+	reporter, ok := e.metrics.(metrics.Reporter)
+	if !ok {
+		return ExecutionStats{}
+	}
+	stats := reporter.Snapshot().Functions[GetFunctionKey(namespace, name)]
 	return ExecutionStats{
-		LastExecution:        time.Time{},
-		TotalExecutions:      0,
-		SuccessfulExecutions: 0,
-		FailedExecutions:     0,
+		LastExecution:        stats.LastCall,
+		TotalExecutions:      stats.Calls,
+		SuccessfulExecutions: stats.Calls - stats.Failures,
+		FailedExecutions:     stats.Failures,
+	}
+}
+
+// recordMemory reports the size of the linear memory of an instance.
+func recordMemory(collector interfaces.MetricsCollector, functionKey FunctionKey, plugin *extism.Plugin) {
+	if memory := plugin.Memory(); memory != nil {
+		collector.RecordMemoryUsage(functionKey, int64(memory.Size()))
 	}
 }
 
 func (e *FunctionExecutor) processResult(
-	functionKey string,
-	cb CircuitBreaker,
+	functionKey FunctionKey,
+	cb callBreaker,
 	entrypoint string,
 	result callResult,
 	startTime time.Time,
+	logged bool,
 ) ([]byte, error) {
 	execTime := time.Since(startTime)
+	e.recordColdStart(functionKey, execTime)
 
 	// Handle error case
 	if result.err != nil {
-		// Record failure in circuit breaker
-		isOpen := cb.RecordFailure()
-
-		// Log if circuit breaker opened
-		if isOpen {
-			e.logCircuitBreakerOpen(functionKey)
+		// Record failure in circuit breaker (crashes were recorded when the instance was discarded)
+		if !result.fatal {
+			e.notifier.RecordError(functionKey, result.err)
 		}
+		e.recordBreakerFailure(functionKey, cb, classifyCallError(result.err))
 
-		return nil, e.logAndWrapError(functionKey, "failed to call function", result.err)
+		if trap, ok := components.ParseTrap(result.err); ok {
+			e.logTrap(functionKey, entrypoint, trap)
+		} else {
+			e.logStore.AddLog(functionKey, logging.LevelError, fmt.Sprintf("failed to call function: %v", result.err))
+		}
+		return nil, callFailure(result.err)
 	}
 
 	// Handle success case
-	e.logStore.AddLog(functionKey, logging.LevelInfo,
-		fmt.Sprintf("Function executed successfully: %s (execution time: %v, response size: %d bytes)",
-			entrypoint, execTime, len(result.output)))
+	if logged {
+		e.logStore.AddLog(functionKey, logging.LevelInfo,
+			fmt.Sprintf("Function executed successfully: %s (execution time: %v, response size: %d bytes)",
+				entrypoint, execTime, len(result.output)))
+	}
 
 	cb.RecordSuccess()
 	return result.output, nil
 }
 
+// recordColdStart completes the cold start of a function when this was its first call since loading.
+func (e *FunctionExecutor) recordColdStart(functionKey FunctionKey, callTime time.Duration) {
+	coldStart, ok := e.coldStarts.Called(functionKey, callTime)
+	if !ok {
+		return
+	}
+	e.logStore.AddLog(functionKey, logging.LevelInfo,
+		fmt.Sprintf("Cold start took %.1fms (pull: %.1fms, compile: %.1fms, instantiate: %.1fms, first call: %.1fms)",
+			coldStart.TotalMs, coldStart.PullMs, coldStart.CompileMs, coldStart.InstantiateMs, coldStart.FirstCallMs))
+}
+
 // handleCancellation handles context cancellation and timeout cases
 func (e *FunctionExecutor) handleCancellation(
 	ctx context.Context,
-	functionKey string,
-	cb CircuitBreaker,
+	functionKey FunctionKey,
+	cb callBreaker,
+	startTime time.Time,
 ) ([]byte, error) {
-	// Record the failure in the circuit breaker
-	isOpen := cb.RecordFailure()
+	e.recordColdStart(functionKey, time.Since(startTime))
 
 	// Determine the specific error message based on cancellation reason
 	var operation string
+	code := domainerrors.CodeExecutionCancelled
 	if ctx.Err() == context.DeadlineExceeded {
-		operation = fmt.Sprintf("function execution timed out after %v", e.defaultTimeout)
+		operation = fmt.Sprintf("function execution timed out after %v", time.Since(startTime).Round(time.Millisecond))
+		code = domainerrors.CodeExecutionTimeout
 	} else {
 		operation = "function execution was cancelled"
 	}
 
-	// Log if circuit breaker opened
-	if isOpen {
-		e.logCircuitBreakerOpen(functionKey)
-	}
+	// Record the failure in the circuit breaker
+	e.notifier.RecordError(functionKey, errors.New(operation))
+	e.recordBreakerFailure(functionKey, cb, code)
+
+	e.logStore.AddLog(functionKey, logging.LevelError, fmt.Sprintf("%s: %v", operation, ctx.Err()))
+	return nil, domainerrors.Wrap(domainerrors.DomainExecution, code, operation, ctx.Err())
+}
+
+// rejectQueuedCall turns away a call that waited too long for an instance. The pool is
+// saturated rather than failing, so the circuit breaker is left alone, and clients are
+// told to retry after about as long as the call waited.
+func (e *FunctionExecutor) rejectQueuedCall(functionKey FunctionKey, timeout *components.QueueTimeoutError) error {
+	msg := fmt.Sprintf("Call turned away after waiting %v for a free instance", timeout.Waited.Round(time.Millisecond))
+	e.logStore.AddLog(functionKey, logging.LevelWarning, fmt.Sprintf("%s (%d calls waiting)", msg, timeout.Depth))
 
-	return nil, e.logAndWrapError(functionKey, operation, ctx.Err())
+	retryAfter := max(1, int(math.Ceil(timeout.Waited.Seconds())))
+	return domainerrors.Wrap(domainerrors.DomainExecution, domainerrors.CodeQueueTimeout, msg, timeout).
+		WithDetails(map[string]interface{}{RetryAfterDetail: retryAfter})
+}
+
+// recordBreakerFailure counts a failed call toward opening the circuit breaker, unless
+// its code is ignored.
+func (e *FunctionExecutor) recordBreakerFailure(functionKey FunctionKey, cb callBreaker, code domainerrors.Code) {
+	if slices.Contains(e.breakerIgnores, code) {
+		return
+	}
+	if cb.RecordFailure() {
+		e.logCircuitBreakerOpen(functionKey, cb.entrypoint)
+	}
 }
 
 func (e *FunctionExecutor) DefaultTimeout() time.Duration {
 	return e.defaultTimeout
 }
+
+// withEgress makes the HTTP requests of a call go through the function's proxy and
+// check its egress policy when they connect, logging each decision in the function's logs.
+func (e *FunctionExecutor) withEgress(ctx context.Context, functionKey FunctionKey) context.Context {
+	if e.egress == nil {
+		return ctx
+	}
+	fe, ok := e.egress(functionKey)
+	if !ok {
+		return ctx
+	}
+
+	ctx = egress.WithProxy(ctx, fe.proxy)
+	return egress.WithPolicy(ctx, fe.policy, func(decision egress.Decision) {
+		message := "Egress " + decision.String()
+		if decision.Allowed {
+			e.logger.Debugf("%s: %s", functionKey, message)
+			e.logStore.AddLog(functionKey, logging.LevelDebug, message)
+			return
+		}
+		e.logger.Printf("%s: %s", functionKey, message)
+		e.logStore.AddLog(functionKey, logging.LevelWarning, message)
+	})
+}
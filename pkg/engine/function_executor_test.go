@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallLoggingSampling(t *testing.T) {
+	executor := NewFunctionExecutor(nil, nil, nil, nil, 0)
+	key := GetFunctionKey("ns", "fn")
+
+	countLogged := func(calls int) int {
+		logged := 0
+		for i := 0; i < calls; i++ {
+			if executor.logsCall(key) {
+				logged++
+			}
+		}
+		return logged
+	}
+
+	// Every call is logged by default
+	assert.Equal(t, 10, countLogged(10))
+	assert.Equal(t, "all", executor.CallLogging(key).String())
+
+	sampled, err := types.ParseCallLogging("1/4")
+	require.NoError(t, err)
+	executor.SetCallLogging(key, sampled)
+	assert.Equal(t, "1/4", executor.CallLogging(key).String())
+	assert.Equal(t, 3, countLogged(10))
+
+	quiet, err := types.ParseCallLogging("quiet")
+	require.NoError(t, err)
+	executor.SetCallLogging(key, quiet)
+	assert.Equal(t, 0, countLogged(10))
+
+	all, err := types.ParseCallLogging("1/1")
+	require.NoError(t, err)
+	executor.SetCallLogging(key, all)
+	assert.Equal(t, 10, countLogged(10))
+
+	for _, value := range []string{"1/0", "2/5", "some"} {
+		_, err := types.ParseCallLogging(value)
+		assert.Error(t, err, value)
+	}
+}
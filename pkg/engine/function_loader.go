@@ -2,13 +2,27 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	extism "github.com/extism/go-sdk"
 	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/egress"
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
 	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/engine/metrics"
+	"github.com/ignitionstack/ignition/pkg/engine/policy"
 	"github.com/ignitionstack/ignition/pkg/engine/utils"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/proxy"
 	"github.com/ignitionstack/ignition/pkg/registry"
 )
 
@@ -16,12 +30,64 @@ type FunctionLoader struct {
 	registry        registry.Registry
 	pluginManager   PluginManager
 	circuitBreakers CircuitBreakerManager
-	logStore        *logging.FunctionLogStore
+	logStore        logging.LogStore
 	logger          logging.Logger
+	hostFunctions   HostFunctionsFactory
+	coldStarts      *components.ColdStartTracker
+	events          *events.Bus
+	admission       *policy.Admission
+	state           interfaces.StateManager
+	metrics         interfaces.MetricsCollector
+	lifecycle       *components.Lifecycle
+
+	// Map modules from storage instead of reading them, when the registry can
+	mapModules bool
+
+	// Variants loaded in order of preference when a load names none
+	preferredVariants []string
+
+	// Compiled modules shared by the functions loading the same module
+	compiled *components.CompiledCache
+
+	// Deny rules added to the egress policy of every function, and the proxy of functions
+	// that do not set their own
+	egressDeny []egress.Rule
+	proxy      proxy.Settings
+
+	// Config keys whose values are masked in the function's logs
+	redaction *logging.Redaction
+
+	// Version settings, egress and requested variant of the most recently loaded
+	// version of each function
+	settingsMu sync.RWMutex
+	settings   map[FunctionKey]manifest.FunctionVersionSettings
+	egress     map[FunctionKey]functionEgress
+	variants   map[FunctionKey]string
 }
 
+// variantKey is the context key of the variant a load asks for.
+type variantKey struct{}
+
+// withVariant returns a context asking loads for a variant of the version, or for the
+// preferred variants when variant is empty. Loads without one, such as automatic
+// reloads, ask for the variant of the last load that set one.
+func withVariant(ctx context.Context, variant string) context.Context {
+	return context.WithValue(ctx, variantKey{}, variant)
+}
+
+// functionEgress is the egress policy and the proxy of a function's HTTP requests.
+type functionEgress struct {
+	policy *egress.Policy
+	proxy  egress.ProxyFunc
+}
+
+// HostFunctionsFactory returns the host functions to expose to loaded functions. A
+// compiled module is shared by every function loading it, so host functions find the
+// function calling them in the context they are called with rather than capturing it.
+type HostFunctionsFactory func() []extism.HostFunction
+
 func NewFunctionLoader(registry registry.Registry, pluginManager PluginManager,
-	circuitBreakers CircuitBreakerManager, logStore *logging.FunctionLogStore,
+	circuitBreakers CircuitBreakerManager, logStore logging.LogStore,
 	logger logging.Logger) *FunctionLoader {
 	return &FunctionLoader{
 		registry:        registry,
@@ -29,9 +95,21 @@ func NewFunctionLoader(registry registry.Registry, pluginManager PluginManager,
 		circuitBreakers: circuitBreakers,
 		logStore:        logStore,
 		logger:          logger,
+		metrics:         metrics.Nop{},
+		lifecycle:       components.NewLifecycle(nil),
+		compiled:        components.NewCompiledCache(),
+		settings:        make(map[FunctionKey]manifest.FunctionVersionSettings),
+		egress:          make(map[FunctionKey]functionEgress),
+		variants:        make(map[FunctionKey]string),
+		redaction:       logging.NewRedaction(nil),
 	}
 }
 
+// SetHostFunctions sets the factory used to build host functions for newly created plugins.
+func (l *FunctionLoader) SetHostFunctions(factory HostFunctionsFactory) {
+	l.hostFunctions = factory
+}
+
 // LoadFunction loads a function with the specified identifier and configuration.
 // This is a convenience method that calls LoadFunctionWithForce with force=false.
 func (l *FunctionLoader) LoadFunction(ctx context.Context, namespace, name, identifier string, config map[string]string) error {
@@ -49,32 +127,239 @@ func (l *FunctionLoader) LoadFunctionWithForce(ctx context.Context, namespace, n
 		return err
 	}
 
+	// A running function keeps serving its current version until a new one replaces it
+	running := l.lifecycle.Phase(functionKey) == components.PhaseRunning
+	if !running {
+		l.transition(functionKey, components.PhaseLoading, "")
+	}
+
+	if err := l.load(ctx, functionKey, identifier, config); err != nil {
+		if !running || !l.pluginManager.IsPluginLoaded(functionKey) {
+			l.transition(functionKey, components.PhaseFailed, err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+// load pulls, admits and instantiates a version of a function, replacing the loaded
+// one when its digest or config differ.
+func (l *FunctionLoader) load(ctx context.Context, functionKey FunctionKey, identifier string, config map[string]string) error {
+	namespace, name := functionKey.Namespace, functionKey.Name
 	l.logStore.AddLog(functionKey, logging.LevelInfo, fmt.Sprintf("Loading function with identifier: %s", identifier))
 
 	// Create a deep copy of the config map to prevent side effects
 	configCopy := l.copyConfig(config)
 
+	// Keep secrets such as API keys out of the function's logs
+	l.logStore.SetSecrets(functionKey, l.redaction.SecretValues(configCopy))
+
 	// Fetch the WASM bytes from the registry
 	loadStart := time.Now()
-	wasmBytes, versionInfo, err := l.pullWithContext(ctx, namespace, name, identifier)
+	wasmBytes, versionInfo, release, err := l.pullWithContext(ctx, namespace, name, identifier)
 	if err != nil {
 		return l.handlePullError(functionKey, err)
 	}
+	// The compilation releases the module once it is done with it; loads failing before
+	// it starts release the module here
+	compiling := false
+	defer func() {
+		if !compiling {
+			release()
+		}
+	}()
 
 	// Log success and record detailed information
+	pullTime := time.Since(loadStart)
 	l.logStore.AddLog(functionKey, logging.LevelInfo,
 		fmt.Sprintf("Function pulled from registry (size: %d bytes, time: %v)",
-			len(wasmBytes), time.Since(loadStart)))
+			len(wasmBytes), pullTime))
+	l.logRangeResolution(functionKey, identifier, versionInfo)
+
+	requested, explicit := ctx.Value(variantKey{}).(string)
+	if !explicit {
+		l.settingsMu.RLock()
+		requested = l.variants[functionKey]
+		l.settingsMu.RUnlock()
+	}
+	variant, err := l.selectVariant(functionKey, versionInfo, requested)
+	if err != nil {
+		return err
+	}
 
+	// Versions stored before a policy was added are checked when they are loaded
+	if err := l.admit(ctx, functionKey, versionInfo, variant); err != nil {
+		return err
+	}
+	if variant != nil {
+		wasmBytes, versionInfo, err = l.pullVariant(functionKey, versionInfo, variant)
+		if err != nil {
+			return err
+		}
+		release()
+		release = func() {}
+	}
 	actualDigest := versionInfo.FullDigest
 
+	// Fail on a config the function cannot run with rather than on the calls reading it
+	schema := versionInfo.Settings.Config
+	configCopy = schema.WithDefaults(configCopy)
+	if err := schema.Check(configCopy); err != nil {
+		return l.logAndWrapError(functionKey, "invalid config",
+			domainerrors.New(domainerrors.DomainFunction, domainerrors.CodeInvalidConfig, "Config does not match the function's schema").
+				WithNamespace(namespace).WithName(name).WithCause(err))
+	}
+
+	egressPolicy, err := egress.NewPolicy(versionInfo.Settings.AllowedUrls, l.egressDeny)
+	if err != nil {
+		return l.logAndWrapError(functionKey, "invalid allowed_urls", err)
+	}
+	egressProxy, err := functionProxy(versionInfo.Settings.Proxy, l.proxy)
+	if err != nil {
+		return l.logAndWrapError(functionKey, "invalid proxy", err)
+	}
+
 	// Check if the function is already loaded and handle accordingly
-	if err := l.handleExistingFunction(functionKey, configCopy, actualDigest); err != nil {
+	wasLoaded := l.pluginManager.IsPluginLoaded(functionKey)
+	reloadReason, err := l.handleExistingFunction(functionKey, configCopy, actualDigest)
+	if err != nil {
 		return err
 	}
+	if reloadReason != "" {
+		l.transition(functionKey, components.PhaseLoading, reloadReason)
+	}
 
 	// Create and initialize the plugin
-	return l.createAndStorePlugin(ctx, functionKey, wasmBytes, versionInfo, configCopy, actualDigest)
+	compiling = true
+	if err := l.createAndStorePlugin(ctx, functionKey, wasmBytes, release, versionInfo, configCopy, actualDigest, pullTime); err != nil {
+		return err
+	}
+	l.settingsMu.Lock()
+	l.egress[functionKey] = functionEgress{policy: egressPolicy, proxy: egressProxy}
+	if explicit {
+		l.variants[functionKey] = requested
+	}
+	l.settingsMu.Unlock()
+
+	l.transition(functionKey, components.PhaseRunning, reloadReason)
+	switch {
+	case !wasLoaded:
+		l.publish(events.TypeLoaded, functionKey, actualDigest, "")
+	case reloadReason != "":
+		l.publish(events.TypeReloaded, functionKey, actualDigest, reloadReason)
+	}
+	return nil
+}
+
+// admit checks a version, with the module of its selected variant if any, against the
+// admission policies before it is loaded.
+func (l *FunctionLoader) admit(ctx context.Context, functionKey FunctionKey, versionInfo *registry.VersionInfo, variant *registry.Variant) error {
+	if l.admission == nil {
+		return nil
+	}
+	module := versionInfo.Module
+	if variant != nil {
+		module = variant.Module
+	}
+	err := l.admission.Admit(ctx, policy.Input{
+		Stage:     policy.StageLoad,
+		Namespace: functionKey.Namespace,
+		Name:      functionKey.Name,
+		Digest:    versionInfo.FullDigest,
+		Settings:  versionInfo.Settings,
+		Module:    module,
+	})
+	if err != nil {
+		return l.logAndWrapError(functionKey, "Admission failed", err)
+	}
+	return nil
+}
+
+// selectVariant returns the variant of a version to load: the requested one, or else
+// the first preferred one the runtime can run. It returns nil to load the default module.
+func (l *FunctionLoader) selectVariant(functionKey FunctionKey, versionInfo *registry.VersionInfo, requested string) (*registry.Variant, error) {
+	candidates := l.preferredVariants
+	if requested != "" {
+		candidates = []string{requested}
+	}
+
+	for _, name := range candidates {
+		if name == registry.DefaultVariant {
+			return nil, nil
+		}
+		variant, ok := versionInfo.FindVariant(name)
+		switch {
+		case !ok && requested != "":
+			return nil, l.logAndWrapError(functionKey, "failed to select variant",
+				fmt.Errorf("%w: version %s has no variant %s", registry.ErrVariantNotFound, versionInfo.Hash, name))
+		case ok && variant.Component && requested != "":
+			return nil, l.logAndWrapError(functionKey, "failed to select variant",
+				fmt.Errorf("variant %s is a component, which the runtime cannot run", name))
+		case ok && !variant.Component:
+			return variant, nil
+		}
+	}
+	return nil, nil
+}
+
+// pullVariant reads the module of a variant and returns the version info to load it
+// with. The module is identified by its own digest, so that switching variants reloads
+// the function and compiled modules are only shared by functions running the same one.
+func (l *FunctionLoader) pullVariant(functionKey FunctionKey, versionInfo *registry.VersionInfo, variant *registry.Variant) ([]byte, *registry.VersionInfo, error) {
+	store, ok := l.registry.(registry.VariantStore)
+	if !ok {
+		return nil, nil, l.logAndWrapError(functionKey, "failed to pull variant", errors.New("the registry does not store variants"))
+	}
+	payload, _, err := store.PullVariant(functionKey.Namespace, functionKey.Name, versionInfo.FullDigest, variant.Name)
+	if err != nil {
+		return nil, nil, l.logAndWrapError(functionKey, "failed to pull variant", err)
+	}
+	l.logStore.AddLog(functionKey, logging.LevelInfo,
+		fmt.Sprintf("Loading variant %s of version %s (size: %d bytes)", variant.Name, versionInfo.Hash, len(payload)))
+
+	selected := *versionInfo
+	selected.FullDigest = strings.TrimPrefix(variant.Checksum, "sha256:")
+	selected.Size = variant.Size
+	selected.Checksum = variant.Checksum
+	selected.Module = variant.Module
+	return payload, &selected, nil
+}
+
+// publish sends a lifecycle event of the function to the engine's event bus.
+func (l *FunctionLoader) publish(eventType string, functionKey FunctionKey, digest, reason string) {
+	event := events.Event{
+		Type:      eventType,
+		Namespace: functionKey.Namespace,
+		Function:  functionKey.Name,
+		Digest:    digest,
+		Reason:    reason,
+	}
+	l.events.Publish(event)
+	mirrorState(l.state, l.pluginManager, event)
+}
+
+// transition moves a function to another lifecycle state. Transitions that are not
+// allowed, such as a function evicted while it was loaded again, are logged and skipped.
+func (l *FunctionLoader) transition(functionKey FunctionKey, to components.FunctionPhase, reason string) {
+	if err := l.lifecycle.Transition(functionKey, to, reason); err != nil {
+		l.logger.Printf("Warning: %v", err)
+	}
+}
+
+// logRangeResolution records which version a semver range such as ^1.2 resolved to.
+func (l *FunctionLoader) logRangeResolution(functionKey FunctionKey, identifier string, versionInfo *registry.VersionInfo) {
+	if registry.HasTag(versionInfo.Tags, identifier) || strings.HasPrefix(versionInfo.FullDigest, identifier) {
+		return
+	}
+	versionRange, err := registry.ParseVersionRange(identifier)
+	if err != nil {
+		return
+	}
+	tag, _ := versionRange.HighestMatch(versionInfo.Tags)
+
+	message := fmt.Sprintf("Resolved version range %s to tag %s (digest: %s)", identifier, tag, versionInfo.Hash)
+	l.logger.Printf("%s: %s", functionKey, message)
+	l.logStore.AddLog(functionKey, logging.LevelInfo, message)
 }
 
 // validateLoadPermissions checks if a function can be loaded based on its stopped status.
@@ -112,7 +397,7 @@ func (l *FunctionLoader) copyConfig(config map[string]string) map[string]string
 
 // logAndWrapError logs an error and wraps it with an EngineError.
 // This centralizes error handling to ensure consistent logging and wrapping.
-func (l *FunctionLoader) logAndWrapError(functionKey, operation string, err error) error {
+func (l *FunctionLoader) logAndWrapError(functionKey FunctionKey, operation string, err error) error {
 	errMsg := fmt.Sprintf("%s: %v", operation, err)
 	l.logger.Errorf(errMsg)
 	l.logStore.AddLog(functionKey, logging.LevelError, errMsg)
@@ -120,16 +405,16 @@ func (l *FunctionLoader) logAndWrapError(functionKey, operation string, err erro
 }
 
 // handlePullError logs and wraps errors from pulling a function from the registry.
-func (l *FunctionLoader) handlePullError(functionKey string, err error) error {
+func (l *FunctionLoader) handlePullError(functionKey FunctionKey, err error) error {
 	return l.logAndWrapError(functionKey, "failed to fetch WASM file from registry", err)
 }
 
-// handleExistingFunction checks if a function needs to be reloaded based on changes.
-// Returns nil if no reload is needed or if reload preparation was successful.
-func (l *FunctionLoader) handleExistingFunction(functionKey string, configCopy map[string]string, actualDigest string) error {
+// handleExistingFunction checks if a function needs to be reloaded based on changes,
+// and returns why it is reloaded, or an empty reason when no reload is needed.
+func (l *FunctionLoader) handleExistingFunction(functionKey FunctionKey, configCopy map[string]string, actualDigest string) (string, error) {
 	// If function is not loaded, nothing to do
 	if !l.pluginManager.IsPluginLoaded(functionKey) {
-		return nil
+		return "", nil
 	}
 
 	// Check if anything has changed
@@ -140,11 +425,13 @@ func (l *FunctionLoader) handleExistingFunction(functionKey string, configCopy m
 	if !digestChanged && !configChanged {
 		l.logger.Printf("Function %s already loaded with same digest and config", functionKey)
 		l.logStore.AddLog(functionKey, logging.LevelInfo, "Function already loaded with same digest and config")
-		return nil
+		return "", nil
 	}
 
 	// Log what changed for debugging
+	var reasons []string
 	if digestChanged {
+		reasons = append(reasons, "new digest")
 		oldDigest, _ := l.pluginManager.GetPluginDigest(functionKey)
 		l.logger.Printf("Function %s digest changed from %s to %s, reloading",
 			functionKey, oldDigest, actualDigest)
@@ -154,6 +441,7 @@ func (l *FunctionLoader) handleExistingFunction(functionKey string, configCopy m
 	}
 
 	if configChanged {
+		reasons = append(reasons, "config changed")
 		l.logger.Printf("Function %s configuration changed, reloading", functionKey)
 		l.logStore.AddLog(functionKey, logging.LevelInfo, "Function configuration changed, reloading")
 	}
@@ -162,33 +450,58 @@ func (l *FunctionLoader) handleExistingFunction(functionKey string, configCopy m
 	l.pluginManager.RemovePlugin(functionKey)
 	l.circuitBreakers.RemoveCircuitBreaker(functionKey)
 
-	return nil
+	return strings.Join(reasons, ", "), nil
 }
 
 // createAndStorePlugin creates a new plugin instance and stores it in the plugin manager
 //
 //nolint:whitespace // Complex function signature with many parameters causes whitespace linting issues
 func (l *FunctionLoader) createAndStorePlugin(
-	ctx context.Context, key string, wasm []byte, vi *registry.VersionInfo,
-	cfg map[string]string, dg string) error {
+	ctx context.Context, key FunctionKey, wasm []byte, release func(), vi *registry.VersionInfo,
+	cfg map[string]string, dg string, pullTime time.Duration) error {
 
-	// Create a new plugin instance
-	initStart := time.Now()
-	plugin, err := l.createPluginWithContext(ctx, wasm, vi, cfg)
+	// Compile the module once; every instance of the pool is created from it, and so
+	// are the instances of other functions loading the same module
+	compileStart := time.Now()
+	compiled, releaseCompiled, err := l.compile(ctx, key, wasm, release, vi, cfg)
 	if err != nil {
 		return l.logAndWrapError(key, "failed to initialize plugin", err)
 	}
+	compileTime := time.Since(compileStart)
+
+	// Create the first instance
+	instantiateStart := time.Now()
+	plugin, err := instantiatePluginWithContext(ctx, compiled)
+	if err != nil {
+		releaseCompiled()
+		return l.logAndWrapError(key, "failed to initialize plugin", err)
+	}
+	instantiateTime := time.Since(instantiateStart)
 
 	// Log successful initialization
 	l.logStore.AddLog(key, logging.LevelInfo,
-		fmt.Sprintf("Plugin initialized successfully (time: %v)", time.Since(initStart)))
+		fmt.Sprintf("Plugin initialized successfully (compile: %v, instantiate: %v)", compileTime, instantiateTime))
+	l.coldStarts.Loaded(key, pullTime, compileTime, instantiateTime)
+	recordMemory(l.metrics, key, plugin)
+
+	// Store the plugin in the plugin manager, which creates further instances on demand
+	factory := func(ctx context.Context) (*extism.Plugin, error) {
+		return instantiatePluginWithContext(ctx, compiled)
+	}
+	l.pluginManager.StorePlugin(key, plugin, factory, dg, cfg)
+
+	// The pool holds the compiled module as long as it has instances
+	if pool, ok := l.pluginManager.GetPool(key); ok {
+		pool.OnDrained(releaseCompiled)
+	}
 
-	// Store the plugin in the plugin manager
-	l.pluginManager.StorePlugin(key, plugin, dg, cfg)
+	l.settingsMu.Lock()
+	l.settings[key] = vi.Settings
+	l.settingsMu.Unlock()
 
 	// Log success
 	successMsg := fmt.Sprintf("Function loaded successfully: %s", key)
-	l.logger.Printf(successMsg)
+	l.logger.Printf("%s", successMsg)
 	l.logStore.AddLog(key, logging.LevelInfo, successMsg)
 	return nil
 }
@@ -211,7 +524,7 @@ func (l *FunctionLoader) UnloadFunction(namespace, name string) error {
 	// Check if the function is loaded
 	if !l.pluginManager.IsPluginLoaded(functionKey) {
 		notLoadedMsg := fmt.Sprintf("Function %s is not loaded, nothing to unload", functionKey)
-		l.logger.Printf(notLoadedMsg)
+		l.logger.Printf("%s", notLoadedMsg)
 		l.logStore.AddLog(functionKey, logging.LevelInfo, notLoadedMsg)
 		return nil
 	}
@@ -226,15 +539,17 @@ func (l *FunctionLoader) UnloadFunction(namespace, name string) error {
 
 	// Log success
 	successMsg := fmt.Sprintf("Function %s unloaded successfully (time: %v)", functionKey, time.Since(unloadStart))
-	l.logger.Printf(successMsg)
+	l.logger.Printf("%s", successMsg)
 	l.logStore.AddLog(functionKey, logging.LevelInfo, successMsg)
 	l.logStore.AddLog(functionKey, logging.LevelInfo, "Function unloaded - this is the final log entry")
+	l.transition(functionKey, components.PhaseUnloaded, "requested")
+	l.publish(events.TypeUnloaded, functionKey, "", "requested")
 
 	return nil
 }
 
 // performUnload does the actual work of unloading a function and handling errors.
-func (l *FunctionLoader) performUnload(functionKey string) error {
+func (l *FunctionLoader) performUnload(functionKey FunctionKey) error {
 	// Remove the plugin from the plugin manager
 	if !l.pluginManager.RemovePlugin(functionKey) {
 		// This should not normally happen since we already checked if it's loaded
@@ -266,7 +581,7 @@ func (l *FunctionLoader) StopFunction(namespace, name string) error {
 	// Check if the function is already stopped
 	if l.pluginManager.IsFunctionStopped(functionKey) {
 		alreadyStoppedMsg := fmt.Sprintf("Function %s is already stopped", functionKey)
-		l.logger.Printf(alreadyStoppedMsg)
+		l.logger.Printf("%s", alreadyStoppedMsg)
 		l.logStore.AddLog(functionKey, logging.LevelInfo, alreadyStoppedMsg)
 		return nil
 	}
@@ -281,15 +596,17 @@ func (l *FunctionLoader) StopFunction(namespace, name string) error {
 
 	// Log success
 	successMsg := fmt.Sprintf("Function %s stopped successfully (time: %v)", functionKey, time.Since(stopStart))
-	l.logger.Printf(successMsg)
+	l.logger.Printf("%s", successMsg)
 	l.logStore.AddLog(functionKey, logging.LevelInfo, successMsg)
 	l.logStore.AddLog(functionKey, logging.LevelInfo, "Function stopped - will not be automatically reloaded")
+	l.transition(functionKey, components.PhaseStopped, "requested")
+	l.publish(events.TypeStopped, functionKey, "", "")
 
 	return nil
 }
 
 // performStop does the actual work of stopping a function and handling errors.
-func (l *FunctionLoader) performStop(functionKey string) error {
+func (l *FunctionLoader) performStop(functionKey FunctionKey) error {
 	// Stop the function using the plugin manager's StopFunction method
 	if !l.pluginManager.StopFunction(functionKey) {
 		// This is not an error - it just means the function wasn't loaded to begin with
@@ -321,49 +638,187 @@ func (l *FunctionLoader) IsStopped(namespace, name string) bool {
 	return l.pluginManager.IsFunctionStopped(functionKey)
 }
 
+// Phase returns the lifecycle state of a function.
+func (l *FunctionLoader) Phase(namespace, name string) components.FunctionPhase {
+	return l.lifecycle.Phase(GetFunctionKey(namespace, name))
+}
+
 // Helper methods
 
-// pullWithContext fetches a WASM module with cancellation support
-func (l *FunctionLoader) pullWithContext(ctx context.Context, namespace, name, identifier string) ([]byte, *registry.VersionInfo, error) {
+// pullWithContext fetches a WASM module with cancellation support. The returned release
+// function unmaps a module mapped from storage and must be called once it is compiled.
+func (l *FunctionLoader) pullWithContext(ctx context.Context, namespace, name, identifier string) ([]byte, *registry.VersionInfo, func(), error) {
 	type pullResult struct {
-		bytes []byte
-		info  *registry.VersionInfo
+		bytes   []byte
+		info    *registry.VersionInfo
+		release func()
 	}
 
-	// Create a wrapper function to use the shared utility
-	wrapper := func() (pullResult, error) {
+	task := utils.Task[pullResult]{
+		Name:    "Registry pull",
+		Observe: l.observe(GetFunctionKey(namespace, name)),
+		Discard: func(result pullResult) { result.release() },
+	}
+	result, err := utils.Run(ctx, task, func() (pullResult, error) {
+		if mapper, ok := l.registry.(registry.ModuleMapper); ok && l.mapModules {
+			bytes, info, release, err := mapper.PullMapped(namespace, name, identifier)
+			return pullResult{bytes, info, release}, err
+		}
 		bytes, info, err := l.registry.Pull(namespace, name, identifier)
-		return pullResult{bytes, info}, err
+		return pullResult{bytes, info, func() {}}, err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return result.bytes, result.info, result.release, nil
+}
+
+// compile returns the compiled module of a version loaded with the given config, shared
+// with the functions that already loaded it or else compiled, and the function releasing
+// the function's hold on it.
+//
+//nolint:whitespace // difficult to format exactly as linter expects
+func (l *FunctionLoader) compile(ctx context.Context, key FunctionKey, wasm []byte, release func(),
+	vi *registry.VersionInfo, cfg map[string]string) (*extism.CompiledPlugin, func(), error) {
+	cacheKey := components.CompiledCacheKey(vi.FullDigest, vi.Settings, cfg)
+	if compiled, releaseCompiled, ok := l.compiled.Acquire(cacheKey); ok {
+		release()
+		l.logStore.AddLog(key, logging.LevelInfo, "Reusing the module already compiled with the same digest and config")
+		return compiled, releaseCompiled, nil
 	}
 
-	// Execute with context handling
-	result, err := utils.ExecuteWithContext(ctx, wrapper)
+	compiled, err := l.compilePluginWithContext(ctx, key, wasm, release, vi, cfg)
 	if err != nil {
 		return nil, nil, err
 	}
+	compiled, releaseCompiled := l.compiled.Add(cacheKey, compiled)
+	return compiled, releaseCompiled, nil
+}
 
-	return result.bytes, result.info, nil
+// CompiledStats describes the compiled modules shared between loaded functions.
+func (l *FunctionLoader) CompiledStats() components.CompiledCacheStats {
+	return l.compiled.Stats()
 }
 
-// createPluginWithContext creates a plugin with cancellation support
+// compilePluginWithContext compiles a plugin with cancellation support
 //
 //nolint:whitespace // difficult to format exactly as linter expects
-func (l *FunctionLoader) createPluginWithContext(ctx context.Context, wasmBytes []byte, versionInfo *registry.VersionInfo, config map[string]string) (*extism.Plugin, error) {
+func (l *FunctionLoader) compilePluginWithContext(ctx context.Context, functionKey FunctionKey, wasmBytes []byte, release func(), versionInfo *registry.VersionInfo, config map[string]string) (*extism.CompiledPlugin, error) {
+	// The compiled module keeps no reference to the module bytes, which are released
+	// once the compilation is done. A compilation may outlive a cancelled load, so
+	// whichever comes first, the compilation starting or the load giving up on it,
+	// claims the release.
+	var claimed atomic.Bool
+	defer func() {
+		if claimed.CompareAndSwap(false, true) {
+			release()
+		}
+	}()
+
+	// Resolve the host functions exposed to this function
+	var hostFunctions []extism.HostFunction
+	if l.hostFunctions != nil {
+		hostFunctions = l.hostFunctions()
+	}
+
+	// Fail early with a clear message if the module needs host functions we don't provide
+	if err := checkHostFunctions(versionInfo, hostFunctions); err != nil {
+		return nil, err
+	}
+
+	// A compilation that finishes after the load was cancelled is closed right away
+	task := utils.Task[*extism.CompiledPlugin]{
+		Name:    "Compilation",
+		Discard: func(compiled *extism.CompiledPlugin) { compiled.Close(context.Background()) },
+		Observe: l.observe(functionKey),
+	}
+	return utils.Run(ctx, task, func() (*extism.CompiledPlugin, error) {
+		if !claimed.CompareAndSwap(false, true) {
+			return nil, ctx.Err()
+		}
+		defer release()
+		return components.CompilePlugin(wasmBytes, versionInfo, config, hostFunctions...)
+	})
+}
 
-	// Create a wrapper function to use the shared utility
-	wrapper := func() (*extism.Plugin, error) {
-		return components.CreatePlugin(wasmBytes, versionInfo, config)
+// instantiatePluginWithContext creates an instance of a compiled plugin with cancellation support
+func instantiatePluginWithContext(ctx context.Context, compiled *extism.CompiledPlugin) (*extism.Plugin, error) {
+	task := utils.Task[*extism.Plugin]{
+		Name:    "Instantiation",
+		Discard: func(plugin *extism.Plugin) { plugin.Close(context.Background()) },
 	}
+	return utils.Run(ctx, task, func() (*extism.Plugin, error) {
+		return components.InstantiatePlugin(compiled)
+	})
+}
 
-	// Execute with context cancellation handling
-	plugin, err := utils.ExecuteWithContext(ctx, wrapper)
+// observe records load steps that panicked or outlived a cancelled load in the function logs.
+func (l *FunctionLoader) observe(functionKey FunctionKey) func(utils.Observation) {
+	return func(o utils.Observation) {
+		switch {
+		case o.Panicked:
+			l.logStore.AddLog(functionKey, logging.LevelError, o.Err.Error())
+		case o.Abandoned:
+			l.logStore.AddLog(functionKey, logging.LevelWarning,
+				fmt.Sprintf("%s finished %v after the load was cancelled", o.Name, o.Duration.Round(time.Millisecond)))
+		}
+	}
+}
 
-	// Clean up resources on error
-	if err != nil && plugin != nil {
-		plugin.Close(context.Background())
+// checkHostFunctions verifies that every host function imported by the module is available.
+func checkHostFunctions(versionInfo *registry.VersionInfo, hostFunctions []extism.HostFunction) error {
+	if versionInfo == nil || versionInfo.Module == nil {
+		return nil
 	}
 
-	return plugin, err
+	provided := make(map[string]bool, len(hostFunctions))
+	for _, fn := range hostFunctions {
+		provided[fn.Name] = true
+	}
+
+	var missing []string
+	for _, name := range versionInfo.Module.HostFunctions {
+		if !provided[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("module imports unsupported host functions: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// GetVersionSettings returns the settings of the version a function was last loaded with
+func (l *FunctionLoader) GetVersionSettings(namespace, name string) (manifest.FunctionVersionSettings, bool) {
+	l.settingsMu.RLock()
+	defer l.settingsMu.RUnlock()
+	settings, ok := l.settings[GetFunctionKey(namespace, name)]
+	return settings, ok
+}
+
+// Egress returns the egress policy and proxy of the version a function was last loaded with
+func (l *FunctionLoader) Egress(functionKey FunctionKey) (functionEgress, bool) {
+	l.settingsMu.RLock()
+	defer l.settingsMu.RUnlock()
+	fe, ok := l.egress[functionKey]
+	return fe, ok
+}
+
+// functionProxy returns the proxy selection of a function's HTTP requests: the proxy it
+// sets, none when it sets proxy.Direct, or else the engine's.
+func functionProxy(setting string, engineProxy proxy.Settings) (egress.ProxyFunc, error) {
+	switch setting {
+	case "":
+		return engineProxy.Func(), nil
+	case proxy.Direct:
+		return func(*http.Request) (*url.URL, error) { return nil, nil }, nil
+	}
+	if _, err := proxy.Parse(setting); err != nil {
+		return nil, err
+	}
+	return proxy.Settings{HTTP: setting, HTTPS: setting, NoProxy: engineProxy.NoProxy}.Func(), nil
 }
 
 // GetDigest returns the current digest of a function
@@ -62,6 +62,7 @@ func (m *FunctionManagerImpl) GetFunctionState(namespace, name string) FunctionS
 		Loaded:           isLoaded,
 		Stopped:          isStopped,
 		PreviouslyLoaded: wasLoaded,
+		Phase:            string(m.loader.Phase(namespace, name)),
 		Config:           config,
 	}
 
@@ -95,13 +96,21 @@ func (m *FunctionManagerImpl) GetFunctionState(namespace, name string) FunctionS
 				state.Tags = tags
 			}
 		}
+
+		// Execution stats come from the metrics collector
+		stats := m.executor.GetStats(namespace, name)
+		state.LastExecutionTime = stats.LastExecution
+		state.TotalExecutions = stats.TotalExecutions
+		state.SuccessfulExecutions = stats.SuccessfulExecutions
+		state.FailedExecutions = stats.FailedExecutions
 	}
 
 	return state
 }
 
-// BuildFunction builds a function and stores it in the registry
-func (m *FunctionManagerImpl) BuildFunction(namespace, name, path, tag string, config manifest.FunctionManifest) (*types.BuildResult, error) {
+// BuildFunction builds a function and stores it in the registry, along with the
+// provenance and SBOM of the build when the registry records them
+func (m *FunctionManagerImpl) BuildFunction(namespace, name, path, tag string, config manifest.FunctionManifest, opts types.BuildOptions) (*types.BuildResult, error) {
 	// Track build time
 	buildStart := time.Now()
 
@@ -114,7 +123,7 @@ func (m *FunctionManagerImpl) BuildFunction(namespace, name, path, tag string, c
 	}
 
 	// Build the function
-	buildResult, err := m.functionSvc.BuildFunction(path, config)
+	buildResult, err := m.functionSvc.BuildFunction(path, config, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build function: %w", err)
 	}
@@ -134,6 +143,17 @@ func (m *FunctionManagerImpl) BuildFunction(namespace, name, path, tag string, c
 	if err := m.registry.Push(namespace, name, wasmBytes, buildResult.Digest, tag, config.FunctionSettings.VersionSettings); err != nil {
 		return nil, fmt.Errorf("failed to store in registry: %w", err)
 	}
+	if recorder, ok := m.registry.(registry.ProvenanceRecorder); ok {
+		if err := recorder.RecordProvenance(namespace, name, buildResult.Digest, buildResult.Provenance); err != nil {
+			return nil, fmt.Errorf("failed to record build provenance: %w", err)
+		}
+	}
+	if store, ok := m.registry.(registry.SBOMStore); ok && buildResult.Components != nil {
+		sbom := registry.NewSBOM(namespace, name, buildResult.Digest, buildResult.Components)
+		if err := store.AttachSBOM(namespace, name, buildResult.Digest, sbom); err != nil {
+			return nil, fmt.Errorf("failed to attach SBOM: %w", err)
+		}
+	}
 
 	// Return build result
 	return &types.BuildResult{
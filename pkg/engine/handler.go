@@ -1,91 +1,204 @@
 package engine
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	extism "github.com/extism/go-sdk"
 	"github.com/go-playground/validator/v10"
+	"github.com/ignitionstack/ignition/internal/selfupdate"
+	"github.com/ignitionstack/ignition/pkg/engine/audit"
 	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/dlq"
 	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
 	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/engine/metrics"
+	"github.com/ignitionstack/ignition/pkg/engine/usage"
+	"github.com/ignitionstack/ignition/pkg/engine/utils"
 	"github.com/ignitionstack/ignition/pkg/registry"
 	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/ignitionstack/ignition/pkg/validation"
 )
 
 type Handlers struct {
 	engine    *Engine // The engine instance that provides all functionality
 	logger    logging.Logger
 	validator *validator.Validate
+
+	// Closed when the servers shut down, ending streaming responses
+	streamsDone  chan struct{}
+	closeStreams func()
+
+	// Responses of load and build requests by idempotency key (nil when disabled)
+	idempotency *idempotencyStore
+
+	// Held while an apply converges the engine, so applies do not interleave
+	applying sync.Mutex
 }
 
 func NewHandlers(engine *Engine, logger logging.Logger) *Handlers {
-	return &Handlers{
-		engine:    engine,
-		logger:    logger,
-		validator: validator.New(),
+	streamsDone := make(chan struct{})
+	h := &Handlers{
+		engine:       engine,
+		logger:       logger,
+		validator:    validator.New(),
+		streamsDone:  streamsDone,
+		closeStreams: sync.OnceFunc(func() { close(streamsDone) }),
+	}
+	if opts := engine.options; opts != nil && opts.Idempotency.Window > 0 {
+		h.idempotency = newIdempotencyStore(opts.Idempotency.Window, opts.Idempotency.MaxKeys, engine.clock.Now)
 	}
+	return h
 }
 
 func (h *Handlers) UnixSocketHandler() http.Handler {
 	mux := http.NewServeMux()
 
 	// Common middleware stack for socket handlers
-	commonMiddleware := []Middleware{
-		h.methodMiddleware(http.MethodPost),
-		h.loggingMiddleware(),
-		h.errorMiddleware(),
+	commonMiddleware := MiddlewareChain{
+		{MiddlewareMethod, h.methodMiddleware(http.MethodPost)},
+		{MiddlewareLogging, h.loggingMiddleware()},
+		{MiddlewareErrors, h.errorMiddleware()},
 	}
 
 	// Common middleware stack for GET endpoints
-	getMiddleware := []Middleware{
-		h.methodMiddleware(http.MethodGet),
-		h.loggingMiddleware(),
-		h.errorMiddleware(),
+	getMiddleware := MiddlewareChain{
+		{MiddlewareMethod, h.methodMiddleware(http.MethodGet)},
+		{MiddlewareLogging, h.loggingMiddleware()},
+		{MiddlewareErrors, h.errorMiddleware()},
 	}
 
 	// Register socket endpoints
-	mux.HandleFunc("/load", h.withMiddleware(h.handleLoad, commonMiddleware...))
-	mux.HandleFunc("/unload", h.withMiddleware(h.handleUnload, commonMiddleware...))
-	mux.HandleFunc("/stop", h.withMiddleware(h.handleStop, commonMiddleware...))
-	mux.HandleFunc("/list", h.withMiddleware(h.handleList, commonMiddleware...))
-	mux.HandleFunc("/build", h.withMiddleware(h.handleBuild, commonMiddleware...))
-	mux.HandleFunc("/reassign-tag", h.withMiddleware(h.handleReassignTag, commonMiddleware...))
-	mux.HandleFunc("/call-once", h.withMiddleware(h.handleOneOffCall, commonMiddleware...))
-	mux.HandleFunc("/status", h.withMiddleware(h.handleStatus, h.methodMiddleware(http.MethodGet), h.errorMiddleware()))
-	mux.HandleFunc("/loaded", h.withMiddleware(h.handleLoadedFunctions, h.methodMiddleware(http.MethodGet), h.errorMiddleware()))
-	mux.HandleFunc("/logs/", h.withMiddleware(h.handleFunctionLogs, getMiddleware...))
+	h.handle(mux, APIAdmin, "/load", h.handleLoad, h.idempotent(h.privileged(audit.OperationLoad, commonMiddleware)))
+	h.handle(mux, APIAdmin, "/load-batch", h.handleLoadBatch, h.idempotent(h.privileged(audit.OperationLoadBatch, commonMiddleware)))
+	h.handle(mux, APIAdmin, "/apply", h.handleApply, h.idempotent(h.privileged(audit.OperationApply, commonMiddleware)))
+	h.handle(mux, APIAdmin, "/apply/plan", h.handleApplyPlan, commonMiddleware)
+	h.handle(mux, APIAdmin, "/unload", h.handleUnload, h.privileged(audit.OperationUnload, commonMiddleware))
+	h.handle(mux, APIAdmin, "/stop", h.handleStop, h.privileged(audit.OperationStop, commonMiddleware))
+	h.handle(mux, APIAdmin, "/list", h.handleList, commonMiddleware)
+	h.handle(mux, APIAdmin, "/build", h.handleBuild, h.idempotent(h.privileged(audit.OperationBuild, commonMiddleware)))
+	h.handle(mux, APIAdmin, "/builds/", h.handleBuildLogs, getMiddleware)
+	h.handle(mux, APIAdmin, "/scale", h.handleScale, h.privileged(audit.OperationScale, commonMiddleware))
+	h.handle(mux, APIAdmin, "/call-logging", h.handleCallLogging, h.audited(audit.OperationCallLogging, commonMiddleware))
+	h.handle(mux, APIAdmin, "/reassign-tag", h.handleReassignTag, h.privileged(audit.OperationReassignTag, commonMiddleware))
+	h.handle(mux, APIAdmin, "/registry/pull", h.handleRegistryPull, commonMiddleware)
+	h.handle(mux, APIAdmin, "/registry/push", h.handleRegistryPush, h.privileged(audit.OperationPush, commonMiddleware))
+	h.handle(mux, APIAdmin, "/registry/variant", h.handleRegistryVariant, h.privileged(audit.OperationPush, commonMiddleware))
+	h.handle(mux, APIAdmin, "/registry/sbom", h.handleRegistrySBOM, commonMiddleware)
+	h.handle(mux, APIAdmin, "/registry/sync", h.handleRegistrySync, h.audited(audit.OperationSync, commonMiddleware))
+	h.handle(mux, APIAdmin, "/call", h.handleCall, commonMiddleware)
+	h.handle(mux, APIAdmin, "/call-once", h.handleOneOffCall, commonMiddleware)
+	h.handle(mux, APIAdmin, "/status", h.handleStatus, getMiddleware.Without(MiddlewareLogging))
+	h.handle(mux, APIAdmin, "/loaded", h.handleLoadedFunctions, getMiddleware.Without(MiddlewareLogging))
+	h.handle(mux, APIAdmin, "/logs/", h.handleFunctionLogs, getMiddleware)
+	h.handle(mux, APIAdmin, "/admin/maintenance", h.handleMaintenanceReport, getMiddleware)
+	h.handle(mux, APIAdmin, "/admin/maintenance/run", h.handleRunMaintenance, commonMiddleware)
+	h.handle(mux, APIAdmin, "/audit", h.handleAudit, getMiddleware)
+	h.handle(mux, APIAdmin, "/snapshot", h.handleSnapshot, getMiddleware)
+	h.handle(mux, APIAdmin, "/usage", h.handleUsage, getMiddleware)
+	h.handle(mux, APIAdmin, "/metrics", h.handleMetrics, getMiddleware.Without(MiddlewareLogging))
+	h.handle(mux, APIAdmin, "/circuit-breakers", h.handleCircuitBreakers, getMiddleware)
+	h.handle(mux, APIAdmin, "/events", h.handleEvents, getMiddleware)
+	h.handle(mux, APIAdmin, "/dlq/", h.handleDeadLetters, commonMiddleware.Without(MiddlewareMethod))
+	// Redrives and purges change the store, so they are a route of their own
+	h.handle(mux, APIAdmin, "POST /dlq/{namespace}/{name}/{action}", h.handleDeadLetters,
+		h.privileged(audit.OperationDeadLetter, commonMiddleware.Without(MiddlewareMethod)))
+	h.handle(mux, APIAdmin, "/pipelines/register", h.handleRegisterPipeline, h.privileged(audit.OperationRegisterPipeline, commonMiddleware))
+	h.handle(mux, APIAdmin, "/pipelines/unregister", h.handleUnregisterPipeline, h.privileged(audit.OperationUnregisterPipeline, commonMiddleware))
 
 	return mux
 }
 
+// audited puts the audit middleware innermost so it sees the handler's result.
+func (h *Handlers) audited(operation string, chain MiddlewareChain) MiddlewareChain {
+	return chain.Innermost(NamedMiddleware{MiddlewareAudit, h.auditMiddleware(operation)})
+}
+
+// privileged audits a destructive operation and restricts it to the privileged uids,
+// checking the caller inside the audit middleware so refusals are recorded too.
+func (h *Handlers) privileged(operation string, chain MiddlewareChain) MiddlewareChain {
+	return h.audited(operation, chain).Innermost(NamedMiddleware{MiddlewarePrivileged, h.privilegedUIDMiddleware()})
+}
+
 func (h *Handlers) HTTPHandler() http.Handler {
+	return h.httpMux(true)
+}
+
+// httpMux serves function calls and health checks, and pipeline calls if asked to.
+func (h *Handlers) httpMux(withPipelines bool) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Common middleware stack for HTTP handlers
-	commonMiddleware := []Middleware{
-		h.corsMiddleware(),
-		h.loggingMiddleware(),
-		h.errorMiddleware(),
+	commonMiddleware := MiddlewareChain{
+		{MiddlewareCORS, h.corsMiddleware()},
+		{MiddlewareLogging, h.loggingMiddleware()},
+		{MiddlewareCompression, h.compressionMiddleware()},
+		{MiddlewareErrors, h.errorMiddleware()},
+	}
+	if options := h.engine.options; options != nil && !options.CORSEnabled {
+		commonMiddleware = commonMiddleware.Without(MiddlewareCORS)
 	}
 
 	// Register HTTP endpoints
-	mux.HandleFunc("/", h.withMiddleware(h.handleFunctionCall,
-		append(commonMiddleware, h.methodMiddleware(http.MethodPost))...))
+	h.handle(mux, APIHTTP, "/", h.handleFunctionCall,
+		commonMiddleware.Outermost(NamedMiddleware{MiddlewareMethod, h.functionMethodMiddleware()}))
+
+	// Pipelines are addressed as /pipelines/name
+	if withPipelines {
+		h.handle(mux, APIHTTP, "/pipelines/", h.handlePipelineCall,
+			commonMiddleware.Outermost(NamedMiddleware{MiddlewareMethod, h.methodMiddleware(http.MethodPost)}))
+	}
 
-	// Add health check endpoint
-	mux.HandleFunc("/health", h.withMiddleware(h.handleHealth,
-		h.methodMiddleware(http.MethodGet), h.errorMiddleware()))
+	// Health endpoints: /healthz for liveness, /readyz and /health for dependency checks
+	healthMiddleware := MiddlewareChain{
+		{MiddlewareMethod, h.methodMiddleware(http.MethodGet)},
+		{MiddlewareErrors, h.errorMiddleware()},
+	}
+	h.handle(mux, APIHTTP, "/health", h.handleHealth, healthMiddleware)
+	h.handle(mux, APIHTTP, "/readyz", h.handleHealth, healthMiddleware)
+	h.handle(mux, APIHTTP, "/healthz", h.handleLiveness, healthMiddleware)
 
 	return mux
 }
 
+// MetricsHandler serves GET /metrics in the Prometheus format, for the metrics listener.
+// It serves nothing unless the engine uses the prometheus collector.
+func (h *Handlers) MetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	h.handle(mux, APIMetrics, "/metrics", h.handleMetrics, MiddlewareChain{
+		{MiddlewareMethod, h.methodMiddleware(http.MethodGet)},
+		{MiddlewareErrors, h.errorMiddleware()},
+	})
+	return mux
+}
+
+// handleMetrics writes the metrics of the functions in the request's namespace scope.
+// Collectors supplied by embedders that serve their own format are served as they are.
+func (h *Handlers) handleMetrics(w http.ResponseWriter, r *http.Request) error {
+	switch exporter := h.engine.metrics.(type) {
+	case *metrics.Prometheus:
+		exporter.Write(w, func(key FunctionKey) bool {
+			return namespaceInScope(r.Context(), key.Namespace)
+		})
+	case http.Handler:
+		exporter.ServeHTTP(w, r)
+	default:
+		return NewNotFoundError("Metrics are only served with engine.metrics.collector prometheus")
+	}
+	return nil
+}
+
 // decodeJSONRequest decodes a JSON request body into a struct.
 func (h *Handlers) decodeJSONRequest(r *http.Request, v interface{}) error {
 	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
@@ -99,10 +212,78 @@ func (h *Handlers) decodeAndValidate(r *http.Request, v interface{}) error {
 		return NewBadRequestError("Invalid request body")
 	}
 
+	return h.validate(v)
+}
+
+// validate checks a decoded request against its validation tags and naming rules.
+func (h *Handlers) validate(v interface{}) error {
 	if err := h.validator.Struct(v); err != nil {
 		return NewBadRequestError(fmt.Sprintf("Validation failed: %v", err))
 	}
 
+	// Enforce the shared naming rules on requests that identify a function
+	if validatable, ok := v.(interface{ Validate() error }); ok {
+		if err := validatable.Validate(); err != nil {
+			return NewBadRequestError(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// decodeCallRequest decodes the request of a call made through the admin API and returns
+// its raw payload, if any. Besides a JSON body, it accepts multipart/form-data with the JSON
+// request in a "request" part and the payload in a "payload" part, sent as is so binary
+// payloads are not mangled by JSON string encoding.
+func (h *Handlers) decodeCallRequest(r *http.Request, v interface{}) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return nil, h.decodeAndValidate(r, v)
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, NewBadRequestError("Invalid multipart request body")
+	}
+
+	var payload []byte
+	decoded := false
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, bodyReadError(err)
+		}
+
+		switch part.FormName() {
+		case types.CallRequestPart:
+			if err := json.NewDecoder(part).Decode(v); err != nil {
+				return nil, NewBadRequestError("Invalid request part")
+			}
+			decoded = true
+		case types.CallPayloadPart:
+			if payload, err = io.ReadAll(part); err != nil {
+				return nil, bodyReadError(err)
+			}
+			if payload == nil {
+				payload = []byte{}
+			}
+		}
+	}
+	if !decoded {
+		return nil, NewBadRequestError(fmt.Sprintf("Missing %q part", types.CallRequestPart))
+	}
+
+	return payload, h.validate(v)
+}
+
+// validateFunctionRef rejects namespaces and names taken from a URL path that break the naming rules.
+func validateFunctionRef(namespace, name string) error {
+	if err := validation.ValidateFunction(namespace, name); err != nil {
+		return NewBadRequestError(err.Error())
+	}
 	return nil
 }
 
@@ -122,14 +303,74 @@ func (h *Handlers) handleLoad(w http.ResponseWriter, r *http.Request) error {
 	h.logger.Printf("Received load request for function: %s/%s (digest: %s)",
 		req.Namespace, req.Name, req.Digest)
 
-	ctx := r.Context()
-	if err := h.engine.LoadFunctionWithForce(ctx, req.Namespace, req.Name, req.Digest, req.Config, req.ForceLoad); err != nil {
+	if err := h.loadFunction(r.Context(), req); err != nil {
 		return err
 	}
 
 	return h.writeJSONResponse(w, map[string]string{"message": "Function loaded successfully"})
 }
 
+// loadFunction imports and loads the function of a validated load request, applies its
+// settings and registers its service.
+func (h *Handlers) loadFunction(ctx context.Context, req types.LoadRequest) error {
+	identifier := req.Digest
+
+	// Import prebuilt modules into the registry, then load the imported version
+	if req.Source != "" {
+		digest, err := h.engine.ImportFunction(ctx, req.Namespace, req.Name, req.Source, req.SourceDigest, req.Digest)
+		if err != nil {
+			return importError(req.Source, err)
+		}
+		identifier = registry.TruncateDigest(digest, 12)
+	}
+
+	config := loadConfig(req)
+
+	// Validate has already checked the policy, priority and call logging
+	reloadPolicy, _ := types.ParseReloadPolicy(req.ReloadPolicy)
+	priority, _ := components.ParsePriority(req.Priority)
+	callLogging, _ := types.ParseCallLogging(req.CallLogging)
+
+	// Every load names its variant, so omitting it returns to the preferred variants
+	ctx = withVariant(ctx, req.Variant)
+	if err := h.engine.LoadFunctionWithForce(ctx, req.Namespace, req.Name, identifier, config, req.ForceLoad); err != nil {
+		return err
+	}
+
+	// Every load sets the log retention, so omitting it restores the defaults
+	h.engine.SetFunctionLogRetention(req.Namespace, req.Name, logging.Retention{
+		MaxEntries: req.LogMaxEntries,
+		MaxAge:     time.Duration(req.LogMaxAgeMs) * time.Millisecond,
+	})
+	h.engine.SetReloadPolicy(req.Namespace, req.Name, reloadPolicy)
+	h.engine.SetFunctionPriority(req.Namespace, req.Name, priority)
+	h.engine.SetFunctionMaxQueueWait(req.Namespace, req.Name, time.Duration(req.MaxQueueWaitMs)*time.Millisecond)
+	h.engine.SetFunctionCallLogging(req.Namespace, req.Name, callLogging)
+
+	// Register the service alias so other functions can address it by name
+	if req.Service != "" {
+		if err := h.engine.RegisterService(req.Service, req.Namespace, req.Name); err != nil {
+			return NewBadRequestError(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// loadConfig returns the config a load request loads its function with, which exposes
+// the service name to the function.
+func loadConfig(req types.LoadRequest) map[string]string {
+	if req.Service == "" {
+		return req.Config
+	}
+	config := make(map[string]string, len(req.Config)+1)
+	for k, v := range req.Config {
+		config[k] = v
+	}
+	config[ServiceNameConfigKey] = req.Service
+	return config
+}
+
 // handleList lists functions in the registry.
 func (h *Handlers) handleList(w http.ResponseWriter, r *http.Request) error {
 	var req types.FunctionRequest
@@ -145,6 +386,9 @@ func (h *Handlers) handleList(w http.ResponseWriter, r *http.Request) error {
 	if req.Namespace == "" && req.Name == "" {
 		return h.handleListAll(w, r)
 	}
+	if err := validateFunctionRef(req.Namespace, req.Name); err != nil {
+		return err
+	}
 
 	h.logger.Printf("Received list request for function: %s/%s", req.Namespace, req.Name)
 
@@ -171,58 +415,22 @@ func (h *Handlers) handleListAll(w http.ResponseWriter, _ *http.Request) error {
 	return h.writeJSONResponse(w, functions)
 }
 
-// handleLoadedFunctions lists currently loaded, previously loaded, and stopped functions.
+// handleLoadedFunctions lists every function the engine has loaded, with its lifecycle state.
 func (h *Handlers) handleLoadedFunctions(w http.ResponseWriter, _ *http.Request) error {
 	h.logger.Printf("Received request to list loaded functions")
 
-	// Get list of loaded functions from the plugin manager
-	functionKeys := h.engine.pluginManager.ListLoadedFunctions()
-
-	// Create a set of loaded functions for fast lookup
-	loadedFunctionsSet := make(map[string]bool, len(functionKeys))
-	for _, key := range functionKeys {
-		loadedFunctionsSet[key] = true
-	}
-
-	// Start with currently running functions
-	loadedFunctions := make([]types.LoadedFunction, 0, len(functionKeys))
-	for _, key := range functionKeys {
-		parts := strings.Split(key, "/")
-		if len(parts) == 2 {
-			loadedFunctions = append(loadedFunctions, types.LoadedFunction{
-				Namespace: parts[0],
-				Name:      parts[1],
-				Status:    "running",
-			})
-		}
-	}
-
-	// Get previously loaded functions and stopped functions
-	previouslyLoadedMap := h.engine.pluginManager.GetPreviouslyLoadedFunctions()
-	stoppedFunctionsMap := h.engine.pluginManager.GetStoppedFunctions()
-
-	// Process all function keys we know about
-	for key := range previouslyLoadedMap {
-		// Skip if it's already in the loaded list
-		if loadedFunctionsSet[key] {
-			continue
-		}
-
-		parts := strings.Split(key, "/")
-		if len(parts) == 2 {
-			// Determine the status - "stopped" takes precedence over "unloaded"
-			status := "unloaded"
-			if _, isStopped := stoppedFunctionsMap[key]; isStopped {
-				status = "stopped"
-			}
-
-			loadedFunctions = append(loadedFunctions, types.LoadedFunction{
-				Namespace: parts[0],
-				Name:      parts[1],
-				Status:    status,
-			})
-		}
+	phases := h.engine.lifecycle.Phases()
+	loadedFunctions := make([]types.LoadedFunction, 0, len(phases))
+	for key, phase := range phases {
+		loadedFunctions = append(loadedFunctions, types.LoadedFunction{
+			Namespace: key.Namespace,
+			Name:      key.Name,
+			Status:    string(phase),
+		})
 	}
+	slices.SortFunc(loadedFunctions, func(a, b types.LoadedFunction) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
 
 	return h.writeJSONResponse(w, loadedFunctions)
 }
@@ -236,9 +444,18 @@ func (h *Handlers) handleBuild(w http.ResponseWriter, r *http.Request) error {
 
 	h.logger.Printf("Received build request for function: %s/%s", req.Namespace, req.Name)
 
-	result, err := h.engine.BuildFunction(req.Namespace, req.Name, req.Path, req.Tag, req.Manifest)
+	buildID := cmp.Or(req.BuildID, newBuildID())
+	opts := types.BuildOptions{Reproducible: req.Reproducible, Debug: req.Debug, BuildID: buildID}
+	result, err := h.engine.BuildFunction(req.Namespace, req.Name, req.Path, req.Tag, req.Manifest, opts)
 	if err != nil {
-		return NewInternalServerError(fmt.Sprintf("Build failed: %v", err))
+		if reqErr := admissionError(err); reqErr != nil {
+			return *reqErr
+		}
+		if errors.Is(err, ErrBuildInProgress) {
+			return NewRequestError(fmt.Sprintf("Build %s is already running", buildID), http.StatusConflict)
+		}
+		// The error ends with the last lines of the output; the rest is kept under the build ID
+		return NewInternalServerError(fmt.Sprintf("Build %s failed (full output at /builds/%s/logs): %v", buildID, buildID, err))
 	}
 
 	response := types.BuildResponse{
@@ -246,11 +463,41 @@ func (h *Handlers) handleBuild(w http.ResponseWriter, r *http.Request) error {
 		Tag:       result.Tag,
 		Status:    "success",
 		BuildTime: result.BuildTime,
+		BuildID:   result.BuildID,
 	}
 
 	return h.writeJSONResponse(w, response)
 }
 
+// handleBuildLogs returns the state and output of a recent build, from the byte
+// given by the offset parameter.
+func (h *Handlers) handleBuildLogs(w http.ResponseWriter, r *http.Request) error {
+	// Parse path: /builds/id/logs
+	pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, "/builds/"), "/")
+	if len(pathParts) != 2 || pathParts[1] != "logs" {
+		return NewBadRequestError("Invalid URL format: expected /builds/id/logs")
+	}
+	id := pathParts[0]
+	if err := validation.ValidateBuildID(id); err != nil {
+		return NewBadRequestError(err.Error())
+	}
+
+	var offset int64
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		var err error
+		offset, err = strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || offset < 0 {
+			return NewBadRequestError(fmt.Sprintf("Invalid 'offset' parameter: %q", offsetStr))
+		}
+	}
+
+	log, ok := h.engine.BuildLog(id, offset)
+	if !ok {
+		return NewNotFoundError(fmt.Sprintf("Build %s not found", id))
+	}
+	return h.writeJSONResponse(w, log)
+}
+
 // handleFunctionCall handles function calls via HTTP.
 func (h *Handlers) handleFunctionCall(w http.ResponseWriter, r *http.Request) error {
 	// Parse the request
@@ -258,51 +505,249 @@ func (h *Handlers) handleFunctionCall(w http.ResponseWriter, r *http.Request) er
 	if err != nil {
 		return err
 	}
+	defer callParams.spooled.Remove()
+
+	// Listeners scoped to some namespaces hide the functions of the others
+	if !namespaceInScope(r.Context(), callParams.namespace) {
+		return NewNotFoundError("Function not found")
+	}
 
 	// Log the request
 	h.logger.Printf("Received call request for function: %s/%s, entrypoint: %s",
 		callParams.namespace, callParams.name, callParams.entrypoint)
 
+	mode, err := streamMode(r)
+	if err != nil {
+		return err
+	}
+	envelope, err := callEnvelopeRequested(r)
+	if err != nil {
+		return err
+	}
+	if envelope && mode != "" {
+		return NewBadRequestError("Streamed calls cannot be wrapped in a call envelope")
+	}
+
+	ctx, cancel, err := h.callContext(r)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	if callParams.spooled != nil {
+		ctx = withSpooledPayload(ctx, callParams.spooled)
+	}
+
+	// Stream the chunks the function emits, if asked to, and end with its output
+	start := time.Now()
+	if mode != "" {
+		stream := newHTTPChunkStream(w, mode)
+		output, err := h.executeFunction(WithChunkWriter(ctx, stream.write), callParams, payload)
+		return stream.finish(output, err, start)
+	}
+
+	// Send the response, wrapped with execution metadata when the caller asks for it,
+	// or honoring the response envelope when the function opts in
+	if envelope || h.usesHTTPEnvelope(callParams.namespace, callParams.name) {
+		output, err := h.executeFunction(ctx, callParams, payload)
+		setExecutionTime(w, start)
+		if err != nil {
+			return err
+		}
+		if envelope {
+			return h.sendCallEnvelope(w, r, callParams, output, start)
+		}
+		return h.sendEnvelopeResponse(w, output)
+	}
+
+	// Emitted chunks past the spool threshold wait in a temporary file, not in memory
+	spool := newOutputSpool(h.engine.options.SpoolThreshold, h.engine.options.SpoolDir)
+	defer spool.Remove()
+
 	// Execute the function with auto-reload capability
-	output, err := h.executeFunction(r.Context(), callParams, payload)
+	output, err := h.executeFunction(WithChunkWriter(ctx, spool.write), callParams, payload)
+	spool.close()
+	setExecutionTime(w, start)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return spool.writeTo(w, output)
+}
+
+// handlePipelineCall runs a registered pipeline via HTTP.
+func (h *Handlers) handlePipelineCall(w http.ResponseWriter, r *http.Request) error {
+	name := strings.TrimPrefix(r.URL.Path, "/pipelines/")
+	if name == "" || strings.Contains(name, "/") {
+		return NewBadRequestError("Invalid URL format: expected /pipelines/name")
+	}
+
+	steps, ok := h.engine.pipelines.Get(name)
+	if !ok {
+		return NewNotFoundError(fmt.Sprintf("Pipeline not found: %s", name))
+	}
+
+	payload, err := decodeCallPayload(r)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Printf("Received call request for pipeline: %s (%d steps)", name, len(steps))
+
+	ctx, cancel, err := h.callContext(r)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	start := time.Now()
+	output, err := h.runPipeline(ctx, name, steps, payload)
+	setExecutionTime(w, start)
 	if err != nil {
 		return err
 	}
 
-	// Send the response
 	return h.sendFunctionResponse(w, output)
 }
 
+// handleRegisterPipeline registers or replaces a pipeline.
+func (h *Handlers) handleRegisterPipeline(w http.ResponseWriter, r *http.Request) error {
+	var req types.RegisterPipelineRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	h.engine.RegisterPipeline(req.Name, req.Steps)
+
+	return h.writeJSONResponse(w, map[string]string{"message": "Pipeline registered successfully"})
+}
+
+// handleUnregisterPipeline removes a pipeline.
+func (h *Handlers) handleUnregisterPipeline(w http.ResponseWriter, r *http.Request) error {
+	var req types.UnregisterPipelineRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	if !h.engine.UnregisterPipeline(req.Name) {
+		return NewNotFoundError(fmt.Sprintf("Pipeline not found: %s", req.Name))
+	}
+
+	return h.writeJSONResponse(w, map[string]string{"message": "Pipeline unregistered successfully"})
+}
+
 // functionCallParams contains the parsed parameters of a function call.
 type functionCallParams struct {
 	namespace  string
 	name       string
 	entrypoint string
+
+	// Path below the entrypoint, for functions that take whole HTTP requests
+	path string
+
+	// Request body kept in a temporary file, for functions that spool payloads
+	spooled *spooledPayload
 }
 
 func (h *Handlers) parseFunctionCallRequest(r *http.Request) (*functionCallParams, string, error) {
-	pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
-	if len(pathParts) != 3 {
-		return nil, "", NewBadRequestError("Invalid URL format: expected /namespace/name/entrypoint")
+	params, err := h.parseFunctionCallPath(r.URL.Path)
+	if err != nil {
+		return nil, "", err
 	}
 
-	payload := ""
+	if h.usesHTTPRequest(params.namespace, params.name) {
+		payload, err := encodeHTTPRequest(r, params.path)
+		if err != nil {
+			return nil, "", err
+		}
+		return params, payload, nil
+	}
 
-	if r.ContentLength > 0 {
-		var req struct {
-			Payload string `json:"payload,omitempty"`
+	if isRawPayload(r) {
+		var threshold int64
+		if h.spoolsPayloads(params.namespace, params.name) {
+			threshold = h.engine.options.SpoolThreshold
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			return nil, "", NewBadRequestError("Invalid JSON request body")
+		payload, spooled, err := readRawPayload(r, threshold, h.engine.options.SpoolDir)
+		if err != nil {
+			return nil, "", err
 		}
-		payload = req.Payload
+		params.spooled = spooled
+		return params, string(payload), nil
+	}
+
+	payload, err := decodeCallPayload(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return params, payload, nil
+}
+
+// parseFunctionCallPath finds the function and entrypoint a call path addresses. Paths
+// may only continue below the entrypoint for functions that take whole HTTP requests.
+func (h *Handlers) parseFunctionCallPath(path string) (*functionCallParams, error) {
+	pathParts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	subPath := func(from int) string {
+		return "/" + strings.Join(pathParts[from:], "/")
+	}
+
+	// /service/entrypoint addresses a function by its compose service name
+	if len(pathParts) >= 2 {
+		namespace, name, ok := h.engine.ResolveService(pathParts[0])
+		if ok && (len(pathParts) == 2 || h.usesHTTPRequest(namespace, name)) {
+			return &functionCallParams{namespace: namespace, name: name, entrypoint: pathParts[1], path: subPath(2)}, nil
+		}
+		if len(pathParts) == 2 {
+			return nil, NewNotFoundError(fmt.Sprintf("Service not found: %s", pathParts[0]))
+		}
+	}
+
+	if len(pathParts) >= 3 {
+		if err := validateFunctionRef(pathParts[0], pathParts[1]); err != nil {
+			return nil, err
+		}
+		if len(pathParts) == 3 || h.usesHTTPRequest(pathParts[0], pathParts[1]) {
+			return &functionCallParams{namespace: pathParts[0], name: pathParts[1], entrypoint: pathParts[2], path: subPath(3)}, nil
+		}
+	}
+
+	return nil, NewBadRequestError("Invalid URL format: expected /namespace/name/entrypoint or /service/entrypoint")
+}
+
+// isRawPayload reports whether the body of an HTTP call is the payload itself rather
+// than a {"payload": ...} document.
+func isRawPayload(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/octet-stream"
+}
+
+// decodeCallPayload reads the optional {"payload": ...} body of an HTTP call, or the
+// raw payload sent as application/octet-stream.
+func decodeCallPayload(r *http.Request) (string, error) {
+	if r.ContentLength == 0 {
+		return "", nil
+	}
+	if isRawPayload(r) {
+		payload, _, err := readRawPayload(r, 0, "")
+		return string(payload), err
 	}
 
-	return &functionCallParams{
-		namespace:  pathParts[0],
-		name:       pathParts[1],
-		entrypoint: pathParts[2],
-	}, payload, nil
+	var req struct {
+		Payload string `json:"payload,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		// Bodies of unknown length (chunked or decompressed) may still be empty
+		if errors.Is(err, io.EOF) {
+			return "", nil
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return "", NewRequestError("Request body too large", http.StatusRequestEntityTooLarge)
+		}
+		return "", NewBadRequestError("Invalid JSON request body")
+	}
+	return req.Payload, nil
 }
 
 // executeFunction attempts to call a function, trying auto-reload if needed.
@@ -328,9 +773,9 @@ func (h *Handlers) executeFunction(ctx context.Context, params *functionCallPara
 		// Check for context cancellation
 		if ctx.Err() != nil {
 			if ctx.Err() == context.DeadlineExceeded {
-				return nil, NewRequestError("Function execution timed out", http.StatusRequestTimeout)
+				return nil, NewRequestErrorWithCause("Function execution timed out", http.StatusRequestTimeout, err)
 			}
-			return nil, NewRequestError("Request cancelled by client", http.StatusGatewayTimeout)
+			return nil, NewRequestErrorWithCause("Request cancelled by client", http.StatusGatewayTimeout, err)
 		}
 
 		// Check for circuit breaker open
@@ -375,7 +820,7 @@ func (h *Handlers) handleFunctionAutoReload(ctx context.Context, namespace, name
 	// Function was loaded before, try to reload it
 	h.logger.Printf("Function %s/%s was previously loaded, attempting to reload with previous config", namespace, name)
 
-	// Find the latest version tag and reload the function
+	// Reload the version chosen by the function's reload policy
 	if err := h.reloadFunction(ctx, metadata, namespace, name, previousConfig); err != nil {
 		return nil, err
 	}
@@ -423,40 +868,52 @@ func (h *Handlers) getMetadataAndConfig(namespace, name string) (*registry.Funct
 	return metadata, previousConfig, nil
 }
 
-// reloadFunction reloads a function with the latest tag and previous configuration.
+// reloadFunction reloads a function with the version its reload policy selects and its previous configuration.
 func (h *Handlers) reloadFunction(ctx context.Context, metadata *registry.FunctionMetadata, namespace, name string, previousConfig map[string]string) error {
-	// Find the best tag to load
-	tagToLoad := h.findLatestTag(metadata)
+	identifier, err := h.engine.resolveReloadVersion(namespace, name, metadata)
+	if err != nil {
+		return err
+	}
 
-	// Load the function with the chosen tag and previous config
-	if err := h.engine.LoadFunctionWithContext(ctx, namespace, name, tagToLoad, previousConfig); err != nil {
+	// Load the function with the chosen version and previous config
+	if err := h.engine.LoadFunctionWithContext(ctx, namespace, name, identifier, previousConfig); err != nil {
 		return fmt.Errorf("failed to reload function: %w", err)
 	}
 
 	return nil
 }
 
-// findLatestTag looks for the latest tag in metadata, falling back to the most recent digest.
-func (h *Handlers) findLatestTag(metadata *registry.FunctionMetadata) string {
-	// Try to find a version with the "latest" tag
-	for _, version := range metadata.Versions {
-		for _, tag := range version.Tags {
-			if tag == "latest" {
-				return "latest"
-			}
-		}
+// handleOneOffCall handles one-off function calls by splitting the process into clear stages.
+// handleCall calls an entrypoint of a loaded function.
+func (h *Handlers) handleCall(w http.ResponseWriter, r *http.Request) error {
+	var req types.CallRequest
+	payload, err := h.decodeCallRequest(r, &req)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Payload = string(payload)
 	}
 
-	// If no "latest" tag found, use the most recent version's digest
-	// (Versions should be sorted with most recent first)
-	if len(metadata.Versions) > 0 {
-		return metadata.Versions[0].FullDigest
+	// Bound the call by the request's deadline header and the engine's default timeout
+	ctx, cancel, err := h.callContext(r)
+	if err != nil {
+		return err
 	}
+	defer cancel()
 
-	return ""
+	start := time.Now()
+	output, err := h.engine.CallFunctionWithContext(ctx, req.Namespace, req.Name, req.Entrypoint, []byte(req.Payload))
+	setExecutionTime(w, start)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(output)
+	return err
 }
 
-// handleOneOffCall handles one-off function calls by splitting the process into clear stages.
 func (h *Handlers) handleOneOffCall(w http.ResponseWriter, r *http.Request) error {
 	// Parse and validate the request
 	req, err := h.parseOneOffCallRequest(r)
@@ -468,11 +925,17 @@ func (h *Handlers) handleOneOffCall(w http.ResponseWriter, r *http.Request) erro
 	h.logger.Printf("Received one-off call request for function: %s/%s (reference: %s, entrypoint: %s)",
 		req.Namespace, req.Name, req.Reference, req.Entrypoint)
 
-	// Get context from the request for cancellation support
-	ctx := r.Context()
+	// Bound the call by the request's deadline header and the engine's default timeout
+	ctx, cancel, err := h.callContext(r)
+	if err != nil {
+		return err
+	}
+	defer cancel()
 
 	// Execute the one-off call with cancellation support
+	start := time.Now()
 	output, err := h.executeOneOffCall(ctx, req)
+	setExecutionTime(w, start)
 	if err != nil {
 		return err
 	}
@@ -485,13 +948,12 @@ func (h *Handlers) handleOneOffCall(w http.ResponseWriter, r *http.Request) erro
 
 func (h *Handlers) parseOneOffCallRequest(r *http.Request) (*types.OneOffCallRequest, error) {
 	var req types.OneOffCallRequest
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		return nil, NewBadRequestError("Invalid request body")
+	payload, err := h.decodeCallRequest(r, &req)
+	if err != nil {
+		return nil, err
 	}
-
-	if req.Namespace == "" || req.Name == "" || req.Reference == "" || req.Entrypoint == "" {
-		return nil, NewBadRequestError("Missing required fields")
+	if payload != nil {
+		req.Payload = string(payload)
 	}
 
 	return &req, nil
@@ -514,47 +976,23 @@ func (h *Handlers) executeOneOffCall(ctx context.Context, req *types.OneOffCallR
 
 // pullFunction pulls a function from the registry with cancellation support.
 func (h *Handlers) pullFunction(ctx context.Context, namespace, name, reference string) ([]byte, *registry.VersionInfo, error) {
-	// Define result type
 	type pullResult struct {
 		wasmBytes   []byte
 		versionInfo *registry.VersionInfo
-		err         error
 	}
 
-	// Create a channel for the result
-	pullCh := make(chan pullResult, 1)
-
-	// Fetch the function in a goroutine
-	go func() {
+	result, err := utils.Run(ctx, utils.Task[pullResult]{Name: "Registry pull"}, func() (pullResult, error) {
 		wasmBytes, versionInfo, err := h.engine.GetRegistry().Pull(namespace, name, reference)
-		select {
-		case pullCh <- pullResult{wasmBytes, versionInfo, err}:
-		case <-ctx.Done():
-			// Context cancelled, but we need to send something to avoid goroutine leak
-			select {
-			case pullCh <- pullResult{nil, nil, ctx.Err()}:
-			default:
-			}
-		}
-	}()
-
-	// Wait for result or context cancellation
-	var result pullResult
-	select {
-	case result = <-pullCh:
-	case <-ctx.Done():
-		result = <-pullCh // Clean up goroutine
-	}
-
-	// Handle errors
-	if result.err != nil {
-		if errors.Is(result.err, registry.ErrFunctionNotFound) || errors.Is(result.err, registry.ErrVersionNotFound) {
-			return nil, nil, NewNotFoundError(result.err.Error())
+		return pullResult{wasmBytes, versionInfo}, err
+	})
+	if err != nil {
+		if errors.Is(err, registry.ErrFunctionNotFound) || errors.Is(err, registry.ErrVersionNotFound) {
+			return nil, nil, NewNotFoundError(err.Error())
 		}
 		if ctx.Err() != nil {
 			return nil, nil, NewRequestError("Request cancelled by client", http.StatusRequestTimeout)
 		}
-		return nil, nil, result.err
+		return nil, nil, err
 	}
 
 	return result.wasmBytes, result.versionInfo, nil
@@ -562,91 +1000,42 @@ func (h *Handlers) pullFunction(ctx context.Context, namespace, name, reference
 
 // createPlugin creates an Extism plugin from WASM bytes with cancellation support.
 func (h *Handlers) createPlugin(ctx context.Context, wasmBytes []byte, versionInfo *registry.VersionInfo, config map[string]string) (*extism.Plugin, error) {
-	// Define result type
-	type pluginResult struct {
-		plugin *extism.Plugin
-		err    error
-	}
-
-	// Create channel for the result
-	pluginCh := make(chan pluginResult, 1)
-
-	// Initialize the plugin in a goroutine
-	go func() {
-		plugin, err := components.CreatePlugin(wasmBytes, versionInfo, config)
-		select {
-		case pluginCh <- pluginResult{plugin, err}:
-		case <-ctx.Done():
-			if plugin != nil && err == nil {
-				plugin.Close(context.Background()) // Clean up resources
-			}
-			select {
-			case pluginCh <- pluginResult{nil, ctx.Err()}:
-			default:
-			}
-		}
-	}()
-
-	// Wait for plugin creation or context cancellation
-	var pluginRes pluginResult
-	select {
-	case pluginRes = <-pluginCh:
-	case <-ctx.Done():
-		pluginRes = <-pluginCh // Clean up goroutine
+	task := utils.Task[*extism.Plugin]{
+		Name:    "Plugin initialization",
+		Discard: func(plugin *extism.Plugin) { plugin.Close(context.Background()) },
 	}
-
-	// Handle errors
-	if pluginRes.err != nil {
+	plugin, err := utils.Run(ctx, task, func() (*extism.Plugin, error) {
+		return components.CreatePlugin(wasmBytes, versionInfo, config)
+	})
+	if err != nil {
 		if ctx.Err() != nil {
 			return nil, NewRequestError("Request cancelled by client", http.StatusRequestTimeout)
 		}
-		return nil, NewInternalServerError(fmt.Sprintf("Failed to initialize plugin: %v", pluginRes.err))
+		return nil, NewInternalServerError(fmt.Sprintf("Failed to initialize plugin: %v", err))
 	}
 
-	return pluginRes.plugin, nil
+	return plugin, nil
 }
 
-// callFunction calls a function in a plugin with cancellation support.
+// callFunction calls a function in a plugin with cancellation support. The plugin is
+// closed to abort the call when the client goes away.
 func (h *Handlers) callFunction(ctx context.Context, plugin *extism.Plugin, entrypoint string, payload string) ([]byte, error) {
-	// Define result type
-	type callResult struct {
-		output []byte
-		err    error
+	task := utils.Task[[]byte]{
+		Name:      "Call to " + entrypoint,
+		Interrupt: func() { plugin.Close(context.Background()) },
 	}
-
-	// Create channel for the result
-	callCh := make(chan callResult, 1)
-
-	// Call the function in a goroutine
-	go func() {
+	output, err := utils.Run(ctx, task, func() ([]byte, error) {
 		_, output, err := plugin.Call(entrypoint, []byte(payload))
-		select {
-		case callCh <- callResult{output, err}:
-		case <-ctx.Done():
-			select {
-			case callCh <- callResult{nil, ctx.Err()}:
-			default:
-			}
-		}
-	}()
-
-	// Wait for call result or context cancellation
-	var callRes callResult
-	select {
-	case callRes = <-callCh:
-	case <-ctx.Done():
-		callRes = <-callCh // Clean up goroutine
-	}
-
-	// Handle errors
-	if callRes.err != nil {
+		return output, err
+	})
+	if err != nil {
 		if ctx.Err() != nil {
 			return nil, NewRequestError("Request cancelled by client", http.StatusRequestTimeout)
 		}
-		return nil, NewInternalServerError(fmt.Sprintf("Failed to call function: %v", callRes.err))
+		return nil, NewInternalServerError(fmt.Sprintf("Failed to call function: %v", err))
 	}
 
-	return callRes.output, nil
+	return output, nil
 }
 
 // handleReassignTag handles tag reassignment requests.
@@ -669,6 +1058,125 @@ func (h *Handlers) handleReassignTag(w http.ResponseWriter, r *http.Request) err
 	return h.writeJSONResponse(w, map[string]string{"message": "Tag reassigned successfully"})
 }
 
+// handleRegistryPull returns a stored version and its module to an engine using this one
+// as its upstream registry.
+func (h *Handlers) handleRegistryPull(w http.ResponseWriter, r *http.Request) error {
+	var req types.PullRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	payload, version, err := h.engine.GetRegistry().Pull(req.Namespace, req.Name, req.Reference)
+	if err != nil {
+		if errors.Is(err, registry.ErrFunctionNotFound) || errors.Is(err, registry.ErrInvalidReference) ||
+			errors.Is(err, registry.ErrTagNotFound) || errors.Is(err, registry.ErrDigestNotFound) {
+			return NewNotFoundError(err.Error())
+		}
+		return err
+	}
+
+	return h.writeJSONResponse(w, types.PullResponse{Version: *version, Payload: payload})
+}
+
+// handleRegistryVariant stores a variant of a version, such as a debug build or a
+// component, to be selected when the version is loaded.
+func (h *Handlers) handleRegistryVariant(w http.ResponseWriter, r *http.Request) error {
+	var req types.AddVariantRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	store, ok := h.engine.GetRegistry().(registry.VariantStore)
+	if !ok {
+		return NewBadRequestError("The registry does not store variants")
+	}
+	version, err := store.AddVariant(req.Namespace, req.Name, req.Reference, req.Variant, req.Payload)
+	switch {
+	case err == nil:
+	case errors.Is(err, registry.ErrFunctionNotFound), errors.Is(err, registry.ErrInvalidReference):
+		return NewNotFoundError(err.Error())
+	case errors.Is(err, registry.ErrInvalidModule),
+		errors.Is(err, registry.ErrNoEntrypoints),
+		errors.Is(err, registry.ErrModuleTooLarge),
+		errors.Is(err, registry.ErrWasiNotEnabled):
+		return NewBadRequestError(fmt.Sprintf("Cannot add variant %s to %s/%s: %v", req.Variant, req.Namespace, req.Name, err))
+	default:
+		return err
+	}
+
+	return h.writeJSONResponse(w, version)
+}
+
+// handleRegistrySBOM returns the SBOM recorded when a version was built.
+func (h *Handlers) handleRegistrySBOM(w http.ResponseWriter, r *http.Request) error {
+	var req types.SBOMRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	store, ok := h.engine.GetRegistry().(registry.SBOMStore)
+	if !ok {
+		return NewBadRequestError("The registry does not store SBOMs")
+	}
+	sbom, err := store.SBOM(req.Namespace, req.Name, req.Reference)
+	if err != nil {
+		if errors.Is(err, registry.ErrFunctionNotFound) || errors.Is(err, registry.ErrInvalidReference) ||
+			errors.Is(err, registry.ErrSBOMNotFound) {
+			return NewNotFoundError(err.Error())
+		}
+		return err
+	}
+
+	return h.writeJSONResponse(w, sbom)
+}
+
+// handleRegistryPush stores a prebuilt version sent by the registry replication of
+// another engine.
+func (h *Handlers) handleRegistryPush(w http.ResponseWriter, r *http.Request) error {
+	var req types.PushRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	h.logger.Printf("Received push request for function: %s/%s (digest: %s)", req.Namespace, req.Name, req.Digest)
+
+	err := h.engine.GetRegistry().Push(req.Namespace, req.Name, req.Payload, req.Digest, req.Tag, req.Settings)
+	switch {
+	case err == nil:
+	case errors.Is(err, registry.ErrInvalidModule),
+		errors.Is(err, registry.ErrNoEntrypoints),
+		errors.Is(err, registry.ErrModuleTooLarge):
+		return NewBadRequestError(fmt.Sprintf("Cannot push %s/%s: %v", req.Namespace, req.Name, err))
+	default:
+		return err
+	}
+
+	return h.writeJSONResponse(w, map[string]string{"message": "Version pushed successfully"})
+}
+
+// handleRegistrySync copies the registry to a replication target.
+func (h *Handlers) handleRegistrySync(w http.ResponseWriter, r *http.Request) error {
+	var req types.RegistrySyncRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	report, err := h.engine.SyncRegistry(r.Context(), req.Target, req.Token)
+	if err != nil {
+		return err
+	}
+	return h.writeJSONResponse(w, report)
+}
+
+// handleSnapshot returns the runtime state of the engine for a later restore, or with
+// ?redact=true a copy with secret config values masked for sharing.
+func (h *Handlers) handleSnapshot(w http.ResponseWriter, r *http.Request) error {
+	if redact, _ := strconv.ParseBool(r.URL.Query().Get("redact")); redact {
+		return h.writeJSONResponse(w, h.engine.RedactedSnapshot())
+	}
+	return h.writeJSONResponse(w, h.engine.Snapshot())
+}
+
 // handleStatus returns the current status of the engine.
 func (h *Handlers) handleStatus(w http.ResponseWriter, _ *http.Request) error {
 	// Get the count of loaded functions from the plugin manager
@@ -676,14 +1184,45 @@ func (h *Handlers) handleStatus(w http.ResponseWriter, _ *http.Request) error {
 
 	status := map[string]interface{}{
 		"status":           "running",
+		"version":          selfupdate.CurrentVersion(),
 		"loaded_functions": loadedCount,
+		"pools":            h.engine.pluginManager.GetPoolStats(),
+		"pipelines":        h.engine.pipelines.List(),
+		"logs":             h.engine.LogUsage(),
+		"cold_starts":      h.engine.ColdStarts(),
+		"panics":           h.engine.Panics(),
+		"compiled_modules": h.engine.CompiledModules(),
+	}
+	if h.engine.logShipper != nil {
+		status["log_shipping"] = h.engine.logShipper.Stats()
+	}
+	if stats := h.engine.ReplicationStats(); stats != nil {
+		status["replication"] = stats
+	}
+	if reporter, ok := h.engine.metrics.(metrics.Reporter); ok {
+		status["metrics"] = reporter.Snapshot()
+	}
+	if ha := h.engine.HAStatus(); ha != nil {
+		status["ha"] = ha
 	}
 
 	return h.writeJSONResponse(w, status)
 }
 
-// handleHealth is a simple health check endpoint.
+// handleHealth runs the engine's dependency checks. A degraded engine still serves
+// traffic, so only an unhealthy verdict fails the probe.
 func (h *Handlers) handleHealth(w http.ResponseWriter, _ *http.Request) error {
+	report := h.engine.Health()
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == types.HealthUnhealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	return json.NewEncoder(w).Encode(report)
+}
+
+// handleLiveness reports that the server is responsive, without checking dependencies.
+func (h *Handlers) handleLiveness(w http.ResponseWriter, _ *http.Request) error {
 	return h.writeJSONResponse(w, map[string]string{"status": "ok"})
 }
 
@@ -719,6 +1258,230 @@ func (h *Handlers) handleStop(w http.ResponseWriter, r *http.Request) error {
 	return h.writeJSONResponse(w, map[string]string{"message": "Function stopped successfully"})
 }
 
+// handleScale sets the number of instances kept for a function.
+func (h *Handlers) handleScale(w http.ResponseWriter, r *http.Request) error {
+	var req types.ScaleRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	if req.Service != "" {
+		namespace, name, ok := h.engine.ResolveService(req.Service)
+		if !ok {
+			return NewNotFoundError(fmt.Sprintf("Service not found: %s", req.Service))
+		}
+		req.Namespace, req.Name = namespace, name
+	}
+
+	h.logger.Printf("Received scale request for function: %s/%s (%d instances)", req.Namespace, req.Name, req.Instances)
+
+	if err := h.engine.ScaleFunction(req.Namespace, req.Name, req.Instances); err != nil {
+		return NewRequestErrorWithCause(fmt.Sprintf("Failed to scale function: %v", err), http.StatusBadRequest, err)
+	}
+
+	return h.writeJSONResponse(w, map[string]interface{}{
+		"message":   "Function scaled successfully",
+		"namespace": req.Namespace,
+		"name":      req.Name,
+		"instances": req.Instances,
+	})
+}
+
+// handleCallLogging sets which calls of a running function write entries to its logs.
+func (h *Handlers) handleCallLogging(w http.ResponseWriter, r *http.Request) error {
+	var req types.CallLoggingRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	if req.Service != "" {
+		namespace, name, ok := h.engine.ResolveService(req.Service)
+		if !ok {
+			return NewNotFoundError(fmt.Sprintf("Service not found: %s", req.Service))
+		}
+		req.Namespace, req.Name = namespace, name
+	}
+
+	// Validate has already checked the call logging
+	policy, _ := types.ParseCallLogging(req.CallLogging)
+	h.logger.Printf("Received call logging request for function: %s/%s (%s)", req.Namespace, req.Name, policy)
+	h.engine.SetFunctionCallLogging(req.Namespace, req.Name, policy)
+
+	return h.writeJSONResponse(w, map[string]interface{}{
+		"message":      "Call logging updated",
+		"namespace":    req.Namespace,
+		"name":         req.Name,
+		"call_logging": policy.String(),
+	})
+}
+
+// handleMaintenanceReport returns the most recent registry maintenance report.
+func (h *Handlers) handleMaintenanceReport(w http.ResponseWriter, _ *http.Request) error {
+	report := h.engine.LastMaintenance()
+	if report == nil {
+		return NewNotFoundError("No registry maintenance has run yet")
+	}
+
+	return h.writeJSONResponse(w, report)
+}
+
+// handleRunMaintenance runs registry maintenance immediately and returns the report.
+func (h *Handlers) handleRunMaintenance(w http.ResponseWriter, _ *http.Request) error {
+	h.logger.Printf("Received registry maintenance request")
+
+	report, err := h.engine.RunMaintenance()
+	if errors.Is(err, ErrMaintenanceNotSupported) {
+		return NewBadRequestError(err.Error())
+	}
+	if err != nil {
+		return NewInternalServerError(fmt.Sprintf("Registry maintenance failed: %v", err))
+	}
+
+	return h.writeJSONResponse(w, report)
+}
+
+// handleAudit returns audit log events, optionally filtered by age, operation and count.
+func (h *Handlers) handleAudit(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+
+	var auditQuery audit.Query
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err := time.ParseDuration(sinceStr)
+		if err != nil {
+			return NewBadRequestError(fmt.Sprintf("Invalid 'since' parameter: %v", err))
+		}
+		auditQuery.Since = time.Now().Add(-since)
+	}
+
+	auditQuery.Operation = query.Get("operation")
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			return NewBadRequestError(fmt.Sprintf("Invalid 'limit' parameter: %s", limitStr))
+		}
+		auditQuery.Limit = limit
+	}
+
+	events, err := h.engine.AuditLog(auditQuery)
+	if err != nil {
+		return NewInternalServerError(fmt.Sprintf("Failed to read audit log: %v", err))
+	}
+
+	return h.writeJSONResponse(w, events)
+}
+
+// handleUsage reports the usage of every namespace this month, or of the one in the namespace parameter.
+func (h *Handlers) handleUsage(w http.ResponseWriter, r *http.Request) error {
+	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+		report, err := h.engine.NamespaceUsage(namespace)
+		if err != nil {
+			return NewInternalServerError(fmt.Sprintf("Failed to read usage: %v", err))
+		}
+		return h.writeJSONResponse(w, []usage.Report{report})
+	}
+
+	reports, err := h.engine.UsageReports()
+	if err != nil {
+		return NewInternalServerError(fmt.Sprintf("Failed to read usage: %v", err))
+	}
+	return h.writeJSONResponse(w, reports)
+}
+
+// handleCircuitBreakers lists the state of every circuit breaker, including the
+// breakers of entrypoints when breakers are kept per entrypoint.
+func (h *Handlers) handleCircuitBreakers(w http.ResponseWriter, _ *http.Request) error {
+	return h.writeJSONResponse(w, h.engine.circuitBreakers.ListCircuitBreakers())
+}
+
+// handleDeadLetters serves the dead letter store of a function:
+// GET /dlq/namespace/name lists entries, POST .../redrive replays them and POST .../purge removes them.
+func (h *Handlers) handleDeadLetters(w http.ResponseWriter, r *http.Request) error {
+	pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, "/dlq/"), "/")
+	if len(pathParts) < 2 || len(pathParts) > 3 {
+		return NewBadRequestError("Invalid URL format: expected /dlq/namespace/name[/redrive|/purge]")
+	}
+
+	namespace, name := pathParts[0], pathParts[1]
+	if err := validateFunctionRef(namespace, name); err != nil {
+		return err
+	}
+
+	action := ""
+	if len(pathParts) == 3 {
+		action = pathParts[2]
+	}
+
+	expectedMethod := http.MethodPost
+	if action == "" {
+		expectedMethod = http.MethodGet
+	}
+	if r.Method != expectedMethod {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil
+	}
+
+	switch action {
+	case "":
+		entries, err := h.engine.DeadLetters(namespace, name)
+		if err != nil {
+			return deadLetterError(err)
+		}
+		return h.writeJSONResponse(w, entries)
+
+	case "redrive":
+		var req types.DeadLetterRequest
+		if err := h.decodeOptionalJSON(r, &req); err != nil {
+			return err
+		}
+
+		h.logger.Printf("Received dead letter redrive request for function: %s/%s", namespace, name)
+
+		response, err := h.engine.RedriveDeadLetters(r.Context(), namespace, name, req.IDs)
+		if err != nil {
+			return deadLetterError(err)
+		}
+		return h.writeJSONResponse(w, response)
+
+	case "purge":
+		var req types.DeadLetterRequest
+		if err := h.decodeOptionalJSON(r, &req); err != nil {
+			return err
+		}
+
+		h.logger.Printf("Received dead letter purge request for function: %s/%s", namespace, name)
+
+		removed, err := h.engine.PurgeDeadLetters(namespace, name, req.IDs)
+		if err != nil {
+			return deadLetterError(err)
+		}
+		return h.writeJSONResponse(w, types.PurgeResponse{Removed: removed})
+
+	default:
+		return NewNotFoundError(fmt.Sprintf("Unknown dead letter action: %s", action))
+	}
+}
+
+// decodeOptionalJSON decodes a JSON body if one was sent.
+func (h *Handlers) decodeOptionalJSON(r *http.Request, v interface{}) error {
+	if r.ContentLength == 0 {
+		return nil
+	}
+	return h.decodeJSONRequest(r, v)
+}
+
+// deadLetterError maps dead letter store errors to request errors.
+func deadLetterError(err error) error {
+	switch {
+	case errors.Is(err, ErrDeadLetterDisabled):
+		return NewRequestError("Dead letter capture is disabled; set engine.dead_letter.enabled", http.StatusConflict)
+	case errors.Is(err, dlq.ErrEntryNotFound):
+		return NewNotFoundError(err.Error())
+	default:
+		return NewInternalServerError("Dead letter operation failed", err)
+	}
+}
+
 // handleFunctionLogs returns logs for a specific function.
 func (h *Handlers) handleFunctionLogs(w http.ResponseWriter, r *http.Request) error {
 	// Parse path: /logs/namespace/name
@@ -728,6 +1491,9 @@ func (h *Handlers) handleFunctionLogs(w http.ResponseWriter, r *http.Request) er
 	}
 
 	namespace, name := pathParts[0], pathParts[1]
+	if err := validateFunctionRef(namespace, name); err != nil {
+		return err
+	}
 
 	h.logger.Printf("Received logs request for function: %s/%s", namespace, name)
 
@@ -762,19 +1528,25 @@ func (h *Handlers) handleFunctionLogs(w http.ResponseWriter, r *http.Request) er
 		tail = 0
 	}
 
+	// Parse level parameter, repeated or comma separated (e.g. level=error,warning)
+	levels, err := logging.ParseLogLevels(query["level"])
+	if err != nil {
+		return NewBadRequestError(fmt.Sprintf("Invalid 'level' parameter: %v", err))
+	}
+
 	// Get logs from the engine's logger for this function
-	logs := h.getEngineLogs(namespace, name, since, tail)
+	logs := h.getEngineLogs(namespace, name, since, tail, levels)
 
 	// Return logs as a JSON array
 	return h.writeJSONResponse(w, logs)
 }
 
 // getEngineLogs retrieves logs for a specific function from the engine's log store.
-func (h *Handlers) getEngineLogs(namespace, name string, since time.Time, tail int) []string {
+func (h *Handlers) getEngineLogs(namespace, name string, since time.Time, tail int, levels []logging.LogLevel) []string {
 	functionKey := GetFunctionKey(namespace, name)
 
 	// Retrieve logs from the engine's log store
-	logs := h.engine.logStore.GetLogs(functionKey, since, tail)
+	logs := h.engine.logStore.GetLogs(functionKey, since, tail, levels...)
 
 	// If there are no logs, add an informational message
 	if len(logs) == 0 {
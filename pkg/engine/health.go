@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// memoryPressureRatio is the share of the Go memory limit above which the engine reports degraded
+const memoryPressureRatio = 0.9
+
+// healthCheck is a named dependency check returning a status and an optional message
+type healthCheck struct {
+	name  string
+	check func() (string, string)
+}
+
+// Health runs every dependency check. The overall status is the worst check status:
+// the registry database and storage are required, so their failure makes the engine
+// unhealthy, while the other checks can only degrade it.
+func (e *Engine) Health() *types.HealthReport {
+	checks := []healthCheck{
+		{name: "registry_db", check: e.checkRegistryDB},
+		{name: "storage", check: e.checkStorage},
+		{name: "plugin_manager", check: e.checkPluginManager},
+		{name: "maintenance", check: e.checkMaintenance},
+		{name: "integrity", check: e.checkIntegrity},
+		{name: "memory", check: checkMemory},
+	}
+
+	report := &types.HealthReport{
+		Status: types.HealthHealthy,
+		Checks: make([]types.HealthCheck, 0, len(checks)),
+	}
+
+	for _, c := range checks {
+		start := time.Now()
+		status, message := c.check()
+		report.Checks = append(report.Checks, types.HealthCheck{
+			Name:     c.name,
+			Status:   status,
+			Message:  message,
+			Duration: time.Since(start).String(),
+		})
+
+		if healthRank(status) > healthRank(report.Status) {
+			report.Status = status
+		}
+	}
+
+	return report
+}
+
+// checkRegistryDB opens a read transaction on the registry database
+func (e *Engine) checkRegistryDB() (string, string) {
+	if e.db == nil {
+		return types.HealthUnhealthy, "registry database is not open"
+	}
+	if err := e.db.View(func(*badger.Txn) error { return nil }); err != nil {
+		return types.HealthUnhealthy, err.Error()
+	}
+	return types.HealthHealthy, ""
+}
+
+// checkStorage verifies that modules can still be written to the registry directory
+func (e *Engine) checkStorage() (string, string) {
+	file, err := os.CreateTemp(e.registryDir, ".health-*")
+	if err != nil {
+		return types.HealthUnhealthy, fmt.Sprintf("registry directory is not writable: %v", err)
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	return types.HealthHealthy, ""
+}
+
+// checkPluginManager reports the loaded functions and any pools stuck in a crash loop
+func (e *Engine) checkPluginManager() (string, string) {
+	var crashLooping []string
+	for key, stats := range e.pluginManager.GetPoolStats() {
+		if stats.CrashLooping {
+			crashLooping = append(crashLooping, key.String())
+		}
+	}
+
+	loaded := e.pluginManager.GetLoadedFunctionCount()
+	if len(crashLooping) > 0 {
+		sort.Strings(crashLooping)
+		return types.HealthDegraded, fmt.Sprintf("%d functions loaded, crash looping: %v", loaded, crashLooping)
+	}
+	return types.HealthHealthy, fmt.Sprintf("%d functions loaded", loaded)
+}
+
+// checkMaintenance reports failed or overdue registry maintenance runs
+func (e *Engine) checkMaintenance() (string, string) {
+	interval := e.options.MaintenanceInterval
+	if _, ok := e.registry.(registry.Maintainer); !ok || interval <= 0 {
+		return types.HealthHealthy, "disabled"
+	}
+
+	last := e.LastMaintenance()
+	switch {
+	case last == nil:
+		return types.HealthHealthy, "no run yet"
+	case last.Error != "":
+		return types.HealthDegraded, fmt.Sprintf("last run failed: %s", last.Error)
+	case time.Since(last.StartedAt) > 2*interval:
+		return types.HealthDegraded, fmt.Sprintf("last run at %s is overdue", last.StartedAt.Format(time.RFC3339))
+	}
+	return types.HealthHealthy, fmt.Sprintf("last run at %s", last.StartedAt.Format(time.RFC3339))
+}
+
+// checkIntegrity reports the last integrity check of stored modules and any versions
+// it could not repair
+func (e *Engine) checkIntegrity() (string, string) {
+	if _, ok := e.registry.(registry.IntegrityChecker); !ok || e.options.IntegrityCheck.Mode == "off" {
+		return types.HealthHealthy, "disabled"
+	}
+
+	last := e.LastIntegrityCheck()
+	switch {
+	case last == nil:
+		return types.HealthHealthy, "no run yet"
+	case last.Error != "":
+		return types.HealthDegraded, fmt.Sprintf("last run failed: %s", last.Error)
+	}
+
+	summary := fmt.Sprintf("last %s run at %s: %d versions, %d verified, %d missing, %d corrupt, %d repaired",
+		last.Mode, last.StartedAt.Format(time.RFC3339), last.Versions, last.Verified,
+		len(last.Missing), len(last.Corrupt), len(last.Repaired))
+	if last.Unrepaired() > 0 {
+		return types.HealthDegraded, summary
+	}
+	return types.HealthHealthy, summary
+}
+
+// checkMemory compares the heap with the Go memory limit (GOMEMLIMIT), when one is set
+func checkMemory() (string, string) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	usage := fmt.Sprintf("heap %d MiB of %d MiB obtained", stats.HeapAlloc>>20, stats.Sys>>20)
+
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return types.HealthHealthy, usage
+	}
+
+	usage += fmt.Sprintf(", limit %d MiB", limit>>20)
+	if float64(stats.HeapAlloc) > float64(limit)*memoryPressureRatio {
+		return types.HealthDegraded, usage
+	}
+	return types.HealthHealthy, usage
+}
+
+func healthRank(status string) int {
+	switch status {
+	case types.HealthUnhealthy:
+		return 2
+	case types.HealthDegraded:
+		return 1
+	default:
+		return 0
+	}
+}
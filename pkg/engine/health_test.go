@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthReport(t *testing.T) {
+	engine, tmpDir := setupTestEngine(t)
+	defer cleanupTest(tmpDir)
+
+	report := engine.Health()
+	assert.Equal(t, types.HealthHealthy, report.Status)
+	require.Len(t, report.Checks, 6)
+
+	// A missing registry directory fails the storage check
+	engine.registryDir = filepath.Join(tmpDir, "missing")
+	report = engine.Health()
+	assert.Equal(t, types.HealthUnhealthy, report.Status)
+
+	handlers := NewHandlers(engine, engine.logger)
+	rec := httptest.NewRecorder()
+	handlers.HTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handlers.HTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// envelopeReservedHeaders are managed by the server and cannot be set by a function
+var envelopeReservedHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Keep-Alive":        true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+// usesHTTPEnvelope reports whether the loaded version of a function returns response envelopes.
+func (h *Handlers) usesHTTPEnvelope(namespace, name string) bool {
+	settings, ok := h.engine.functionLoader.GetVersionSettings(namespace, name)
+	return ok && settings.HTTPEnvelope
+}
+
+// sendEnvelopeResponse writes a function's response envelope as the HTTP response.
+func (h *Handlers) sendEnvelopeResponse(w http.ResponseWriter, output []byte) error {
+	resp, body, err := decodeHTTPEnvelope(output)
+	if err != nil {
+		return NewRequestErrorWithCause("Function returned an invalid response envelope", http.StatusBadGateway, err)
+	}
+
+	for name, value := range resp.Headers {
+		w.Header().Set(name, value)
+	}
+	if resp.Cache != nil {
+		w.Header().Set("Cache-Control", resp.Cache.CacheControl())
+	}
+	if w.Header().Get("Content-Type") == "" && len(body) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	w.WriteHeader(resp.Status)
+	_, err = w.Write(body)
+	return err
+}
+
+// decodeHTTPEnvelope parses and validates a response envelope, returning it with the decoded body.
+func decodeHTTPEnvelope(output []byte) (types.HTTPResponse, []byte, error) {
+	var resp types.HTTPResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return resp, nil, fmt.Errorf("failed to decode envelope: %w", err)
+	}
+
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	if resp.Status < 200 || resp.Status > 599 {
+		return resp, nil, fmt.Errorf("invalid status code %d", resp.Status)
+	}
+
+	for name, value := range resp.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") || strings.ContainsAny(value, "\r\n") {
+			return resp, nil, fmt.Errorf("invalid header %q", name)
+		}
+		if envelopeReservedHeaders[http.CanonicalHeaderKey(name)] {
+			return resp, nil, fmt.Errorf("header %s cannot be set by functions", http.CanonicalHeaderKey(name))
+		}
+		if resp.Cache != nil && http.CanonicalHeaderKey(name) == "Cache-Control" {
+			return resp, nil, fmt.Errorf("set either cache directives or a Cache-Control header, not both")
+		}
+	}
+	if resp.Cache != nil {
+		if err := resp.Cache.Validate(); err != nil {
+			return resp, nil, err
+		}
+	}
+
+	body := []byte(resp.Body)
+	if resp.IsBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+		if err != nil {
+			return resp, nil, fmt.Errorf("failed to decode base64 body: %w", err)
+		}
+		body = decoded
+	}
+
+	return resp, body, nil
+}
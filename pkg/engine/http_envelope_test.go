@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendEnvelopeResponse(t *testing.T) {
+	h := &Handlers{}
+
+	rec := httptest.NewRecorder()
+	err := h.sendEnvelopeResponse(rec, []byte(`{"status":302,"headers":{"Location":"/login"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/login", rec.Header().Get("Location"))
+	assert.Empty(t, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	err = h.sendEnvelopeResponse(rec, []byte(`{"headers":{"content-type":"text/plain"},"body":"aGVsbG8=","is_base64":true}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "hello", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	err = h.sendEnvelopeResponse(rec, []byte(`{"body":"{}","cache":{"ttl_seconds":60,"shared_ttl_seconds":300}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "public, max-age=60, s-maxage=300", rec.Header().Get("Cache-Control"))
+}
+
+func TestCacheDirectives(t *testing.T) {
+	tests := []struct {
+		directives   types.CacheDirectives
+		cacheControl string
+		sharedTTL    time.Duration
+	}{
+		{types.CacheDirectives{TTLSeconds: 60}, "public, max-age=60", time.Minute},
+		{types.CacheDirectives{TTLSeconds: 60, StaleWhileRevalidateSeconds: 30}, "public, max-age=60, stale-while-revalidate=30", time.Minute},
+		{types.CacheDirectives{TTLSeconds: 60, SharedTTLSeconds: 300, Private: true}, "private, max-age=60", 0},
+		{types.CacheDirectives{NoStore: true}, "no-store", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.cacheControl, tt.directives.CacheControl())
+		assert.Equal(t, tt.sharedTTL, tt.directives.SharedTTL())
+	}
+}
+
+func TestDecodeHTTPEnvelopeRejectsInvalid(t *testing.T) {
+	for _, output := range []string{
+		`not json`,
+		`{"status":99}`,
+		`{"status":600}`,
+		`{"headers":{"Content-Length":"10"}}`,
+		`{"headers":{"X-Bad":"a\r\nb"}}`,
+		`{"body":"%%%","is_base64":true}`,
+		`{"cache":{"ttl_seconds":-1}}`,
+		`{"cache":{"no_store":true,"ttl_seconds":60}}`,
+		`{"headers":{"cache-control":"no-cache"},"cache":{"ttl_seconds":60}}`,
+	} {
+		_, _, err := decodeHTTPEnvelope([]byte(output))
+		assert.Error(t, err, output)
+	}
+
+	h := &Handlers{}
+	err := h.sendEnvelopeResponse(httptest.NewRecorder(), []byte(`{"status":600}`))
+	var reqErr RequestError
+	require.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusBadGateway, reqErr.StatusCode)
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// usesHTTPRequest reports whether the loaded version of a function takes whole HTTP requests.
+func (h *Handlers) usesHTTPRequest(namespace, name string) bool {
+	settings, ok := h.engine.functionLoader.GetVersionSettings(namespace, name)
+	return ok && settings.HTTPRequest
+}
+
+// functionMethodMiddleware only lets POST calls through, except to functions that take
+// whole HTTP requests, which route every method themselves.
+func (h *Handlers) functionMethodMiddleware() Middleware {
+	postOnly := h.methodMiddleware(http.MethodPost)
+	return func(next HandlerFunc) HandlerFunc {
+		restricted := postOnly(next)
+		return func(w http.ResponseWriter, r *http.Request) error {
+			if r.Method == http.MethodPost {
+				return next(w, r)
+			}
+			if params, err := h.parseFunctionCallPath(r.URL.Path); err == nil && h.usesHTTPRequest(params.namespace, params.name) {
+				return next(w, r)
+			}
+			return restricted(w, r)
+		}
+	}
+}
+
+// encodeHTTPRequest builds the request envelope passed to functions that take whole HTTP requests.
+func encodeHTTPRequest(r *http.Request, path string) (string, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return "", NewRequestError("Request body too large", http.StatusRequestEntityTooLarge)
+		}
+		return "", NewBadRequestError("Failed to read request body")
+	}
+
+	req := types.HTTPRequest{
+		Method: r.Method,
+		Path:   path,
+	}
+	if query := r.URL.Query(); len(query) > 0 {
+		req.Query = make(map[string]string, len(query))
+		for name := range query {
+			req.Query[name] = query.Get(name)
+		}
+	}
+	if len(r.Header) > 0 {
+		req.Headers = make(map[string]string, len(r.Header))
+		for name, values := range r.Header {
+			req.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+	if utf8.Valid(body) {
+		req.Body = string(body)
+	} else {
+		req.Body = base64.StdEncoding.EncodeToString(body)
+		req.IsBase64 = true
+	}
+
+	encoded, err := json.Marshal(req)
+	if err != nil {
+		return "", NewInternalServerError("Failed to encode request")
+	}
+	return string(encoded), nil
+}
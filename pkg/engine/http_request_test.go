@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeHTTPRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPut, "/ns/fn/handle/items/7?tag=a&tag=b", strings.NewReader(`{"name":"x"}`))
+	r.Header.Add("Accept", "text/plain")
+	r.Header.Add("Accept", "application/json")
+
+	payload, err := encodeHTTPRequest(r, "/items/7")
+	require.NoError(t, err)
+
+	var req types.HTTPRequest
+	require.NoError(t, json.Unmarshal([]byte(payload), &req))
+	assert.Equal(t, http.MethodPut, req.Method)
+	assert.Equal(t, "/items/7", req.Path)
+	assert.Equal(t, map[string]string{"tag": "a"}, req.Query)
+	assert.Equal(t, "text/plain, application/json", req.Headers["Accept"])
+	assert.Equal(t, `{"name":"x"}`, req.Body)
+	assert.False(t, req.IsBase64)
+
+	// Binary bodies are base64 encoded
+	r = httptest.NewRequest(http.MethodPost, "/ns/fn/handle", strings.NewReader("\xff\xfe"))
+	payload, err = encodeHTTPRequest(r, "/")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(payload), &req))
+	assert.Equal(t, "//4=", req.Body)
+	assert.True(t, req.IsBase64)
+}
@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header
+const maxIdempotencyKeyLength = 255
+
+// idempotencyStore remembers the responses of requests by idempotency key.
+type idempotencyStore struct {
+	mu      sync.Mutex
+	window  time.Duration
+	maxKeys int
+	now     func() time.Time
+	entries map[string]*idempotencyEntry
+}
+
+// idempotencyEntry is a request with an idempotency key, and its response once it succeeded
+type idempotencyEntry struct {
+	fingerprint [sha256.Size]byte
+	expires     time.Time
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+func newIdempotencyStore(window time.Duration, maxKeys int, now func() time.Time) *idempotencyStore {
+	return &idempotencyStore{
+		window:  window,
+		maxKeys: maxKeys,
+		now:     now,
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+// begin claims key for a request. It returns the entry of an earlier request with the
+// key, or nil when the caller claimed it and must call finish or release.
+func (s *idempotencyStore) begin(key string, fingerprint [sha256.Size]byte) *idempotencyEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expires) {
+		copied := *entry
+		return &copied
+	}
+
+	s.evict(now)
+	s.entries[key] = &idempotencyEntry{fingerprint: fingerprint, expires: now.Add(s.window)}
+	return nil
+}
+
+// evict drops expired entries, then the entries closest to expiring while the store is full.
+// Requests still running are kept, so their key cannot be claimed twice.
+func (s *idempotencyStore) evict(now time.Time) {
+	for key, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+	for len(s.entries) >= s.maxKeys {
+		var oldest string
+		for key, entry := range s.entries {
+			if entry.done && (oldest == "" || entry.expires.Before(s.entries[oldest].expires)) {
+				oldest = key
+			}
+		}
+		if oldest == "" {
+			return
+		}
+		delete(s.entries, oldest)
+	}
+}
+
+// finish records the response of the request that claimed key.
+func (s *idempotencyStore) finish(key string, status int, header http.Header, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok {
+		entry.done = true
+		entry.status = status
+		entry.header = header
+		entry.body = body
+	}
+}
+
+// release forgets key after its request failed, so a retry does the work again.
+func (s *idempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// recordingWriter passes a response through while keeping a copy of it
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	rw.body.Write(p)
+	return rw.ResponseWriter.Write(p)
+}
+
+// idempotent runs the idempotency middleware right before the method check, so replays
+// are logged but neither handled nor audited again.
+func (h *Handlers) idempotent(chain MiddlewareChain) MiddlewareChain {
+	return chain.Outside(MiddlewareMethod, NamedMiddleware{MiddlewareIdempotency, h.idempotencyMiddleware()})
+}
+
+// idempotencyMiddleware replays the response of an earlier successful request with the
+// same Idempotency-Key header and body, within the configured window. Failed requests
+// are not remembered, so they can be retried.
+func (h *Handlers) idempotencyMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			key := r.Header.Get(types.IdempotencyKeyHeader)
+			if key == "" || h.idempotency == nil {
+				return next(w, r)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return NewBadRequestError(fmt.Sprintf("%s must be at most %d characters", types.IdempotencyKeyHeader, maxIdempotencyKeyLength))
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				return NewBadRequestError(fmt.Sprintf("Failed to read request body: %v", err))
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			// Keys are scoped to the endpoint, and a key is only replayed for the same body
+			key = r.URL.Path + " " + key
+			fingerprint := sha256.Sum256(body)
+
+			earlier := h.idempotency.begin(key, fingerprint)
+			switch {
+			case earlier == nil:
+			case earlier.fingerprint != fingerprint:
+				return NewRequestError(fmt.Sprintf("%s was already used for a different request", types.IdempotencyKeyHeader), http.StatusUnprocessableEntity)
+			case !earlier.done:
+				return NewRequestError(fmt.Sprintf("A request with this %s is still running", types.IdempotencyKeyHeader), http.StatusConflict)
+			default:
+				for name, values := range earlier.header {
+					w.Header()[name] = values
+				}
+				w.Header().Set(types.IdempotentReplayedHeader, "true")
+				w.WriteHeader(earlier.status)
+				_, err := w.Write(earlier.body)
+				return err
+			}
+
+			recorder := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			if err := next(recorder, r); err != nil || recorder.status >= http.StatusBadRequest {
+				h.idempotency.release(key)
+				return err
+			}
+			h.idempotency.finish(key, recorder.status, w.Header().Clone(), recorder.body.Bytes())
+			return nil
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	now := time.Now()
+	h := &Handlers{idempotency: newIdempotencyStore(time.Minute, 10, func() time.Time { return now })}
+
+	calls := 0
+	fail := false
+	handler := h.idempotencyMiddleware()(func(w http.ResponseWriter, _ *http.Request) error {
+		calls++
+		if fail {
+			return errors.New("registry unavailable")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"digest":"abc"}`))
+		return err
+	})
+	send := func(key, body string) (*httptest.ResponseRecorder, error) {
+		r := httptest.NewRequest(http.MethodPost, "/load", strings.NewReader(body))
+		r.Header.Set(types.IdempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		return w, handler(w, r)
+	}
+
+	w, err := send("k1", `{"name":"fn"}`)
+	require.NoError(t, err)
+	assert.Empty(t, w.Header().Get(types.IdempotentReplayedHeader))
+
+	// A retry gets the first response without running the handler
+	w, err = send("k1", `{"name":"fn"}`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, `{"digest":"abc"}`, w.Body.String())
+	assert.Equal(t, "true", w.Header().Get(types.IdempotentReplayedHeader))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	// The key cannot be reused for another request
+	_, err = send("k1", `{"name":"other"}`)
+	var reqErr RequestError
+	require.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, reqErr.StatusCode)
+
+	// Failed requests are not remembered
+	fail = true
+	_, err = send("k2", `{"name":"fn"}`)
+	require.Error(t, err)
+	fail = false
+	_, err = send("k2", `{"name":"fn"}`)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	// Responses are replayed within the window only
+	now = now.Add(2 * time.Minute)
+	_, err = send("k1", `{"name":"fn"}`)
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls)
+}
+
+func TestIdempotencyStoreRejectsRunningKey(t *testing.T) {
+	store := newIdempotencyStore(time.Minute, 1, time.Now)
+	fingerprint := [32]byte{1}
+
+	assert.Nil(t, store.begin("k1", fingerprint))
+	earlier := store.begin("k1", fingerprint)
+	require.NotNil(t, earlier)
+	assert.False(t, earlier.done)
+
+	// A full store keeps the keys of running requests
+	assert.Nil(t, store.begin("k2", fingerprint))
+	assert.Len(t, store.entries, 2)
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/registry/remote"
+)
+
+// ImportFunction fetches a prebuilt module from an HTTPS URL or OCI reference, verifies
+// it by digest and stores it in the local registry, optionally under a tag. When the
+// expected digest is already in the registry nothing is downloaded. It returns the
+// digest of the stored version.
+func (e *Engine) ImportFunction(ctx context.Context, namespace, name, source, expectedDigest, tag string) (string, error) {
+	key := GetFunctionKey(namespace, name)
+
+	if expectedDigest != "" && e.hasVersion(namespace, name, expectedDigest) {
+		e.logStore.AddLog(key, logging.LevelInfo, fmt.Sprintf("Using cached import of %s (%s)", source, expectedDigest))
+		if tag != "" {
+			if err := e.registry.ReassignTag(namespace, name, tag, expectedDigest); err != nil {
+				return "", fmt.Errorf("failed to tag cached import: %w", err)
+			}
+		}
+		return expectedDigest, nil
+	}
+
+	e.logger.Printf("Importing %s/%s from %s", namespace, name, source)
+	artifact, err := e.fetcher.Fetch(ctx, source, expectedDigest)
+	if err != nil {
+		return "", err
+	}
+
+	// Imported modules carry no manifest, so WASI follows what the module imports
+	settings := manifest.FunctionVersionSettings{}
+	if info, err := registry.InspectModule(artifact.Payload); err == nil {
+		settings.Wasi = info.RequiresWasi
+	}
+
+	if err := e.registry.Push(namespace, name, artifact.Payload, artifact.Digest, tag, settings); err != nil {
+		return "", fmt.Errorf("failed to store imported module: %w", err)
+	}
+
+	e.logStore.AddLog(key, logging.LevelInfo,
+		fmt.Sprintf("Imported %s (%d bytes, %s)", source, len(artifact.Payload), artifact.Digest))
+	return artifact.Digest, nil
+}
+
+// hasVersion reports whether the registry holds a version with exactly this digest.
+func (e *Engine) hasVersion(namespace, name, digest string) bool {
+	metadata, err := e.registry.Get(namespace, name)
+	if err != nil {
+		return false
+	}
+	for _, version := range metadata.Versions {
+		if version.FullDigest == digest {
+			return true
+		}
+	}
+	return false
+}
+
+// importError maps remote fetch failures to request errors.
+func importError(source string, err error) error {
+	if reqErr := admissionError(err); reqErr != nil {
+		return *reqErr
+	}
+
+	switch {
+	case errors.Is(err, remote.ErrUnsupportedReference),
+		errors.Is(err, remote.ErrDigestRequired),
+		errors.Is(err, remote.ErrTooLarge),
+		errors.Is(err, registry.ErrInvalidModule),
+		errors.Is(err, registry.ErrNoEntrypoints),
+		errors.Is(err, registry.ErrModuleTooLarge):
+		return NewBadRequestError(fmt.Sprintf("Cannot import %s: %v", source, err))
+	case errors.Is(err, remote.ErrDigestMismatch):
+		return NewRequestErrorWithCause(fmt.Sprintf("Verification of %s failed: %v", source, err), http.StatusUnprocessableEntity, err)
+	default:
+		return NewRequestErrorWithCause(fmt.Sprintf("Failed to import %s: %v", source, err), http.StatusBadGateway, err)
+	}
+}
@@ -0,0 +1,535 @@
+package engine_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine"
+	"github.com/ignitionstack/ignition/pkg/engine/api"
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/engine/policy"
+	"github.com/ignitionstack/ignition/pkg/engine/testutil"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	localRegistry "github.com/ignitionstack/ignition/pkg/registry/local"
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationLoadCallUnload(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+	assert.True(t, eng.IsLoaded("ns", "echo"))
+
+	output, err := eng.Call("ns", "echo", testutil.EntrypointEcho, []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(output))
+
+	// An unloaded function is loaded again on its next call
+	require.NoError(t, eng.Unload("ns", "echo"))
+	assert.False(t, eng.IsLoaded("ns", "echo"))
+
+	output, err = eng.Call("ns", "echo", testutil.EntrypointEcho, []byte("again"))
+	require.NoError(t, err)
+	assert.Equal(t, "again", string(output))
+	assert.True(t, eng.IsLoaded("ns", "echo"))
+}
+
+func TestIntegrationMappedModules(t *testing.T) {
+	eng := testutil.Start(t, testutil.WithOptions(func(o *engine.Options) {
+		o.WithMapModules(true)
+	}))
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+
+	// The module is unmapped once compiled, so calls only use the compiled plugin
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+	output, err := eng.Call("ns", "echo", testutil.EntrypointEcho, []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(output))
+}
+
+func TestIntegrationIntegrityCheck(t *testing.T) {
+	eng := testutil.Start(t, testutil.WithOptions(func(o *engine.Options) {
+		o.WithIntegrityCheck(config.IntegrityCheckConfig{Mode: "full"})
+	}))
+
+	// The check runs once in the background when the engine starts
+	require.Eventually(t, func() bool { return eng.LastIntegrityCheck() != nil }, 5*time.Second, 10*time.Millisecond)
+	digest := eng.Push("ns", "echo", "latest", testutil.EchoModule)
+
+	// A corrupt module degrades the engine, as it cannot be repaired without an upstream
+	storage := localRegistry.NewLocalStorage(eng.RegistryDir)
+	path := storage.BuildWASMPath("ns", "echo", registry.TruncateDigest(digest, 12))
+	require.NoError(t, storage.WriteWASMFile(path, testutil.EchoModule[:len(testutil.EchoModule)-1]))
+
+	report, err := eng.CheckIntegrity()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns/echo@" + registry.TruncateDigest(digest, 12)}, report.Corrupt)
+
+	health := eng.Health()
+	assert.Equal(t, types.HealthDegraded, health.Status)
+	i := slices.IndexFunc(health.Checks, func(c types.HealthCheck) bool { return c.Name == "integrity" })
+	require.GreaterOrEqual(t, i, 0)
+	assert.Contains(t, health.Checks[i].Message, "1 corrupt, 0 repaired")
+}
+
+func TestIntegrationSharedCompiledModules(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+	eng.Push("other", "echo", "latest", testutil.EchoModule)
+
+	// The same digest loaded under two names is compiled once
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+	require.NoError(t, eng.Load("other", "echo", "latest"))
+	stats := eng.CompiledModules()
+	assert.Equal(t, 1, stats.Modules)
+	assert.Equal(t, int64(1), stats.Hits)
+
+	// The shared module outlives either function
+	require.NoError(t, eng.Unload("ns", "echo"))
+	output, err := eng.Call("other", "echo", testutil.EntrypointEcho, []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(output))
+
+	require.NoError(t, eng.Unload("other", "echo"))
+	assert.Eventually(t, func() bool { return eng.CompiledModules().Modules == 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestIntegrationStoppedFunctionIsNotReloaded(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+
+	require.NoError(t, eng.Stop("ns", "echo"))
+	_, err := eng.Call("ns", "echo", testutil.EntrypointRun, nil)
+	var callErr *testutil.CallError
+	require.True(t, errors.As(err, &callErr), "%v", err)
+	assert.False(t, eng.IsLoaded("ns", "echo"))
+
+	// Loading explicitly brings it back
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+	_, err = eng.Call("ns", "echo", testutil.EntrypointRun, nil)
+	assert.NoError(t, err)
+}
+
+func TestIntegrationCrashedInstanceIsReplaced(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+
+	_, err := eng.Call("ns", "echo", testutil.EntrypointTrap, nil)
+	var callErr *testutil.CallError
+	require.True(t, errors.As(err, &callErr), "%v", err)
+	assert.Equal(t, http.StatusInternalServerError, callErr.StatusCode)
+
+	output, err := eng.Call("ns", "echo", testutil.EntrypointEcho, []byte("still here"))
+	require.NoError(t, err)
+	assert.Equal(t, "still here", string(output))
+}
+
+func TestIntegrationTimedOutCallIsInterrupted(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+
+	start := time.Now()
+	_, err := eng.CallWithTimeout("ns", "echo", testutil.EntrypointSpin, nil, 100*time.Millisecond)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// The spinning instance was closed and replaced, so the pool still serves calls
+	output, err := eng.CallWithTimeout("ns", "echo", testutil.EntrypointEcho, []byte("next"), 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "next", string(output))
+	assert.True(t, slices.ContainsFunc(eng.Logs("ns", "echo"), func(line string) bool {
+		return strings.Contains(line, "Plugin instance replaced")
+	}))
+}
+
+func TestIntegrationEventStream(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.Push("ns", "echo", "v1", testutil.EchoModule)
+	// A custom section gives the second version its own digest
+	eng.Push("ns", "echo", "v2", append(slices.Clone(testutil.EchoModule), 0x00, 0x03, 0x01, 'v', 0x00))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan events.Event, 10)
+	streamErr := make(chan error, 1)
+	go func() {
+		filter := events.Filter{
+			Functions: []engine.FunctionKey{{Namespace: "ns", Name: "echo"}},
+			Types:     []string{events.TypeLoaded, events.TypeReloaded, events.TypeUnloaded},
+		}
+		streamErr <- eng.Client.StreamEvents(ctx, filter, func(event events.Event) error {
+			received <- event
+			return nil
+		})
+	}()
+	require.Eventually(t, func() bool { return eng.Events().Subscribers() == 1 }, 5*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, eng.Load("ns", "echo", "v1"))
+	require.NoError(t, eng.Load("ns", "echo", "v2"))
+	require.NoError(t, eng.Unload("ns", "echo"))
+
+	var got []events.Event
+	for len(got) < 3 {
+		select {
+		case event := <-received:
+			got = append(got, event)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of 3 events", len(got))
+		}
+	}
+	assert.Equal(t, events.TypeLoaded, got[0].Type)
+	assert.Equal(t, events.TypeReloaded, got[1].Type)
+	assert.Equal(t, "new digest", got[1].Reason)
+	assert.NotEqual(t, got[0].Digest, got[1].Digest)
+	assert.Equal(t, events.TypeUnloaded, got[2].Type)
+
+	cancel()
+	assert.ErrorIs(t, <-streamErr, context.Canceled)
+}
+
+func TestIntegrationFunctionStates(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.Push("ns", "echo", "v1", testutil.EchoModule)
+	sub := eng.Events().Subscribe(events.Filter{Types: []string{events.TypeStateChanged}}, 16)
+	defer sub.Close()
+
+	require.NoError(t, eng.Load("ns", "echo", "v1"))
+	require.NoError(t, eng.Stop("ns", "echo"))
+	require.Error(t, eng.Load("ns", "missing", "v1"))
+
+	var got []string
+	for len(got) < 5 {
+		select {
+		case event := <-sub.C:
+			got = append(got, event.Function+":"+event.From+"->"+event.To)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of 5 state changes", len(got))
+		}
+	}
+	assert.Equal(t, []string{
+		"echo:unloaded->loading",
+		"echo:loading->running",
+		"echo:running->stopped",
+		"missing:unloaded->loading",
+		"missing:loading->failed",
+	}, got)
+
+	functions, err := eng.Client.ListFunctions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []types.LoadedFunction{
+		{Namespace: "ns", Name: "echo", Status: "stopped"},
+		{Namespace: "ns", Name: "missing", Status: "failed"},
+	}, functions)
+}
+
+func TestIntegrationHTTPRequestFunction(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.PushWithSettings("ns", "web", "latest", testutil.EchoModule, manifest.FunctionVersionSettings{HTTPRequest: true})
+	require.NoError(t, eng.Load("ns", "web", "latest"))
+
+	// The echo entrypoint answers with the request envelope it was given
+	resp, err := http.Get("http://" + eng.HTTPAddr + "/ns/web/echo/users/42?verbose=1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var req types.HTTPRequest
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&req))
+	assert.Equal(t, http.MethodGet, req.Method)
+	assert.Equal(t, "/users/42", req.Path)
+	assert.Equal(t, map[string]string{"verbose": "1"}, req.Query)
+
+	// Functions without http_request still only take POST calls on their entrypoint
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+	resp, err = http.Get("http://" + eng.HTTPAddr + "/ns/echo/echo")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestIntegrationBinaryPayload(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+
+	// Bytes that are not valid UTF-8 would be mangled in a JSON string
+	payload := []byte{0x00, 0xff, 0xfe, 'a', 0x80, '\n'}
+
+	output, err := eng.Client.CallFunction(context.Background(), "ns", "echo", testutil.EntrypointEcho, payload, nil)
+	require.NoError(t, err)
+	assert.Equal(t, payload, output)
+
+	resp, err := http.Post("http://"+eng.HTTPAddr+"/ns/echo/echo", "application/octet-stream", bytes.NewReader(payload))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, payload, body)
+}
+
+func TestIntegrationCallEnvelope(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+eng.HTTPAddr+"/ns/echo/echo?envelope=true", strings.NewReader(`{"payload":"[1,2]"}`))
+	require.NoError(t, err)
+	req.Header.Set(types.RequestIDHeader, "req-42")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var envelope types.CallEnvelope
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&envelope))
+	assert.JSONEq(t, `[1,2]`, string(envelope.Output))
+	assert.Equal(t, "req-42", envelope.RequestID)
+	assert.True(t, strings.HasPrefix(envelope.Function, "ns/echo@"))
+	assert.GreaterOrEqual(t, envelope.ExecutionTimeMs, 0.0)
+}
+
+func TestIntegrationAdmissionPolicy(t *testing.T) {
+	eng := testutil.Start(t, testutil.WithOptions(func(o *engine.Options) {
+		o.Policy.Rules.RequiredLabels = []string{"owner"}
+	}))
+
+	// Versions without the required label are not stored
+	err := eng.GetRegistry().Push("ns", "echo", testutil.EchoModule, "digest", "latest", manifest.FunctionVersionSettings{})
+	require.ErrorIs(t, err, policy.ErrDenied)
+	assert.Contains(t, err.Error(), "required label owner is missing")
+
+	eng.PushWithSettings("ns", "echo", "latest", testutil.EchoModule, manifest.FunctionVersionSettings{
+		Labels: map[string]string{"owner": "payments"},
+	})
+
+	// Evaluators added later apply to stored versions when they are loaded
+	eng.UseAdmissionPolicy(policy.EvaluatorFunc(func(_ context.Context, input policy.Input) (policy.Decision, error) {
+		if input.Stage == policy.StageLoad && input.Settings.Labels["owner"] == "payments" {
+			return policy.Decision{Reasons: []string{"payments functions are frozen"}}, nil
+		}
+		return policy.Decision{Allowed: true}, nil
+	}))
+
+	err = eng.Load("ns", "echo", "latest")
+	var errResp api.ErrorResponse
+	require.ErrorAs(t, err, &errResp)
+	assert.Equal(t, http.StatusForbidden, errResp.Code)
+	assert.Contains(t, errResp.Message, "payments functions are frozen")
+	assert.False(t, eng.IsLoaded("ns", "echo"))
+}
+
+func TestIntegrationConfigSchema(t *testing.T) {
+	eng := testutil.Start(t)
+	eng.PushWithSettings("ns", "echo", "latest", testutil.EchoModule, manifest.FunctionVersionSettings{
+		Config: manifest.ConfigSchema{
+			"API_KEY": {Required: true},
+			"PORT":    {Type: manifest.ConfigTypeInt, Default: "8080"},
+			"TIMEOUT": {Type: manifest.ConfigTypeDuration},
+		},
+	})
+
+	// Every mismatch is reported at once, before the function is compiled
+	err := eng.Client.LoadFunction(context.Background(), "ns", "echo", "latest", map[string]string{"PORT": "http", "TIMEOUT": "5"})
+	var errResp api.ErrorResponse
+	require.ErrorAs(t, err, &errResp)
+	assert.Equal(t, http.StatusBadRequest, errResp.Code)
+	assert.Contains(t, errResp.Message, "missing required config keys: API_KEY")
+	assert.Contains(t, errResp.Message, "PORT must be of type int")
+	assert.Contains(t, errResp.Message, "TIMEOUT must be of type duration")
+	assert.NotContains(t, errResp.Message, "http")
+	assert.False(t, eng.IsLoaded("ns", "echo"))
+
+	require.NoError(t, eng.Client.LoadFunction(context.Background(), "ns", "echo", "latest", map[string]string{"API_KEY": "secret"}))
+	assert.True(t, eng.IsLoaded("ns", "echo"))
+}
+
+func TestIntegrationUsageQuota(t *testing.T) {
+	eng := testutil.Start(t, testutil.WithOptions(func(o *engine.Options) {
+		o.Usage.Quotas = map[string]config.QuotaConfig{"ns": {DailyInvocations: 2}}
+	}))
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+
+	for range 2 {
+		_, err := eng.Call("ns", "echo", testutil.EntrypointEcho, []byte("hi"))
+		require.NoError(t, err)
+	}
+	_, err := eng.Call("ns", "echo", testutil.EntrypointEcho, []byte("hi"))
+	var callErr *testutil.CallError
+	require.True(t, errors.As(err, &callErr), "%v", err)
+	assert.Equal(t, http.StatusTooManyRequests, callErr.StatusCode)
+	assert.Contains(t, callErr.Message, "namespace ns exceeded its daily invocation quota")
+
+	reports, err := eng.Client.GetUsage(context.Background(), "ns")
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, int64(2), reports[0].Daily.Invocations)
+	assert.Equal(t, int64(2), reports[0].Quota.DailyInvocations)
+}
+
+func TestIntegrationRegistryReplication(t *testing.T) {
+	adminAddr := testutil.FreeAddr(t)
+	standby := testutil.Start(t, testutil.WithOptions(func(o *engine.Options) {
+		o.AdminAddr = adminAddr
+		o.AdminToken = "standby-token"
+	}))
+	primary := testutil.Start(t, testutil.WithOptions(func(o *engine.Options) {
+		o.Replication.Target = "http://" + adminAddr
+		o.Replication.Token = "standby-token"
+	}))
+
+	// Pushes reach the standby engine in the background
+	primary.Push("ns", "echo", "latest", testutil.EchoModule)
+	require.Eventually(t, func() bool {
+		metadata, err := standby.GetRegistry().Get("ns", "echo")
+		return err == nil && len(metadata.Versions) == 1 && slices.Contains(metadata.Versions[0].Tags, "latest")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, standby.Load("ns", "echo", "latest"))
+	output, err := standby.Call("ns", "echo", testutil.EntrypointEcho, []byte("replicated"))
+	require.NoError(t, err)
+	assert.Equal(t, "replicated", string(output))
+
+	// The configured target is caught up, so a sync has nothing to copy
+	report, err := primary.Client.SyncRegistry(context.Background(), "", "")
+	require.NoError(t, err)
+	assert.Zero(t, report.VersionsCopied)
+	assert.Zero(t, report.TagsUpdated)
+
+	// Another directory gets a full copy
+	report, err = primary.Client.SyncRegistry(context.Background(), t.TempDir(), "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.VersionsCopied)
+	assert.Equal(t, 1, report.TagsUpdated)
+	assert.Empty(t, report.Errors)
+}
+
+func TestIntegrationUpstreamRegistry(t *testing.T) {
+	adminAddr := testutil.FreeAddr(t)
+	shared := testutil.Start(t, testutil.WithOptions(func(o *engine.Options) {
+		o.AdminAddr = adminAddr
+		o.AdminToken = "shared-token"
+	}))
+	local := testutil.Start(t, testutil.WithOptions(func(o *engine.Options) {
+		o.RegistryUpstream.URL = "http://" + adminAddr
+		o.RegistryUpstream.Token = "shared-token"
+	}))
+	shared.Push("ns", "echo", "latest", testutil.EchoModule)
+
+	// The first load pulls the version from the shared engine and caches it
+	require.NoError(t, local.Load("ns", "echo", "latest"))
+	output, err := local.Call("ns", "echo", testutil.EntrypointEcho, []byte("cached"))
+	require.NoError(t, err)
+	assert.Equal(t, "cached", string(output))
+
+	metadata, err := local.GetRegistry().Get("ns", "echo")
+	require.NoError(t, err)
+	require.Len(t, metadata.Versions, 1)
+	assert.Equal(t, []string{"latest"}, metadata.Versions[0].Tags)
+
+	// Versions the shared engine does not have either are still not found
+	err = local.Load("ns", "missing", "latest")
+	assert.ErrorContains(t, err, "function not found")
+	assert.NotContains(t, err.Error(), "upstream registry")
+}
+
+func TestIntegrationStandbyFailover(t *testing.T) {
+	ha := config.HAConfig{LockFile: filepath.Join(t.TempDir(), "engine.lock"), SyncInterval: 20 * time.Millisecond}
+	primary := testutil.Start(t, testutil.WithOptions(func(o *engine.Options) {
+		o.WithHA(ha)
+	}))
+	primary.Push("ns", "echo", "latest", testutil.EchoModule)
+	require.NoError(t, primary.Load("ns", "echo", "latest"))
+
+	// The standby shares the listeners of the primary and keeps its own registry
+	options := engine.DefaultEngineOptions().WithHA(ha)
+	standby, err := engine.NewEngineWithOptions(primary.SocketPath, primary.HTTPAddr, t.TempDir(),
+		logging.NewStdLogger(io.Discard), options)
+	require.NoError(t, err)
+	sub := standby.Events().Subscribe(events.Filter{Types: []string{events.TypeStandby, events.TypeFailover}}, 4)
+	defer sub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- standby.Run(ctx) }()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+		require.NoError(t, standby.Close())
+	}()
+
+	assert.Equal(t, events.TypeStandby, (<-sub.C).Type)
+	require.Eventually(t, func() bool { return standby.IsLoaded("ns", "echo") }, 5*time.Second, 10*time.Millisecond)
+
+	// The version came over with its tag
+	metadata, err := standby.GetRegistry().Get("ns", "echo")
+	require.NoError(t, err)
+	require.Len(t, metadata.Versions, 1)
+	assert.Equal(t, []string{"latest"}, metadata.Versions[0].Tags)
+
+	primary.Shutdown()
+	select {
+	case event := <-sub.C:
+		assert.Equal(t, events.TypeFailover, event.Type)
+	case <-time.After(5 * time.Second):
+		t.Fatal("standby did not take over")
+	}
+
+	require.Eventually(t, func() bool {
+		output, err := primary.Call("ns", "echo", testutil.EntrypointEcho, []byte("failover"))
+		return err == nil && string(output) == "failover"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, standby.HAStatus().TookOver)
+}
+
+func TestIntegrationPrometheusMetrics(t *testing.T) {
+	metricsAddr := testutil.FreeAddr(t)
+	eng := testutil.Start(t, testutil.WithOptions(func(o *engine.Options) {
+		o.WithMetrics(config.MetricsConfig{Collector: "prometheus"})
+		o.WithMetricsAddr(metricsAddr)
+	}))
+	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+	require.NoError(t, eng.Load("ns", "echo", "latest"))
+	_, err := eng.Call("ns", "echo", testutil.EntrypointEcho, []byte("hello"))
+	require.NoError(t, err)
+
+	// The public listener does not expose the metrics of every namespace
+	public, err := http.Get("http://" + eng.HTTPAddr + "/metrics")
+	require.NoError(t, err)
+	public.Body.Close()
+	assert.NotEqual(t, http.StatusOK, public.StatusCode)
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = http.Get("http://" + metricsAddr + "/metrics")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `ignition_function_calls_total{namespace="ns",function="echo",outcome="success"} 1`)
+	assert.Regexp(t, `ignition_function_memory_bytes\{namespace="ns",function="echo"\} [1-9]`, string(body))
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"sync"
+)
+
+// Call is a function invocation as seen by interceptors. An interceptor may replace
+// the payload before passing the call on.
+type Call struct {
+	Namespace  string
+	Name       string
+	Entrypoint string
+	Payload    []byte
+}
+
+// CallHandler performs a call and returns the function output.
+type CallHandler func(ctx context.Context, call *Call) ([]byte, error)
+
+// Interceptor wraps a call to run code before and after it, for example to transform
+// payloads, inject claims from the context or record metrics. An interceptor can reject
+// a call by returning an error without calling next; a RequestError sets the HTTP status.
+type Interceptor func(next CallHandler) CallHandler
+
+// interceptorRegistry holds the interceptors applied to every call and to single functions.
+type interceptorRegistry struct {
+	mu        sync.RWMutex
+	global    []Interceptor
+	functions map[FunctionKey][]Interceptor
+}
+
+func newInterceptorRegistry() *interceptorRegistry {
+	return &interceptorRegistry{
+		functions: make(map[FunctionKey][]Interceptor),
+	}
+}
+
+func (r *interceptorRegistry) add(interceptor Interceptor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.global = append(r.global, interceptor)
+}
+
+func (r *interceptorRegistry) addFunction(key FunctionKey, interceptor Interceptor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.functions[key] = append(r.functions[key], interceptor)
+}
+
+func (r *interceptorRegistry) clearFunction(key FunctionKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.functions, key)
+}
+
+// wrap builds the handler chain for a function. As with HTTP middleware, the last
+// registered interceptor is the outermost, and global interceptors wrap the
+// function's own.
+func (r *interceptorRegistry) wrap(key FunctionKey, handler CallHandler) CallHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, interceptor := range r.functions[key] {
+		handler = interceptor(handler)
+	}
+	for _, interceptor := range r.global {
+		handler = interceptor(handler)
+	}
+	return handler
+}
+
+// UseInterceptor adds an interceptor applied to calls of every function.
+func (e *Engine) UseInterceptor(interceptor Interceptor) {
+	e.functionExecutor.interceptors.add(interceptor)
+}
+
+// UseFunctionInterceptor adds an interceptor applied to calls of one function.
+// It stays registered when the function is reloaded.
+func (e *Engine) UseFunctionInterceptor(namespace, name string, interceptor Interceptor) {
+	e.functionExecutor.interceptors.addFunction(GetFunctionKey(namespace, name), interceptor)
+}
+
+// ClearFunctionInterceptors removes the interceptors registered for one function.
+func (e *Engine) ClearFunctionInterceptors(namespace, name string) {
+	e.functionExecutor.interceptors.clearFunction(GetFunctionKey(namespace, name))
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tagInterceptor appends its tag to the payload on the way in and to the output on the way out
+func tagInterceptor(tag string) Interceptor {
+	return func(next CallHandler) CallHandler {
+		return func(ctx context.Context, call *Call) ([]byte, error) {
+			call.Payload = append(call.Payload, []byte(" in:"+tag)...)
+			output, err := next(ctx, call)
+			return append(output, []byte(" out:"+tag)...), err
+		}
+	}
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	registry := newInterceptorRegistry()
+	key := GetFunctionKey("ns", "fn")
+	other := GetFunctionKey("ns", "other")
+
+	registry.add(tagInterceptor("g1"))
+	registry.add(tagInterceptor("g2"))
+	registry.addFunction(key, tagInterceptor("f1"))
+
+	echo := func(_ context.Context, call *Call) ([]byte, error) {
+		return append([]byte(nil), call.Payload...), nil
+	}
+
+	output, err := registry.wrap(key, echo)(context.Background(), &Call{Payload: []byte("p")})
+	require.NoError(t, err)
+	assert.Equal(t, "p in:g2 in:g1 in:f1 out:f1 out:g1 out:g2", string(output))
+
+	output, err = registry.wrap(other, echo)(context.Background(), &Call{Payload: []byte("p")})
+	require.NoError(t, err)
+	assert.Equal(t, "p in:g2 in:g1 out:g1 out:g2", string(output))
+
+	registry.clearFunction(key)
+	output, err = registry.wrap(key, echo)(context.Background(), &Call{Payload: []byte("p")})
+	require.NoError(t, err)
+	assert.Equal(t, "p in:g2 in:g1 out:g1 out:g2", string(output))
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"fmt"
+	"net/url"
 )
 
 // FunctionKey represents a unique identifier for a function
@@ -15,6 +16,49 @@ func (k FunctionKey) String() string {
 	return fmt.Sprintf("%s/%s", k.Namespace, k.Name)
 }
 
+// IsZero reports whether the key has neither a namespace nor a name
+func (k FunctionKey) IsZero() bool {
+	return k.Namespace == "" && k.Name == ""
+}
+
+// Encode returns an unambiguous storage encoding of the key. Each component is
+// path-escaped so that a '/' inside a namespace or name survives a round trip.
+func (k FunctionKey) Encode() string {
+	return url.PathEscape(k.Namespace) + "/" + url.PathEscape(k.Name)
+}
+
+// DecodeFunctionKey parses a key produced by Encode
+func DecodeFunctionKey(encoded string) (FunctionKey, error) {
+	rawNamespace, rawName, found := SplitFunctionKey(encoded)
+	if !found {
+		return FunctionKey{}, fmt.Errorf("invalid function key format: %s", encoded)
+	}
+	namespace, err := url.PathUnescape(rawNamespace)
+	if err != nil {
+		return FunctionKey{}, fmt.Errorf("invalid function key namespace %q: %w", rawNamespace, err)
+	}
+	name, err := url.PathUnescape(rawName)
+	if err != nil {
+		return FunctionKey{}, fmt.Errorf("invalid function key name %q: %w", rawName, err)
+	}
+	return FunctionKey{Namespace: namespace, Name: name}, nil
+}
+
+// MarshalText implements encoding.TextMarshaler so keys can be used as JSON map keys
+func (k FunctionKey) MarshalText() ([]byte, error) {
+	return []byte(k.Encode()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (k *FunctionKey) UnmarshalText(text []byte) error {
+	key, err := DecodeFunctionKey(string(text))
+	if err != nil {
+		return err
+	}
+	*k = key
+	return nil
+}
+
 // NewFunctionKey creates a new FunctionKey from namespace and name
 func NewFunctionKey(namespace, name string) FunctionKey {
 	return FunctionKey{
@@ -0,0 +1,39 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctionKeyEncodeRoundTrip(t *testing.T) {
+	keys := []FunctionKey{
+		NewFunctionKey("ns", "fn"),
+		NewFunctionKey("team/a", "fn"),
+		NewFunctionKey("ns", "fn/v2"),
+		NewFunctionKey("ns", "hello world%"),
+	}
+
+	for _, key := range keys {
+		decoded, err := DecodeFunctionKey(key.Encode())
+		require.NoError(t, err)
+		assert.Equal(t, key, decoded)
+	}
+
+	_, err := DecodeFunctionKey("no-separator")
+	assert.Error(t, err)
+}
+
+func TestFunctionKeyJSONMapKey(t *testing.T) {
+	in := map[FunctionKey]int{NewFunctionKey("team/a", "fn"): 1}
+
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"team%2Fa/fn":1}`, string(data))
+
+	var out map[FunctionKey]int
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+}
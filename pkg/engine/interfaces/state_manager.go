@@ -7,26 +7,27 @@ import (
 // FunctionState contains the complete state information for a function
 type FunctionState struct {
 	// Basic state
-	Loaded           bool
-	Running          bool
-	Stopped          bool
-	PreviouslyLoaded bool
+	Loaded           bool   // Whether the function is currently loaded
+	Running          bool   // Whether the function is currently running
+	Stopped          bool   // Whether the function has been explicitly stopped
+	PreviouslyLoaded bool   // Whether the function was previously loaded in this session
+	Phase            string // Lifecycle state: unloaded, loading, running, idle, stopped or failed
 
 	// Configuration
-	Config map[string]string
+	Config map[string]string // Current function configuration
 
 	// Execution stats
-	LastExecutionTime    time.Time
-	TotalExecutions      int64
-	SuccessfulExecutions int64
-	FailedExecutions     int64
+	LastExecutionTime    time.Time // When the function was last executed
+	TotalExecutions      int64     // Total number of executions
+	SuccessfulExecutions int64     // Number of successful executions
+	FailedExecutions     int64     // Number of failed executions
 
 	// Circuit breaker status
-	CircuitBreakerOpen bool
+	CircuitBreakerOpen bool // Whether the circuit breaker is open
 
 	// Registry info
-	Digest string
-	Tags   []string
+	Digest string   // Current function digest
+	Tags   []string // Tags associated with this function
 }
 
 // StateManager defines the interface for managing function state
@@ -68,7 +69,7 @@ type StateManager interface {
 	RemoveRuntime(namespace, name string)
 
 	// ListLoaded returns a list of all loaded functions
-	ListLoaded() []string
+	ListLoaded() []FunctionKey
 }
 
 // CircuitBreakerManager manages circuit breakers for functions
@@ -99,4 +100,7 @@ type CircuitBreaker interface {
 
 	// Reset resets the circuit breaker state to closed
 	Reset()
+
+	// Trip opens the circuit breaker immediately
+	Trip()
 }
@@ -0,0 +1,36 @@
+// Package ipc is the local transport between the engine and its clients: a Unix
+// socket, or a named pipe on Windows, where Unix sockets are not an option for
+// servers. Paths are socket files or pipe names such as \\.\pipe\ignition-engine.
+package ipc
+
+import (
+	"context"
+	"net"
+)
+
+// DefaultPath returns the path the engine listens on and clients dial by default.
+func DefaultPath() string {
+	return defaultPath()
+}
+
+// Listen listens on path. A stale socket left by an engine that crashed is replaced,
+// but a path another engine is serving is refused.
+func Listen(path string) (net.Listener, error) {
+	return listen(path)
+}
+
+// Dial connects to the engine listening on path.
+func Dial(ctx context.Context, path string) (net.Conn, error) {
+	return dial(ctx, path)
+}
+
+// NotReady reports whether a dial failed because no engine listens on the path yet,
+// so it may be retried.
+func NotReady(err error) bool {
+	return notReady(err)
+}
+
+// Cleanup removes what Listen left behind once its listener is closed.
+func Cleanup(path string) error {
+	return cleanup(path)
+}
@@ -0,0 +1,63 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+func defaultPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".ignition", "engine.sock")
+}
+
+func listen(path string) (net.Listener, error) {
+	// Check if socket is already in use before removing
+	if _, err := os.Stat(path); err == nil {
+		// Socket file exists, let's check if it's active
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			// Connection successful, socket is in use by another process
+			conn.Close()
+			return nil, fmt.Errorf("socket %s is already in use by another process (possibly another ignition engine instance)", path)
+		}
+		// Socket file exists but no process is listening, safe to remove
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket file: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		// Some other error occurred when checking the socket file
+		return nil, fmt.Errorf("failed to check socket file status: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start Unix socket listener: %w", err)
+	}
+	return listener, nil
+}
+
+func dial(ctx context.Context, path string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", path)
+}
+
+func notReady(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT)
+}
+
+func cleanup(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenAndDial(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.sock")
+
+	_, err := Dial(context.Background(), path)
+	require.Error(t, err)
+	assert.True(t, NotReady(err))
+
+	listener, err := Listen(path)
+	require.NoError(t, err)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	conn, err := Dial(context.Background(), path)
+	require.NoError(t, err)
+	conn.Close()
+
+	// A socket another engine serves is refused
+	_, err = Listen(path)
+	assert.ErrorContains(t, err, "already in use")
+
+	require.NoError(t, listener.Close())
+	require.NoError(t, Cleanup(path))
+	require.NoError(t, Cleanup(path))
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.sock")
+
+	// A socket file left by an engine that crashed
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	listener, err := Listen(path)
+	require.NoError(t, err)
+	assert.NoError(t, listener.Close())
+}
@@ -0,0 +1,77 @@
+//go:build windows
+
+package ipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+)
+
+// pipePrefix is the namespace of named pipes on the local machine
+const pipePrefix = `\\.\pipe\`
+
+// defaultPath is a pipe of the current user, so engines of several users do not clash.
+func defaultPath() string {
+	name := "ignition-engine"
+	if u, err := user.Current(); err == nil {
+		name += "-" + strings.NewReplacer(`\`, "-", "/", "-").Replace(u.Username)
+	}
+	return pipePrefix + name
+}
+
+func listen(path string) (net.Listener, error) {
+	if !strings.HasPrefix(path, pipePrefix) {
+		return nil, fmt.Errorf("socket %s is not a named pipe, expected a path such as %signition-engine", path, pipePrefix)
+	}
+
+	// Pipes vanish with the process serving them, so an existing pipe is in use
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if conn, err := winio.DialPipeContext(ctx, path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("socket %s is already in use by another process (possibly another ignition engine instance)", path)
+	}
+
+	// Like a socket file created by the user, the pipe is only open to them and to the system
+	sid, err := currentUserSID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the user of the engine: %w", err)
+	}
+	listener, err := winio.ListenPipe(path, &winio.PipeConfig{
+		SecurityDescriptor: fmt.Sprintf("D:P(A;;GA;;;%s)(A;;GA;;;SY)", sid),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start named pipe listener: %w", err)
+	}
+	return listener, nil
+}
+
+func dial(ctx context.Context, path string) (net.Conn, error) {
+	return winio.DialPipeContext(ctx, path)
+}
+
+func notReady(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, winio.ErrTimeout)
+}
+
+// cleanup has nothing to do, as pipes are removed when their listener is closed.
+func cleanup(_ string) error {
+	return nil
+}
+
+func currentUserSID() (string, error) {
+	tokenUser, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	return tokenUser.User.Sid.String(), nil
+}
@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+)
+
+type namespaceScopeKey struct{}
+
+// NamespaceHTTPHandler serves function calls and health checks like HTTPHandler, but
+// only for functions in the given namespaces. Calls to other functions get a 404 as
+// if they were not loaded, and pipelines are not served since their steps may span
+// namespaces.
+func (h *Handlers) NamespaceHTTPHandler(namespaces []string) http.Handler {
+	allowed := make(map[string]struct{}, len(namespaces))
+	for _, namespace := range namespaces {
+		allowed[namespace] = struct{}{}
+	}
+
+	mux := h.httpMux(false)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), namespaceScopeKey{}, allowed)))
+	})
+}
+
+// namespaceInScope reports whether a request may reach functions of the namespace.
+// Requests that did not come through a scoped listener may reach every namespace.
+func namespaceInScope(ctx context.Context, namespace string) bool {
+	allowed, ok := ctx.Value(namespaceScopeKey{}).(map[string]struct{})
+	if !ok {
+		return true
+	}
+	_, ok = allowed[namespace]
+	return ok
+}
@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceHTTPHandler(t *testing.T) {
+	engine, tmpDir := setupTestEngine(t)
+	defer cleanupTest(tmpDir)
+
+	handler := NewHandlers(engine, engine.logger).NamespaceHTTPHandler([]string{"public"})
+
+	// Functions outside the listener's namespaces look like they are not loaded
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/internal/billing/handler", strings.NewReader("{}")))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Pipelines are not served on scoped listeners
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pipelines/checkout", strings.NewReader("{}")))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	scoped := context.WithValue(t.Context(), namespaceScopeKey{}, map[string]struct{}{"public": {}})
+	assert.True(t, namespaceInScope(scoped, "public"))
+	assert.False(t, namespaceInScope(scoped, "internal"))
+	assert.True(t, namespaceInScope(t.Context(), "internal"))
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// defaultLoadBatchConcurrency is how many loads of a batch run at once when the request does not say
+const defaultLoadBatchConcurrency = 4
+
+// handleLoadBatch loads several functions in one request. Loads run concurrently, each
+// once the services it depends on are loaded; a load whose dependency failed is skipped.
+// The response reports every load in request order, so it succeeds even when loads fail.
+func (h *Handlers) handleLoadBatch(w http.ResponseWriter, r *http.Request) error {
+	var req types.LoadBatchRequest
+	if err := h.decodeAndValidate(r, &req); err != nil {
+		return err
+	}
+
+	h.logger.Printf("Received load batch request for %d functions", len(req.Loads))
+
+	results := runLoadBatch(r.Context(), req, h.loadBatchItem)
+
+	resp := types.LoadBatchResponse{Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case types.LoadBatchLoaded:
+			resp.Loaded++
+		case types.LoadBatchFailed:
+			resp.Failed++
+		default:
+			resp.Skipped++
+		}
+	}
+	return h.writeJSONResponse(w, resp)
+}
+
+// loadBatchItem loads one function of a batch, then applies its scale.
+func (h *Handlers) loadBatchItem(ctx context.Context, item types.LoadBatchItem) error {
+	if err := h.loadFunction(ctx, item.LoadRequest); err != nil {
+		return err
+	}
+	if item.Scale != nil {
+		if err := h.engine.ScaleFunction(item.Namespace, item.Name, *item.Scale); err != nil {
+			return fmt.Errorf("failed to scale to %d instances: %w", *item.Scale, err)
+		}
+	}
+	return nil
+}
+
+// runLoadBatch runs load for every item of a validated batch, at most req.Concurrency at
+// once, starting each item once the items of the services it depends on are loaded.
+func runLoadBatch(ctx context.Context, req types.LoadBatchRequest,
+	load func(context.Context, types.LoadBatchItem) error) []types.LoadBatchResult {
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultLoadBatchConcurrency
+	}
+
+	services := make(map[string]int, len(req.Loads))
+	for i, item := range req.Loads {
+		if item.Service != "" {
+			services[item.Service] = i
+		}
+	}
+
+	results := make([]types.LoadBatchResult, len(req.Loads))
+	done := make([]chan struct{}, len(req.Loads))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	slots := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, item := range req.Loads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+
+			result := &results[i]
+			*result = types.LoadBatchResult{
+				Namespace: item.Namespace,
+				Name:      item.Name,
+				Service:   item.Service,
+				Status:    types.LoadBatchFailed,
+			}
+
+			// Validate has ruled out cycles, so waiting on dependencies cannot deadlock
+			for _, dep := range item.DependsOn {
+				<-done[services[dep]]
+				if results[services[dep]].Status != types.LoadBatchLoaded {
+					result.Status = types.LoadBatchSkipped
+					result.Error = fmt.Sprintf("dependency %q was not loaded", dep)
+					return
+				}
+			}
+
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				result.Error = ctx.Err().Error()
+				return
+			}
+			err := load(ctx, item)
+			<-slots
+
+			if err != nil {
+				result.Error = err.Error()
+				return
+			}
+			result.Status = types.LoadBatchLoaded
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func batchItem(service string, dependsOn ...string) types.LoadBatchItem {
+	item := types.LoadBatchItem{DependsOn: dependsOn}
+	item.Namespace, item.Name, item.Digest, item.Service = "ns", service, "latest", service
+	return item
+}
+
+func TestRunLoadBatchOrdersDependencies(t *testing.T) {
+	req := types.LoadBatchRequest{Loads: []types.LoadBatchItem{
+		batchItem("api", "db", "cache"),
+		batchItem("db"),
+		batchItem("cache"),
+		batchItem("worker", "broken"),
+		batchItem("broken"),
+	}}
+	require.NoError(t, req.Validate())
+
+	var mu sync.Mutex
+	var order []string
+	results := runLoadBatch(context.Background(), req, func(_ context.Context, item types.LoadBatchItem) error {
+		mu.Lock()
+		order = append(order, item.Service)
+		mu.Unlock()
+		if item.Service == "broken" {
+			return errors.New("function not found")
+		}
+		return nil
+	})
+
+	require.Len(t, results, 5)
+	assert.Equal(t, types.LoadBatchLoaded, results[0].Status)
+	assert.Equal(t, "api", results[0].Service)
+	assert.Equal(t, types.LoadBatchFailed, results[4].Status)
+	assert.Equal(t, "function not found", results[4].Error)
+	assert.Equal(t, types.LoadBatchSkipped, results[3].Status)
+	assert.Contains(t, results[3].Error, `"broken"`)
+
+	// Dependents load after their dependencies, and skipped loads never run
+	api := slices.Index(order, "api")
+	assert.Greater(t, api, slices.Index(order, "db"))
+	assert.Greater(t, api, slices.Index(order, "cache"))
+	assert.NotContains(t, order, "worker")
+}
+
+func TestRunLoadBatchBoundsConcurrency(t *testing.T) {
+	req := types.LoadBatchRequest{Concurrency: 2}
+	for _, service := range []string{"a", "b", "c", "d", "e", "f"} {
+		req.Loads = append(req.Loads, batchItem(service))
+	}
+
+	var running, peak atomic.Int32
+	results := runLoadBatch(context.Background(), req, func(context.Context, types.LoadBatchItem) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	})
+
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+	for _, result := range results {
+		assert.Equal(t, types.LoadBatchLoaded, result.Status)
+	}
+}
+
+func TestLoadBatchRequestValidate(t *testing.T) {
+	req := types.LoadBatchRequest{Loads: []types.LoadBatchItem{batchItem("a", "b"), batchItem("b", "a")}}
+	assert.ErrorContains(t, req.Validate(), "dependency cycle: a -> b -> a")
+
+	req = types.LoadBatchRequest{Loads: []types.LoadBatchItem{batchItem("a", "missing")}}
+	assert.ErrorContains(t, req.Validate(), `"missing"`)
+
+	req = types.LoadBatchRequest{Loads: []types.LoadBatchItem{batchItem("a"), batchItem("a")}}
+	assert.ErrorContains(t, req.Validate(), "loaded twice")
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package engine
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock is not supported without flock, so engines cannot be paired.
+func tryLock(_ string) (*os.File, error) {
+	return nil, errors.New("engine HA requires file locks, which are not available on this platform")
+}
@@ -0,0 +1,309 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+)
+
+// logFileExt is the extension of the current log file of a function; rotated files
+// append a generation number to it (name.jsonl.1 is the most recent rotation)
+const logFileExt = ".jsonl"
+
+// FileSinkOptions configures a FileSink.
+type FileSinkOptions struct {
+	// Directory holding one subdirectory per namespace
+	Dir string
+
+	// Size in bytes at which a function's log file is rotated (0 never rotates)
+	MaxSize int64
+
+	// Number of rotated files kept per function (0 keeps none)
+	MaxFiles int
+
+	// Rotated files older than this are deleted (0 keeps them until evicted by MaxFiles)
+	MaxAge time.Duration
+}
+
+// FileSink persists function log entries as JSON lines, one file per function, so logs
+// survive engine restarts and outlive the in-memory store.
+type FileSink struct {
+	opts  FileSinkOptions
+	mu    sync.Mutex
+	files map[interfaces.FunctionKey]*sinkFile
+}
+
+type sinkFile struct {
+	file *os.File
+	size int64
+}
+
+// fileEntry is the on-disk form of a log entry
+type fileEntry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+// NewFileSink creates the log directory and returns a sink writing into it.
+func NewFileSink(opts FileSinkOptions) (*FileSink, error) {
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	return &FileSink{
+		opts:  opts,
+		files: make(map[interfaces.FunctionKey]*sinkFile),
+	}, nil
+}
+
+// Write appends an entry to the function's log file, rotating it when it grows past MaxSize.
+func (s *FileSink) Write(functionKey interfaces.FunctionKey, entry FunctionLogEntry) error {
+	line, err := json.Marshal(fileEntry{
+		Time:    entry.Timestamp,
+		Level:   strings.ToLower(entry.Level.String()),
+		Message: entry.Message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode log entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.open(functionKey)
+	if err != nil {
+		return err
+	}
+
+	if s.opts.MaxSize > 0 && f.size > 0 && f.size+int64(len(line)) > s.opts.MaxSize {
+		if err := s.rotate(functionKey, f); err != nil {
+			return err
+		}
+		if f, err = s.open(functionKey); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write log entry: %w", err)
+	}
+	return nil
+}
+
+// Read returns the function's persisted entries after since (all when zero), oldest
+// first, limited to the last tail entries when tail is positive. With levels, only
+// entries at one of those levels are returned.
+func (s *FileSink) Read(functionKey interfaces.FunctionKey, since time.Time, tail int, levels ...LogLevel) ([]FunctionLogEntry, error) {
+	base, err := s.basePath(functionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []FunctionLogEntry
+	for _, path := range s.generations(base) {
+		fileEntries, err := readLogFile(path, since)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range fileEntries {
+			if matchesLevel(entry.Level, levels) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	if tail > 0 && len(entries) > tail {
+		entries = entries[len(entries)-tail:]
+	}
+	return entries, nil
+}
+
+// Prune deletes rotated files older than MaxAge and returns how many were removed.
+func (s *FileSink) Prune() int {
+	if s.opts.MaxAge <= 0 {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-s.opts.MaxAge)
+	removed := 0
+	_ = filepath.WalkDir(s.opts.Dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || rotatedGeneration(path, strings.TrimSuffix(path, filepath.Ext(path))) == 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err == nil && info.ModTime().Before(cutoff) && os.Remove(path) == nil {
+			removed++
+		}
+		return nil
+	})
+	return removed
+}
+
+// Close closes every open log file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for key, f := range s.files {
+		errs = append(errs, f.file.Close())
+		delete(s.files, key)
+	}
+	return errors.Join(errs...)
+}
+
+// open returns the function's current log file, opening it for append if needed
+func (s *FileSink) open(functionKey interfaces.FunctionKey) (*sinkFile, error) {
+	if f, ok := s.files[functionKey]; ok {
+		return f, nil
+	}
+
+	base, err := s.basePath(functionKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(base), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(base+logFileExt, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	f := &sinkFile{file: file, size: info.Size()}
+	s.files[functionKey] = f
+	return f, nil
+}
+
+// rotate shifts the rotated generations up by one, dropping those beyond MaxFiles,
+// and moves the current file to generation 1
+func (s *FileSink) rotate(functionKey interfaces.FunctionKey, f *sinkFile) error {
+	f.file.Close()
+	delete(s.files, functionKey)
+
+	base, err := s.basePath(functionKey)
+	if err != nil {
+		return err
+	}
+
+	if s.opts.MaxFiles <= 0 {
+		return os.Remove(base + logFileExt)
+	}
+
+	_ = os.Remove(rotatedPath(base, s.opts.MaxFiles))
+	for gen := s.opts.MaxFiles - 1; gen >= 1; gen-- {
+		if err := os.Rename(rotatedPath(base, gen), rotatedPath(base, gen+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+	if err := os.Rename(base+logFileExt, rotatedPath(base, 1)); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return nil
+}
+
+// generations lists the function's existing log files from oldest to newest
+func (s *FileSink) generations(base string) []string {
+	matches, _ := filepath.Glob(base + logFileExt + ".*")
+
+	rotated := make([]string, 0, len(matches))
+	for _, path := range matches {
+		if rotatedGeneration(path, base+logFileExt) > 0 {
+			rotated = append(rotated, path)
+		}
+	}
+	sort.Slice(rotated, func(i, j int) bool {
+		return rotatedGeneration(rotated[i], base+logFileExt) > rotatedGeneration(rotated[j], base+logFileExt)
+	})
+
+	if _, err := os.Stat(base + logFileExt); err == nil {
+		rotated = append(rotated, base+logFileExt)
+	}
+	return rotated
+}
+
+// basePath returns the log file path of a function without extension. Keys are
+// path-escaped, so a component can only escape the directory by being "." or "..".
+func (s *FileSink) basePath(functionKey interfaces.FunctionKey) (string, error) {
+	for _, part := range []string{functionKey.Namespace, functionKey.Name} {
+		if part == "" || part == "." || part == ".." {
+			return "", fmt.Errorf("invalid function key for log file: %s", functionKey)
+		}
+	}
+	return filepath.Join(s.opts.Dir, filepath.FromSlash(functionKey.Encode())), nil
+}
+
+func rotatedPath(base string, gen int) string {
+	return base + logFileExt + "." + strconv.Itoa(gen)
+}
+
+// rotatedGeneration returns the generation number of a rotation of current, or 0
+// when path is not one
+func rotatedGeneration(path, current string) int {
+	suffix, ok := strings.CutPrefix(path, current+".")
+	if !ok || !strings.HasSuffix(current, logFileExt) {
+		return 0
+	}
+	gen, err := strconv.Atoi(suffix)
+	if err != nil || gen < 1 {
+		return 0
+	}
+	return gen
+}
+
+// readLogFile decodes the entries of one log file written after since
+func readLogFile(path string, since time.Time) ([]FunctionLogEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []FunctionLogEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var fe fileEntry
+		// Skip lines cut short by a crash instead of failing the whole read
+		if err := json.Unmarshal(scanner.Bytes(), &fe); err != nil {
+			continue
+		}
+		if !since.IsZero() && !fe.Time.After(since) {
+			continue
+		}
+		level, _ := ParseLogLevel(fe.Level)
+		entries = append(entries, FunctionLogEntry{Timestamp: fe.Time, Level: level, Message: fe.Message})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSinkRotation(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(FileSinkOptions{Dir: dir, MaxSize: 200, MaxFiles: 2})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, sink.Write(key, FunctionLogEntry{Timestamp: time.Now(), Level: LevelInfo, Message: "entry"}))
+	}
+
+	current := filepath.Join(dir, "ns", "fn.jsonl")
+	assert.FileExists(t, current)
+	assert.FileExists(t, current+".1")
+	assert.FileExists(t, current+".2")
+	assert.NoFileExists(t, current+".3")
+
+	info, err := os.Stat(current)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), int64(200))
+
+	entries, err := sink.Read(key, time.Time{}, 0)
+	require.NoError(t, err)
+	assert.Less(t, len(entries), 20)
+	assert.Equal(t, LevelInfo, entries[0].Level)
+
+	tail, err := sink.Read(key, time.Time{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, entries[len(entries)-2:], tail)
+}
+
+func TestFileSinkPrune(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(FileSinkOptions{Dir: dir, MaxSize: 50, MaxFiles: 5, MaxAge: time.Hour})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, sink.Write(key, FunctionLogEntry{Timestamp: time.Now(), Level: LevelInfo, Message: "entry"}))
+	}
+
+	rotated := filepath.Join(dir, "ns", "fn.jsonl.1")
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(rotated, old, old))
+
+	assert.Equal(t, 1, sink.Prune())
+	assert.NoFileExists(t, rotated)
+	assert.FileExists(t, filepath.Join(dir, "ns", "fn.jsonl"))
+}
+
+func TestFunctionLogStoreReadsTrimmedLogsFromSink(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(FileSinkOptions{Dir: dir})
+	require.NoError(t, err)
+
+	store := NewFunctionLogStoreWithOptions(LogStoreOptions{MaxEntries: 2, MinLevel: LevelInfo, Sink: sink})
+	for _, msg := range []string{"first", "second", "third"} {
+		store.AddLog(key, LevelInfo, msg)
+	}
+
+	// The last two entries are answered from memory
+	logs := store.GetLogs(key, time.Time{}, 2)
+	require.Len(t, logs, 2)
+	assert.Contains(t, logs[0], "second")
+
+	// Asking for more than memory holds falls back to the files
+	logs = store.GetLogs(key, time.Time{}, 0)
+	require.Len(t, logs, 3)
+	assert.Contains(t, logs[0], "[INFO] first")
+	require.NoError(t, sink.Close())
+
+	// A fresh store, as after a restart, still serves the persisted entries
+	sink, err = NewFileSink(FileSinkOptions{Dir: dir})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	restarted := NewFunctionLogStoreWithOptions(LogStoreOptions{MaxEntries: 2, MinLevel: LevelInfo, Sink: sink})
+	logs = restarted.GetLogs(key, time.Time{}, 0)
+	require.Len(t, logs, 3)
+	assert.True(t, restarted.Usage().Persisted)
+}
@@ -3,8 +3,12 @@ package logging
 import (
 	"fmt"
 	"io"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
 )
 
 type LogLevel int
@@ -56,24 +60,205 @@ type FunctionLogEntry struct {
 	Message   string
 }
 
+// LogStore records per-function log entries. The engine shares a single store
+// between the loader, executor and plugin manager so every code path logs to
+// the same place.
+type LogStore interface {
+	AddLog(functionKey interfaces.FunctionKey, level LogLevel, message string)
+	// GetLogs returns entries after since, limited to the last tail; with levels,
+	// only entries at one of those levels are returned
+	GetLogs(functionKey interfaces.FunctionKey, since time.Time, tail int, levels ...LogLevel) []string
+
+	// SetRetention overrides the store limits for one function; a zero Retention restores them
+	SetRetention(functionKey interfaces.FunctionKey, retention Retention)
+
+	// SetSecrets masks these values in the function's later entries; none stops masking
+	SetSecrets(functionKey interfaces.FunctionKey, secrets []string)
+
+	// Trim drops entries past their retention window and returns how many were removed
+	Trim() int
+
+	// Usage reports how many entries the store holds
+	Usage() LogStoreUsage
+}
+
+// Retention limits the entries kept for one function. Zero fields fall back to the store limits.
+type Retention struct {
+	// Maximum number of entries kept
+	MaxEntries int `json:"max_entries,omitempty"`
+
+	// Entries older than this are discarded
+	MaxAge time.Duration `json:"max_age,omitempty"`
+}
+
+// LogStoreUsage summarizes the contents of a log store.
+type LogStoreUsage struct {
+	Entries   int                                         `json:"entries"`
+	Bytes     int64                                       `json:"bytes"`
+	Functions map[interfaces.FunctionKey]FunctionLogUsage `json:"functions"`
+
+	// Whether entries are also written to log files, and how many writes failed
+	Persisted       bool  `json:"persisted"`
+	FileWriteErrors int64 `json:"file_write_errors,omitempty"`
+}
+
+// FunctionLogUsage describes the entries kept for one function and the limits applied to them.
+type FunctionLogUsage struct {
+	Entries    int       `json:"entries"`
+	Bytes      int64     `json:"bytes"`
+	Oldest     time.Time `json:"oldest"`
+	MaxEntries int       `json:"max_entries"`
+	MaxAge     string    `json:"max_age,omitempty"`
+}
+
+// LogStoreOptions configures a FunctionLogStore.
+type LogStoreOptions struct {
+	// Maximum number of entries kept per function
+	MaxEntries int
+
+	// Entries less severe than this level are dropped
+	MinLevel LogLevel
+
+	// Entries older than this are discarded (0 keeps entries until evicted by MaxEntries)
+	Retention time.Duration
+
+	// Optional sink that persists every entry to disk
+	Sink *FileSink
+
+	// Optional forwarder that ships every entry to external systems
+	Forwarder Forwarder
+}
+
+// Forwarder receives every entry recorded in a log store. Forward must not block.
+type Forwarder interface {
+	Forward(functionKey interfaces.FunctionKey, entry FunctionLogEntry)
+}
+
+// ParseLogLevel converts a level name (debug, info, warning, error) into a LogLevel.
+func ParseLogLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarning, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level: %s", name)
+	}
+}
+
+// ParseLogLevels converts level names into LogLevels. Each name may list several
+// levels separated by commas, such as "error,warning"; empty names are skipped.
+func ParseLogLevels(names []string) ([]LogLevel, error) {
+	var levels []LogLevel
+	for _, name := range names {
+		for _, part := range strings.Split(name, ",") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			level, err := ParseLogLevel(part)
+			if err != nil {
+				return nil, err
+			}
+			levels = append(levels, level)
+		}
+	}
+	return levels, nil
+}
+
+// matchesLevel reports whether level is one of levels; no levels match everything.
+func matchesLevel(level LogLevel, levels []LogLevel) bool {
+	if len(levels) == 0 {
+		return true
+	}
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the display name of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LevelWarning:
+		return "WARNING"
+	case LevelError:
+		return "ERROR"
+	case LevelDebug:
+		return "DEBUG"
+	default:
+		return "INFO"
+	}
+}
+
+// severity orders levels from least to most severe. LevelDebug is declared
+// last for compatibility, so its numeric value cannot be compared directly.
+func (l LogLevel) severity() int {
+	switch l {
+	case LevelDebug:
+		return 0
+	case LevelWarning:
+		return 2
+	case LevelError:
+		return 3
+	default:
+		return 1
+	}
+}
+
 type FunctionLogStore struct {
-	logs       map[string][]FunctionLogEntry
+	logs       map[interfaces.FunctionKey][]FunctionLogEntry
 	mutex      sync.RWMutex
 	maxEntries int
+	minLevel   LogLevel
+	retention  time.Duration
+
+	// Per-function overrides of maxEntries and retention
+	overrides map[interfaces.FunctionKey]Retention
+
+	// Per-function maskers of secret config values
+	redactors map[interfaces.FunctionKey]*strings.Replacer
+
+	// Persists entries beyond the in-memory limits (nil when disabled)
+	sink       *FileSink
+	sinkErrors atomic.Int64
+
+	// Ships entries to external systems (nil when disabled)
+	forwarder Forwarder
 }
 
 // NewFunctionLogStore creates a new FunctionLogStore.
 func NewFunctionLogStore(maxEntries int) *FunctionLogStore {
+	return NewFunctionLogStoreWithOptions(LogStoreOptions{
+		MaxEntries: maxEntries,
+		MinLevel:   LevelDebug,
+	})
+}
+
+// NewFunctionLogStoreWithOptions creates a new FunctionLogStore with level filtering and retention.
+func NewFunctionLogStoreWithOptions(opts LogStoreOptions) *FunctionLogStore {
 	return &FunctionLogStore{
-		logs:       make(map[string][]FunctionLogEntry),
-		maxEntries: maxEntries,
+		logs:       make(map[interfaces.FunctionKey][]FunctionLogEntry),
+		overrides:  make(map[interfaces.FunctionKey]Retention),
+		redactors:  make(map[interfaces.FunctionKey]*strings.Replacer),
+		maxEntries: opts.MaxEntries,
+		minLevel:   opts.MinLevel,
+		retention:  opts.Retention,
+		sink:       opts.Sink,
+		forwarder:  opts.Forwarder,
 	}
 }
 
 // AddLog adds a log entry for a function.
-func (s *FunctionLogStore) AddLog(functionKey string, level LogLevel, message string) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+func (s *FunctionLogStore) AddLog(functionKey interfaces.FunctionKey, level LogLevel, message string) {
+	if level.severity() < s.minLevel.severity() {
+		return
+	}
 
 	entry := FunctionLogEntry{
 		Timestamp: time.Now(),
@@ -81,61 +266,229 @@ func (s *FunctionLogStore) AddLog(functionKey string, level LogLevel, message st
 		Message:   message,
 	}
 
-	if _, exists := s.logs[functionKey]; !exists {
-		s.logs[functionKey] = make([]FunctionLogEntry, 0)
+	s.mutex.Lock()
+	if redactor := s.redactors[functionKey]; redactor != nil {
+		entry.Message = redactor.Replace(entry.Message)
 	}
+	s.appendEntry(functionKey, entry)
+	s.mutex.Unlock()
 
-	s.logs[functionKey] = append(s.logs[functionKey], entry)
+	// Write outside the lock so slow disks don't block readers
+	if s.sink != nil {
+		if err := s.sink.Write(functionKey, entry); err != nil {
+			s.sinkErrors.Add(1)
+		}
+	}
+	if s.forwarder != nil {
+		s.forwarder.Forward(functionKey, entry)
+	}
+}
+
+// appendEntry adds an entry to the in-memory log of a function and applies its limits
+func (s *FunctionLogStore) appendEntry(functionKey interfaces.FunctionKey, entry FunctionLogEntry) {
+
+	maxEntries, maxAge := s.limits(functionKey)
+	entries := append(expire(s.logs[functionKey], maxAge, entry.Timestamp), entry)
 
 	// If we've exceeded the max number of entries, remove the oldest ones
-	if len(s.logs[functionKey]) > s.maxEntries {
-		s.logs[functionKey] = s.logs[functionKey][len(s.logs[functionKey])-s.maxEntries:]
+	if maxEntries > 0 && len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
 	}
+
+	s.logs[functionKey] = entries
 }
 
-// GetLogs retrieves logs for a function.
-func (s *FunctionLogStore) GetLogs(functionKey string, since time.Time, tail int) []string {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+// limits returns the entry cap and retention window that apply to a function.
+func (s *FunctionLogStore) limits(functionKey interfaces.FunctionKey) (int, time.Duration) {
+	maxEntries, maxAge := s.maxEntries, s.retention
+	if override, ok := s.overrides[functionKey]; ok {
+		if override.MaxEntries > 0 {
+			maxEntries = override.MaxEntries
+		}
+		if override.MaxAge > 0 {
+			maxAge = override.MaxAge
+		}
+	}
+	return maxEntries, maxAge
+}
 
-	if _, exists := s.logs[functionKey]; !exists {
-		return []string{}
+// expire drops entries that are past the retention window.
+func expire(entries []FunctionLogEntry, maxAge time.Duration, now time.Time) []FunctionLogEntry {
+	if maxAge <= 0 {
+		return entries
 	}
 
-	entries := s.logs[functionKey]
-	var filtered []FunctionLogEntry
+	cutoff := now.Add(-maxAge)
+	for i, entry := range entries {
+		if entry.Timestamp.After(cutoff) {
+			return entries[i:]
+		}
+	}
+	return entries[:0]
+}
 
-	// Filter by time if since is not zero
-	if !since.IsZero() {
+// SetSecrets masks the secret values in the entries later added for a function. Entries
+// already kept are left as they are; no secrets stop masking.
+func (s *FunctionLogStore) SetSecrets(functionKey interfaces.FunctionKey, secrets []string) {
+	redactor := newRedactor(secrets)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if redactor == nil {
+		delete(s.redactors, functionKey)
+	} else {
+		s.redactors[functionKey] = redactor
+	}
+}
+
+// SetRetention overrides the entry cap and retention window of one function and
+// applies them to the entries already kept.
+func (s *FunctionLogStore) SetRetention(functionKey interfaces.FunctionKey, retention Retention) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if retention == (Retention{}) {
+		delete(s.overrides, functionKey)
+	} else {
+		s.overrides[functionKey] = retention
+	}
+
+	entries, ok := s.logs[functionKey]
+	if !ok {
+		return
+	}
+	maxEntries, maxAge := s.limits(functionKey)
+	entries = expire(entries, maxAge, time.Now())
+	if maxEntries > 0 && len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+	s.logs[functionKey] = entries
+}
+
+// Trim drops expired entries of every function, forgetting functions left without
+// entries, and returns the number of entries removed. Expired log files are deleted too.
+func (s *FunctionLogStore) Trim() int {
+	if s.sink != nil {
+		s.sink.Prune()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for key, entries := range s.logs {
+		_, maxAge := s.limits(key)
+		kept := expire(entries, maxAge, now)
+		removed += len(entries) - len(kept)
+
+		if len(kept) == 0 {
+			delete(s.logs, key)
+			continue
+		}
+		// Copy so the trimmed prefix can be garbage collected
+		if len(kept) < len(entries) {
+			kept = append([]FunctionLogEntry(nil), kept...)
+		}
+		s.logs[key] = kept
+	}
+	return removed
+}
+
+// Usage reports the entries and message bytes kept per function.
+func (s *FunctionLogStore) Usage() LogStoreUsage {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	usage := LogStoreUsage{
+		Functions:       make(map[interfaces.FunctionKey]FunctionLogUsage, len(s.logs)),
+		Persisted:       s.sink != nil,
+		FileWriteErrors: s.sinkErrors.Load(),
+	}
+	for key, entries := range s.logs {
+		maxEntries, maxAge := s.limits(key)
+		fn := FunctionLogUsage{
+			Entries:    len(entries),
+			MaxEntries: maxEntries,
+		}
+		if maxAge > 0 {
+			fn.MaxAge = maxAge.String()
+		}
+		if len(entries) > 0 {
+			fn.Oldest = entries[0].Timestamp
+		}
 		for _, entry := range entries {
-			if entry.Timestamp.After(since) {
-				filtered = append(filtered, entry)
-			}
+			fn.Bytes += int64(len(entry.Message))
 		}
-	} else {
-		filtered = entries
+
+		usage.Functions[key] = fn
+		usage.Entries += fn.Entries
+		usage.Bytes += fn.Bytes
 	}
+	return usage
+}
 
-	// Apply tail limit if specified
-	if tail > 0 && len(filtered) > tail {
-		filtered = filtered[len(filtered)-tail:]
+// GetLogs retrieves logs for a function, only those at one of levels when any are
+// given. With a file sink, entries that the in-memory store no longer holds are read
+// back from disk.
+func (s *FunctionLogStore) GetLogs(functionKey interfaces.FunctionKey, since time.Time, tail int, levels ...LogLevel) []string {
+	filtered, complete := s.memoryLogs(functionKey, since, tail, levels)
+
+	if !complete && s.sink != nil {
+		if persisted, err := s.sink.Read(functionKey, since, tail, levels...); err == nil && len(persisted) > len(filtered) {
+			filtered = persisted
+		}
 	}
 
 	// Convert to strings for output
 	result := make([]string, len(filtered))
 	for i, entry := range filtered {
-		levelStr := "INFO"
-		if entry.Level == LevelWarning {
-			levelStr = "WARNING"
-		} else if entry.Level == LevelError {
-			levelStr = "ERROR"
-		}
-
 		result[i] = fmt.Sprintf("[%s] [%s] %s",
 			entry.Timestamp.Format(time.RFC3339),
-			levelStr,
+			entry.Level,
 			entry.Message)
 	}
 
 	return result
 }
+
+// memoryLogs selects the in-memory entries of a function and reports whether they
+// fully answer the query, which is not the case when older entries were dropped.
+func (s *FunctionLogStore) memoryLogs(functionKey interfaces.FunctionKey, since time.Time, tail int, levels []LogLevel) ([]FunctionLogEntry, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries, exists := s.logs[functionKey]
+	if !exists || len(entries) == 0 {
+		return nil, false
+	}
+	reachesBack := !since.IsZero() && !entries[0].Timestamp.After(since)
+
+	// Never return entries past the retention window, even if they haven't been pruned yet
+	if _, maxAge := s.limits(functionKey); maxAge > 0 {
+		cutoff := time.Now().Add(-maxAge)
+		if since.Before(cutoff) {
+			since = cutoff
+		}
+	}
+
+	var filtered []FunctionLogEntry
+
+	// Filter by time if since is not zero, and by level if levels are given
+	if !since.IsZero() || len(levels) > 0 {
+		for _, entry := range entries {
+			if entry.Timestamp.After(since) && matchesLevel(entry.Level, levels) {
+				filtered = append(filtered, entry)
+			}
+		}
+	} else {
+		filtered = entries
+	}
+
+	// Apply tail limit if specified
+	if tail > 0 && len(filtered) >= tail {
+		return filtered[len(filtered)-tail:], true
+	}
+
+	return filtered, reachesBack
+}
@@ -0,0 +1,183 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var key = interfaces.NewFunctionKey("ns", "fn")
+
+func TestFunctionLogStoreMinLevel(t *testing.T) {
+	store := NewFunctionLogStoreWithOptions(LogStoreOptions{
+		MaxEntries: 10,
+		MinLevel:   LevelWarning,
+	})
+
+	store.AddLog(key, LevelDebug, "debug")
+	store.AddLog(key, LevelInfo, "info")
+	store.AddLog(key, LevelWarning, "warning")
+	store.AddLog(key, LevelError, "error")
+
+	logs := store.GetLogs(key, time.Time{}, 0)
+	require.Len(t, logs, 2)
+	assert.Contains(t, logs[0], "[WARNING] warning")
+	assert.Contains(t, logs[1], "[ERROR] error")
+}
+
+func TestFunctionLogStoreLevelFilter(t *testing.T) {
+	store := NewFunctionLogStoreWithOptions(LogStoreOptions{MaxEntries: 10, MinLevel: LevelDebug})
+
+	store.AddLog(key, LevelError, "first error")
+	store.AddLog(key, LevelInfo, "info")
+	store.AddLog(key, LevelWarning, "warning")
+	store.AddLog(key, LevelError, "second error")
+	store.AddLog(key, LevelDebug, "debug")
+
+	logs := store.GetLogs(key, time.Time{}, 0, LevelError)
+	require.Len(t, logs, 2)
+	assert.Contains(t, logs[0], "[ERROR] first error")
+	assert.Contains(t, logs[1], "[ERROR] second error")
+
+	// The tail applies to the filtered entries
+	logs = store.GetLogs(key, time.Time{}, 1, LevelError)
+	require.Len(t, logs, 1)
+	assert.Contains(t, logs[0], "second error")
+
+	levels, err := ParseLogLevels([]string{"error,warn", "debug"})
+	require.NoError(t, err)
+	assert.Len(t, store.GetLogs(key, time.Time{}, 0, levels...), 4)
+
+	_, err = ParseLogLevels([]string{"error,verbose"})
+	assert.Error(t, err)
+}
+
+func TestFunctionLogStoreCapacityAndRetention(t *testing.T) {
+	store := NewFunctionLogStoreWithOptions(LogStoreOptions{
+		MaxEntries: 2,
+		MinLevel:   LevelInfo,
+		Retention:  time.Hour,
+	})
+
+	store.AddLog(key, LevelInfo, "first")
+	store.AddLog(key, LevelInfo, "second")
+	store.AddLog(key, LevelInfo, "third")
+
+	logs := store.GetLogs(key, time.Time{}, 0)
+	require.Len(t, logs, 2)
+	assert.Contains(t, logs[0], "second")
+	assert.Contains(t, logs[1], "third")
+
+	// Age the stored entries past the retention window
+	store.mutex.Lock()
+	for i := range store.logs[key] {
+		store.logs[key][i].Timestamp = time.Now().Add(-2 * time.Hour)
+	}
+	store.mutex.Unlock()
+
+	assert.Empty(t, store.GetLogs(key, time.Time{}, 0))
+
+	store.AddLog(key, LevelInfo, "fresh")
+	logs = store.GetLogs(key, time.Time{}, 0)
+	require.Len(t, logs, 1)
+	assert.Contains(t, logs[0], "fresh")
+}
+
+func TestParseLogLevel(t *testing.T) {
+	level, err := ParseLogLevel("WARN")
+	require.NoError(t, err)
+	assert.Equal(t, LevelWarning, level)
+
+	_, err = ParseLogLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestFunctionLogStoreRetentionOverride(t *testing.T) {
+	store := NewFunctionLogStoreWithOptions(LogStoreOptions{
+		MaxEntries: 10,
+		MinLevel:   LevelInfo,
+	})
+	other := interfaces.NewFunctionKey("ns", "other")
+
+	for _, msg := range []string{"one", "two", "three"} {
+		store.AddLog(key, LevelInfo, msg)
+		store.AddLog(other, LevelInfo, msg)
+	}
+
+	// Lowering the cap trims the entries already kept
+	store.SetRetention(key, Retention{MaxEntries: 1, MaxAge: time.Hour})
+	require.Len(t, store.GetLogs(key, time.Time{}, 0), 1)
+	assert.Len(t, store.GetLogs(other, time.Time{}, 0), 3)
+
+	usage := store.Usage()
+	assert.Equal(t, 4, usage.Entries)
+	assert.Equal(t, 1, usage.Functions[key].MaxEntries)
+	assert.Equal(t, "1h0m0s", usage.Functions[key].MaxAge)
+	assert.Equal(t, 10, usage.Functions[other].MaxEntries)
+	assert.Equal(t, int64(len("onetwothree")), usage.Functions[other].Bytes)
+
+	// Age the entries past the override; only the overridden function expires
+	store.mutex.Lock()
+	for _, k := range []interfaces.FunctionKey{key, other} {
+		for i := range store.logs[k] {
+			store.logs[k][i].Timestamp = time.Now().Add(-2 * time.Hour)
+		}
+	}
+	store.mutex.Unlock()
+
+	assert.Equal(t, 1, store.Trim())
+	usage = store.Usage()
+	assert.NotContains(t, usage.Functions, key)
+	assert.Equal(t, 3, usage.Entries)
+
+	// A zero retention restores the store defaults
+	store.SetRetention(key, Retention{})
+	for i := 0; i < 3; i++ {
+		store.AddLog(key, LevelInfo, "again")
+	}
+	assert.Len(t, store.GetLogs(key, time.Time{}, 0), 3)
+}
+
+func TestFunctionLogStoreRedactsSecrets(t *testing.T) {
+	store := NewFunctionLogStore(10)
+	config := map[string]string{
+		"API_KEY":     "sk-123456",
+		"db_password": "hunter2hunter2",
+		"PASSWORD2":   "abc", // too short to mask
+		"REGION":      "eu-west-1",
+	}
+	store.SetSecrets(key, SecretValues(config))
+
+	store.AddLog(key, LevelInfo, "connecting to eu-west-1 with sk-123456 and hunter2hunter2, abc")
+	store.SetSecrets(key, nil)
+	store.AddLog(key, LevelInfo, "sk-123456")
+
+	logs := store.GetLogs(key, time.Time{}, 0)
+	require.Len(t, logs, 2)
+	assert.Contains(t, logs[0], "connecting to eu-west-1 with [REDACTED] and [REDACTED], abc")
+	assert.Contains(t, logs[1], "sk-123456")
+}
+
+func TestRedactionPatterns(t *testing.T) {
+	redaction := NewRedaction([]string{"dsn", "Secret"})
+	config := map[string]string{
+		"DATABASE_DSN":  "postgres://app:pw@db/app",
+		"client_secret": "s3cr3t-value",
+		"API_KEY":       "sk-123456",
+	}
+
+	assert.True(t, redaction.IsSecretKey("database_dsn"))
+	assert.False(t, redaction.IsSecretKey("API_KEY"))
+	assert.Equal(t, []string{"postgres://app:pw@db/app", "s3cr3t-value"}, redaction.SecretValues(config))
+	assert.Equal(t, map[string]string{
+		"DATABASE_DSN":  Redacted,
+		"client_secret": Redacted,
+		"API_KEY":       "sk-123456",
+	}, redaction.Config(config))
+
+	// Without patterns the defaults apply
+	assert.True(t, NewRedaction(nil).IsSecretKey("API_KEY"))
+}
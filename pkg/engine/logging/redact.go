@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"sort"
+	"strings"
+)
+
+// Redacted replaces secret config values in function log entries.
+const Redacted = "[REDACTED]"
+
+// minSecretLength keeps short values such as "1" or "yes" from being masked everywhere
+const minSecretLength = 4
+
+// DefaultSecretPatterns are the parts of config keys whose values are treated as secrets
+// when the engine configures none.
+var DefaultSecretPatterns = []string{"SECRET", "TOKEN", "PASSWORD", "PASSWD", "KEY", "CREDENTIAL", "PRIVATE"}
+
+// Redaction decides which config keys hold secrets: those containing one of its
+// patterns, ignoring case.
+type Redaction struct {
+	patterns []string
+}
+
+// NewRedaction returns a Redaction matching keys against the patterns, or against
+// DefaultSecretPatterns when there are none.
+func NewRedaction(patterns []string) *Redaction {
+	if len(patterns) == 0 {
+		patterns = DefaultSecretPatterns
+	}
+	upper := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		upper = append(upper, strings.ToUpper(pattern))
+	}
+	return &Redaction{patterns: upper}
+}
+
+var defaultRedaction = NewRedaction(nil)
+
+// IsSecretKey reports whether a config key names a secret, such as API_KEY or db_password.
+func (r *Redaction) IsSecretKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, pattern := range r.patterns {
+		if strings.Contains(upper, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretValues returns the values of the secret keys of a config, longest first so
+// a secret containing another is masked whole.
+func (r *Redaction) SecretValues(config map[string]string) []string {
+	var secrets []string
+	for key, value := range config {
+		if r.IsSecretKey(key) && len(value) >= minSecretLength {
+			secrets = append(secrets, value)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	return secrets
+}
+
+// Config returns a copy of a config with the values of its secret keys replaced.
+func (r *Redaction) Config(config map[string]string) map[string]string {
+	if config == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(config))
+	for key, value := range config {
+		if r.IsSecretKey(key) {
+			value = Redacted
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// IsSecretKey reports whether a config key matches one of DefaultSecretPatterns.
+func IsSecretKey(key string) bool {
+	return defaultRedaction.IsSecretKey(key)
+}
+
+// SecretValues returns the values of the keys of a config matching DefaultSecretPatterns.
+func SecretValues(config map[string]string) []string {
+	return defaultRedaction.SecretValues(config)
+}
+
+// newRedactor returns a replacer masking the secrets, or nil when there are none.
+func newRedactor(secrets []string) *strings.Replacer {
+	if len(secrets) == 0 {
+		return nil
+	}
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, secret := range secrets {
+		pairs = append(pairs, secret, Redacted)
+	}
+	return strings.NewReplacer(pairs...)
+}
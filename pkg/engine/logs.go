@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+// startLogTrimming drops expired function log entries on the configured interval until ctx is done.
+// Without it, entries of a function that stopped logging are only dropped when read.
+func (e *Engine) startLogTrimming(ctx context.Context) {
+	interval := e.options.LogTrimInterval
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if removed := e.logStore.Trim(); removed > 0 {
+					e.logger.Debugf("Trimmed %d expired function log entries", removed)
+				}
+			}
+		}
+	}()
+}
+
+// FunctionLogs returns the most recent log lines of a function, oldest first.
+// A zero since and limit return everything kept in the log store; levels keep only
+// the lines at one of those levels.
+func (e *Engine) FunctionLogs(namespace, name string, since time.Time, limit int, levels ...logging.LogLevel) []string {
+	return e.logStore.GetLogs(GetFunctionKey(namespace, name), since, limit, levels...)
+}
+
+// SetFunctionLogRetention overrides how many log entries are kept for a function and
+// for how long. A zero retention restores the engine defaults.
+func (e *Engine) SetFunctionLogRetention(namespace, name string, retention logging.Retention) {
+	e.logStore.SetRetention(GetFunctionKey(namespace, name), retention)
+}
+
+// LogUsage reports the entries held by the function log store.
+func (e *Engine) LogUsage() logging.LogStoreUsage {
+	return e.logStore.Usage()
+}
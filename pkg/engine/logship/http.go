@@ -0,0 +1,191 @@
+package logship
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+// httpExporter posts JSON documents built from a batch to an HTTP endpoint.
+type httpExporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	encode  func([]Entry) any
+}
+
+func (h *httpExporter) Export(ctx context.Context, entries []Entry) error {
+	body, err := json.Marshal(h.encode(entries))
+	if err != nil {
+		return fmt.Errorf("failed to encode log batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range h.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (h *httpExporter) Close() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
+
+// newLokiExporter creates an exporter for the Loki push API. Entries are
+// grouped into streams labelled with their namespace, function and level plus
+// the configured static labels.
+func newLokiExporter(cfg config.LogSinkConfig) *httpExporter {
+	return &httpExporter{
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		client:  &http.Client{},
+		encode: func(entries []Entry) any {
+			return lokiPush(entries, cfg.Labels)
+		},
+	}
+}
+
+type lokiRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func lokiPush(entries []Entry, static map[string]string) lokiRequest {
+	streams := make(map[string]*lokiStream)
+	var order []string
+	for _, entry := range entries {
+		id := entry.Function.String() + "\x00" + entry.Level.String()
+		stream, ok := streams[id]
+		if !ok {
+			labels := make(map[string]string, len(static)+3)
+			for name, value := range static {
+				labels[name] = value
+			}
+			labels["namespace"] = entry.Function.Namespace
+			labels["function"] = entry.Function.Name
+			labels["level"] = strings.ToLower(entry.Level.String())
+			stream = &lokiStream{Stream: labels}
+			streams[id] = stream
+			order = append(order, id)
+		}
+		stream.Values = append(stream.Values, [2]string{
+			strconv.FormatInt(entry.Timestamp.UnixNano(), 10),
+			entry.Message,
+		})
+	}
+
+	sort.Strings(order)
+	req := lokiRequest{Streams: make([]lokiStream, 0, len(order))}
+	for _, id := range order {
+		req.Streams = append(req.Streams, *streams[id])
+	}
+	return req
+}
+
+// newOTLPExporter creates an exporter for the OTLP/HTTP logs endpoint using
+// the JSON encoding.
+func newOTLPExporter(cfg config.LogSinkConfig) *httpExporter {
+	return &httpExporter{
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		client:  &http.Client{},
+		encode:  otlpLogs,
+	}
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string          `json:"timeUnixNano"`
+	SeverityNumber int             `json:"severityNumber"`
+	SeverityText   string          `json:"severityText"`
+	Body           otlpValue       `json:"body"`
+	Attributes     []otlpAttribute `json:"attributes"`
+}
+
+func otlpAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: value}}
+}
+
+// otlpSeverity maps a log level to the OTLP severity number.
+func otlpSeverity(level logging.LogLevel) int {
+	switch level {
+	case logging.LevelDebug:
+		return 5
+	case logging.LevelWarning:
+		return 13
+	case logging.LevelError:
+		return 17
+	default:
+		return 9
+	}
+}
+
+func otlpLogs(entries []Entry) any {
+	records := make([]otlpLogRecord, 0, len(entries))
+	for _, entry := range entries {
+		records = append(records, otlpLogRecord{
+			TimeUnixNano:   strconv.FormatInt(entry.Timestamp.UnixNano(), 10),
+			SeverityNumber: otlpSeverity(entry.Level),
+			SeverityText:   entry.Level.String(),
+			Body:           otlpValue{StringValue: entry.Message},
+			Attributes: []otlpAttribute{
+				otlpAttr("ignition.namespace", entry.Function.Namespace),
+				otlpAttr("ignition.function", entry.Function.Name),
+			},
+		})
+	}
+
+	return map[string]any{
+		"resourceLogs": []any{
+			map[string]any{
+				"resource": map[string]any{
+					"attributes": []otlpAttribute{otlpAttr("service.name", "ignition")},
+				},
+				"scopeLogs": []any{
+					map[string]any{
+						"scope":      map[string]any{"name": "ignition"},
+						"logRecords": records,
+					},
+				},
+			},
+		},
+	}
+}
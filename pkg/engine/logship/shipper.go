@@ -0,0 +1,304 @@
+// Package logship forwards function log entries to external log systems
+// such as syslog, Grafana Loki and OTLP collectors.
+package logship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+const (
+	defaultBufferSize    = 10000
+	defaultBatchSize     = 500
+	defaultFlushInterval = 2 * time.Second
+	defaultSendTimeout   = 10 * time.Second
+	initialRetryBackoff  = 500 * time.Millisecond
+)
+
+// Entry is a function log entry queued for shipping.
+type Entry struct {
+	Function interfaces.FunctionKey
+	logging.FunctionLogEntry
+}
+
+// Exporter sends batches of entries to one destination.
+type Exporter interface {
+	Export(ctx context.Context, entries []Entry) error
+	Close() error
+}
+
+// SinkStats reports the delivery counters of one sink.
+type SinkStats struct {
+	Type      string `json:"type"`
+	Target    string `json:"target"`
+	Queued    int    `json:"queued"`
+	Sent      int64  `json:"sent"`
+	Dropped   int64  `json:"dropped"`
+	Failed    int64  `json:"failed"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Shipper fans function log entries out to its sinks. Every sink has its own
+// bounded queue and worker, so a slow or unreachable destination never blocks
+// function execution or the other sinks: when a queue is full new entries for
+// that sink are dropped and counted.
+type Shipper struct {
+	sinks  []*sink
+	logger logging.Logger
+	wg     sync.WaitGroup
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+type sink struct {
+	typ        string
+	target     string
+	exporter   Exporter
+	namespaces map[string]bool
+	timeout    time.Duration
+	queue      chan Entry
+	done       chan struct{}
+
+	sent    atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+
+	mu        sync.Mutex
+	lastError string
+}
+
+// New creates a shipper for the configured sinks and starts its workers.
+func New(cfg config.LogShippingConfig, logger logging.Logger) (*Shipper, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	exporters := make([]Exporter, 0, len(cfg.Sinks))
+	for _, sinkCfg := range cfg.Sinks {
+		exporter, err := NewExporter(sinkCfg)
+		if err != nil {
+			for _, created := range exporters {
+				created.Close()
+			}
+			return nil, err
+		}
+		exporters = append(exporters, exporter)
+	}
+
+	return newShipper(cfg, exporters, logger), nil
+}
+
+// newShipper wires already created exporters, one per configured sink.
+func newShipper(cfg config.LogShippingConfig, exporters []Exporter, logger logging.Logger) *Shipper {
+	bufferSize := cfg.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	flushInterval := cfg.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	s := &Shipper{logger: logger}
+	for i, exporter := range exporters {
+		sinkCfg := cfg.Sinks[i]
+		sk := &sink{
+			typ:      sinkCfg.Type,
+			target:   sinkTarget(sinkCfg),
+			exporter: exporter,
+			timeout:  sinkCfg.Timeout,
+			queue:    make(chan Entry, bufferSize),
+			done:     make(chan struct{}),
+		}
+		if sk.timeout <= 0 {
+			sk.timeout = defaultSendTimeout
+		}
+		if len(sinkCfg.Namespaces) > 0 {
+			sk.namespaces = make(map[string]bool, len(sinkCfg.Namespaces))
+			for _, ns := range sinkCfg.Namespaces {
+				sk.namespaces[ns] = true
+			}
+		}
+		s.sinks = append(s.sinks, sk)
+
+		s.wg.Add(1)
+		go s.run(sk, batchSize, flushInterval, cfg.MaxRetries)
+	}
+	return s
+}
+
+// NewExporter creates the exporter for a sink configuration.
+func NewExporter(cfg config.LogSinkConfig) (Exporter, error) {
+	switch cfg.Type {
+	case "loki":
+		return newLokiExporter(cfg), nil
+	case "otlp":
+		return newOTLPExporter(cfg), nil
+	case "syslog":
+		return newSyslogExporter(cfg)
+	default:
+		return nil, fmt.Errorf("unknown log sink type %q", cfg.Type)
+	}
+}
+
+func sinkTarget(cfg config.LogSinkConfig) string {
+	switch {
+	case cfg.URL != "":
+		return cfg.URL
+	case cfg.Address != "":
+		return cfg.Network + "://" + cfg.Address
+	default:
+		return "local"
+	}
+}
+
+// Forward queues an entry on every sink that accepts its namespace. It never
+// blocks; entries are dropped when a sink's queue is full.
+func (s *Shipper) Forward(functionKey interfaces.FunctionKey, entry logging.FunctionLogEntry) {
+	item := Entry{Function: functionKey, FunctionLogEntry: entry}
+	for _, sk := range s.sinks {
+		if sk.namespaces != nil && !sk.namespaces[functionKey.Namespace] {
+			continue
+		}
+		select {
+		case <-sk.done:
+			sk.dropped.Add(1)
+		default:
+			select {
+			case sk.queue <- item:
+			default:
+				sk.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// Stats returns the delivery counters of every sink.
+func (s *Shipper) Stats() []SinkStats {
+	stats := make([]SinkStats, 0, len(s.sinks))
+	for _, sk := range s.sinks {
+		sk.mu.Lock()
+		lastError := sk.lastError
+		sk.mu.Unlock()
+		stats = append(stats, SinkStats{
+			Type:      sk.typ,
+			Target:    sk.target,
+			Queued:    len(sk.queue),
+			Sent:      sk.sent.Load(),
+			Dropped:   sk.dropped.Load(),
+			Failed:    sk.failed.Load(),
+			LastError: lastError,
+		})
+	}
+	return stats
+}
+
+// Close stops accepting entries, sends what is still queued and closes the
+// exporters. It is safe to call more than once.
+func (s *Shipper) Close() error {
+	s.closeOnce.Do(func() {
+		for _, sk := range s.sinks {
+			close(sk.done)
+		}
+		s.wg.Wait()
+
+		var errs []error
+		for _, sk := range s.sinks {
+			if err := sk.exporter.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s sink %s: %w", sk.typ, sk.target, err))
+			}
+		}
+		s.closeErr = errors.Join(errs...)
+	})
+	return s.closeErr
+}
+
+func (s *Shipper) run(sk *sink, batchSize int, flushInterval time.Duration, maxRetries int) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Entry, 0, batchSize)
+	flush := func(retry bool) {
+		if len(batch) == 0 {
+			return
+		}
+		retries := maxRetries
+		if !retry {
+			retries = 0
+		}
+		s.send(sk, batch, retries)
+		batch = make([]Entry, 0, batchSize)
+	}
+
+	for {
+		select {
+		case entry := <-sk.queue:
+			batch = append(batch, entry)
+			if len(batch) >= batchSize {
+				flush(true)
+			}
+		case <-ticker.C:
+			flush(true)
+		case <-sk.done:
+			// Drain what was queued before shutdown without waiting on retries
+			for {
+				select {
+				case entry := <-sk.queue:
+					batch = append(batch, entry)
+					if len(batch) >= batchSize {
+						flush(false)
+					}
+				default:
+					flush(false)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Shipper) send(sk *sink, batch []Entry, retries int) {
+	backoff := initialRetryBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), sk.timeout)
+		err = sk.exporter.Export(ctx, batch)
+		cancel()
+		if err == nil {
+			sk.sent.Add(int64(len(batch)))
+			return
+		}
+		if attempt >= retries {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-sk.done:
+			retries = attempt
+		}
+	}
+
+	sk.failed.Add(int64(len(batch)))
+	sk.mu.Lock()
+	sk.lastError = err.Error()
+	sk.mu.Unlock()
+	if s.logger != nil {
+		s.logger.Errorf("Failed to ship %d log entries to %s sink %s: %v", len(batch), sk.typ, sk.target, err)
+	}
+}
@@ -0,0 +1,181 @@
+package logship
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+type recordingExporter struct {
+	mu      sync.Mutex
+	batches [][]Entry
+	block   chan struct{}
+	err     error
+}
+
+func (r *recordingExporter) Export(_ context.Context, entries []Entry) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.batches = append(r.batches, append([]Entry(nil), entries...))
+	return nil
+}
+
+func (r *recordingExporter) Close() error { return nil }
+
+func (r *recordingExporter) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	total := 0
+	for _, batch := range r.batches {
+		total += len(batch)
+	}
+	return total
+}
+
+func entry(msg string) logging.FunctionLogEntry {
+	return logging.FunctionLogEntry{Timestamp: time.Unix(1700000000, 0), Level: logging.LevelInfo, Message: msg}
+}
+
+func TestShipperBatchesAndFiltersNamespaces(t *testing.T) {
+	all := &recordingExporter{}
+	prod := &recordingExporter{}
+	cfg := config.LogShippingConfig{
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		Sinks:         []config.LogSinkConfig{{Type: "loki"}, {Type: "loki", Namespaces: []string{"prod"}}},
+	}
+	shipper := newShipper(cfg, []Exporter{all, prod}, nil)
+
+	shipper.Forward(interfaces.FunctionKey{Namespace: "dev", Name: "fn"}, entry("one"))
+	shipper.Forward(interfaces.FunctionKey{Namespace: "prod", Name: "fn"}, entry("two"))
+	shipper.Forward(interfaces.FunctionKey{Namespace: "prod", Name: "fn"}, entry("three"))
+
+	// The first sink sends a full batch without waiting for the flush interval
+	assert.Eventually(t, func() bool { return all.count() == 2 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, shipper.Close())
+	assert.Equal(t, 3, all.count())
+	assert.Equal(t, 2, prod.count())
+
+	stats := shipper.Stats()
+	assert.Equal(t, int64(3), stats[0].Sent)
+	assert.Equal(t, int64(2), stats[1].Sent)
+}
+
+func TestShipperDropsWhenQueueIsFull(t *testing.T) {
+	exporter := &recordingExporter{block: make(chan struct{})}
+	cfg := config.LogShippingConfig{
+		BufferSize:    2,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		Sinks:         []config.LogSinkConfig{{Type: "loki"}},
+	}
+	shipper := newShipper(cfg, []Exporter{exporter}, nil)
+	key := interfaces.FunctionKey{Namespace: "ns", Name: "fn"}
+
+	// The worker picks up the first entry and blocks on it, two more fill the queue
+	shipper.Forward(key, entry("in flight"))
+	assert.Eventually(t, func() bool { return len(shipper.sinks[0].queue) == 0 }, time.Second, 10*time.Millisecond)
+	for i := 0; i < 5; i++ {
+		shipper.Forward(key, entry("queued"))
+	}
+
+	stats := shipper.Stats()
+	assert.Equal(t, 2, stats[0].Queued)
+	assert.Equal(t, int64(3), stats[0].Dropped)
+
+	close(exporter.block)
+	require.NoError(t, shipper.Close())
+	assert.Equal(t, int64(3), shipper.Stats()[0].Sent)
+}
+
+func TestShipperCountsFailedBatches(t *testing.T) {
+	exporter := &recordingExporter{err: errors.New("unavailable")}
+	cfg := config.LogShippingConfig{
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		Sinks:         []config.LogSinkConfig{{Type: "otlp"}},
+	}
+	shipper := newShipper(cfg, []Exporter{exporter}, nil)
+	shipper.Forward(interfaces.FunctionKey{Namespace: "ns", Name: "fn"}, entry("lost"))
+	require.NoError(t, shipper.Close())
+
+	stats := shipper.Stats()
+	assert.Equal(t, int64(1), stats[0].Failed)
+	assert.Equal(t, "unavailable", stats[0].LastError)
+}
+
+func TestLokiExporter(t *testing.T) {
+	var got lokiRequest
+	var tenant string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = r.Header.Get("X-Scope-OrgID")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := NewExporter(config.LogSinkConfig{
+		Type:    "loki",
+		URL:     server.URL,
+		Headers: map[string]string{"X-Scope-OrgID": "team"},
+		Labels:  map[string]string{"env": "test"},
+	})
+	require.NoError(t, err)
+
+	key := interfaces.FunctionKey{Namespace: "ns", Name: "fn"}
+	require.NoError(t, exporter.Export(context.Background(), []Entry{
+		{Function: key, FunctionLogEntry: entry("hello")},
+		{Function: key, FunctionLogEntry: entry("world")},
+	}))
+
+	assert.Equal(t, "team", tenant)
+	require.Len(t, got.Streams, 1)
+	assert.Equal(t, map[string]string{"env": "test", "namespace": "ns", "function": "fn", "level": "info"}, got.Streams[0].Stream)
+	assert.Equal(t, [][2]string{{"1700000000000000000", "hello"}, {"1700000000000000000", "world"}}, got.Streams[0].Values)
+}
+
+func TestOTLPExporterReportsErrors(t *testing.T) {
+	var body map[string]any
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	exporter, err := NewExporter(config.LogSinkConfig{Type: "otlp", URL: server.URL})
+	require.NoError(t, err)
+
+	batch := []Entry{{Function: interfaces.FunctionKey{Namespace: "ns", Name: "fn"}, FunctionLogEntry: entry("hello")}}
+	require.NoError(t, exporter.Export(context.Background(), batch))
+
+	resourceLogs := body["resourceLogs"].([]any)
+	scopeLogs := resourceLogs[0].(map[string]any)["scopeLogs"].([]any)
+	records := scopeLogs[0].(map[string]any)["logRecords"].([]any)
+	require.Len(t, records, 1)
+	record := records[0].(map[string]any)
+	assert.Equal(t, float64(9), record["severityNumber"])
+	assert.Equal(t, "hello", record["body"].(map[string]any)["stringValue"])
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, exporter.Export(context.Background(), batch))
+}
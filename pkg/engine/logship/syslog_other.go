@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logship
+
+import (
+	"errors"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+)
+
+func newSyslogExporter(config.LogSinkConfig) (Exporter, error) {
+	return nil, errors.New("syslog log shipping is not supported on this platform")
+}
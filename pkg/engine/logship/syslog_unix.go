@@ -0,0 +1,53 @@
+//go:build !windows && !plan9
+
+package logship
+
+import (
+	"context"
+	"log/syslog"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+// syslogExporter writes entries to a local or remote syslog daemon.
+type syslogExporter struct {
+	writer *syslog.Writer
+}
+
+func newSyslogExporter(cfg config.LogSinkConfig) (Exporter, error) {
+	tag := cfg.Tag
+	if tag == "" {
+		tag = "ignition"
+	}
+	writer, err := syslog.Dial(cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogExporter{writer: writer}, nil
+}
+
+func (s *syslogExporter) Export(_ context.Context, entries []Entry) error {
+	for _, entry := range entries {
+		msg := entry.Function.String() + ": " + entry.Message
+		var err error
+		switch entry.Level {
+		case logging.LevelDebug:
+			err = s.writer.Debug(msg)
+		case logging.LevelWarning:
+			err = s.writer.Warning(msg)
+		case logging.LevelError:
+			err = s.writer.Err(msg)
+		default:
+			err = s.writer.Info(msg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syslogExporter) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// ErrMaintenanceNotSupported is returned when the registry cannot run maintenance.
+var ErrMaintenanceNotSupported = errors.New("registry does not support maintenance")
+
+// ErrIntegrityCheckNotSupported is returned when the registry cannot verify stored modules.
+var ErrIntegrityCheckNotSupported = errors.New("registry does not support integrity checks")
+
+// startRegistryMaintenance runs registry maintenance on the configured interval until ctx is done.
+func (e *Engine) startRegistryMaintenance(ctx context.Context) {
+	interval := e.options.MaintenanceInterval
+	if interval <= 0 {
+		return
+	}
+	if _, ok := e.registry.(registry.Maintainer); !ok {
+		return
+	}
+
+	go func() {
+		ticker := e.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				// Errors are recorded in the report and logged by RunMaintenance
+				_, _ = e.RunMaintenance()
+			}
+		}
+	}()
+}
+
+// RunMaintenance runs registry GC and consistency checks now and records the result.
+func (e *Engine) RunMaintenance() (*registry.MaintenanceReport, error) {
+	maintainer, ok := e.registry.(registry.Maintainer)
+	if !ok {
+		return nil, ErrMaintenanceNotSupported
+	}
+
+	report, err := maintainer.Maintain()
+	if report != nil {
+		e.maintenanceMu.Lock()
+		e.lastMaintenance = report
+		e.maintenanceMu.Unlock()
+	}
+
+	if err != nil {
+		e.logger.Errorf("Registry maintenance failed: %v", err)
+		return report, err
+	}
+
+	e.logger.Printf("Registry maintenance completed in %s: %d value log files rewritten, %d orphan files, %d missing files",
+		report.Duration, report.GCRewrites, len(report.OrphanFiles), len(report.MissingFiles))
+	for _, path := range report.OrphanFiles {
+		e.logger.Printf("Registry maintenance: orphan WASM file %s", path)
+	}
+	for _, path := range report.MissingFiles {
+		e.logger.Errorf("Registry maintenance: missing WASM file %s", path)
+	}
+
+	return report, nil
+}
+
+// startIntegrityCheck verifies stored modules in the background when the check is enabled.
+func (e *Engine) startIntegrityCheck() {
+	if e.options.IntegrityCheck.Mode == "off" {
+		return
+	}
+	if _, ok := e.registry.(registry.IntegrityChecker); !ok {
+		return
+	}
+
+	// Errors are recorded in the report and logged by CheckIntegrity
+	go func() { _, _ = e.CheckIntegrity() }()
+}
+
+// CheckIntegrity verifies stored modules against their metadata now, as configured
+// for startup, and records the result. Full checks are run when the check is off.
+func (e *Engine) CheckIntegrity() (*registry.IntegrityReport, error) {
+	checker, ok := e.registry.(registry.IntegrityChecker)
+	if !ok {
+		return nil, ErrIntegrityCheckNotSupported
+	}
+
+	sample := 0
+	if e.options.IntegrityCheck.Mode == "sample" {
+		sample = e.options.IntegrityCheck.SampleSize
+	}
+	report, err := checker.CheckIntegrity(sample)
+	if report != nil {
+		e.maintenanceMu.Lock()
+		e.lastIntegrity = report
+		e.maintenanceMu.Unlock()
+	}
+
+	if err != nil {
+		e.logger.Errorf("Registry integrity check failed: %v", err)
+		return report, err
+	}
+
+	e.logger.Printf("Registry integrity check (%s) completed in %s: %d versions, %d verified, %d missing, %d corrupt, %d repaired",
+		report.Mode, report.Duration, report.Versions, report.Verified, len(report.Missing), len(report.Corrupt), len(report.Repaired))
+	for _, version := range report.Missing {
+		e.logger.Errorf("Registry integrity check: missing WASM file of %s", version)
+	}
+	for _, version := range report.Corrupt {
+		e.logger.Errorf("Registry integrity check: corrupt WASM file of %s", version)
+	}
+	for _, version := range report.Repaired {
+		e.logger.Printf("Registry integrity check: repaired %s from the upstream registry", version)
+	}
+
+	return report, nil
+}
+
+// migrateRegistry brings the registry schema up to date, logging each applied migration.
+func migrateRegistry(reg registry.Registry, logger logging.Logger) error {
+	migrator, ok := reg.(registry.Migrator)
+	if !ok {
+		return nil
+	}
+
+	report, err := migrator.Migrate(false)
+	if err != nil {
+		return fmt.Errorf("failed to migrate registry: %w", err)
+	}
+
+	if report.FromVersion != report.ToVersion {
+		logger.Printf("Migrated registry schema from version %d to %d", report.FromVersion, report.ToVersion)
+		for _, step := range report.Steps {
+			logger.Printf("Registry migration %d (%s): %d records updated", step.Version, step.Description, step.Records)
+		}
+		if report.BackupPath != "" {
+			logger.Printf("Registry backup written to %s", report.BackupPath)
+		}
+	}
+
+	return nil
+}
+
+// LastMaintenance returns the most recent maintenance report, if any.
+func (e *Engine) LastMaintenance() *registry.MaintenanceReport {
+	e.maintenanceMu.RLock()
+	defer e.maintenanceMu.RUnlock()
+	return e.lastMaintenance
+}
+
+// LastIntegrityCheck returns the most recent integrity check report, if any.
+func (e *Engine) LastIntegrityCheck() *registry.IntegrityReport {
+	e.maintenanceMu.RLock()
+	defer e.maintenanceMu.RUnlock()
+	return e.lastIntegrity
+}
@@ -0,0 +1,189 @@
+// Package metrics provides the built-in collectors of call metrics: one kept in memory
+// and reported in the engine status, and one that also serves the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+)
+
+// Collectors that can be selected in configuration
+const (
+	KindNone       = "none"
+	KindMemory     = "memory"
+	KindPrometheus = "prometheus"
+)
+
+// New creates the collector of the given kind. An empty kind selects the in-memory collector.
+func New(kind string) (interfaces.MetricsCollector, error) {
+	switch kind {
+	case "", KindMemory:
+		return NewMemory(), nil
+	case KindPrometheus:
+		return NewPrometheus(nil), nil
+	case KindNone:
+		return Nop{}, nil
+	default:
+		return nil, fmt.Errorf("unknown metrics collector %q", kind)
+	}
+}
+
+// Reporter is a collector whose metrics can be read back.
+type Reporter interface {
+	interfaces.MetricsCollector
+
+	// Snapshot returns a copy of the metrics recorded so far.
+	Snapshot() Snapshot
+}
+
+// FailureRecorder is a collector that also counts failed calls by error code, such as
+// execution_trap or execution_timeout.
+type FailureRecorder interface {
+	RecordFailure(key interfaces.FunctionKey, code string)
+}
+
+// QueueRecorder is a collector that also records calls waiting for an instance of a
+// saturated pool: how many are waiting, and how long each waited before it got an
+// instance or was turned away.
+type QueueRecorder interface {
+	RecordQueueDepth(key interfaces.FunctionKey, depth int)
+	RecordQueueWait(key interfaces.FunctionKey, seconds float64, admitted bool)
+}
+
+// FunctionStats are the metrics recorded for one function.
+type FunctionStats struct {
+	Calls    int64 `json:"calls"`
+	Failures int64 `json:"failures"`
+
+	// Failed calls by error code
+	FailuresByCode map[string]int64 `json:"failures_by_code,omitempty"`
+
+	TotalSeconds float64   `json:"total_seconds"`
+	MaxSeconds   float64   `json:"max_seconds"`
+	MemoryBytes  int64     `json:"memory_bytes"`
+	LastCall     time.Time `json:"last_call,omitempty"`
+
+	// Calls waiting for an instance, and how long calls waited for one
+	QueueDepth          int     `json:"queue_depth"`
+	QueueWaits          int64   `json:"queue_waits"`
+	QueueWaitSeconds    float64 `json:"queue_wait_seconds"`
+	MaxQueueWaitSeconds float64 `json:"max_queue_wait_seconds"`
+
+	// Calls turned away after waiting longer than the max queue wait
+	QueueTimeouts int64 `json:"queue_timeouts"`
+}
+
+// Snapshot is a copy of the metrics recorded by a collector.
+type Snapshot struct {
+	Functions    map[interfaces.FunctionKey]FunctionStats `json:"functions"`
+	InFlight     int                                      `json:"in_flight"`
+	PeakInFlight int                                      `json:"peak_in_flight"`
+}
+
+// Memory keeps call metrics in memory since the engine started.
+type Memory struct {
+	mu        sync.Mutex
+	functions map[interfaces.FunctionKey]*FunctionStats
+	inFlight  int
+	peak      int
+}
+
+// NewMemory creates an in-memory collector.
+func NewMemory() *Memory {
+	return &Memory{functions: make(map[interfaces.FunctionKey]*FunctionStats)}
+}
+
+// function returns the stats of a function, creating them on first use. The lock must be held.
+func (m *Memory) function(key interfaces.FunctionKey) *FunctionStats {
+	stats, ok := m.functions[key]
+	if !ok {
+		stats = &FunctionStats{}
+		m.functions[key] = stats
+	}
+	return stats
+}
+
+func (m *Memory) RecordExecution(key interfaces.FunctionKey, duration float64, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.function(key)
+	stats.Calls++
+	if !success {
+		stats.Failures++
+	}
+	stats.TotalSeconds += duration
+	stats.MaxSeconds = max(stats.MaxSeconds, duration)
+	stats.LastCall = time.Now().UTC()
+}
+
+func (m *Memory) RecordFailure(key interfaces.FunctionKey, code string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.function(key)
+	if stats.FailuresByCode == nil {
+		stats.FailuresByCode = make(map[string]int64)
+	}
+	stats.FailuresByCode[code]++
+}
+
+func (m *Memory) RecordQueueDepth(key interfaces.FunctionKey, depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.function(key).QueueDepth = depth
+}
+
+func (m *Memory) RecordQueueWait(key interfaces.FunctionKey, seconds float64, admitted bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.function(key)
+	stats.QueueWaits++
+	stats.QueueWaitSeconds += seconds
+	stats.MaxQueueWaitSeconds = max(stats.MaxQueueWaitSeconds, seconds)
+	if !admitted {
+		stats.QueueTimeouts++
+	}
+}
+
+func (m *Memory) RecordMemoryUsage(key interfaces.FunctionKey, bytesUsed int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.function(key).MemoryBytes = bytesUsed
+}
+
+func (m *Memory) RecordConcurrency(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight = count
+	m.peak = max(m.peak, count)
+}
+
+func (m *Memory) Snapshot() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := Snapshot{
+		Functions:    make(map[interfaces.FunctionKey]FunctionStats, len(m.functions)),
+		InFlight:     m.inFlight,
+		PeakInFlight: m.peak,
+	}
+	for key, stats := range m.functions {
+		copied := *stats
+		copied.FailuresByCode = maps.Clone(stats.FailuresByCode)
+		snapshot.Functions[key] = copied
+	}
+	return snapshot
+}
+
+// Nop discards every metric.
+type Nop struct{}
+
+func (Nop) RecordExecution(interfaces.FunctionKey, float64, bool) {}
+func (Nop) RecordMemoryUsage(interfaces.FunctionKey, int64)       {}
+func (Nop) RecordConcurrency(int)                                 {}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	key := interfaces.NewFunctionKey("ns", "fn")
+	collector := NewMemory()
+	collector.RecordConcurrency(2)
+	collector.RecordConcurrency(1)
+	collector.RecordExecution(key, 0.5, true)
+	collector.RecordExecution(key, 1.5, false)
+	collector.RecordFailure(key, "execution_trap")
+	collector.RecordMemoryUsage(key, 65536)
+
+	snapshot := collector.Snapshot()
+	assert.Equal(t, 1, snapshot.InFlight)
+	assert.Equal(t, 2, snapshot.PeakInFlight)
+
+	stats := snapshot.Functions[key]
+	assert.Equal(t, int64(2), stats.Calls)
+	assert.Equal(t, int64(1), stats.Failures)
+	assert.Equal(t, 2.0, stats.TotalSeconds)
+	assert.Equal(t, 1.5, stats.MaxSeconds)
+	assert.Equal(t, int64(65536), stats.MemoryBytes)
+	assert.Equal(t, map[string]int64{"execution_trap": 1}, stats.FailuresByCode)
+	assert.False(t, stats.LastCall.IsZero())
+}
+
+func TestPrometheus(t *testing.T) {
+	key := interfaces.NewFunctionKey("ns", `say"hi`)
+	collector := NewPrometheus([]float64{0.1, 1})
+	collector.RecordExecution(key, 0.05, true)
+	collector.RecordExecution(key, 0.5, true)
+	collector.RecordExecution(key, 5, false)
+	collector.RecordFailure(key, "execution_timeout")
+	collector.RecordQueueDepth(key, 3)
+	collector.RecordQueueWait(key, 0, true)
+	collector.RecordQueueWait(key, 2, false)
+
+	recorder := httptest.NewRecorder()
+	collector.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "version=0.0.4")
+	for _, line := range []string{
+		`ignition_function_calls_total{namespace="ns",function="say\"hi",outcome="success"} 2`,
+		`ignition_function_calls_total{namespace="ns",function="say\"hi",outcome="failure"} 1`,
+		`ignition_function_failures_total{namespace="ns",function="say\"hi",code="execution_timeout"} 1`,
+		`ignition_function_call_duration_seconds_bucket{namespace="ns",function="say\"hi",le="0.1"} 1`,
+		`ignition_function_call_duration_seconds_bucket{namespace="ns",function="say\"hi",le="1"} 2`,
+		`ignition_function_call_duration_seconds_bucket{namespace="ns",function="say\"hi",le="+Inf"} 3`,
+		`ignition_function_call_duration_seconds_sum{namespace="ns",function="say\"hi"} 5.55`,
+		`ignition_function_queue_depth{namespace="ns",function="say\"hi"} 3`,
+		`ignition_function_queue_wait_seconds_bucket{namespace="ns",function="say\"hi",le="0.1"} 1`,
+		`ignition_function_queue_wait_seconds_count{namespace="ns",function="say\"hi"} 2`,
+		`ignition_function_queue_timeouts_total{namespace="ns",function="say\"hi"} 1`,
+		`ignition_calls_in_flight 0`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+
+	// Functions left out by the filter have no series
+	other := interfaces.NewFunctionKey("other", "fn")
+	collector.RecordExecution(other, 0.05, true)
+	recorder = httptest.NewRecorder()
+	collector.Write(recorder, func(key interfaces.FunctionKey) bool { return key.Namespace == "other" })
+	assert.Contains(t, recorder.Body.String(), `namespace="other"`)
+	assert.NotContains(t, recorder.Body.String(), `namespace="ns"`)
+}
+
+func TestNew(t *testing.T) {
+	for kind, expected := range map[string]interfaces.MetricsCollector{
+		"":         &Memory{},
+		KindMemory: &Memory{},
+		KindNone:   Nop{},
+	} {
+		collector, err := New(kind)
+		require.NoError(t, err)
+		assert.IsType(t, expected, collector, kind)
+	}
+
+	collector, err := New(KindPrometheus)
+	require.NoError(t, err)
+	assert.IsType(t, &Prometheus{}, collector)
+
+	_, err = New("statsd")
+	assert.ErrorContains(t, err, "unknown metrics collector")
+}
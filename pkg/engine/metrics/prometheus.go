@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the call duration histogram
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Prometheus keeps call metrics in memory along with histograms of call durations and
+// queue waits, and serves them in the Prometheus text exposition format.
+type Prometheus struct {
+	*Memory
+
+	buckets    []float64
+	mu         sync.Mutex
+	histograms map[interfaces.FunctionKey][]uint64
+	queueWaits map[interfaces.FunctionKey][]uint64
+}
+
+// NewPrometheus creates a Prometheus collector with the given histogram buckets,
+// sorted in increasing order. Nil buckets use DefaultBuckets.
+func NewPrometheus(buckets []float64) *Prometheus {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return &Prometheus{
+		Memory:     NewMemory(),
+		buckets:    buckets,
+		histograms: make(map[interfaces.FunctionKey][]uint64),
+		queueWaits: make(map[interfaces.FunctionKey][]uint64),
+	}
+}
+
+func (p *Prometheus) RecordExecution(key interfaces.FunctionKey, duration float64, success bool) {
+	p.Memory.RecordExecution(key, duration, success)
+	p.observe(p.histograms, key, duration)
+}
+
+func (p *Prometheus) RecordQueueWait(key interfaces.FunctionKey, seconds float64, admitted bool) {
+	p.Memory.RecordQueueWait(key, seconds, admitted)
+	p.observe(p.queueWaits, key, seconds)
+}
+
+// observe counts value in the buckets of a function's histogram.
+func (p *Prometheus) observe(histograms map[interfaces.FunctionKey][]uint64, key interfaces.FunctionKey, value float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	counts, ok := histograms[key]
+	if !ok {
+		counts = make([]uint64, len(p.buckets))
+		histograms[key] = counts
+	}
+	for i, bound := range p.buckets {
+		if value <= bound {
+			counts[i]++
+		}
+	}
+}
+
+// copyHistograms copies histograms. The lock must be held.
+func copyHistograms(histograms map[interfaces.FunctionKey][]uint64) map[interfaces.FunctionKey][]uint64 {
+	copied := make(map[interfaces.FunctionKey][]uint64, len(histograms))
+	for key, counts := range histograms {
+		copied[key] = append([]uint64(nil), counts...)
+	}
+	return copied
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	p.Write(w, nil)
+}
+
+// Write writes the metrics in the Prometheus text format, leaving out the series of
+// functions include rejects. A nil include writes every function.
+func (p *Prometheus) Write(w http.ResponseWriter, include func(interfaces.FunctionKey) bool) {
+	snapshot := p.Snapshot()
+	p.mu.Lock()
+	histograms := copyHistograms(p.histograms)
+	queueWaits := copyHistograms(p.queueWaits)
+	p.mu.Unlock()
+
+	keys := make([]interfaces.FunctionKey, 0, len(snapshot.Functions))
+	for key := range snapshot.Functions {
+		if include == nil || include(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+
+	header(out, "ignition_function_calls_total", "counter", "Calls of each function by outcome.")
+	for _, key := range keys {
+		stats := snapshot.Functions[key]
+		fmt.Fprintf(out, "ignition_function_calls_total{%s,outcome=\"success\"} %d\n", labels(key), stats.Calls-stats.Failures)
+		fmt.Fprintf(out, "ignition_function_calls_total{%s,outcome=\"failure\"} %d\n", labels(key), stats.Failures)
+	}
+
+	header(out, "ignition_function_failures_total", "counter", "Failed calls of each function by error code.")
+	for _, key := range keys {
+		failures := snapshot.Functions[key].FailuresByCode
+		codes := make([]string, 0, len(failures))
+		for code := range failures {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Fprintf(out, "ignition_function_failures_total{%s,code=\"%s\"} %d\n", labels(key), escapeLabel(code), failures[code])
+		}
+	}
+
+	header(out, "ignition_function_call_duration_seconds", "histogram", "Duration of function calls.")
+	for _, key := range keys {
+		stats := snapshot.Functions[key]
+		p.writeHistogram(out, "ignition_function_call_duration_seconds", key, histograms[key], stats.Calls, stats.TotalSeconds)
+	}
+
+	header(out, "ignition_function_queue_depth", "gauge", "Calls of each function waiting for a free instance.")
+	for _, key := range keys {
+		fmt.Fprintf(out, "ignition_function_queue_depth{%s} %d\n", labels(key), snapshot.Functions[key].QueueDepth)
+	}
+
+	header(out, "ignition_function_queue_wait_seconds", "histogram", "Time calls waited for a free instance.")
+	for _, key := range keys {
+		stats := snapshot.Functions[key]
+		p.writeHistogram(out, "ignition_function_queue_wait_seconds", key, queueWaits[key], stats.QueueWaits, stats.QueueWaitSeconds)
+	}
+
+	header(out, "ignition_function_queue_timeouts_total", "counter", "Calls turned away after waiting longer than the max queue wait.")
+	for _, key := range keys {
+		fmt.Fprintf(out, "ignition_function_queue_timeouts_total{%s} %d\n", labels(key), snapshot.Functions[key].QueueTimeouts)
+	}
+
+	header(out, "ignition_function_memory_bytes", "gauge", "Linear memory of the most recently used instance of each function.")
+	for _, key := range keys {
+		fmt.Fprintf(out, "ignition_function_memory_bytes{%s} %d\n", labels(key), snapshot.Functions[key].MemoryBytes)
+	}
+
+	header(out, "ignition_calls_in_flight", "gauge", "Function calls in progress.")
+	fmt.Fprintf(out, "ignition_calls_in_flight %d\n", snapshot.InFlight)
+}
+
+// writeHistogram writes the buckets, sum and count of a function's histogram.
+func (p *Prometheus) writeHistogram(out *bufio.Writer, name string, key interfaces.FunctionKey, counts []uint64,
+	total int64, sum float64) {
+	for i, bound := range p.buckets {
+		var count uint64
+		if counts != nil {
+			count = counts[i]
+		}
+		fmt.Fprintf(out, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels(key), formatFloat(bound), count)
+	}
+	fmt.Fprintf(out, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels(key), total)
+	fmt.Fprintf(out, "%s_sum{%s} %s\n", name, labels(key), formatFloat(sum))
+	fmt.Fprintf(out, "%s_count{%s} %d\n", name, labels(key), total)
+}
+
+func header(out *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// labels renders the labels identifying a function.
+func labels(key interfaces.FunctionKey) string {
+	return fmt.Sprintf("namespace=\"%s\",function=\"%s\"", escapeLabel(key.Namespace), escapeLabel(key.Name))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
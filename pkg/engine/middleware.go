@@ -3,17 +3,25 @@ package engine
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"time"
 
+	"github.com/ignitionstack/ignition/pkg/engine/audit"
 	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/ignitionstack/ignition/pkg/types"
 )
 
 type HandlerFunc func(http.ResponseWriter, *http.Request) error
 
 type Middleware func(HandlerFunc) HandlerFunc
 
+// withMiddleware wraps a handler in the middlewares, the first innermost. Every handler
+// is wrapped in the recovery middleware first, so panics reach the other middlewares as errors.
 func (h *Handlers) withMiddleware(handler HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
+	handler = h.recoveryMiddleware()(handler)
 	for _, middleware := range middlewares {
 		handler = middleware(handler)
 	}
@@ -50,6 +58,14 @@ func (h *Handlers) errorMiddleware() Middleware {
 				case errors.As(err, &reqErr):
 					// Already a RequestError, use as is
 
+				case admissionError(err) != nil:
+					// Refused by an admission policy
+					reqErr = *admissionError(err)
+
+				case quotaError(err) != nil:
+					// Namespace over its usage quota
+					reqErr = *quotaError(err)
+
 				case isDomainError(err):
 					// Convert domain error to request error with appropriate status code
 					var domainErr *domainerrors.DomainError
@@ -67,10 +83,6 @@ func (h *Handlers) errorMiddleware() Middleware {
 				// Log the error with context about the request
 				h.logger.Errorf("Handler error (%s %s): %v", r.Method, r.URL.Path, err)
 
-				// Send the error response to the client
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(reqErr.StatusCode)
-
 				// Build response with error details
 				response := map[string]interface{}{
 					"error":  reqErr.Message,
@@ -82,8 +94,18 @@ func (h *Handlers) errorMiddleware() Middleware {
 				if errors.As(err, &de) {
 					response["domain"] = string(de.ErrDomain)
 					response["code"] = string(de.ErrCode)
+
+					// Calls turned away under load tell clients when to try again
+					if seconds, ok := de.Details[RetryAfterDetail].(int); ok {
+						w.Header().Set("Retry-After", strconv.Itoa(seconds))
+						response["retry_after"] = seconds
+					}
 				}
 
+				// Send the error response to the client
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(reqErr.StatusCode)
+
 				if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
 					h.logger.Errorf("Failed to encode error response: %v", encodeErr)
 				}
@@ -110,12 +132,68 @@ func (h *Handlers) loggingMiddleware() Middleware {
 	}
 }
 
+// auditMiddleware records the operation, its source and parameters in the audit log.
+// It must wrap the handler directly so the handler's error is visible.
+func (h *Handlers) auditMiddleware(operation string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			event := audit.Event{
+				Timestamp: time.Now(),
+				Operation: operation,
+				Source:    auditSource(r),
+				Params:    auditParams(r),
+			}
+
+			err := next(w, r)
+
+			event.Success = err == nil
+			if err != nil {
+				event.Error = err.Error()
+			}
+			h.engine.RecordAudit(event)
+
+			return err
+		}
+	}
+}
+
+// privilegedUIDMiddleware rejects Unix socket callers whose uid is not in the engine's
+// privileged uids. Callers on the TCP admin listener have already presented the admin
+// token. When the uid of a socket caller cannot be read, or the handler is served without
+// the peer of its connections, the request is rejected.
+func (h *Handlers) privilegedUIDMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			allowed := h.engine.options.PrivilegedUIDs
+			if len(allowed) == 0 {
+				return next(w, r)
+			}
+			peer, ok := requestPeer(r)
+			if !ok {
+				return NewRequestError("Operation requires a privileged uid, and the caller is unknown", http.StatusForbidden)
+			}
+			if !peer.unix {
+				return next(w, r)
+			}
+
+			if !peer.hasCredentials {
+				return NewRequestError("Operation requires a privileged uid, and the caller's uid is unknown", http.StatusForbidden)
+			}
+			if !slices.Contains(allowed, peer.uid) {
+				return NewRequestError(fmt.Sprintf("Operation not permitted for uid %d", peer.uid), http.StatusForbidden)
+			}
+			return next(w, r)
+		}
+	}
+}
+
 func (h *Handlers) corsMiddleware() Middleware {
 	return func(next HandlerFunc) HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) error {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+types.TimeoutHeader+", "+types.PriorityHeader+", "+types.StreamHeader+", "+types.RequestIDHeader)
+			w.Header().Set("Access-Control-Expose-Headers", types.ExecutionTimeHeader+", "+types.StreamErrorTrailer+", "+types.RequestIDHeader)
 
 			if r.Method == http.MethodOptions {
 				w.WriteHeader(http.StatusOK)
@@ -140,3 +218,8 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap lets http.ResponseController reach the connection, to flush streamed responses.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"net/http"
+	"slices"
+)
+
+// Names of the built-in middlewares, used to find, reorder or drop them in a MiddlewareChain.
+const (
+	MiddlewareMethod      = "method"
+	MiddlewareLogging     = "logging"
+	MiddlewareErrors      = "errors"
+	MiddlewareCORS        = "cors"
+	MiddlewareCompression = "compression"
+	MiddlewareAudit       = "audit"
+	MiddlewarePrivileged  = "privileged"
+	MiddlewareIdempotency = "idempotency"
+)
+
+// APIs an endpoint can belong to.
+const (
+	APIAdmin = "admin" // the Unix socket and the TCP admin listener
+	APIHTTP  = "http"  // function calls and health checks, on every HTTP listener
+
+	APIMetrics = "metrics" // the Prometheus metrics on server.metrics_addr
+)
+
+// Endpoint identifies the route a middleware chain is built for.
+type Endpoint struct {
+	API  string
+	Path string // the route pattern, such as "/load", "/logs/" or "/"
+}
+
+// NamedMiddleware is a middleware with the name chains refer to it by.
+type NamedMiddleware struct {
+	Name       string
+	Middleware Middleware
+}
+
+// MiddlewareChain is an ordered list of middlewares, the first innermost. Every chain
+// is wrapped around the recovery middleware, which always runs closest to the handler.
+type MiddlewareChain []NamedMiddleware
+
+// ChainFunc returns the middleware chain of an endpoint given its default chain. It can
+// reorder or drop built-in middlewares and add its own.
+type ChainFunc func(endpoint Endpoint, chain MiddlewareChain) MiddlewareChain
+
+// Names returns the names of the middlewares, innermost first.
+func (c MiddlewareChain) Names() []string {
+	names := make([]string, len(c))
+	for i, m := range c {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// Has reports whether the chain contains a middleware with the name.
+func (c MiddlewareChain) Has(name string) bool {
+	return c.index(name) >= 0
+}
+
+// Without returns the chain without the middlewares with these names.
+func (c MiddlewareChain) Without(names ...string) MiddlewareChain {
+	var chain MiddlewareChain
+	for _, m := range c {
+		if !slices.Contains(names, m.Name) {
+			chain = append(chain, m)
+		}
+	}
+	return chain
+}
+
+// Outermost returns the chain with m added so it runs first.
+func (c MiddlewareChain) Outermost(m NamedMiddleware) MiddlewareChain {
+	return append(slices.Clip(c), m)
+}
+
+// Innermost returns the chain with m added so it runs last, right before the handler.
+func (c MiddlewareChain) Innermost(m NamedMiddleware) MiddlewareChain {
+	return append(MiddlewareChain{m}, c...)
+}
+
+// Inside returns the chain with m added so it runs right after the named middleware.
+// Add a middleware inside MiddlewareErrors for its errors to be rendered as JSON
+// responses. Without the named middleware, m is added outermost.
+func (c MiddlewareChain) Inside(name string, m NamedMiddleware) MiddlewareChain {
+	i := c.index(name)
+	if i < 0 {
+		return c.Outermost(m)
+	}
+	return slices.Insert(slices.Clone(c), i, m)
+}
+
+// Outside returns the chain with m added so it runs right before the named middleware.
+// Without the named middleware, m is added outermost.
+func (c MiddlewareChain) Outside(name string, m NamedMiddleware) MiddlewareChain {
+	i := c.index(name)
+	if i < 0 {
+		return c.Outermost(m)
+	}
+	return slices.Insert(slices.Clone(c), i+1, m)
+}
+
+func (c MiddlewareChain) index(name string) int {
+	return slices.IndexFunc(c, func(m NamedMiddleware) bool { return m.Name == name })
+}
+
+func (c MiddlewareChain) middlewares() []Middleware {
+	middlewares := make([]Middleware, len(c))
+	for i, m := range c {
+		middlewares[i] = m.Middleware
+	}
+	return middlewares
+}
+
+// handle registers a handler with its default middleware chain, as changed by the
+// engine's chain functions.
+func (h *Handlers) handle(mux *http.ServeMux, api, path string, handler HandlerFunc, chain MiddlewareChain) {
+	endpoint := Endpoint{API: api, Path: path}
+	if h.engine.options != nil {
+		for _, fn := range h.engine.options.MiddlewareChains {
+			chain = fn(endpoint, chain)
+		}
+	}
+	mux.HandleFunc(path, h.withMiddleware(handler, chain.middlewares()...))
+}
+
+// UseMiddleware returns a chain function that adds m outermost on every endpoint of the API.
+func UseMiddleware(api string, m NamedMiddleware) ChainFunc {
+	return func(endpoint Endpoint, chain MiddlewareChain) MiddlewareChain {
+		if endpoint.API != api {
+			return chain
+		}
+		return chain.Outermost(m)
+	}
+}
+
+// DisableMiddleware returns a chain function that drops the named middlewares from one endpoint.
+func DisableMiddleware(endpoint Endpoint, names ...string) ChainFunc {
+	return func(e Endpoint, chain MiddlewareChain) MiddlewareChain {
+		if e != endpoint {
+			return chain
+		}
+		return chain.Without(names...)
+	}
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareChainEditing(t *testing.T) {
+	named := func(name string) NamedMiddleware {
+		return NamedMiddleware{Name: name}
+	}
+	chain := MiddlewareChain{named("a"), named("b"), named("c")}
+
+	assert.Equal(t, []string{"a", "c"}, chain.Without("b").Names())
+	assert.Equal(t, []string{"x", "a", "b", "c"}, chain.Innermost(named("x")).Names())
+	assert.Equal(t, []string{"a", "b", "c", "x"}, chain.Outermost(named("x")).Names())
+	assert.Equal(t, []string{"a", "x", "b", "c"}, chain.Inside("b", named("x")).Names())
+	assert.Equal(t, []string{"a", "b", "x", "c"}, chain.Outside("b", named("x")).Names())
+	assert.Equal(t, []string{"a", "b", "c", "x"}, chain.Inside("missing", named("x")).Names())
+
+	// Editing never changes the chain it was derived from
+	assert.Equal(t, []string{"a", "b", "c"}, chain.Names())
+	assert.True(t, chain.Has("c"))
+}
+
+func TestMiddlewareChainOptions(t *testing.T) {
+	chains := map[Endpoint][]string{}
+	record := func(endpoint Endpoint, chain MiddlewareChain) MiddlewareChain {
+		chains[endpoint] = chain.Names()
+		return chain
+	}
+
+	requireTenant := NamedMiddleware{Name: "tenant", Middleware: func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			if r.Header.Get("X-Tenant") == "" {
+				return NewRequestError("Missing tenant", http.StatusUnauthorized)
+			}
+			return next(w, r)
+		}
+	}}
+
+	options := DefaultEngineOptions().
+		WithCORS(false).
+		WithMiddlewareChain(func(endpoint Endpoint, chain MiddlewareChain) MiddlewareChain {
+			if endpoint.API != APIHTTP {
+				return chain
+			}
+			return chain.Inside(MiddlewareErrors, requireTenant)
+		}).
+		WithMiddlewareChain(DisableMiddleware(Endpoint{API: APIAdmin, Path: "/list"}, MiddlewareLogging)).
+		WithMiddlewareChain(record)
+	h := &Handlers{engine: &Engine{options: options}, logger: logging.NewStdLogger(io.Discard)}
+
+	handler := h.HTTPHandler()
+	h.UnixSocketHandler()
+
+	assert.Equal(t, []string{MiddlewareLogging, MiddlewareCompression, "tenant", MiddlewareErrors, MiddlewareMethod},
+		chains[Endpoint{API: APIHTTP, Path: "/"}])
+	assert.Equal(t, []string{MiddlewareMethod, "tenant", MiddlewareErrors}, chains[Endpoint{API: APIHTTP, Path: "/healthz"}])
+	assert.Equal(t, []string{MiddlewareMethod, MiddlewareErrors}, chains[Endpoint{API: APIAdmin, Path: "/list"}])
+	assert.Equal(t, []string{MiddlewarePrivileged, MiddlewareAudit, MiddlewareMethod, MiddlewareLogging, MiddlewareErrors},
+		chains[Endpoint{API: APIAdmin, Path: "/stop"}])
+
+	// The added middleware's error is rendered by the errors middleware
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/ns/fn/run", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "Missing tenant", body["error"])
+}
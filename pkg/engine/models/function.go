@@ -30,6 +30,7 @@ type BuildResult struct {
 	BuildTime string `json:"build_time,omitempty"`
 	Tag       string `json:"tag,omitempty"`
 	Reused    bool   `json:"reused,omitempty"`
+	BuildID   string `json:"build_id,omitempty"`
 }
 
 // LoadResult contains information about a successful load operation
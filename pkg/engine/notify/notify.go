@@ -0,0 +1,230 @@
+// Package notify sends webhook notifications when the circuit breaker of a
+// function opens or closes.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+// Event types.
+const (
+	EventCircuitOpen   = "circuit_open"
+	EventCircuitClosed = "circuit_closed"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// Event describes a circuit breaker state change.
+type Event struct {
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Function  string `json:"function"`
+
+	// Entrypoint whose circuit changed, when circuit breakers guard each entrypoint
+	Entrypoint string `json:"entrypoint,omitempty"`
+
+	State    string    `json:"state"`
+	Previous string    `json:"previous_state"`
+	Failures int       `json:"failure_count"`
+	Errors   []string  `json:"recent_errors,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Notifier posts events to the configured webhooks. Requests are sent in the
+// background so a slow webhook never delays function calls.
+type Notifier struct {
+	webhooks     []config.WebhookConfig
+	errorSamples int
+	client       *http.Client
+	logger       logging.Logger
+
+	mu     sync.Mutex
+	errors map[interfaces.FunctionKey][]string
+
+	wg sync.WaitGroup
+}
+
+// New creates a notifier for the configured webhooks.
+func New(cfg config.NotificationsConfig, logger logging.Logger) (*Notifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	errorSamples := cfg.ErrorSamples
+	if errorSamples < 0 {
+		errorSamples = 0
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Notifier{
+		webhooks:     cfg.Webhooks,
+		errorSamples: errorSamples,
+		client:       &http.Client{Timeout: timeout},
+		logger:       logger,
+		errors:       make(map[interfaces.FunctionKey][]string),
+	}, nil
+}
+
+// RecordError remembers a failure of a function so the next notification
+// about it can include recent errors.
+func (n *Notifier) RecordError(key interfaces.FunctionKey, err error) {
+	if n == nil || err == nil || n.errorSamples == 0 {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	samples := append(n.errors[key], err.Error())
+	if len(samples) > n.errorSamples {
+		samples = samples[len(samples)-n.errorSamples:]
+	}
+	n.errors[key] = samples
+}
+
+// CircuitChanged is called by the circuit breakers on every state change. It
+// notifies when a circuit opens, and when it closes again.
+func (n *Notifier) CircuitChanged(key interfaces.FunctionKey, entrypoint, from, to string, failures int) {
+	if n == nil {
+		return
+	}
+
+	var eventType string
+	switch to {
+	case components.StateOpen:
+		eventType = EventCircuitOpen
+	case components.StateClosed:
+		eventType = EventCircuitClosed
+	default:
+		return
+	}
+
+	n.mu.Lock()
+	samples := append([]string(nil), n.errors[key]...)
+	if eventType == EventCircuitClosed {
+		delete(n.errors, key)
+	}
+	n.mu.Unlock()
+
+	n.Notify(Event{
+		Type:       eventType,
+		Namespace:  key.Namespace,
+		Function:   key.Name,
+		Entrypoint: entrypoint,
+		State:      to,
+		Previous:   from,
+		Failures:   failures,
+		Errors:     samples,
+		Time:       time.Now().UTC(),
+	})
+}
+
+// Notify sends an event to every webhook subscribed to its type.
+func (n *Notifier) Notify(event Event) {
+	if n == nil {
+		return
+	}
+
+	for _, hook := range n.webhooks {
+		if !subscribed(hook, event.Type) {
+			continue
+		}
+
+		n.wg.Add(1)
+		go func(hook config.WebhookConfig) {
+			defer n.wg.Done()
+			if err := n.send(hook, event); err != nil && n.logger != nil {
+				n.logger.Errorf("Failed to send %s notification for %s/%s to %s: %v",
+					event.Type, event.Namespace, event.Function, hook.URL, err)
+			}
+		}(hook)
+	}
+}
+
+// Close waits for notifications that are still being sent.
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+	n.wg.Wait()
+}
+
+func subscribed(hook config.WebhookConfig, eventType string) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, e := range hook.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) send(hook config.WebhookConfig, event Event) error {
+	var payload any = event
+	if hook.Format == "slack" {
+		payload = slackMessage(event)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range hook.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// slackMessage renders an event as a Slack-compatible incoming webhook payload.
+func slackMessage(event Event) map[string]string {
+	var b strings.Builder
+	if event.Type == EventCircuitOpen {
+		fmt.Fprintf(&b, ":red_circle: Circuit breaker opened for *%s/%s* after %d failures",
+			event.Namespace, event.Function, event.Failures)
+	} else {
+		fmt.Fprintf(&b, ":large_green_circle: Circuit breaker closed for *%s/%s*",
+			event.Namespace, event.Function)
+	}
+	if len(event.Errors) > 0 {
+		b.WriteString("\nRecent errors:\n```")
+		for _, msg := range event.Errors {
+			b.WriteString("\n" + msg)
+		}
+		b.WriteString("\n```")
+	}
+	return map[string]string{"text": b.String()}
+}
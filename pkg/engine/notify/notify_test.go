@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
+)
+
+type webhookRecorder struct {
+	mu       sync.Mutex
+	payloads []map[string]any
+	headers  []http.Header
+}
+
+func (w *webhookRecorder) server(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.mu.Lock()
+		w.payloads = append(w.payloads, payload)
+		w.headers = append(w.headers, r.Header.Clone())
+		w.mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNotifierSendsCircuitEvents(t *testing.T) {
+	recorder := &webhookRecorder{}
+	server := recorder.server(t)
+
+	notifier, err := New(config.NotificationsConfig{
+		ErrorSamples: 2,
+		Webhooks: []config.WebhookConfig{{
+			URL:     server.URL,
+			Headers: map[string]string{"Authorization": "Bearer secret"},
+		}},
+	}, nil)
+	require.NoError(t, err)
+
+	key := interfaces.FunctionKey{Namespace: "ns", Name: "fn"}
+	notifier.RecordError(key, errors.New("first"))
+	notifier.RecordError(key, errors.New("second"))
+	notifier.RecordError(key, errors.New("third"))
+
+	notifier.CircuitChanged(key, "", "closed", "open", 3)
+	notifier.Close()
+	notifier.CircuitChanged(key, "", "open", "half-open", 3)
+	notifier.CircuitChanged(key, "", "half-open", "closed", 0)
+	notifier.Close()
+
+	require.Len(t, recorder.payloads, 2)
+	opened := recorder.payloads[0]
+	assert.Equal(t, EventCircuitOpen, opened["type"])
+	assert.Equal(t, "ns", opened["namespace"])
+	assert.Equal(t, "fn", opened["function"])
+	assert.Equal(t, float64(3), opened["failure_count"])
+	assert.Equal(t, []any{"second", "third"}, opened["recent_errors"])
+	assert.Equal(t, "Bearer secret", recorder.headers[0].Get("Authorization"))
+
+	closed := recorder.payloads[1]
+	assert.Equal(t, EventCircuitClosed, closed["type"])
+	assert.Equal(t, []any{"second", "third"}, closed["recent_errors"])
+}
+
+func TestNotifierSlackFormatAndEventFilter(t *testing.T) {
+	recorder := &webhookRecorder{}
+	server := recorder.server(t)
+
+	notifier, err := New(config.NotificationsConfig{
+		ErrorSamples: 5,
+		Webhooks: []config.WebhookConfig{{
+			URL:    server.URL,
+			Format: "slack",
+			Events: []string{EventCircuitOpen},
+		}},
+	}, nil)
+	require.NoError(t, err)
+
+	key := interfaces.FunctionKey{Namespace: "ns", Name: "fn"}
+	notifier.RecordError(key, errors.New("boom"))
+	notifier.CircuitChanged(key, "", "closed", "open", 5)
+	notifier.CircuitChanged(key, "", "half-open", "closed", 0)
+	notifier.Close()
+
+	require.Len(t, recorder.payloads, 1)
+	text := recorder.payloads[0]["text"].(string)
+	assert.Contains(t, text, "Circuit breaker opened for *ns/fn* after 5 failures")
+	assert.Contains(t, text, "boom")
+}
+
+func TestNewRejectsInvalidWebhooks(t *testing.T) {
+	_, err := New(config.NotificationsConfig{Webhooks: []config.WebhookConfig{{URL: "http://x", Format: "xml"}}}, nil)
+	assert.Error(t, err)
+
+	_, err = New(config.NotificationsConfig{Webhooks: []config.WebhookConfig{{URL: "http://x", Events: []string{"opened"}}}}, nil)
+	assert.Error(t, err)
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/ignitionstack/ignition/pkg/engine/components"
 	"github.com/ignitionstack/ignition/pkg/engine/config"
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
 )
 
 type Options struct {
@@ -14,14 +16,168 @@ type Options struct {
 	// Capacity of the log store
 	LogStoreCapacity int
 
+	// Minimum level recorded in the function log store
+	LogLevel logging.LogLevel
+
+	// How long function log entries are kept (0 keeps them until evicted by capacity)
+	LogRetention time.Duration
+
+	// How often expired function log entries are trimmed (0 only trims when a function logs)
+	LogTrimInterval time.Duration
+
+	// How long admin audit events are kept (0 keeps them forever)
+	AuditRetention time.Duration
+
+	// Write function logs to rotated files under the registry directory
+	LogFilesEnabled bool
+
+	// Rotation of function log files (the directory is derived from the registry directory)
+	LogFiles logging.FileSinkOptions
+
+	// Forwarding of function logs to external systems (no sinks disables it)
+	LogShipping config.LogShippingConfig
+
+	// Webhooks notified when a circuit breaker opens or closes (none disables it)
+	Notifications config.NotificationsConfig
+
+	// Admission policies evaluated before functions are stored or loaded
+	Policy config.PolicyConfig
+
+	// Persistence of usage counters and quotas per namespace
+	Usage config.UsageConfig
+
+	// Collector of call metrics
+	Metrics config.MetricsConfig
+
+	// Resource watchdog writing diagnostic bundles when thresholds are exceeded
+	Watchdog config.WatchdogConfig
+
+	// Replay of load and build responses to retries with the same Idempotency-Key
+	Idempotency config.IdempotencyConfig
+
+	// Persist failed calls in the dead letter store
+	DeadLetterEnabled bool
+
+	// Maximum number of dead letter entries kept per function (0 means no cap)
+	DeadLetterMaxEntries int
+
+	// Size in bytes above which payloads and outputs are kept in temporary files (0 disables spooling)
+	SpoolThreshold int64
+
+	// Directory of spooled payloads and outputs (empty uses the system temporary directory)
+	SpoolDir string
+
+	// Accept compressed request bodies and compress responses on the HTTP endpoint
+	CompressionEnabled bool
+
+	// Smallest response in bytes that gets compressed
+	CompressionMinSize int
+
+	// Maximum decompressed request body size in bytes (0 disables the limit)
+	MaxDecompressedSize int64
+
+	// Send CORS headers on the HTTP endpoint (disable when only internal clients call it)
+	CORSEnabled bool
+
+	// Serve the admin API on the Unix socket
+	SocketEnabled bool
+
+	// How long to wait for another process to release the registry database (0 fails at once)
+	LockWait time.Duration
+
+	// TCP address of the admin API listener (empty disables it)
+	AdminAddr string
+
+	// Bearer token required by the TCP admin listener
+	AdminToken string
+
+	// Uids of Unix socket callers allowed to run destructive operations (empty allows every caller)
+	PrivilegedUIDs []uint32
+
+	// Additional HTTP listeners that only serve functions of some namespaces
+	Listeners []config.ListenerConfig
+
+	// TCP address serving the Prometheus metrics (empty disables it)
+	MetricsAddr string
+
+	// Maximum size in bytes of a wasm module accepted by the registry
+	MaxModuleSize int64
+
+	// Map modules from registry storage when loading them instead of reading them
+	MapModules bool
+
+	// Variants loaded in order of preference when a load names none
+	PreferredVariants []string
+
+	// Source of the key encrypting registry modules and metadata (none stores them in plain)
+	RegistryEncryption config.RegistryEncryptionConfig
+
+	// Secondary registry receiving a copy of every registry write (empty target disables it)
+	Replication config.ReplicationConfig
+
+	// Shared registry that pull misses are fetched from and cached (empty URL disables it)
+	RegistryUpstream config.UpstreamConfig
+
+	// Active/standby pairing with other engines on the host (empty lock file disables it)
+	HA config.HAConfig
+
+	// Egress rules applied to the HTTP requests of every function
+	Egress config.EgressConfig
+
+	// Config keys whose values are masked in function logs and redacted snapshots
+	Redaction config.RedactionConfig
+
+	// Proxy of function HTTP requests and remote registries (empty uses the environment)
+	Proxy config.ProxyConfig
+
+	// Function build settings
+	Build config.BuildConfig
+
+	// How often to run registry maintenance (0 disables it)
+	MaintenanceInterval time.Duration
+
+	// Verification of stored modules when the engine starts
+	IntegrityCheck config.IntegrityCheckConfig
+
+	// Functions that change the middleware chain of each endpoint, applied in order
+	MiddlewareChains []ChainFunc
+
+	// Time source for plugin TTL cleanup, circuit breaker resets, pool autoscaling and
+	// registry maintenance (nil uses the system clock)
+	Clock components.Clock
+
 	CircuitBreakerSettings components.CircuitBreakerSettings
 	PluginManagerSettings  components.PluginManagerSettings
 }
 
 func DefaultEngineOptions() *Options {
 	return &Options{
-		DefaultTimeout:   30 * time.Second,
-		LogStoreCapacity: 1000,
+		DefaultTimeout:       30 * time.Second,
+		LogStoreCapacity:     1000,
+		LogLevel:             logging.LevelInfo,
+		LogTrimInterval:      time.Minute,
+		MaxModuleSize:        64 << 20,
+		MaintenanceInterval:  1 * time.Hour,
+		IntegrityCheck:       config.IntegrityCheckConfig{Mode: "sample", SampleSize: 10},
+		AuditRetention:       30 * 24 * time.Hour,
+		DeadLetterMaxEntries: 100,
+		SpoolThreshold:       8 << 20,
+		Usage:                config.UsageConfig{PersistInterval: 30 * time.Second},
+		Metrics:              config.MetricsConfig{Collector: "memory"},
+		Watchdog:             config.WatchdogConfig{Interval: 30 * time.Second, Cooldown: 15 * time.Minute, MaxBundles: 5},
+		Idempotency:          config.IdempotencyConfig{Window: 10 * time.Minute, MaxKeys: 1000},
+		Replication:          config.ReplicationConfig{QueueSize: 1000, MaxRetries: 5},
+		HA:                   config.HAConfig{SyncInterval: 2 * time.Second},
+		LogFiles: logging.FileSinkOptions{
+			MaxSize:  10 << 20,
+			MaxFiles: 5,
+			MaxAge:   7 * 24 * time.Hour,
+		},
+		CompressionEnabled:  true,
+		CompressionMinSize:  1024,
+		MaxDecompressedSize: 32 << 20,
+		CORSEnabled:         true,
+		SocketEnabled:       true,
 		CircuitBreakerSettings: components.CircuitBreakerSettings{
 			FailureThreshold: 5,
 			ResetTimeout:     30 * time.Second,
@@ -29,21 +185,81 @@ func DefaultEngineOptions() *Options {
 		PluginManagerSettings: components.PluginManagerSettings{
 			TTL:             10 * time.Minute,
 			CleanupInterval: 1 * time.Minute,
+			Pool:            components.DefaultPoolSettings(),
 		},
 	}
 }
 
 func OptionsFromConfig(cfg *config.Config) *Options {
+	// LoadConfig rejects unknown levels, so a parse failure here falls back to info
+	logLevel, _ := logging.ParseLogLevel(cfg.Engine.LogLevel)
+
 	return &Options{
-		DefaultTimeout:   cfg.Engine.DefaultTimeout,
-		LogStoreCapacity: cfg.Engine.LogStoreCapacity,
+		DefaultTimeout:       cfg.Engine.DefaultTimeout,
+		LogStoreCapacity:     cfg.Engine.LogStoreCapacity,
+		LogLevel:             logLevel,
+		LogRetention:         cfg.Engine.LogRetention,
+		LogTrimInterval:      cfg.Engine.LogTrimInterval,
+		MaxModuleSize:        cfg.Registry.MaxModuleSize,
+		MapModules:           cfg.Registry.MapModules,
+		PreferredVariants:    cfg.Registry.PreferredVariants,
+		RegistryEncryption:   cfg.Registry.Encryption,
+		Replication:          cfg.Registry.Replication,
+		RegistryUpstream:     cfg.Registry.Upstream,
+		HA:                   cfg.Engine.HA,
+		Egress:               cfg.Engine.Egress,
+		Redaction:            cfg.Engine.Redaction,
+		Proxy:                cfg.Engine.Proxy,
+		Build:                cfg.Engine.Build,
+		MaintenanceInterval:  cfg.Registry.MaintenanceInterval,
+		IntegrityCheck:       cfg.Registry.IntegrityCheck,
+		AuditRetention:       cfg.Engine.AuditRetention,
+		DeadLetterEnabled:    cfg.Engine.DeadLetter.Enabled,
+		DeadLetterMaxEntries: cfg.Engine.DeadLetter.MaxEntries,
+		SpoolThreshold:       cfg.Engine.Spool.Threshold,
+		SpoolDir:             cfg.Engine.Spool.Dir,
+		LogFilesEnabled:      cfg.Engine.LogFiles.Enabled,
+		LogFiles: logging.FileSinkOptions{
+			MaxSize:  cfg.Engine.LogFiles.MaxSize,
+			MaxFiles: cfg.Engine.LogFiles.MaxFiles,
+			MaxAge:   cfg.Engine.LogFiles.MaxAge,
+		},
+		LogShipping:         cfg.Engine.LogShipping,
+		Notifications:       cfg.Engine.Notifications,
+		Policy:              cfg.Engine.Policy,
+		Usage:               cfg.Engine.Usage,
+		Metrics:             cfg.Engine.Metrics,
+		Watchdog:            cfg.Engine.Watchdog,
+		Idempotency:         cfg.Engine.Idempotency,
+		CompressionEnabled:  cfg.Server.Compression.Enabled,
+		CompressionMinSize:  cfg.Server.Compression.MinSize,
+		MaxDecompressedSize: cfg.Server.Compression.MaxRequestSize,
+		CORSEnabled:         cfg.Server.CORSEnabled,
+		SocketEnabled:       cfg.Server.SocketEnabled,
+		LockWait:            cfg.Server.LockWait,
+		AdminAddr:           cfg.Server.AdminAddr,
+		AdminToken:          cfg.Server.AdminToken,
+		PrivilegedUIDs:      cfg.Server.PrivilegedUIDs,
+		Listeners:           cfg.Server.Listeners,
+		MetricsAddr:         cfg.Server.MetricsAddr,
 		CircuitBreakerSettings: components.CircuitBreakerSettings{
 			FailureThreshold: cfg.Engine.CircuitBreaker.FailureThreshold,
 			ResetTimeout:     cfg.Engine.CircuitBreaker.ResetTimeout,
+			Ignore:           ignoredCodes(cfg.Engine.CircuitBreaker.Ignore),
+			Granularity:      cfg.Engine.CircuitBreaker.Granularity,
 		},
 		PluginManagerSettings: components.PluginManagerSettings{
 			TTL:             cfg.Engine.PluginManager.TTL,
 			CleanupInterval: cfg.Engine.PluginManager.CleanupInterval,
+			Pool: components.PoolSettings{
+				MinInstances:      cfg.Engine.PluginManager.Pool.MinInstances,
+				MaxInstances:      cfg.Engine.PluginManager.Pool.MaxInstances,
+				ScaleInterval:     cfg.Engine.PluginManager.Pool.ScaleInterval,
+				MaxRestarts:       cfg.Engine.PluginManager.Pool.MaxRestarts,
+				RestartBackoff:    cfg.Engine.PluginManager.Pool.RestartBackoff,
+				MaxRestartBackoff: cfg.Engine.PluginManager.Pool.MaxRestartBackoff,
+				MaxQueueWait:      cfg.Engine.PluginManager.Pool.MaxQueueWait,
+			},
 		},
 	}
 }
@@ -58,6 +274,194 @@ func (o *Options) WithLogStoreCapacity(capacity int) *Options {
 	return o
 }
 
+func (o *Options) WithLogLevel(level logging.LogLevel) *Options {
+	o.LogLevel = level
+	return o
+}
+
+func (o *Options) WithLogRetention(retention time.Duration) *Options {
+	o.LogRetention = retention
+	return o
+}
+
+func (o *Options) WithLogTrimInterval(interval time.Duration) *Options {
+	o.LogTrimInterval = interval
+	return o
+}
+
+func (o *Options) WithLogFiles(enabled bool, settings logging.FileSinkOptions) *Options {
+	o.LogFilesEnabled = enabled
+	o.LogFiles = settings
+	return o
+}
+
+func (o *Options) WithLogShipping(shipping config.LogShippingConfig) *Options {
+	o.LogShipping = shipping
+	return o
+}
+
+func (o *Options) WithNotifications(notifications config.NotificationsConfig) *Options {
+	o.Notifications = notifications
+	return o
+}
+
+func (o *Options) WithPolicy(policy config.PolicyConfig) *Options {
+	o.Policy = policy
+	return o
+}
+
+func (o *Options) WithUsage(usage config.UsageConfig) *Options {
+	o.Usage = usage
+	return o
+}
+
+func (o *Options) WithMetrics(metrics config.MetricsConfig) *Options {
+	o.Metrics = metrics
+	return o
+}
+
+func (o *Options) WithWatchdog(watchdog config.WatchdogConfig) *Options {
+	o.Watchdog = watchdog
+	return o
+}
+
+func (o *Options) WithIdempotency(idempotency config.IdempotencyConfig) *Options {
+	o.Idempotency = idempotency
+	return o
+}
+
+func (o *Options) WithAuditRetention(retention time.Duration) *Options {
+	o.AuditRetention = retention
+	return o
+}
+
+func (o *Options) WithDeadLetter(enabled bool, maxEntries int) *Options {
+	o.DeadLetterEnabled = enabled
+	o.DeadLetterMaxEntries = maxEntries
+	return o
+}
+
+func (o *Options) WithSpooling(threshold int64, dir string) *Options {
+	o.SpoolThreshold = threshold
+	o.SpoolDir = dir
+	return o
+}
+
+func (o *Options) WithCompression(enabled bool, minSize int, maxDecompressedSize int64) *Options {
+	o.CompressionEnabled = enabled
+	o.CompressionMinSize = minSize
+	o.MaxDecompressedSize = maxDecompressedSize
+	return o
+}
+
+func (o *Options) WithCORS(enabled bool) *Options {
+	o.CORSEnabled = enabled
+	return o
+}
+
+// WithMiddlewareChain adds a function that changes the middleware chain of each endpoint.
+// Functions run in the order they were added, each given the chain the previous returned.
+func (o *Options) WithMiddlewareChain(fn ChainFunc) *Options {
+	o.MiddlewareChains = append(o.MiddlewareChains, fn)
+	return o
+}
+
+func (o *Options) WithSocket(enabled bool) *Options {
+	o.SocketEnabled = enabled
+	return o
+}
+
+func (o *Options) WithLockWait(wait time.Duration) *Options {
+	o.LockWait = wait
+	return o
+}
+
+func (o *Options) WithAdminListener(addr, token string) *Options {
+	o.AdminAddr = addr
+	o.AdminToken = token
+	return o
+}
+
+func (o *Options) WithPrivilegedUIDs(uids ...uint32) *Options {
+	o.PrivilegedUIDs = uids
+	return o
+}
+
+func (o *Options) WithListeners(listeners ...config.ListenerConfig) *Options {
+	o.Listeners = listeners
+	return o
+}
+
+func (o *Options) WithMetricsAddr(addr string) *Options {
+	o.MetricsAddr = addr
+	return o
+}
+
+func (o *Options) WithMaxModuleSize(size int64) *Options {
+	o.MaxModuleSize = size
+	return o
+}
+
+func (o *Options) WithMapModules(enabled bool) *Options {
+	o.MapModules = enabled
+	return o
+}
+
+func (o *Options) WithPreferredVariants(variants []string) *Options {
+	o.PreferredVariants = variants
+	return o
+}
+
+func (o *Options) WithRegistryEncryption(encryption config.RegistryEncryptionConfig) *Options {
+	o.RegistryEncryption = encryption
+	return o
+}
+
+func (o *Options) WithReplication(replication config.ReplicationConfig) *Options {
+	o.Replication = replication
+	return o
+}
+
+func (o *Options) WithRegistryUpstream(upstream config.UpstreamConfig) *Options {
+	o.RegistryUpstream = upstream
+	return o
+}
+
+func (o *Options) WithHA(ha config.HAConfig) *Options {
+	o.HA = ha
+	return o
+}
+
+func (o *Options) WithEgress(egress config.EgressConfig) *Options {
+	o.Egress = egress
+	return o
+}
+
+func (o *Options) WithRedaction(redaction config.RedactionConfig) *Options {
+	o.Redaction = redaction
+	return o
+}
+
+func (o *Options) WithProxy(proxy config.ProxyConfig) *Options {
+	o.Proxy = proxy
+	return o
+}
+
+func (o *Options) WithBuild(build config.BuildConfig) *Options {
+	o.Build = build
+	return o
+}
+
+func (o *Options) WithMaintenanceInterval(interval time.Duration) *Options {
+	o.MaintenanceInterval = interval
+	return o
+}
+
+func (o *Options) WithIntegrityCheck(check config.IntegrityCheckConfig) *Options {
+	o.IntegrityCheck = check
+	return o
+}
+
 func (o *Options) WithCircuitBreakerSettings(settings components.CircuitBreakerSettings) *Options {
 	o.CircuitBreakerSettings = settings
 	return o
@@ -67,3 +471,17 @@ func (o *Options) WithPluginManagerSettings(settings components.PluginManagerSet
 	o.PluginManagerSettings = settings
 	return o
 }
+
+func (o *Options) WithClock(clock components.Clock) *Options {
+	o.Clock = clock
+	return o
+}
+
+// ignoredCodes converts the error codes ignored by circuit breakers in configuration.
+func ignoredCodes(codes []string) []domainerrors.Code {
+	ignored := make([]domainerrors.Code, 0, len(codes))
+	for _, code := range codes {
+		ignored = append(ignored, domainerrors.Code(code))
+	}
+	return ignored
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package engine
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// socketPeer identifies the process on the other end of a unix socket connection
+func socketPeer(conn net.Conn) peerInfo {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return peerInfo{source: conn.RemoteAddr().String()}
+	}
+
+	peer := peerInfo{source: "unix", unix: true}
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return peer
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil || credErr != nil {
+		return peer
+	}
+
+	peer.hasCredentials = true
+	peer.uid, peer.gid, peer.pid = cred.Uid, cred.Gid, cred.Pid
+	peer.source = fmt.Sprintf("unix:uid=%d,gid=%d,pid=%d", cred.Uid, cred.Gid, cred.Pid)
+	return peer
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package engine
+
+import (
+	"net"
+)
+
+// socketPeer identifies the other end of a socket connection.
+// Peer credentials are only read on Linux.
+func socketPeer(conn net.Conn) peerInfo {
+	if _, ok := conn.(*net.UnixConn); ok {
+		return peerInfo{source: "unix", unix: true}
+	}
+
+	// Named pipes stand in for the socket on Windows
+	if conn.RemoteAddr().Network() == "pipe" {
+		return peerInfo{source: "pipe", unix: true}
+	}
+	return peerInfo{source: conn.RemoteAddr().String()}
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/engine/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivilegedUIDs(t *testing.T) {
+	engine, tmpDir := setupTestEngine(t)
+	defer cleanupTest(tmpDir)
+
+	engine.options.WithPrivilegedUIDs(0)
+	handler := NewHandlers(engine, engine.logger).UnixSocketHandler()
+
+	send := func(method, path, body string, peer peerInfo) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), peerSourceKey{}, peer))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	stop := func(peer peerInfo) int {
+		return send(http.MethodPost, "/stop", `{"namespace":"ns","name":"fn"}`, peer)
+	}
+
+	unprivileged := peerInfo{source: "unix:uid=1000,gid=1000,pid=42", unix: true, hasCredentials: true, uid: 1000, gid: 1000, pid: 42}
+	assert.Equal(t, http.StatusForbidden, stop(unprivileged))
+
+	// Socket callers whose uid is unknown are refused as well
+	assert.Equal(t, http.StatusForbidden, stop(peerInfo{source: "unix", unix: true}))
+
+	// Privileged callers and token holders on the TCP admin listener get past the check
+	root := peerInfo{source: "unix:uid=0,gid=0,pid=1", unix: true, hasCredentials: true}
+	assert.NotEqual(t, http.StatusForbidden, stop(root))
+	assert.NotEqual(t, http.StatusForbidden, stop(peerInfo{source: "10.0.0.1:5000"}))
+
+	// Refusals are audited with the caller's credentials
+	events, err := engine.AuditLog(audit.Query{Operation: audit.OperationStop})
+	require.NoError(t, err)
+	var refused []audit.Event
+	for _, event := range events {
+		if strings.Contains(event.Error, "not permitted") {
+			refused = append(refused, event)
+		}
+	}
+	require.Len(t, refused, 1)
+	assert.False(t, refused[0].Success)
+	assert.Equal(t, "unix:uid=1000,gid=1000,pid=42", refused[0].Source)
+
+	// Other routes changing engine state are restricted too, while reads stay open
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/dlq/ns/fn/purge", `{}`, unprivileged))
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/pipelines/unregister", `{"name":"p"}`, unprivileged))
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/scale", `{"namespace":"ns","name":"fn","instances":2}`, unprivileged))
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/apply", `{"project":"shop","services":{}}`, unprivileged))
+	assert.NotEqual(t, http.StatusForbidden, send(http.MethodGet, "/dlq/ns/fn", "", unprivileged))
+
+	// Pushing or building with an existing tag moves it, and loading replaces a running digest
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/registry/push", `{"namespace":"ns","name":"fn","tag":"latest"}`, unprivileged))
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/registry/variant", `{"namespace":"ns","name":"fn","tag":"latest"}`, unprivileged))
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/build", `{"namespace":"ns","name":"fn","tag":"latest"}`, unprivileged))
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/load", `{"namespace":"ns","name":"fn","digest":"latest"}`, unprivileged))
+	assert.NotEqual(t, http.StatusForbidden, send(http.MethodPost, "/load", `{"namespace":"ns","name":"fn","digest":"latest"}`, root))
+
+	// A handler served without the peer of its connections refuses privileged operations
+	req := httptest.NewRequest(http.MethodPost, "/stop", strings.NewReader(`{"namespace":"ns","name":"fn"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Planning an apply only reads the engine, so it is neither restricted nor audited
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/apply/plan", `{"project":"shop","services":{}}`, unprivileged))
+	events, err = engine.AuditLog(audit.Query{Operation: audit.OperationApply})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.False(t, events[0].Success)
+
+	events, err = engine.AuditLog(audit.Query{Operation: audit.OperationDeadLetter})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, map[string]string{"namespace": "ns", "name": "fn", "action": "purge"}, events[0].Params)
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// PipelineRegistry holds the pipelines declared by compose files, keyed by name, and
+// the project that applied them.
+type PipelineRegistry struct {
+	mu        sync.RWMutex
+	pipelines map[string][]types.PipelineStep
+	owners    map[string]string
+}
+
+// NewPipelineRegistry creates an empty pipeline registry.
+func NewPipelineRegistry() *PipelineRegistry {
+	return &PipelineRegistry{
+		pipelines: make(map[string][]types.PipelineStep),
+		owners:    make(map[string]string),
+	}
+}
+
+// Register stores a pipeline, replacing any previous definition with the same name and
+// its owner.
+func (r *PipelineRegistry) Register(name string, steps []types.PipelineStep) {
+	stored := make([]types.PipelineStep, len(steps))
+	copy(stored, steps)
+
+	r.mu.Lock()
+	r.pipelines[name] = stored
+	delete(r.owners, name)
+	r.mu.Unlock()
+}
+
+// SetOwner records the project owning a registered pipeline.
+func (r *PipelineRegistry) SetOwner(name, project string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pipelines[name]; ok {
+		r.owners[name] = project
+	}
+}
+
+// Owner returns the project owning a pipeline, or "" when no project applied it.
+func (r *PipelineRegistry) Owner(name string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.owners[name]
+}
+
+// Get returns the steps of a pipeline.
+func (r *PipelineRegistry) Get(name string) ([]types.PipelineStep, bool) {
+	r.mu.RLock()
+	steps, ok := r.pipelines[name]
+	r.mu.RUnlock()
+
+	return steps, ok
+}
+
+// Unregister removes a pipeline and reports whether it existed.
+func (r *PipelineRegistry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.pipelines[name]
+	delete(r.pipelines, name)
+	delete(r.owners, name)
+	return ok
+}
+
+// List returns the registered pipeline names in sorted order.
+func (r *PipelineRegistry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.pipelines))
+	for name := range r.pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// RegisterPipeline makes a pipeline callable over HTTP.
+func (e *Engine) RegisterPipeline(name string, steps []types.PipelineStep) {
+	e.pipelines.Register(name, steps)
+	e.logger.Printf("Registered pipeline %s with %d steps", name, len(steps))
+}
+
+// UnregisterPipeline removes a pipeline and reports whether it existed.
+func (e *Engine) UnregisterPipeline(name string) bool {
+	return e.pipelines.Unregister(name)
+}
+
+// runPipeline calls each step in order, feeding the output of one step into the next.
+// A failing step either aborts the run or, with the continue policy, is skipped so
+// the next step receives the same input.
+func (h *Handlers) runPipeline(ctx context.Context, name string, steps []types.PipelineStep, payload string) ([]byte, error) {
+	output := []byte(payload)
+
+	for i, step := range steps {
+		params, err := h.resolvePipelineStep(step)
+		if err == nil {
+			output, err = h.runPipelineStep(ctx, params, step, output)
+		}
+		if err == nil {
+			continue
+		}
+
+		// The whole request is gone, so there is nothing left to continue with
+		if ctx.Err() != nil || step.OnError != types.PipelineContinue {
+			return nil, pipelineStepError(name, i, step, err)
+		}
+		h.logger.Printf("Pipeline %s: step %d (%s) failed, continuing: %v", name, i+1, step.Entrypoint, err)
+	}
+
+	return output, nil
+}
+
+// runPipelineStep calls one step under its own timeout. On failure the input is
+// returned unchanged so a continued pipeline passes it along.
+func (h *Handlers) runPipelineStep(ctx context.Context, params *functionCallParams, step types.PipelineStep, input []byte) ([]byte, error) {
+	if step.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(step.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	output, err := h.executeFunction(ctx, params, string(input))
+	if err != nil {
+		return input, err
+	}
+	return output, nil
+}
+
+// resolvePipelineStep turns a step into call parameters, resolving service names at call time.
+func (h *Handlers) resolvePipelineStep(step types.PipelineStep) (*functionCallParams, error) {
+	if step.Service == "" {
+		return &functionCallParams{namespace: step.Namespace, name: step.Name, entrypoint: step.Entrypoint}, nil
+	}
+
+	namespace, name, ok := h.engine.ResolveService(step.Service)
+	if !ok {
+		return nil, NewNotFoundError(fmt.Sprintf("Service not found: %s", step.Service))
+	}
+	return &functionCallParams{namespace: namespace, name: name, entrypoint: step.Entrypoint}, nil
+}
+
+// pipelineStepError names the failing step while keeping the step's status code.
+func pipelineStepError(pipeline string, index int, step types.PipelineStep, err error) error {
+	status := http.StatusInternalServerError
+	var reqErr RequestError
+	if errors.As(err, &reqErr) {
+		status = reqErr.StatusCode
+	}
+
+	return NewRequestErrorWithCause(
+		fmt.Sprintf("Pipeline %s failed at step %d (%s): %v", pipeline, index+1, step.Entrypoint, err),
+		status, err)
+}
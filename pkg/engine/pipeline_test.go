@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPipelineErrorPolicies(t *testing.T) {
+	engine, tmpDir := setupTestEngine(t)
+	defer cleanupTest(tmpDir)
+
+	handlers := NewHandlers(engine, engine.logger)
+
+	// Steps targeting a missing service fail without touching any plugin
+	missing := types.PipelineStep{Service: "missing", Entrypoint: "run", OnError: types.PipelineContinue}
+
+	output, err := handlers.runPipeline(context.Background(), "skip", []types.PipelineStep{missing, missing}, "input")
+	require.NoError(t, err)
+	assert.Equal(t, "input", string(output))
+
+	missing.OnError = ""
+	_, err = handlers.runPipeline(context.Background(), "fail", []types.PipelineStep{missing}, "input")
+	require.Error(t, err)
+
+	var reqErr RequestError
+	require.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusNotFound, reqErr.StatusCode)
+	assert.Contains(t, reqErr.Message, "Pipeline fail failed at step 1 (run)")
+}
+
+func TestRegisterPipelineRequestValidate(t *testing.T) {
+	valid := types.RegisterPipelineRequest{
+		Name: "checkout",
+		Steps: []types.PipelineStep{
+			{Service: "cart", Entrypoint: "total"},
+			{Namespace: "shop", Name: "tax", Entrypoint: "apply", TimeoutMs: 500, OnError: types.PipelineContinue},
+		},
+	}
+	require.NoError(t, valid.Validate())
+
+	both := valid
+	both.Steps = []types.PipelineStep{{Service: "cart", Namespace: "shop", Name: "tax", Entrypoint: "apply"}}
+	assert.Error(t, both.Validate())
+
+	badPolicy := valid
+	badPolicy.Steps = []types.PipelineStep{{Service: "cart", Entrypoint: "total", OnError: "retry"}}
+	assert.Error(t, badPolicy.Validate())
+
+	badName := valid
+	badName.Name = "a/b"
+	assert.Error(t, badName.Validate())
+}
@@ -0,0 +1,299 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// Advisory is a vulnerability advisory in the OSV format, with the fields the
+// vulnerability check reads.
+type Advisory struct {
+	ID       string            `json:"id"`
+	Aliases  []string          `json:"aliases,omitempty"`
+	Summary  string            `json:"summary,omitempty"`
+	Affected []AffectedPackage `json:"affected,omitempty"`
+
+	// CVSS vectors of the advisory
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity,omitempty"`
+
+	// Severity assigned by the database, such as the GitHub advisory database
+	DatabaseSpecific struct {
+		Severity string `json:"severity,omitempty"`
+	} `json:"database_specific"`
+}
+
+// AffectedPackage lists the versions of a package an advisory applies to.
+type AffectedPackage struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges []struct {
+		Type   string              `json:"type"`
+		Events []map[string]string `json:"events"`
+	} `json:"ranges,omitempty"`
+	Versions []string `json:"versions,omitempty"`
+}
+
+// Rating returns the severity of the advisory: low, moderate, high or critical. The
+// severity of the database is preferred, else the highest CVSS v3 score is rated.
+// Advisories with neither are rated high.
+func (a Advisory) Rating() string {
+	switch severity := strings.ToLower(a.DatabaseSpecific.Severity); severity {
+	case "low", "moderate", "high", "critical":
+		return severity
+	case "medium":
+		return "moderate"
+	}
+
+	best := -1.0
+	for _, s := range a.Severity {
+		if score, ok := cvss3Score(s.Score); ok && score > best {
+			best = score
+		}
+	}
+	switch {
+	case best < 0:
+		return "high"
+	case best >= 9:
+		return "critical"
+	case best >= 7:
+		return "high"
+	case best >= 4:
+		return "moderate"
+	default:
+		return "low"
+	}
+}
+
+// Names returns the ID and aliases of the advisory.
+func (a Advisory) Names() []string {
+	return append([]string{a.ID}, a.Aliases...)
+}
+
+// affects reports whether the advisory applies to a version of a package.
+func (a Advisory) affects(ecosystem, name, version string) bool {
+	for _, affected := range a.Affected {
+		if affected.Package.Ecosystem != ecosystem || affected.Package.Name != name {
+			continue
+		}
+		for _, v := range affected.Versions {
+			if v == version {
+				return true
+			}
+		}
+		for _, r := range affected.Ranges {
+			if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+				continue
+			}
+			if inRange(r.Events, version) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inRange reports whether version falls in one of the intervals the events of an OSV
+// range open with introduced and close with fixed or last_affected.
+func inRange(events []map[string]string, version string) bool {
+	matches := func(expr string) bool {
+		r, err := registry.ParseVersionRange(expr)
+		if err != nil {
+			return false
+		}
+		_, ok := r.HighestMatch([]string{version})
+		return ok
+	}
+
+	introduced := ""
+	for _, event := range events {
+		switch {
+		case event["introduced"] != "":
+			introduced = event["introduced"]
+		case introduced != "" && event["fixed"] != "":
+			if matches(">=" + introduced + " <" + event["fixed"]) {
+				return true
+			}
+			introduced = ""
+		case introduced != "" && event["last_affected"] != "":
+			if matches(">=" + introduced + " <=" + event["last_affected"]) {
+				return true
+			}
+			introduced = ""
+		}
+	}
+	return introduced != "" && matches(">="+introduced)
+}
+
+// AdvisorySource finds the advisories affecting a package, given by its package URL.
+type AdvisorySource interface {
+	Advisories(ctx context.Context, purl string) ([]Advisory, error)
+}
+
+// osvEcosystems maps package URL types to OSV ecosystems.
+var osvEcosystems = map[string]string{
+	"golang": "Go",
+	"cargo":  "crates.io",
+	"npm":    "npm",
+}
+
+// parsePURL splits a package URL into its OSV ecosystem, name and version.
+func parsePURL(purl string) (ecosystem, name, version string, ok bool) {
+	rest, found := strings.CutPrefix(purl, "pkg:")
+	if !found {
+		return "", "", "", false
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		rest, version = rest[:i], rest[i+1:]
+	}
+	kind, name, found := strings.Cut(rest, "/")
+	if !found {
+		return "", "", "", false
+	}
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	ecosystem, ok = osvEcosystems[kind]
+	return ecosystem, name, version, ok
+}
+
+// AdvisoryDB is a local database of advisories, such as an export of the OSV database.
+type AdvisoryDB struct {
+	// Advisories by ecosystem and package name
+	packages map[string][]Advisory
+}
+
+// LoadAdvisoryDB reads advisories from a JSON file holding one advisory or a list of
+// them, or from every .json file under a directory.
+func LoadAdvisoryDB(path string) (*AdvisoryDB, error) {
+	db := &AdvisoryDB{packages: make(map[string][]Advisory)}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open advisory database: %w", err)
+	}
+	if !info.IsDir() {
+		return db, db.load(path)
+	}
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(file) != ".json" {
+			return err
+		}
+		return db.load(file)
+	})
+	return db, err
+}
+
+func (db *AdvisoryDB) load(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read advisories: %w", err)
+	}
+
+	var advisories []Advisory
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &advisories)
+	} else {
+		var advisory Advisory
+		err = json.Unmarshal(trimmed, &advisory)
+		advisories = append(advisories, advisory)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid advisories in %s: %w", file, err)
+	}
+
+	for _, advisory := range advisories {
+		seen := make(map[string]bool)
+		for _, affected := range advisory.Affected {
+			key := affected.Package.Ecosystem + "/" + affected.Package.Name
+			if !seen[key] {
+				seen[key] = true
+				db.packages[key] = append(db.packages[key], advisory)
+			}
+		}
+	}
+	return nil
+}
+
+// Advisories returns the advisories of the database affecting the version of a package.
+func (db *AdvisoryDB) Advisories(_ context.Context, purl string) ([]Advisory, error) {
+	ecosystem, name, version, ok := parsePURL(purl)
+	if !ok || version == "" {
+		return nil, nil
+	}
+	var matched []Advisory
+	for _, advisory := range db.packages[ecosystem+"/"+name] {
+		if advisory.affects(ecosystem, name, version) {
+			matched = append(matched, advisory)
+		}
+	}
+	return matched, nil
+}
+
+// OSV queries the OSV API for the advisories of packages, caching the answers, as
+// the packages of a version do not change.
+type OSV struct {
+	url    string
+	client *http.Client
+	cache  sync.Map
+}
+
+// NewOSV creates a client of the OSV API at baseURL.
+func NewOSV(baseURL string, transport http.RoundTripper, timeout time.Duration) *OSV {
+	return &OSV{
+		url:    strings.TrimSuffix(baseURL, "/"),
+		client: &http.Client{Transport: transport, Timeout: timeout},
+	}
+}
+
+// Advisories asks the OSV API about the version of a package.
+func (o *OSV) Advisories(ctx context.Context, purl string) ([]Advisory, error) {
+	if cached, ok := o.cache.Load(purl); ok {
+		return cached.([]Advisory), nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"package": map[string]string{"purl": purl}})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url+"/v1/query", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("osv query failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("osv query failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var result struct {
+		Vulns []Advisory `json:"vulns"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid osv response: %w", err)
+	}
+	o.cache.Store(purl, result.Vulns)
+	return result.Vulns, nil
+}
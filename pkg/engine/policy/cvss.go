@@ -0,0 +1,81 @@
+package policy
+
+import (
+	"math"
+	"strings"
+)
+
+// cvss3Metrics holds the weights of the base metrics of CVSS v3.
+var cvss3Metrics = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// cvss3Score computes the base score of a CVSS v3 vector such as
+// CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H.
+func cvss3Score(vector string) (float64, bool) {
+	if !strings.HasPrefix(vector, "CVSS:3.") {
+		return 0, false
+	}
+
+	values := make(map[string]float64)
+	changed := false
+	for _, part := range strings.Split(vector, "/")[1:] {
+		metric, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return 0, false
+		}
+		if metric == "S" {
+			changed = value == "C"
+			continue
+		}
+		if weights, known := cvss3Metrics[metric]; known {
+			weight, valid := weights[value]
+			if !valid {
+				return 0, false
+			}
+			values[metric] = weight
+		}
+	}
+	if len(values) != len(cvss3Metrics) {
+		return 0, false
+	}
+
+	// Privileges weigh more when the scope changes
+	if changed {
+		switch values["PR"] {
+		case 0.62:
+			values["PR"] = 0.68
+		case 0.27:
+			values["PR"] = 0.5
+		}
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	exploitability := 8.22 * values["AV"] * values["AC"] * values["PR"] * values["UI"]
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), true
+}
+
+// roundUp rounds up to one decimal, as specified by CVSS v3.1.
+func roundUp(x float64) float64 {
+	n := int(math.Round(x * 100000))
+	if n%10000 == 0 {
+		return float64(n) / 100000
+	}
+	return float64(n/10000+1) / 10
+}
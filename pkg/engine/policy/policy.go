@@ -0,0 +1,137 @@
+// Package policy decides whether function versions may be stored in the registry
+// and loaded, using built-in rules and external webhook evaluators.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// Stages at which admission is evaluated.
+const (
+	// StagePush covers every version stored in the registry: builds, imports and pushes
+	StagePush = "push"
+
+	// StageLoad covers loading a stored version, so policies added later apply to old versions
+	StageLoad = "load"
+)
+
+// ErrDenied is matched by every admission denial.
+var ErrDenied = errors.New("admission denied")
+
+// Input describes the function version an evaluator decides on.
+type Input struct {
+	Stage     string                           `json:"stage"`
+	Namespace string                           `json:"namespace"`
+	Name      string                           `json:"name"`
+	Digest    string                           `json:"digest"`
+	Settings  manifest.FunctionVersionSettings `json:"settings"`
+
+	// Imports, exports and memory of the module (nil when it could not be inspected)
+	Module *registry.ModuleInfo `json:"module,omitempty"`
+}
+
+// Decision is the verdict of an evaluator, with the reasons of a denial.
+type Decision struct {
+	Allowed bool     `json:"allowed"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Evaluator decides whether a function version is admitted. An error means no decision
+// could be made, which fails the admission.
+type Evaluator interface {
+	Evaluate(ctx context.Context, input Input) (Decision, error)
+}
+
+// EvaluatorFunc adapts a function to the Evaluator interface.
+type EvaluatorFunc func(ctx context.Context, input Input) (Decision, error)
+
+func (f EvaluatorFunc) Evaluate(ctx context.Context, input Input) (Decision, error) {
+	return f(ctx, input)
+}
+
+// DeniedError reports the reasons every evaluator gave for denying a version.
+type DeniedError struct {
+	Stage     string
+	Namespace string
+	Name      string
+	Reasons   []string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("%s of %s/%s denied by admission policy: %s", e.Stage, e.Namespace, e.Name, strings.Join(e.Reasons, "; "))
+}
+
+func (e *DeniedError) Is(target error) bool {
+	return target == ErrDenied
+}
+
+// Admission runs the admission evaluators of an engine. Every evaluator must allow a
+// version for it to be admitted.
+type Admission struct {
+	mu         sync.RWMutex
+	evaluators []Evaluator
+}
+
+// New creates an admission from the configured rules and webhook. Without any, every
+// version is admitted until an evaluator is added with Use.
+func New(cfg config.PolicyConfig) (*Admission, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	admission := &Admission{}
+	rules, err := NewRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	if rules != nil {
+		admission.Use(rules)
+	}
+	if cfg.Webhook.URL != "" {
+		admission.Use(NewWebhook(cfg.Webhook))
+	}
+	return admission, nil
+}
+
+// Use adds an evaluator consulted on every admission.
+func (a *Admission) Use(evaluator Evaluator) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.evaluators = append(a.evaluators, evaluator)
+}
+
+// Admit asks every evaluator about a version and returns a *DeniedError collecting
+// the reasons of those that deny it.
+func (a *Admission) Admit(ctx context.Context, input Input) error {
+	a.mu.RLock()
+	evaluators := a.evaluators
+	a.mu.RUnlock()
+
+	var reasons []string
+	for _, evaluator := range evaluators {
+		decision, err := evaluator.Evaluate(ctx, input)
+		if err != nil {
+			return fmt.Errorf("admission policy evaluation failed: %w", err)
+		}
+		if decision.Allowed {
+			continue
+		}
+		if len(decision.Reasons) == 0 {
+			decision.Reasons = []string{"denied without a reason"}
+		}
+		reasons = append(reasons, decision.Reasons...)
+	}
+
+	if len(reasons) > 0 {
+		return &DeniedError{Stage: input.Stage, Namespace: input.Namespace, Name: input.Name, Reasons: reasons}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package policy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	digest := "0123abcd"
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(digest)))
+
+	rules, err := NewRules(config.PolicyRulesConfig{
+		AllowedNamespaces: []string{"team-*"},
+		RequiredLabels:    []string{"owner"},
+		MaxMemoryMB:       1,
+		AllowedURLs:       []string{"api.example.com"},
+		RequireSignature:  true,
+		SignatureKeys:     []string{base64.StdEncoding.EncodeToString(pub)},
+	})
+	require.NoError(t, err)
+
+	allowed := Input{
+		Namespace: "team-a",
+		Digest:    digest,
+		Settings: manifest.FunctionVersionSettings{
+			Labels:      map[string]string{"owner": "payments"},
+			AllowedUrls: []string{"api.example.com"},
+			Signature:   signature,
+		},
+		Module: &registry.ModuleInfo{MinMemoryPages: 2, MaxMemoryPages: 16},
+	}
+	decision, err := rules.Evaluate(context.Background(), allowed)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed, decision.Reasons)
+
+	denied := Input{
+		Namespace: "other",
+		Digest:    "ffff",
+		Settings: manifest.FunctionVersionSettings{
+			AllowedUrls: []string{"evil.example.com"},
+			Signature:   signature,
+		},
+		Module: &registry.ModuleInfo{MinMemoryPages: 32},
+	}
+	decision, err = rules.Evaluate(context.Background(), denied)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, []string{
+		"namespace other is not allowed",
+		"required label owner is missing",
+		"initial memory of 2 MiB exceeds the limit of 1 MiB",
+		"allowed URL evil.example.com is not in the policy allowlist",
+		"signature does not match any trusted key",
+	}, decision.Reasons)
+}
+
+func TestNewRules(t *testing.T) {
+	rules, err := NewRules(config.PolicyRulesConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, rules)
+
+	_, err = NewRules(config.PolicyRulesConfig{RequireSignature: true, SignatureKeys: []string{"bm90IGEga2V5"}})
+	assert.ErrorContains(t, err, "signature key 1")
+
+	_, err = NewRules(config.PolicyRulesConfig{AllowedNamespaces: []string{"team-["}})
+	assert.ErrorContains(t, err, "invalid pattern")
+}
+
+func TestWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var input Input
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		if input.Namespace == "broken" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(Decision{Allowed: input.Namespace == "ns", Reasons: []string{"not ns"}})
+	}))
+	defer server.Close()
+
+	cfg := config.PolicyWebhookConfig{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer secret"}}
+	webhook := NewWebhook(cfg)
+
+	decision, err := webhook.Evaluate(context.Background(), Input{Namespace: "ns"})
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	decision, err = webhook.Evaluate(context.Background(), Input{Namespace: "other"})
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, []string{"not ns"}, decision.Reasons)
+
+	_, err = webhook.Evaluate(context.Background(), Input{Namespace: "broken"})
+	assert.ErrorContains(t, err, "unexpected status 500: boom")
+
+	cfg.FailOpen = true
+	decision, err = NewWebhook(cfg).Evaluate(context.Background(), Input{Namespace: "broken"})
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+}
+
+func TestAdmission(t *testing.T) {
+	admission, err := New(config.PolicyConfig{})
+	require.NoError(t, err)
+	require.NoError(t, admission.Admit(context.Background(), Input{Stage: StagePush, Namespace: "ns", Name: "fn"}))
+
+	admission.Use(EvaluatorFunc(func(context.Context, Input) (Decision, error) {
+		return Decision{Allowed: false, Reasons: []string{"first"}}, nil
+	}))
+	admission.Use(EvaluatorFunc(func(context.Context, Input) (Decision, error) {
+		return Decision{Allowed: false}, nil
+	}))
+
+	err = admission.Admit(context.Background(), Input{Stage: StageLoad, Namespace: "ns", Name: "fn"})
+	require.ErrorIs(t, err, ErrDenied)
+	assert.EqualError(t, err, "load of ns/fn denied by admission policy: first; denied without a reason")
+
+	admission.Use(EvaluatorFunc(func(context.Context, Input) (Decision, error) {
+		return Decision{}, errors.New("unreachable")
+	}))
+	err = admission.Admit(context.Background(), Input{Stage: StageLoad, Namespace: "ns", Name: "fn"})
+	assert.NotErrorIs(t, err, ErrDenied)
+	assert.ErrorContains(t, err, "unreachable")
+}
+
+// sbomStore serves one SBOM for every version of ns/fn.
+type sbomStore struct {
+	sbom *registry.SBOM
+}
+
+func (s sbomStore) AttachSBOM(string, string, string, *registry.SBOM) error { return nil }
+
+func (s sbomStore) SBOM(namespace, name, _ string) (*registry.SBOM, error) {
+	if namespace+"/"+name != "ns/fn" {
+		return nil, registry.ErrSBOMNotFound
+	}
+	return s.sbom, nil
+}
+
+const advisories = `[
+  {"id": "GHSA-crit", "aliases": ["CVE-2024-0001"], "summary": "remote code execution",
+   "affected": [{"package": {"ecosystem": "crates.io", "name": "serde"},
+     "ranges": [{"type": "SEMVER", "events": [{"introduced": "1.0.0"}, {"fixed": "1.0.150"}]}]}],
+   "database_specific": {"severity": "CRITICAL"}},
+  {"id": "GO-low", "affected": [{"package": {"ecosystem": "Go", "name": "golang.org/x/text"}, "versions": ["v0.3.0"]}],
+   "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:L/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N"}]}
+]`
+
+func TestVulnerabilities(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "advisories.json")
+	require.NoError(t, os.WriteFile(db, []byte(advisories), 0o600))
+	store := sbomStore{sbom: registry.NewSBOM("ns", "fn", "digest", []registry.SBOMComponent{
+		{Name: "serde", Version: "1.0.100", PURL: "pkg:cargo/serde@1.0.100"},
+		{Name: "golang.org/x/text", Version: "v0.3.0", PURL: "pkg:golang/golang.org/x/text@v0.3.0"},
+	})}
+	load := Input{Stage: StageLoad, Namespace: "ns", Name: "fn", Digest: "digest"}
+
+	var reported []Finding
+	cfg := config.VulnerabilityPolicyConfig{Mode: "enforce", AdvisoryDB: db}
+	evaluator, err := NewVulnerabilities(cfg, store, nil, func(_ Input, f Finding) { reported = append(reported, f) })
+	require.NoError(t, err)
+
+	decision, err := evaluator.Evaluate(context.Background(), load)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, []string{"GHSA-crit (critical) affects pkg:cargo/serde@1.0.100"}, decision.Reasons)
+
+	// Pushes, and versions without an SBOM, are not checked
+	decision, err = evaluator.Evaluate(context.Background(), Input{Stage: StagePush, Namespace: "ns", Name: "fn"})
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	decision, err = evaluator.Evaluate(context.Background(), Input{Stage: StageLoad, Namespace: "ns", Name: "other"})
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Empty(t, reported)
+
+	// Overrides accept an advisory by alias and are reported, like every finding in warn mode
+	cfg.Severity = "low"
+	cfg.Overrides = []config.VulnerabilityOverride{{Advisory: "CVE-2024-0001", Function: "ns/*", Reason: "not reachable"}}
+	evaluator, err = NewVulnerabilities(cfg, store, nil, func(_ Input, f Finding) { reported = append(reported, f) })
+	require.NoError(t, err)
+	decision, err = evaluator.Evaluate(context.Background(), load)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, []string{"GO-low (low) affects pkg:golang/golang.org/x/text@v0.3.0"}, decision.Reasons)
+	require.Len(t, reported, 1)
+	assert.Equal(t, "CVE-2024-0001", reported[0].Advisory)
+	assert.Equal(t, "not reachable", reported[0].Override.Reason)
+
+	cfg.Mode = "warn"
+	reported = nil
+	evaluator, err = NewVulnerabilities(cfg, store, nil, func(_ Input, f Finding) { reported = append(reported, f) })
+	require.NoError(t, err)
+	decision, err = evaluator.Evaluate(context.Background(), load)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Len(t, reported, 2)
+}
+
+func TestOSV(t *testing.T) {
+	queries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		assert.Equal(t, "/v1/query", r.URL.Path)
+		var query struct {
+			Package struct {
+				PURL string `json:"purl"`
+			} `json:"package"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&query))
+		assert.Equal(t, "pkg:npm/%40scope/pkg@1.0.0", query.Package.PURL)
+		w.Write([]byte(`{"vulns": [{"id": "GHSA-npm", "database_specific": {"severity": "HIGH"}}]}`))
+	}))
+	defer server.Close()
+
+	osv := NewOSV(server.URL, nil, time.Second)
+	for range 2 {
+		found, err := osv.Advisories(context.Background(), "pkg:npm/%40scope/pkg@1.0.0")
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "high", found[0].Rating())
+	}
+	assert.Equal(t, 1, queries)
+}
+
+func TestCVSS3Score(t *testing.T) {
+	for vector, want := range map[string]float64{
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H": 9.8,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H": 10,
+		"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:L/I:L/A:N": 5.4,
+		"CVSS:3.0/AV:L/AC:L/PR:N/UI:R/S:U/C:N/I:N/A:N": 0,
+	} {
+		score, ok := cvss3Score(vector)
+		require.True(t, ok, vector)
+		assert.Equal(t, want, score, vector)
+	}
+	_, ok := cvss3Score("CVSS:2.0/AV:N")
+	assert.False(t, ok)
+}
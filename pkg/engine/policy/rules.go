@@ -0,0 +1,108 @@
+package policy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"path"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// Rules is the built-in evaluator, checking versions against the rules of the engine config.
+type Rules struct {
+	cfg  config.PolicyRulesConfig
+	keys []ed25519.PublicKey
+}
+
+// NewRules compiles the configured rules, or returns nil when none is set.
+func NewRules(cfg config.PolicyRulesConfig) (*Rules, error) {
+	if len(cfg.AllowedNamespaces) == 0 && len(cfg.RequiredLabels) == 0 && cfg.MaxMemoryMB <= 0 &&
+		len(cfg.AllowedURLs) == 0 && !cfg.RequireSignature {
+		return nil, nil
+	}
+
+	for _, pattern := range append(append([]string{}, cfg.AllowedNamespaces...), cfg.AllowedURLs...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	rules := &Rules{cfg: cfg}
+	for i, encoded := range cfg.SignatureKeys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("signature key %d is not a base64 ed25519 public key", i+1)
+		}
+		rules.keys = append(rules.keys, ed25519.PublicKey(key))
+	}
+	return rules, nil
+}
+
+// Evaluate checks every rule and denies the version with the reason of each one it breaks.
+func (r *Rules) Evaluate(_ context.Context, input Input) (Decision, error) {
+	var reasons []string
+
+	if len(r.cfg.AllowedNamespaces) > 0 && !matchAny(r.cfg.AllowedNamespaces, input.Namespace) {
+		reasons = append(reasons, fmt.Sprintf("namespace %s is not allowed", input.Namespace))
+	}
+
+	for _, label := range r.cfg.RequiredLabels {
+		if input.Settings.Labels[label] == "" {
+			reasons = append(reasons, fmt.Sprintf("required label %s is missing", label))
+		}
+	}
+
+	if limit := uint64(r.cfg.MaxMemoryMB) << 20; r.cfg.MaxMemoryMB > 0 && input.Module != nil {
+		if size := input.Module.MinMemoryPages * registry.MemoryPageSize; size > limit {
+			reasons = append(reasons, fmt.Sprintf("initial memory of %d MiB exceeds the limit of %d MiB", size>>20, r.cfg.MaxMemoryMB))
+		}
+		if size := input.Module.MaxMemoryPages * registry.MemoryPageSize; size > limit {
+			reasons = append(reasons, fmt.Sprintf("maximum memory of %d MiB exceeds the limit of %d MiB", size>>20, r.cfg.MaxMemoryMB))
+		}
+	}
+
+	if len(r.cfg.AllowedURLs) > 0 {
+		for _, url := range input.Settings.AllowedUrls {
+			if !matchAny(r.cfg.AllowedURLs, url) {
+				reasons = append(reasons, fmt.Sprintf("allowed URL %s is not in the policy allowlist", url))
+			}
+		}
+	}
+
+	if r.cfg.RequireSignature {
+		if reason := r.checkSignature(input); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	return Decision{Allowed: len(reasons) == 0, Reasons: reasons}, nil
+}
+
+// checkSignature returns why the version's signature is not trusted, or "" when it is.
+func (r *Rules) checkSignature(input Input) string {
+	if input.Settings.Signature == "" {
+		return "module is not signed"
+	}
+	signature, err := base64.StdEncoding.DecodeString(input.Settings.Signature)
+	if err != nil {
+		return "signature is not valid base64"
+	}
+	for _, key := range r.keys {
+		if ed25519.Verify(key, []byte(input.Digest), signature) {
+			return ""
+		}
+	}
+	return "signature does not match any trusted key"
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// severityRanks orders the severities of advisories.
+var severityRanks = map[string]int{"low": 1, "moderate": 2, "high": 3, "critical": 4}
+
+// Finding is an advisory affecting a package a version was built from.
+type Finding struct {
+	Advisory string
+	Summary  string
+	Severity string
+
+	// Package URL of the affected package
+	Package string
+
+	// Override accepting the advisory for the function, nil when none applies
+	Override *config.VulnerabilityOverride
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s (%s) affects %s", f.Advisory, f.Severity, f.Package)
+}
+
+// Vulnerabilities is the evaluator checking the SBOM of a version against advisories
+// before it is loaded. Versions without an SBOM are admitted.
+type Vulnerabilities struct {
+	cfg    config.VulnerabilityPolicyConfig
+	sboms  registry.SBOMStore
+	source AdvisorySource
+
+	// Told about the findings that do not deny a version: those accepted by an override
+	// and, in warn mode, every other one
+	report func(input Input, finding Finding)
+}
+
+// NewVulnerabilities creates the evaluator of the configured vulnerability policy, reading
+// advisories from the advisory database or else from the OSV API through transport.
+func NewVulnerabilities(cfg config.VulnerabilityPolicyConfig, sboms registry.SBOMStore,
+	transport http.RoundTripper, report func(Input, Finding)) (*Vulnerabilities, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	for _, override := range cfg.Overrides {
+		if _, err := path.Match(override.Function, ""); err != nil {
+			return nil, fmt.Errorf("invalid function pattern %q: %w", override.Function, err)
+		}
+	}
+
+	if cfg.Severity == "" {
+		cfg.Severity = "critical"
+	}
+
+	v := &Vulnerabilities{cfg: cfg, sboms: sboms, report: report}
+	if cfg.AdvisoryDB != "" {
+		db, err := LoadAdvisoryDB(cfg.AdvisoryDB)
+		if err != nil {
+			return nil, err
+		}
+		v.source = db
+	} else {
+		v.source = NewOSV(cfg.OSVURL, transport, cfg.Timeout)
+	}
+	return v, nil
+}
+
+// Evaluate denies a version in enforce mode when an advisory at or above the configured
+// severity affects one of its packages and no override accepts it.
+func (v *Vulnerabilities) Evaluate(ctx context.Context, input Input) (Decision, error) {
+	if input.Stage != StageLoad {
+		return Decision{Allowed: true}, nil
+	}
+
+	sbom, err := v.sboms.SBOM(input.Namespace, input.Name, input.Digest)
+	if errors.Is(err, registry.ErrSBOMNotFound) {
+		return Decision{Allowed: true}, nil
+	}
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to read SBOM: %w", err)
+	}
+
+	findings, err := v.findings(ctx, sbom)
+	if err != nil {
+		if v.cfg.FailOpen {
+			return Decision{Allowed: true}, nil
+		}
+		return Decision{}, err
+	}
+
+	var reasons []string
+	for _, finding := range findings {
+		finding.Override = v.override(input, finding.Advisory)
+		if finding.Override == nil && v.cfg.Mode == "enforce" {
+			reasons = append(reasons, finding.String())
+			continue
+		}
+		if v.report != nil {
+			v.report(input, finding)
+		}
+	}
+	return Decision{Allowed: len(reasons) == 0, Reasons: reasons}, nil
+}
+
+// findings returns the advisories at or above the configured severity affecting the
+// components of an SBOM. Advisories are named by the first of their names an override
+// lists, so that overrides may use either IDs or aliases.
+func (v *Vulnerabilities) findings(ctx context.Context, sbom *registry.SBOM) ([]Finding, error) {
+	threshold := severityRanks[v.cfg.Severity]
+	var findings []Finding
+	for _, component := range sbom.Components {
+		if component.PURL == "" {
+			continue
+		}
+		advisories, err := v.source.Advisories(ctx, component.PURL)
+		if err != nil {
+			return nil, err
+		}
+		for _, advisory := range advisories {
+			severity := advisory.Rating()
+			if severityRanks[severity] < threshold {
+				continue
+			}
+			findings = append(findings, Finding{
+				Advisory: v.advisoryName(advisory),
+				Summary:  advisory.Summary,
+				Severity: severity,
+				Package:  component.PURL,
+			})
+		}
+	}
+	return findings, nil
+}
+
+func (v *Vulnerabilities) advisoryName(advisory Advisory) string {
+	for _, name := range advisory.Names() {
+		for _, override := range v.cfg.Overrides {
+			if override.Advisory == name {
+				return name
+			}
+		}
+	}
+	return advisory.ID
+}
+
+// override returns the override accepting an advisory for the function, if any.
+func (v *Vulnerabilities) override(input Input, advisory string) *config.VulnerabilityOverride {
+	function := input.Namespace + "/" + input.Name
+	for i, override := range v.cfg.Overrides {
+		if override.Advisory != advisory {
+			continue
+		}
+		if matched, _ := path.Match(override.Function, function); override.Function == "" || matched {
+			return &v.cfg.Overrides[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+)
+
+const defaultWebhookTimeout = 5 * time.Second
+
+// Webhook is an external evaluator: it posts the Input as JSON and reads a Decision back.
+type Webhook struct {
+	cfg    config.PolicyWebhookConfig
+	client *http.Client
+}
+
+// NewWebhook creates an evaluator for the configured webhook.
+func NewWebhook(cfg config.PolicyWebhookConfig) *Webhook {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &Webhook{cfg: cfg, client: &http.Client{Timeout: timeout}}
+}
+
+// Evaluate asks the webhook for a decision. When it fails, the version is admitted if
+// the webhook fails open, and the error is returned otherwise.
+func (w *Webhook) Evaluate(ctx context.Context, input Input) (Decision, error) {
+	decision, err := w.ask(ctx, input)
+	if err != nil {
+		if w.cfg.FailOpen {
+			return Decision{Allowed: true}, nil
+		}
+		return Decision{}, fmt.Errorf("policy webhook: %w", err)
+	}
+	return decision, nil
+}
+
+func (w *Webhook) ask(ctx context.Context, input Input) (Decision, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range w.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return Decision{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Decision{}, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var decision Decision
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return Decision{}, fmt.Errorf("invalid decision: %w", err)
+	}
+	return decision, nil
+}
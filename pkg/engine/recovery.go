@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+// PanicStats counts the panics the engine recovered from instead of crashing.
+type PanicStats struct {
+	// Panics in API handlers, on the socket and HTTP endpoints
+	Handlers int64 `json:"handlers"`
+
+	// Panics during function calls, including host functions called by functions
+	Calls int64 `json:"calls"`
+}
+
+// panicCounters are shared by the handlers and the executor, which count separately.
+type panicCounters struct {
+	handlers atomic.Int64
+	calls    atomic.Int64
+}
+
+func (c *panicCounters) stats() PanicStats {
+	return PanicStats{Handlers: c.handlers.Load(), Calls: c.calls.Load()}
+}
+
+// Panics returns how many panics the engine has recovered from since it started.
+func (e *Engine) Panics() PanicStats {
+	return e.panics.stats()
+}
+
+// recordCallPanic logs a panic during a call of the function, with its stack trace,
+// to the function's logs and counts it.
+func recordCallPanic(counters *panicCounters, logStore logging.LogStore, functionKey FunctionKey, where string, r interface{}) {
+	counters.calls.Add(1)
+	logStore.AddLog(functionKey, logging.LevelError, fmt.Sprintf("%s panicked: %v\n%s", where, r, debug.Stack()))
+}
+
+// recoveryMiddleware turns a panic in a handler into a 500 response, so a bug in one
+// request cannot take the engine down. The stack trace goes to the engine log.
+func (h *Handlers) recoveryMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					h.engine.panics.handlers.Add(1)
+					h.logger.Errorf("Panic handling %s %s: %v\n%s", r.Method, r.URL.Path, p, debug.Stack())
+					err = domainerrors.New(domainerrors.DomainEngine, domainerrors.CodeInternalError,
+						"Internal error while handling the request")
+				}
+			}()
+			return next(w, r)
+		}
+	}
+}
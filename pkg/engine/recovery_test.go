@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	engine, tmpDir := setupTestEngine(t)
+	defer cleanupTest(tmpDir)
+
+	handlers := NewHandlers(engine, engine.logger)
+	handler := handlers.withMiddleware(func(http.ResponseWriter, *http.Request) error {
+		panic("handler bug")
+	}, handlers.errorMiddleware())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/load", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "engine", body["domain"])
+	assert.Equal(t, "internal_error", body["code"])
+
+	// The engine keeps serving, and the panic is counted
+	assert.Equal(t, PanicStats{Handlers: 1}, engine.Panics())
+
+	rec = httptest.NewRecorder()
+	handlers.HTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRecordCallPanicLogsStack(t *testing.T) {
+	engine, tmpDir := setupTestEngine(t)
+	defer cleanupTest(tmpDir)
+
+	key := GetFunctionKey("ns", "fn")
+	recordCallPanic(engine.panics, engine.logStore, key, "Call to run", "boom")
+
+	assert.Equal(t, int64(1), engine.Panics().Calls)
+	logs := engine.logStore.GetLogs(key, time.Time{}, 0)
+	require.NotEmpty(t, logs)
+	assert.Contains(t, logs[len(logs)-1], "Call to run panicked: boom")
+	assert.Contains(t, logs[len(logs)-1], "goroutine")
+}
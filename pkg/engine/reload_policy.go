@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// SetReloadPolicy sets which version is loaded when the function is reloaded
+// automatically, for example on the first call after it was evicted for being idle.
+func (e *Engine) SetReloadPolicy(namespace, name string, policy types.ReloadPolicy) {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	if e.reloadPolicies == nil {
+		e.reloadPolicies = make(map[FunctionKey]types.ReloadPolicy)
+	}
+	key := GetFunctionKey(namespace, name)
+	if policy.Kind == "" || policy.Kind == types.ReloadLatest {
+		delete(e.reloadPolicies, key)
+		return
+	}
+	e.reloadPolicies[key] = policy
+}
+
+// ReloadPolicy returns the reload policy of a function, "latest" unless one was set.
+func (e *Engine) ReloadPolicy(namespace, name string) types.ReloadPolicy {
+	e.reloadMu.RLock()
+	defer e.reloadMu.RUnlock()
+
+	if policy, ok := e.reloadPolicies[GetFunctionKey(namespace, name)]; ok {
+		return policy
+	}
+	return types.ReloadPolicy{Kind: types.ReloadLatest}
+}
+
+// resolveReloadVersion picks the tag or digest to reload a function with according
+// to its reload policy. It fails rather than falling back to another version when
+// nothing in the registry satisfies the policy.
+func (e *Engine) resolveReloadVersion(namespace, name string, metadata *registry.FunctionMetadata) (string, error) {
+	key := GetFunctionKey(namespace, name)
+	policy := e.ReloadPolicy(namespace, name)
+	previousDigest, _ := e.pluginManager.GetPluginDigest(key)
+
+	var identifier, digest string
+	switch policy.Kind {
+	case types.ReloadPinned:
+		version := findVersionByDigest(metadata, previousDigest)
+		if previousDigest == "" || version == nil {
+			return "", NewNotFoundError("Pinned version of the function is no longer in the registry")
+		}
+		identifier, digest = version.Hash, version.FullDigest
+
+	case types.ReloadTag:
+		version := findVersionByTag(metadata, policy.Tag)
+		if version == nil {
+			return "", NewNotFoundError(fmt.Sprintf("Tag %q required by the reload policy does not exist", policy.Tag))
+		}
+		identifier, digest = policy.Tag, version.FullDigest
+
+	case types.ReloadSemver:
+		var tags []string
+		for _, version := range metadata.Versions {
+			tags = append(tags, version.Tags...)
+		}
+		tag, ok := policy.HighestMatch(tags)
+		if !ok {
+			return "", NewNotFoundError(fmt.Sprintf("No tag matches the reload policy range %q", policy.Range))
+		}
+		identifier, digest = tag, findVersionByTag(metadata, tag).FullDigest
+
+	default:
+		if version := findVersionByTag(metadata, "latest"); version != nil {
+			identifier, digest = "latest", version.FullDigest
+		} else if len(metadata.Versions) > 0 {
+			// Versions are sorted with the most recent first
+			identifier, digest = metadata.Versions[0].Hash, metadata.Versions[0].FullDigest
+		}
+	}
+
+	if previousDigest != "" && digest != previousDigest {
+		e.logger.Printf("Reloading %s/%s at %s (policy %s) changes its version from %s to %s",
+			namespace, name, identifier, policy, registry.TruncateDigest(previousDigest, 12), registry.TruncateDigest(digest, 12))
+	}
+	return identifier, nil
+}
+
+func findVersionByTag(metadata *registry.FunctionMetadata, tag string) *registry.VersionInfo {
+	for i, version := range metadata.Versions {
+		for _, t := range version.Tags {
+			if t == tag {
+				return &metadata.Versions[i]
+			}
+		}
+	}
+	return nil
+}
+
+func findVersionByDigest(metadata *registry.FunctionMetadata, digest string) *registry.VersionInfo {
+	for i, version := range metadata.Versions {
+		if version.FullDigest == digest {
+			return &metadata.Versions[i]
+		}
+	}
+	return nil
+}
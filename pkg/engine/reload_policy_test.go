@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReloadPolicy(t *testing.T) {
+	tags := []string{"latest", "v1.1.9", "1.2.0", "1.2.7", "1.3.0", "1.3.1-rc.1", "2.0.0", "stable"}
+
+	tests := []struct {
+		policy string
+		kind   string
+		match  string
+	}{
+		{"", types.ReloadLatest, ""},
+		{"pinned", types.ReloadPinned, ""},
+		{"tag:stable", types.ReloadTag, ""},
+		{"~1.2", types.ReloadSemver, "1.2.7"},
+		{"semver:~1.2.3", types.ReloadSemver, "1.2.7"},
+		{"~3", types.ReloadSemver, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			policy, err := types.ParseReloadPolicy(tt.policy)
+			require.NoError(t, err)
+			assert.Equal(t, tt.kind, policy.Kind)
+
+			if tt.kind == types.ReloadSemver {
+				match, ok := policy.HighestMatch(tags)
+				assert.Equal(t, tt.match != "", ok)
+				assert.Equal(t, tt.match, match)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"tag:", "newest", "~1.a"} {
+		_, err := types.ParseReloadPolicy(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestResolveReloadVersion(t *testing.T) {
+	engine, dir := setupTestEngine(t)
+	defer cleanupTest(dir)
+
+	metadata := &registry.FunctionMetadata{
+		Versions: []registry.VersionInfo{
+			{Hash: "ccc", FullDigest: "ccc-full", Tags: []string{"1.3.0", "latest"}},
+			{Hash: "bbb", FullDigest: "bbb-full", Tags: []string{"1.2.4", "stable"}},
+			{Hash: "aaa", FullDigest: "aaa-full", Tags: []string{"1.2.1"}},
+		},
+	}
+
+	resolve := func(policy string) (string, error) {
+		parsed, err := types.ParseReloadPolicy(policy)
+		require.NoError(t, err)
+		engine.SetReloadPolicy("ns", "fn", parsed)
+		return engine.resolveReloadVersion("ns", "fn", metadata)
+	}
+
+	identifier, err := resolve("latest")
+	require.NoError(t, err)
+	assert.Equal(t, "latest", identifier)
+
+	identifier, err = resolve("tag:stable")
+	require.NoError(t, err)
+	assert.Equal(t, "stable", identifier)
+
+	identifier, err = resolve("~1.2")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.4", identifier)
+
+	// Nothing satisfying the policy is an error rather than a jump to another version
+	_, err = resolve("tag:beta")
+	assert.ErrorContains(t, err, "does not exist")
+	_, err = resolve("^2.0")
+	assert.ErrorContains(t, err, "No tag matches")
+	_, err = resolve("pinned")
+	assert.ErrorContains(t, err, "no longer in the registry")
+
+	engine.SetReloadPolicy("ns", "fn", types.ReloadPolicy{Kind: types.ReloadLatest})
+	assert.Equal(t, types.ReloadLatest, engine.ReloadPolicy("ns", "fn").Kind)
+}
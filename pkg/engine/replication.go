@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ignitionstack/ignition/pkg/engine/replication"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// checkReplicationTarget rejects a target directory that is the registry itself.
+func checkReplicationTarget(registryDir, target string) error {
+	if replication.IsRemote(target) {
+		return nil
+	}
+
+	registryPath, err := filepath.Abs(registryDir)
+	if err != nil {
+		return err
+	}
+	targetPath, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	if registryPath == targetPath {
+		return fmt.Errorf("replication target %s is the registry directory", target)
+	}
+	return nil
+}
+
+// openReplicationTarget opens a replication target. Replicas in directories are
+// encrypted with the key of the registry.
+func (e *Engine) openReplicationTarget(target, token string) (replication.Target, error) {
+	if err := checkReplicationTarget(e.registryDir, target); err != nil {
+		return nil, err
+	}
+	return replication.OpenTarget(target, token, e.registryCipher, e.transport)
+}
+
+// SyncRegistry copies to target the versions and tags of the registry it is missing.
+// An empty target syncs the configured replication target.
+func (e *Engine) SyncRegistry(ctx context.Context, target, token string) (types.RegistrySyncReport, error) {
+	configured := e.options.Replication
+	if target == "" {
+		if configured.Target == "" {
+			return types.RegistrySyncReport{}, NewBadRequestError("No sync target given and registry replication is not configured")
+		}
+		target = configured.Target
+	}
+	if target == configured.Target && token == "" {
+		token = configured.Token
+	}
+
+	// The configured target is already open while the engine replicates to it
+	var replica replication.Target
+	if e.replicator != nil && target == configured.Target && e.replicator.Target() != nil {
+		replica = e.replicator.Target()
+	} else {
+		opened, err := e.openReplicationTarget(target, token)
+		if err != nil {
+			return types.RegistrySyncReport{}, NewBadRequestError(fmt.Sprintf("Cannot open sync target %s: %v", target, err))
+		}
+		defer opened.Close()
+		replica = opened
+	}
+
+	report, err := replication.Sync(ctx, e.registry, replica)
+	report.Target = target
+	return report, err
+}
+
+// ReplicationStats returns the replication counters, or nil when replication is disabled.
+func (e *Engine) ReplicationStats() *replication.Stats {
+	if e.replicator == nil {
+		return nil
+	}
+	stats := e.replicator.Stats()
+	return &stats
+}
@@ -0,0 +1,228 @@
+// Package replication copies registry writes to a secondary registry, another registry
+// directory or a remote engine, so it can take over when the primary registry is lost.
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+const (
+	initialRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = 30 * time.Second
+)
+
+// Replicator applies registry writes to a target in the background, in the order they
+// were committed. Writes that cannot be queued or applied are logged and left to Sync.
+type Replicator struct {
+	target     Target
+	queue      chan registry.WriteEvent
+	maxRetries int
+	backoff    time.Duration
+	logger     logging.Logger
+
+	dropped atomic.Int64
+	failed  atomic.Int64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// New creates a replicator. Writes are queued from now on and applied once Start is called.
+func New(cfg config.ReplicationConfig, logger logging.Logger) *Replicator {
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+
+	return &Replicator{
+		queue:      make(chan registry.WriteEvent, queueSize),
+		maxRetries: cfg.MaxRetries,
+		backoff:    initialRetryBackoff,
+		logger:     logger,
+		stop:       make(chan struct{}),
+	}
+}
+
+// Target returns the target writes are replicated to, or nil before Start.
+func (r *Replicator) Target() Target {
+	return r.target
+}
+
+// Enqueue queues a write for replication without blocking, dropping it when the queue is
+// full. It is meant to be the write observer of the source registry.
+func (r *Replicator) Enqueue(event registry.WriteEvent) {
+	select {
+	case r.queue <- event:
+	default:
+		r.dropped.Add(1)
+		r.logger.Errorf("Replication queue is full, dropped %s of %s/%s", event.Op, event.Namespace, event.Name)
+	}
+}
+
+// Start replicates queued writes from source to target until ctx is done or the
+// replicator is closed. The replicator takes ownership of target.
+func (r *Replicator) Start(ctx context.Context, source registry.Registry, target Target) {
+	r.target = target
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.stop:
+				return
+			case event := <-r.queue:
+				r.replicate(ctx, source, event)
+			}
+		}
+	}()
+}
+
+// Close stops replication and closes the target. Writes still queued are not replicated.
+func (r *Replicator) Close() error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	r.wg.Wait()
+
+	if pending := len(r.queue); pending > 0 {
+		r.logger.Errorf("%d registry writes were not replicated, run `ignition registry sync` to copy them", pending)
+	}
+	if r.target == nil {
+		return nil
+	}
+	return r.target.Close()
+}
+
+// Stats counts the writes waiting in the queue, and those dropped from a full queue or
+// given up after every retry since the engine started.
+type Stats struct {
+	Pending int   `json:"pending"`
+	Dropped int64 `json:"dropped"`
+	Failed  int64 `json:"failed"`
+}
+
+// Stats returns the replication counters.
+func (r *Replicator) Stats() Stats {
+	return Stats{Pending: len(r.queue), Dropped: r.dropped.Load(), Failed: r.failed.Load()}
+}
+
+// replicate applies a write to the target, retrying with backoff until it succeeds,
+// attempts run out or the replicator stops.
+func (r *Replicator) replicate(ctx context.Context, source registry.Registry, event registry.WriteEvent) {
+	backoff := r.backoff
+	for attempt := 0; ; attempt++ {
+		err := apply(source, r.target, event)
+		if err == nil {
+			return
+		}
+		if attempt >= r.maxRetries {
+			r.failed.Add(1)
+			r.logger.Errorf("Failed to replicate %s of %s/%s: %v", event.Op, event.Namespace, event.Name, err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// apply copies a single write from source to target.
+func apply(source registry.Registry, target Target, event registry.WriteEvent) error {
+	switch event.Op {
+	case registry.WritePush:
+		return copyVersion(source, target, event.Namespace, event.Name, event.Digest, event.Tag)
+	case registry.WriteTag:
+		err := target.ReassignTag(event.Namespace, event.Name, event.Tag, event.Digest)
+		if errors.Is(err, registry.ErrDigestNotFound) || errors.Is(err, registry.ErrFunctionNotFound) {
+			// The version never made it to the target, so it is copied along with the tag
+			return copyVersion(source, target, event.Namespace, event.Name, event.Digest, event.Tag)
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown registry write %q", event.Op)
+	}
+}
+
+// copyVersion pushes the version of source identified by digest to target.
+func copyVersion(source registry.Registry, target Target, namespace, name, digest, tag string) error {
+	payload, version, err := source.Pull(namespace, name, registry.TruncateDigest(digest, 12))
+	if err != nil {
+		return fmt.Errorf("failed to read version from source: %w", err)
+	}
+	return target.Push(namespace, name, payload, version.FullDigest, tag, version.Settings)
+}
+
+// Sync copies to target the versions of source it is missing and moves its tags to
+// match source. Failures on a function are reported and do not stop the sync.
+func Sync(ctx context.Context, source registry.Registry, target Target) (types.RegistrySyncReport, error) {
+	var report types.RegistrySyncReport
+
+	functions, err := source.ListAll()
+	if err != nil {
+		return report, fmt.Errorf("failed to list source registry: %w", err)
+	}
+	existing, err := target.ListAll()
+	if err != nil {
+		return report, fmt.Errorf("failed to list target registry: %w", err)
+	}
+
+	// Tags of each version the target already has, by function
+	stored := make(map[string]map[string][]string, len(existing))
+	for _, function := range existing {
+		versions := make(map[string][]string, len(function.Versions))
+		for _, version := range function.Versions {
+			versions[version.Hash] = version.Tags
+		}
+		stored[function.Namespace+"/"+function.Name] = versions
+	}
+
+	for _, function := range functions {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		id := function.Namespace + "/" + function.Name
+		versions := stored[id]
+		for _, version := range function.Versions {
+			tags, ok := versions[version.Hash]
+			if !ok {
+				if err := copyVersion(source, target, function.Namespace, function.Name, version.FullDigest, ""); err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("%s@%s: %v", id, version.Hash, err))
+					continue
+				}
+				report.VersionsCopied++
+			}
+
+			for _, tag := range version.Tags {
+				if registry.HasTag(tags, tag) {
+					continue
+				}
+				if err := target.ReassignTag(function.Namespace, function.Name, tag, version.FullDigest); err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("%s:%s: %v", id, tag, err))
+					continue
+				}
+				report.TagsUpdated++
+			}
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,147 @@
+package replication
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	localRegistry "github.com/ignitionstack/ignition/pkg/registry/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openDirectory(t *testing.T, opts ...localRegistry.Option) registry.Registry {
+	dir := t.TempDir()
+	db, err := localRegistry.OpenDatabase(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return localRegistry.NewLocalRegistry(dir, db, opts...)
+}
+
+func openTarget(t *testing.T) Target {
+	target, err := OpenTarget(t.TempDir(), "", nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { target.Close() })
+	return target
+}
+
+// taggedDigest returns the full digest of the version of a function holding tag.
+func taggedDigest(t *testing.T, target Target, namespace, name, tag string) string {
+	functions, err := target.ListAll()
+	require.NoError(t, err)
+	for _, function := range functions {
+		if function.Namespace != namespace || function.Name != name {
+			continue
+		}
+		for _, version := range function.Versions {
+			if registry.HasTag(version.Tags, tag) {
+				return version.FullDigest
+			}
+		}
+	}
+	return ""
+}
+
+func TestReplicator(t *testing.T) {
+	replicator := New(config.ReplicationConfig{QueueSize: 10, MaxRetries: 1}, logging.NewStdLogger(io.Discard))
+	source := openDirectory(t, localRegistry.WithWriteObserver(replicator.Enqueue))
+	target := openTarget(t)
+
+	settings := manifest.FunctionVersionSettings{Wasi: true}
+	require.NoError(t, source.Push("ns", "fn", []byte("v1"), "digest-one-full", "latest", settings))
+	require.NoError(t, source.Push("ns", "fn", []byte("v2"), "digest-two-full", "", settings))
+	require.NoError(t, source.ReassignTag("ns", "fn", "stable", "digest-two-full"))
+	require.NoError(t, source.ReassignTag("ns", "fn", "latest", "digest-two-f"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	replicator.Start(ctx, source, target)
+
+	require.Eventually(t, func() bool {
+		return taggedDigest(t, target, "ns", "fn", "latest") == "digest-two-full"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "digest-two-full", taggedDigest(t, target, "ns", "fn", "stable"))
+
+	payload, version, err := target.(*directoryTarget).Pull("ns", "fn", "digest-one-f")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), payload)
+	assert.Equal(t, settings, version.Settings)
+
+	require.NoError(t, replicator.Close())
+	assert.Equal(t, Stats{}, replicator.Stats())
+}
+
+func TestReplicatorDropsWritesWhenQueueIsFull(t *testing.T) {
+	replicator := New(config.ReplicationConfig{QueueSize: 1}, logging.NewStdLogger(io.Discard))
+	replicator.Enqueue(registry.WriteEvent{Op: registry.WritePush, Namespace: "ns", Name: "a"})
+	replicator.Enqueue(registry.WriteEvent{Op: registry.WritePush, Namespace: "ns", Name: "b"})
+
+	assert.Equal(t, Stats{Pending: 1, Dropped: 1}, replicator.Stats())
+}
+
+func TestSync(t *testing.T) {
+	source := openDirectory(t)
+	target := openTarget(t)
+
+	require.NoError(t, source.Push("ns", "fn", []byte("v1"), "digest-one-full", "", manifest.FunctionVersionSettings{}))
+	require.NoError(t, source.Push("ns", "fn", []byte("v2"), "digest-two-full", "latest", manifest.FunctionVersionSettings{}))
+	require.NoError(t, source.Push("other", "fn", []byte("v1"), "digest-one-full", "v1", manifest.FunctionVersionSettings{}))
+
+	// The target already has a version, with a tag the source has moved on from
+	require.NoError(t, target.Push("ns", "fn", []byte("v1"), "digest-one-full", "latest", manifest.FunctionVersionSettings{}))
+
+	report, err := Sync(context.Background(), source, target)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.VersionsCopied)
+	assert.Equal(t, 2, report.TagsUpdated)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, "digest-two-full", taggedDigest(t, target, "ns", "fn", "latest"))
+	assert.Equal(t, "digest-one-full", taggedDigest(t, target, "other", "fn", "v1"))
+
+	// A second sync has nothing left to copy
+	report, err = Sync(context.Background(), source, target)
+	require.NoError(t, err)
+	assert.Zero(t, report.VersionsCopied)
+	assert.Zero(t, report.TagsUpdated)
+}
+
+func TestRemoteTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/list":
+			_, _ = w.Write([]byte(`[{"namespace":"ns","name":"fn","versions":[{"hash":"abc","full_digest":"abcdef"}]}]`))
+		case "/reassign-tag":
+			http.Error(w, "digest not found", http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	target, err := OpenTarget(server.URL+"/", "secret", nil, nil)
+	require.NoError(t, err)
+	defer target.Close()
+
+	functions, err := target.ListAll()
+	require.NoError(t, err)
+	require.Len(t, functions, 1)
+	assert.Equal(t, "abcdef", functions[0].Versions[0].FullDigest)
+
+	assert.NoError(t, target.Push("ns", "fn", []byte("wasm"), "abcdef", "", manifest.FunctionVersionSettings{}))
+	assert.ErrorIs(t, target.ReassignTag("ns", "fn", "latest", "abcdef"), registry.ErrDigestNotFound)
+
+	unauthorized, err := OpenTarget(server.URL, "wrong", nil, nil)
+	require.NoError(t, err)
+	assert.ErrorContains(t, unauthorized.Push("ns", "fn", []byte("wasm"), "abcdef", "", manifest.FunctionVersionSettings{}), "401")
+}
@@ -0,0 +1,169 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/internal/repository"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	localRegistry "github.com/ignitionstack/ignition/pkg/registry/local"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// remoteTimeout bounds each request to a remote engine, module uploads included
+const remoteTimeout = 2 * time.Minute
+
+// Target is a secondary registry that receives copies of registry writes.
+type Target interface {
+	// ListAll returns the metadata of every function stored in the target.
+	ListAll() ([]registry.FunctionMetadata, error)
+
+	// Push stores a version, moving tag to it when set.
+	Push(namespace, name string, payload []byte, digest, tag string, settings manifest.FunctionVersionSettings) error
+
+	// ReassignTag moves tag to a stored version, failing with registry.ErrDigestNotFound
+	// when the target does not have it.
+	ReassignTag(namespace, name, tag, digest string) error
+
+	// Close releases the resources held by the target.
+	Close() error
+}
+
+// IsRemote reports whether spec names a remote engine rather than a directory.
+func IsRemote(spec string) bool {
+	return strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://")
+}
+
+// OpenTarget opens the target named by spec: the http(s) URL of the admin API of another
+// engine, authenticated with token, or the directory of a secondary registry, encrypted
+// with cipher when it is not nil. Remote targets are reached through transport, or
+// http.DefaultTransport when it is nil.
+func OpenTarget(spec, token string, cipher *localRegistry.Cipher, transport http.RoundTripper) (Target, error) {
+	if IsRemote(spec) {
+		return &remoteTarget{
+			baseURL: strings.TrimSuffix(spec, "/"),
+			token:   token,
+			client:  &http.Client{Timeout: remoteTimeout, Transport: transport},
+		}, nil
+	}
+
+	db, err := localRegistry.OpenDatabase(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open registry at %s: %w", spec, err)
+	}
+
+	// Modules were validated by the source registry, so they are stored as is
+	var options []localRegistry.Option
+	if cipher != nil {
+		options = append(options, localRegistry.WithEncryption(cipher))
+	}
+	return &directoryTarget{
+		Registry: localRegistry.NewLocalRegistry(spec, db, options...),
+		db:       db,
+	}, nil
+}
+
+// directoryTarget replicates to a registry in another directory, such as a mounted volume.
+type directoryTarget struct {
+	registry.Registry
+	db repository.DBRepository
+}
+
+func (t *directoryTarget) Close() error {
+	return t.db.Close()
+}
+
+// remoteTarget replicates to another engine through its TCP admin API.
+type remoteTarget struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func (t *remoteTarget) ListAll() ([]registry.FunctionMetadata, error) {
+	var functions []registry.FunctionMetadata
+	if err := t.post("/list", types.FunctionRequest{}, &functions); err != nil {
+		return nil, err
+	}
+	return functions, nil
+}
+
+func (t *remoteTarget) Push(namespace, name string, payload []byte, digest, tag string, settings manifest.FunctionVersionSettings) error {
+	return t.post("/registry/push", types.PushRequest{
+		FunctionRequest: types.FunctionRequest{Namespace: namespace, Name: name},
+		Digest:          digest,
+		Tag:             tag,
+		Settings:        settings,
+		Payload:         payload,
+	}, nil)
+}
+
+func (t *remoteTarget) ReassignTag(namespace, name, tag, digest string) error {
+	err := t.post("/reassign-tag", types.ReassignTagRequest{
+		FunctionRequest: types.FunctionRequest{Namespace: namespace, Name: name},
+		Tag:             tag,
+		Digest:          digest,
+	}, nil)
+
+	// The remote engine answers 404 for both a missing function and a missing version
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound {
+		return fmt.Errorf("%w: %v", registry.ErrDigestNotFound, err)
+	}
+	return err
+}
+
+func (t *remoteTarget) Close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
+
+// statusError is an error answer of the remote engine.
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("remote engine answered %d: %s", e.code, e.message)
+}
+
+// post sends body as JSON to path and decodes the answer into out unless it is nil.
+func (t *remoteTarget) post(path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &statusError{code: resp.StatusCode, message: strings.TrimSpace(string(msg))}
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -6,6 +6,8 @@ import (
 	"log/slog"
 	"sync"
 	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/interfaces"
 )
 
 // Logger interface for logging.
@@ -74,9 +76,9 @@ func DefaultLimits() Limits {
 // Manager handles resource allocation and limiting.
 type Manager struct {
 	limits        Limits
-	executionSem  chan struct{}            // Semaphore for limiting concurrent executions
-	functionSems  map[string]chan struct{} // Per-function semaphores
-	functionSemMu sync.Mutex               // Mutex for the functionSems map
+	executionSem  chan struct{}                            // Semaphore for limiting concurrent executions
+	functionSems  map[interfaces.FunctionKey]chan struct{} // Per-function semaphores
+	functionSemMu sync.Mutex                               // Mutex for the functionSems map
 }
 
 // NewManager creates a new resource manager with the specified limits.
@@ -84,7 +86,7 @@ func NewManager(limits Limits) *Manager {
 	return &Manager{
 		limits:       limits,
 		executionSem: make(chan struct{}, limits.MaxConcurrentCalls),
-		functionSems: make(map[string]chan struct{}),
+		functionSems: make(map[interfaces.FunctionKey]chan struct{}),
 	}
 }
 
@@ -109,7 +111,7 @@ func (rm *Manager) ReleaseExecution() {
 }
 
 // AcquireFunctionExecution attempts to acquire a function-specific execution slot.
-func (rm *Manager) AcquireFunctionExecution(ctx context.Context, functionKey string) error {
+func (rm *Manager) AcquireFunctionExecution(ctx context.Context, functionKey interfaces.FunctionKey) error {
 	// Get or create the function semaphore
 	sem := rm.getFunctionSemaphore(functionKey)
 
@@ -123,7 +125,7 @@ func (rm *Manager) AcquireFunctionExecution(ctx context.Context, functionKey str
 }
 
 // ReleaseFunctionExecution releases a function-specific execution slot.
-func (rm *Manager) ReleaseFunctionExecution(functionKey string) {
+func (rm *Manager) ReleaseFunctionExecution(functionKey interfaces.FunctionKey) {
 	rm.functionSemMu.Lock()
 	sem, exists := rm.functionSems[functionKey]
 	rm.functionSemMu.Unlock()
@@ -143,7 +145,7 @@ func (rm *Manager) ReleaseFunctionExecution(functionKey string) {
 }
 
 // getFunctionSemaphore gets or creates a semaphore for a function.
-func (rm *Manager) getFunctionSemaphore(functionKey string) chan struct{} {
+func (rm *Manager) getFunctionSemaphore(functionKey interfaces.FunctionKey) chan struct{} {
 	rm.functionSemMu.Lock()
 	defer rm.functionSemMu.Unlock()
 
@@ -160,7 +162,7 @@ func (rm *Manager) getFunctionSemaphore(functionKey string) chan struct{} {
 // WithResourceLimits applies resource limits to an operation and executes it.
 func (rm *Manager) WithResourceLimits(
 	ctx context.Context,
-	functionKey string,
+	functionKey interfaces.FunctionKey,
 	operation func() (interface{}, error),
 ) (interface{}, error) {
 	// Acquire global execution slot
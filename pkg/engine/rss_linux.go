@@ -0,0 +1,28 @@
+//go:build linux
+
+package engine
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// residentMemory returns the resident set size of the process from /proc, falling back
+// to the memory obtained by the Go runtime.
+func residentMemory(stats *runtime.MemStats) int64 {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return int64(stats.Sys)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return int64(stats.Sys)
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return int64(stats.Sys)
+	}
+	return pages * int64(os.Getpagesize())
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package engine
+
+import "runtime"
+
+// residentMemory approximates the resident set size with the memory obtained by the Go
+// runtime, which excludes memory allocated outside it.
+func residentMemory(stats *runtime.MemStats) int64 {
+	return int64(stats.Sys)
+}
@@ -2,24 +2,40 @@ package engine
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/ipc"
 	"github.com/ignitionstack/ignition/pkg/engine/logging"
 )
 
 type Server struct {
 	socketPath   string
 	httpAddr     string
+	adminAddr    string
+	adminToken   string
 	handlers     *Handlers
 	logger       logging.Logger
 	httpServer   *http.Server
 	socketServer *http.Server
+	adminServer  *http.Server
+
+	// HTTP listeners scoped to some namespaces, and their servers once started
+	namespaceListeners []config.ListenerConfig
+	namespaceServers   []*http.Server
+
+	// Address serving the Prometheus metrics, and its server once started
+	metricsAddr   string
+	metricsServer *http.Server
 }
 
 func NewServer(socketPath, httpAddr string, handlers *Handlers, logger logging.Logger) *Server {
@@ -31,10 +47,43 @@ func NewServer(socketPath, httpAddr string, handlers *Handlers, logger logging.L
 	}
 }
 
+// WithAdminListener serves the admin API over TCP on addr in addition to (or instead of)
+// the Unix socket. Every request must carry the token as a bearer token.
+func (s *Server) WithAdminListener(addr, token string) *Server {
+	s.adminAddr = addr
+	s.adminToken = token
+	return s
+}
+
+// WithNamespaceListeners serves the function endpoint on additional addresses, each
+// limited to the functions of its namespaces.
+func (s *Server) WithNamespaceListeners(listeners []config.ListenerConfig) *Server {
+	s.namespaceListeners = listeners
+	return s
+}
+
+// WithMetricsListener serves GET /metrics on addr, apart from the function endpoints.
+func (s *Server) WithMetricsListener(addr string) *Server {
+	s.metricsAddr = addr
+	return s
+}
+
+// Start serves the HTTP endpoint unless its address is empty, the Unix socket unless
+// its path is empty, the TCP admin listener and the namespace listeners if configured,
+// until a shutdown signal arrives.
 func (s *Server) Start() error {
+	return s.Serve(context.Background())
+}
+
+// Serve is like Start, but also shuts the servers down when ctx is done.
+func (s *Server) Serve(ctx context.Context) error {
+	if s.adminAddr != "" && s.adminToken == "" {
+		return fmt.Errorf("the TCP admin listener requires an admin token")
+	}
+
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer func() {
 		signal.Stop(signalChan)
 		cancel()
@@ -49,64 +98,147 @@ func (s *Server) Start() error {
 		}
 	}()
 
-	// Check if socket is already in use before removing
-	if _, err := os.Stat(s.socketPath); err == nil {
-		// Socket file exists, let's check if it's active
-		conn, err := net.Dial("unix", s.socketPath)
-		if err == nil {
-			// Connection successful, socket is in use by another process
-			conn.Close()
-			return fmt.Errorf("socket %s is already in use by another process (possibly another ignition engine instance)", s.socketPath)
+	var socketListener, adminListener, httpListener, metricsListener net.Listener
+	var scopedListeners []net.Listener
+	closeListeners := func() {
+		for _, l := range append([]net.Listener{socketListener, adminListener, httpListener, metricsListener}, scopedListeners...) {
+			if l != nil {
+				l.Close()
+			}
 		}
-		// Socket file exists but no process is listening, safe to remove
-		if err := os.Remove(s.socketPath); err != nil {
-			return fmt.Errorf("failed to remove stale socket file: %w", err)
+	}
+
+	if s.socketPath != "" {
+		listener, err := ipc.Listen(s.socketPath)
+		if err != nil {
+			return err
 		}
-	} else if !os.IsNotExist(err) {
-		// Some other error occurred when checking the socket file
-		return fmt.Errorf("failed to check socket file status: %w", err)
+		socketListener = listener
 	}
 
-	socketListener, err := net.Listen("unix", s.socketPath)
-	if err != nil {
-		return fmt.Errorf("failed to start Unix socket listener: %w", err)
+	if s.adminAddr != "" {
+		listener, err := net.Listen("tcp", s.adminAddr)
+		if err != nil {
+			closeListeners()
+			return fmt.Errorf("failed to start admin listener: %w", err)
+		}
+		adminListener = listener
 	}
 
-	httpListener, err := net.Listen("tcp", s.httpAddr)
-	if err != nil {
-		socketListener.Close()
-		return fmt.Errorf("failed to start HTTP listener: %w", err)
+	if s.httpAddr != "" {
+		listener, err := net.Listen("tcp", s.httpAddr)
+		if err != nil {
+			closeListeners()
+			return fmt.Errorf("failed to start HTTP listener: %w", err)
+		}
+		httpListener = listener
 	}
 
-	s.httpServer = &http.Server{
-		Handler:      s.handlers.HTTPHandler(),
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
+	for _, scoped := range s.namespaceListeners {
+		listener, err := net.Listen("tcp", scoped.Addr)
+		if err != nil {
+			closeListeners()
+			return fmt.Errorf("failed to start HTTP listener for namespaces %s: %w", strings.Join(scoped.Namespaces, ", "), err)
+		}
+		scopedListeners = append(scopedListeners, listener)
 	}
 
-	s.socketServer = &http.Server{
-		Handler:      s.handlers.UnixSocketHandler(),
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
+	if s.metricsAddr != "" {
+		listener, err := net.Listen("tcp", s.metricsAddr)
+		if err != nil {
+			closeListeners()
+			return fmt.Errorf("failed to start metrics listener: %w", err)
+		}
+		metricsListener = listener
 	}
 
-	errChan := make(chan error, 2)
+	errChan := make(chan error, 4+len(scopedListeners))
 
-	go func() {
-		s.logger.Printf("Unix socket server listening on %s", s.socketPath)
-		if err := s.socketServer.Serve(socketListener); err != nil && err != http.ErrServerClosed {
-			errChan <- fmt.Errorf("unix socket server error: %w", err)
+	if socketListener != nil {
+		s.socketServer = &http.Server{
+			Handler:      s.handlers.UnixSocketHandler(),
+			ConnContext:  withPeerSource,
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  120 * time.Second,
 		}
-	}()
+		s.socketServer.RegisterOnShutdown(s.handlers.closeStreams)
 
-	go func() {
-		s.logger.Printf("HTTP server listening on %s", s.httpAddr)
-		if err := s.httpServer.Serve(httpListener); err != nil && err != http.ErrServerClosed {
-			errChan <- fmt.Errorf("http server error: %w", err)
+		go func() {
+			s.logger.Printf("Unix socket server listening on %s", s.socketPath)
+			if err := s.socketServer.Serve(socketListener); err != nil && err != http.ErrServerClosed {
+				errChan <- fmt.Errorf("unix socket server error: %w", err)
+			}
+		}()
+	}
+
+	if adminListener != nil {
+		s.adminServer = &http.Server{
+			Handler:      requireBearerToken(s.adminToken, s.handlers.UnixSocketHandler()),
+			ConnContext:  withPeerSource,
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  120 * time.Second,
+		}
+		s.adminServer.RegisterOnShutdown(s.handlers.closeStreams)
+
+		go func() {
+			s.logger.Printf("Admin server listening on %s", s.adminAddr)
+			if err := s.adminServer.Serve(adminListener); err != nil && err != http.ErrServerClosed {
+				errChan <- fmt.Errorf("admin server error: %w", err)
+			}
+		}()
+	}
+
+	if httpListener != nil {
+		s.httpServer = &http.Server{
+			Handler:      s.handlers.HTTPHandler(),
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  120 * time.Second,
 		}
-	}()
+
+		go func() {
+			s.logger.Printf("HTTP server listening on %s", s.httpAddr)
+			if err := s.httpServer.Serve(httpListener); err != nil && err != http.ErrServerClosed {
+				errChan <- fmt.Errorf("http server error: %w", err)
+			}
+		}()
+	}
+
+	for i, listener := range scopedListeners {
+		scoped := s.namespaceListeners[i]
+		server := &http.Server{
+			Handler:      s.handlers.NamespaceHTTPHandler(scoped.Namespaces),
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  120 * time.Second,
+		}
+		s.namespaceServers = append(s.namespaceServers, server)
+
+		go func() {
+			s.logger.Printf("HTTP server for namespaces %s listening on %s", strings.Join(scoped.Namespaces, ", "), scoped.Addr)
+			if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				errChan <- fmt.Errorf("http server for %s error: %w", scoped.Addr, err)
+			}
+		}()
+	}
+
+	if metricsListener != nil {
+		s.metricsServer = &http.Server{
+			Handler:      s.handlers.MetricsHandler(),
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  120 * time.Second,
+		}
+
+		go func() {
+			s.logger.Printf("Metrics server listening on %s", s.metricsAddr)
+			if err := s.metricsServer.Serve(metricsListener); err != nil && err != http.ErrServerClosed {
+				errChan <- fmt.Errorf("metrics server error: %w", err)
+			}
+		}()
+	}
 
 	s.logger.Printf("Engine servers started successfully and ready to accept connections")
 
@@ -119,13 +251,31 @@ func (s *Server) Start() error {
 	}
 }
 
+// requireBearerToken rejects requests that do not carry the given bearer token.
+func requireBearerToken(token string, next http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":  "Missing or invalid admin token",
+				"status": http.StatusUnauthorized,
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) shutdown() error {
 	s.logger.Printf("Beginning graceful shutdown...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var httpErr, socketErr, fileErr error
+	var httpErr, socketErr, adminErr, fileErr error
 
 	// Shutdown HTTP server
 	if s.httpServer != nil {
@@ -137,6 +287,24 @@ func (s *Server) shutdown() error {
 		}
 	}
 
+	for _, server := range s.namespaceServers {
+		if err := server.Shutdown(ctx); err != nil {
+			s.logger.Errorf("Error shutting down HTTP server on %s: %v", server.Addr, err)
+			if httpErr == nil {
+				httpErr = err
+			}
+		}
+	}
+
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			s.logger.Errorf("Error shutting down metrics server: %v", err)
+			if httpErr == nil {
+				httpErr = err
+			}
+		}
+	}
+
 	if s.socketServer != nil {
 		socketErr = s.socketServer.Shutdown(ctx)
 		if socketErr != nil {
@@ -146,21 +314,19 @@ func (s *Server) shutdown() error {
 		}
 	}
 
-	if s.socketPath != "" {
-		// Check if the file still exists before trying to remove it
-		if _, err := os.Stat(s.socketPath); err == nil {
-			fileErr = os.Remove(s.socketPath)
-			if fileErr != nil {
-				s.logger.Errorf("Error removing socket file: %v", fileErr)
-			}
-		} else if os.IsNotExist(err) {
-			// File already gone, this is fine, log it for debugging
-			s.logger.Printf("Socket file already removed, skipping cleanup")
-			fileErr = nil
+	if s.adminServer != nil {
+		adminErr = s.adminServer.Shutdown(ctx)
+		if adminErr != nil {
+			s.logger.Errorf("Error shutting down admin server: %v", adminErr)
 		} else {
-			// Some other error during stat
-			s.logger.Errorf("Error checking socket file status: %v", err)
-			fileErr = err
+			s.logger.Printf("Admin server shutdown successful")
+		}
+	}
+
+	if s.socketServer != nil {
+		fileErr = ipc.Cleanup(s.socketPath)
+		if fileErr != nil {
+			s.logger.Errorf("Error removing socket file: %v", fileErr)
 		}
 	}
 
@@ -172,5 +338,8 @@ func (s *Server) shutdown() error {
 	if socketErr != nil {
 		return socketErr
 	}
+	if adminErr != nil {
+		return adminErr
+	}
 	return fileErr
 }
@@ -0,0 +1,270 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+// ServiceNameConfigKey is the config key under which a function loaded as a
+// compose service can read its own service name.
+const ServiceNameConfigKey = "IGNITION_SERVICE_NAME"
+
+// CallServiceHostFunction is the name of the host function that lets a function
+// call another loaded function by its service name.
+const CallServiceHostFunction = "ignition_call_service"
+
+// ServiceRegistry maps compose service names to the functions backing them, and to
+// the project that applied them.
+type ServiceRegistry struct {
+	mu       sync.RWMutex
+	services map[string]FunctionKey
+	owners   map[string]string
+}
+
+// NewServiceRegistry creates an empty service registry.
+func NewServiceRegistry() *ServiceRegistry {
+	return &ServiceRegistry{
+		services: make(map[string]FunctionKey),
+		owners:   make(map[string]string),
+	}
+}
+
+// Register points a service name at a namespace/name pair, replacing any previous mapping
+// and its owner.
+func (r *ServiceRegistry) Register(service, namespace, name string) error {
+	if service == "" {
+		return fmt.Errorf("service name cannot be empty")
+	}
+	if strings.Contains(service, "/") {
+		return fmt.Errorf("service name %q cannot contain '/'", service)
+	}
+
+	r.mu.Lock()
+	r.services[service] = FunctionKey{Namespace: namespace, Name: name}
+	delete(r.owners, service)
+	r.mu.Unlock()
+
+	return nil
+}
+
+// SetOwner records the project owning a registered service.
+func (r *ServiceRegistry) SetOwner(service, project string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.services[service]; ok {
+		r.owners[service] = project
+	}
+}
+
+// Owner returns the project owning a service, or "" when no project applied it.
+func (r *ServiceRegistry) Owner(service string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.owners[service]
+}
+
+// Resolve returns the function registered for a service name.
+func (r *ServiceRegistry) Resolve(service string) (FunctionKey, bool) {
+	r.mu.RLock()
+	id, ok := r.services[service]
+	r.mu.RUnlock()
+
+	return id, ok
+}
+
+// Unregister removes a service name and reports whether it was registered.
+func (r *ServiceRegistry) Unregister(service string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.services[service]
+	delete(r.services, service)
+	delete(r.owners, service)
+	return ok
+}
+
+// UnregisterFunction removes every service name that points at the given function.
+func (r *ServiceRegistry) UnregisterFunction(namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := GetFunctionKey(namespace, name)
+	for service, id := range r.services {
+		if id == key {
+			delete(r.services, service)
+			delete(r.owners, service)
+		}
+	}
+}
+
+// Entries returns a copy of every service mapping.
+func (r *ServiceRegistry) Entries() map[string]FunctionKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make(map[string]FunctionKey, len(r.services))
+	for service, key := range r.services {
+		entries[service] = key
+	}
+
+	return entries
+}
+
+// List returns the registered service names in sorted order.
+func (r *ServiceRegistry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	services := make([]string, 0, len(r.services))
+	for service := range r.services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	return services
+}
+
+// serviceCallRequest is the JSON input accepted by the ignition_call_service host function.
+type serviceCallRequest struct {
+	Service    string `json:"service"`
+	Entrypoint string `json:"entrypoint"`
+	Payload    string `json:"payload,omitempty"`
+}
+
+type callerFunctionKey struct{}
+
+type callChainKey struct{}
+
+// withCaller records the function a call runs, which host functions read back with
+// callerFrom. Host functions do not capture their caller, so a compiled module can be
+// shared by every function loading it. The function is also appended to the call chain
+// of the services calling each other, read back with callChain.
+func withCaller(ctx context.Context, key FunctionKey) context.Context {
+	chain := append(slices.Clone(callChain(ctx)), key)
+	ctx = context.WithValue(ctx, callChainKey{}, chain)
+	return context.WithValue(ctx, callerFunctionKey{}, key)
+}
+
+func callerFrom(ctx context.Context) FunctionKey {
+	key, _ := ctx.Value(callerFunctionKey{}).(FunctionKey)
+	return key
+}
+
+// callChain returns the functions of the service calls leading to a call, outermost first.
+func callChain(ctx context.Context) []FunctionKey {
+	chain, _ := ctx.Value(callChainKey{}).([]FunctionKey)
+	return chain
+}
+
+// checkServiceCall rejects a service call to a function already on the call chain. Its
+// instance is busy with the outer call, so the call would wait on itself until it times out.
+func checkServiceCall(ctx context.Context, service string, target FunctionKey) error {
+	chain := callChain(ctx)
+	if len(chain) > 0 && chain[len(chain)-1] == target {
+		return fmt.Errorf("service %q cannot call itself", service)
+	}
+	if !slices.Contains(chain, target) {
+		return nil
+	}
+
+	keys := make([]string, 0, len(chain)+1)
+	for _, key := range chain {
+		keys = append(keys, key.String())
+	}
+	keys = append(keys, target.String())
+	return fmt.Errorf("service %q is already being called, calling it again would form the cycle %s", service, strings.Join(keys, " -> "))
+}
+
+// hostFunctions builds every host function exposed to loaded functions.
+func (e *Engine) hostFunctions() []extism.HostFunction {
+	functions := append(e.serviceHostFunctions(), e.emitHostFunction())
+	return append(functions, e.payloadHostFunctions()...)
+}
+
+// serviceHostFunctions builds the host functions functions call services with. The
+// call chain is checked so a function cannot be called re-entrantly, directly or through
+// other services.
+func (e *Engine) serviceHostFunctions() []extism.HostFunction {
+	callService := extism.NewHostFunctionWithStack(
+		CallServiceHostFunction,
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			callerKey := callerFrom(ctx)
+
+			// A panic here fails the service call instead of the calling function's call
+			defer func() {
+				if r := recover(); r != nil {
+					recordCallPanic(e.panics, e.logStore, callerKey, "Host function "+CallServiceHostFunction, r)
+					stack[0] = 0
+				}
+			}()
+
+			output, err := e.callServiceFromHost(ctx, p, stack[0])
+			if err != nil {
+				e.logStore.AddLog(callerKey, logging.LevelError, fmt.Sprintf("Service call failed: %v", err))
+				stack[0] = 0
+				return
+			}
+
+			offset, err := p.WriteBytes(output)
+			if err != nil {
+				e.logStore.AddLog(callerKey, logging.LevelError, fmt.Sprintf("Failed to write service call output: %v", err))
+				stack[0] = 0
+				return
+			}
+			stack[0] = offset
+		},
+		[]extism.ValueType{extism.ValueTypePTR},
+		[]extism.ValueType{extism.ValueTypePTR},
+	)
+
+	return []extism.HostFunction{callService}
+}
+
+// callServiceFromHost decodes a host call request and dispatches it to the target service.
+func (e *Engine) callServiceFromHost(ctx context.Context, p *extism.CurrentPlugin, offset uint64) ([]byte, error) {
+	input, err := p.ReadBytes(offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+
+	var req serviceCallRequest
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if req.Service == "" || req.Entrypoint == "" {
+		return nil, fmt.Errorf("service and entrypoint are required")
+	}
+
+	target, ok := e.services.Resolve(req.Service)
+	if !ok {
+		return nil, fmt.Errorf("unknown service %q", req.Service)
+	}
+	if err := checkServiceCall(ctx, req.Service, GetFunctionKey(target.Namespace, target.Name)); err != nil {
+		return nil, err
+	}
+
+	// The chunks the service emits are part of its output, not of the caller's stream
+	ctx = WithChunkWriter(ctx, nil)
+	return e.CallFunctionWithContext(ctx, target.Namespace, target.Name, req.Entrypoint, []byte(req.Payload))
+}
+
+// RegisterService maps a service name to a loaded function.
+func (e *Engine) RegisterService(service, namespace, name string) error {
+	return e.services.Register(service, namespace, name)
+}
+
+// ResolveService returns the namespace and name registered for a service.
+func (e *Engine) ResolveService(service string) (namespace, name string, ok bool) {
+	id, ok := e.services.Resolve(service)
+	return id.Namespace, id.Name, ok
+}
@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckServiceCall(t *testing.T) {
+	a := GetFunctionKey("ns", "a")
+	b := GetFunctionKey("ns", "b")
+	c := GetFunctionKey("ns", "c")
+
+	// A is running and calls B
+	ctx := withCaller(context.Background(), a)
+	require.NoError(t, checkServiceCall(ctx, "b", b))
+	require.EqualError(t, checkServiceCall(ctx, "a", a), `service "a" cannot call itself`)
+
+	// B calling back into A would wait on A's busy instance
+	ctx = withCaller(ctx, b)
+	assert.Equal(t, b, callerFrom(ctx))
+	assert.Equal(t, []FunctionKey{a, b}, callChain(ctx))
+	err := checkServiceCall(ctx, "a", a)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ns/a -> ns/b -> ns/a")
+	require.NoError(t, checkServiceCall(ctx, "c", c))
+
+	// Calls outside the chain do not see it
+	assert.Equal(t, []FunctionKey{c}, callChain(withCaller(context.Background(), c)))
+}
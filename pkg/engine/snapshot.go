@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// Snapshot captures the functions, scales, services and pipelines of the running engine.
+func (e *Engine) Snapshot() *types.EngineSnapshot {
+	snapshot := &types.EngineSnapshot{
+		Version:   types.SnapshotVersion,
+		CreatedAt: time.Now().UTC(),
+		Functions: []types.SnapshotFunction{},
+		Services:  make(map[string]types.SnapshotTarget),
+		Pipelines: make(map[string][]types.PipelineStep),
+	}
+
+	known := e.pluginManager.GetPreviouslyLoadedFunctions()
+	stopped := e.pluginManager.GetStoppedFunctions()
+	scales := e.pluginManager.GetFunctionScales()
+	for key := range stopped {
+		known[key] = true
+	}
+	for key := range scales {
+		known[key] = true
+	}
+
+	for key := range known {
+		fn := types.SnapshotFunction{
+			Namespace: key.Namespace,
+			Name:      key.Name,
+			Status:    types.SnapshotUnloaded,
+			Instances: scales[key],
+		}
+		if digest, ok := e.pluginManager.GetPluginDigest(key); ok {
+			fn.Digest = digest
+		}
+		if config, ok := e.pluginManager.GetPluginConfig(key); ok {
+			fn.Config = config
+		}
+		if policy := e.ReloadPolicy(key.Namespace, key.Name); policy.Kind != types.ReloadLatest {
+			fn.ReloadPolicy = policy.String()
+		}
+		if priority := e.FunctionPriority(key.Namespace, key.Name); priority != components.PriorityNormal {
+			fn.Priority = priority.String()
+		}
+		fn.MaxQueueWaitMs = e.FunctionMaxQueueWait(key.Namespace, key.Name).Milliseconds()
+		if logging := e.FunctionCallLogging(key.Namespace, key.Name); logging.Mode != types.CallLogAll {
+			fn.CallLogging = logging.String()
+		}
+
+		switch {
+		case stopped[key]:
+			fn.Status = types.SnapshotStopped
+		case e.pluginManager.IsPluginLoaded(key):
+			fn.Status = types.SnapshotRunning
+		case fn.Digest == "":
+			// Scaled but never loaded: only the scale is worth keeping
+			fn.Status = types.SnapshotStopped
+		}
+
+		snapshot.Functions = append(snapshot.Functions, fn)
+	}
+
+	sort.Slice(snapshot.Functions, func(i, j int) bool {
+		a, b := snapshot.Functions[i], snapshot.Functions[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	for service, key := range e.services.Entries() {
+		snapshot.Services[service] = types.SnapshotTarget{Namespace: key.Namespace, Name: key.Name}
+	}
+
+	for _, name := range e.pipelines.List() {
+		if steps, ok := e.pipelines.Get(name); ok {
+			snapshot.Pipelines[name] = steps
+		}
+	}
+
+	return snapshot
+}
+
+// RedactedSnapshot is Snapshot with the values of secret config keys masked.
+func (e *Engine) RedactedSnapshot() *types.EngineSnapshot {
+	snapshot := e.Snapshot()
+	snapshot.Redacted = true
+	for i := range snapshot.Functions {
+		snapshot.Functions[i].Config = e.redaction.Config(snapshot.Functions[i].Config)
+	}
+	return snapshot
+}
+
+// RestoreSnapshot brings the engine to the state recorded in a snapshot. Functions are
+// loaded by digest so the exact recorded versions come back. Restoring carries on past
+// individual failures and returns them joined together.
+func (e *Engine) RestoreSnapshot(ctx context.Context, snapshot *types.EngineSnapshot) error {
+	if err := snapshot.Validate(); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+
+	var errs []error
+	for _, fn := range snapshot.Functions {
+		if err := e.restoreFunction(ctx, fn); err != nil {
+			errs = append(errs, fmt.Errorf("function %s/%s: %w", fn.Namespace, fn.Name, err))
+		}
+	}
+
+	for service, target := range snapshot.Services {
+		if e.IsFunctionStopped(target.Namespace, target.Name) {
+			continue
+		}
+		if err := e.RegisterService(service, target.Namespace, target.Name); err != nil {
+			errs = append(errs, fmt.Errorf("service %s: %w", service, err))
+		}
+	}
+
+	for name, steps := range snapshot.Pipelines {
+		e.RegisterPipeline(name, steps)
+	}
+
+	e.logger.Printf("Restored snapshot from %s: %d functions, %d services, %d pipelines (%d errors)",
+		snapshot.CreatedAt.Format(time.RFC3339), len(snapshot.Functions), len(snapshot.Services),
+		len(snapshot.Pipelines), len(errs))
+
+	return errors.Join(errs...)
+}
+
+// restoreFunction applies the scale, reload policy, priority, max queue wait and call logging of a function and returns it to its recorded status.
+func (e *Engine) restoreFunction(ctx context.Context, fn types.SnapshotFunction) error {
+	// Validate has already checked the policy and priority
+	policy, _ := types.ParseReloadPolicy(fn.ReloadPolicy)
+	e.SetReloadPolicy(fn.Namespace, fn.Name, policy)
+	priority, _ := components.ParsePriority(fn.Priority)
+	e.SetFunctionPriority(fn.Namespace, fn.Name, priority)
+	e.SetFunctionMaxQueueWait(fn.Namespace, fn.Name, time.Duration(fn.MaxQueueWaitMs)*time.Millisecond)
+	callLogging, _ := types.ParseCallLogging(fn.CallLogging)
+	e.SetFunctionCallLogging(fn.Namespace, fn.Name, callLogging)
+
+	if fn.Instances > 0 {
+		if err := e.ScaleFunction(fn.Namespace, fn.Name, fn.Instances); err != nil {
+			return err
+		}
+	}
+
+	// A warm standby taking over already runs most functions as recorded
+	if fn.Digest != "" && !e.loadedWith(GetFunctionKey(fn.Namespace, fn.Name), fn.Digest, fn.Config) {
+		identifier := registry.TruncateDigest(fn.Digest, 12)
+		if err := e.LoadFunctionWithForce(ctx, fn.Namespace, fn.Name, identifier, fn.Config, true); err != nil {
+			return err
+		}
+	}
+
+	switch fn.Status {
+	case types.SnapshotUnloaded:
+		return e.UnloadFunction(fn.Namespace, fn.Name)
+	case types.SnapshotStopped:
+		return e.StopFunction(fn.Namespace, fn.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	source, sourceDir := setupTestEngine(t)
+	defer cleanupTest(sourceDir)
+
+	steps := []types.PipelineStep{{Service: "api", Entrypoint: "handle"}}
+	require.NoError(t, source.RegisterService("api", "ns", "api"))
+	source.RegisterPipeline("checkout", steps)
+	require.NoError(t, source.ScaleFunction("ns", "worker", 3))
+
+	snapshot := source.Snapshot()
+	require.NoError(t, snapshot.Validate())
+	require.Len(t, snapshot.Functions, 1)
+	assert.Equal(t, types.SnapshotFunction{
+		Namespace: "ns",
+		Name:      "worker",
+		Status:    types.SnapshotStopped,
+		Instances: 3,
+	}, snapshot.Functions[0])
+	assert.Equal(t, types.SnapshotTarget{Namespace: "ns", Name: "api"}, snapshot.Services["api"])
+
+	target, targetDir := setupTestEngine(t)
+	defer cleanupTest(targetDir)
+
+	require.NoError(t, target.RestoreSnapshot(context.Background(), snapshot))
+
+	restored, ok := target.pipelines.Get("checkout")
+	require.True(t, ok)
+	assert.Equal(t, steps, restored)
+
+	namespace, name, ok := target.ResolveService("api")
+	require.True(t, ok)
+	assert.Equal(t, "ns/api", namespace+"/"+name)
+	assert.Equal(t, 3, target.pluginManager.GetFunctionScales()[GetFunctionKey("ns", "worker")])
+	assert.True(t, target.IsFunctionStopped("ns", "worker"))
+}
+
+func TestSnapshotValidate(t *testing.T) {
+	snapshot := types.EngineSnapshot{
+		Version:   types.SnapshotVersion,
+		Functions: []types.SnapshotFunction{{Namespace: "ns", Name: "fn", Status: types.SnapshotRunning}},
+	}
+	assert.ErrorContains(t, snapshot.Validate(), "no digest")
+
+	snapshot.Functions[0].Digest = "sha256:a"
+	snapshot.Redacted = true
+	assert.ErrorContains(t, snapshot.Validate(), "cannot be restored")
+
+	snapshot.Version = 99
+	assert.ErrorContains(t, snapshot.Validate(), "unsupported snapshot version")
+}
@@ -0,0 +1,247 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+// Host functions reading a request body spooled to a temporary file
+const (
+	PayloadSizeHostFunction = "ignition_payload_size"
+	PayloadReadHostFunction = "ignition_payload_read"
+)
+
+// spoolPattern names the temporary files of spooled payloads and outputs
+const spoolPattern = "ignition-spool-*"
+
+// spooledPayload is a call payload kept in a temporary file instead of memory.
+type spooledPayload struct {
+	file *os.File
+	size int64
+}
+
+type spooledPayloadKey struct{}
+
+func withSpooledPayload(ctx context.Context, payload *spooledPayload) context.Context {
+	return context.WithValue(ctx, spooledPayloadKey{}, payload)
+}
+
+func spooledPayloadFrom(ctx context.Context) *spooledPayload {
+	payload, _ := ctx.Value(spooledPayloadKey{}).(*spooledPayload)
+	return payload
+}
+
+// Remove closes and deletes the temporary file. It is a no-op on a nil payload.
+func (p *spooledPayload) Remove() {
+	if p == nil {
+		return
+	}
+	p.file.Close()
+	os.Remove(p.file.Name())
+}
+
+// spoolsPayloads reports whether the loaded version of a function reads large payloads from spool files.
+func (h *Handlers) spoolsPayloads(namespace, name string) bool {
+	settings, ok := h.engine.functionLoader.GetVersionSettings(namespace, name)
+	return ok && settings.SpoolPayloads
+}
+
+// readRawPayload reads a raw request body. With a positive threshold, a body larger
+// than threshold bytes is copied to a temporary file under dir rather than memory.
+func readRawPayload(r *http.Request, threshold int64, dir string) ([]byte, *spooledPayload, error) {
+	if threshold <= 0 {
+		body, err := io.ReadAll(r.Body)
+		return body, nil, bodyReadError(err)
+	}
+
+	head, err := io.ReadAll(io.LimitReader(r.Body, threshold+1))
+	if err != nil {
+		return nil, nil, bodyReadError(err)
+	}
+	if int64(len(head)) <= threshold {
+		return head, nil, nil
+	}
+
+	file, err := os.CreateTemp(dir, spoolPattern)
+	if err != nil {
+		return nil, nil, NewInternalServerError(fmt.Sprintf("Failed to spool request body: %v", err))
+	}
+	payload := &spooledPayload{file: file}
+	if payload.size, err = io.Copy(file, io.MultiReader(bytes.NewReader(head), r.Body)); err != nil {
+		payload.Remove()
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, nil, bodyReadError(err)
+		}
+		return nil, nil, NewInternalServerError(fmt.Sprintf("Failed to spool request body: %v", err))
+	}
+	return nil, payload, nil
+}
+
+// bodyReadError converts a failure to read a request body into a request error.
+func bodyReadError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return NewRequestError("Request body too large", http.StatusRequestEntityTooLarge)
+	}
+	return NewBadRequestError("Failed to read request body")
+}
+
+// payloadHostFunctions builds the host functions a function reads its spooled
+// payload with. ignition_payload_size returns its size in bytes, or -1 when the payload
+// of the call was not spooled. ignition_payload_read takes an offset and a length and
+// returns a pointer to at most length bytes, empty past the end, or 0 on failure.
+func (e *Engine) payloadHostFunctions() []extism.HostFunction {
+	size := extism.NewHostFunctionWithStack(
+		PayloadSizeHostFunction,
+		func(ctx context.Context, _ *extism.CurrentPlugin, stack []uint64) {
+			payload := spooledPayloadFrom(ctx)
+			if payload == nil {
+				stack[0] = extism.EncodeI64(-1)
+				return
+			}
+			stack[0] = extism.EncodeI64(payload.size)
+		},
+		[]extism.ValueType{},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	read := extism.NewHostFunctionWithStack(
+		PayloadReadHostFunction,
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			callerKey := callerFrom(ctx)
+
+			defer func() {
+				if r := recover(); r != nil {
+					recordCallPanic(e.panics, e.logStore, callerKey, "Host function "+PayloadReadHostFunction, r)
+					stack[0] = 0
+				}
+			}()
+
+			offset, length := int64(stack[0]), int64(stack[1])
+			stack[0] = 0
+			payload := spooledPayloadFrom(ctx)
+			if payload == nil || offset < 0 || length < 0 {
+				return
+			}
+
+			chunk := make([]byte, max(0, min(length, payload.size-offset)))
+			if _, err := payload.file.ReadAt(chunk, offset); err != nil && !errors.Is(err, io.EOF) {
+				e.logStore.AddLog(callerKey, logging.LevelError, fmt.Sprintf("Failed to read spooled payload: %v", err))
+				return
+			}
+			ptr, err := p.WriteBytes(chunk)
+			if err != nil {
+				e.logStore.AddLog(callerKey, logging.LevelError, fmt.Sprintf("Failed to write spooled payload chunk: %v", err))
+				return
+			}
+			stack[0] = ptr
+		},
+		[]extism.ValueType{extism.ValueTypeI64, extism.ValueTypeI64},
+		[]extism.ValueType{extism.ValueTypePTR},
+	)
+
+	return []extism.HostFunction{size, read}
+}
+
+// outputSpool collects the chunks a function emits during a call nobody streams. Once
+// they pass threshold bytes they move to a temporary file under dir, so large outputs
+// are copied to the response without being held in memory.
+type outputSpool struct {
+	mu        sync.Mutex
+	threshold int64
+	dir       string
+	buf       bytes.Buffer
+	file      *os.File
+	closed    bool
+
+	// First failure to spool a chunk, which fails the call
+	err error
+}
+
+func newOutputSpool(threshold int64, dir string) *outputSpool {
+	return &outputSpool{threshold: threshold, dir: dir}
+}
+
+func (s *outputSpool) write(chunk []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errStreamClosed
+	}
+
+	if s.file == nil && s.threshold > 0 && int64(s.buf.Len()+len(chunk)) > s.threshold {
+		file, err := os.CreateTemp(s.dir, spoolPattern)
+		if err != nil {
+			return s.fail(err)
+		}
+		s.file = file
+		if _, err := s.buf.WriteTo(file); err != nil {
+			return s.fail(err)
+		}
+	}
+	if s.file != nil {
+		if _, err := s.file.Write(chunk); err != nil {
+			return s.fail(err)
+		}
+		return nil
+	}
+	s.buf.Write(chunk)
+	return nil
+}
+
+// close stops collecting, as a call that timed out may keep emitting.
+func (s *outputSpool) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+}
+
+// fail records err and stops collecting, as the output could no longer be complete.
+func (s *outputSpool) fail(err error) error {
+	s.closed = true
+	s.err = err
+	return err
+}
+
+// writeTo copies the collected chunks, then output, to w. The spool must be closed.
+func (s *outputSpool) writeTo(w io.Writer, output []byte) error {
+	if s.err != nil {
+		return NewInternalServerError(fmt.Sprintf("Failed to spool function output: %v", s.err))
+	}
+	if s.file != nil {
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, s.file); err != nil {
+			return err
+		}
+	} else if _, err := w.Write(s.buf.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(output)
+	return err
+}
+
+// Remove deletes the temporary file, if the chunks needed one.
+func (s *outputSpool) Remove() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.file != nil {
+		s.file.Close()
+		os.Remove(s.file.Name())
+	}
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRawPayload(t *testing.T) {
+	dir := t.TempDir()
+
+	r := httptest.NewRequest(http.MethodPost, "/ns/fn/run", strings.NewReader("small"))
+	payload, spooled, err := readRawPayload(r, 8, dir)
+	require.NoError(t, err)
+	assert.Nil(t, spooled)
+	assert.Equal(t, "small", string(payload))
+
+	r = httptest.NewRequest(http.MethodPost, "/ns/fn/run", strings.NewReader("larger than eight"))
+	payload, spooled, err = readRawPayload(r, 8, dir)
+	require.NoError(t, err)
+	require.NotNil(t, spooled)
+	assert.Nil(t, payload)
+	assert.Equal(t, int64(17), spooled.size)
+
+	content, err := os.ReadFile(spooled.file.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "larger than eight", string(content))
+
+	spooled.Remove()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestOutputSpool(t *testing.T) {
+	dir := t.TempDir()
+	spool := newOutputSpool(8, dir)
+	defer spool.Remove()
+
+	require.NoError(t, spool.write([]byte("one ")))
+	assert.Nil(t, spool.file)
+	require.NoError(t, spool.write([]byte("two three ")))
+	require.NotNil(t, spool.file)
+	spool.close()
+	assert.ErrorIs(t, spool.write([]byte("late")), errStreamClosed)
+
+	var out bytes.Buffer
+	require.NoError(t, spool.writeTo(&out, []byte("done")))
+	assert.Equal(t, "one two three done", out.String())
+
+	spool.Remove()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDecodeCallPayloadRaw(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/pipelines/p", strings.NewReader(`{"not":"wrapped"}`))
+	r.Header.Set("Content-Type", "application/octet-stream")
+	payload, err := decodeCallPayload(r)
+	require.NoError(t, err)
+	assert.Equal(t, `{"not":"wrapped"}`, payload)
+}
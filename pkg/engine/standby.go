@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// haState records the leadership of an engine paired with a standby.
+type haState struct {
+	lock        *os.File
+	activeSince time.Time
+	tookOver    bool
+}
+
+// HAStatus describes the leadership of an engine paired with a standby.
+type HAStatus struct {
+	LockFile    string    `json:"lock_file"`
+	ActiveSince time.Time `json:"active_since"`
+	TookOver    bool      `json:"took_over"`
+}
+
+// HAStatus returns the leadership of the engine, or nil when HA is disabled.
+func (e *Engine) HAStatus() *HAStatus {
+	if e.options.HA.LockFile == "" {
+		return nil
+	}
+
+	e.haMu.RLock()
+	defer e.haMu.RUnlock()
+	return &HAStatus{
+		LockFile:    e.options.HA.LockFile,
+		ActiveSince: e.ha.activeSince,
+		TookOver:    e.ha.tookOver,
+	}
+}
+
+// awaitLeadership returns once the engine holds the HA lock file, at once when no other
+// engine holds it. Until then the engine is a standby: it follows the functions running
+// on the active engine, loading the same versions so they are warm when it takes over.
+// It returns the error of ctx when the standby is stopped first.
+func (e *Engine) awaitLeadership(ctx context.Context) error {
+	lockFile := e.options.HA.LockFile
+	if lockFile == "" {
+		return nil
+	}
+
+	lock, err := tryLock(lockFile)
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", lockFile, err)
+	}
+	if lock != nil {
+		e.logger.Printf("Holding HA lock %s, engine is active", lockFile)
+		e.becomeActive(lock, false)
+		return nil
+	}
+
+	e.logger.Printf("HA lock %s is held by another engine, running as standby", lockFile)
+	e.events.Publish(events.Event{Type: events.TypeStandby, Reason: "lock held by the active engine"})
+
+	active, err := client.NewEngineClient(e.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to create client for the active engine: %w", err)
+	}
+
+	ticker := time.NewTicker(e.options.HA.SyncInterval)
+	defer ticker.Stop()
+
+	var last *types.EngineSnapshot
+	for {
+		if snapshot, err := e.warmFrom(ctx, active); err != nil {
+			e.logger.Errorf("Standby failed to sync with the active engine: %v", err)
+		} else {
+			last = snapshot
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		lock, err := tryLock(lockFile)
+		if err != nil {
+			return fmt.Errorf("failed to lock %s: %w", lockFile, err)
+		}
+		if lock != nil {
+			e.takeOver(ctx, lock, last)
+			return nil
+		}
+	}
+}
+
+// takeOver makes a standby the active engine, restoring the last state seen on the
+// engine it replaces before its listeners start.
+func (e *Engine) takeOver(ctx context.Context, lock *os.File, last *types.EngineSnapshot) {
+	e.logger.Printf("Active engine is gone, taking over HA lock %s", e.options.HA.LockFile)
+	e.becomeActive(lock, true)
+
+	if last != nil {
+		if err := e.RestoreSnapshot(ctx, last); err != nil {
+			e.logger.Errorf("Failed to restore the state of the previous active engine: %v", err)
+		}
+	}
+	e.events.Publish(events.Event{Type: events.TypeFailover, Reason: "active engine stopped"})
+}
+
+func (e *Engine) becomeActive(lock *os.File, tookOver bool) {
+	e.haMu.Lock()
+	defer e.haMu.Unlock()
+	e.ha = haState{lock: lock, activeSince: time.Now().UTC(), tookOver: tookOver}
+}
+
+// releaseLeadership releases the HA lock so the standby can take over. The lock file
+// stays in place: removing it would let two engines lock different files.
+func (e *Engine) releaseLeadership() {
+	e.haMu.Lock()
+	defer e.haMu.Unlock()
+	if e.ha.lock != nil {
+		e.ha.lock.Close()
+		e.ha.lock = nil
+	}
+}
+
+// warmFrom loads on the standby the functions running on the active engine and returns
+// the state of the active engine.
+func (e *Engine) warmFrom(ctx context.Context, active *client.EngineClient) (*types.EngineSnapshot, error) {
+	snapshot, err := active.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fn := range snapshot.Functions {
+		if fn.Status != types.SnapshotRunning || fn.Digest == "" {
+			continue
+		}
+		if err := e.warmFunction(ctx, active, fn); err != nil {
+			e.logger.Errorf("Standby failed to warm %s/%s: %v", fn.Namespace, fn.Name, err)
+		}
+	}
+	return snapshot, nil
+}
+
+// warmFunction loads the version of a function running on the active engine, copying
+// it and its tags from the registry of the active engine when the standby lacks it.
+func (e *Engine) warmFunction(ctx context.Context, active *client.EngineClient, fn types.SnapshotFunction) error {
+	if e.loadedWith(GetFunctionKey(fn.Namespace, fn.Name), fn.Digest, fn.Config) {
+		return nil
+	}
+
+	identifier := registry.TruncateDigest(fn.Digest, 12)
+	if _, _, err := e.registry.Pull(fn.Namespace, fn.Name, identifier); err != nil {
+		pulled, err := active.PullVersion(ctx, fn.Namespace, fn.Name, identifier)
+		if err != nil {
+			return err
+		}
+		version := pulled.Version
+		if err := e.registry.Push(fn.Namespace, fn.Name, pulled.Payload, version.FullDigest, "", version.Settings); err != nil {
+			return err
+		}
+		for _, tag := range version.Tags {
+			if err := e.registry.ReassignTag(fn.Namespace, fn.Name, tag, version.FullDigest); err != nil {
+				return err
+			}
+		}
+	}
+
+	return e.LoadFunctionWithContext(ctx, fn.Namespace, fn.Name, identifier, fn.Config)
+}
+
+// loadedWith reports whether a function is loaded with the given digest and config,
+// which makes loading it again a needless recompile.
+func (e *Engine) loadedWith(key FunctionKey, digest string, config map[string]string) bool {
+	loaded, ok := e.pluginManager.GetPluginDigest(key)
+	return ok && loaded == digest && e.pluginManager.IsPluginLoaded(key) && !e.pluginManager.HasConfigChanged(key, e.loadedConfig(key, config))
+}
+
+// loadedConfig returns config with the defaults of the config schema of the version a
+// function is loaded with, which the loader adds, so they do not count as a change.
+func (e *Engine) loadedConfig(key FunctionKey, config map[string]string) map[string]string {
+	if e.functionLoader == nil {
+		return config
+	}
+	settings, ok := e.functionLoader.GetVersionSettings(key.Namespace, key.Name)
+	if !ok {
+		return config
+	}
+	return settings.Config.WithDefaults(config)
+}
@@ -0,0 +1,229 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// EmitHostFunction is the name of the host function that sends a chunk of output to
+// the caller while the call is still running.
+const EmitHostFunction = "ignition_emit"
+
+// Streaming modes of HTTP calls, selected with the X-Ignition-Stream header
+const (
+	streamChunked = "chunked"
+	streamSSE     = "sse"
+)
+
+// errStreamClosed is returned to chunks emitted after their call returned
+var errStreamClosed = errors.New("stream closed")
+
+// ChunkWriter receives the chunks a function emits during a call. An error tells the
+// function its chunk was not delivered, typically because the caller went away.
+type ChunkWriter func(chunk []byte) error
+
+type chunkWriterKey struct{}
+
+// WithChunkWriter returns a context in which function calls hand the chunks they emit
+// to w as they come. Without a chunk writer, or with a nil one, emitted chunks are
+// returned ahead of the call output.
+func WithChunkWriter(ctx context.Context, w ChunkWriter) context.Context {
+	return context.WithValue(ctx, chunkWriterKey{}, w)
+}
+
+func chunkWriterFrom(ctx context.Context) ChunkWriter {
+	w, _ := ctx.Value(chunkWriterKey{}).(ChunkWriter)
+	return w
+}
+
+// emitHostFunction builds the ignition_emit host function. It takes a pointer to the
+// chunk and returns 0 once the chunk is delivered, 1 otherwise.
+func (e *Engine) emitHostFunction() extism.HostFunction {
+	return extism.NewHostFunctionWithStack(
+		EmitHostFunction,
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			callerKey := callerFrom(ctx)
+
+			defer func() {
+				if r := recover(); r != nil {
+					recordCallPanic(e.panics, e.logStore, callerKey, "Host function "+EmitHostFunction, r)
+					stack[0] = 1
+				}
+			}()
+
+			chunk, err := p.ReadBytes(stack[0])
+			if err != nil {
+				e.logStore.AddLog(callerKey, logging.LevelError, fmt.Sprintf("Failed to read emitted chunk: %v", err))
+				stack[0] = 1
+				return
+			}
+
+			write := chunkWriterFrom(ctx)
+			if write == nil || write(chunk) != nil {
+				stack[0] = 1
+				return
+			}
+			stack[0] = 0
+		},
+		[]extism.ValueType{extism.ValueTypePTR},
+		[]extism.ValueType{extism.ValueTypeI32},
+	)
+}
+
+// chunkBuffer collects the chunks of a call nobody streams, to return them with its output.
+type chunkBuffer struct {
+	mu     sync.Mutex
+	data   []byte
+	closed bool
+}
+
+func (b *chunkBuffer) write(chunk []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errStreamClosed
+	}
+	b.data = append(b.data, chunk...)
+	return nil
+}
+
+// close stops collecting, as a call that timed out may keep emitting, and returns the chunks.
+func (b *chunkBuffer) close() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return b.data
+}
+
+// streamMode returns how the caller asked for emitted chunks to be streamed, or an
+// empty string when the output is returned at once.
+func streamMode(r *http.Request) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(r.Header.Get(types.StreamHeader))); mode {
+	case streamChunked, streamSSE:
+		return mode, nil
+	case "":
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			return streamSSE, nil
+		}
+		return "", nil
+	default:
+		return "", NewBadRequestError(fmt.Sprintf("Invalid %s header %q, expected %s or %s",
+			types.StreamHeader, mode, streamChunked, streamSSE))
+	}
+}
+
+// httpChunkStream writes the chunks a function emits to an HTTP response as they come.
+// The response starts with the first chunk, so a call that fails before emitting
+// anything still gets a regular error response.
+type httpChunkStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	sse     bool
+	started bool
+	closed  bool
+}
+
+func newHTTPChunkStream(w http.ResponseWriter, mode string) *httpChunkStream {
+	return &httpChunkStream{w: w, rc: http.NewResponseController(w), sse: mode == streamSSE}
+}
+
+func (s *httpChunkStream) write(chunk []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errStreamClosed
+	}
+
+	s.start()
+	if err := s.writeData(chunk); err != nil {
+		s.closed = true
+		return err
+	}
+	if err := s.rc.Flush(); err != nil {
+		s.closed = true
+		return err
+	}
+	return nil
+}
+
+// finish ends the stream with the call output, or with its error when chunks were
+// already sent. Otherwise the error is returned to be answered as usual.
+func (s *httpChunkStream) finish(output []byte, callErr error, start time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if callErr != nil && !s.started {
+		setExecutionTime(s.w, start)
+		return callErr
+	}
+
+	// The client may be gone already, in which case these writes fail and nobody is left to tell
+	s.start()
+	switch {
+	case callErr != nil:
+		message := strings.ReplaceAll(callErr.Error(), "\n", " ")
+		if s.sse {
+			_, _ = fmt.Fprintf(s.w, "event: error\ndata: %s\n\n", message)
+		}
+		s.w.Header().Set(types.StreamErrorTrailer, message)
+	case len(output) > 0:
+		_ = s.writeData(output)
+	}
+	if s.sse {
+		_, _ = fmt.Fprint(s.w, "event: end\ndata:\n\n")
+	}
+	setExecutionTime(s.w, start)
+	return nil
+}
+
+// start sends the response headers, announcing the trailers sent when the call returns.
+func (s *httpChunkStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+
+	header := s.w.Header()
+	if s.sse {
+		header.Set("Content-Type", "text/event-stream")
+	} else {
+		header.Set("Content-Type", "application/octet-stream")
+	}
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Trailer", types.ExecutionTimeHeader+", "+types.StreamErrorTrailer)
+	header.Del(types.ExecutionTimeHeader)
+	s.w.WriteHeader(http.StatusOK)
+}
+
+// writeData writes a chunk as is, or as one server-sent event with a data line per line.
+func (s *httpChunkStream) writeData(data []byte) error {
+	if !s.sse {
+		_, err := s.w.Write(data)
+		return err
+	}
+
+	var event bytes.Buffer
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		event.WriteString("data: ")
+		event.Write(line)
+		event.WriteByte('\n')
+	}
+	event.WriteByte('\n')
+	_, err := s.w.Write(event.Bytes())
+	return err
+}
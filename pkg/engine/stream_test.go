@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamMode(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/ns/fn/run", nil)
+	mode, err := streamMode(r)
+	require.NoError(t, err)
+	assert.Empty(t, mode)
+
+	r.Header.Set("Accept", "text/event-stream")
+	mode, err = streamMode(r)
+	require.NoError(t, err)
+	assert.Equal(t, streamSSE, mode)
+
+	r.Header.Set(types.StreamHeader, "Chunked")
+	mode, err = streamMode(r)
+	require.NoError(t, err)
+	assert.Equal(t, streamChunked, mode)
+
+	r.Header.Set(types.StreamHeader, "websocket")
+	_, err = streamMode(r)
+	assert.Error(t, err)
+}
+
+func TestHTTPChunkStream(t *testing.T) {
+	t.Run("chunked", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		stream := newHTTPChunkStream(rec, streamChunked)
+		require.NoError(t, stream.write([]byte("one ")))
+		require.NoError(t, stream.write([]byte("two ")))
+		require.NoError(t, stream.finish([]byte("done"), nil, time.Now()))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, rec.Flushed)
+		assert.Equal(t, "one two done", rec.Body.String())
+		assert.ErrorIs(t, stream.write([]byte("late")), errStreamClosed)
+	})
+
+	t.Run("sse", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		stream := newHTTPChunkStream(rec, streamSSE)
+		require.NoError(t, stream.write([]byte("a\nb")))
+		require.NoError(t, stream.finish(nil, errors.New("boom"), time.Now()))
+
+		assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "data: a\ndata: b\n\nevent: error\ndata: boom\n\nevent: end\ndata:\n\n", rec.Body.String())
+		assert.Equal(t, "boom", rec.Result().Trailer.Get(types.StreamErrorTrailer))
+	})
+
+	t.Run("error before first chunk", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		stream := newHTTPChunkStream(rec, streamChunked)
+		callErr := errors.New("boom")
+		assert.Equal(t, callErr, stream.finish(nil, callErr, time.Now()))
+		assert.Empty(t, rec.Body.String())
+	})
+}
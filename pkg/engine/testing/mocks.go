@@ -3,6 +3,7 @@ package testing
 import (
 	"context"
 	"fmt"
+	"io"
 	"sync"
 
 	extism "github.com/extism/go-sdk"
@@ -23,23 +24,24 @@ const (
 
 // MockPluginManager is a mock implementation of PluginManager for testing.
 type MockPluginManager struct {
-	plugins map[string]*extism.Plugin
+	plugins map[components.FunctionKey]*components.PluginPool
 	mutex   sync.RWMutex
 
 	// Function call tracking for assertions
 	Calls struct {
-		GetPlugin                    []string
-		StorePlugin                  []string
-		RemovePlugin                 []string
-		StopFunction                 []string
-		IsFunctionStopped            []string
-		ClearStoppedStatus           []string
-		IsPluginLoaded               []string
-		WasPreviouslyLoaded          []string
-		HasConfigChanged             []string
-		HasDigestChanged             []string
-		GetPluginDigest              []string
-		GetPluginConfig              []string
+		GetPool                      []components.FunctionKey
+		StorePlugin                  []components.FunctionKey
+		RemovePlugin                 []components.FunctionKey
+		ScaleFunction                []components.FunctionKey
+		StopFunction                 []components.FunctionKey
+		IsFunctionStopped            []components.FunctionKey
+		ClearStoppedStatus           []components.FunctionKey
+		IsPluginLoaded               []components.FunctionKey
+		WasPreviouslyLoaded          []components.FunctionKey
+		HasConfigChanged             []components.FunctionKey
+		HasDigestChanged             []components.FunctionKey
+		GetPluginDigest              []components.FunctionKey
+		GetPluginConfig              []components.FunctionKey
 		StartCleanup                 int
 		Shutdown                     int
 		GetLogStore                  int
@@ -47,65 +49,69 @@ type MockPluginManager struct {
 		GetLoadedFunctionCount       int
 		GetPreviouslyLoadedFunctions int
 		GetStoppedFunctions          int
+		GetFunctionScales            int
 	}
 
 	// Mock behavior configuration
 	Behavior struct {
-		IsStoppedFunc           func(key string) bool
-		WasPreviouslyLoadedFunc func(key string) (bool, map[string]string)
-		HasConfigChangedFunc    func(key string, newConfig map[string]string) bool
-		HasDigestChangedFunc    func(key string, newDigest string) bool
+		IsStoppedFunc           func(key components.FunctionKey) bool
+		WasPreviouslyLoadedFunc func(key components.FunctionKey) (bool, map[string]string)
+		HasConfigChangedFunc    func(key components.FunctionKey, newConfig map[string]string) bool
+		HasDigestChangedFunc    func(key components.FunctionKey, newDigest string) bool
 	}
 
 	// Storage for function state
 	FunctionState struct {
-		stopped          map[string]bool
-		previouslyLoaded map[string]bool
-		configs          map[string]map[string]string
-		digests          map[string]string
+		stopped          map[components.FunctionKey]bool
+		previouslyLoaded map[components.FunctionKey]bool
+		configs          map[components.FunctionKey]map[string]string
+		digests          map[components.FunctionKey]string
 	}
 
-	logStore *logging.FunctionLogStore
+	logStore logging.LogStore
 }
 
 // NewMockPluginManager creates a new mock plugin manager.
 func NewMockPluginManager() *MockPluginManager {
 	return &MockPluginManager{
-		plugins: make(map[string]*extism.Plugin),
+		plugins: make(map[components.FunctionKey]*components.PluginPool),
 		FunctionState: struct {
-			stopped          map[string]bool
-			previouslyLoaded map[string]bool
-			configs          map[string]map[string]string
-			digests          map[string]string
+			stopped          map[components.FunctionKey]bool
+			previouslyLoaded map[components.FunctionKey]bool
+			configs          map[components.FunctionKey]map[string]string
+			digests          map[components.FunctionKey]string
 		}{
-			stopped:          make(map[string]bool),
-			previouslyLoaded: make(map[string]bool),
-			configs:          make(map[string]map[string]string),
-			digests:          make(map[string]string),
+			stopped:          make(map[components.FunctionKey]bool),
+			previouslyLoaded: make(map[components.FunctionKey]bool),
+			configs:          make(map[components.FunctionKey]map[string]string),
+			digests:          make(map[components.FunctionKey]string),
 		},
 		logStore: logging.NewFunctionLogStore(defaultLogStoreCapacity),
 	}
 }
 
-// GetPlugin implements PluginManager.GetPlugin.
-func (m *MockPluginManager) GetPlugin(key string) (*extism.Plugin, bool) {
+// GetPool implements PluginManager.GetPool.
+func (m *MockPluginManager) GetPool(key components.FunctionKey) (*components.PluginPool, bool) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
-	m.Calls.GetPlugin = append(m.Calls.GetPlugin, key)
+	m.Calls.GetPool = append(m.Calls.GetPool, key)
 
-	plugin, exists := m.plugins[key]
-	return plugin, exists
+	pool, exists := m.plugins[key]
+	return pool, exists
 }
 
 // StorePlugin implements PluginManager.StorePlugin.
-func (m *MockPluginManager) StorePlugin(key string, plugin *extism.Plugin, digest string, config map[string]string) {
+func (m *MockPluginManager) StorePlugin(key components.FunctionKey, plugin *extism.Plugin, _ components.PluginFactory,
+	digest string, config map[string]string) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	m.Calls.StorePlugin = append(m.Calls.StorePlugin, key)
 
-	m.plugins[key] = plugin
+	// Pin the mock pool to the given instance
+	m.plugins[key] = components.NewPluginPool(key, plugin, nil, components.PoolSettings{},
+		logging.NewStdLogger(io.Discard), m.logStore)
 	m.FunctionState.previouslyLoaded[key] = true
 
 	if digest != "" {
@@ -123,7 +129,16 @@ func (m *MockPluginManager) StorePlugin(key string, plugin *extism.Plugin, diges
 }
 
 // RemovePlugin implements PluginManager.RemovePlugin.
-func (m *MockPluginManager) RemovePlugin(key string) bool {
+// ScaleFunction implements PluginManager.ScaleFunction.
+func (m *MockPluginManager) ScaleFunction(key components.FunctionKey, _ int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.Calls.ScaleFunction = append(m.Calls.ScaleFunction, key)
+	return nil
+}
+
+func (m *MockPluginManager) RemovePlugin(key components.FunctionKey) bool {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -139,7 +154,7 @@ func (m *MockPluginManager) RemovePlugin(key string) bool {
 }
 
 // StopFunction implements PluginManager.StopFunction.
-func (m *MockPluginManager) StopFunction(key string) bool {
+func (m *MockPluginManager) StopFunction(key components.FunctionKey) bool {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -157,7 +172,7 @@ func (m *MockPluginManager) StopFunction(key string) bool {
 }
 
 // IsFunctionStopped implements PluginManager.IsFunctionStopped.
-func (m *MockPluginManager) IsFunctionStopped(key string) bool {
+func (m *MockPluginManager) IsFunctionStopped(key components.FunctionKey) bool {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -171,7 +186,7 @@ func (m *MockPluginManager) IsFunctionStopped(key string) bool {
 }
 
 // ClearStoppedStatus implements PluginManager.ClearStoppedStatus.
-func (m *MockPluginManager) ClearStoppedStatus(key string) {
+func (m *MockPluginManager) ClearStoppedStatus(key components.FunctionKey) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -181,7 +196,7 @@ func (m *MockPluginManager) ClearStoppedStatus(key string) {
 }
 
 // IsPluginLoaded implements PluginManager.IsPluginLoaded.
-func (m *MockPluginManager) IsPluginLoaded(key string) bool {
+func (m *MockPluginManager) IsPluginLoaded(key components.FunctionKey) bool {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -192,7 +207,7 @@ func (m *MockPluginManager) IsPluginLoaded(key string) bool {
 }
 
 // WasPreviouslyLoaded implements PluginManager.WasPreviouslyLoaded.
-func (m *MockPluginManager) WasPreviouslyLoaded(key string) (bool, map[string]string) {
+func (m *MockPluginManager) WasPreviouslyLoaded(key components.FunctionKey) (bool, map[string]string) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -217,7 +232,7 @@ func (m *MockPluginManager) WasPreviouslyLoaded(key string) (bool, map[string]st
 }
 
 // HasConfigChanged implements PluginManager.HasConfigChanged.
-func (m *MockPluginManager) HasConfigChanged(key string, newConfig map[string]string) bool {
+func (m *MockPluginManager) HasConfigChanged(key components.FunctionKey, newConfig map[string]string) bool {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -246,7 +261,7 @@ func (m *MockPluginManager) HasConfigChanged(key string, newConfig map[string]st
 }
 
 // HasDigestChanged implements PluginManager.HasDigestChanged.
-func (m *MockPluginManager) HasDigestChanged(key string, newDigest string) bool {
+func (m *MockPluginManager) HasDigestChanged(key components.FunctionKey, newDigest string) bool {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -261,7 +276,7 @@ func (m *MockPluginManager) HasDigestChanged(key string, newDigest string) bool
 }
 
 // GetPluginDigest implements PluginManager.GetPluginDigest.
-func (m *MockPluginManager) GetPluginDigest(key string) (string, bool) {
+func (m *MockPluginManager) GetPluginDigest(key components.FunctionKey) (string, bool) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -272,7 +287,7 @@ func (m *MockPluginManager) GetPluginDigest(key string) (string, bool) {
 }
 
 // GetPluginConfig implements PluginManager.GetPluginConfig.
-func (m *MockPluginManager) GetPluginConfig(key string) (map[string]string, bool) {
+func (m *MockPluginManager) GetPluginConfig(key components.FunctionKey) (map[string]string, bool) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -305,19 +320,19 @@ func (m *MockPluginManager) Shutdown() {
 }
 
 // GetLogStore implements PluginManager.GetLogStore.
-func (m *MockPluginManager) GetLogStore() *logging.FunctionLogStore {
+func (m *MockPluginManager) GetLogStore() logging.LogStore {
 	m.Calls.GetLogStore++
 	return m.logStore
 }
 
 // ListLoadedFunctions implements PluginManager.ListLoadedFunctions.
-func (m *MockPluginManager) ListLoadedFunctions() []string {
+func (m *MockPluginManager) ListLoadedFunctions() []components.FunctionKey {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
 	m.Calls.ListLoadedFunctions++
 
-	keys := make([]string, 0, len(m.plugins))
+	keys := make([]components.FunctionKey, 0, len(m.plugins))
 	for key := range m.plugins {
 		keys = append(keys, key)
 	}
@@ -325,6 +340,19 @@ func (m *MockPluginManager) ListLoadedFunctions() []string {
 	return keys
 }
 
+// GetPoolStats implements PluginManager.GetPoolStats.
+func (m *MockPluginManager) GetPoolStats() map[components.FunctionKey]components.PoolStats {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	stats := make(map[components.FunctionKey]components.PoolStats, len(m.plugins))
+	for key, pool := range m.plugins {
+		stats[key] = pool.Stats()
+	}
+
+	return stats
+}
+
 // GetLoadedFunctionCount implements PluginManager.GetLoadedFunctionCount.
 func (m *MockPluginManager) GetLoadedFunctionCount() int {
 	m.mutex.RLock()
@@ -336,14 +364,14 @@ func (m *MockPluginManager) GetLoadedFunctionCount() int {
 }
 
 // GetPreviouslyLoadedFunctions implements PluginManager.GetPreviouslyLoadedFunctions.
-func (m *MockPluginManager) GetPreviouslyLoadedFunctions() map[string]bool {
+func (m *MockPluginManager) GetPreviouslyLoadedFunctions() map[components.FunctionKey]bool {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
 	m.Calls.GetPreviouslyLoadedFunctions++
 
 	// Copy the map to avoid mutation
-	result := make(map[string]bool, len(m.FunctionState.previouslyLoaded))
+	result := make(map[components.FunctionKey]bool, len(m.FunctionState.previouslyLoaded))
 	for k, v := range m.FunctionState.previouslyLoaded {
 		result[k] = v
 	}
@@ -352,14 +380,14 @@ func (m *MockPluginManager) GetPreviouslyLoadedFunctions() map[string]bool {
 }
 
 // GetStoppedFunctions implements PluginManager.GetStoppedFunctions.
-func (m *MockPluginManager) GetStoppedFunctions() map[string]bool {
+func (m *MockPluginManager) GetStoppedFunctions() map[components.FunctionKey]bool {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
 	m.Calls.GetStoppedFunctions++
 
 	// Copy the map to avoid mutation
-	result := make(map[string]bool, len(m.FunctionState.stopped))
+	result := make(map[components.FunctionKey]bool, len(m.FunctionState.stopped))
 	for k, v := range m.FunctionState.stopped {
 		result[k] = v
 	}
@@ -367,6 +395,16 @@ func (m *MockPluginManager) GetStoppedFunctions() map[string]bool {
 	return result
 }
 
+// GetFunctionScales implements PluginManager.GetFunctionScales.
+func (m *MockPluginManager) GetFunctionScales() map[components.FunctionKey]int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.Calls.GetFunctionScales++
+
+	return map[components.FunctionKey]int{}
+}
+
 // MockCircuitBreaker is a mock implementation of CircuitBreaker for testing.
 type MockCircuitBreaker struct {
 	State        string
@@ -378,6 +416,7 @@ type MockCircuitBreaker struct {
 		RecordFailure   int
 		IsOpen          int
 		Reset           int
+		Trip            int
 		GetState        int
 		GetFailureCount int
 	}
@@ -442,6 +481,13 @@ func (m *MockCircuitBreaker) Reset() {
 	m.FailureCount = 0
 }
 
+// Trip implements CircuitBreaker.Trip.
+func (m *MockCircuitBreaker) Trip() {
+	m.Calls.Trip++
+
+	m.State = circuitStateOpen
+}
+
 // GetState implements CircuitBreaker.GetState.
 func (m *MockCircuitBreaker) GetState() string {
 	m.Calls.GetState++
@@ -458,15 +504,15 @@ func (m *MockCircuitBreaker) GetFailureCount() int {
 
 // MockCircuitBreakerManager is a mock implementation of CircuitBreakerManager for testing.
 type MockCircuitBreakerManager struct {
-	circuitBreakers map[string]*MockCircuitBreaker
+	circuitBreakers map[components.FunctionKey]*MockCircuitBreaker
 	mutex           sync.RWMutex
 
 	// Function call tracking for assertions
 	Calls struct {
-		GetCircuitBreaker      []string
-		RemoveCircuitBreaker   []string
+		GetCircuitBreaker      []components.FunctionKey
+		RemoveCircuitBreaker   []components.FunctionKey
 		Reset                  int
-		GetCircuitBreakerState []string
+		GetCircuitBreakerState []components.FunctionKey
 		GetAllCircuitBreakers  int
 	}
 }
@@ -474,12 +520,12 @@ type MockCircuitBreakerManager struct {
 // NewMockCircuitBreakerManager creates a new mock circuit breaker manager.
 func NewMockCircuitBreakerManager() *MockCircuitBreakerManager {
 	return &MockCircuitBreakerManager{
-		circuitBreakers: make(map[string]*MockCircuitBreaker),
+		circuitBreakers: make(map[components.FunctionKey]*MockCircuitBreaker),
 	}
 }
 
 // GetCircuitBreaker implements CircuitBreakerManager.GetCircuitBreaker.
-func (m *MockCircuitBreakerManager) GetCircuitBreaker(key string) components.CircuitBreaker {
+func (m *MockCircuitBreakerManager) GetCircuitBreaker(key components.FunctionKey) components.CircuitBreaker {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -495,7 +541,7 @@ func (m *MockCircuitBreakerManager) GetCircuitBreaker(key string) components.Cir
 }
 
 // RemoveCircuitBreaker implements CircuitBreakerManager.RemoveCircuitBreaker.
-func (m *MockCircuitBreakerManager) RemoveCircuitBreaker(key string) {
+func (m *MockCircuitBreakerManager) RemoveCircuitBreaker(key components.FunctionKey) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -517,7 +563,7 @@ func (m *MockCircuitBreakerManager) Reset() {
 }
 
 // GetCircuitBreakerState implements CircuitBreakerManager.GetCircuitBreakerState.
-func (m *MockCircuitBreakerManager) GetCircuitBreakerState(key string) string {
+func (m *MockCircuitBreakerManager) GetCircuitBreakerState(key components.FunctionKey) string {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -532,13 +578,13 @@ func (m *MockCircuitBreakerManager) GetCircuitBreakerState(key string) string {
 }
 
 // GetAllCircuitBreakers implements CircuitBreakerManager.GetAllCircuitBreakers.
-func (m *MockCircuitBreakerManager) GetAllCircuitBreakers() map[string]components.CircuitBreaker {
+func (m *MockCircuitBreakerManager) GetAllCircuitBreakers() map[components.FunctionKey]components.CircuitBreaker {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
 	m.Calls.GetAllCircuitBreakers++
 
-	result := make(map[string]components.CircuitBreaker, len(m.circuitBreakers))
+	result := make(map[components.FunctionKey]components.CircuitBreaker, len(m.circuitBreakers))
 	for key, cb := range m.circuitBreakers {
 		result[key] = cb
 	}
@@ -546,6 +592,30 @@ func (m *MockCircuitBreakerManager) GetAllCircuitBreakers() map[string]component
 	return result
 }
 
+// GetEntrypointCircuitBreaker implements CircuitBreakerManager.GetEntrypointCircuitBreaker.
+// The mock shares one breaker between the entrypoints of a function.
+func (m *MockCircuitBreakerManager) GetEntrypointCircuitBreaker(key components.FunctionKey, _ string) components.CircuitBreaker {
+	return m.GetCircuitBreaker(key)
+}
+
+// ListCircuitBreakers implements CircuitBreakerManager.ListCircuitBreakers.
+func (m *MockCircuitBreakerManager) ListCircuitBreakers() []components.CircuitBreakerStatus {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	statuses := make([]components.CircuitBreakerStatus, 0, len(m.circuitBreakers))
+	for key, cb := range m.circuitBreakers {
+		statuses = append(statuses, components.CircuitBreakerStatus{
+			Namespace: key.Namespace,
+			Function:  key.Name,
+			State:     cb.State,
+			Failures:  cb.FailureCount,
+		})
+	}
+
+	return statuses
+}
+
 // MockRegistry is a mock implementation of Registry for testing.
 type MockRegistry struct {
 	Functions map[string]map[string]*registry.FunctionMetadata
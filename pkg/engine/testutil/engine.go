@@ -0,0 +1,284 @@
+// Package testutil runs a complete Ignition engine in-process for integration tests.
+//
+// An engine started with Start has its own temporary registry, serves the admin API
+// on a Unix socket and the HTTP endpoint on a free local port, and shuts down when
+// the test ends:
+//
+//	eng := testutil.Start(t)
+//	eng.Push("ns", "echo", "latest", testutil.EchoModule)
+//	require.NoError(t, eng.Load("ns", "echo", "latest"))
+//	output, err := eng.Call("ns", "echo", testutil.EntrypointEcho, []byte("hello"))
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine"
+	"github.com/ignitionstack/ignition/pkg/engine/client"
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+// startTimeout bounds how long Start waits for the engine to accept requests
+const startTimeout = 10 * time.Second
+
+// Engine is an engine running in the test process.
+type Engine struct {
+	*engine.Engine
+
+	// Client talks to the admin API over the engine socket
+	Client *client.EngineClient
+
+	SocketPath  string
+	HTTPAddr    string
+	RegistryDir string
+
+	// Address of the TCP admin listener, empty unless set through the options
+	AdminAddr string
+
+	t        testing.TB
+	cancel   context.CancelFunc
+	done     chan error
+	stopOnce sync.Once
+}
+
+// Option adjusts the engine options before the engine starts.
+type Option func(*engine.Options)
+
+// WithOptions applies arbitrary changes to the engine options, such as pool sizes
+// or the plugin TTL.
+func WithOptions(fn func(*engine.Options)) Option {
+	return Option(fn)
+}
+
+// WithClock makes the engine read time from clock, typically a components.FakeClock.
+func WithClock(clock components.Clock) Option {
+	return func(o *engine.Options) {
+		o.WithClock(clock)
+	}
+}
+
+// WithDefaultTimeout sets the timeout of calls that do not set their own.
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return func(o *engine.Options) {
+		o.WithDefaultTimeout(timeout)
+	}
+}
+
+// Start runs an engine until the test ends, failing the test if it does not come up.
+func Start(t testing.TB, opts ...Option) *Engine {
+	t.Helper()
+
+	// Unix socket paths are short, so the socket does not live under t.TempDir
+	socketDir, err := os.MkdirTemp("", "ignition-")
+	if err != nil {
+		t.Fatalf("failed to create socket directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(socketDir) })
+
+	options := engine.DefaultEngineOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	e := &Engine{
+		SocketPath:  filepath.Join(socketDir, "engine.sock"),
+		HTTPAddr:    FreeAddr(t),
+		RegistryDir: t.TempDir(),
+		AdminAddr:   options.AdminAddr,
+		t:           t,
+		done:        make(chan error, 1),
+	}
+
+	e.Engine, err = engine.NewEngineWithOptions(e.SocketPath, e.HTTPAddr, e.RegistryDir,
+		logging.NewStdLogger(io.Discard), options)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(context.Background())
+	go func() {
+		e.done <- e.Engine.Run(ctx)
+	}()
+	t.Cleanup(e.Shutdown)
+
+	e.Client, err = client.NewEngineClient(e.SocketPath)
+	if err != nil {
+		t.Fatalf("failed to create engine client: %v", err)
+	}
+	e.waitUntilReady()
+
+	return e
+}
+
+// waitUntilReady polls the engine socket and HTTP endpoint until both answer.
+func (e *Engine) waitUntilReady() {
+	e.t.Helper()
+
+	deadline := time.Now().Add(startTimeout)
+	for {
+		select {
+		case err := <-e.done:
+			e.t.Fatalf("engine stopped while starting: %v", err)
+		default:
+		}
+
+		if e.Client.Ping(context.Background()) == nil {
+			if resp, err := http.Get("http://" + e.HTTPAddr + "/healthz"); err == nil {
+				resp.Body.Close()
+				return
+			}
+		}
+
+		if time.Now().After(deadline) {
+			e.t.Fatalf("engine did not start within %v", startTimeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Shutdown stops the engine before the test ends, as when the process goes away.
+func (e *Engine) Shutdown() {
+	e.stopOnce.Do(e.stop)
+}
+
+// stop shuts the servers down and closes the registry.
+func (e *Engine) stop() {
+	e.cancel()
+	select {
+	case err := <-e.done:
+		if err != nil {
+			e.t.Errorf("engine stopped with an error: %v", err)
+		}
+	case <-time.After(startTimeout):
+		e.t.Errorf("engine did not stop within %v", startTimeout)
+	}
+
+	if err := e.Engine.Close(); err != nil {
+		e.t.Errorf("failed to close engine: %v", err)
+	}
+}
+
+// Push stores a wasm module in the registry under the tag and returns its digest,
+// failing the test if the registry rejects it.
+func (e *Engine) Push(namespace, name, tag string, module []byte) string {
+	e.t.Helper()
+	return e.PushWithSettings(namespace, name, tag, module, manifest.FunctionVersionSettings{})
+}
+
+// PushWithSettings is Push for a version with settings, such as http_request.
+func (e *Engine) PushWithSettings(namespace, name, tag string, module []byte, settings manifest.FunctionVersionSettings) string {
+	e.t.Helper()
+
+	sum := sha256.Sum256(module)
+	digest := hex.EncodeToString(sum[:])
+	if err := e.GetRegistry().Push(namespace, name, module, digest, tag, settings); err != nil {
+		e.t.Fatalf("failed to push %s/%s:%s: %v", namespace, name, tag, err)
+	}
+	return digest
+}
+
+// Load loads a pushed function through the admin API.
+func (e *Engine) Load(namespace, name, reference string) error {
+	return e.Client.LoadFunction(context.Background(), namespace, name, reference, nil)
+}
+
+// Unload unloads a function through the admin API.
+func (e *Engine) Unload(namespace, name string) error {
+	return e.Client.UnloadFunction(context.Background(), namespace, name)
+}
+
+// Stop stops a function through the admin API.
+func (e *Engine) Stop(namespace, name string) error {
+	return e.Client.StopFunction(context.Background(), namespace, name)
+}
+
+// CallError is returned by Call when the HTTP endpoint answers with an error status.
+type CallError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *CallError) Error() string {
+	return fmt.Sprintf("call failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Call invokes an entrypoint through the public HTTP endpoint, like a client of the
+// engine would. The payload is sent as the request's payload string.
+func (e *Engine) Call(namespace, name, entrypoint string, payload []byte) ([]byte, error) {
+	return e.CallWithTimeout(namespace, name, entrypoint, payload, 0)
+}
+
+// CallWithTimeout is like Call, with a call deadline sent in the timeout header.
+func (e *Engine) CallWithTimeout(namespace, name, entrypoint string, payload []byte, timeout time.Duration) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"payload": string(payload)})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("http://%s/%s/%s/%s", e.HTTPAddr, namespace, name, entrypoint)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if timeout > 0 {
+		req.Header.Set(types.TimeoutHeader, timeout.String())
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	output, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		message := string(output)
+		if json.Unmarshal(output, &errResp) == nil && errResp.Error != "" {
+			message = errResp.Error
+		}
+		return nil, &CallError{StatusCode: resp.StatusCode, Message: message}
+	}
+	return output, nil
+}
+
+// Logs returns the log lines the engine kept for a function.
+func (e *Engine) Logs(namespace, name string) []string {
+	return e.FunctionLogs(namespace, name, time.Time{}, 0)
+}
+
+// FreeAddr returns a local TCP address that was free a moment ago, for listeners set
+// through the options.
+func FreeAddr(t testing.TB) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
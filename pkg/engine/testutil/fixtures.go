@@ -0,0 +1,125 @@
+package testutil
+
+// Entrypoints exported by EchoModule.
+const (
+	// EntrypointEcho returns its input as output
+	EntrypointEcho = "echo"
+
+	// EntrypointRun returns without output
+	EntrypointRun = "run"
+
+	// EntrypointTrap executes unreachable, which crashes the instance
+	EntrypointTrap = "trap"
+
+	// EntrypointSpin loops forever, until the call is interrupted
+	EntrypointSpin = "spin"
+)
+
+// EchoModule is a prebuilt wasm module exporting EntrypointEcho, EntrypointRun,
+// EntrypointTrap and EntrypointSpin. It only imports the Extism kernel, so it loads
+// without WASI or host functions.
+var EchoModule = buildEchoModule()
+
+// wasm binary encoding constants
+const (
+	sectionType     = 0x01
+	sectionImport   = 0x02
+	sectionFunction = 0x03
+	sectionExport   = 0x07
+	sectionCode     = 0x0a
+
+	kindFunc = 0x00
+	typeFunc = 0x60
+	typeI32  = 0x7f
+	typeI64  = 0x7e
+
+	opUnreachable = 0x00
+	opLoop        = 0x03
+	opBr          = 0x0c
+	opEnd         = 0x0b
+	opCall        = 0x10
+	opI32Const    = 0x41
+	blockVoid     = 0x40
+)
+
+func buildEchoModule() []byte {
+	const kernel = "extism:host/env"
+
+	// Types: 0 is () -> i64, 1 is (i64, i64) -> (), 2 is () -> i32
+	types := vector(
+		[]byte{typeFunc, 0x00, 0x01, typeI64},
+		[]byte{typeFunc, 0x02, typeI64, typeI64, 0x00},
+		[]byte{typeFunc, 0x00, 0x01, typeI32},
+	)
+
+	// Imported functions 0, 1 and 2
+	imports := vector(
+		concat(name(kernel), name("input_offset"), []byte{kindFunc, 0x00}),
+		concat(name(kernel), name("input_length"), []byte{kindFunc, 0x00}),
+		concat(name(kernel), name("output_set"), []byte{kindFunc, 0x01}),
+	)
+
+	// Defined functions 3 to 6, in the order of their bodies
+	entrypoints := []string{EntrypointEcho, EntrypointRun, EntrypointTrap, EntrypointSpin}
+	bodies := [][]byte{
+		// output_set(input_offset(), input_length()); return 0
+		{opCall, 0x00, opCall, 0x01, opCall, 0x02, opI32Const, 0x00, opEnd},
+		{opI32Const, 0x00, opEnd},
+		{opUnreachable, opEnd},
+		{opLoop, blockVoid, opBr, 0x00, opEnd, opI32Const, 0x00, opEnd},
+	}
+
+	functions := make([][]byte, len(bodies))
+	exports := make([][]byte, len(entrypoints))
+	code := make([][]byte, len(bodies))
+	for i, body := range bodies {
+		functions[i] = []byte{0x02}
+		exports[i] = concat(name(entrypoints[i]), []byte{kindFunc, byte(3 + i)})
+		code[i] = sized(append([]byte{0x00}, body...)) // no locals
+	}
+
+	return concat(
+		[]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		section(sectionType, types),
+		section(sectionImport, imports),
+		section(sectionFunction, vector(functions...)),
+		section(sectionExport, vector(exports...)),
+		section(sectionCode, vector(code...)),
+	)
+}
+
+func section(id byte, body []byte) []byte {
+	return append([]byte{id}, sized(body)...)
+}
+
+func vector(items ...[]byte) []byte {
+	return concat(append([][]byte{uleb128(len(items))}, items...)...)
+}
+
+func sized(body []byte) []byte {
+	return append(uleb128(len(body)), body...)
+}
+
+func name(s string) []byte {
+	return append(uleb128(len(s)), s...)
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+func uleb128(n int) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	domainerrors "github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/ignitionstack/ignition/pkg/engine/usage"
+)
+
+// startUsagePersistence writes usage counters to the registry database on the configured
+// interval until ctx is done.
+func (e *Engine) startUsagePersistence(ctx context.Context) {
+	interval := e.options.Usage.PersistInterval
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.flushUsage()
+			}
+		}
+	}()
+}
+
+// flushUsage persists pending usage counters. Failures are logged and retried on the next flush.
+func (e *Engine) flushUsage() {
+	if err := e.usage.Flush(); err != nil {
+		e.logger.Errorf("Failed to persist usage: %v", err)
+	}
+}
+
+// meterCall counts a call to a function of the namespace. Calls that never ran, because
+// the function was not loaded or its circuit breaker was open, are not counted.
+func (e *Engine) meterCall(namespace string, elapsed time.Duration, err error) {
+	if errors.Is(err, ErrFunctionNotLoaded) ||
+		domainerrors.Is(err, domainerrors.DomainFunction, domainerrors.CodeFunctionNotLoaded) ||
+		domainerrors.Is(err, domainerrors.DomainExecution, domainerrors.CodeCircuitBreakerOpen) {
+		return
+	}
+	e.usage.Record(namespace, elapsed)
+}
+
+// NamespaceUsage returns the usage of a namespace in the current UTC day and month.
+func (e *Engine) NamespaceUsage(namespace string) (usage.Report, error) {
+	return e.usage.Report(namespace)
+}
+
+// UsageReports returns the usage of every namespace with calls this month or a quota of its own.
+func (e *Engine) UsageReports() ([]usage.Report, error) {
+	return e.usage.Reports()
+}
+
+// quotaError converts a quota refusal into a 429 response, returning nil for other errors.
+func quotaError(err error) *RequestError {
+	var exceeded *usage.QuotaExceededError
+	if !errors.As(err, &exceeded) {
+		return nil
+	}
+	reqErr := NewRequestErrorWithCause(
+		fmt.Sprintf("%s, resets at %s", exceeded.Error(), exceeded.ResetsAt.Format(time.RFC3339)),
+		http.StatusTooManyRequests, err)
+	return &reqErr
+}
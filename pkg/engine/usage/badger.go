@@ -0,0 +1,119 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/internal/repository"
+)
+
+// keyPrefix namespaces usage counters inside the shared registry database
+const keyPrefix = "usage:"
+
+// dailyRetention is how long counters of a day are kept; monthly counters are kept forever
+const dailyRetention = 90 * 24 * time.Hour
+
+// badgerStore keeps counters in Badger under usage:<period>/<namespace>.
+type badgerStore struct {
+	dbRepo repository.DBRepository
+}
+
+// NewBadgerStore creates a usage store backed by the given database.
+func NewBadgerStore(dbRepo repository.DBRepository) Store {
+	return &badgerStore{dbRepo: dbRepo}
+}
+
+func (s *badgerStore) Add(period string, deltas map[string]Counters) error {
+	err := s.dbRepo.Update(func(txn *badger.Txn) error {
+		for namespace, delta := range deltas {
+			key := counterKey(period, namespace)
+			counters, err := getCounters(txn, key)
+			if err != nil {
+				return err
+			}
+
+			value, err := json.Marshal(counters.add(delta))
+			if err != nil {
+				return err
+			}
+			entry := badger.NewEntry(key, value)
+			if len(period) == len(time.DateOnly) {
+				entry = entry.WithTTL(dailyRetention)
+			}
+			if err := txn.SetEntry(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store usage: %w", err)
+	}
+	return nil
+}
+
+func (s *badgerStore) Get(period, namespace string) (Counters, error) {
+	var counters Counters
+	err := s.dbRepo.View(func(txn *badger.Txn) error {
+		var err error
+		counters, err = getCounters(txn, counterKey(period, namespace))
+		return err
+	})
+	if err != nil {
+		return Counters{}, fmt.Errorf("failed to read usage: %w", err)
+	}
+	return counters, nil
+}
+
+func (s *badgerStore) List(period string) (map[string]Counters, error) {
+	prefix := []byte(keyPrefix + period + "/")
+	usage := map[string]Counters{}
+
+	err := s.dbRepo.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			namespace := strings.TrimPrefix(string(it.Item().Key()), string(prefix))
+			err := it.Item().Value(func(val []byte) error {
+				var counters Counters
+				if err := json.Unmarshal(val, &counters); err != nil {
+					return fmt.Errorf("failed to unmarshal usage of %s: %w", namespace, err)
+				}
+				usage[namespace] = counters
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage: %w", err)
+	}
+	return usage, nil
+}
+
+func getCounters(txn *badger.Txn, key []byte) (Counters, error) {
+	var counters Counters
+	item, err := txn.Get(key)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return counters, nil
+	}
+	if err != nil {
+		return counters, err
+	}
+	err = item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &counters)
+	})
+	return counters, err
+}
+
+func counterKey(period, namespace string) []byte {
+	return []byte(keyPrefix + period + "/" + namespace)
+}
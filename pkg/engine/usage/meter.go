@@ -0,0 +1,222 @@
+package usage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+)
+
+// periodKey identifies the counters of a namespace in a day or month
+type periodKey struct {
+	period    string
+	namespace string
+}
+
+// Meter counts invocations in memory and adds them to the store on Flush. Counters
+// read from the store are cached for the current day and month.
+type Meter struct {
+	store Store
+	cfg   config.UsageConfig
+	now   func() time.Time
+
+	mu        sync.Mutex
+	persisted map[periodKey]Counters
+	pending   map[periodKey]Counters
+}
+
+// NewMeter creates a meter enforcing the configured quotas. A nil now uses time.Now.
+func NewMeter(store Store, cfg config.UsageConfig, now func() time.Time) *Meter {
+	if now == nil {
+		now = time.Now
+	}
+	return &Meter{
+		store:     store,
+		cfg:       cfg,
+		now:       now,
+		persisted: make(map[periodKey]Counters),
+		pending:   make(map[periodKey]Counters),
+	}
+}
+
+// QuotaFor returns the quota of a namespace: its own if configured, the default otherwise.
+func (m *Meter) QuotaFor(namespace string) Quota {
+	quota, ok := m.cfg.Quotas[namespace]
+	if !ok {
+		quota = m.cfg.DefaultQuota
+	}
+	return Quota(quota)
+}
+
+// Record counts one invocation that ran for elapsed.
+func (m *Meter) Record(namespace string, elapsed time.Duration) {
+	now := m.now()
+	delta := Counters{Invocations: 1, ExecutionSeconds: elapsed.Seconds()}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, period := range []string{Day(now), Month(now)} {
+		key := periodKey{period, namespace}
+		m.pending[key] = m.pending[key].add(delta)
+	}
+}
+
+// Check returns a *QuotaExceededError when the namespace has used up its quota. Calls
+// running concurrently are only counted once they end, so they may overshoot it.
+func (m *Meter) Check(namespace string) error {
+	quota := m.QuotaFor(namespace)
+	if quota.IsZero() {
+		return nil
+	}
+
+	now := m.now().UTC()
+	daily, monthly, err := m.usage(namespace, now)
+	if err != nil {
+		return err
+	}
+
+	nextDay := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	exceeded := func(limit string, resetsAt time.Time) error {
+		return &QuotaExceededError{Namespace: namespace, Limit: limit, ResetsAt: resetsAt}
+	}
+
+	switch {
+	case quota.MonthlyInvocations > 0 && monthly.Invocations >= quota.MonthlyInvocations:
+		return exceeded("monthly invocation", nextMonth)
+	case quota.MonthlySeconds > 0 && monthly.ExecutionSeconds >= quota.MonthlySeconds:
+		return exceeded("monthly execution time", nextMonth)
+	case quota.DailyInvocations > 0 && daily.Invocations >= quota.DailyInvocations:
+		return exceeded("daily invocation", nextDay)
+	case quota.DailySeconds > 0 && daily.ExecutionSeconds >= quota.DailySeconds:
+		return exceeded("daily execution time", nextDay)
+	}
+	return nil
+}
+
+// Report returns the usage of a namespace in the current day and month.
+func (m *Meter) Report(namespace string) (Report, error) {
+	now := m.now()
+	daily, monthly, err := m.usage(namespace, now)
+	if err != nil {
+		return Report{}, err
+	}
+	return m.report(namespace, now, daily, monthly), nil
+}
+
+// Reports returns the usage of every namespace that has used functions this month or
+// has a quota of its own, sorted by namespace.
+func (m *Meter) Reports() ([]Report, error) {
+	now := m.now()
+	day, month := Day(now), Month(now)
+
+	daily, err := m.store.List(day)
+	if err != nil {
+		return nil, err
+	}
+	monthly, err := m.store.List(month)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	for key, pending := range m.pending {
+		switch key.period {
+		case day:
+			daily[key.namespace] = daily[key.namespace].add(pending)
+		case month:
+			monthly[key.namespace] = monthly[key.namespace].add(pending)
+		}
+	}
+	m.mu.Unlock()
+
+	namespaces := make([]string, 0, len(monthly))
+	for namespace := range monthly {
+		namespaces = append(namespaces, namespace)
+	}
+	for namespace := range m.cfg.Quotas {
+		if _, ok := monthly[namespace]; !ok {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	reports := make([]Report, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		reports = append(reports, m.report(namespace, now, daily[namespace], monthly[namespace]))
+	}
+	return reports, nil
+}
+
+func (m *Meter) report(namespace string, now time.Time, daily, monthly Counters) Report {
+	report := Report{
+		Namespace: namespace,
+		Day:       Day(now),
+		Month:     Month(now),
+		Daily:     daily,
+		Monthly:   monthly,
+	}
+	if quota := m.QuotaFor(namespace); !quota.IsZero() {
+		report.Quota = &quota
+	}
+	return report
+}
+
+// Flush adds the pending counters to the store and drops cached counters of past periods.
+// Counters that could not be stored stay pending for the next flush.
+func (m *Meter) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byPeriod := make(map[string]map[string]Counters)
+	for key, pending := range m.pending {
+		if byPeriod[key.period] == nil {
+			byPeriod[key.period] = make(map[string]Counters)
+		}
+		byPeriod[key.period][key.namespace] = pending
+	}
+
+	for period, deltas := range byPeriod {
+		if err := m.store.Add(period, deltas); err != nil {
+			return err
+		}
+		for namespace, delta := range deltas {
+			key := periodKey{period, namespace}
+			if persisted, ok := m.persisted[key]; ok {
+				m.persisted[key] = persisted.add(delta)
+			}
+			delete(m.pending, key)
+		}
+	}
+
+	now := m.now()
+	day, month := Day(now), Month(now)
+	for key := range m.persisted {
+		if key.period != day && key.period != month {
+			delete(m.persisted, key)
+		}
+	}
+	return nil
+}
+
+// usage returns the daily and monthly counters of a namespace, stored and pending.
+func (m *Meter) usage(namespace string, now time.Time) (Counters, Counters, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var counters [2]Counters
+	for i, period := range []string{Day(now), Month(now)} {
+		key := periodKey{period, namespace}
+		persisted, ok := m.persisted[key]
+		if !ok {
+			var err error
+			if persisted, err = m.store.Get(period, namespace); err != nil {
+				return Counters{}, Counters{}, err
+			}
+			m.persisted[key] = persisted
+		}
+		counters[i] = persisted.add(m.pending[key])
+	}
+	return counters[0], counters[1], nil
+}
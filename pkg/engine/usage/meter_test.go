@@ -0,0 +1,87 @@
+package usage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/internal/repository"
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) Store {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return NewBadgerStore(repository.NewBadgerDBRepository(db))
+}
+
+func TestMeterQuotas(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)
+	cfg := config.UsageConfig{
+		DefaultQuota: config.QuotaConfig{DailyInvocations: 2},
+		Quotas:       map[string]config.QuotaConfig{"batch": {MonthlySeconds: 10}},
+	}
+	meter := NewMeter(store, cfg, func() time.Time { return now })
+
+	require.NoError(t, meter.Check("web"))
+	meter.Record("web", time.Second)
+	meter.Record("web", time.Second)
+
+	err := meter.Check("web")
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	var exceeded *QuotaExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, "daily invocation", exceeded.Limit)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), exceeded.ResetsAt)
+
+	// Namespace quotas replace the default
+	meter.Record("batch", 4*time.Second)
+	meter.Record("batch", 4*time.Second)
+	meter.Record("batch", 4*time.Second)
+	assert.ErrorContains(t, meter.Check("batch"), "namespace batch exceeded its monthly execution time quota")
+
+	// Counters survive a restart once flushed
+	require.NoError(t, meter.Flush())
+	restarted := NewMeter(store, cfg, func() time.Time { return now })
+	assert.ErrorIs(t, restarted.Check("web"), ErrQuotaExceeded)
+
+	// A new day resets the daily counters
+	now = now.Add(2 * time.Hour)
+	assert.NoError(t, restarted.Check("web"))
+}
+
+func TestMeterReports(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	cfg := config.UsageConfig{Quotas: map[string]config.QuotaConfig{"idle": {DailyInvocations: 5}}}
+	meter := NewMeter(store, cfg, func() time.Time { return now })
+
+	meter.Record("web", 500*time.Millisecond)
+	require.NoError(t, meter.Flush())
+	meter.Record("web", 250*time.Millisecond)
+
+	report, err := meter.Report("web")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10-15", report.Day)
+	assert.Equal(t, "2026-10", report.Month)
+	assert.Equal(t, Counters{Invocations: 2, ExecutionSeconds: 0.75}, report.Daily)
+	assert.Equal(t, Counters{Invocations: 2, ExecutionSeconds: 0.75}, report.Monthly)
+	assert.Nil(t, report.Quota)
+
+	reports, err := meter.Reports()
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, "idle", reports[0].Namespace)
+	assert.Equal(t, &Quota{DailyInvocations: 5}, reports[0].Quota)
+	assert.Equal(t, "web", reports[1].Namespace)
+	assert.Equal(t, int64(2), reports[1].Monthly.Invocations)
+}
@@ -0,0 +1,86 @@
+// Package usage meters function invocations per namespace and enforces quotas on them.
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQuotaExceeded is matched by every call refused because of a quota.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Counters is the usage of a namespace over a period.
+type Counters struct {
+	Invocations      int64   `json:"invocations"`
+	ExecutionSeconds float64 `json:"execution_seconds"`
+}
+
+func (c Counters) add(other Counters) Counters {
+	return Counters{
+		Invocations:      c.Invocations + other.Invocations,
+		ExecutionSeconds: c.ExecutionSeconds + other.ExecutionSeconds,
+	}
+}
+
+// Quota limits the usage of a namespace per UTC day and month (0 means no limit).
+type Quota struct {
+	DailyInvocations   int64   `json:"daily_invocations,omitempty"`
+	MonthlyInvocations int64   `json:"monthly_invocations,omitempty"`
+	DailySeconds       float64 `json:"daily_seconds,omitempty"`
+	MonthlySeconds     float64 `json:"monthly_seconds,omitempty"`
+}
+
+// IsZero reports whether the quota sets no limit.
+func (q Quota) IsZero() bool {
+	return q == Quota{}
+}
+
+// Report is the usage of a namespace in the current day and month.
+type Report struct {
+	Namespace string   `json:"namespace"`
+	Day       string   `json:"day"`
+	Month     string   `json:"month"`
+	Daily     Counters `json:"daily"`
+	Monthly   Counters `json:"monthly"`
+	Quota     *Quota   `json:"quota,omitempty"`
+}
+
+// QuotaExceededError reports the limit a namespace has reached.
+type QuotaExceededError struct {
+	Namespace string
+	Limit     string
+
+	// Start of the next period, when the limit resets
+	ResetsAt time.Time
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("namespace %s exceeded its %s quota", e.Namespace, e.Limit)
+}
+
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Day names the UTC day of t, as 2006-01-02.
+func Day(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// Month names the UTC month of t, as 2006-01.
+func Month(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// Store persists usage counters per period and namespace.
+type Store interface {
+	// Add adds the counters of each namespace to those stored for the period
+	Add(period string, deltas map[string]Counters) error
+
+	// Get returns the counters of a namespace, zero when it has no usage in the period
+	Get(period, namespace string) (Counters, error)
+
+	// List returns the counters of every namespace with usage in the period
+	List(period string) (map[string]Counters, error)
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/ignitionstack/ignition/pkg/engine/errors"
 )
@@ -12,35 +14,113 @@ type Result[T any] struct {
 	Err   error
 }
 
-func ExecuteWithContext[T any](ctx context.Context, operation func() (T, error)) (T, error) {
+// Task describes an operation executed by Run.
+type Task[T any] struct {
+	// Name identifies the operation in errors and observations
+	Name string
+
+	// Interrupt stops the operation once the caller gives up on it, for example by
+	// closing the plugin it runs on. Without it the operation runs to completion.
+	Interrupt func()
+
+	// Discard releases the value of an operation that succeeds after the caller gave
+	// up on it, such as a plugin that nobody would close otherwise.
+	Discard func(T)
+
+	// Observe is called once the operation has returned, even if nobody waits for it.
+	Observe func(Observation)
+}
+
+// Observation describes how an operation executed by Run went.
+type Observation struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+
+	// The operation panicked; Err holds the recovered value
+	Panicked bool
+
+	// The caller gave up before the operation returned
+	Abandoned bool
+}
+
+// Run executes an operation until it returns or the context is done, whichever
+// comes first. When the context is done first, the task is interrupted and the
+// operation's eventual value is discarded, so an abandoned operation neither keeps
+// running longer than it has to nor leaks what it produced. A panic in the
+// operation is returned as an error.
+func Run[T any](ctx context.Context, task Task[T], operation func() (T, error)) (T, error) {
 	var zero T
 
-	// Create a buffered channel to avoid goroutine leaks
-	resultCh := make(chan Result[T], 1)
+	if err := ctx.Err(); err != nil {
+		return zero, contextError(task.Name, err)
+	}
+
+	done := make(chan Result[T])
+	abandon := make(chan struct{})
 
-	// Execute the operation in a goroutine
 	go func() {
-		value, err := operation()
+		start := time.Now()
+		result, panicked := recoverOperation(task.Name, operation)
 
-		// Try to send the result, handling the case where the context is cancelled
+		abandoned := false
 		select {
-		case resultCh <- Result[T]{Value: value, Err: err}:
-			// Result sent successfully
-		case <-ctx.Done():
-			// Context was cancelled, nothing to do
+		case done <- result:
+		case <-abandon:
+			abandoned = true
+			if result.Err == nil && task.Discard != nil {
+				task.Discard(result.Value)
+			}
+		}
+
+		if task.Observe != nil {
+			task.Observe(Observation{
+				Name:      task.Name,
+				Duration:  time.Since(start),
+				Err:       result.Err,
+				Panicked:  panicked,
+				Abandoned: abandoned,
+			})
 		}
 	}()
 
-	// Wait for the result or context cancellation
 	select {
-	case result := <-resultCh:
+	case result := <-done:
 		return result.Value, result.Err
 
 	case <-ctx.Done():
-		if ctx.Err() == context.DeadlineExceeded {
-			return zero, errors.ErrExecutionTimeout
+		close(abandon)
+		if task.Interrupt != nil {
+			task.Interrupt()
+		}
+		return zero, contextError(task.Name, ctx.Err())
+	}
+}
+
+func recoverOperation[T any](name string, operation func() (T, error)) (result Result[T], panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			result = Result[T]{Err: errors.New(errors.DomainExecution, errors.CodeExecutionPanicked,
+				fmt.Sprintf("%s panicked: %v", operationName(name), r))}
 		}
-		return zero, errors.Wrap(errors.DomainExecution, errors.CodeExecutionCancelled,
-			"Operation was cancelled", ctx.Err())
+	}()
+
+	value, err := operation()
+	return Result[T]{Value: value, Err: err}, false
+}
+
+func contextError(name string, err error) error {
+	if err == context.DeadlineExceeded {
+		return errors.ErrExecutionTimeout
+	}
+	return errors.Wrap(errors.DomainExecution, errors.CodeExecutionCancelled,
+		fmt.Sprintf("%s was cancelled", operationName(name)), err)
+}
+
+func operationName(name string) string {
+	if name == "" {
+		return "Operation"
 	}
+	return name
 }
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReturnsResult(t *testing.T) {
+	observed := make(chan Observation, 1)
+	value, err := Run(context.Background(), Task[int]{Name: "Sum", Observe: func(o Observation) { observed <- o }},
+		func() (int, error) { return 42, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 42, value)
+
+	o := <-observed
+	assert.Equal(t, "Sum", o.Name)
+	assert.False(t, o.Abandoned)
+	assert.False(t, o.Panicked)
+}
+
+func TestRunInterruptsAndDiscardsAbandonedOperations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := make(chan struct{})
+	discarded := make(chan int, 1)
+	observed := make(chan Observation, 1)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := Run(ctx, Task[int]{
+		Name:      "Slow",
+		Interrupt: func() { close(stop) },
+		Discard:   func(value int) { discarded <- value },
+		Observe:   func(o Observation) { observed <- o },
+	}, func() (int, error) {
+		<-stop
+		return 7, nil
+	})
+	assert.True(t, errors.Is(err, errors.DomainExecution, errors.CodeExecutionCancelled))
+
+	// The interrupted operation returns, and nobody leaks what it produced
+	assert.Equal(t, 7, <-discarded)
+	assert.True(t, (<-observed).Abandoned)
+}
+
+func TestRunTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := Run(ctx, Task[int]{}, func() (int, error) {
+		time.Sleep(50 * time.Millisecond)
+		return 0, nil
+	})
+	assert.ErrorIs(t, err, errors.ErrExecutionTimeout)
+}
+
+func TestRunRecoversPanics(t *testing.T) {
+	observed := make(chan Observation, 1)
+	_, err := Run(context.Background(), Task[int]{Name: "Call", Observe: func(o Observation) { observed <- o }},
+		func() (int, error) { panic("boom") })
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.DomainExecution, errors.CodeExecutionPanicked))
+	assert.Contains(t, err.Error(), "Call panicked: boom")
+	assert.True(t, (<-observed).Panicked)
+}
@@ -0,0 +1,254 @@
+package engine
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/components"
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+)
+
+// watchdogBundlePrefix starts the name of every diagnostic bundle, so old ones can be pruned
+const watchdogBundlePrefix = "watchdog-"
+
+// ResourceSample is one reading of the resources the watchdog watches.
+type ResourceSample struct {
+	Time       time.Time `json:"time"`
+	RSS        int64     `json:"rss"`
+	Goroutines int       `json:"goroutines"`
+	Cache      int64     `json:"cache"`
+	HeapAlloc  uint64    `json:"heap_alloc"`
+}
+
+// watchdogReport is the summary written to report.json in a diagnostic bundle
+type watchdogReport struct {
+	Sample    ResourceSample     `json:"sample"`
+	Breaches  []string           `json:"breaches"`
+	Functions []watchdogFunction `json:"functions"`
+	LSMSize   int64              `json:"lsm_size"`
+	VLogSize  int64              `json:"vlog_size"`
+}
+
+// watchdogFunction is a loaded function and the size of its instance pool
+type watchdogFunction struct {
+	Namespace string                `json:"namespace"`
+	Name      string                `json:"name"`
+	Digest    string                `json:"digest,omitempty"`
+	Pool      *components.PoolStats `json:"pool,omitempty"`
+}
+
+// startWatchdog samples resource usage on the configured interval until ctx is done.
+func (e *Engine) startWatchdog(ctx context.Context) {
+	cfg := e.options.Watchdog
+	if !cfg.Enabled || cfg.Interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := e.clock.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				// Failures to write a bundle are logged by checkWatchdog
+				_, _ = e.checkWatchdog()
+			}
+		}
+	}()
+}
+
+// SampleResources reads the resident memory, goroutine count and registry cache size of the engine.
+func (e *Engine) SampleResources() ResourceSample {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	sample := ResourceSample{
+		Time:       e.clock.Now().UTC(),
+		RSS:        residentMemory(&stats),
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  stats.HeapAlloc,
+	}
+	if e.db != nil {
+		sample.Cache = e.db.CacheSize()
+	}
+	return sample
+}
+
+// checkWatchdog samples resource usage and, when a threshold is exceeded outside the
+// cooldown of the previous bundle, writes a diagnostic bundle and publishes a watchdog
+// event. It returns the path of the bundle, or "" when none was written.
+func (e *Engine) checkWatchdog() (string, error) {
+	cfg := e.options.Watchdog
+	sample := e.SampleResources()
+
+	var breaches []string
+	if cfg.MaxRSS > 0 && sample.RSS > cfg.MaxRSS {
+		breaches = append(breaches, fmt.Sprintf("rss %d MiB exceeds %d MiB", sample.RSS>>20, cfg.MaxRSS>>20))
+	}
+	if cfg.MaxGoroutines > 0 && sample.Goroutines > cfg.MaxGoroutines {
+		breaches = append(breaches, fmt.Sprintf("%d goroutines exceed %d", sample.Goroutines, cfg.MaxGoroutines))
+	}
+	if cfg.MaxCache > 0 && sample.Cache > cfg.MaxCache {
+		breaches = append(breaches, fmt.Sprintf("registry cache %d MiB exceeds %d MiB", sample.Cache>>20, cfg.MaxCache>>20))
+	}
+	if len(breaches) == 0 {
+		return "", nil
+	}
+
+	e.watchdogMu.Lock()
+	if !e.lastWatchdogBundle.IsZero() && sample.Time.Sub(e.lastWatchdogBundle) < cfg.Cooldown {
+		e.watchdogMu.Unlock()
+		return "", nil
+	}
+	e.lastWatchdogBundle = sample.Time
+	e.watchdogMu.Unlock()
+
+	reason := strings.Join(breaches, ", ")
+	path, err := e.writeDiagnosticBundle(sample, breaches)
+	if err != nil {
+		e.logger.Errorf("Watchdog: %s; failed to write diagnostic bundle: %v", reason, err)
+		e.events.Publish(events.Event{Type: events.TypeWatchdog, Reason: reason})
+		return "", err
+	}
+
+	e.logger.Errorf("Watchdog: %s; diagnostic bundle written to %s", reason, path)
+	e.events.Publish(events.Event{Type: events.TypeWatchdog, Reason: reason + "; bundle " + path})
+	return path, nil
+}
+
+// watchdogDir returns the directory diagnostic bundles are written to.
+func (e *Engine) watchdogDir() string {
+	if e.options.Watchdog.Dir != "" {
+		return e.options.Watchdog.Dir
+	}
+	return filepath.Join(e.registryDir, "diagnostics")
+}
+
+// writeDiagnosticBundle writes a gzipped tar of the watchdog report, a heap profile and
+// the goroutine stacks, then removes bundles beyond the configured number.
+func (e *Engine) writeDiagnosticBundle(sample ResourceSample, breaches []string) (string, error) {
+	dir := e.watchdogDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	report := watchdogReport{
+		Sample:    sample,
+		Breaches:  breaches,
+		Functions: e.watchdogFunctions(),
+	}
+	if e.db != nil {
+		report.LSMSize, report.VLogSize = e.db.Size()
+	}
+	summary, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	var heap, goroutines bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		return "", fmt.Errorf("heap profile: %w", err)
+	}
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutines, 1); err != nil {
+		return "", fmt.Errorf("goroutine profile: %w", err)
+	}
+
+	name := watchdogBundlePrefix + sample.Time.Format("20060102T150405Z") + ".tar.gz"
+	tmp, err := os.CreateTemp(dir, ".bundle-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(gz)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{"report.json", summary},
+		{"heap.pprof", heap.Bytes()},
+		{"goroutines.txt", goroutines.Bytes()},
+	} {
+		header := &tar.Header{Name: file.name, Mode: 0o600, Size: int64(len(file.data)), ModTime: sample.Time}
+		if err := tw.WriteHeader(header); err != nil {
+			tmp.Close()
+			return "", err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			tmp.Close()
+			return "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	e.pruneDiagnosticBundles(dir)
+	return path, nil
+}
+
+// watchdogFunctions lists the loaded functions with the sizes of their instance pools.
+func (e *Engine) watchdogFunctions() []watchdogFunction {
+	stats := e.pluginManager.GetPoolStats()
+	functions := []watchdogFunction{}
+	for _, key := range e.pluginManager.ListLoadedFunctions() {
+		fn := watchdogFunction{Namespace: key.Namespace, Name: key.Name}
+		if digest, ok := e.pluginManager.GetPluginDigest(key); ok {
+			fn.Digest = digest
+		}
+		if pool, ok := stats[key]; ok {
+			fn.Pool = &pool
+		}
+		functions = append(functions, fn)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].Namespace != functions[j].Namespace {
+			return functions[i].Namespace < functions[j].Namespace
+		}
+		return functions[i].Name < functions[j].Name
+	})
+	return functions
+}
+
+// pruneDiagnosticBundles removes the oldest bundles beyond the configured number.
+func (e *Engine) pruneDiagnosticBundles(dir string) {
+	bundles, err := filepath.Glob(filepath.Join(dir, watchdogBundlePrefix+"*.tar.gz"))
+	if err != nil {
+		return
+	}
+	// Names embed the UTC time, so they sort oldest first
+	sort.Strings(bundles)
+	for len(bundles) > e.options.Watchdog.MaxBundles {
+		if err := os.Remove(bundles[0]); err != nil {
+			e.logger.Errorf("Watchdog: failed to remove old bundle %s: %v", bundles[0], err)
+		}
+		bundles = bundles[1:]
+	}
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine/config"
+	"github.com/ignitionstack/ignition/pkg/engine/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchdogWritesBundle(t *testing.T) {
+	engine, tmpDir := setupTestEngine(t)
+	defer cleanupTest(tmpDir)
+
+	sample := engine.SampleResources()
+	assert.Positive(t, sample.RSS)
+	assert.Positive(t, sample.Goroutines)
+
+	// Below every threshold nothing is written
+	engine.options.Watchdog = config.WatchdogConfig{Enabled: true, MaxGoroutines: 1 << 20, MaxBundles: 1}
+	path, err := engine.checkWatchdog()
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	sub := engine.Events().Subscribe(events.Filter{Types: []string{events.TypeWatchdog}}, 4)
+	defer sub.Close()
+
+	engine.options.Watchdog.MaxGoroutines = 1
+	path, err = engine.checkWatchdog()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(engine.registryDir, "diagnostics"), filepath.Dir(path))
+	event := <-sub.C
+	assert.Contains(t, event.Reason, "goroutines exceed 1")
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	assert.Equal(t, []string{"report.json", "heap.pprof", "goroutines.txt"}, names)
+
+	// A lasting breach writes no other bundle until the cooldown has passed
+	engine.options.Watchdog.Cooldown = time.Hour
+	path, err = engine.checkWatchdog()
+	require.NoError(t, err)
+	assert.Empty(t, path)
+}
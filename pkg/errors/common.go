@@ -79,3 +79,35 @@ func IsFunctionNotLoaded(err error) bool {
 func IsCircuitBreakerOpen(err error) bool {
 	return errors.Is(err, ErrCircuitBreakerOpen)
 }
+
+// ExitError carries a process exit code alongside the underlying error so
+// commands can signal distinct failure modes to the shell.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// WithExitCode wraps err so the CLI exits with the given code.
+func WithExitCode(err error, code int) error {
+	return &ExitError{Code: code, Err: err}
+}
+
+// ExitCode returns the exit code carried by err, or 1 if none was set.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
@@ -0,0 +1,165 @@
+package functest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Caller performs a single call to the function under test.
+type Caller func(ctx context.Context, entrypoint string, payload []byte, config map[string]string) ([]byte, error)
+
+// Result is the outcome of one test case.
+type Result struct {
+	Name     string
+	Duration time.Duration
+
+	// Number of calls made (more than one for fuzz cases)
+	Calls int
+
+	// Failure describes the first failed assertion; empty means the case passed
+	Failure string
+
+	// Input that caused the failure, useful to reproduce fuzz failures
+	FailingInput string
+}
+
+// Passed reports whether the case succeeded.
+func (r Result) Passed() bool {
+	return r.Failure == ""
+}
+
+// Report is the outcome of a whole spec.
+type Report struct {
+	Function string
+	Started  time.Time
+	Duration time.Duration
+	Results  []Result
+}
+
+// Failures returns the number of failed cases.
+func (r *Report) Failures() int {
+	failed := 0
+	for _, res := range r.Results {
+		if !res.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Run executes every case in the spec in order and collects the results.
+// It only stops early when the context is cancelled.
+func Run(ctx context.Context, function string, spec *Spec, call Caller) *Report {
+	report := &Report{
+		Function: function,
+		Started:  time.Now(),
+	}
+
+	for _, tc := range spec.Tests {
+		if ctx.Err() != nil {
+			break
+		}
+		report.Results = append(report.Results, runCase(ctx, tc, call))
+	}
+
+	report.Duration = time.Since(report.Started)
+	return report
+}
+
+func runCase(ctx context.Context, tc Case, call Caller) Result {
+	start := time.Now()
+	result := Result{Name: tc.Name}
+
+	inputs := []interface{}{tc.Input}
+	if tc.Fuzz != nil {
+		inputs = fuzzInputs(tc.Fuzz)
+	}
+
+	for i, input := range inputs {
+		data, err := payload(input)
+		if err != nil {
+			result.Failure = fmt.Sprintf("failed to encode input: %v", err)
+			break
+		}
+
+		output, callErr := call(ctx, tc.Entrypoint, data, tc.Config)
+		result.Calls++
+
+		if failure := check(tc.Expect, output, callErr); failure != "" {
+			if tc.Fuzz != nil {
+				failure = fmt.Sprintf("run %d of %d: %s", i+1, len(inputs), failure)
+				result.FailingInput = string(data)
+			}
+			result.Failure = failure
+			break
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// fuzzInputs generates the inputs for a fuzz case; a fixed seed makes them reproducible
+func fuzzInputs(fuzz *Fuzz) []interface{} {
+	seed := fuzz.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	runs := fuzz.Runs
+	if runs == 0 {
+		runs = defaultFuzzRuns
+	}
+
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec // test inputs, not security sensitive
+	inputs := make([]interface{}, runs)
+	for i := range inputs {
+		inputs[i] = Generate(fuzz.Schema, rng)
+	}
+	return inputs
+}
+
+// check applies the expectations to a call result and describes the first violation
+func check(expect Expect, output []byte, callErr error) string {
+	if expect.Error {
+		if callErr == nil {
+			return "expected the call to fail, but it succeeded"
+		}
+		return ""
+	}
+	if callErr != nil {
+		return fmt.Sprintf("call failed: %v", callErr)
+	}
+
+	if expect.Output != nil && string(output) != *expect.Output {
+		return fmt.Sprintf("expected output %q, got %q", *expect.Output, output)
+	}
+	if expect.Contains != "" && !strings.Contains(string(output), expect.Contains) {
+		return fmt.Sprintf("expected output to contain %q, got %q", expect.Contains, output)
+	}
+
+	if expect.JSON == nil && expect.Schema == nil {
+		return ""
+	}
+
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	if err := decoder.Decode(&decoded); err != nil {
+		return fmt.Sprintf("output is not valid JSON: %v", err)
+	}
+
+	if expect.JSON != nil && !jsonEqual(expect.JSON, decoded) {
+		want, _ := json.Marshal(expect.JSON)
+		return fmt.Sprintf("expected JSON %s, got %s", want, output)
+	}
+	if expect.Schema != nil {
+		if errs := ValidateSchema(expect.Schema, decoded); len(errs) > 0 {
+			return "output does not match schema: " + strings.Join(errs, "; ")
+		}
+	}
+	return ""
+}
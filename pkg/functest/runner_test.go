@@ -0,0 +1,118 @@
+package functest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `
+tests:
+  - name: echoes text
+    entrypoint: echo
+    input: hello
+    expect:
+      output: hello
+  - name: returns json
+    entrypoint: echo
+    input: {name: ada, age: 36}
+    expect:
+      json: {name: ada, age: 36}
+      schema:
+        type: object
+        required: [name]
+  - name: rejects empty input
+    entrypoint: fail
+    expect:
+      error: true
+  - name: fuzz echo
+    entrypoint: echo
+    fuzz:
+      runs: 25
+      seed: 7
+      schema:
+        type: object
+        properties:
+          id: {type: integer, minimum: 1}
+        required: [id]
+    expect:
+      schema:
+        type: object
+        required: [id]
+`
+
+// echo returns its payload, and fails for the "fail" entrypoint
+func echo(_ context.Context, entrypoint string, payload []byte, _ map[string]string) ([]byte, error) {
+	if entrypoint == "fail" {
+		return nil, errors.New("boom")
+	}
+	return payload, nil
+}
+
+func writeSpec(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), SpecFile)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestRunSpec(t *testing.T) {
+	spec, err := LoadSpec(writeSpec(t, testSpec))
+	require.NoError(t, err)
+
+	report := Run(context.Background(), "ns/echo", spec, echo)
+	require.Len(t, report.Results, 4)
+	assert.Zero(t, report.Failures())
+	assert.Equal(t, 25, report.Results[3].Calls)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, report))
+	assert.Contains(t, buf.String(), `<testsuite name="ns/echo" tests="4" failures="0"`)
+}
+
+func TestRunReportsFailures(t *testing.T) {
+	spec, err := LoadSpec(writeSpec(t, `
+tests:
+  - name: wrong output
+    entrypoint: echo
+    input: hello
+    expect:
+      contains: goodbye
+  - name: fuzz breaks schema
+    entrypoint: echo
+    fuzz:
+      seed: 1
+      schema: {type: integer}
+    expect:
+      schema: {type: string}
+`))
+	require.NoError(t, err)
+
+	report := Run(context.Background(), "ns/echo", spec, echo)
+	assert.Equal(t, 2, report.Failures())
+	assert.Contains(t, report.Results[0].Failure, `expected output to contain "goodbye"`)
+	assert.True(t, strings.HasPrefix(report.Results[1].Failure, "run 1 of 100"))
+	assert.NotEmpty(t, report.Results[1].FailingInput)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, report))
+	assert.Contains(t, buf.String(), `<failure message=`)
+}
+
+func TestLoadSpecValidation(t *testing.T) {
+	_, err := LoadSpec(writeSpec(t, "tests:\n  - name: a\n    entrypoint: x\n  - name: a\n    entrypoint: y\n"))
+	assert.ErrorContains(t, err, `duplicate test name "a"`)
+
+	_, err = LoadSpec(writeSpec(t, "tests:\n  - name: a\n    entrypoint: x\n    input: 1\n    fuzz: {schema: {type: string}}\n"))
+	assert.ErrorContains(t, err, "sets both input and fuzz")
+
+	_, err = LoadSpec(writeSpec(t, "tests:\n  - name: a\n    entrypont: x\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,330 @@
+package functest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The schema support is a practical subset of JSON Schema: type, properties,
+// required, additionalProperties, items, enum, const, minimum, maximum,
+// minLength, maxLength, pattern, minItems and maxItems.
+
+// maxGeneratedLength caps strings and arrays generated without an explicit maximum
+const maxGeneratedLength = 16
+
+// ValidateSchema checks a decoded JSON value against a schema and returns every violation.
+func ValidateSchema(schema, value interface{}) []string {
+	var errs []string
+	validate(schema, value, "$", &errs)
+	return errs
+}
+
+func validate(schema, value interface{}, path string, errs *[]string) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if c, ok := s["const"]; ok && !jsonEqual(c, value) {
+		fail("expected %v, got %v", c, value)
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %v is not one of %v", value, enum)
+		}
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, value) {
+		fail("expected type %v, got %s", t, typeName(value))
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := len([]rune(v))
+		if min, ok := number(s["minLength"]); ok && float64(length) < min {
+			fail("length %d is below minLength %v", length, min)
+		}
+		if max, ok := number(s["maxLength"]); ok && float64(length) > max {
+			fail("length %d exceeds maxLength %v", length, max)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fail("invalid pattern %q: %v", pattern, err)
+			} else if !re.MatchString(v) {
+				fail("%q does not match pattern %q", v, pattern)
+			}
+		}
+	case float64:
+		if min, ok := number(s["minimum"]); ok && v < min {
+			fail("%v is below minimum %v", v, min)
+		}
+		if max, ok := number(s["maximum"]); ok && v > max {
+			fail("%v exceeds maximum %v", v, max)
+		}
+	case []interface{}:
+		if min, ok := number(s["minItems"]); ok && float64(len(v)) < min {
+			fail("%d items is below minItems %v", len(v), min)
+		}
+		if max, ok := number(s["maxItems"]); ok && float64(len(v)) > max {
+			fail("%d items exceeds maxItems %v", len(v), max)
+		}
+		if items, ok := s["items"]; ok {
+			for i, item := range v {
+				validate(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, r := range required {
+				if _, ok := v[fmt.Sprint(r)]; !ok {
+					fail("missing required property %q", r)
+				}
+			}
+		}
+		properties, _ := s["properties"].(map[string]interface{})
+		for _, key := range sortedKeys(v) {
+			if prop, ok := properties[key]; ok {
+				validate(prop, v[key], path+"."+key, errs)
+			} else if allowed, ok := s["additionalProperties"].(bool); ok && !allowed {
+				fail("unexpected property %q", key)
+			}
+		}
+	}
+}
+
+// Generate produces a random value that satisfies the schema. Patterns are not
+// used for generation, so pattern-constrained strings should set an enum instead.
+func Generate(schema interface{}, rng *rand.Rand) interface{} {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if c, ok := s["const"]; ok {
+		return c
+	}
+	if enum, ok := s["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[rng.Intn(len(enum))]
+	}
+
+	t := s["type"]
+	if types, ok := t.([]interface{}); ok && len(types) > 0 {
+		t = types[rng.Intn(len(types))]
+	}
+
+	switch t {
+	case "string":
+		minLen, maxLen := bounds(s, "minLength", "maxLength", 0, maxGeneratedLength)
+		return randomString(rng, minLen+rng.Intn(maxLen-minLen+1))
+	case "integer":
+		min, max := numberBounds(s, -1000, 1000)
+		lo, hi := int64(math.Ceil(min)), int64(math.Floor(max))
+		if hi < lo {
+			return float64(lo)
+		}
+		return float64(lo + rng.Int63n(hi-lo+1))
+	case "number":
+		min, max := numberBounds(s, -1000, 1000)
+		return min + rng.Float64()*(max-min)
+	case "boolean":
+		return rng.Intn(2) == 1
+	case "null":
+		return nil
+	case "array":
+		minItems, maxItems := bounds(s, "minItems", "maxItems", 0, maxGeneratedLength)
+		out := make([]interface{}, minItems+rng.Intn(maxItems-minItems+1))
+		for i := range out {
+			out[i] = Generate(s["items"], rng)
+		}
+		return out
+	default:
+		out := map[string]interface{}{}
+		properties, _ := s["properties"].(map[string]interface{})
+		required := map[string]bool{}
+		if r, ok := s["required"].([]interface{}); ok {
+			for _, name := range r {
+				required[fmt.Sprint(name)] = true
+			}
+		}
+		for _, key := range sortedKeys(properties) {
+			// Optional properties are included half of the time
+			if required[key] || rng.Intn(2) == 1 {
+				out[key] = Generate(properties[key], rng)
+			}
+		}
+		return out
+	}
+}
+
+// matchesType reports whether value has the schema type (or one of the types)
+func matchesType(t, value interface{}) bool {
+	if types, ok := t.([]interface{}); ok {
+		for _, candidate := range types {
+			if matchesType(candidate, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// jsonEqual compares two values after coercing YAML numbers to JSON's float64
+func jsonEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(toJSONValue(a), toJSONValue(b))
+}
+
+func toJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = toJSONValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = toJSONValue(item)
+		}
+		return out
+	default:
+		if f, ok := number(v); ok {
+			return f
+		}
+		return v
+	}
+}
+
+// number converts YAML and JSON numeric values to float64
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// bounds reads an integer range from the schema, falling back to the defaults
+func bounds(s map[string]interface{}, minKey, maxKey string, defMin, defMax int) (int, int) {
+	min, max := defMin, defMax
+	if v, ok := number(s[minKey]); ok {
+		min = int(v)
+	}
+	if v, ok := number(s[maxKey]); ok {
+		max = int(v)
+	} else if max < min {
+		max = min + defMax
+	}
+	if max < min {
+		max = min
+	}
+	return min, max
+}
+
+// numberBounds reads minimum and maximum from the schema, falling back to the defaults
+func numberBounds(s map[string]interface{}, defMin, defMax float64) (float64, float64) {
+	min, max := defMin, defMax
+	if v, ok := number(s["minimum"]); ok {
+		min = v
+		if _, ok := number(s["maximum"]); !ok && max < min {
+			max = min + (defMax - defMin)
+		}
+	}
+	if v, ok := number(s["maximum"]); ok {
+		max = v
+		if _, ok := number(s["minimum"]); !ok && min > max {
+			min = max - (defMax - defMin)
+		}
+	}
+	if max < min {
+		max = min
+	}
+	return min, max
+}
+
+// randomString mixes ASCII with a few multi-byte and control characters to shake out encoding bugs
+func randomString(rng *rand.Rand, length int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 _-.\"\\\n\téü☃😀"
+	runes := []rune(alphabet)
+	var b strings.Builder
+	for i := 0; i < length; i++ {
+		b.WriteRune(runes[rng.Intn(len(runes))])
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,48 @@
+package functest
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var userSchema = map[string]interface{}{
+	"type":                 "object",
+	"required":             []interface{}{"name", "age"},
+	"additionalProperties": false,
+	"properties": map[string]interface{}{
+		"name": map[string]interface{}{"type": "string", "minLength": 1, "maxLength": 8},
+		"age":  map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 150},
+		"role": map[string]interface{}{"enum": []interface{}{"admin", "user"}},
+		"tags": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "maxItems": 3},
+	},
+}
+
+func TestValidateSchema(t *testing.T) {
+	valid := map[string]interface{}{"name": "ada", "age": float64(36), "role": "admin"}
+	assert.Empty(t, ValidateSchema(userSchema, valid))
+
+	invalid := map[string]interface{}{
+		"name":  "",
+		"age":   float64(1.5),
+		"role":  "root",
+		"extra": true,
+	}
+	errs := ValidateSchema(userSchema, invalid)
+	assert.Contains(t, errs, `$.name: length 0 is below minLength 1`)
+	assert.Contains(t, errs, `$.age: expected type integer, got number`)
+	assert.Contains(t, errs, `$.role: value root is not one of [admin user]`)
+	assert.Contains(t, errs, `$: unexpected property "extra"`)
+
+	errs = ValidateSchema(userSchema, map[string]interface{}{"name": "ada"})
+	assert.Equal(t, []string{`$: missing required property "age"`}, errs)
+}
+
+func TestGenerateSatisfiesSchema(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		value := Generate(userSchema, rng)
+		assert.Empty(t, ValidateSchema(userSchema, value), "generated %v", value)
+	}
+}
@@ -0,0 +1,153 @@
+// Package functest runs declarative tests against WebAssembly functions.
+package functest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SpecFile is the name of the test spec kept next to ignition.yml
+const SpecFile = "ignition.test.yml"
+
+// defaultFuzzRuns is the number of generated inputs when a fuzz case sets no runs
+const defaultFuzzRuns = 100
+
+// Spec is the content of an ignition.test.yml file.
+type Spec struct {
+	Tests []Case `yaml:"tests"`
+}
+
+// Case is a single test. It either sends a fixed input or, with fuzz, inputs
+// generated from a JSON schema, and checks every output against expect.
+type Case struct {
+	Name       string            `yaml:"name"`
+	Entrypoint string            `yaml:"entrypoint"`
+	Input      interface{}       `yaml:"input,omitempty"`
+	Config     map[string]string `yaml:"config,omitempty"`
+	Fuzz       *Fuzz             `yaml:"fuzz,omitempty"`
+	Expect     Expect            `yaml:"expect"`
+}
+
+// Fuzz generates inputs from a JSON schema.
+type Fuzz struct {
+	Schema interface{} `yaml:"schema"`
+	Runs   int         `yaml:"runs,omitempty"`
+	Seed   int64       `yaml:"seed,omitempty"`
+}
+
+// Expect holds the assertions applied to a call's output. Unset assertions are skipped.
+type Expect struct {
+	// The call must fail (true) or succeed (false, the default)
+	Error bool `yaml:"error,omitempty"`
+
+	// The output must equal this string exactly
+	Output *string `yaml:"output,omitempty"`
+
+	// The output must contain this string
+	Contains string `yaml:"contains,omitempty"`
+
+	// The output must be JSON equal to this value
+	JSON interface{} `yaml:"json,omitempty"`
+
+	// The output must be JSON matching this schema
+	Schema interface{} `yaml:"schema,omitempty"`
+}
+
+// LoadSpec reads and validates a test spec file.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test spec: %w", err)
+	}
+
+	var spec Spec
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse test spec %s: %w", path, err)
+	}
+
+	for i := range spec.Tests {
+		tc := &spec.Tests[i]
+		tc.Input = normalize(tc.Input)
+		tc.Expect.JSON = normalize(tc.Expect.JSON)
+		tc.Expect.Schema = normalize(tc.Expect.Schema)
+		if tc.Fuzz != nil {
+			tc.Fuzz.Schema = normalize(tc.Fuzz.Schema)
+		}
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid test spec %s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// Validate checks that every case is runnable.
+func (s *Spec) Validate() error {
+	if len(s.Tests) == 0 {
+		return errors.New("no tests defined")
+	}
+
+	names := make(map[string]bool, len(s.Tests))
+	for i, tc := range s.Tests {
+		if tc.Name == "" {
+			return fmt.Errorf("test %d has no name", i+1)
+		}
+		if names[tc.Name] {
+			return fmt.Errorf("duplicate test name %q", tc.Name)
+		}
+		names[tc.Name] = true
+
+		if tc.Entrypoint == "" {
+			return fmt.Errorf("test %q has no entrypoint", tc.Name)
+		}
+		if tc.Fuzz != nil {
+			if tc.Input != nil {
+				return fmt.Errorf("test %q sets both input and fuzz", tc.Name)
+			}
+			if tc.Fuzz.Schema == nil {
+				return fmt.Errorf("test %q fuzz has no schema", tc.Name)
+			}
+			if tc.Fuzz.Runs < 0 {
+				return fmt.Errorf("test %q fuzz runs cannot be negative", tc.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// payload encodes an input for a call: strings are sent as-is, anything else as JSON
+func payload(input interface{}) ([]byte, error) {
+	switch v := input.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// normalize converts the map[interface{}]interface{} values produced by the YAML
+// decoder into JSON-compatible map[string]interface{} values
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[fmt.Sprint(key)] = normalize(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
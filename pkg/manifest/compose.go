@@ -5,25 +5,193 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/ignitionstack/ignition/pkg/registry/remote"
 	"gopkg.in/yaml.v2"
 )
 
 // ComposeManifest represents the structure of an ignition-compose.yml file.
 type ComposeManifest struct {
-	Version  string                    `yaml:"version,omitempty"`
-	Services map[string]ComposeService `yaml:"services"`
+	Version string `yaml:"version,omitempty"`
+
+	// Project owning the services and pipelines compose apply converges; defaults to the
+	// name of the directory holding the file
+	Name string `yaml:"name,omitempty"`
+
+	// Namespace of function references written without one, instead of the default
+	// namespace of the CLI
+	Namespace string `yaml:"namespace,omitempty"`
+
+	Services  map[string]ComposeService  `yaml:"services"`
+	Pipelines map[string]ComposePipeline `yaml:"pipelines,omitempty"`
+}
+
+// QualifyFunctions prefixes the function references of services and pipeline steps
+// written without a namespace with the namespace of the file, or else with namespace.
+func (m *ComposeManifest) QualifyFunctions(namespace string) {
+	if m.Namespace != "" {
+		namespace = m.Namespace
+	}
+	qualify := func(ref string) string {
+		if ref == "" || strings.Contains(ref, "/") {
+			return ref
+		}
+		return namespace + "/" + ref
+	}
+
+	for name, service := range m.Services {
+		service.Function = qualify(service.Function)
+		m.Services[name] = service
+	}
+	for _, pipeline := range m.Pipelines {
+		for i := range pipeline.Steps {
+			pipeline.Steps[i].Function = qualify(pipeline.Steps[i].Function)
+		}
+	}
 }
 
 // ComposeService represents a single function service in the compose file.
 type ComposeService struct {
-	Function      string            `yaml:"function"`         // namespace/name:tag format
+	Function      string            `yaml:"function"`         // namespace/name:tag format, the namespace may be left out
+	Source        string            `yaml:"source,omitempty"` // https:// URL or oci:// reference of a prebuilt module
+	Digest        string            `yaml:"digest,omitempty"` // sha256 digest the source must match
 	Config        map[string]string `yaml:"config,omitempty"` // Deprecated: use Environment instead
 	Environment   map[string]string `yaml:"environment,omitempty"`
+	EnvFile       StringList        `yaml:"env_file,omitempty"` // KEY=VALUE files, relative to the compose file
 	DependsOn     []string          `yaml:"depends_on,omitempty"`
 	HostName      string            `yaml:"hostname,omitempty"`
 	RestartPolicy string            `yaml:"restart,omitempty"` // "always", "on-failure", "no"
 	Ports         []string          `yaml:"ports,omitempty"`   // For future use with network config
+	Scale         int               `yaml:"scale,omitempty"`   // Fixed number of instances, 0 autoscales
+
+	Healthcheck *ComposeHealthcheck `yaml:"healthcheck,omitempty"`
+
+	// Values read from EnvFile when the compose file is parsed
+	EnvFileValues map[string]string `yaml:"-"`
+}
+
+// StringList is a YAML list of strings that may also be written as a single string.
+type StringList []string
+
+// UnmarshalYAML accepts either a string or a list of strings.
+func (l *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		*l = StringList{single}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// ServiceConfig returns the config map a service is loaded with. Environment takes
+// precedence over values from env files, which take precedence over the legacy Config.
+func (s ComposeService) ServiceConfig() map[string]string {
+	config := make(map[string]string)
+	for _, values := range []map[string]string{s.Config, s.EnvFileValues, s.Environment} {
+		for k, v := range values {
+			config[k] = v
+		}
+	}
+	return config
+}
+
+// ComposeHealthcheck probes a service by calling one of its entrypoints. The service is
+// healthy as soon as a call succeeds, and unhealthy after Retries consecutive failures.
+type ComposeHealthcheck struct {
+	Entrypoint  string `yaml:"entrypoint"`
+	Payload     string `yaml:"payload,omitempty"`
+	Interval    string `yaml:"interval,omitempty"`     // between attempts, default 1s
+	Timeout     string `yaml:"timeout,omitempty"`      // of each call, default 5s
+	Retries     int    `yaml:"retries,omitempty"`      // consecutive failures tolerated, default 3
+	StartPeriod string `yaml:"start_period,omitempty"` // failures during it are not counted
+}
+
+// Healthcheck defaults.
+const (
+	DefaultHealthcheckInterval = time.Second
+	DefaultHealthcheckTimeout  = 5 * time.Second
+	DefaultHealthcheckRetries  = 3
+)
+
+// IntervalDuration parses the delay between attempts, defaulting to DefaultHealthcheckInterval.
+func (h ComposeHealthcheck) IntervalDuration() (time.Duration, error) {
+	return parseDuration("interval", h.Interval, DefaultHealthcheckInterval)
+}
+
+// TimeoutDuration parses the timeout of each call, defaulting to DefaultHealthcheckTimeout.
+func (h ComposeHealthcheck) TimeoutDuration() (time.Duration, error) {
+	return parseDuration("timeout", h.Timeout, DefaultHealthcheckTimeout)
+}
+
+// StartPeriodDuration parses the grace period after loading, zero when none is set.
+func (h ComposeHealthcheck) StartPeriodDuration() (time.Duration, error) {
+	return parseDuration("start_period", h.StartPeriod, 0)
+}
+
+// RetryCount returns the number of consecutive failures tolerated, defaulting to DefaultHealthcheckRetries.
+func (h ComposeHealthcheck) RetryCount() int {
+	if h.Retries == 0 {
+		return DefaultHealthcheckRetries
+	}
+	return h.Retries
+}
+
+func (h ComposeHealthcheck) validate() error {
+	if h.Entrypoint == "" {
+		return errors.New("is missing required 'entrypoint' field")
+	}
+	if h.Retries < 0 {
+		return fmt.Errorf("has invalid 'retries' %d, must not be negative", h.Retries)
+	}
+	for _, parse := range []func() (time.Duration, error){h.IntervalDuration, h.TimeoutDuration, h.StartPeriodDuration} {
+		if _, err := parse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDuration parses an optional, non-negative Go duration.
+func parseDuration(field, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", field, value, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("%s %q must not be negative", field, value)
+	}
+	return duration, nil
+}
+
+// ComposePipeline chains entrypoint calls behind a single HTTP route, feeding each
+// step's output into the next step's input.
+type ComposePipeline struct {
+	Steps []ComposePipelineStep `yaml:"steps"`
+}
+
+// ComposePipelineStep is one call in a pipeline. It targets either a service of the
+// compose file or a function by namespace/name.
+type ComposePipelineStep struct {
+	Service    string `yaml:"service,omitempty"`
+	Function   string `yaml:"function,omitempty"` // namespace/name format, the namespace may be left out
+	Entrypoint string `yaml:"entrypoint"`
+	Timeout    string `yaml:"timeout,omitempty"`  // Go duration, e.g. "500ms"
+	OnError    string `yaml:"on_error,omitempty"` // "abort" (default) or "continue"
+}
+
+// TimeoutDuration parses the step timeout, returning zero when none is set.
+func (s ComposePipelineStep) TimeoutDuration() (time.Duration, error) {
+	return parseDuration("timeout", s.Timeout, 0)
 }
 
 // ParseComposeFile parses an ignition-compose.yml file and returns a ComposeManifest.
@@ -64,6 +232,9 @@ func ParseComposeFile(filePath string) (*ComposeManifest, error) {
 	if unmarshalErr := yaml.Unmarshal(data, &manifest); unmarshalErr != nil {
 		return nil, fmt.Errorf("failed to parse compose file: %w", unmarshalErr)
 	}
+	if manifest.Name == "" {
+		manifest.Name = filepath.Base(filepath.Dir(absPath))
+	}
 
 	// Validate the manifest
 	if len(manifest.Services) == 0 {
@@ -74,7 +245,82 @@ func ParseComposeFile(filePath string) (*ComposeManifest, error) {
 		if service.Function == "" {
 			return nil, fmt.Errorf("service '%s' is missing required 'function' field", name)
 		}
+		if service.Source != "" {
+			if !remote.IsRemoteReference(service.Source) {
+				return nil, fmt.Errorf("service '%s' has invalid 'source' %q, expected an https:// URL or oci:// reference", name, service.Source)
+			}
+			if strings.HasPrefix(service.Source, remote.SchemeHTTPS) && service.Digest == "" {
+				return nil, fmt.Errorf("service '%s' must set 'digest' to verify its https:// source", name)
+			}
+		}
+		if service.Scale < 0 {
+			return nil, fmt.Errorf("service '%s' has invalid 'scale' %d, must not be negative", name, service.Scale)
+		}
+		if service.Healthcheck != nil {
+			if err := service.Healthcheck.validate(); err != nil {
+				return nil, fmt.Errorf("service '%s' healthcheck %w", name, err)
+			}
+		}
+		if len(service.EnvFile) > 0 {
+			paths := make([]string, len(service.EnvFile))
+			for i, path := range service.EnvFile {
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(filepath.Dir(absPath), path)
+				}
+				paths[i] = path
+			}
+			if service.EnvFileValues, err = ParseEnvFiles(paths); err != nil {
+				return nil, fmt.Errorf("service '%s' env_file: %w", name, err)
+			}
+			manifest.Services[name] = service
+		}
+		if service.Digest != "" {
+			if service.Source == "" {
+				return nil, fmt.Errorf("service '%s' sets 'digest' without a 'source'", name)
+			}
+			if err := remote.ValidateDigest(service.Digest); err != nil {
+				return nil, fmt.Errorf("service '%s': %w", name, err)
+			}
+		}
+	}
+
+	for name, pipeline := range manifest.Pipelines {
+		if err := validatePipeline(&manifest, pipeline); err != nil {
+			return nil, fmt.Errorf("pipeline '%s': %w", name, err)
+		}
 	}
 
 	return &manifest, nil
 }
+
+// validatePipeline checks the shape of a pipeline; naming rules are enforced by the engine.
+func validatePipeline(manifest *ComposeManifest, pipeline ComposePipeline) error {
+	if len(pipeline.Steps) == 0 {
+		return errors.New("must contain at least one step")
+	}
+
+	for i, step := range pipeline.Steps {
+		switch {
+		case step.Service != "" && step.Function != "":
+			return fmt.Errorf("step %d must set either 'service' or 'function', not both", i+1)
+		case step.Service != "":
+			if _, ok := manifest.Services[step.Service]; !ok {
+				return fmt.Errorf("step %d references unknown service '%s'", i+1, step.Service)
+			}
+		case step.Function == "":
+			return fmt.Errorf("step %d is missing 'service' or 'function'", i+1)
+		}
+
+		if step.Entrypoint == "" {
+			return fmt.Errorf("step %d is missing required 'entrypoint' field", i+1)
+		}
+		if _, err := step.TimeoutDuration(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if step.OnError != "" && step.OnError != "abort" && step.OnError != "continue" {
+			return fmt.Errorf("step %d has unknown on_error policy '%s', expected abort or continue", i+1, step.OnError)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package manifest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config key types a schema can declare. Keys without a type are strings.
+const (
+	ConfigTypeString   = "string"
+	ConfigTypeInt      = "int"
+	ConfigTypeNumber   = "number"
+	ConfigTypeBool     = "bool"
+	ConfigTypeDuration = "duration"
+)
+
+// ConfigKey describes a config key a function reads.
+type ConfigKey struct {
+	Type        string `yaml:"type,omitempty" toml:"type,omitempty"`
+	Required    bool   `yaml:"required,omitempty" toml:"required,omitempty"`
+	Default     string `yaml:"default,omitempty" toml:"default,omitempty"`
+	Description string `yaml:"description,omitempty" toml:"description,omitempty"`
+}
+
+// ConfigSchema declares the config keys of a function by name. The engine checks the
+// config of a load against it, so a missing or malformed key fails the load instead of
+// the calls reading it. Keys left out of the schema are not checked.
+type ConfigSchema map[string]ConfigKey
+
+// Validate checks that every key has a known type and that defaults are of that type.
+func (s ConfigSchema) Validate() error {
+	for _, name := range slices.Sorted(maps.Keys(s)) {
+		key := s[name]
+		if !slices.Contains([]string{"", ConfigTypeString, ConfigTypeInt, ConfigTypeNumber, ConfigTypeBool, ConfigTypeDuration}, key.Type) {
+			return fmt.Errorf("config key %s has unknown type %q", name, key.Type)
+		}
+		if key.Default == "" {
+			continue
+		}
+		if key.Required {
+			return fmt.Errorf("config key %s is required and has a default", name)
+		}
+		if err := key.check(key.Default); err != nil {
+			return fmt.Errorf("default of config key %s %w", name, err)
+		}
+	}
+	return nil
+}
+
+// WithDefaults returns a copy of config with the defaults of the keys it does not set.
+func (s ConfigSchema) WithDefaults(config map[string]string) map[string]string {
+	result := maps.Clone(config)
+	for name, key := range s {
+		if key.Default == "" {
+			continue
+		}
+		if _, ok := config[name]; !ok {
+			if result == nil {
+				result = make(map[string]string)
+			}
+			result[name] = key.Default
+		}
+	}
+	return result
+}
+
+// Check returns a *ConfigError listing the required keys missing from config and the
+// keys whose value is not of their type, or nil when config matches the schema.
+func (s ConfigSchema) Check(config map[string]string) error {
+	var configErr ConfigError
+	for _, name := range slices.Sorted(maps.Keys(s)) {
+		key := s[name]
+		value, ok := config[name]
+		switch {
+		case !ok:
+			if key.Required {
+				configErr.Missing = append(configErr.Missing, name)
+			}
+		default:
+			if err := key.check(value); err != nil {
+				configErr.Invalid = append(configErr.Invalid, name+" "+err.Error())
+			}
+		}
+	}
+	if len(configErr.Missing) == 0 && len(configErr.Invalid) == 0 {
+		return nil
+	}
+	return &configErr
+}
+
+// check returns an error when value is not of the key's type.
+func (k ConfigKey) check(value string) error {
+	var err error
+	switch k.Type {
+	case ConfigTypeInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case ConfigTypeNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	case ConfigTypeBool:
+		_, err = strconv.ParseBool(value)
+	case ConfigTypeDuration:
+		_, err = time.ParseDuration(value)
+	case "", ConfigTypeString:
+	default:
+		return fmt.Errorf("has unknown type %q", k.Type)
+	}
+	// The value is left out of the error, since it may be a secret
+	if err != nil {
+		return fmt.Errorf("must be of type %s", k.Type)
+	}
+	return nil
+}
+
+// ConfigError reports the keys of a config that do not match a function's schema.
+type ConfigError struct {
+	Missing []string // required keys the config does not set
+	Invalid []string // keys with a value of the wrong type, with the reason
+}
+
+func (e *ConfigError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing required config keys: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Invalid) > 0 {
+		parts = append(parts, "invalid config keys: "+strings.Join(e.Invalid, "; "))
+	}
+	return strings.Join(parts, "; ")
+}
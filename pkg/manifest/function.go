@@ -19,6 +19,36 @@ type FunctionSettings struct {
 type FunctionVersionSettings struct {
 	Wasi        bool     `yaml:"enable_wasi" toml:"enable_wasi"`
 	AllowedUrls []string `yaml:"allowed_urls" toml:"allowed_urls"`
+
+	// Proxy is the http(s) or socks5 proxy URL the function's HTTP requests go through
+	// instead of the engine's proxy, or "direct" to connect without one.
+	Proxy string `yaml:"proxy,omitempty" toml:"proxy,omitempty"`
+
+	// HTTPEnvelope makes the public HTTP endpoint treat the function output as a
+	// response envelope (status, headers, body) instead of a raw JSON body.
+	HTTPEnvelope bool `yaml:"http_envelope" toml:"http_envelope"`
+
+	// HTTPRequest makes the public HTTP endpoint pass the function the whole request
+	// (method, path below the entrypoint, query, headers, body) instead of the call
+	// payload, and accept every method.
+	HTTPRequest bool `yaml:"http_request" toml:"http_request"`
+
+	// SpoolPayloads lets the public HTTP endpoint keep raw request bodies larger than
+	// the engine spool threshold in a temporary file, read by the function through the
+	// ignition_payload_size and ignition_payload_read host functions.
+	SpoolPayloads bool `yaml:"spool_payloads" toml:"spool_payloads"`
+
+	// Labels are free-form metadata recorded with the version, such as its owner,
+	// which admission policies can require.
+	Labels map[string]string `yaml:"labels,omitempty" toml:"labels,omitempty"`
+
+	// Signature is a base64 ed25519 signature of the module's hex sha256 digest,
+	// checked by admission policies that require signed modules.
+	Signature string `yaml:"signature,omitempty" toml:"signature,omitempty"`
+
+	// Config declares the config keys the function reads, with their type and whether
+	// they are required or have a default. Loads with a config not matching it fail.
+	Config ConfigSchema `yaml:"config,omitempty" toml:"config,omitempty"`
 }
 
 func (m *FunctionManifest) MarhsalYaml() ([]byte, error) {
@@ -0,0 +1,168 @@
+// Package proxy selects the HTTP proxy of outgoing requests, from explicit settings or
+// else from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// Direct is the proxy setting of a function that connects directly, even when the
+// engine has a proxy.
+const Direct = "direct"
+
+// Settings names the proxies of outgoing requests. Zero settings use the environment.
+type Settings struct {
+	// Proxy of http:// requests (empty connects directly)
+	HTTP string
+
+	// Proxy of https:// requests (empty connects directly)
+	HTTPS string
+
+	// Comma separated hosts, domains, addresses and CIDR blocks reached directly, in
+	// the format of NO_PROXY
+	NoProxy string
+}
+
+// IsZero reports whether no proxy is set, so the environment applies.
+func (s Settings) IsZero() bool {
+	return s.HTTP == "" && s.HTTPS == "" && s.NoProxy == ""
+}
+
+// Validate checks that the proxies are http, https or socks5 URLs.
+func (s Settings) Validate() error {
+	for _, raw := range []string{s.HTTP, s.HTTPS} {
+		if raw == "" {
+			continue
+		}
+		if _, err := Parse(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse parses a proxy URL. Like the environment variables, a proxy without a scheme
+// is an http proxy.
+func Parse(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("proxy %q must be an http, https or socks5 URL", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy %q has no host", raw)
+	}
+	return u, nil
+}
+
+// For returns the proxy of a request to u, or nil when it connects directly. Requests
+// to localhost and loopback addresses always connect directly.
+func (s Settings) For(u *url.URL) (*url.URL, error) {
+	if s.IsZero() {
+		return http.ProxyFromEnvironment(&http.Request{URL: u})
+	}
+
+	var raw string
+	switch u.Scheme {
+	case "http":
+		raw = s.HTTP
+	case "https":
+		raw = s.HTTPS
+	}
+	if raw == "" || isLocal(u.Hostname()) || s.bypass(u) {
+		return nil, nil
+	}
+	return Parse(raw)
+}
+
+// Func returns the settings as the Proxy function of an http.Transport.
+func (s Settings) Func() func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		return s.For(req.URL)
+	}
+}
+
+// Transport returns a copy of http.DefaultTransport sending requests through the proxies.
+func (s Settings) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = s.Func()
+	return transport
+}
+
+// bypass reports whether NoProxy lists the host of u.
+func (s Settings) bypass(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	addr, addrErr := netip.ParseAddr(host)
+	port := u.Port()
+	if port == "" {
+		port = defaultPort(u.Scheme)
+	}
+
+	for _, entry := range strings.Split(s.NoProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		}
+
+		// Entries may be limited to one port
+		if h, entryPort, err := net.SplitHostPort(entry); err == nil {
+			if entryPort != port {
+				continue
+			}
+			entry = h
+		}
+
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			if addrErr == nil && prefix.Contains(addr.Unmap()) {
+				return true
+			}
+			continue
+		}
+		if entryAddr, err := netip.ParseAddr(entry); err == nil {
+			if addrErr == nil && entryAddr == addr {
+				return true
+			}
+			continue
+		}
+
+		// example.com covers the domain and its subdomains, .example.com only its subdomains
+		domain := strings.TrimPrefix(entry, ".")
+		if (host == domain && !strings.HasPrefix(entry, ".")) || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLocal(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsLoopback()
+}
+
+func defaultPort(scheme string) string {
+	switch scheme {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	u, err := Parse("proxy.internal:3128")
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.internal:3128", u.String())
+
+	for _, raw := range []string{"socks5://proxy.internal:1080", "https://proxy.internal"} {
+		_, err := Parse(raw)
+		assert.NoError(t, err, raw)
+	}
+	for _, raw := range []string{"ftp://proxy.internal", "http://", "http://%zz"} {
+		_, err := Parse(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestSettingsFor(t *testing.T) {
+	settings := Settings{
+		HTTP:    "http://plain.internal:3128",
+		HTTPS:   "http://secure.internal:3128",
+		NoProxy: "example.com, .corp.local, 10.0.0.0/8, 192.168.1.1, api.other.com:8443",
+	}
+	tests := []struct {
+		url   string
+		proxy string
+	}{
+		{"http://api.public.com/v1", "http://plain.internal:3128"},
+		{"https://api.public.com/v1", "http://secure.internal:3128"},
+		{"https://example.com", ""},
+		{"https://www.example.com", ""},
+		{"https://corp.local", "http://secure.internal:3128"},
+		{"https://git.corp.local", ""},
+		{"http://10.1.2.3", ""},
+		{"http://192.168.1.1:8080", ""},
+		{"http://192.168.1.2", "http://plain.internal:3128"},
+		{"https://api.other.com:8443", ""},
+		{"https://api.other.com", "http://secure.internal:3128"},
+		{"http://localhost:8080", ""},
+		{"http://127.0.0.1", ""},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		require.NoError(t, err)
+		proxyURL, err := settings.For(u)
+		require.NoError(t, err, tt.url)
+		if tt.proxy == "" {
+			assert.Nil(t, proxyURL, tt.url)
+			continue
+		}
+		require.NotNil(t, proxyURL, tt.url)
+		assert.Equal(t, tt.proxy, proxyURL.String(), tt.url)
+	}
+}
+
+func TestSettingsForWildcard(t *testing.T) {
+	u, err := url.Parse("https://api.public.com")
+	require.NoError(t, err)
+
+	proxyURL, err := Settings{HTTPS: "http://secure.internal:3128", NoProxy: "*"}.For(u)
+	require.NoError(t, err)
+	assert.Nil(t, proxyURL)
+}
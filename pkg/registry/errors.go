@@ -8,4 +8,14 @@ var (
 	ErrDigestNotFound   = errors.New("digest not found")
 	ErrInvalidReference = errors.New("invalid reference format")
 	ErrVersionNotFound  = errors.New("version not found")
+	ErrInvalidModule    = errors.New("invalid wasm module")
+	ErrModuleTooLarge   = errors.New("wasm module exceeds maximum size")
+	ErrNoEntrypoints    = errors.New("wasm module exports no callable entrypoints")
+	ErrWasiNotEnabled   = errors.New("wasm module imports WASI but wasi is disabled in the manifest")
+	ErrSchemaTooNew     = errors.New("registry schema is newer than this version of ignition supports")
+	ErrConflict         = errors.New("function metadata was changed by a concurrent update")
+	ErrEncrypted        = errors.New("registry data is encrypted and no encryption key is configured")
+	ErrDecryptionFailed = errors.New("failed to decrypt registry data, the encryption key may be wrong")
+	ErrSBOMNotFound     = errors.New("no SBOM recorded for version")
+	ErrVariantNotFound  = errors.New("variant not found")
 )
@@ -0,0 +1,153 @@
+package localregistry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/internal/repository"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// ErrDatabaseLocked is matched by the errors of opening a database another process holds.
+var ErrDatabaseLocked = errors.New("database is locked by another process")
+
+// Backoff between attempts to open a locked database
+const (
+	lockRetryInterval    = 100 * time.Millisecond
+	maxLockRetryInterval = 2 * time.Second
+)
+
+// lockFile is the file badger locks in a database directory, holding the pid of the
+// process writing to it
+const lockFile = "LOCK"
+
+// LockedError reports a database held by another process, such as a running engine.
+type LockedError struct {
+	Path string
+	PID  int // pid of the process writing to the database; 0 when unknown
+}
+
+func (e *LockedError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("database %s is locked by another process (pid %d), such as a running engine", e.Path, e.PID)
+	}
+	return fmt.Sprintf("database %s is locked by another process, such as a running engine or a command reading it", e.Path)
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrDatabaseLocked
+}
+
+// OpenBadger opens the badger database in path. While another process holds it, opening
+// is retried with backoff for up to wait, after which a *LockedError is returned.
+func OpenBadger(path string, wait time.Duration) (*badger.DB, error) {
+	opts := badger.DefaultOptions(path)
+	opts.Logger = nil
+	return openBadger(opts, wait)
+}
+
+// OpenBadgerReadOnly opens the badger database in path without writing to it. Several
+// processes can read a database at once, but not while one writes to it.
+func OpenBadgerReadOnly(path string) (*badger.DB, error) {
+	opts := badger.DefaultOptions(path).WithReadOnly(true)
+	opts.Logger = nil
+	return openBadger(opts, 0)
+}
+
+func openBadger(opts badger.Options, wait time.Duration) (*badger.DB, error) {
+	deadline := time.Now().Add(wait)
+	interval := lockRetryInterval
+	for {
+		db, err := badger.Open(opts)
+		if err == nil {
+			return db, nil
+		}
+		// Badger formats the lock error into its message, so it can only be told by its text
+		if !strings.Contains(err.Error(), "Another process is using this Badger database") {
+			return nil, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, &LockedError{Path: opts.Dir, PID: lockHolder(opts.Dir)}
+		}
+		time.Sleep(min(interval, remaining))
+		interval = min(2*interval, maxLockRetryInterval)
+	}
+}
+
+// lockHolder returns the pid of the process writing to the database in dir, or 0.
+func lockHolder(dir string) int {
+	data, err := os.ReadFile(filepath.Join(dir, lockFile))
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// OpenDatabase opens the registry database kept in registryDir.
+func OpenDatabase(registryDir string) (repository.DBRepository, error) {
+	return OpenDatabaseWithWait(registryDir, 0)
+}
+
+// OpenDatabaseWithWait opens the registry database kept in registryDir, waiting up to
+// wait for another process to release it.
+func OpenDatabaseWithWait(registryDir string, wait time.Duration) (repository.DBRepository, error) {
+	db, err := OpenBadger(filepath.Join(registryDir, "registry.db"), wait)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open registry database: %w", err)
+	}
+
+	return repository.NewBadgerDBRepository(db), nil
+}
+
+// OpenDatabaseReadOnly opens the registry database kept in registryDir for reading, for
+// commands that inspect the registry without an engine. Registries left by an engine
+// that did not shut down cleanly cannot be read until an engine opens them again.
+func OpenDatabaseReadOnly(registryDir string) (repository.DBRepository, error) {
+	path := filepath.Join(registryDir, "registry.db")
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open registry database: %w", err)
+	}
+
+	db, err := OpenBadgerReadOnly(path)
+	if err != nil && strings.Contains(err.Error(), badger.ErrTruncateNeeded.Error()) {
+		return nil, fmt.Errorf("failed to open registry database: %s was not closed cleanly; start the engine to recover it", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open registry database: %w", err)
+	}
+
+	return repository.NewBadgerDBRepository(db), nil
+}
+
+// ReadRegistry opens the registry kept in registryDir with OpenDatabaseReadOnly, runs read
+// on it and closes it. A non-nil key decrypts a registry the engine encrypts with it.
+func ReadRegistry(registryDir string, key []byte, read func(registry.Registry) error) error {
+	var opts []Option
+	if key != nil {
+		cipher, err := NewCipher(key)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, WithEncryption(cipher))
+	}
+
+	dbRepo, err := OpenDatabaseReadOnly(registryDir)
+	if err != nil {
+		return err
+	}
+	defer dbRepo.Close()
+
+	return read(NewLocalRegistry(registryDir, dbRepo, opts...))
+}
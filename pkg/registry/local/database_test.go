@@ -0,0 +1,78 @@
+package localregistry
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenDatabaseLocked(t *testing.T) {
+	dir := t.TempDir()
+	dbRepo, err := OpenDatabase(dir)
+	require.NoError(t, err)
+
+	// The holder's pid comes from the lock file badger writes
+	_, err = OpenDatabase(dir)
+	require.ErrorIs(t, err, ErrDatabaseLocked)
+	var locked *LockedError
+	require.ErrorAs(t, err, &locked)
+	assert.Equal(t, os.Getpid(), locked.PID)
+	assert.Contains(t, err.Error(), "pid")
+
+	// A wait outlasting the holder opens the database once it is released
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		dbRepo.Close()
+	}()
+	dbRepo, err = OpenDatabaseWithWait(dir, 5*time.Second)
+	require.NoError(t, err)
+	require.NoError(t, NewLocalRegistry(dir, dbRepo).Push("ns", "fn", []byte("wasm"), "digest", "latest", manifest.FunctionVersionSettings{}))
+
+	_, err = OpenDatabaseReadOnly(dir)
+	require.ErrorIs(t, err, ErrDatabaseLocked)
+	require.NoError(t, dbRepo.Close())
+
+	// Several readers can share a database closed by its writer
+	first, err := OpenDatabaseReadOnly(dir)
+	require.NoError(t, err)
+	defer first.Close()
+	second, err := OpenDatabaseReadOnly(dir)
+	require.NoError(t, err)
+	defer second.Close()
+
+	metadata, err := NewLocalRegistry(dir, second).Get("ns", "fn")
+	require.NoError(t, err)
+	assert.Len(t, metadata.Versions, 1)
+}
+
+func TestReadRegistryEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{1}, 32)
+	cipher, err := NewCipher(key)
+	require.NoError(t, err)
+
+	dbRepo, err := OpenDatabase(dir)
+	require.NoError(t, err)
+	require.NoError(t, NewLocalRegistry(dir, dbRepo, WithEncryption(cipher)).Push("ns", "fn", []byte("wasm"), "digest", "latest", manifest.FunctionVersionSettings{}))
+	require.NoError(t, dbRepo.Close())
+
+	// Read-only readers decrypt the registry with the key of the engine
+	require.NoError(t, ReadRegistry(dir, key, func(reg registry.Registry) error {
+		metadata, err := reg.Get("ns", "fn")
+		require.NoError(t, err)
+		assert.Equal(t, "digest", metadata.Versions[0].FullDigest)
+		return nil
+	}))
+
+	err = ReadRegistry(dir, nil, func(reg registry.Registry) error {
+		_, err := reg.Get("ns", "fn")
+		return err
+	})
+	assert.ErrorIs(t, err, registry.ErrEncrypted)
+}
@@ -0,0 +1,141 @@
+package localregistry
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// encryptedMagic starts every encrypted value. Wasm modules start with \0asm and
+// metadata with '{', so values written before encryption was enabled stay readable.
+var encryptedMagic = []byte("IGNENC1\x00")
+
+// Cipher encrypts registry values with AES-256-GCM. Each value is bound to where it
+// is stored, so encrypted values cannot be swapped between functions.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a 32-byte key.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// WithEncryption encrypts wasm modules and function metadata written by the registry.
+// Values written without encryption can still be read.
+func WithEncryption(c *Cipher) Option {
+	return func(r *localRegistry) {
+		r.cipher = c
+	}
+}
+
+// seal encrypts plaintext bound to location. A nil cipher returns plaintext as is.
+func (c *Cipher) seal(plaintext []byte, location string) ([]byte, error) {
+	if c == nil {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := make([]byte, 0, len(encryptedMagic)+len(nonce)+len(plaintext)+c.aead.Overhead())
+	sealed = append(sealed, encryptedMagic...)
+	sealed = append(sealed, nonce...)
+	return c.aead.Seal(sealed, nonce, plaintext, []byte(location)), nil
+}
+
+// open decrypts a value sealed for location. Values without the encryption marker
+// are returned as is.
+func (c *Cipher) open(data []byte, location string) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		return data, nil
+	}
+	if c == nil {
+		return nil, registry.ErrEncrypted
+	}
+
+	data = data[len(encryptedMagic):]
+	if len(data) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("%w: value is truncated", registry.ErrDecryptionFailed)
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(location))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", registry.ErrDecryptionFailed, err)
+	}
+	return plaintext, nil
+}
+
+// encryptedStorage encrypts wasm files on their way to and from the underlying storage.
+type encryptedStorage struct {
+	registry.Storage
+	rootDir string
+	cipher  *Cipher
+}
+
+func (s *encryptedStorage) ReadWASMFile(path string) ([]byte, error) {
+	data, err := s.Storage.ReadWASMFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.cipher.open(data, s.location(path))
+}
+
+// MapWASMFile maps a WASM file from the underlying storage. Encrypted files are
+// decrypted into memory and their mapping released at once.
+func (s *encryptedStorage) MapWASMFile(path string) ([]byte, func(), error) {
+	mapper, ok := s.Storage.(wasmFileMapper)
+	if !ok {
+		data, err := s.ReadWASMFile(path)
+		return data, func() {}, err
+	}
+
+	data, release, err := mapper.MapWASMFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		return data, release, nil
+	}
+
+	defer release()
+	plaintext, err := s.cipher.open(data, s.location(path))
+	if err != nil {
+		return nil, nil, err
+	}
+	return plaintext, func() {}, nil
+}
+
+func (s *encryptedStorage) WriteWASMFile(path string, data []byte) error {
+	sealed, err := s.cipher.seal(data, s.location(path))
+	if err != nil {
+		return err
+	}
+	return s.Storage.WriteWASMFile(path, sealed)
+}
+
+// location is the path relative to the registry directory, so it can be moved.
+func (s *encryptedStorage) location(path string) string {
+	if rel, err := filepath.Rel(s.rootDir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
@@ -0,0 +1,80 @@
+package localregistry
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedRegistry(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+	dbRepo := setup.registry.(*localRegistry).dbRepo
+
+	cipher, err := NewCipher(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	encrypted := NewLocalRegistry(setup.tmpDir, dbRepo, WithEncryption(cipher))
+
+	// A version pushed before encryption was enabled stays readable
+	require.NoError(t, setup.registry.Push("test", "plain", []byte("plain wasm"), "plain123", "latest", defaultSettings))
+	payload, _, err := encrypted.Pull("test", "plain", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("plain wasm"), payload)
+
+	payload = []byte("\x00asm proprietary module")
+	require.NoError(t, encrypted.Push("test", "secret", payload, "secret123", "latest", defaultSettings))
+
+	// Neither the module nor the metadata is stored in plain
+	stored, err := os.ReadFile(NewLocalStorage(setup.tmpDir).BuildWASMPath("test", "secret", "secret123"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(stored), "proprietary")
+	require.NoError(t, setup.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(buildFunctionKey("test", "secret"))
+		require.NoError(t, err)
+		return item.Value(func(val []byte) error {
+			assert.NotContains(t, string(val), "secret123")
+			return nil
+		})
+	}))
+
+	pulled, version, err := encrypted.Pull("test", "secret", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, payload, pulled)
+	assert.Equal(t, "secret123", version.FullDigest)
+
+	functions, err := encrypted.ListAll()
+	require.NoError(t, err)
+	assert.Len(t, functions, 2)
+
+	// Without the key, or with another one, encrypted values cannot be read
+	_, err = setup.registry.Get("test", "secret")
+	assert.ErrorIs(t, err, registry.ErrEncrypted)
+
+	otherCipher, err := NewCipher(bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+	_, _, err = NewLocalRegistry(setup.tmpDir, dbRepo, WithEncryption(otherCipher)).Pull("test", "secret", "latest")
+	assert.ErrorIs(t, err, registry.ErrDecryptionFailed)
+}
+
+func TestCipherBindsValuesToTheirLocation(t *testing.T) {
+	cipher, err := NewCipher(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	sealed, err := cipher.seal([]byte("value"), "func:a/one")
+	require.NoError(t, err)
+
+	opened, err := cipher.open(sealed, "func:a/one")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), opened)
+
+	_, err = cipher.open(sealed, "func:a/two")
+	assert.ErrorIs(t, err, registry.ErrDecryptionFailed)
+
+	_, err = NewCipher([]byte("short"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package localregistry
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// storedVersion is a version together with the function it belongs to.
+type storedVersion struct {
+	namespace string
+	name      string
+	version   registry.VersionInfo
+}
+
+func (v storedVersion) String() string {
+	return fmt.Sprintf("%s/%s@%s", v.namespace, v.name, v.version.Hash)
+}
+
+// CheckIntegrity verifies that every stored version has its WASM file. The contents of
+// up to sample versions picked at random, or of every version when sample is zero, are
+// also checked against the recorded size and checksum; versions stored before
+// checksums were recorded are only checked by size. Missing and corrupt files are
+// fetched again from the upstream registry, if any.
+func (r *localRegistry) CheckIntegrity(sample int) (*registry.IntegrityReport, error) {
+	start := time.Now()
+	report := &registry.IntegrityReport{
+		StartedAt: start,
+		Mode:      "full",
+		Missing:   []string{},
+		Corrupt:   []string{},
+		Repaired:  []string{},
+	}
+	if sample > 0 {
+		report.Mode = "sample"
+	}
+
+	functions, err := r.ListAll()
+	if err != nil {
+		return r.finishIntegrityReport(report, start, err)
+	}
+	files, err := r.storage.ListWASMFiles()
+	if err != nil {
+		return r.finishIntegrityReport(report, start, err)
+	}
+	present := make(map[string]bool, len(files))
+	for _, path := range files {
+		present[path] = true
+	}
+
+	// Every version is checked for its file, only present ones can be verified
+	var stored []storedVersion
+	for _, fn := range functions {
+		for _, version := range fn.Versions {
+			report.Versions++
+			v := storedVersion{namespace: fn.Namespace, name: fn.Name, version: version}
+			if !present[r.storage.BuildWASMPath(fn.Namespace, fn.Name, version.Hash)] {
+				report.Missing = append(report.Missing, v.String())
+				r.repairVersion(report, v)
+				continue
+			}
+			stored = append(stored, v)
+		}
+	}
+
+	if sample > 0 && sample < len(stored) {
+		rand.Shuffle(len(stored), func(i, j int) { stored[i], stored[j] = stored[j], stored[i] })
+		stored = stored[:sample]
+	}
+	for _, v := range stored {
+		report.Verified++
+		payload, err := r.storage.ReadWASMFile(r.storage.BuildWASMPath(v.namespace, v.name, v.version.Hash))
+		if err != nil || !matchesVersion(payload, v.version) {
+			report.Corrupt = append(report.Corrupt, v.String())
+			r.repairVersion(report, v)
+		}
+	}
+
+	sort.Strings(report.Missing)
+	sort.Strings(report.Corrupt)
+	sort.Strings(report.Repaired)
+
+	return r.finishIntegrityReport(report, start, nil)
+}
+
+// repairVersion writes the WASM file of a version again from the upstream registry,
+// trying its hash and then its tags, and records it as repaired on success.
+func (r *localRegistry) repairVersion(report *registry.IntegrityReport, v storedVersion) {
+	if r.upstream == nil {
+		return
+	}
+
+	for _, reference := range append([]string{v.version.Hash}, v.version.Tags...) {
+		payload, _, err := r.upstream.Pull(context.Background(), v.namespace, v.name, reference)
+		if err != nil || !matchesVersion(payload, v.version) {
+			continue
+		}
+		if err := r.storage.WriteWASMFile(r.storage.BuildWASMPath(v.namespace, v.name, v.version.Hash), payload); err != nil {
+			return
+		}
+		report.Repaired = append(report.Repaired, v.String())
+		return
+	}
+}
+
+// matchesVersion reports whether payload is the module recorded in version.
+func matchesVersion(payload []byte, version registry.VersionInfo) bool {
+	if int64(len(payload)) != version.Size {
+		return false
+	}
+	return version.Checksum == "" || registry.Checksum(payload) == version.Checksum
+}
+
+func (r *localRegistry) finishIntegrityReport(report *registry.IntegrityReport, start time.Time, err error) (*registry.IntegrityReport, error) {
+	report.Duration = time.Since(start).String()
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report, err
+}
@@ -0,0 +1,79 @@
+package localregistry
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// gcDiscardRatio is the fraction of a value log file that must be stale before it is rewritten.
+const gcDiscardRatio = 0.5
+
+// Maintain runs Badger value log GC and checks stored WASM files against the
+// version metadata. Inconsistencies are reported, never repaired automatically.
+func (r *localRegistry) Maintain() (*registry.MaintenanceReport, error) {
+	start := time.Now()
+	report := &registry.MaintenanceReport{
+		StartedAt:    start,
+		OrphanFiles:  []string{},
+		MissingFiles: []string{},
+	}
+
+	// Reclaim space from the value log
+	rewrites, err := r.dbRepo.RunGC(gcDiscardRatio)
+	report.GCRewrites = rewrites
+	if err != nil {
+		return r.finishReport(report, start, fmt.Errorf("value log GC failed: %w", err))
+	}
+	report.LSMSize, report.VLogSize = r.dbRepo.Size()
+
+	// Collect the paths every known version expects
+	functions, err := r.ListAll()
+	if err != nil {
+		return r.finishReport(report, start, err)
+	}
+
+	expected := make(map[string]bool)
+	for _, fn := range functions {
+		for _, version := range fn.Versions {
+			expected[r.storage.BuildWASMPath(fn.Namespace, fn.Name, version.Hash)] = true
+			for _, variant := range version.Variants {
+				expected[r.variantPath(fn.Namespace, fn.Name, version.Hash, variant.Name)] = true
+			}
+		}
+	}
+
+	// Compare against what is actually on disk
+	files, err := r.storage.ListWASMFiles()
+	if err != nil {
+		return r.finishReport(report, start, err)
+	}
+
+	present := make(map[string]bool, len(files))
+	for _, path := range files {
+		present[path] = true
+		if !expected[path] {
+			report.OrphanFiles = append(report.OrphanFiles, path)
+		}
+	}
+	for path := range expected {
+		if !present[path] {
+			report.MissingFiles = append(report.MissingFiles, path)
+		}
+	}
+
+	sort.Strings(report.OrphanFiles)
+	sort.Strings(report.MissingFiles)
+
+	return r.finishReport(report, start, nil)
+}
+
+func (r *localRegistry) finishReport(report *registry.MaintenanceReport, start time.Time, err error) (*registry.MaintenanceReport, error) {
+	report.Duration = time.Since(start).String()
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report, err
+}
@@ -0,0 +1,244 @@
+package localregistry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// SchemaVersion is the metadata schema written by this version of the registry.
+// Databases created before versioning was introduced are at version 0.
+const SchemaVersion = 1
+
+// migrationBatchSize caps the number of records rewritten per transaction
+const migrationBatchSize = 1000
+
+// schemaVersionKey holds the schema version of the database
+var schemaVersionKey = []byte("meta:schema_version")
+
+// functionKeyPrefix is the prefix of every function metadata record
+var functionKeyPrefix = []byte("func:")
+
+// migration upgrades function metadata records to a schema version. Upgrades must
+// be idempotent, since an interrupted migration is simply run again, and report
+// whether they changed the record.
+type migration struct {
+	version     int
+	description string
+	upgrade     func(metadata *registry.FunctionMetadata) bool
+}
+
+// migrations lists every schema migration in version order
+var migrations = []migration{
+	{
+		version:     1,
+		description: "Backfill missing version hashes and tag lists",
+		upgrade:     backfillVersionFields,
+	},
+}
+
+// Migrate upgrades the database to SchemaVersion. Before changing any record a
+// backup of the whole database is written to the backups directory. A dry run
+// only reports the pending migrations and how many records each would change.
+func (r *localRegistry) Migrate(dryRun bool) (*registry.MigrationReport, error) {
+	current, err := r.schemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if current > SchemaVersion {
+		return nil, fmt.Errorf("%w: database is at version %d, supported up to %d",
+			registry.ErrSchemaTooNew, current, SchemaVersion)
+	}
+
+	report := &registry.MigrationReport{
+		FromVersion: current,
+		ToVersion:   SchemaVersion,
+		DryRun:      dryRun,
+		Steps:       []registry.MigrationStep{},
+	}
+	if current == SchemaVersion {
+		return report, nil
+	}
+
+	// Plan every pending migration against the current records
+	records, err := r.loadFunctionRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]*registry.FunctionMetadata)
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		step := registry.MigrationStep{Version: m.version, Description: m.description}
+		for key, metadata := range records {
+			if m.upgrade(metadata) {
+				changed[key] = metadata
+				step.Records++
+			}
+		}
+		report.Steps = append(report.Steps, step)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if len(changed) > 0 {
+		backupPath, err := r.backupDatabase(current)
+		if err != nil {
+			return nil, err
+		}
+		report.BackupPath = backupPath
+
+		if err := r.writeFunctionRecords(changed); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.withWriteTx(func(txn *badger.Txn) error {
+		return txn.Set(schemaVersionKey, []byte(strconv.Itoa(SchemaVersion)))
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record schema version: %w", err)
+	}
+
+	return report, nil
+}
+
+// schemaVersion reads the schema version of the database
+func (r *localRegistry) schemaVersion() (int, error) {
+	version := 0
+	err := r.withReadTx(func(txn *badger.Txn) error {
+		item, err := txn.Get(schemaVersionKey)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			version, err = strconv.Atoi(string(val))
+			return err
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// loadFunctionRecords reads every function metadata record keyed by its database key
+func (r *localRegistry) loadFunctionRecords() (map[string]*registry.FunctionMetadata, error) {
+	records := make(map[string]*registry.FunctionMetadata)
+
+	err := r.withReadTx(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(functionKeyPrefix); it.ValidForPrefix(functionKeyPrefix); it.Next() {
+			item := it.Item()
+			err := item.Value(func(val []byte) error {
+				var metadata registry.FunctionMetadata
+				if err := r.decodeMetadata(item.Key(), val, &metadata); err != nil {
+					return err
+				}
+				records[string(item.KeyCopy(nil))] = &metadata
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read function metadata: %w", err)
+	}
+
+	return records, nil
+}
+
+// writeFunctionRecords stores upgraded records in batches
+func (r *localRegistry) writeFunctionRecords(records map[string]*registry.FunctionMetadata) error {
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+
+	for start := 0; start < len(keys); start += migrationBatchSize {
+		batch := keys[start:min(start+migrationBatchSize, len(keys))]
+		err := r.withWriteTx(func(txn *badger.Txn) error {
+			for _, key := range batch {
+				val, err := r.encodeMetadata([]byte(key), records[key])
+				if err != nil {
+					return fmt.Errorf("failed to encode metadata %s: %w", key, err)
+				}
+				if err := txn.Set([]byte(key), val); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write migrated metadata: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// backupDatabase writes a full database backup and returns its path
+func (r *localRegistry) backupDatabase(version int) (string, error) {
+	dir := filepath.Join(r.rootDir, "backups")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("registry-v%d-%s.bak", version, time.Now().UTC().Format("20060102T150405Z")))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+
+	if err := r.dbRepo.Backup(file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", fmt.Errorf("failed to back up registry database: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write backup file: %w", err)
+	}
+
+	return path, nil
+}
+
+// backfillVersionFields fills the short hash from the full digest and replaces
+// null version and tag lists written by early releases.
+func backfillVersionFields(metadata *registry.FunctionMetadata) bool {
+	changed := false
+	if metadata.Versions == nil {
+		metadata.Versions = []registry.VersionInfo{}
+		changed = true
+	}
+
+	for i := range metadata.Versions {
+		version := &metadata.Versions[i]
+		if version.Hash == "" && version.FullDigest != "" {
+			version.Hash = registry.TruncateDigest(version.FullDigest, 12)
+			changed = true
+		}
+		if version.Tags == nil {
+			version.Tags = []string{}
+			changed = true
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package localregistry
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads a file into memory where memory mapping is not available.
+func mapFile(file *os.File, size int) ([]byte, func(), error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() {}, nil
+}
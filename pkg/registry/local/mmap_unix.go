@@ -0,0 +1,18 @@
+//go:build unix
+
+package localregistry
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps a file read-only into memory. Its pages are read from disk as they are
+// touched and can be dropped by the kernel under memory pressure, unlike heap copies.
+func mapFile(file *os.File, size int) ([]byte, func(), error) {
+	data, err := syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() { _ = syscall.Munmap(data) }, nil
+}
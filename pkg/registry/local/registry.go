@@ -4,24 +4,84 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 
 	badger "github.com/dgraph-io/badger/v4"
 	"github.com/ignitionstack/ignition/internal/repository"
 	"github.com/ignitionstack/ignition/pkg/manifest"
 	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/validation"
 )
 
+// maxUpdateAttempts bounds how often a metadata update is retried after losing
+// a race with a concurrent writer.
+const maxUpdateAttempts = 10
+
 type localRegistry struct {
 	dbRepo  repository.DBRepository
 	storage registry.Storage
+	rootDir string
+
+	// Module validation applied on push
+	validateModules bool
+	maxModuleSize   int64
+
+	// Decides whether a version may be stored (nil admits everything)
+	admit AdmitFunc
+
+	// Encrypts modules and metadata at rest (nil stores them in plain)
+	cipher *Cipher
+
+	// Told about every committed write (nil tells no one)
+	observe func(registry.WriteEvent)
+
+	// Registry that pull misses are fetched from and cached (nil serves local versions only)
+	upstream registry.Upstream
+}
+
+// AdmitFunc decides whether a version may be stored, returning an error to refuse it.
+// info is nil when the module could not be inspected.
+type AdmitFunc func(namespace, name, digest string, settings manifest.FunctionVersionSettings, info *registry.ModuleInfo) error
+
+// Option configures a local registry.
+type Option func(*localRegistry)
+
+// WithModuleValidation makes Push reject payloads that are not valid wasm modules,
+// exceed maxSize bytes (zero disables the limit), or export no callable entrypoints.
+func WithModuleValidation(maxSize int64) Option {
+	return func(r *localRegistry) {
+		r.validateModules = true
+		r.maxModuleSize = maxSize
+	}
+}
+
+// WithAdmission makes Push ask admit before anything is written.
+func WithAdmission(admit AdmitFunc) Option {
+	return func(r *localRegistry) {
+		r.admit = admit
+	}
 }
 
-func NewLocalRegistry(rootDir string, dbRepo repository.DBRepository) registry.Registry {
-	return &localRegistry{
+// WithWriteObserver makes the registry call observe after every committed push and
+// tag reassignment. observe runs on the writing goroutine and must not block.
+func WithWriteObserver(observe func(registry.WriteEvent)) Option {
+	return func(r *localRegistry) {
+		r.observe = observe
+	}
+}
+
+func NewLocalRegistry(rootDir string, dbRepo repository.DBRepository, opts ...Option) registry.Registry {
+	r := &localRegistry{
 		dbRepo:  dbRepo,
 		storage: NewLocalStorage(rootDir),
+		rootDir: rootDir,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	r.storage = &encryptedStorage{Storage: r.storage, rootDir: rootDir, cipher: r.cipher}
+	return r
 }
 
 func (r *localRegistry) Get(namespace, name string) (*registry.FunctionMetadata, error) {
@@ -34,33 +94,113 @@ func (r *localRegistry) Get(namespace, name string) (*registry.FunctionMetadata,
 	return metadata, err
 }
 
+// readFunc reads the WASM file at path.
+type readFunc func(path string) ([]byte, error)
+
+// wasmFileMapper is implemented by storage that can map WASM files into memory.
+type wasmFileMapper interface {
+	MapWASMFile(path string) ([]byte, func(), error)
+}
+
 func (r *localRegistry) Pull(namespace, name, reference string) ([]byte, *registry.VersionInfo, error) {
+	return r.pull(namespace, name, reference, r.storage.ReadWASMFile)
+}
+
+// PullMapped works like Pull, but maps the module from storage instead of reading it.
+// Encrypted modules are decrypted into memory.
+func (r *localRegistry) PullMapped(namespace, name, reference string) ([]byte, *registry.VersionInfo, func(), error) {
+	mapper, ok := r.storage.(wasmFileMapper)
+	if !ok {
+		wasmBytes, versionInfo, err := r.Pull(namespace, name, reference)
+		return wasmBytes, versionInfo, func() {}, err
+	}
+
+	// Versions are looked up by digest and then by tag, so several files may be mapped
+	var releases []func()
+	release := func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+	read := func(path string) ([]byte, error) {
+		data, release, err := mapper.MapWASMFile(path)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, release)
+		return data, nil
+	}
+
+	wasmBytes, versionInfo, err := r.pull(namespace, name, reference, read)
+	if err != nil {
+		release()
+		return nil, nil, nil, err
+	}
+	return wasmBytes, versionInfo, release, nil
+}
+
+func (r *localRegistry) pull(namespace, name, reference string, read readFunc) ([]byte, *registry.VersionInfo, error) {
 	// Try to pull by digest first
-	wasmBytes, versionInfo, digestErr := r.pullByDigest(namespace, name, reference)
+	wasmBytes, versionInfo, digestErr := r.pullByDigest(namespace, name, reference, read)
 	if digestErr == nil {
 		return wasmBytes, versionInfo, nil
 	}
 
 	// If that fails, try by tag
-	wasmBytes, versionInfo, tagErr := r.pullByTag(namespace, name, reference)
+	wasmBytes, versionInfo, tagErr := r.pullByTag(namespace, name, reference, read)
 	if tagErr == nil {
 		return wasmBytes, versionInfo, nil
 	}
 
-	// If both fail with specific errors, return a more helpful error
+	// If both fail with specific errors, return a more helpful error, otherwise the tag error
+	err := tagErr
 	if errors.Is(tagErr, registry.ErrTagNotFound) && errors.Is(digestErr, registry.ErrDigestNotFound) {
-		return nil, nil, fmt.Errorf("%w: %s", registry.ErrInvalidReference, reference)
+		err = fmt.Errorf("%w: %s", registry.ErrInvalidReference, reference)
 	}
 
-	// Otherwise return the tag error
-	return nil, nil, tagErr
+	// Versions missing locally are fetched from the upstream registry, if any
+	if r.upstream != nil && isMiss(err) {
+		return r.pullThrough(namespace, name, reference, err, read)
+	}
+	return nil, nil, err
 }
 
 func (r *localRegistry) Push(namespace, name string, payload []byte, fullDigest, tag string, settings manifest.FunctionVersionSettings) error {
+	if err := validation.ValidateFunction(namespace, name); err != nil {
+		return err
+	}
+	if tag != "" {
+		if err := validation.ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+
 	shortDigest := registry.TruncateDigest(fullDigest, 12)
 	path := r.storage.BuildWASMPath(namespace, name, shortDigest)
 
-	return r.withWriteTx(func(txn *badger.Txn) error {
+	// Validate the module before anything is written
+	var moduleInfo *registry.ModuleInfo
+	if r.validateModules {
+		info, err := registry.ValidateModule(payload, settings, r.maxModuleSize)
+		if err != nil {
+			return fmt.Errorf("module validation failed for %s/%s: %w", namespace, name, err)
+		}
+		moduleInfo = info
+	}
+
+	if r.admit != nil {
+		info := moduleInfo
+		if info == nil {
+			info, _ = registry.InspectModule(payload)
+		}
+		if err := r.admit(namespace, name, fullDigest, settings, info); err != nil {
+			return err
+		}
+	}
+
+	// Pushing a stored version without a tag changes nothing observers need to know about
+	changed := false
+	err := r.updateFunction(func(txn *badger.Txn) error {
 		// Get or create function metadata
 		metadata, err := r.getOrCreateMetadata(txn, namespace, name)
 		if err != nil {
@@ -74,6 +214,7 @@ func (r *localRegistry) Push(namespace, name string, payload []byte, fullDigest,
 
 		// Check if this version already exists
 		versionExists := r.versionExists(metadata, shortDigest)
+		changed = !versionExists || tag != ""
 
 		// If the version doesn't exist, write the WASM file and create version info
 		if !versionExists {
@@ -81,6 +222,7 @@ func (r *localRegistry) Push(namespace, name string, payload []byte, fullDigest,
 				return fmt.Errorf("failed to write WASM file: %w", err)
 			}
 			newVersion := registry.CreateVersionInfo(shortDigest, fullDigest, payload, tag, settings)
+			newVersion.Module = moduleInfo
 			metadata.Versions = append(metadata.Versions, newVersion)
 		} else if tag != "" {
 			// If the version exists and a tag is provided, add the tag to the version
@@ -90,10 +232,14 @@ func (r *localRegistry) Push(namespace, name string, payload []byte, fullDigest,
 		// Update the metadata in the database
 		return r.updateMetadata(txn, namespace, name, metadata)
 	})
+	if err == nil && changed {
+		r.notify(registry.WriteEvent{Op: registry.WritePush, Namespace: namespace, Name: name, Digest: fullDigest, Tag: tag})
+	}
+	return err
 }
 
 func (r *localRegistry) ReassignTag(namespace, name, tag, newDigest string) error {
-	return r.withWriteTx(func(txn *badger.Txn) error {
+	err := r.updateFunction(func(txn *badger.Txn) error {
 		// Get function metadata
 		var metadata *registry.FunctionMetadata
 		err := r.getFunctionMetadata(txn, namespace, name, &metadata)
@@ -124,6 +270,44 @@ func (r *localRegistry) ReassignTag(namespace, name, tag, newDigest string) erro
 		// Update metadata in database
 		return r.updateMetadata(txn, namespace, name, metadata)
 	})
+	if err == nil {
+		r.notify(registry.WriteEvent{Op: registry.WriteTag, Namespace: namespace, Name: name, Digest: newDigest, Tag: tag})
+	}
+	return err
+}
+
+// RecordProvenance attaches provenance to a stored version that has none yet.
+func (r *localRegistry) RecordProvenance(namespace, name, digest string, provenance registry.Provenance) error {
+	return r.updateFunction(func(txn *badger.Txn) error {
+		var metadata *registry.FunctionMetadata
+		if err := r.getFunctionMetadata(txn, namespace, name, &metadata); err != nil {
+			return err
+		}
+		if metadata == nil {
+			return registry.ErrFunctionNotFound
+		}
+
+		shortDigest := registry.TruncateDigest(digest, 12)
+		for i := range metadata.Versions {
+			version := &metadata.Versions[i]
+			if version.Hash != shortDigest {
+				continue
+			}
+			if version.Provenance != nil {
+				return nil
+			}
+			version.Provenance = &provenance
+			return r.updateMetadata(txn, namespace, name, metadata)
+		}
+		return registry.ErrDigestNotFound
+	})
+}
+
+// notify tells the write observer, if any, about a committed write.
+func (r *localRegistry) notify(event registry.WriteEvent) {
+	if r.observe != nil {
+		r.observe(event)
+	}
 }
 
 func (r *localRegistry) DigestExists(namespace, name, digest string) (bool, error) {
@@ -158,15 +342,14 @@ func (r *localRegistry) ListAll() ([]registry.FunctionMetadata, error) {
 		defer it.Close()
 
 		// Iterate through all keys with the "func:" prefix
-		prefix := []byte("func:")
-		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		for it.Seek(functionKeyPrefix); it.ValidForPrefix(functionKeyPrefix); it.Next() {
 			item := it.Item()
 
 			// Read and parse the value
 			err := item.Value(func(val []byte) error {
 				var metadata registry.FunctionMetadata
-				if err := json.Unmarshal(val, &metadata); err != nil {
-					return fmt.Errorf("failed to unmarshal metadata: %w", err)
+				if err := r.decodeMetadata(item.Key(), val, &metadata); err != nil {
+					return err
 				}
 				functions = append(functions, metadata)
 				return nil
@@ -194,6 +377,27 @@ func (r *localRegistry) withWriteTx(fn func(txn *badger.Txn) error) error {
 	return r.dbRepo.Update(fn)
 }
 
+// updateFunction runs a read-modify-write of function metadata. Badger aborts the
+// commit of a transaction whose reads were changed by a concurrent writer; the whole
+// update is then run again on fresh metadata, so concurrent pushes never drop
+// each other's versions or tags.
+func (r *localRegistry) updateFunction(fn func(txn *badger.Txn) error) error {
+	backoff := 2 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := r.withWriteTx(fn)
+		if !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+		if attempt == maxUpdateAttempts {
+			return fmt.Errorf("%w: gave up after %d attempts", registry.ErrConflict, attempt)
+		}
+
+		// Jitter the wait so racing writers do not collide again
+		time.Sleep(backoff + time.Duration(rand.Int63n(int64(backoff))))
+		backoff *= 2
+	}
+}
+
 // getFunctionMetadata retrieves a function's metadata from the database.
 func (r *localRegistry) getFunctionMetadata(txn *badger.Txn, namespace, name string, metadata **registry.FunctionMetadata) error {
 	key := buildFunctionKey(namespace, name)
@@ -210,10 +414,7 @@ func (r *localRegistry) getFunctionMetadata(txn *badger.Txn, namespace, name str
 	// Read and parse the value
 	return item.Value(func(val []byte) error {
 		*metadata = &registry.FunctionMetadata{}
-		if err := json.Unmarshal(val, *metadata); err != nil {
-			return fmt.Errorf("failed to unmarshal metadata: %w", err)
-		}
-		return nil
+		return r.decodeMetadata(key, val, *metadata)
 	})
 }
 
@@ -239,11 +440,11 @@ func (r *localRegistry) getOrCreateMetadata(txn *badger.Txn, namespace, name str
 	// Read and parse existing metadata
 	var metadata registry.FunctionMetadata
 	err = item.Value(func(val []byte) error {
-		return json.Unmarshal(val, &metadata)
+		return r.decodeMetadata(key, val, &metadata)
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		return nil, err
 	}
 
 	return &metadata, nil
@@ -253,13 +454,14 @@ func (r *localRegistry) getOrCreateMetadata(txn *badger.Txn, namespace, name str
 func (r *localRegistry) updateMetadata(txn *badger.Txn, namespace, name string, metadata *registry.FunctionMetadata) error {
 	key := buildFunctionKey(namespace, name)
 
-	// Update the timestamp
+	// Update the timestamp and revision
 	metadata.UpdatedAt = time.Now()
+	metadata.Revision++
 
 	// Marshal the metadata to JSON
-	val, err := json.Marshal(metadata)
+	val, err := r.encodeMetadata(key, metadata)
 	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
+		return err
 	}
 
 	// Write to the database
@@ -281,12 +483,12 @@ func (r *localRegistry) versionExists(metadata *registry.FunctionMetadata, short
 }
 
 // pullByDigest retrieves a function by its digest.
-func (r *localRegistry) pullByDigest(namespace, name, shortDigest string) ([]byte, *registry.VersionInfo, error) {
+func (r *localRegistry) pullByDigest(namespace, name, shortDigest string, read readFunc) ([]byte, *registry.VersionInfo, error) {
 	// Build the path to the WASM file
 	path := r.storage.BuildWASMPath(namespace, name, shortDigest)
 
 	// Read the WASM file
-	wasmBytes, err := r.storage.ReadWASMFile(path)
+	wasmBytes, err := read(path)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read WASM file: %w", err)
 	}
@@ -320,8 +522,8 @@ func (r *localRegistry) pullByDigest(namespace, name, shortDigest string) ([]byt
 	return wasmBytes, versionInfo, nil
 }
 
-// pullByTag retrieves a function by its tag.
-func (r *localRegistry) pullByTag(namespace, name, tag string) ([]byte, *registry.VersionInfo, error) {
+// pullByTag retrieves a function by its tag, or by the highest tag in a semver range.
+func (r *localRegistry) pullByTag(namespace, name, tag string, read readFunc) ([]byte, *registry.VersionInfo, error) {
 	var wasmBytes []byte
 	var versionInfo *registry.VersionInfo
 
@@ -331,26 +533,37 @@ func (r *localRegistry) pullByTag(namespace, name, tag string) ([]byte, *registr
 			return err
 		}
 
-		// Find the version with the matching tag
-		for _, v := range metadata.Versions {
+		// Find the version with the matching tag, or else the highest version
+		// whose tag satisfies a semver range such as ^1.2
+		var match *registry.VersionInfo
+		for i, v := range metadata.Versions {
 			if registry.HasTag(v.Tags, tag) {
-				// Create a copy to avoid issues with the slice
-				versionInfoCopy := v
-				versionInfo = &versionInfoCopy
+				match = &metadata.Versions[i]
+				break
+			}
+		}
+		if match == nil {
+			if versionRange, err := registry.ParseVersionRange(tag); err == nil {
+				match, _, _ = registry.ResolveVersionRange(metadata.Versions, versionRange)
+			}
+		}
+		if match == nil {
+			return registry.ErrTagNotFound
+		}
 
-				// Read the WASM file
-				path := r.storage.BuildWASMPath(namespace, name, v.Hash)
-				var err error
-				wasmBytes, err = r.storage.ReadWASMFile(path)
-				if err != nil {
-					return fmt.Errorf("failed to read WASM file: %w", err)
-				}
+		// Create a copy to avoid issues with the slice
+		versionInfoCopy := *match
+		versionInfo = &versionInfoCopy
 
-				return nil
-			}
+		// Read the WASM file
+		path := r.storage.BuildWASMPath(namespace, name, match.Hash)
+		var err error
+		wasmBytes, err = read(path)
+		if err != nil {
+			return fmt.Errorf("failed to read WASM file: %w", err)
 		}
 
-		return registry.ErrTagNotFound
+		return nil
 	})
 
 	if err != nil {
@@ -360,6 +573,27 @@ func (r *localRegistry) pullByTag(namespace, name, tag string) ([]byte, *registr
 	return wasmBytes, versionInfo, nil
 }
 
+// decodeMetadata decrypts and parses the metadata record stored under key.
+func (r *localRegistry) decodeMetadata(key, val []byte, metadata *registry.FunctionMetadata) error {
+	plaintext, err := r.cipher.open(val, string(key))
+	if err != nil {
+		return fmt.Errorf("failed to read metadata %s: %w", key, err)
+	}
+	if err := json.Unmarshal(plaintext, metadata); err != nil {
+		return fmt.Errorf("failed to unmarshal metadata %s: %w", key, err)
+	}
+	return nil
+}
+
+// encodeMetadata serializes and encrypts a metadata record to be stored under key.
+func (r *localRegistry) encodeMetadata(key []byte, metadata *registry.FunctionMetadata) ([]byte, error) {
+	val, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return r.cipher.seal(val, string(key))
+}
+
 // buildFunctionKey creates a database key for a function.
 func buildFunctionKey(namespace, name string) []byte {
 	return []byte(fmt.Sprintf("func:%s/%s", namespace, name))
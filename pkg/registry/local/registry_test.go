@@ -3,8 +3,10 @@ package localregistry
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	badger "github.com/dgraph-io/badger/v4"
@@ -197,6 +199,80 @@ func TestPull(t *testing.T) {
 	})
 }
 
+func TestPullMapped(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+
+	payload := []byte("test wasm v1")
+	require.NoError(t, setup.registry.Push("test", "func1", payload, "digest1", "v1", defaultSettings))
+
+	mapper, ok := setup.registry.(registry.ModuleMapper)
+	require.True(t, ok)
+
+	for _, reference := range []string{"digest1", "v1"} {
+		wasmBytes, versionInfo, release, err := mapper.PullMapped("test", "func1", reference)
+		require.NoError(t, err, reference)
+		assert.Equal(t, payload, wasmBytes, reference)
+		assert.Equal(t, "digest1", versionInfo.FullDigest, reference)
+		release()
+	}
+
+	_, _, _, err := mapper.PullMapped("test", "func1", "nonexistent")
+	assert.ErrorIs(t, err, registry.ErrTagNotFound)
+}
+
+func TestPullBySemverRange(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+
+	for digest, tag := range map[string]string{"d120": "1.2.0", "d127": "1.2.7", "d140": "v1.4.0", "d150rc": "1.5.0-rc.1"} {
+		require.NoError(t, setup.registry.Push("test", "func1", []byte(digest), digest, tag, defaultSettings))
+	}
+
+	tests := map[string]string{"~1.2": "d127", "^1.2": "d140", "1.x": "d140", "<1.2.7": "d120"}
+	for reference, digest := range tests {
+		wasmBytes, versionInfo, err := setup.registry.Pull("test", "func1", reference)
+		require.NoError(t, err, reference)
+		assert.Equal(t, []byte(digest), wasmBytes, reference)
+		assert.Equal(t, digest, versionInfo.FullDigest, reference)
+	}
+
+	_, _, err := setup.registry.Pull("test", "func1", "^2")
+	assert.ErrorIs(t, err, registry.ErrTagNotFound)
+}
+
+func TestConcurrentPushesKeepEveryVersion(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+
+	const pushes = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, pushes)
+	for i := 0; i < pushes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			digest := fmt.Sprintf("digest%02d", i)
+			errs <- setup.registry.Push("test", "func1", []byte(digest), digest, fmt.Sprintf("build-%d", i), defaultSettings)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	metadata, err := setup.registry.Get("test", "func1")
+	require.NoError(t, err)
+	require.Len(t, metadata.Versions, pushes)
+	assert.Equal(t, int64(pushes), metadata.Revision)
+	for i := 0; i < pushes; i++ {
+		_, versionInfo, err := setup.registry.Pull("test", "func1", fmt.Sprintf("build-%d", i))
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("digest%02d", i), versionInfo.FullDigest)
+	}
+}
+
 func TestReassignTag(t *testing.T) {
 	setup := setupTestRegistry(t)
 	defer setup.cleanup()
@@ -402,3 +478,220 @@ func (m *mockStorage) WriteWASMFile(path string, data []byte) error {
 func (m *mockStorage) BuildWASMPath(namespace, name, shortDigest string) string {
 	return filepath.Join(namespace, name, shortDigest+".wasm")
 }
+
+func (m *mockStorage) ListWASMFiles() ([]string, error) {
+	paths := make([]string, 0, len(m.files))
+	for path := range m.files {
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func TestMaintain(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+
+	// Push two versions, then remove one file and add an untracked one
+	require.NoError(t, setup.registry.Push("test", "func1", []byte("wasm one"), "digest-one", "v1", defaultSettings))
+	require.NoError(t, setup.registry.Push("test", "func1", []byte("wasm two"), "digest-two", "v2", defaultSettings))
+
+	storage := NewLocalStorage(setup.tmpDir)
+	missing := storage.BuildWASMPath("test", "func1", "digest-two")
+	require.NoError(t, os.Remove(missing))
+
+	orphan := storage.BuildWASMPath("test", "func1", "stale")
+	require.NoError(t, storage.WriteWASMFile(orphan, []byte("stale")))
+
+	maintainer, ok := setup.registry.(registry.Maintainer)
+	require.True(t, ok)
+
+	report, err := maintainer.Maintain()
+	require.NoError(t, err)
+	assert.Equal(t, []string{orphan}, report.OrphanFiles)
+	assert.Equal(t, []string{missing}, report.MissingFiles)
+	assert.NotEmpty(t, report.Duration)
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+
+	// Corrupt the file of a version the upstream serves and remove one it does not
+	require.NoError(t, setup.registry.Push("ns", "fn", []byte("upstream wasm"), "upstream1234567", "latest", defaultSettings))
+	require.NoError(t, setup.registry.Push("ns", "fn", []byte("local wasm"), "local1234567", "", defaultSettings))
+	require.NoError(t, setup.registry.Push("ns", "intact", []byte("intact wasm"), "intact123456", "", defaultSettings))
+
+	storage := NewLocalStorage(setup.tmpDir)
+	require.NoError(t, storage.WriteWASMFile(storage.BuildWASMPath("ns", "fn", "upstream1234"), []byte("upstream wasN")))
+	require.NoError(t, os.Remove(storage.BuildWASMPath("ns", "fn", "local1234567")))
+
+	checker, ok := setup.registry.(registry.IntegrityChecker)
+	require.True(t, ok)
+
+	report, err := checker.CheckIntegrity(0)
+	require.NoError(t, err)
+	assert.Equal(t, "full", report.Mode)
+	assert.Equal(t, 3, report.Versions)
+	assert.Equal(t, 2, report.Verified)
+	assert.Equal(t, []string{"ns/fn@local1234567"}, report.Missing)
+	assert.Equal(t, []string{"ns/fn@upstream1234"}, report.Corrupt)
+	assert.Empty(t, report.Repaired)
+	assert.Equal(t, 2, report.Unrepaired())
+
+	// Samples never verify more versions than asked for
+	report, err = checker.CheckIntegrity(1)
+	require.NoError(t, err)
+	assert.Equal(t, "sample", report.Mode)
+	assert.Equal(t, 1, report.Verified)
+
+	// With an upstream registry, the corrupt version is written again
+	reg := NewLocalRegistry(setup.tmpDir, setup.registry.(*localRegistry).dbRepo, WithUpstream(&fakeUpstream{}))
+	report, err = reg.(registry.IntegrityChecker).CheckIntegrity(0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns/fn@upstream1234"}, report.Repaired)
+	assert.Equal(t, 1, report.Unrepaired())
+
+	payload, _, err := reg.Pull("ns", "fn", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("upstream wasm"), payload)
+}
+
+func TestRecordProvenance(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+
+	require.NoError(t, setup.registry.Push("ns", "fn", []byte("built wasm"), "built1234567890", "latest", defaultSettings))
+	recorder, ok := setup.registry.(registry.ProvenanceRecorder)
+	require.True(t, ok)
+
+	first := registry.Provenance{Builder: "ignition v1", SourceHash: "sha256:first", Reproducible: true}
+	require.NoError(t, recorder.RecordProvenance("ns", "fn", "built1234567890", first))
+
+	// Rebuilding the same digest keeps the provenance of the build that stored it
+	require.NoError(t, recorder.RecordProvenance("ns", "fn", "built1234567890", registry.Provenance{Builder: "ignition v2"}))
+
+	_, version, err := setup.registry.Pull("ns", "fn", "latest")
+	require.NoError(t, err)
+	require.NotNil(t, version.Provenance)
+	assert.Equal(t, first, *version.Provenance)
+
+	require.ErrorIs(t, recorder.RecordProvenance("ns", "fn", "unknown123456", first), registry.ErrDigestNotFound)
+	require.ErrorIs(t, recorder.RecordProvenance("ns", "other", "built1234567890", first), registry.ErrFunctionNotFound)
+}
+
+func TestSBOM(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+
+	require.NoError(t, setup.registry.Push("ns", "fn", []byte("built wasm"), "built1234567890", "v1.2.0", defaultSettings))
+	store, ok := setup.registry.(registry.SBOMStore)
+	require.True(t, ok)
+
+	_, err := store.SBOM("ns", "fn", "v1.2.0")
+	require.ErrorIs(t, err, registry.ErrSBOMNotFound)
+
+	sbom := registry.NewSBOM("ns", "fn", "built1234567890", []registry.SBOMComponent{
+		{Type: "library", Name: "serde", Version: "1.0.200", PURL: "pkg:cargo/serde@1.0.200"},
+	})
+	require.NoError(t, store.AttachSBOM("ns", "fn", "built1234567890", sbom))
+
+	// SBOMs resolve by digest, tag and semver range, and stay out of the metadata
+	for _, reference := range []string{"built1234567890", "v1.2.0", "^1.0"} {
+		stored, err := store.SBOM("ns", "fn", reference)
+		require.NoError(t, err, reference)
+		assert.Equal(t, sbom.Components, stored.Components, reference)
+		assert.Equal(t, "ns/fn", stored.Metadata.Component.Name, reference)
+	}
+	_, err = store.SBOM("ns", "fn", "v2.0.0")
+	require.ErrorIs(t, err, registry.ErrInvalidReference)
+
+	require.ErrorIs(t, store.AttachSBOM("ns", "fn", "unknown123456", sbom), registry.ErrDigestNotFound)
+	require.ErrorIs(t, store.AttachSBOM("ns", "other", "built1234567890", sbom), registry.ErrFunctionNotFound)
+}
+
+func TestVariants(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+
+	require.NoError(t, setup.registry.Push("ns", "fn", []byte("built wasm"), "built1234567890", "v1.2.0", defaultSettings))
+	store, ok := setup.registry.(registry.VariantStore)
+	require.True(t, ok)
+
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	version, err := store.AddVariant("ns", "fn", "v1.2.0", "debug", module)
+	require.NoError(t, err)
+	require.Len(t, version.Variants, 1)
+	assert.False(t, version.Variants[0].Component)
+	assert.Equal(t, registry.Checksum(module), version.Variants[0].Checksum)
+
+	payload, variant, err := store.PullVariant("ns", "fn", "built1234567890", "debug")
+	require.NoError(t, err)
+	assert.Equal(t, module, payload)
+	assert.Equal(t, "debug", variant.Name)
+
+	// Components are detected, and a variant of the same name is replaced
+	component := []byte("\x00asm\x0d\x00\x01\x00")
+	_, err = store.AddVariant("ns", "fn", "built1234567890", "wasi-p2", component)
+	require.NoError(t, err)
+	version, err = store.AddVariant("ns", "fn", "^1.0", "debug", append(module, 0x00, 0x00))
+	require.NoError(t, err)
+	require.Len(t, version.Variants, 2)
+	assert.Equal(t, int64(10), version.Variants[0].Size)
+	assert.True(t, version.Variants[1].Component)
+
+	metadata, err := setup.registry.Get("ns", "fn")
+	require.NoError(t, err)
+	assert.Len(t, metadata.Versions[0].Variants, 2)
+
+	_, err = store.AddVariant("ns", "fn", "v1.2.0", registry.DefaultVariant, module)
+	require.Error(t, err)
+	_, err = store.AddVariant("ns", "fn", "v1.2.0", "Debug Build", module)
+	require.Error(t, err)
+	_, err = store.AddVariant("ns", "fn", "v2.0.0", "debug", module)
+	require.ErrorIs(t, err, registry.ErrInvalidReference)
+	_, _, err = store.PullVariant("ns", "fn", "built1234567890", "release")
+	require.ErrorIs(t, err, registry.ErrVariantNotFound)
+	_, _, err = store.PullVariant("ns", "fn", "unknown123456", "debug")
+	require.ErrorIs(t, err, registry.ErrDigestNotFound)
+}
+
+func TestMigrate(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+
+	// A record written before versions carried a short hash or tag list
+	legacy := `{"namespace":"ns","name":"fn","versions":[{"full_digest":"abcdef0123456789abcdef","tags":null}]}`
+	require.NoError(t, setup.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(buildFunctionKey("ns", "fn"), []byte(legacy))
+	}))
+
+	migrator := setup.registry.(registry.Migrator)
+
+	report, err := migrator.Migrate(true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.FromVersion)
+	require.Len(t, report.Steps, 1)
+	assert.Equal(t, 1, report.Steps[0].Records)
+	assert.Empty(t, report.BackupPath)
+
+	report, err = migrator.Migrate(false)
+	require.NoError(t, err)
+	assert.FileExists(t, report.BackupPath)
+
+	metadata, err := setup.registry.Get("ns", "fn")
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef012345", metadata.Versions[0].Hash)
+	assert.NotNil(t, metadata.Versions[0].Tags)
+
+	report, err = migrator.Migrate(false)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, report.FromVersion)
+	assert.Empty(t, report.Steps)
+
+	// Databases written by a newer release are refused
+	require.NoError(t, setup.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(schemaVersionKey, []byte("99"))
+	}))
+	_, err = migrator.Migrate(false)
+	assert.ErrorIs(t, err, registry.ErrSchemaTooNew)
+}
@@ -0,0 +1,102 @@
+package localregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// AttachSBOM stores the SBOM of a stored version. SBOMs are kept apart from the
+// function metadata, so listing functions does not read them.
+func (r *localRegistry) AttachSBOM(namespace, name, digest string, sbom *registry.SBOM) error {
+	shortDigest := registry.TruncateDigest(digest, 12)
+	return r.updateFunction(func(txn *badger.Txn) error {
+		var metadata *registry.FunctionMetadata
+		if err := r.getFunctionMetadata(txn, namespace, name, &metadata); err != nil {
+			return err
+		}
+		if !r.versionExists(metadata, shortDigest) {
+			return registry.ErrDigestNotFound
+		}
+
+		val, err := json.Marshal(sbom)
+		if err != nil {
+			return fmt.Errorf("failed to marshal SBOM: %w", err)
+		}
+		key := buildSBOMKey(namespace, name, shortDigest)
+		sealed, err := r.cipher.seal(val, string(key))
+		if err != nil {
+			return err
+		}
+		if err := txn.Set(key, sealed); err != nil {
+			return fmt.Errorf("failed to write SBOM: %w", err)
+		}
+		return nil
+	})
+}
+
+// SBOM returns the SBOM of the version a digest, tag or semver range resolves to.
+func (r *localRegistry) SBOM(namespace, name, reference string) (*registry.SBOM, error) {
+	var sbom *registry.SBOM
+
+	err := r.withReadTx(func(txn *badger.Txn) error {
+		var metadata *registry.FunctionMetadata
+		if err := r.getFunctionMetadata(txn, namespace, name, &metadata); err != nil {
+			return err
+		}
+		version := resolveReference(metadata, reference)
+		if version == nil {
+			return fmt.Errorf("%w: %s", registry.ErrInvalidReference, reference)
+		}
+
+		key := buildSBOMKey(namespace, name, version.Hash)
+		item, err := txn.Get(key)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return registry.ErrSBOMNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		return item.Value(func(val []byte) error {
+			plaintext, err := r.cipher.open(val, string(key))
+			if err != nil {
+				return fmt.Errorf("failed to read SBOM %s: %w", key, err)
+			}
+			sbom = &registry.SBOM{}
+			return json.Unmarshal(plaintext, sbom)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sbom, nil
+}
+
+// resolveReference returns the version of a digest, else of a tag, else the highest
+// version whose tag satisfies a semver range, like a pull.
+func resolveReference(metadata *registry.FunctionMetadata, reference string) *registry.VersionInfo {
+	shortDigest := registry.TruncateDigest(reference, 12)
+	for i, v := range metadata.Versions {
+		if v.Hash == shortDigest {
+			return &metadata.Versions[i]
+		}
+	}
+	for i, v := range metadata.Versions {
+		if registry.HasTag(v.Tags, reference) {
+			return &metadata.Versions[i]
+		}
+	}
+	if versionRange, err := registry.ParseVersionRange(reference); err == nil {
+		match, _, _ := registry.ResolveVersionRange(metadata.Versions, versionRange)
+		return match
+	}
+	return nil
+}
+
+// buildSBOMKey creates the database key of the SBOM of a version.
+func buildSBOMKey(namespace, name, shortDigest string) []byte {
+	return []byte(fmt.Sprintf("sbom:%s/%s@%s", namespace, name, shortDigest))
+}
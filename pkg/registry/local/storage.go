@@ -2,8 +2,10 @@ package localregistry
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ignitionstack/ignition/pkg/registry"
 )
@@ -27,6 +29,34 @@ func (s *localStorage) ReadWASMFile(path string) ([]byte, error) {
 	return data, nil
 }
 
+// MapWASMFile maps a WASM file into memory instead of reading it. Calling release
+// unmaps it, after which the bytes must not be used.
+func (s *localStorage) MapWASMFile(path string) ([]byte, func(), error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("WASM file not found: %w", registry.ErrFunctionNotFound)
+		}
+		return nil, nil, fmt.Errorf("failed to read WASM file: %w", err)
+	}
+	// The mapping outlives the file descriptor
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read WASM file: %w", err)
+	}
+	if info.Size() == 0 {
+		return []byte{}, func() {}, nil
+	}
+
+	data, release, err := mapFile(file, int(info.Size()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to map WASM file: %w", err)
+	}
+	return data, release, nil
+}
+
 func (s *localStorage) WriteWASMFile(path string, data []byte) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return fmt.Errorf("failed to create directories: %w", err)
@@ -40,3 +70,27 @@ func (s *localStorage) WriteWASMFile(path string, data []byte) error {
 func (s *localStorage) BuildWASMPath(namespace, name, shortDigest string) string {
 	return filepath.Join(s.rootDir, "storage", namespace, name, "versions", shortDigest+".wasm")
 }
+
+// ListWASMFiles returns the paths of every WASM file in storage.
+func (s *localStorage) ListWASMFiles() ([]string, error) {
+	var paths []string
+
+	storageDir := filepath.Join(s.rootDir, "storage")
+	err := filepath.WalkDir(storageDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == storageDir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".wasm") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list WASM files: %w", err)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,58 @@
+package localregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/validation"
+)
+
+// WithUpstream makes pulls of versions missing locally fall back to upstream. Fetched
+// versions are stored like pushed ones, with the tag they were pulled by, so later
+// pulls are served locally.
+func WithUpstream(upstream registry.Upstream) Option {
+	return func(r *localRegistry) {
+		r.upstream = upstream
+	}
+}
+
+// isMiss reports whether a pull failed because the version is not stored locally.
+func isMiss(err error) bool {
+	return errors.Is(err, registry.ErrFunctionNotFound) ||
+		errors.Is(err, registry.ErrInvalidReference) ||
+		errors.Is(err, registry.ErrTagNotFound) ||
+		errors.Is(err, registry.ErrDigestNotFound) ||
+		errors.Is(err, registry.ErrVersionNotFound)
+}
+
+// pullThrough fetches a version from the upstream registry and caches it. When the
+// upstream does not have it either, the local miss is returned.
+func (r *localRegistry) pullThrough(namespace, name, reference string, missErr error, read readFunc) ([]byte, *registry.VersionInfo, error) {
+	payload, version, err := r.upstream.Pull(context.Background(), namespace, name, reference)
+	if errors.Is(err, registry.ErrVersionNotFound) {
+		return nil, nil, missErr
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w (upstream registry: %v)", missErr, err)
+	}
+
+	// A reference that is not the digest of the version is the tag it was pulled by
+	shortDigest := registry.TruncateDigest(version.FullDigest, 12)
+	tag := ""
+	if reference != shortDigest && reference != version.FullDigest && validation.ValidateTag(reference) == nil {
+		tag = reference
+	}
+
+	// Cached versions go through the same admission and validation as pushed ones
+	if err := r.Push(namespace, name, payload, version.FullDigest, tag, version.Settings); err != nil {
+		return nil, nil, fmt.Errorf("failed to cache version from upstream registry: %w", err)
+	}
+	if version.Provenance != nil {
+		if err := r.RecordProvenance(namespace, name, version.FullDigest, *version.Provenance); err != nil {
+			return nil, nil, fmt.Errorf("failed to cache version from upstream registry: %w", err)
+		}
+	}
+	return r.pullByDigest(namespace, name, shortDigest, read)
+}
@@ -0,0 +1,66 @@
+package localregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUpstream serves one version of ns/fn under the tag "latest" and its digest.
+type fakeUpstream struct {
+	pulls int
+	err   error
+}
+
+func (u *fakeUpstream) Pull(_ context.Context, namespace, name, reference string) ([]byte, *registry.VersionInfo, error) {
+	u.pulls++
+	if u.err != nil {
+		return nil, nil, u.err
+	}
+	if namespace != "ns" || name != "fn" || (reference != "latest" && reference != "upstream1234") {
+		return nil, nil, fmt.Errorf("%w: %s", registry.ErrVersionNotFound, reference)
+	}
+	return []byte("upstream wasm"), &registry.VersionInfo{FullDigest: "upstream1234567", Settings: defaultSettings}, nil
+}
+
+func TestPullThroughUpstream(t *testing.T) {
+	setup := setupTestRegistry(t)
+	defer setup.cleanup()
+
+	upstream := &fakeUpstream{}
+	reg := NewLocalRegistry(setup.tmpDir, setup.registry.(*localRegistry).dbRepo, WithUpstream(upstream))
+
+	payload, version, err := reg.Pull("ns", "fn", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("upstream wasm"), payload)
+	assert.Equal(t, "upstream1234567", version.FullDigest)
+	assert.Equal(t, []string{"latest"}, version.Tags)
+
+	// The version and its tag are cached, so later pulls stay local
+	_, _, err = reg.Pull("ns", "fn", "latest")
+	require.NoError(t, err)
+	_, _, err = reg.Pull("ns", "fn", "upstream1234")
+	require.NoError(t, err)
+	assert.Equal(t, 1, upstream.pulls)
+
+	metadata, err := setup.registry.Get("ns", "fn")
+	require.NoError(t, err)
+	assert.Len(t, metadata.Versions, 1)
+
+	// Misses upstream too keep the local error
+	_, _, err = reg.Pull("ns", "other", "latest")
+	assert.ErrorIs(t, err, registry.ErrFunctionNotFound)
+	_, _, err = reg.Pull("ns", "fn", "v2")
+	assert.ErrorIs(t, err, registry.ErrTagNotFound)
+	assert.Equal(t, 3, upstream.pulls)
+
+	upstream.err = errors.New("connection refused")
+	_, _, err = reg.Pull("ns", "unreachable", "latest")
+	assert.ErrorIs(t, err, registry.ErrFunctionNotFound)
+	assert.ErrorContains(t, err, "connection refused")
+}
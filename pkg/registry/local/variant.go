@@ -0,0 +1,110 @@
+package localregistry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// AddVariant stores a variant of a version next to its module. Core modules are
+// validated like pushed versions when module validation is enabled; components are
+// only checked against the size limit.
+func (r *localRegistry) AddVariant(namespace, name, reference, variant string, payload []byte) (*registry.VersionInfo, error) {
+	if err := registry.ValidateVariantName(variant); err != nil {
+		return nil, err
+	}
+
+	var updated *registry.VersionInfo
+	err := r.updateFunction(func(txn *badger.Txn) error {
+		var metadata *registry.FunctionMetadata
+		if err := r.getFunctionMetadata(txn, namespace, name, &metadata); err != nil {
+			return err
+		}
+		version := resolveReference(metadata, reference)
+		if version == nil {
+			return fmt.Errorf("%w: %s", registry.ErrInvalidReference, reference)
+		}
+
+		stored := registry.Variant{
+			Name:      variant,
+			Size:      int64(len(payload)),
+			Checksum:  registry.Checksum(payload),
+			CreatedAt: time.Now(),
+			Component: registry.IsComponent(payload),
+		}
+		switch {
+		case stored.Component:
+			if r.maxModuleSize > 0 && stored.Size > r.maxModuleSize {
+				return fmt.Errorf("%w: %d bytes (limit %d bytes)", registry.ErrModuleTooLarge, stored.Size, r.maxModuleSize)
+			}
+		case r.validateModules:
+			info, err := registry.ValidateModule(payload, version.Settings, r.maxModuleSize)
+			if err != nil {
+				return fmt.Errorf("module validation failed for variant %s of %s/%s: %w", variant, namespace, name, err)
+			}
+			stored.Module = info
+		default:
+			stored.Module, _ = registry.InspectModule(payload)
+		}
+
+		if err := r.storage.WriteWASMFile(r.variantPath(namespace, name, version.Hash, variant), payload); err != nil {
+			return fmt.Errorf("failed to write variant: %w", err)
+		}
+		if existing, ok := version.FindVariant(variant); ok {
+			*existing = stored
+		} else {
+			version.Variants = append(version.Variants, stored)
+		}
+
+		versionCopy := *version
+		updated = &versionCopy
+		return r.updateMetadata(txn, namespace, name, metadata)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// PullVariant reads a variant of the version with a digest.
+func (r *localRegistry) PullVariant(namespace, name, digest, variant string) ([]byte, *registry.Variant, error) {
+	shortDigest := registry.TruncateDigest(digest, 12)
+
+	var found *registry.Variant
+	err := r.withReadTx(func(txn *badger.Txn) error {
+		var metadata *registry.FunctionMetadata
+		if err := r.getFunctionMetadata(txn, namespace, name, &metadata); err != nil {
+			return err
+		}
+		for i := range metadata.Versions {
+			if metadata.Versions[i].Hash != shortDigest {
+				continue
+			}
+			variantInfo, ok := metadata.Versions[i].FindVariant(variant)
+			if !ok {
+				return fmt.Errorf("%w: %s of %s/%s@%s", registry.ErrVariantNotFound, variant, namespace, name, shortDigest)
+			}
+			found = variantInfo
+			return nil
+		}
+		return registry.ErrDigestNotFound
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload, err := r.storage.ReadWASMFile(r.variantPath(namespace, name, shortDigest, variant))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read variant: %w", err)
+	}
+	return payload, found, nil
+}
+
+// variantPath returns the path of a variant, next to the module of its version.
+func (r *localRegistry) variantPath(namespace, name, shortDigest, variant string) string {
+	path := r.storage.BuildWASMPath(namespace, name, shortDigest)
+	return strings.TrimSuffix(path, ".wasm") + "." + variant + ".wasm"
+}
@@ -0,0 +1,68 @@
+package registry
+
+import "time"
+
+// MaintenanceReport describes the outcome of a registry maintenance run.
+type MaintenanceReport struct {
+	StartedAt    time.Time `json:"started_at"`
+	Duration     string    `json:"duration"`
+	GCRewrites   int       `json:"gc_rewrites"`
+	LSMSize      int64     `json:"lsm_size"`
+	VLogSize     int64     `json:"vlog_size"`
+	OrphanFiles  []string  `json:"orphan_files"`
+	MissingFiles []string  `json:"missing_files"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Maintainer is implemented by registries that support periodic compaction and
+// consistency checks.
+type Maintainer interface {
+	Maintain() (*MaintenanceReport, error)
+}
+
+// MigrationReport describes the schema migrations applied to a registry, or the
+// ones that would be applied on a dry run.
+type MigrationReport struct {
+	FromVersion int             `json:"from_version"`
+	ToVersion   int             `json:"to_version"`
+	DryRun      bool            `json:"dry_run"`
+	Steps       []MigrationStep `json:"steps"`
+	BackupPath  string          `json:"backup_path,omitempty"`
+}
+
+// MigrationStep is a single schema migration and the number of records it changed.
+type MigrationStep struct {
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	Records     int    `json:"records"`
+}
+
+// Migrator is implemented by registries with a versioned storage schema.
+type Migrator interface {
+	Migrate(dryRun bool) (*MigrationReport, error)
+}
+
+// IntegrityReport describes the outcome of a registry integrity check. Problems are
+// listed as namespace/name@hash.
+type IntegrityReport struct {
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+	Mode      string    `json:"mode"`
+	Versions  int       `json:"versions"`
+	Verified  int       `json:"verified"`
+	Missing   []string  `json:"missing"`
+	Corrupt   []string  `json:"corrupt"`
+	Repaired  []string  `json:"repaired"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Unrepaired returns the number of missing or corrupt versions that were not repaired.
+func (r *IntegrityReport) Unrepaired() int {
+	return len(r.Missing) + len(r.Corrupt) - len(r.Repaired)
+}
+
+// IntegrityChecker is implemented by registries that can verify stored modules against
+// their version metadata. A sample of zero verifies the contents of every version.
+type IntegrityChecker interface {
+	CheckIntegrity(sample int) (*IntegrityReport, error)
+}
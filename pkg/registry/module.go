@@ -0,0 +1,329 @@
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ignitionstack/ignition/pkg/manifest"
+)
+
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+const (
+	wasiModulePrefix     = "wasi_"
+	extismHostUserModule = "extism:host/user"
+)
+
+// Section and external kind identifiers from the WebAssembly binary format.
+const (
+	sectionImport = 2
+	sectionMemory = 5
+	sectionExport = 7
+
+	externFunc   = 0
+	externTable  = 1
+	externMemory = 2
+	externGlobal = 3
+	externTag    = 4
+)
+
+// ModuleInfo describes the imports and exports of a wasm module.
+type ModuleInfo struct {
+	Entrypoints   []string `json:"entrypoints"`
+	RequiresWasi  bool     `json:"requires_wasi"`
+	HostFunctions []string `json:"host_functions,omitempty"`
+
+	// Initial and maximum size of the module's memory in 64 KiB pages; MaxMemoryPages
+	// is 0 when the module declares no maximum
+	MinMemoryPages uint64 `json:"min_memory_pages,omitempty"`
+	MaxMemoryPages uint64 `json:"max_memory_pages,omitempty"`
+}
+
+// MemoryPageSize is the size in bytes of a wasm memory page.
+const MemoryPageSize = 64 << 10
+
+// ValidateModule inspects a wasm module and checks it against the size limit and
+// version settings. A maxSize of zero or less disables the size check.
+func ValidateModule(payload []byte, settings manifest.FunctionVersionSettings, maxSize int64) (*ModuleInfo, error) {
+	if maxSize > 0 && int64(len(payload)) > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes (limit %d bytes)", ErrModuleTooLarge, len(payload), maxSize)
+	}
+
+	info, err := InspectModule(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(info.Entrypoints) == 0 {
+		return nil, ErrNoEntrypoints
+	}
+
+	if info.RequiresWasi && !settings.Wasi {
+		return nil, ErrWasiNotEnabled
+	}
+
+	return info, nil
+}
+
+// InspectModule parses the import and export sections of a wasm binary.
+func InspectModule(payload []byte) (*ModuleInfo, error) {
+	if len(payload) < 8 || !bytes.Equal(payload[:4], wasmMagic) {
+		return nil, fmt.Errorf("%w: missing wasm header", ErrInvalidModule)
+	}
+
+	r := &wasmReader{data: payload, pos: 8}
+	info := &ModuleInfo{Entrypoints: []string{}}
+
+	for !r.done() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+
+		section := &wasmReader{data: body}
+		switch id {
+		case sectionImport:
+			if err := readImports(section, info); err != nil {
+				return nil, err
+			}
+		case sectionMemory:
+			if err := readMemories(section, info); err != nil {
+				return nil, err
+			}
+		case sectionExport:
+			if err := readExports(section, info); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Strings(info.Entrypoints)
+	sort.Strings(info.HostFunctions)
+	return info, nil
+}
+
+func readImports(r *wasmReader, info *ModuleInfo) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+
+	for i := uint32(0); i < count; i++ {
+		module, err := r.name()
+		if err != nil {
+			return err
+		}
+		field, err := r.name()
+		if err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if kind == externMemory {
+			err = info.readMemory(r)
+		} else {
+			err = r.skipImportDesc(kind)
+		}
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(module, wasiModulePrefix) {
+			info.RequiresWasi = true
+		}
+		if kind == externFunc && module == extismHostUserModule {
+			info.HostFunctions = append(info.HostFunctions, field)
+		}
+	}
+
+	return nil
+}
+
+func readMemories(r *wasmReader, info *ModuleInfo) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+
+	for i := uint32(0); i < count; i++ {
+		if err := info.readMemory(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readMemory reads the limits of a defined or imported memory, keeping the largest.
+func (info *ModuleInfo) readMemory(r *wasmReader) error {
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	minPages, err := r.u64()
+	if err != nil {
+		return err
+	}
+	info.MinMemoryPages = max(info.MinMemoryPages, minPages)
+	if flags&0x01 != 0 {
+		maxPages, err := r.u64()
+		if err != nil {
+			return err
+		}
+		info.MaxMemoryPages = max(info.MaxMemoryPages, maxPages)
+	}
+	return nil
+}
+
+func readExports(r *wasmReader, info *ModuleInfo) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+
+	for i := uint32(0); i < count; i++ {
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if _, err := r.u32(); err != nil {
+			return err
+		}
+
+		// WASI lifecycle exports are not callable entrypoints
+		if kind == externFunc && name != "_start" && name != "_initialize" {
+			info.Entrypoints = append(info.Entrypoints, name)
+		}
+	}
+
+	return nil
+}
+
+// wasmReader is a minimal cursor over wasm binary data.
+type wasmReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *wasmReader) done() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *wasmReader) byte() (byte, error) {
+	if r.done() {
+		return 0, fmt.Errorf("%w: unexpected end of data", ErrInvalidModule)
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *wasmReader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidModule)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// u32 reads an unsigned LEB128 encoded 32-bit integer.
+func (r *wasmReader) u32() (uint32, error) {
+	var result uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: malformed integer", ErrInvalidModule)
+}
+
+// u64 reads an unsigned LEB128 encoded 64-bit integer.
+func (r *wasmReader) u64() (uint64, error) {
+	var result uint64
+	for shift := uint(0); shift < 70; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: malformed integer", ErrInvalidModule)
+}
+
+func (r *wasmReader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(int(n))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (r *wasmReader) skipLimits() error {
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if _, err := r.u64(); err != nil {
+		return err
+	}
+	if flags&0x01 != 0 {
+		if _, err := r.u64(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *wasmReader) skipImportDesc(kind byte) error {
+	switch kind {
+	case externFunc:
+		_, err := r.u32()
+		return err
+	case externTable:
+		if _, err := r.byte(); err != nil {
+			return err
+		}
+		return r.skipLimits()
+	case externMemory:
+		return r.skipLimits()
+	case externGlobal:
+		_, err := r.bytes(2)
+		return err
+	case externTag:
+		if _, err := r.byte(); err != nil {
+			return err
+		}
+		_, err := r.u32()
+		return err
+	default:
+		return fmt.Errorf("%w: unknown import kind %d", ErrInvalidModule, kind)
+	}
+}
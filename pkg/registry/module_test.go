@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestModule assembles a wasm binary with the given import and export sections.
+func buildTestModule(imports [][2]string, exports []string) []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+	name := func(s string) []byte {
+		return append([]byte{byte(len(s))}, s...)
+	}
+	section := func(id byte, body []byte) []byte {
+		return append([]byte{id, byte(len(body))}, body...)
+	}
+
+	if len(imports) > 0 {
+		body := []byte{byte(len(imports))}
+		for _, imp := range imports {
+			body = append(body, name(imp[0])...)
+			body = append(body, name(imp[1])...)
+			body = append(body, externFunc, 0x00)
+		}
+		module = append(module, section(sectionImport, body)...)
+	}
+
+	if len(exports) > 0 {
+		body := []byte{byte(len(exports))}
+		for i, exp := range exports {
+			body = append(body, name(exp)...)
+			body = append(body, externFunc, byte(i))
+		}
+		module = append(module, section(sectionExport, body)...)
+	}
+
+	return module
+}
+
+func TestInspectModule(t *testing.T) {
+	payload := buildTestModule(
+		[][2]string{
+			{"wasi_snapshot_preview1", "fd_write"},
+			{"extism:host/user", "ignition_call_service"},
+		},
+		[]string{"_start", "greet", "handle"},
+	)
+
+	info, err := InspectModule(payload)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"greet", "handle"}, info.Entrypoints)
+	assert.True(t, info.RequiresWasi)
+	assert.Equal(t, []string{"ignition_call_service"}, info.HostFunctions)
+}
+
+func TestInspectModuleMemory(t *testing.T) {
+	payload := buildTestModule(nil, []string{"handle"})
+	// One memory of 17 pages, growing up to 256
+	payload = append(payload, sectionMemory, 0x05, 0x01, 0x01, 0x11, 0x80, 0x02)
+
+	info, err := InspectModule(payload)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(17), info.MinMemoryPages)
+	assert.Equal(t, uint64(256), info.MaxMemoryPages)
+}
+
+func TestValidateModule(t *testing.T) {
+	wasi := manifest.FunctionVersionSettings{Wasi: true}
+	noWasi := manifest.FunctionVersionSettings{Wasi: false}
+
+	tests := []struct {
+		name     string
+		payload  []byte
+		settings manifest.FunctionVersionSettings
+		maxSize  int64
+		wantErr  error
+	}{
+		{
+			name:     "valid module",
+			payload:  buildTestModule(nil, []string{"greet"}),
+			settings: wasi,
+		},
+		{
+			name:     "not a wasm module",
+			payload:  []byte("test wasm"),
+			settings: wasi,
+			wantErr:  ErrInvalidModule,
+		},
+		{
+			name:     "truncated section",
+			payload:  buildTestModule(nil, []string{"greet"})[:12],
+			settings: wasi,
+			wantErr:  ErrInvalidModule,
+		},
+		{
+			name:     "too large",
+			payload:  buildTestModule(nil, []string{"greet"}),
+			settings: wasi,
+			maxSize:  10,
+			wantErr:  ErrModuleTooLarge,
+		},
+		{
+			name:     "no entrypoints",
+			payload:  buildTestModule(nil, []string{"_start"}),
+			settings: wasi,
+			wantErr:  ErrNoEntrypoints,
+		},
+		{
+			name:     "wasi import without wasi enabled",
+			payload:  buildTestModule([][2]string{{"wasi_snapshot_preview1", "fd_write"}}, []string{"greet"}),
+			settings: noWasi,
+			wantErr:  ErrWasiNotEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateModule(tt.payload, tt.settings, tt.maxSize)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
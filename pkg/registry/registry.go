@@ -1,6 +1,10 @@
 package registry
 
-import "github.com/ignitionstack/ignition/pkg/manifest"
+import (
+	"context"
+
+	"github.com/ignitionstack/ignition/pkg/manifest"
+)
 
 type Registry interface {
 	Get(namespace, name string) (*FunctionMetadata, error)
@@ -10,3 +14,26 @@ type Registry interface {
 	DigestExists(namespace, name, digest string) (bool, error)
 	ListAll() ([]FunctionMetadata, error)
 }
+
+// ModuleMapper is implemented by registries that can map modules from storage into
+// memory instead of reading them, so their pages are only resident while in use.
+type ModuleMapper interface {
+	// PullMapped works like Pull. The module stays mapped until release is called,
+	// after which its bytes must not be used.
+	PullMapped(namespace, name, reference string) (wasm []byte, info *VersionInfo, release func(), err error)
+}
+
+// ProvenanceRecorder is implemented by registries that record how versions were built.
+type ProvenanceRecorder interface {
+	// RecordProvenance attaches provenance to a stored version. A version keeps the
+	// provenance of the build that stored it, so later builds of the same digest
+	// change nothing.
+	RecordProvenance(namespace, name, digest string, provenance Provenance) error
+}
+
+// Upstream is a registry that pulls fall back to when a version is not stored locally.
+// Pull fails with an error wrapping ErrVersionNotFound when the upstream does not have
+// the version either.
+type Upstream interface {
+	Pull(ctx context.Context, namespace, name, reference string) ([]byte, *VersionInfo, error)
+}
@@ -0,0 +1,303 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Media types understood when resolving OCI references
+const (
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// wasmLayerTypes are the layer media types used by common wasm OCI tooling
+var wasmLayerTypes = map[string]bool{
+	"application/wasm":                                  true,
+	"application/vnd.wasm.content.layer.v1+wasm":        true,
+	"application/vnd.module.wasm.content.layer.v1+wasm": true,
+}
+
+// maxManifestSize caps manifest and token responses
+const maxManifestSize = 4 << 20
+
+// ociReference is a parsed registry/repository[:tag][@digest] reference
+type ociReference struct {
+	registry   string
+	repository string
+	reference  string // tag or digest
+}
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+	Platform  *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform,omitempty"`
+}
+
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// parseOCIReference parses a reference without its oci:// scheme
+func parseOCIReference(ref string) (*ociReference, error) {
+	registry, rest, ok := strings.Cut(ref, "/")
+	if !ok || registry == "" || rest == "" {
+		return nil, fmt.Errorf("%w: expected oci://registry/repository[:tag|@digest], got oci://%s", ErrUnsupportedReference, ref)
+	}
+
+	parsed := &ociReference{registry: registry, repository: rest, reference: "latest"}
+	if repo, digest, ok := strings.Cut(rest, "@"); ok {
+		if err := ValidateDigest(digest); err != nil {
+			return nil, err
+		}
+		parsed.repository, parsed.reference = repo, digest
+	} else if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		parsed.repository, parsed.reference = rest[:i], rest[i+1:]
+	}
+
+	if parsed.repository == "" || parsed.reference == "" {
+		return nil, fmt.Errorf("%w: oci://%s", ErrUnsupportedReference, ref)
+	}
+	return parsed, nil
+}
+
+func (f *Fetcher) fetchOCI(ctx context.Context, ref string) (*Artifact, error) {
+	parsed, err := parseOCIReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &ociSession{fetcher: f, ref: parsed}
+
+	m, err := session.manifest(ctx, parsed.reference)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve an index to the wasm manifest it lists
+	if m.MediaType == mediaTypeOCIIndex || m.MediaType == mediaTypeDockerList || len(m.Manifests) > 0 {
+		child, err := selectWasmManifest(m.Manifests)
+		if err != nil {
+			return nil, fmt.Errorf("oci://%s: %w", ref, err)
+		}
+		if m, err = session.manifest(ctx, child.Digest); err != nil {
+			return nil, err
+		}
+	}
+
+	layer, err := selectWasmLayer(m.Layers)
+	if err != nil {
+		return nil, fmt.Errorf("oci://%s: %w", ref, err)
+	}
+	if f.maxSize > 0 && layer.Size > f.maxSize {
+		return nil, fmt.Errorf("%w: %d bytes (limit %d bytes)", ErrTooLarge, layer.Size, f.maxSize)
+	}
+
+	payload, err := session.blob(ctx, layer.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return &Artifact{Payload: payload, Digest: layer.Digest}, nil
+}
+
+func selectWasmManifest(manifests []descriptor) (*descriptor, error) {
+	for i, m := range manifests {
+		if m.Platform != nil && (m.Platform.Architecture == "wasm" || strings.HasPrefix(m.Platform.OS, "wasi")) {
+			return &manifests[i], nil
+		}
+	}
+	if len(manifests) == 1 {
+		return &manifests[0], nil
+	}
+	return nil, fmt.Errorf("index does not list a wasm manifest")
+}
+
+func selectWasmLayer(layers []descriptor) (*descriptor, error) {
+	for i, layer := range layers {
+		if wasmLayerTypes[layer.MediaType] {
+			return &layers[i], nil
+		}
+	}
+	if len(layers) == 1 {
+		return &layers[0], nil
+	}
+	return nil, fmt.Errorf("manifest has no wasm layer")
+}
+
+// ociSession talks to one repository, reusing the bearer token it obtained
+type ociSession struct {
+	fetcher *Fetcher
+	ref     *ociReference
+	token   string
+}
+
+func (s *ociSession) manifest(ctx context.Context, reference string) (*manifest, error) {
+	accept := strings.Join([]string{mediaTypeOCIManifest, mediaTypeOCIIndex, mediaTypeDockerManifest, mediaTypeDockerList}, ", ")
+	body, err := s.get(ctx, "manifests/"+reference, accept, maxManifestSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Manifests fetched by digest are content addressed, so check them too
+	if strings.HasPrefix(reference, "sha256:") && Digest(body) != reference {
+		return nil, fmt.Errorf("%w: manifest %s", ErrDigestMismatch, reference)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest for %s: %w", reference, err)
+	}
+	return &m, nil
+}
+
+func (s *ociSession) blob(ctx context.Context, digest string) ([]byte, error) {
+	if err := ValidateDigest(digest); err != nil {
+		return nil, err
+	}
+
+	payload, err := s.get(ctx, "blobs/"+digest, "", s.fetcher.maxSize)
+	if err != nil {
+		return nil, err
+	}
+	if actual := Digest(payload); actual != digest {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, digest, actual)
+	}
+	return payload, nil
+}
+
+// get requests a registry path, obtaining an anonymous bearer token when challenged
+func (s *ociSession) get(ctx context.Context, path, accept string, limit int64) ([]byte, error) {
+	target := fmt.Sprintf("https://%s/v2/%s/%s", s.ref.registry, s.ref.repository, path)
+
+	resp, err := s.do(ctx, target, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && s.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if s.token, err = s.authenticate(ctx, challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = s.do(ctx, target, accept); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, target)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", target, resp.Status)
+	}
+
+	limited := &Fetcher{maxSize: limit}
+	return limited.readLimited(resp.Body)
+}
+
+func (s *ociSession) do(ctx context.Context, target, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.fetcher.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", target, err)
+	}
+	return resp, nil
+}
+
+// authenticate answers a Bearer challenge with an anonymous pull token
+func (s *ociSession) authenticate(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("registry %s requires unsupported authentication %q", s.ref.registry, scheme)
+	}
+
+	values := parseChallenge(params)
+	realm := values["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry %s sent a bearer challenge without a realm", s.ref.registry)
+	}
+
+	query := url.Values{}
+	if service := values["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := values["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", s.ref.repository)
+	}
+	query.Set("scope", scope)
+
+	resp, err := s.do(ctx, realm+"?"+query.Encode(), "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to obtain a pull token from %s: %s", realm, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&token); err != nil {
+		return "", fmt.Errorf("invalid token response from %s: %w", realm, err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+	return "", fmt.Errorf("token response from %s holds no token", realm)
+}
+
+// parseChallenge parses the comma separated key="value" pairs of a WWW-Authenticate header
+func parseChallenge(params string) map[string]string {
+	values := map[string]string{}
+	for params != "" {
+		var pair string
+		// Values are quoted and may contain commas (e.g. multiple scopes)
+		key, rest, ok := strings.Cut(strings.TrimLeft(params, " ,"), "=")
+		if !ok {
+			break
+		}
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			pair, params = rest[1:end+1], rest[end+2:]
+		} else {
+			pair, params, _ = strings.Cut(rest, ",")
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = pair
+	}
+	return values
+}
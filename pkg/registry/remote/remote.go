@@ -0,0 +1,155 @@
+// Package remote fetches prebuilt wasm modules from HTTPS URLs and OCI registries.
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Supported reference schemes
+const (
+	SchemeHTTPS = "https://"
+	SchemeOCI   = "oci://"
+)
+
+var (
+	// ErrUnsupportedReference is returned for references that are neither HTTPS URLs nor OCI references
+	ErrUnsupportedReference = errors.New("unsupported remote reference")
+
+	// ErrDigestRequired is returned when fetching an HTTPS URL without an expected digest
+	ErrDigestRequired = errors.New("a sha256 digest is required to verify modules fetched over HTTPS")
+
+	// ErrDigestMismatch is returned when fetched content does not match its expected digest
+	ErrDigestMismatch = errors.New("digest mismatch")
+
+	// ErrTooLarge is returned when a module exceeds the size limit
+	ErrTooLarge = errors.New("remote module exceeds the size limit")
+
+	// ErrNotFound is returned when an OCI registry has no manifest or blob for a reference
+	ErrNotFound = errors.New("remote module not found")
+)
+
+// Artifact is a fetched and verified wasm module
+type Artifact struct {
+	Payload []byte
+	Digest  string // sha256:<hex> of the payload
+}
+
+// IsRemoteReference reports whether ref points at an external artifact rather than a registry tag or digest.
+func IsRemoteReference(ref string) bool {
+	return strings.HasPrefix(ref, SchemeHTTPS) || strings.HasPrefix(ref, SchemeOCI)
+}
+
+// Fetcher downloads modules and verifies them by digest
+type Fetcher struct {
+	client  *http.Client
+	maxSize int64
+}
+
+// NewFetcher creates a fetcher. A nil client uses a client with a one minute timeout;
+// a maxSize of zero or less disables the size limit.
+func NewFetcher(client *http.Client, maxSize int64) *Fetcher {
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute}
+	}
+	return &Fetcher{client: client, maxSize: maxSize}
+}
+
+// Fetch downloads the module behind ref. HTTPS URLs must come with an expected digest;
+// OCI blobs are always checked against the digest in their manifest and, when given,
+// against the expected digest too.
+func (f *Fetcher) Fetch(ctx context.Context, ref, expectedDigest string) (*Artifact, error) {
+	if expectedDigest != "" {
+		if err := ValidateDigest(expectedDigest); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		artifact *Artifact
+		err      error
+	)
+	switch {
+	case strings.HasPrefix(ref, SchemeHTTPS):
+		if expectedDigest == "" {
+			return nil, ErrDigestRequired
+		}
+		artifact, err = f.fetchURL(ctx, ref)
+	case strings.HasPrefix(ref, SchemeOCI):
+		artifact, err = f.fetchOCI(ctx, strings.TrimPrefix(ref, SchemeOCI))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedReference, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedDigest != "" && artifact.Digest != expectedDigest {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, expectedDigest, artifact.Digest)
+	}
+	return artifact, nil
+}
+
+func (f *Fetcher) fetchURL(ctx context.Context, url string) (*Artifact, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %s: %w", url, err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	payload, err := f.readLimited(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Artifact{Payload: payload, Digest: Digest(payload)}, nil
+}
+
+// readLimited reads a body, failing once it exceeds the size limit
+func (f *Fetcher) readLimited(body io.Reader) ([]byte, error) {
+	if f.maxSize <= 0 {
+		return io.ReadAll(body)
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(body, f.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(payload)) > f.maxSize {
+		return nil, fmt.Errorf("%w (limit %d bytes)", ErrTooLarge, f.maxSize)
+	}
+	return payload, nil
+}
+
+// Digest returns the sha256:<hex> digest of payload
+func Digest(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ValidateDigest checks that digest has the sha256:<64 hex chars> form
+func ValidateDigest(digest string) error {
+	hexPart, ok := strings.CutPrefix(digest, "sha256:")
+	if !ok || len(hexPart) != sha256.Size*2 {
+		return fmt.Errorf("invalid digest %q: expected sha256:<64 hex characters>", digest)
+	}
+	if _, err := hex.DecodeString(hexPart); err != nil {
+		return fmt.Errorf("invalid digest %q: %w", digest, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var module = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+func TestFetchURL(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(module)
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(server.Client(), 1024)
+	url := server.URL + "/fn.wasm"
+
+	_, err := fetcher.Fetch(context.Background(), url, "")
+	assert.ErrorIs(t, err, ErrDigestRequired)
+
+	artifact, err := fetcher.Fetch(context.Background(), url, Digest(module))
+	require.NoError(t, err)
+	assert.Equal(t, module, artifact.Payload)
+
+	_, err = fetcher.Fetch(context.Background(), url, Digest([]byte("other")))
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+
+	_, err = NewFetcher(server.Client(), 4).Fetch(context.Background(), url, Digest(module))
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestFetchOCIWithTokenAuth(t *testing.T) {
+	layerDigest := Digest(module)
+	manifestBody, err := json.Marshal(map[string]interface{}{
+		"mediaType": mediaTypeOCIManifest,
+		"layers": []map[string]interface{}{
+			{"mediaType": "application/vnd.wasm.content.layer.v1+wasm", "digest": layerDigest, "size": len(module)},
+		},
+	})
+	require.NoError(t, err)
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			assert.Equal(t, "repository:org/fn:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token":"secret"}`))
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/org/fn/manifests/v1":
+			w.Header().Set("Content-Type", mediaTypeOCIManifest)
+			_, _ = w.Write(manifestBody)
+		case r.URL.Path == "/v2/org/fn/blobs/"+layerDigest:
+			_, _ = w.Write(module)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ref := "oci://" + strings.TrimPrefix(server.URL, "https://") + "/org/fn:v1"
+	artifact, err := NewFetcher(server.Client(), 0).Fetch(context.Background(), ref, "")
+	require.NoError(t, err)
+	assert.Equal(t, module, artifact.Payload)
+	assert.Equal(t, layerDigest, artifact.Digest)
+}
+
+func TestParseOCIReference(t *testing.T) {
+	ref, err := parseOCIReference("ghcr.io/org/fn")
+	require.NoError(t, err)
+	assert.Equal(t, ociReference{registry: "ghcr.io", repository: "org/fn", reference: "latest"}, *ref)
+
+	ref, err = parseOCIReference("localhost:5000/fn:v2")
+	require.NoError(t, err)
+	assert.Equal(t, ociReference{registry: "localhost:5000", repository: "fn", reference: "v2"}, *ref)
+
+	_, err = parseOCIReference("ghcr.io/org/fn@sha256:short")
+	assert.Error(t, err)
+
+	_, err = parseOCIReference("ghcr.io")
+	assert.ErrorIs(t, err, ErrUnsupportedReference)
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+)
+
+// SBOM is a CycloneDX software bill of materials of a module, listing the packages
+// it was built from.
+type SBOM struct {
+	BOMFormat   string          `json:"bomFormat"`
+	SpecVersion string          `json:"specVersion"`
+	Version     int             `json:"version"`
+	Metadata    SBOMMetadata    `json:"metadata"`
+	Components  []SBOMComponent `json:"components"`
+}
+
+// SBOMMetadata describes the module an SBOM is about.
+type SBOMMetadata struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Component SBOMComponent `json:"component"`
+}
+
+// SBOMComponent is a package in an SBOM, identified by its package URL.
+type SBOMComponent struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+// NewSBOM returns the SBOM of the module of a version, built from components.
+func NewSBOM(namespace, name, digest string, components []SBOMComponent) *SBOM {
+	if components == nil {
+		components = []SBOMComponent{}
+	}
+	return &SBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata: SBOMMetadata{
+			Timestamp: time.Now().UTC(),
+			Component: SBOMComponent{
+				Type:    "application",
+				Name:    fmt.Sprintf("%s/%s", namespace, name),
+				Version: digest,
+			},
+		},
+		Components: components,
+	}
+}
+
+// SBOMStore is implemented by registries that keep the SBOMs of versions.
+type SBOMStore interface {
+	// AttachSBOM stores the SBOM of a stored version, replacing any previous one.
+	AttachSBOM(namespace, name, digest string, sbom *SBOM) error
+
+	// SBOM returns the SBOM of the version a digest, tag or semver range resolves to,
+	// failing with ErrSBOMNotFound when the version has none.
+	SBOM(namespace, name, reference string) (*SBOM, error)
+}
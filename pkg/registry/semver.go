@@ -0,0 +1,267 @@
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VersionRange is a semantic version range such as "~1.2", "^1.4.0", "1.x" or
+// ">=1.2.0 <2.0.0", matched against the tags of a function.
+type VersionRange struct {
+	expr        string
+	constraints []semverConstraint
+}
+
+// ParseVersionRange parses space separated terms that must all hold. A term is a
+// version with optional ~ or ^ prefix, a comparison (>, >=, <, <=, =) or a version
+// where x or * stand for any number.
+func ParseVersionRange(expr string) (VersionRange, error) {
+	constraints, err := parseSemverRange(expr)
+	if err != nil {
+		return VersionRange{}, err
+	}
+	return VersionRange{expr: strings.TrimSpace(expr), constraints: constraints}, nil
+}
+
+// String returns the range as it was written.
+func (r VersionRange) String() string {
+	return r.expr
+}
+
+// HighestMatch returns the tag with the highest version in the range. Tags that are
+// not semantic versions, and pre-releases, are skipped.
+func (r VersionRange) HighestMatch(tags []string) (string, bool) {
+	var best string
+	var bestVersion semver
+	found := false
+	for _, tag := range tags {
+		version, ok := parseSemver(tag)
+		if !ok || version.pre != "" || !r.matches(version) {
+			continue
+		}
+		if !found || version.compare(bestVersion) > 0 {
+			best, bestVersion, found = tag, version, true
+		}
+	}
+	return best, found
+}
+
+// ResolveVersionRange returns the version carrying the highest tag in the range,
+// along with that tag.
+func ResolveVersionRange(versions []VersionInfo, r VersionRange) (*VersionInfo, string, bool) {
+	var tags []string
+	for _, version := range versions {
+		tags = append(tags, version.Tags...)
+	}
+	tag, ok := r.HighestMatch(tags)
+	if !ok {
+		return nil, "", false
+	}
+	for i := range versions {
+		if HasTag(versions[i].Tags, tag) {
+			return &versions[i], tag, true
+		}
+	}
+	return nil, "", false
+}
+
+// CompareVersions compares two semantic versions, returning a negative number, zero or
+// a positive number when a is lower than, equal to or higher than b. It reports false
+// when either is not a semantic version.
+func CompareVersions(a, b string) (int, bool) {
+	va, ok := parseSemver(a)
+	if !ok {
+		return 0, false
+	}
+	vb, ok := parseSemver(b)
+	if !ok {
+		return 0, false
+	}
+	return va.compare(vb), true
+}
+
+func (r VersionRange) matches(v semver) bool {
+	for _, c := range r.constraints {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// semver is a parsed MAJOR.MINOR.PATCH[-PRERELEASE] version.
+type semver struct {
+	major, minor, patch int
+	pre                 string
+}
+
+// parseSemver parses a full version, with an optional "v" prefix and ignoring build metadata.
+func parseSemver(s string) (semver, bool) {
+	v, parts, ok := parsePartialSemver(s)
+	return v, ok && parts == 3
+}
+
+// parsePartialSemver parses a version that may omit the minor and patch numbers,
+// reporting how many numbers were given.
+func parsePartialSemver(s string) (semver, int, bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	var v semver
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.pre = s[i+1:]
+		s = s[:i]
+		if v.pre == "" {
+			return semver{}, 0, false
+		}
+	}
+
+	fields := strings.Split(s, ".")
+	if len(fields) > 3 {
+		return semver{}, 0, false
+	}
+	numbers := []*int{&v.major, &v.minor, &v.patch}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return semver{}, 0, false
+		}
+		*numbers[i] = n
+	}
+	if v.pre != "" && len(fields) < 3 {
+		return semver{}, 0, false
+	}
+	return v, len(fields), true
+}
+
+func (v semver) compare(o semver) int {
+	for _, d := range []int{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d != 0 {
+			return d
+		}
+	}
+	switch {
+	case v.pre == o.pre:
+		return 0
+	case v.pre == "":
+		return 1
+	case o.pre == "":
+		return -1
+	default:
+		return strings.Compare(v.pre, o.pre)
+	}
+}
+
+type semverConstraint struct {
+	op      string // one of =, >, >=, <, <=
+	version semver
+}
+
+func (c semverConstraint) matches(v semver) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// parseSemverRange parses space separated constraints that must all hold.
+func parseSemverRange(expr string) ([]semverConstraint, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty range")
+	}
+
+	var constraints []semverConstraint
+	for _, field := range fields {
+		parsed, err := parseSemverTerm(field)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, parsed...)
+	}
+	return constraints, nil
+}
+
+// parseSemverTerm expands one term (~1.2, ^1.2.3, 1.x, >=1.0.0, 1.2.3) into constraints.
+func parseSemverTerm(term string) ([]semverConstraint, error) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(term, op); ok {
+			v, parts, ok := parsePartialSemver(rest)
+			if !ok {
+				return nil, fmt.Errorf("invalid version %q", rest)
+			}
+			if op == "=" && parts < 3 {
+				return wildcardRange(v, parts), nil
+			}
+			return []semverConstraint{{op: op, version: v}}, nil
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(term, "~"); ok {
+		v, parts, ok := parsePartialSemver(rest)
+		if !ok {
+			return nil, fmt.Errorf("invalid version %q", rest)
+		}
+		// ~1 allows any 1.x.y, ~1.2 and ~1.2.3 allow patch updates
+		upper := semver{major: v.major + 1}
+		if parts > 1 {
+			upper = semver{major: v.major, minor: v.minor + 1}
+		}
+		return []semverConstraint{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	}
+
+	if rest, ok := strings.CutPrefix(term, "^"); ok {
+		v, parts, ok := parsePartialSemver(rest)
+		if !ok {
+			return nil, fmt.Errorf("invalid version %q", rest)
+		}
+		// ^ allows updates that do not change the leftmost non-zero number
+		var upper semver
+		switch {
+		case v.major > 0 || parts == 1:
+			upper = semver{major: v.major + 1}
+		case v.minor > 0 || parts == 2:
+			upper = semver{minor: v.minor + 1}
+		default:
+			upper = semver{patch: v.patch + 1}
+		}
+		return []semverConstraint{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	}
+
+	// A bare version, where x or * stand for any number
+	trimmed := term
+	for strings.HasSuffix(trimmed, ".x") || strings.HasSuffix(trimmed, ".X") || strings.HasSuffix(trimmed, ".*") {
+		trimmed = trimmed[:len(trimmed)-2]
+	}
+	if trimmed == "x" || trimmed == "X" || trimmed == "*" {
+		return []semverConstraint{{op: ">=", version: semver{}}}, nil
+	}
+	v, parts, ok := parsePartialSemver(trimmed)
+	if !ok {
+		return nil, fmt.Errorf("invalid version %q", term)
+	}
+	if parts == 3 {
+		return []semverConstraint{{op: "=", version: v}}, nil
+	}
+	return wildcardRange(v, parts), nil
+}
+
+// wildcardRange matches every version that starts with the given major (and minor) number.
+func wildcardRange(v semver, parts int) []semverConstraint {
+	upper := semver{major: v.major + 1}
+	if parts == 2 {
+		upper = semver{major: v.major, minor: v.minor + 1}
+	}
+	return []semverConstraint{{op: ">=", version: v}, {op: "<", version: upper}}
+}
@@ -0,0 +1,59 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionRangeHighestMatch(t *testing.T) {
+	tags := []string{"latest", "v1.1.9", "1.2.0", "1.2.7", "1.3.0", "1.3.1-rc.1", "2.0.0", "0.2.5", "0.3.0"}
+
+	tests := map[string]string{
+		"~1.2":           "1.2.7",
+		"~1.2.3":         "1.2.7",
+		"~1":             "1.3.0",
+		"^1.1":           "1.3.0",
+		"^0.2":           "0.2.5",
+		"1.x":            "1.3.0",
+		"1.2.X":          "1.2.7",
+		"1.2":            "1.2.7",
+		"=1.2.0":         "1.2.0",
+		">=1.0.0 <1.2.0": "v1.1.9",
+		"*":              "2.0.0",
+		"~3":             "",
+	}
+	for expr, want := range tests {
+		versionRange, err := ParseVersionRange(expr)
+		require.NoError(t, err, expr)
+
+		match, ok := versionRange.HighestMatch(tags)
+		assert.Equal(t, want != "", ok, expr)
+		assert.Equal(t, want, match, expr)
+	}
+}
+
+func TestParseVersionRangeRejectsInvalidRanges(t *testing.T) {
+	for _, expr := range []string{"", "latest", "~1.a", ">=", "1.2.3.4", "^"} {
+		_, err := ParseVersionRange(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cmp, ok := CompareVersions("v1.10.0", "1.9.3")
+	require.True(t, ok)
+	assert.Positive(t, cmp)
+
+	cmp, ok = CompareVersions("1.2.0-beta.1", "1.2.0")
+	require.True(t, ok)
+	assert.Negative(t, cmp)
+
+	cmp, ok = CompareVersions("1.2.0+build.5", "v1.2.0")
+	require.True(t, ok)
+	assert.Zero(t, cmp)
+
+	_, ok = CompareVersions("dev", "1.2.0")
+	assert.False(t, ok)
+}
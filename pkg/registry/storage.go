@@ -4,4 +4,5 @@ type Storage interface {
 	ReadWASMFile(path string) ([]byte, error)
 	WriteWASMFile(path string, data []byte) error
 	BuildWASMPath(namespace, name, shortDigest string) string
+	ListWASMFiles() ([]string, error)
 }
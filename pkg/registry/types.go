@@ -13,6 +13,25 @@ type FunctionMetadata struct {
 	UpdatedAt time.Time              `json:"updated_at"`
 	Versions  []VersionInfo          `json:"versions"`
 	Config    map[string]interface{} `json:"config"`
+
+	// Incremented on every write, so readers can tell whether the metadata changed
+	Revision int64 `json:"revision"`
+}
+
+// Registry writes reported to write observers
+const (
+	WritePush = "push"
+	WriteTag  = "tag"
+)
+
+// WriteEvent describes a committed registry write: a pushed version, with the tag it
+// was pushed under if any, or a tag moved to a version.
+type WriteEvent struct {
+	Op        string
+	Namespace string
+	Name      string
+	Digest    string
+	Tag       string
 }
 
 type VersionInfo struct {
@@ -20,6 +39,35 @@ type VersionInfo struct {
 	FullDigest string                           `json:"full_digest"`
 	CreatedAt  time.Time                        `json:"created_at"`
 	Size       int64                            `json:"size"`
+	Checksum   string                           `json:"checksum,omitempty"`
 	Tags       []string                         `json:"tags"`
 	Settings   manifest.FunctionVersionSettings `json:"settings"`
+	Module     *ModuleInfo                      `json:"module,omitempty"`
+	Provenance *Provenance                      `json:"provenance,omitempty"`
+
+	// Alternative modules of the version, selected when it is loaded
+	Variants []Variant `json:"variants,omitempty"`
+}
+
+// Provenance records how a version was built, for versions built by the engine.
+type Provenance struct {
+	// Version of ignition that ran the build
+	Builder string `json:"builder"`
+
+	// Versions of the tools that compiled the module, by tool name
+	Toolchain map[string]string `json:"toolchain,omitempty"`
+
+	// Hash of the source files the module was built from
+	SourceHash string `json:"source_hash"`
+
+	// Environment settings and commands of the build
+	Flags []string `json:"flags,omitempty"`
+
+	// Whether timestamps and paths embedded by the toolchain were normalized
+	Reproducible bool `json:"reproducible"`
+
+	// Whether function names and DWARF sections were kept for symbolized traps
+	Debug bool `json:"debug,omitempty"`
+
+	BuiltAt time.Time `json:"built_at"`
 }
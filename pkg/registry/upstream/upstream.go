@@ -0,0 +1,140 @@
+// Package upstream pulls versions missing from the local registry from a shared
+// registry: another ignition engine or an OCI registry.
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/registry/remote"
+	"github.com/ignitionstack/ignition/pkg/types"
+)
+
+const (
+	// defaultTimeout bounds each upstream pull when no timeout is given
+	defaultTimeout = time.Minute
+
+	// maxMetadataSize is the room left for version metadata in an engine response
+	maxMetadataSize = 1 << 20
+)
+
+// New creates the upstream named by url: the http(s) URL of the admin API of another
+// engine, authenticated with token, or an oci://registry/repository prefix under which
+// each function is stored as <prefix>/<namespace>/<name>. Modules larger than maxSize
+// are refused; zero or less disables the limit. Requests go through transport, or
+// http.DefaultTransport when it is nil.
+func New(url, token string, timeout time.Duration, maxSize int64, transport http.RoundTripper) (registry.Upstream, error) {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Timeout: timeout, Transport: transport}
+
+	switch {
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		return &engineUpstream{
+			baseURL: strings.TrimSuffix(url, "/"),
+			token:   token,
+			client:  client,
+			maxSize: maxSize,
+		}, nil
+	case strings.HasPrefix(url, remote.SchemeOCI):
+		return &ociUpstream{
+			prefix:  strings.TrimSuffix(url, "/"),
+			fetcher: remote.NewFetcher(client, maxSize),
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: upstream %s must be an http(s) or oci:// URL", remote.ErrUnsupportedReference, url)
+	}
+}
+
+// engineUpstream pulls from another engine through its admin API.
+type engineUpstream struct {
+	baseURL string
+	token   string
+	client  *http.Client
+	maxSize int64
+}
+
+func (u *engineUpstream) Pull(ctx context.Context, namespace, name, reference string) ([]byte, *registry.VersionInfo, error) {
+	body, err := json.Marshal(types.PullRequest{
+		FunctionRequest: types.FunctionRequest{Namespace: namespace, Name: name},
+		Reference:       reference,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.baseURL+"/registry/pull", bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if u.token != "" {
+		req.Header.Set("Authorization", "Bearer "+u.token)
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil, fmt.Errorf("%w: %s/%s:%s", registry.ErrVersionNotFound, namespace, name, reference)
+	case resp.StatusCode != http.StatusOK:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, nil, fmt.Errorf("upstream engine answered %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	// Payloads are base64 encoded in the response, so the limit allows for the encoding
+	var reader io.Reader = resp.Body
+	if u.maxSize > 0 {
+		reader = io.LimitReader(resp.Body, (u.maxSize+2)/3*4+maxMetadataSize)
+	}
+	var pulled types.PullResponse
+	if err := json.NewDecoder(reader).Decode(&pulled); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode upstream response: %w", err)
+	}
+	if u.maxSize > 0 && int64(len(pulled.Payload)) > u.maxSize {
+		return nil, nil, fmt.Errorf("%w (limit %d bytes)", remote.ErrTooLarge, u.maxSize)
+	}
+	return pulled.Payload, &pulled.Version, nil
+}
+
+// ociUpstream pulls tagged modules from an OCI registry.
+type ociUpstream struct {
+	prefix  string
+	fetcher *remote.Fetcher
+}
+
+func (u *ociUpstream) Pull(ctx context.Context, namespace, name, reference string) ([]byte, *registry.VersionInfo, error) {
+	ref := fmt.Sprintf("%s/%s/%s:%s", u.prefix, namespace, name, reference)
+	artifact, err := u.fetcher.Fetch(ctx, ref, "")
+	if err != nil {
+		if errors.Is(err, remote.ErrNotFound) {
+			return nil, nil, fmt.Errorf("%w: %s", registry.ErrVersionNotFound, ref)
+		}
+		return nil, nil, err
+	}
+
+	// OCI artifacts carry no manifest, so WASI follows what the module imports
+	version := &registry.VersionInfo{
+		FullDigest: artifact.Digest,
+		Size:       int64(len(artifact.Payload)),
+		Settings:   manifest.FunctionVersionSettings{},
+	}
+	if info, err := registry.InspectModule(artifact.Payload); err == nil {
+		version.Settings.Wasi = info.RequiresWasi
+	}
+	return artifact.Payload, version, nil
+}
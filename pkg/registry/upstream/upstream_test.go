@@ -0,0 +1,54 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/registry/remote"
+	"github.com/ignitionstack/ignition/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineUpstream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req types.PullRequest
+		if r.URL.Path != "/registry/pull" || r.Header.Get("Authorization") != "Bearer secret" ||
+			json.NewDecoder(r.Body).Decode(&req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.Reference != "latest" {
+			http.Error(w, "tag not found", http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(types.PullResponse{
+			Version: registry.VersionInfo{FullDigest: "sha256:abc", Tags: []string{"latest"}},
+			Payload: []byte("\x00asm module"),
+		})
+	}))
+	defer server.Close()
+
+	up, err := New(server.URL+"/", "secret", 0, 1024, nil)
+	require.NoError(t, err)
+
+	payload, version, err := up.Pull(context.Background(), "ns", "fn", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("\x00asm module"), payload)
+	assert.Equal(t, "sha256:abc", version.FullDigest)
+
+	_, _, err = up.Pull(context.Background(), "ns", "fn", "v2")
+	assert.ErrorIs(t, err, registry.ErrVersionNotFound)
+
+	small, err := New(server.URL, "secret", 0, 4, nil)
+	require.NoError(t, err)
+	_, _, err = small.Pull(context.Background(), "ns", "fn", "latest")
+	assert.Error(t, err)
+
+	_, err = New("ftp://shared", "", 0, 0, nil)
+	assert.ErrorIs(t, err, remote.ErrUnsupportedReference)
+}
@@ -1,6 +1,8 @@
 package registry
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/ignitionstack/ignition/pkg/manifest"
@@ -13,6 +15,13 @@ func TruncateDigest(digest string, length int) string {
 	return digest[:length]
 }
 
+// Checksum returns the sha256 checksum of a stored module. Unlike the digest, which
+// builds derive from the function source, it always covers the module itself.
+func Checksum(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
 func HasTag(tags []string, tag string) bool {
 	for _, t := range tags {
 		if t == tag {
@@ -57,6 +66,7 @@ func CreateVersionInfo(shortDigest, fullDigest string, payload []byte, tag strin
 		Hash:       shortDigest,
 		FullDigest: fullDigest,
 		Size:       int64(len(payload)),
+		Checksum:   Checksum(payload),
 		CreatedAt:  time.Now(),
 		Tags:       tags,
 		Settings:   settings,
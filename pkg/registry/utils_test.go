@@ -243,6 +243,7 @@ func TestCreateVersionInfo(t *testing.T) {
 				Hash:       "abc123",
 				FullDigest: "abcdef123456",
 				Size:       int64(len(payload)),
+				Checksum:   "sha256:813ca5285c28ccee5cab8b10ebda9c908fd6d78ed9dc94cc65ea6cb67a7f13ae",
 				Tags:       []string{"latest"},
 				Settings:   defaultSettings,
 			},
@@ -258,6 +259,7 @@ func TestCreateVersionInfo(t *testing.T) {
 				Hash:       "abc123",
 				FullDigest: "abcdef123456",
 				Size:       int64(len(payload)),
+				Checksum:   "sha256:813ca5285c28ccee5cab8b10ebda9c908fd6d78ed9dc94cc65ea6cb67a7f13ae",
 				Tags:       []string{},
 				Settings:   defaultSettings,
 			},
@@ -276,6 +278,7 @@ func TestCreateVersionInfo(t *testing.T) {
 				Hash:       "abc123",
 				FullDigest: "abcdef123456",
 				Size:       int64(len(payload)),
+				Checksum:   "sha256:813ca5285c28ccee5cab8b10ebda9c908fd6d78ed9dc94cc65ea6cb67a7f13ae",
 				Tags:       []string{"v1"},
 				Settings: manifest.FunctionVersionSettings{
 					Wasi:        false,
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// DefaultVariant names the module a version digest names, as opposed to its variants.
+const DefaultVariant = "default"
+
+// Variant is an alternative module of a version, such as a debug build keeping its
+// names section or a WASI preview 2 component, stored alongside the module the
+// version digest names.
+type Variant struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	Checksum  string    `json:"checksum"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Whether the module is a component rather than a core module
+	Component bool `json:"component,omitempty"`
+
+	// Imports, exports and memory of a core module (nil for components)
+	Module *ModuleInfo `json:"module,omitempty"`
+}
+
+// VariantStore is implemented by registries that keep variants of versions.
+type VariantStore interface {
+	// AddVariant stores a variant of the version a digest, tag or semver range resolves
+	// to, replacing a variant of the same name, and returns the updated version.
+	AddVariant(namespace, name, reference, variant string, payload []byte) (*VersionInfo, error)
+
+	// PullVariant returns a variant of the version with a digest, failing with
+	// ErrVariantNotFound when the version has none of that name.
+	PullVariant(namespace, name, digest, variant string) ([]byte, *Variant, error)
+}
+
+var variantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{0,31}$`)
+
+// ValidateVariantName checks that a variant name is a short lowercase identifier, such
+// as wasi-p2 or debug, other than the name of the default module.
+func ValidateVariantName(variant string) error {
+	if variant == DefaultVariant {
+		return fmt.Errorf("variant name %q is reserved for the module of the version", variant)
+	}
+	if !variantNamePattern.MatchString(variant) {
+		return fmt.Errorf("invalid variant name %q: use up to 32 lowercase letters, digits, dots and dashes", variant)
+	}
+	return nil
+}
+
+// componentLayer is the version and layer field of the header of a component binary.
+var componentLayer = []byte{0x0d, 0x00, 0x01, 0x00}
+
+// IsComponent reports whether a wasm binary is a component rather than a core module.
+func IsComponent(payload []byte) bool {
+	return len(payload) >= 8 && bytes.Equal(payload[:4], wasmMagic) && bytes.Equal(payload[4:8], componentLayer)
+}
+
+// FindVariant returns the variant of a version with the given name.
+func (v *VersionInfo) FindVariant(name string) (*Variant, bool) {
+	for i := range v.Variants {
+		if v.Variants[i].Name == name {
+			return &v.Variants[i], true
+		}
+	}
+	return nil, false
+}
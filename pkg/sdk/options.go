@@ -0,0 +1,106 @@
+package sdk
+
+import (
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+)
+
+// runtimeConfig collects the options passed to New
+type runtimeConfig struct {
+	registryDir  string
+	logger       logging.Logger
+	options      *engine.Options
+	interceptors []engine.Interceptor
+}
+
+// Option configures a Runtime.
+type Option func(*runtimeConfig)
+
+// WithRegistryDir keeps the registry in dir, so loaded modules persist across runs
+// and can be shared with the ignition CLI while no engine daemon uses it.
+func WithRegistryDir(dir string) Option {
+	return func(c *runtimeConfig) {
+		c.registryDir = dir
+	}
+}
+
+// WithLogger sends engine logs to logger. By default they are discarded.
+func WithLogger(logger logging.Logger) Option {
+	return func(c *runtimeConfig) {
+		c.logger = logger
+	}
+}
+
+// WithEngineOptions replaces the default engine options, such as the default call
+// timeout, pool sizes or circuit breaker settings. Listener settings are ignored.
+func WithEngineOptions(options *engine.Options) Option {
+	return func(c *runtimeConfig) {
+		if options != nil {
+			c.options = options
+		}
+	}
+}
+
+// WithInterceptors applies interceptors to calls of every function. The last
+// interceptor is the outermost.
+func WithInterceptors(interceptors ...engine.Interceptor) Option {
+	return func(c *runtimeConfig) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// loadConfig collects the options passed to Load
+type loadConfig struct {
+	config       map[string]string
+	wasi         *bool
+	allowedHosts []string
+	instances    int
+}
+
+// LoadOption configures how a module is loaded.
+type LoadOption func(*loadConfig)
+
+// WithConfig passes configuration values to the function.
+func WithConfig(config map[string]string) LoadOption {
+	return func(c *loadConfig) {
+		c.config = config
+	}
+}
+
+// WithWasi enables or disables WASI instead of detecting it from the module's imports.
+func WithWasi(enabled bool) LoadOption {
+	return func(c *loadConfig) {
+		c.wasi = &enabled
+	}
+}
+
+// WithAllowedHosts lets the function make HTTP requests to the given hosts.
+func WithAllowedHosts(hosts ...string) LoadOption {
+	return func(c *loadConfig) {
+		c.allowedHosts = hosts
+	}
+}
+
+// WithInstances keeps a fixed number of instances instead of autoscaling.
+func WithInstances(instances int) LoadOption {
+	return func(c *loadConfig) {
+		c.instances = instances
+	}
+}
+
+// callConfig collects the options passed to Call
+type callConfig struct {
+	timeout time.Duration
+}
+
+// CallOption configures a single call.
+type CallOption func(*callConfig)
+
+// WithTimeout bounds the call, capped by the engine's default timeout.
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(c *callConfig) {
+		c.timeout = timeout
+	}
+}
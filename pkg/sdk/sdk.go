@@ -0,0 +1,193 @@
+// Package sdk embeds the Ignition engine in Go programs.
+//
+// A Runtime loads WebAssembly modules straight from bytes and calls them in-process,
+// without a running engine daemon or a prebuilt registry:
+//
+//	rt, err := sdk.New()
+//	if err != nil {
+//		return err
+//	}
+//	defer rt.Close()
+//
+//	if _, err := rt.Load(ctx, "default", "greeter", wasmBytes, sdk.WithConfig(map[string]string{"GREETING": "Hello"})); err != nil {
+//		return err
+//	}
+//	output, err := rt.Call(ctx, "default", "greeter", "greet", []byte(`{"name":"World"}`))
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ignitionstack/ignition/pkg/engine"
+	"github.com/ignitionstack/ignition/pkg/engine/logging"
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// Runtime is an embedded engine. It is safe for concurrent use.
+type Runtime struct {
+	engine *engine.Engine
+
+	// Temporary registry directory removed on Close (empty when the caller chose one)
+	tempDir string
+}
+
+// New creates an embedded engine. Without WithRegistryDir the registry lives in a
+// temporary directory that is removed by Close.
+func New(opts ...Option) (*Runtime, error) {
+	cfg := runtimeConfig{
+		logger:  logging.NewStdLogger(io.Discard),
+		options: engine.DefaultEngineOptions(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	rt := &Runtime{}
+	registryDir := cfg.registryDir
+	if registryDir == "" {
+		dir, err := os.MkdirTemp("", "ignition-sdk-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create registry directory: %w", err)
+		}
+		registryDir = dir
+		rt.tempDir = dir
+	}
+
+	eng, err := engine.NewEngineWithOptions("", "", registryDir, cfg.logger, cfg.options.WithSocket(false))
+	if err != nil {
+		rt.removeTempDir()
+		return nil, err
+	}
+	for _, interceptor := range cfg.interceptors {
+		eng.UseInterceptor(interceptor)
+	}
+
+	rt.engine = eng
+	return rt, nil
+}
+
+// Load stores a wasm module and loads it as namespace/name, replacing any version
+// loaded before. Unless WithWasi is given, WASI is enabled when the module imports it.
+// It returns the module digest.
+func (r *Runtime) Load(ctx context.Context, namespace, name string, wasmBytes []byte, opts ...LoadOption) (string, error) {
+	cfg := loadConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	settings := manifest.FunctionVersionSettings{AllowedUrls: cfg.allowedHosts}
+	if cfg.wasi != nil {
+		settings.Wasi = *cfg.wasi
+	} else if info, err := registry.InspectModule(wasmBytes); err == nil {
+		settings.Wasi = info.RequiresWasi
+	}
+
+	digest, err := r.engine.LoadFunctionFromBytes(ctx, namespace, name, wasmBytes, settings, cfg.config)
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.instances > 0 {
+		if err := r.engine.ScaleFunction(namespace, name, cfg.instances); err != nil {
+			return "", err
+		}
+	}
+	return digest, nil
+}
+
+// LoadFile reads a wasm module from disk and loads it like Load.
+func (r *Runtime) LoadFile(ctx context.Context, namespace, name, path string, opts ...LoadOption) (string, error) {
+	wasmBytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read wasm module: %w", err)
+	}
+	return r.Load(ctx, namespace, name, wasmBytes, opts...)
+}
+
+// Call invokes an entrypoint of a loaded function and returns its output.
+func (r *Runtime) Call(ctx context.Context, namespace, name, entrypoint string, payload []byte, opts ...CallOption) ([]byte, error) {
+	cfg := callConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+
+	return r.engine.CallFunctionWithContext(ctx, namespace, name, entrypoint, payload)
+}
+
+// CallJSON encodes input as JSON, calls the entrypoint and decodes the output into
+// output. A nil output discards the result.
+func (r *Runtime) CallJSON(ctx context.Context, namespace, name, entrypoint string, input, output interface{}, opts ...CallOption) error {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode input: %w", err)
+	}
+
+	result, err := r.Call(ctx, namespace, name, entrypoint, payload, opts...)
+	if err != nil {
+		return err
+	}
+	if output == nil {
+		return nil
+	}
+	if err := json.Unmarshal(result, output); err != nil {
+		return fmt.Errorf("failed to decode output: %w", err)
+	}
+	return nil
+}
+
+// Intercept applies interceptors to calls of one function, inside the global ones.
+// They stay registered when the function is reloaded.
+func (r *Runtime) Intercept(namespace, name string, interceptors ...engine.Interceptor) {
+	for _, interceptor := range interceptors {
+		r.engine.UseFunctionInterceptor(namespace, name, interceptor)
+	}
+}
+
+// Unload removes a function from memory. It can be loaded again later.
+func (r *Runtime) Unload(namespace, name string) error {
+	return r.engine.UnloadFunction(namespace, name)
+}
+
+// IsLoaded reports whether a function is loaded.
+func (r *Runtime) IsLoaded(namespace, name string) bool {
+	return r.engine.IsLoaded(namespace, name)
+}
+
+// Logs returns the engine's log lines for a function, including the
+// output of the module's logging calls. A zero since returns all kept lines.
+func (r *Runtime) Logs(namespace, name string, since time.Time) []string {
+	return r.engine.FunctionLogs(namespace, name, since, 0)
+}
+
+// Engine returns the underlying engine for features the SDK does not wrap.
+func (r *Runtime) Engine() *engine.Engine {
+	return r.engine
+}
+
+// Close unloads every function and releases the registry. A temporary registry is deleted.
+func (r *Runtime) Close() error {
+	err := r.engine.Close()
+	if removeErr := r.removeTempDir(); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+func (r *Runtime) removeTempDir() error {
+	if r.tempDir == "" {
+		return nil
+	}
+	return os.RemoveAll(r.tempDir)
+}
@@ -0,0 +1,96 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/ignitionstack/ignition/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testModule exports "run", which returns 0 without output
+var testModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x01, 0x05, 0x01, 0x60, 0x00, 0x01, 0x7f, // type: () -> i32
+	0x03, 0x02, 0x01, 0x00, // one function of that type
+	0x07, 0x07, 0x01, 0x03, 'r', 'u', 'n', 0x00, 0x00, // export "run"
+	0x0a, 0x06, 0x01, 0x04, 0x00, 0x41, 0x00, 0x0b, // i32.const 0
+}
+
+func TestRuntimeLoadAndCall(t *testing.T) {
+	rt, err := New()
+	require.NoError(t, err)
+	tempDir := rt.tempDir
+
+	ctx := context.Background()
+	digest, err := rt.Load(ctx, "default", "test", testModule, WithConfig(map[string]string{"key": "value"}))
+	require.NoError(t, err)
+	assert.Len(t, digest, 64)
+	assert.True(t, rt.IsLoaded("default", "test"))
+
+	output, err := rt.Call(ctx, "default", "test", "run", nil)
+	require.NoError(t, err)
+	assert.Empty(t, output)
+
+	// The first call completes the cold start of the load
+	coldStarts := rt.Engine().ColdStarts()[engine.GetFunctionKey("default", "test")]
+	assert.Equal(t, int64(1), coldStarts.Count)
+	assert.Positive(t, coldStarts.Last.CompileMs)
+
+	_, err = rt.Call(ctx, "default", "test", "missing", nil)
+	assert.Error(t, err)
+
+	// Loading the same module again reuses the stored version
+	again, err := rt.Load(ctx, "default", "test", testModule)
+	require.NoError(t, err)
+	assert.Equal(t, digest, again)
+
+	require.NoError(t, rt.Unload("default", "test"))
+	assert.False(t, rt.IsLoaded("default", "test"))
+
+	require.NoError(t, rt.Close())
+	_, err = os.Stat(tempDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRuntimeRejectsInvalidModule(t *testing.T) {
+	rt, err := New(WithRegistryDir(t.TempDir()))
+	require.NoError(t, err)
+	defer rt.Close()
+
+	_, err = rt.Load(context.Background(), "default", "broken", []byte("not wasm"))
+	assert.Error(t, err)
+	assert.Empty(t, rt.tempDir)
+}
+
+func TestRuntimeInterceptors(t *testing.T) {
+	var seen []string
+	record := func(next engine.CallHandler) engine.CallHandler {
+		return func(ctx context.Context, call *engine.Call) ([]byte, error) {
+			seen = append(seen, call.Namespace+"/"+call.Name+":"+call.Entrypoint)
+			return next(ctx, call)
+		}
+	}
+
+	rt, err := New(WithInterceptors(record))
+	require.NoError(t, err)
+	defer rt.Close()
+
+	ctx := context.Background()
+	_, err = rt.Load(ctx, "default", "test", testModule)
+	require.NoError(t, err)
+
+	denied := errors.New("denied")
+	rt.Intercept("default", "test", func(engine.CallHandler) engine.CallHandler {
+		return func(context.Context, *engine.Call) ([]byte, error) {
+			return nil, denied
+		}
+	})
+
+	_, err = rt.Call(ctx, "default", "test", "run", nil)
+	assert.ErrorIs(t, err, denied)
+	assert.Equal(t, []string{"default/test:run"}, seen)
+}
@@ -0,0 +1,163 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/validation"
+)
+
+// Kinds of objects an apply converges
+const (
+	ApplyKindService  = "service"
+	ApplyKindPipeline = "pipeline"
+)
+
+// Actions reported for the objects of an apply
+const (
+	ApplyCreated   = "created"
+	ApplyUpdated   = "updated"
+	ApplyUnchanged = "unchanged"
+	ApplyRemoved   = "removed"
+	ApplyFailed    = "failed"
+	ApplySkipped   = "skipped" // a service it depends on failed
+)
+
+// ApplyService is a service of an applied spec, as written in a compose file once its
+// env files are read.
+type ApplyService struct {
+	// namespace/name:tag; the tag is optional with a source, and may be a semver range
+	Function  string            `json:"function"`
+	Source    string            `json:"source,omitempty"`
+	Digest    string            `json:"digest,omitempty"`
+	Config    map[string]string `json:"config,omitempty"`
+	DependsOn []string          `json:"depends_on,omitempty"`
+	Scale     int               `json:"scale,omitempty"` // 0 autoscales
+}
+
+// ApplyRequest is the whole desired state of the services and pipelines of a project.
+// Services and pipelines the project registered on the engine but missing from it are
+// removed; those of other projects are left alone.
+type ApplyRequest struct {
+	// Project owning the services and pipelines, usually the compose project name
+	Project string `json:"project"`
+
+	Services  map[string]ApplyService   `json:"services"`
+	Pipelines map[string][]PipelineStep `json:"pipelines,omitempty"`
+
+	// Loads running at once; zero uses the engine default
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// Validate checks the project, the services as a load batch and every pipeline.
+func (r ApplyRequest) Validate() error {
+	if r.Project == "" {
+		return fmt.Errorf("project is required")
+	}
+	if _, err := r.LoadBatch(); err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(r.Pipelines) {
+		pipeline := RegisterPipelineRequest{Name: name, Steps: r.Pipelines[name]}
+		if err := pipeline.Validate(); err != nil {
+			return fmt.Errorf("pipeline %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// LoadBatch returns the loads of the services, in service name order, checked like a
+// load batch request.
+func (r ApplyRequest) LoadBatch() (LoadBatchRequest, error) {
+	batch := LoadBatchRequest{Concurrency: r.Concurrency}
+	for _, name := range sortedKeys(r.Services) {
+		service := r.Services[name]
+		namespace, function, tag, err := parseServiceFunction(service)
+		if err != nil {
+			return LoadBatchRequest{}, fmt.Errorf("service %q: %w", name, err)
+		}
+
+		scale := service.Scale
+		item := LoadBatchItem{DependsOn: service.DependsOn, Scale: &scale}
+		item.Namespace, item.Name = namespace, function
+		item.Digest = tag
+		item.Config = service.Config
+		item.ForceLoad = true
+		item.Service = name
+		item.Source = service.Source
+		item.SourceDigest = service.Digest
+		batch.Loads = append(batch.Loads, item)
+	}
+	if len(batch.Loads) == 0 {
+		return batch, nil
+	}
+	return batch, batch.Validate()
+}
+
+// parseServiceFunction splits the namespace/name:tag function reference of a service.
+func parseServiceFunction(service ApplyService) (namespace, name, tag string, err error) {
+	ref, tag, _ := strings.Cut(service.Function, ":")
+	namespace, name, ok := strings.Cut(ref, "/")
+	switch {
+	case !ok:
+		return "", "", "", fmt.Errorf("invalid function reference %q, expected namespace/name:tag", service.Function)
+	case tag == "" && service.Source == "":
+		return "", "", "", fmt.Errorf("function reference %q needs a tag", service.Function)
+	case tag != "" && service.Source == "":
+		// Semver ranges such as ^1.2 are resolved by the registry on pull
+		if err := validation.ValidateTag(tag); err != nil {
+			if _, rangeErr := registry.ParseVersionRange(tag); rangeErr != nil {
+				return "", "", "", err
+			}
+		}
+	}
+	return namespace, name, tag, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ApplyChange is what an apply did to one service or pipeline.
+type ApplyChange struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+
+	// Function backing a service, as namespace/name
+	Function string `json:"function,omitempty"`
+
+	// What changed in an updated object: function, loaded (its function had been
+	// unloaded), digest, config, scale or steps; stopped for a created service whose
+	// function had been stopped
+	Reasons []string `json:"reasons,omitempty"`
+
+	// Digest of the function of a service before the apply and after it, or the digest a
+	// plan resolved the spec to (empty when it cannot be known before loading)
+	PreviousDigest string `json:"previous_digest,omitempty"`
+	Digest         string `json:"digest,omitempty"`
+
+	// Config keys of a service whose values change, without the values
+	ConfigKeys []string `json:"config_keys,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// ApplyResponse reports every change of an apply: services in name order, then
+// pipelines in name order, removed objects included.
+type ApplyResponse struct {
+	Created   int           `json:"created"`
+	Updated   int           `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	Removed   int           `json:"removed"`
+	Failed    int           `json:"failed"`
+	Skipped   int           `json:"skipped"`
+	Changes   []ApplyChange `json:"changes"`
+}
@@ -0,0 +1,35 @@
+package types
+
+import "time"
+
+// States of a build
+const (
+	BuildRunning   = "running"
+	BuildSucceeded = "succeeded"
+	BuildFailed    = "failed"
+)
+
+// BuildLog is the output of a build, or the part of it after a requested offset.
+type BuildLog struct {
+	ID         string     `json:"id"`
+	Namespace  string     `json:"namespace"`
+	Name       string     `json:"name"`
+	Tag        string     `json:"tag,omitempty"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	// Output is what the build commands printed, starting at byte Offset of the full output
+	Output string `json:"output"`
+	Offset int64  `json:"offset"`
+
+	// Size is the length of the full output so far; ask for it as the offset to get
+	// only what is printed next
+	Size int64 `json:"size"`
+}
+
+// Done reports whether the build has finished.
+func (l BuildLog) Done() bool {
+	return l.Status != BuildRunning
+}
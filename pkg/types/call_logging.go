@@ -0,0 +1,66 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Call logging modes.
+const (
+	CallLogAll     = "all"     // every call writes its entries
+	CallLogSampled = "sampled" // one call in SampleRate writes its entries
+	CallLogQuiet   = "quiet"   // no successful call writes entries
+)
+
+// CallLogging decides which calls of a function write their call and result entries to
+// the function's logs. Failed calls always log their error.
+type CallLogging struct {
+	Mode       string
+	SampleRate int // for CallLogSampled
+}
+
+// ParseCallLogging parses "all" (or an empty string), "quiet" or "1/N" to log one call in N.
+func ParseCallLogging(value string) (CallLogging, error) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "", CallLogAll:
+		return CallLogging{Mode: CallLogAll}, nil
+	case CallLogQuiet:
+		return CallLogging{Mode: CallLogQuiet}, nil
+	}
+
+	rate, ok := strings.CutPrefix(value, "1/")
+	n, err := strconv.Atoi(rate)
+	if !ok || err != nil || n < 1 {
+		return CallLogging{}, fmt.Errorf("invalid call logging %q (expected all, quiet or 1/N)", value)
+	}
+	if n == 1 {
+		return CallLogging{Mode: CallLogAll}, nil
+	}
+	return CallLogging{Mode: CallLogSampled, SampleRate: n}, nil
+}
+
+// String returns the call logging in the form ParseCallLogging accepts.
+func (c CallLogging) String() string {
+	switch c.Mode {
+	case CallLogSampled:
+		return fmt.Sprintf("1/%d", c.SampleRate)
+	case "":
+		return CallLogAll
+	default:
+		return c.Mode
+	}
+}
+
+// Logs reports whether the call with sequence number n, counted from 0, writes its entries.
+func (c CallLogging) Logs(n uint64) bool {
+	switch c.Mode {
+	case CallLogQuiet:
+		return false
+	case CallLogSampled:
+		return n%uint64(c.SampleRate) == 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,31 @@
+package types
+
+// Outcomes of re-driving a dead letter entry
+const (
+	RedriveSucceeded = "succeeded"
+	RedriveFailed    = "failed"
+)
+
+// DeadLetterRequest selects dead letter entries by ID; no IDs selects every entry.
+type DeadLetterRequest struct {
+	IDs []string `json:"ids,omitempty"`
+}
+
+// RedriveResult is the outcome of replaying one dead letter entry.
+type RedriveResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RedriveResponse summarizes a re-drive of dead letter entries.
+type RedriveResponse struct {
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+	Results   []RedriveResult `json:"results"`
+}
+
+// PurgeResponse reports how many dead letter entries were removed.
+type PurgeResponse struct {
+	Removed int `json:"removed"`
+}
@@ -1,7 +1,14 @@
 package types
 
 import (
+	"fmt"
+	"io"
 	"time"
+
+	"github.com/ignitionstack/ignition/pkg/manifest"
+	"github.com/ignitionstack/ignition/pkg/registry"
+	"github.com/ignitionstack/ignition/pkg/registry/remote"
+	"github.com/ignitionstack/ignition/pkg/validation"
 )
 
 // BuildRequest represents a request to build a function.
@@ -10,6 +17,50 @@ type BuildRequest struct {
 	Name      string `json:"name" validate:"required"`
 	Path      string `json:"path" validate:"required"`
 	Tag       string `json:"tag"`
+
+	// Reproducible normalizes the timestamps and paths the toolchain embeds in the module
+	Reproducible bool `json:"reproducible,omitempty"`
+
+	// Debug keeps function names and DWARF sections in the module
+	Debug bool `json:"debug,omitempty"`
+
+	// BuildID names the build, so its output can be read while it runs; the engine
+	// picks one when empty
+	BuildID string `json:"build_id,omitempty"`
+}
+
+// BuildOptions adjusts how a function is built.
+type BuildOptions struct {
+	// Normalize the timestamps and paths the toolchain embeds in the module, where it
+	// allows it
+	Reproducible bool
+
+	// Keep the function names and DWARF sections of the module, so traps are reported
+	// with symbols and source lines
+	Debug bool
+
+	// Names the build in the engine's build logs; the engine picks one when empty
+	BuildID string
+
+	// Receives the output of the build commands; nil passes it through to the
+	// stdout and stderr of the process
+	Output io.Writer
+}
+
+// Validate checks the function identifier and optional tag against the naming rules.
+func (r BuildRequest) Validate() error {
+	if err := validation.ValidateFunction(r.Namespace, r.Name); err != nil {
+		return err
+	}
+	if r.Tag != "" {
+		if err := validation.ValidateTag(r.Tag); err != nil {
+			return err
+		}
+	}
+	if r.BuildID != "" {
+		return validation.ValidateBuildID(r.BuildID)
+	}
+	return nil
 }
 
 // BuildResponse represents the response from a build operation.
@@ -18,6 +69,7 @@ type BuildResponse struct {
 	Tag       string `json:"tag"`
 	Status    string `json:"status"`
 	BuildTime string `json:"build_time"`
+	BuildID   string `json:"build_id"`
 }
 
 // BuildResult contains information about a successful build.
@@ -28,6 +80,7 @@ type BuildResult struct {
 	BuildTime string `json:"build_time"`
 	Tag       string `json:"tag"`
 	Reused    bool   `json:"reused"`
+	BuildID   string `json:"build_id,omitempty"`
 }
 
 // LoadResult contains information about a successful load operation.
@@ -44,14 +97,93 @@ type FunctionRequest struct {
 	Name      string `json:"name" validate:"required"`
 }
 
-// LoadRequest represents a request to load a function.
+// Validate checks the function identifier against the naming rules.
+func (r FunctionRequest) Validate() error {
+	return validation.ValidateFunction(r.Namespace, r.Name)
+}
+
+// LoadRequest represents a request to load a function. When Source points at an
+// HTTPS URL or OCI reference, the module is imported into the registry first and
+// Digest is an optional tag to give the imported version.
 type LoadRequest struct {
 	FunctionRequest
-	Digest    string            `json:"digest" validate:"required"`
-	Config    map[string]string `json:"config,omitempty"`
-	ForceLoad bool              `json:"force_load,omitempty"`
+	Digest       string            `json:"digest" validate:"required_without=Source"`
+	Config       map[string]string `json:"config,omitempty"`
+	ForceLoad    bool              `json:"force_load,omitempty"`
+	Service      string            `json:"service,omitempty"`
+	Source       string            `json:"source,omitempty"`
+	SourceDigest string            `json:"source_digest,omitempty"`
+
+	// Log retention of the function; zero values use the engine defaults
+	LogMaxEntries int   `json:"log_max_entries,omitempty" validate:"min=0"`
+	LogMaxAgeMs   int64 `json:"log_max_age_ms,omitempty" validate:"min=0"`
+
+	// Version to load when the function is reloaded automatically (see ParseReloadPolicy)
+	ReloadPolicy string `json:"reload_policy,omitempty"`
+
+	// Priority of the function's calls when its pool is saturated
+	Priority string `json:"priority,omitempty" validate:"omitempty,oneof=high normal low"`
+
+	// Longest the function's calls wait for a free instance; zero uses the engine default
+	MaxQueueWaitMs int64 `json:"max_queue_wait_ms,omitempty" validate:"min=0"`
+
+	// Calls that write entries to the function's logs (see ParseCallLogging)
+	CallLogging string `json:"call_logging,omitempty"`
+
+	// Variant of the version to load, "default" for its module (empty loads the
+	// preferred variants of the engine)
+	Variant string `json:"variant,omitempty"`
+}
+
+// Validate checks the function identifier, the reload policy, the variant and, for
+// imports, the source and tag.
+func (r LoadRequest) Validate() error {
+	if err := r.FunctionRequest.Validate(); err != nil {
+		return err
+	}
+	if _, err := ParseReloadPolicy(r.ReloadPolicy); err != nil {
+		return err
+	}
+	if _, err := ParseCallLogging(r.CallLogging); err != nil {
+		return err
+	}
+	if r.Variant != "" && r.Variant != registry.DefaultVariant {
+		if err := registry.ValidateVariantName(r.Variant); err != nil {
+			return err
+		}
+	}
+	if r.Source == "" {
+		return nil
+	}
+
+	if !remote.IsRemoteReference(r.Source) {
+		return fmt.Errorf("source must be an https:// URL or oci:// reference, got %q", r.Source)
+	}
+	if r.SourceDigest != "" {
+		if err := remote.ValidateDigest(r.SourceDigest); err != nil {
+			return err
+		}
+	}
+	if r.Digest != "" {
+		return validation.ValidateTag(r.Digest)
+	}
+	return nil
+}
+
+// CallRequest represents a request to call an entrypoint of a loaded function.
+type CallRequest struct {
+	FunctionRequest
+	Entrypoint string `json:"entrypoint" validate:"required"`
+	Payload    string `json:"payload,omitempty"`
 }
 
+// Parts of a call request sent as multipart/form-data, which carries the payload as
+// raw bytes instead of a JSON string
+const (
+	CallRequestPart = "request"
+	CallPayloadPart = "payload"
+)
+
 // OneOffCallRequest represents a request to call a function once.
 type OneOffCallRequest struct {
 	FunctionRequest
@@ -61,6 +193,43 @@ type OneOffCallRequest struct {
 	Config     map[string]string `json:"config,omitempty"`
 }
 
+// ScaleRequest sets the number of instances of a function, addressed either by
+// namespace and name or by compose service name. Zero instances restores autoscaling.
+type ScaleRequest struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Service   string `json:"service,omitempty"`
+	Instances int    `json:"instances" validate:"min=0"`
+}
+
+// Validate checks that exactly one way of addressing the function is used.
+func (r ScaleRequest) Validate() error {
+	if r.Service != "" {
+		if r.Namespace != "" || r.Name != "" {
+			return fmt.Errorf("set either service or namespace and name, not both")
+		}
+		return nil
+	}
+	return validation.ValidateFunction(r.Namespace, r.Name)
+}
+
+// CallLoggingRequest sets which calls of a running function write entries to its logs,
+// addressed either by namespace and name or by compose service name.
+type CallLoggingRequest struct {
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Service     string `json:"service,omitempty"`
+	CallLogging string `json:"call_logging"`
+}
+
+// Validate checks the call logging and that exactly one way of addressing the function is used.
+func (r CallLoggingRequest) Validate() error {
+	if _, err := ParseCallLogging(r.CallLogging); err != nil {
+		return err
+	}
+	return ScaleRequest{Namespace: r.Namespace, Name: r.Name, Service: r.Service}.Validate()
+}
+
 // ReassignTagRequest represents a request to reassign a tag..
 type ReassignTagRequest struct {
 	FunctionRequest
@@ -68,6 +237,88 @@ type ReassignTagRequest struct {
 	Digest string `json:"digest" validate:"required"`
 }
 
+// Validate checks the function identifier and tag against the naming rules.
+func (r ReassignTagRequest) Validate() error {
+	if err := r.FunctionRequest.Validate(); err != nil {
+		return err
+	}
+	return validation.ValidateTag(r.Tag)
+}
+
+// PushRequest stores a prebuilt module version in the registry as is, as sent by
+// registry replication.
+type PushRequest struct {
+	FunctionRequest
+	Digest   string                           `json:"digest" validate:"required"`
+	Tag      string                           `json:"tag,omitempty"`
+	Settings manifest.FunctionVersionSettings `json:"settings"`
+	Payload  []byte                           `json:"payload" validate:"required"`
+}
+
+// Validate checks the function identifier and optional tag against the naming rules.
+func (r PushRequest) Validate() error {
+	if err := r.FunctionRequest.Validate(); err != nil {
+		return err
+	}
+	if r.Tag != "" {
+		return validation.ValidateTag(r.Tag)
+	}
+	return nil
+}
+
+// PullRequest fetches a stored version, as sent by engines using this one as their
+// upstream registry.
+type PullRequest struct {
+	FunctionRequest
+	Reference string `json:"reference" validate:"required"`
+}
+
+// AddVariantRequest stores a variant of the version a reference resolves to.
+type AddVariantRequest struct {
+	FunctionRequest
+	Reference string `json:"reference" validate:"required"`
+	Variant   string `json:"variant" validate:"required"`
+	Payload   []byte `json:"payload" validate:"required"`
+}
+
+// Validate checks the function identifier and the variant name.
+func (r AddVariantRequest) Validate() error {
+	if err := r.FunctionRequest.Validate(); err != nil {
+		return err
+	}
+	return registry.ValidateVariantName(r.Variant)
+}
+
+// SBOMRequest asks for the SBOM of the version a reference resolves to.
+type SBOMRequest struct {
+	FunctionRequest
+	Reference string `json:"reference" validate:"required"`
+}
+
+// PullResponse holds a pulled version and its module.
+type PullResponse struct {
+	Version registry.VersionInfo `json:"version"`
+	Payload []byte               `json:"payload"`
+}
+
+// RegistrySyncRequest asks the engine to copy its registry to a replication target.
+type RegistrySyncRequest struct {
+	// Directory of a secondary registry, or URL of the admin API of a remote engine;
+	// empty syncs the configured replication target
+	Target string `json:"target,omitempty"`
+
+	// Bearer token of the remote admin API
+	Token string `json:"token,omitempty"`
+}
+
+// RegistrySyncReport describes what a registry sync copied to its target.
+type RegistrySyncReport struct {
+	Target         string   `json:"target"`
+	VersionsCopied int      `json:"versions_copied"`
+	TagsUpdated    int      `json:"tags_updated"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
 // ListResponse represents the response from a list operation.
 type ListResponse struct {
 	Functions []FunctionInfo `json:"functions"`
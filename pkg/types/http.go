@@ -0,0 +1,168 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPResponse is the envelope returned by functions whose version enables
+// http_envelope. The public HTTP endpoint writes it out as a regular response.
+type HTTPResponse struct {
+	// Status code to respond with (defaults to 200)
+	Status int `json:"status,omitempty"`
+
+	// Response headers, e.g. Content-Type or Location
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Response body
+	Body string `json:"body,omitempty"`
+
+	// Set when Body is base64 encoded, for binary responses
+	IsBase64 bool `json:"is_base64,omitempty"`
+
+	// How long the response may be reused, sent as its Cache-Control header
+	Cache *CacheDirectives `json:"cache,omitempty"`
+}
+
+// CacheDirectives tell the engine's response cache and downstream caches, such as
+// CDNs, how long a response may be reused.
+type CacheDirectives struct {
+	// Seconds any cache, browsers included, may reuse the response (max-age)
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+
+	// Seconds shared caches may reuse the response instead, when set (s-maxage)
+	SharedTTLSeconds int `json:"shared_ttl_seconds,omitempty"`
+
+	// Seconds a stale response may still be served while it is refreshed
+	StaleWhileRevalidateSeconds int `json:"stale_while_revalidate_seconds,omitempty"`
+
+	// Only the caller's own cache may keep the response, not shared caches
+	Private bool `json:"private,omitempty"`
+
+	// No cache may keep the response
+	NoStore bool `json:"no_store,omitempty"`
+}
+
+// Validate checks that the durations are not negative and that no_store is not
+// combined with other directives.
+func (c CacheDirectives) Validate() error {
+	if c.TTLSeconds < 0 || c.SharedTTLSeconds < 0 || c.StaleWhileRevalidateSeconds < 0 {
+		return fmt.Errorf("cache durations cannot be negative")
+	}
+	if c.NoStore && c != (CacheDirectives{NoStore: true}) {
+		return fmt.Errorf("no_store cannot be combined with other cache directives")
+	}
+	return nil
+}
+
+// CacheControl returns the directives as the value of a Cache-Control header.
+func (c CacheDirectives) CacheControl() string {
+	if c.NoStore {
+		return "no-store"
+	}
+
+	directives := []string{"public"}
+	if c.Private {
+		directives[0] = "private"
+	}
+	directives = append(directives, "max-age="+strconv.Itoa(c.TTLSeconds))
+	if c.SharedTTLSeconds > 0 && !c.Private {
+		directives = append(directives, "s-maxage="+strconv.Itoa(c.SharedTTLSeconds))
+	}
+	if c.StaleWhileRevalidateSeconds > 0 {
+		directives = append(directives, "stale-while-revalidate="+strconv.Itoa(c.StaleWhileRevalidateSeconds))
+	}
+	return strings.Join(directives, ", ")
+}
+
+// SharedTTL returns how long a shared cache, such as the engine's, may keep the
+// response: zero for private responses and those that must not be stored.
+func (c CacheDirectives) SharedTTL() time.Duration {
+	switch {
+	case c.NoStore || c.Private:
+		return 0
+	case c.SharedTTLSeconds > 0:
+		return time.Duration(c.SharedTTLSeconds) * time.Second
+	default:
+		return time.Duration(c.TTLSeconds) * time.Second
+	}
+}
+
+// HTTPRequest is the envelope passed as input to functions whose version enables
+// http_request, in place of the call payload.
+type HTTPRequest struct {
+	// Request method, e.g. GET or POST
+	Method string `json:"method"`
+
+	// Path below the entrypoint, such as "/" or "/users/42"
+	Path string `json:"path"`
+
+	// First value of each query parameter
+	Query map[string]string `json:"query,omitempty"`
+
+	// Request headers, multiple values joined with ", "
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Request body
+	Body string `json:"body,omitempty"`
+
+	// Set when Body is base64 encoded, because the request body is not valid UTF-8
+	IsBase64 bool `json:"is_base64,omitempty"`
+}
+
+// CallEnvelope wraps the output of a call made on the public HTTP endpoint with
+// execution metadata, for callers that ask for it with the envelope query parameter
+// or the CallEnvelopeProfile media type profile.
+type CallEnvelope struct {
+	// Function output, embedded as is when it is JSON and as a string otherwise
+	Output json.RawMessage `json:"output"`
+
+	// Set when Output is a base64 encoded string, because the output is not valid UTF-8
+	IsBase64 bool `json:"is_base64,omitempty"`
+
+	// Time the engine spent on the call, in milliseconds
+	ExecutionTimeMs float64 `json:"execution_time_ms"`
+
+	// Function that served the call, as namespace/name@digest
+	Function string `json:"function"`
+
+	// Request ID of the call, taken from the X-Request-Id header or generated
+	RequestID string `json:"request_id"`
+}
+
+// CallEnvelopeProfile selects the call envelope in an Accept header, as in
+// application/json; profile="ignition-envelope"
+const CallEnvelopeProfile = "ignition-envelope"
+
+// Headers used to coordinate call deadlines and priorities between callers and the engine
+const (
+	// TimeoutHeader sets the deadline of a single call, as a Go duration ("250ms") or milliseconds
+	TimeoutHeader = "X-Ignition-Timeout"
+
+	// PriorityHeader sets the queue priority of a single call: high, normal or low
+	PriorityHeader = "X-Ignition-Priority"
+
+	// ExecutionTimeHeader reports how long the engine spent on a call, in milliseconds
+	ExecutionTimeHeader = "X-Ignition-Execution-Time"
+
+	// StreamHeader asks for the chunks a function emits to be streamed as they come:
+	// "chunked" for the raw bytes, "sse" for server-sent events
+	StreamHeader = "X-Ignition-Stream"
+
+	// RequestIDHeader identifies a call; the engine generates an ID when a caller asking
+	// for the call envelope does not send one
+	RequestIDHeader = "X-Request-Id"
+
+	// StreamErrorTrailer carries the error of a chunked stream that failed after it started
+	StreamErrorTrailer = "X-Ignition-Error"
+
+	// IdempotencyKeyHeader names a load or build request, so that a retry with the same key
+	// gets the response of the first request instead of doing the work again
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set to "true" on responses replayed for an idempotency key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
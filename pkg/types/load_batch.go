@@ -0,0 +1,126 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Outcomes of the loads of a batch
+const (
+	LoadBatchLoaded  = "loaded"
+	LoadBatchFailed  = "failed"
+	LoadBatchSkipped = "skipped"
+)
+
+// MaxLoadBatchConcurrency bounds how many loads of a batch run at once
+const MaxLoadBatchConcurrency = 32
+
+// LoadBatchItem is one function of a batch load.
+type LoadBatchItem struct {
+	LoadRequest
+
+	// Services of the batch that must load before this one; if one fails, this
+	// load is skipped
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// Instances to keep after the load, 0 to restore autoscaling; nil leaves
+	// the scaling of the function as it is
+	Scale *int `json:"scale,omitempty"`
+}
+
+// LoadBatchRequest loads several functions, concurrently where their dependencies allow.
+type LoadBatchRequest struct {
+	Loads []LoadBatchItem `json:"loads" validate:"required,min=1,dive"`
+
+	// Loads running at once; zero uses the engine default
+	Concurrency int `json:"concurrency,omitempty" validate:"min=0"`
+}
+
+// Validate checks every load, that dependencies name services of the batch and that
+// they do not form a cycle.
+func (r LoadBatchRequest) Validate() error {
+	if r.Concurrency > MaxLoadBatchConcurrency {
+		return fmt.Errorf("concurrency must be at most %d", MaxLoadBatchConcurrency)
+	}
+
+	services := make(map[string]int, len(r.Loads))
+	for i, load := range r.Loads {
+		if err := load.Validate(); err != nil {
+			return fmt.Errorf("load %d: %w", i+1, err)
+		}
+		if load.Scale != nil && *load.Scale < 0 {
+			return fmt.Errorf("load %d: scale must not be negative", i+1)
+		}
+		if load.Service == "" {
+			continue
+		}
+		if _, ok := services[load.Service]; ok {
+			return fmt.Errorf("service %q is loaded twice", load.Service)
+		}
+		services[load.Service] = i
+	}
+
+	for i, load := range r.Loads {
+		for _, dep := range load.DependsOn {
+			if _, ok := services[dep]; !ok {
+				return fmt.Errorf("load %d depends on %q, which is not a service of the batch", i+1, dep)
+			}
+		}
+	}
+	return r.checkCycles(services)
+}
+
+// checkCycles reports the first dependency cycle between the loads.
+func (r LoadBatchRequest) checkCycles(services map[string]int) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(r.Loads))
+	var path []string
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, r.Loads[i].Service), " -> "))
+		}
+		state[i] = visiting
+		path = append(path, r.Loads[i].Service)
+		for _, dep := range r.Loads[i].DependsOn {
+			if err := visit(services[dep]); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range r.Loads {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadBatchResult is the outcome of one load of a batch.
+type LoadBatchResult struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Service   string `json:"service,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// LoadBatchResponse holds the outcome of every load of a batch, in request order.
+type LoadBatchResponse struct {
+	Loaded  int               `json:"loaded"`
+	Failed  int               `json:"failed"`
+	Skipped int               `json:"skipped"`
+	Results []LoadBatchResult `json:"results"`
+}
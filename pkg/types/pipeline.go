@@ -0,0 +1,78 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ignitionstack/ignition/pkg/validation"
+)
+
+// Pipeline error policies
+const (
+	// PipelineAbort stops the pipeline at the failing step (the default)
+	PipelineAbort = "abort"
+
+	// PipelineContinue skips the failing step and feeds its input to the next step
+	PipelineContinue = "continue"
+)
+
+// PipelineStep is a single entrypoint call in a pipeline. The target is either a
+// compose service or a namespace/name pair.
+type PipelineStep struct {
+	Service    string `json:"service,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Entrypoint string `json:"entrypoint" validate:"required"`
+	TimeoutMs  int64  `json:"timeout_ms,omitempty"`
+	OnError    string `json:"on_error,omitempty"`
+}
+
+// RegisterPipelineRequest represents a request to register a pipeline.
+type RegisterPipelineRequest struct {
+	Name  string         `json:"name" validate:"required"`
+	Steps []PipelineStep `json:"steps" validate:"required,min=1,dive"`
+}
+
+// Validate checks the pipeline name and every step.
+func (r RegisterPipelineRequest) Validate() error {
+	if err := validation.ValidatePipelineName(r.Name); err != nil {
+		return err
+	}
+	if len(r.Steps) == 0 {
+		return errors.New("pipeline must have at least one step")
+	}
+
+	for i, step := range r.Steps {
+		if err := step.validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s PipelineStep) validate() error {
+	switch {
+	case s.Service != "" && (s.Namespace != "" || s.Name != ""):
+		return errors.New("set either service or namespace and name, not both")
+	case s.Service == "":
+		if err := validation.ValidateFunction(s.Namespace, s.Name); err != nil {
+			return err
+		}
+	}
+
+	if s.Entrypoint == "" {
+		return errors.New("entrypoint is required")
+	}
+	if s.TimeoutMs < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	if s.OnError != "" && s.OnError != PipelineAbort && s.OnError != PipelineContinue {
+		return fmt.Errorf("unknown error policy %q (expected %s or %s)", s.OnError, PipelineAbort, PipelineContinue)
+	}
+	return nil
+}
+
+// UnregisterPipelineRequest represents a request to remove a pipeline.
+type UnregisterPipelineRequest struct {
+	Name string `json:"name" validate:"required"`
+}
@@ -0,0 +1,72 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ignitionstack/ignition/pkg/registry"
+)
+
+// Reload policy kinds.
+const (
+	ReloadLatest = "latest" // the "latest" tag, or the newest version without one
+	ReloadPinned = "pinned" // the digest that was loaded
+	ReloadTag    = "tag"    // whatever version a tag points at
+	ReloadSemver = "semver" // the highest tag matching a semantic version range
+)
+
+// ReloadPolicy decides which version the engine loads when it reloads a function
+// on its own, such as on the first call after the function was evicted for being idle.
+type ReloadPolicy struct {
+	Kind  string
+	Tag   string // for ReloadTag
+	Range string // for ReloadSemver
+
+	versionRange registry.VersionRange
+}
+
+// ParseReloadPolicy parses "latest" (or an empty string), "pinned", "tag:<tag>" or a
+// semantic version range such as "~1.2", "^1.4.0", "1.x" or ">=1.2.0 <2.0.0",
+// optionally written as "semver:<range>".
+func ParseReloadPolicy(value string) (ReloadPolicy, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "" || value == ReloadLatest:
+		return ReloadPolicy{Kind: ReloadLatest}, nil
+	case value == ReloadPinned:
+		return ReloadPolicy{Kind: ReloadPinned}, nil
+	case strings.HasPrefix(value, ReloadTag+":"):
+		tag := strings.TrimPrefix(value, ReloadTag+":")
+		if tag == "" {
+			return ReloadPolicy{}, fmt.Errorf("reload policy %q has no tag", value)
+		}
+		return ReloadPolicy{Kind: ReloadTag, Tag: tag}, nil
+	}
+
+	expr := strings.TrimPrefix(value, ReloadSemver+":")
+	versionRange, err := registry.ParseVersionRange(expr)
+	if err != nil {
+		return ReloadPolicy{}, fmt.Errorf("invalid reload policy %q (expected latest, pinned, tag:<tag> or a semver range): %w", value, err)
+	}
+	return ReloadPolicy{Kind: ReloadSemver, Range: versionRange.String(), versionRange: versionRange}, nil
+}
+
+// String returns the policy in the form ParseReloadPolicy accepts.
+func (p ReloadPolicy) String() string {
+	switch p.Kind {
+	case ReloadTag:
+		return ReloadTag + ":" + p.Tag
+	case ReloadSemver:
+		return ReloadSemver + ":" + p.Range
+	case "":
+		return ReloadLatest
+	default:
+		return p.Kind
+	}
+}
+
+// HighestMatch returns the tag with the highest version that satisfies the semver
+// range. Tags that are not semantic versions, and pre-releases, are skipped.
+func (p ReloadPolicy) HighestMatch(tags []string) (string, bool) {
+	return p.versionRange.HighestMatch(tags)
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// SnapshotVersion is the format version written into engine snapshots
+const SnapshotVersion = 1
+
+// Function states recorded in a snapshot
+const (
+	SnapshotRunning  = "running"
+	SnapshotUnloaded = "unloaded"
+	SnapshotStopped  = "stopped"
+)
+
+// EngineSnapshot captures the runtime state of an engine so it can be restored on start.
+type EngineSnapshot struct {
+	Version   int                       `json:"version"`
+	CreatedAt time.Time                 `json:"created_at"`
+	Functions []SnapshotFunction        `json:"functions"`
+	Services  map[string]SnapshotTarget `json:"services,omitempty"`
+	Pipelines map[string][]PipelineStep `json:"pipelines,omitempty"`
+
+	// Redacted snapshots have the values of secret config keys masked, so they can be
+	// shared but not restored
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// SnapshotFunction is the recorded state of one function.
+type SnapshotFunction struct {
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Digest    string            `json:"digest,omitempty"`
+	Config    map[string]string `json:"config,omitempty"`
+	Status    string            `json:"status"`
+	Instances int               `json:"instances,omitempty"`
+
+	// Reload policy of the function; empty means latest
+	ReloadPolicy string `json:"reload_policy,omitempty"`
+
+	// Queue priority of the function's calls; empty means normal
+	Priority string `json:"priority,omitempty"`
+
+	// Max queue wait of the function's calls; zero means the engine default
+	MaxQueueWaitMs int64 `json:"max_queue_wait_ms,omitempty"`
+
+	// Calls that write entries to the function's logs; empty means all
+	CallLogging string `json:"call_logging,omitempty"`
+}
+
+// SnapshotTarget is the function a service name points at.
+type SnapshotTarget struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Validate checks that the snapshot can be restored by this engine.
+func (s EngineSnapshot) Validate() error {
+	if s.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", s.Version)
+	}
+	if s.Redacted {
+		return fmt.Errorf("snapshot has redacted config values and cannot be restored")
+	}
+
+	for _, fn := range s.Functions {
+		switch fn.Status {
+		case SnapshotRunning, SnapshotUnloaded:
+			if fn.Digest == "" {
+				return fmt.Errorf("function %s/%s has no digest", fn.Namespace, fn.Name)
+			}
+		case SnapshotStopped:
+		default:
+			return fmt.Errorf("function %s/%s has unknown status %q", fn.Namespace, fn.Name, fn.Status)
+		}
+		if _, err := ParseReloadPolicy(fn.ReloadPolicy); err != nil {
+			return fmt.Errorf("function %s/%s: %w", fn.Namespace, fn.Name, err)
+		}
+		if _, err := ParseCallLogging(fn.CallLogging); err != nil {
+			return fmt.Errorf("function %s/%s: %w", fn.Namespace, fn.Name, err)
+		}
+		switch fn.Priority {
+		case "", "high", "normal", "low":
+		default:
+			return fmt.Errorf("function %s/%s has unknown priority %q", fn.Namespace, fn.Name, fn.Priority)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// Package validation holds the naming rules shared by the CLI, the engine
+// handlers and the registry.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+const (
+	// MaxNameLength is the maximum length of a namespace or function name
+	MaxNameLength = 63
+
+	// MaxTagLength is the maximum length of a tag
+	MaxTagLength = 128
+)
+
+var (
+	// ErrInvalidName is returned when a namespace, function name or tag breaks the naming rules
+	ErrInvalidName = errors.New("invalid name")
+
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	tagPattern  = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+)
+
+// ValidateNamespace checks that a namespace is safe to use in URLs and storage paths
+func ValidateNamespace(namespace string) error {
+	return validateName("namespace", namespace)
+}
+
+// ValidateName checks that a function name is safe to use in URLs and storage paths
+func ValidateName(name string) error {
+	return validateName("function name", name)
+}
+
+// ValidateFunction checks both the namespace and the name of a function
+func ValidateFunction(namespace, name string) error {
+	if err := ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	return ValidateName(name)
+}
+
+// ValidateTag checks that a tag is well formed
+func ValidateTag(tag string) error {
+	if tag == "" || len(tag) > MaxTagLength || !tagPattern.MatchString(tag) {
+		return fmt.Errorf("%w: tag %q must be 1-%d characters of letters, digits, '.', '_' or '-', and must not start with '.' or '-'",
+			ErrInvalidName, tag, MaxTagLength)
+	}
+	return nil
+}
+
+func validateName(kind, value string) error {
+	if value == "" || len(value) > MaxNameLength || !namePattern.MatchString(value) {
+		return fmt.Errorf("%w: %s %q must be 1-%d characters of letters, digits, '.', '_' or '-', starting with a letter or digit",
+			ErrInvalidName, kind, value, MaxNameLength)
+	}
+	return nil
+}
+
+// ValidateBuildID checks that a build ID is safe to use in URLs
+func ValidateBuildID(id string) error {
+	return validateName("build ID", id)
+}
+
+// ValidatePipelineName checks that a pipeline name is safe to use in URLs
+func ValidatePipelineName(name string) error {
+	return validateName("pipeline name", name)
+}
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFunction(t *testing.T) {
+	valid := [][2]string{
+		{"test", "func1"},
+		{"my-team", "hello_world"},
+		{"ns.v2", "Fn-1"},
+	}
+	for _, v := range valid {
+		assert.NoError(t, ValidateFunction(v[0], v[1]), "%s/%s", v[0], v[1])
+	}
+
+	invalid := [][2]string{
+		{"", "fn"},
+		{"ns", ""},
+		{"a/b", "fn"},
+		{"ns", "fn:latest"},
+		{"ns", "hello world"},
+		{"..", "fn"},
+		{"-ns", "fn"},
+		{"ns", "fn%2F"},
+		{strings.Repeat("a", MaxNameLength+1), "fn"},
+	}
+	for _, v := range invalid {
+		assert.ErrorIs(t, ValidateFunction(v[0], v[1]), ErrInvalidName, "%s/%s", v[0], v[1])
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	for _, tag := range []string{"latest", "v1.2.3", "_dev", "0123456789ab"} {
+		assert.NoError(t, ValidateTag(tag), tag)
+	}
+	for _, tag := range []string{"", ".hidden", "-x", "a/b", "a:b", strings.Repeat("t", MaxTagLength+1)} {
+		assert.ErrorIs(t, ValidateTag(tag), ErrInvalidName, tag)
+	}
+}